@@ -35,13 +35,19 @@ func main() {
 		cmd.Setup,
 		cmd.Teardown,
 		cmd.NewBuildCommand(appName, action.Build),
+		cmd.NewFetchArtifactsCommand(appName, action.FetchArtifacts),
 		cmd.NewCustomizeCommand(appName, action.Customize),
 		cmd.NewInitCommand(appName, action.Init),
 		cmd.NewVersionCommand(appName),
 		cmd.NewReleaseInfoCommand(appName, action.ReleaseInfo),
+		cmd.NewManifestCommand(appName, action.ManifestActions),
+		cmd.NewDependencyGraphCommand(appName, action.DependencyGraph),
+		cmd.NewDoctorCommand(appName, action.Doctor),
+		cmd.NewDisksCommand(appName, action.Disks),
+		cmd.NewServeCommand(appName, action.Serve),
 	)
 
 	if err := application.Run(context.Background(), os.Args); err != nil {
-		log.Fatal(err)
+		log.Fatal(cmd.RedactError(application, err))
 	}
 }
@@ -34,15 +34,29 @@ func main() {
 		cmd.GlobalFlags(),
 		cmd.Setup,
 		cmd.Teardown,
-		cmd.NewInstallCommand(appName, action.Install),
-		cmd.NewUpgradeCommand(appName, action.Upgrade),
+		cmd.WithProxy(cmd.NewInstallCommand(appName, action.Install)),
+		cmd.WithProxy(cmd.NewUpgradeCommand(appName, action.Upgrade)),
+		cmd.WithProxy(cmd.NewCheckUpgradeCommand(appName, action.CheckUpgrade)),
 		cmd.NewKernelModulesCommand(appName, action.ManageKernelModules),
-		cmd.NewUnpackImageCommand(appName, action.Unpack),
-		cmd.NewBuildInstallerCommand(appName, action.BuildInstaller),
-		cmd.NewResetCommand(appName, action.Reset),
+		cmd.WithProxy(cmd.NewUnpackImageCommand(appName, action.Unpack)),
+		cmd.WithProxy(cmd.NewBuildInstallerCommand(appName, action.BuildInstaller)),
+		cmd.WithProxy(cmd.NewResetCommand(appName, action.Reset)),
+		cmd.NewRestoreCommand(appName, action.Restore),
+		cmd.NewRestorePartitionsCommand(appName, action.RestorePartitions),
+		cmd.NewMigrateDataCommand(appName, action.MigrateData),
+		cmd.NewExpandPartitionsCommand(appName, action.ExpandPartitions),
+		cmd.NewExportCommand(appName, action.Export),
+		cmd.NewCloneCommand(appName, action.Clone),
+		cmd.WithProxy(cmd.NewApplyOverlayCommand(appName, action.ApplyOverlay)),
+		cmd.NewTakeoverCommand(appName, action.Takeover),
+		cmd.NewFirmwareCommand(appName, action.FirmwareActions),
+		cmd.NewSnapshotCommand(appName, action.SnapshotActions),
+		cmd.NewConfextCommand(appName, action.ConfextActions),
+		cmd.NewVerifyCommand(appName, action.Verify),
+		cmd.NewDisksCommand(appName, action.Disks),
 		cmd.NewVersionCommand(appName))
 
 	if err := application.Run(context.Background(), os.Args); err != nil {
-		log.Fatal(err)
+		log.Fatal(cmd.RedactError(application, err))
 	}
 }
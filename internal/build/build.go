@@ -21,23 +21,33 @@ package build
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/suse/elemental/v3/internal/config"
+	"github.com/suse/elemental/v3/internal/customize"
 	"github.com/suse/elemental/v3/internal/image"
 	imginstall "github.com/suse/elemental/v3/internal/image/install"
 	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/cache"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/filesystem"
 	"github.com/suse/elemental/v3/pkg/fips"
 	"github.com/suse/elemental/v3/pkg/firmware"
 	"github.com/suse/elemental/v3/pkg/install"
+	"github.com/suse/elemental/v3/pkg/installer"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/registry"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
 	"github.com/suse/elemental/v3/pkg/upgrade"
 )
 
+// squashfsTreeDir is the build directory where the root tree of squashfs images is prepared
+const squashfsTreeDir = "squashfs-root"
+
 type configManager interface {
 	ConfigureComponents(ctx context.Context, conf *image.Configuration, output config.Output) (*resolver.ResolvedManifest, error)
 }
@@ -46,11 +56,14 @@ type Builder struct {
 	System        *sys.System
 	ConfigManager configManager
 	Local         bool
+	Registry      *registry.Config
+	// Cache, if offline, provides the unpacked OS image instead of the registry
+	Cache *cache.Cache
 }
 
 func (b *Builder) Run(ctx context.Context, d *image.Definition, output config.Output) error {
 	logger := b.System.Logger()
-	runner := b.System.Runner()
+	raw := &d.Configuration.Installation.RAW
 
 	logger.Info("Configuring image components")
 	rm, err := b.ConfigManager.ConfigureComponents(ctx, d.Configuration, output)
@@ -59,23 +72,59 @@ func (b *Builder) Run(ctx context.Context, d *image.Definition, output config.Ou
 		return err
 	}
 
-	logger.Info("Creating RAW disk image")
-	if err = createDisk(runner, d.Image, d.Configuration.Installation.RAW.DiskSize); err != nil {
-		logger.Error("Creating RAW disk image failed")
+	if err = d.Image.RenderOutputName(rm, time.Now()); err != nil {
+		logger.Error("Rendering output image name failed")
 		return err
 	}
 
-	logger.Info("Attaching loop device to RAW disk image")
-	device, err := attachDevice(runner, d.Image)
+	osImage, err := b.osSource(ctx, rm.CorePlatform.Components.OperatingSystem.Image.Base, d)
 	if err != nil {
-		logger.Error("Attaching loop device failed")
+		logger.Error("Resolving OS image failed")
 		return err
 	}
-	defer func() {
-		if dErr := detachDevice(runner, device); dErr != nil {
-			logger.Error("Detaching loop device failed: %v", dErr)
+
+	switch d.Image.ImageType {
+	case image.TypeISO:
+		return b.buildISO(ctx, osImage, d, output)
+	case image.TypeSquashfs:
+		return b.buildSquashfs(ctx, osImage, d, output)
+	}
+
+	diskImage := d.Image.OutputImageName
+	if !raw.Format.IsRAW() {
+		diskImage = filepath.Join(output.RootPath, "disk.raw")
+	}
+
+	if err = b.installDisk(ctx, diskImage, osImage, d, output); err != nil {
+		return err
+	}
+
+	if !raw.Format.IsRAW() {
+		logger.Info("Converting RAW disk image to %s", raw.Format)
+		if err = convertDisk(b.System.Runner(), diskImage, d.Image.OutputImageName, raw.Format); err != nil {
+			logger.Error("Converting RAW disk image failed")
+			return err
 		}
-	}()
+	}
+
+	return nil
+}
+
+// installDisk creates a RAW disk image at the given path and installs the given OS image into it
+func (b *Builder) installDisk(ctx context.Context, diskImage string, osImage *deployment.ImageSource, d *image.Definition, output config.Output) error {
+	logger := b.System.Logger()
+
+	raw := d.Configuration.Installation.RAW
+
+	var diskSize deployment.MiB
+	var err error
+	if !raw.DiskSize.IsAuto() {
+		diskSize, err = rawDiskSize(raw.DiskSize)
+		if err != nil {
+			logger.Error("Parsing RAW disk size failed")
+			return err
+		}
+	}
 
 	err = vfs.MkdirAll(b.System.FS(), output.OverlaysDir(), vfs.DirPerm)
 	if err != nil {
@@ -86,8 +135,8 @@ func (b *Builder) Run(ctx context.Context, d *image.Definition, output config.Ou
 	logger.Info("Preparing installation setup")
 	dep, err := newDeployment(
 		b.System,
-		device,
-		rm.CorePlatform.Components.OperatingSystem.Image.Base,
+		diskImage,
+		osImage,
 		&d.Configuration.Installation,
 		output,
 	)
@@ -95,22 +144,39 @@ func (b *Builder) Run(ctx context.Context, d *image.Definition, output config.Ou
 		logger.Error("Preparing installation setup failed")
 		return err
 	}
+	if raw.DiskSize.IsAuto() {
+		diskSize, err = b.estimateDiskSize(ctx, dep, osImage, output, raw.Slack())
+		if err != nil {
+			logger.Error("Computing RAW disk size failed")
+			return err
+		}
+		logger.Info("Computed RAW disk size: %dMiB", diskSize)
+	}
+	dep.Disks[0].Size = diskSize
+	dep.Disks[0].ExpandPartitions = raw.ExpandPartitions
+	dep.Disks[0].RepartDefinitions = raw.RepartDefinitions
 
-	boot, err := bootloader.New(dep.BootConfig.Bootloader, b.System)
+	if err = dep.Sanitize(b.System); err != nil {
+		logger.Error("Preparing installation setup failed")
+		return fmt.Errorf("sanitizing deployment: %w", err)
+	}
+
+	boot, err := bootloader.New(ctx, dep.BootConfig.Bootloader, b.System)
 	if err != nil {
 		logger.Error("Parsing boot config failed")
 		return err
 	}
 
-	unpackOpts := unpack.WithLocal(b.Local)
+	unpackOpts := []unpack.Opt{unpack.WithLocal(b.Local), unpack.WithRegistryConfig(b.Registry)}
 	manager := firmware.NewEfiBootManager(b.System)
 	upgrader := upgrade.New(
 		ctx, b.System, upgrade.WithBootManager(manager), upgrade.WithBootloader(boot),
-		upgrade.WithUnpackOpts(unpackOpts),
+		upgrade.WithRegistryConfig(b.Registry), upgrade.WithLocalImage(b.Local),
+		upgrade.WithUnpackOpts(unpackOpts...),
 	)
 	installer := install.New(
 		ctx, b.System, install.WithUpgrader(upgrader),
-		install.WithUnpackOpts(unpackOpts),
+		install.WithUnpackOpts(unpackOpts...),
 	)
 
 	logger.Info("Installing OS")
@@ -126,7 +192,8 @@ func (b *Builder) Run(ctx context.Context, d *image.Definition, output config.Ou
 
 func newDeployment(
 	system *sys.System,
-	installationDevice, osImage string,
+	installationDevice string,
+	osImage *deployment.ImageSource,
 	installation *imginstall.Installation,
 	output config.Output,
 	customPartitions ...*deployment.Partition,
@@ -150,17 +217,13 @@ func newDeployment(
 	d.BootConfig.Bootloader = installation.Bootloader
 	d.BootConfig.KernelCmdline = installation.KernelCmdLine
 	d.Security.CryptoPolicy = installation.CryptoPolicy
+	d.Compression = installation.Compression
 
 	if d.IsFipsEnabled() {
 		d.BootConfig.KernelCmdline = fips.AppendCommandLine(d.BootConfig.KernelCmdline)
 	}
 
-	osURI := fmt.Sprintf("%s://%s", deployment.OCI, osImage)
-	osSource, err := deployment.NewSrcFromURI(osURI)
-	if err != nil {
-		return nil, fmt.Errorf("parsing OS source URI %q: %w", osURI, err)
-	}
-	d.SourceOS = osSource
+	d.SourceOS = osImage
 
 	overlaysURI := fmt.Sprintf("%s://%s", deployment.Dir, output.OverlaysDir())
 	overlaySource, err := deployment.NewSrcFromURI(overlaysURI)
@@ -169,37 +232,222 @@ func newDeployment(
 	}
 	d.OverlayTree = overlaySource
 
-	if err = d.Sanitize(system); err != nil {
-		return nil, fmt.Errorf("sanitizing deployment: %w", err)
+	return d, nil
+}
+
+// buildISO creates a self-installing live ISO which installs the given OS image to the
+// configured ISO device on boot
+func (b *Builder) buildISO(ctx context.Context, osImage *deployment.ImageSource, d *image.Definition, output config.Output) error {
+	logger := b.System.Logger()
+	installation := &d.Configuration.Installation
+
+	if installation.ISO.Device == "" {
+		return fmt.Errorf("missing device configuration for ISO image type")
 	}
 
-	return d, nil
+	err := vfs.MkdirAll(b.System.FS(), output.OverlaysDir(), vfs.DirPerm)
+	if err != nil {
+		logger.Error("Failed creating overlay dir")
+		return err
+	}
+
+	logger.Info("Preparing installation setup")
+	dep, err := newDeployment(b.System, installation.ISO.Device, osImage, installation, output)
+	if err != nil {
+		logger.Error("Preparing installation setup failed")
+		return err
+	}
+
+	dep.Installer.CfgScript, err = customize.WriteAutoInstaller(b.System.FS(), output.RootPath, installer.ISO)
+	if err != nil {
+		logger.Error("Writing auto-installer script failed")
+		return err
+	}
+
+	if err = dep.Sanitize(b.System, deployment.CheckDiskDevice); err != nil {
+		logger.Error("Preparing installation setup failed")
+		return fmt.Errorf("sanitizing deployment: %w", err)
+	}
+
+	media := installer.NewMedia(
+		ctx, b.System, installer.ISO,
+		installer.WithOutputFile(d.Image.OutputImageName),
+		installer.WithUnpackOpts(unpack.WithLocal(b.Local), unpack.WithRegistryConfig(b.Registry)),
+	)
+	media.OutputDir = output.RootPath
+
+	logger.Info("Building installer ISO")
+	if err = media.Build(dep); err != nil {
+		logger.Error("Building installer ISO failed")
+		return err
+	}
+
+	logger.Info("Installer ISO build complete")
+
+	return nil
 }
 
-func createDisk(runner sys.Runner, img image.Image, diskSize imginstall.DiskSize) error {
+// buildSquashfs unpacks the given OS image and the overlays tree into a single root tree and
+// packs it as a squashfs image. The image can be used as the OS source of deployments.
+func (b *Builder) buildSquashfs(ctx context.Context, osImage *deployment.ImageSource, d *image.Definition, output config.Output) (err error) {
+	logger := b.System.Logger()
+
+	rootTree := filepath.Join(output.RootPath, squashfsTreeDir)
+	err = vfs.MkdirAll(b.System.FS(), rootTree, vfs.DirPerm)
+	if err != nil {
+		logger.Error("Failed creating squashfs root tree dir")
+		return err
+	}
+	defer func() {
+		e := vfs.ForceRemoveAll(b.System.FS(), rootTree)
+		if err == nil && e != nil {
+			err = e
+		}
+	}()
+
+	opts := []unpack.Opt{unpack.WithLocal(b.Local), unpack.WithRegistryConfig(b.Registry)}
+	if d.Image.Platform != nil {
+		opts = append(opts, unpack.WithPlatformRef(d.Image.Platform.String()))
+	}
+
+	logger.Info("Unpacking OS image")
+	unpacker, err := unpack.NewUnpacker(b.System, osImage, opts...)
+	if err != nil {
+		logger.Error("Unpacking OS image failed")
+		return err
+	}
+	_, err = unpacker.Unpack(ctx, rootTree)
+	if err != nil {
+		logger.Error("Unpacking OS image failed")
+		return err
+	}
+
+	logger.Info("Building squashfs image")
+	var compression *deployment.Compression
+	if d.Configuration.Installation.Compression != nil {
+		compression = d.Configuration.Installation.Compression.Images
+	}
+	if err = packSquashfs(ctx, b.System, rootTree, output.OverlaysDir(), d.Image.OutputImageName, compression); err != nil {
+		logger.Error("Building squashfs image failed")
+		return err
+	}
+
+	logger.Info("Squashfs image build complete")
+	return nil
+}
+
+// packSquashfs syncs the overlays tree, if any, on top of the given root tree and packs it as a squashfs image
+// with the given compression
+func packSquashfs(
+	ctx context.Context, s *sys.System, rootTree, overlaysDir, target string, compression *deployment.Compression,
+) error {
+	if ok, _ := vfs.Exists(s.FS(), overlaysDir); ok {
+		_, err := unpack.NewDirectoryUnpacker(s, overlaysDir).Unpack(ctx, rootTree)
+		if err != nil {
+			return fmt.Errorf("applying overlays: %w", err)
+		}
+	}
+
+	err := filesystem.CreateSquashFS(ctx, s, rootTree, target, filesystem.SquashfsCompressionOptions(compression))
+	if err != nil {
+		return fmt.Errorf("creating squashfs image: %w", err)
+	}
+	return nil
+}
+
+// osSource returns the source of the given OS image, the image of the local containers storage for
+// local builds or the tree stored in the cache for offline builds
+func (b *Builder) osSource(ctx context.Context, osImage string, d *image.Definition) (*deployment.ImageSource, error) {
+	if b.Local {
+		return deployment.NewContainersStorageSrc(osImage), nil
+	}
+	if b.Cache == nil || !b.Cache.Offline() {
+		return deployment.NewOCISrc(osImage), nil
+	}
+
+	var platform string
+	if d.Image.Platform != nil {
+		platform = d.Image.Platform.String()
+	}
+
+	entry, _, err := b.Cache.Store(ctx, osImage, platform, nil)
+	if err != nil {
+		return nil, fmt.Errorf("looking up OS image: %w", err)
+	}
+	return deployment.NewDirSrc(entry), nil
+}
+
+// rawDiskSize returns the size of the RAW disk image, defaults to 10G if no size is given
+func rawDiskSize(diskSize imginstall.DiskSize) (deployment.MiB, error) {
 	const defaultSize = "10G"
 
 	if diskSize == "" {
 		diskSize = defaultSize
 	} else if !diskSize.IsValid() {
-		return fmt.Errorf("invalid disk size definition '%s'", diskSize)
+		return 0, fmt.Errorf("invalid disk size definition '%s'", diskSize)
 	}
 
-	_, err := runner.Run("truncate", "-s", string(diskSize), img.OutputImageName)
-	return err
+	size, err := diskSize.ToMiB()
+	if err != nil {
+		return 0, fmt.Errorf("parsing disk size '%s': %w", diskSize, err)
+	} else if size == 0 {
+		return 0, fmt.Errorf("disk size '%s' is below 1M", diskSize)
+	}
+	return deployment.MiB(size), nil
 }
 
-func attachDevice(runner sys.Runner, img image.Image) (string, error) {
-	out, err := runner.Run("losetup", "-f", "--show", img.OutputImageName)
+// estimateDiskSize computes the RAW disk size required to install the given OS image
+// and the overlays tree on the given deployment. OCI layer sizes are compressed, hence
+// they are scaled by an expansion factor to approximate the unpacked size.
+func (b *Builder) estimateDiskSize(
+	ctx context.Context, d *deployment.Deployment, osImage *deployment.ImageSource, output config.Output, slack uint,
+) (deployment.MiB, error) {
+	var osSize int64
+	var err error
+	switch {
+	case osImage.IsDir():
+		osSize, err = vfs.DirSizeContext(ctx, b.System.FS(), osImage.URI())
+	case osImage.IsContainersStorage():
+		osSize, err = unpack.NewContainersStorageUnpacker(b.System, osImage.URI()).Size(ctx)
+	default:
+		unpacker := unpack.NewOCIUnpacker(
+			b.System, osImage.URI(), unpack.WithLocalOCI(b.Local), unpack.WithRegistryConfigOCI(b.Registry),
+		)
+		osSize, err = unpacker.Size(ctx)
+		osSize *= customize.ImageExpansion
+	}
+	if err != nil {
+		return 0, fmt.Errorf("computing OS image size: %w", err)
+	}
+
+	overlaysSize, err := vfs.DirSizeContext(ctx, b.System.FS(), output.OverlaysDir())
 	if err != nil {
-		return "", err
+		return 0, fmt.Errorf("computing overlays size: %w", err)
 	}
 
-	device := strings.TrimSpace(string(out))
-	return device, nil
+	content := osSize + overlaysSize
+	return customize.AutoDiskSize(content, slack, d.Disks[0].Partitions...), nil
 }
 
-func detachDevice(runner sys.Runner, device string) error {
-	_, err := runner.Run("losetup", "-d", device)
-	return err
+// convertDisk converts the given RAW disk image to the given format. Formats supporting it
+// are compressed or created with a sparse friendly subformat.
+func convertDisk(runner sys.Runner, rawImage, target string, format imginstall.DiskFormat) error {
+	args := []string{"convert", "-f", string(imginstall.FormatRAW), "-O", string(format)}
+
+	switch format {
+	case imginstall.FormatQCOW2:
+		args = append(args, "-c")
+	case imginstall.FormatVMDK:
+		args = append(args, "-o", "subformat=streamOptimized")
+	case imginstall.FormatVHDX:
+		args = append(args, "-o", "subformat=dynamic")
+	default:
+		return fmt.Errorf("unsupported disk image format '%s'", format)
+	}
+
+	out, err := runner.Run("qemu-img", append(args, rawImage, target)...)
+	if err != nil {
+		return fmt.Errorf("converting disk image to %s: %s: %w", format, strings.TrimSpace(string(out)), err)
+	}
+	return nil
 }
@@ -18,13 +18,241 @@ limitations under the License.
 package build
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/config"
+	"github.com/suse/elemental/v3/internal/image"
+	imginstall "github.com/suse/elemental/v3/internal/image/install"
+	"github.com/suse/elemental/v3/pkg/cache"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
 func TestBuildSuite(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Build test suite")
 }
+
+var _ = Describe("Disk image", Label("build"), func() {
+	var runner *sysmock.Runner
+
+	BeforeEach(func() {
+		runner = sysmock.NewRunner()
+	})
+
+	It("defaults the RAW disk size", func() {
+		Expect(rawDiskSize("")).To(BeEquivalentTo(10240))
+		Expect(rawDiskSize("2G")).To(BeEquivalentTo(2048))
+	})
+
+	It("fails to parse an invalid RAW disk size", func() {
+		_, err := rawDiskSize("10X")
+		Expect(err).To(MatchError("invalid disk size definition '10X'"))
+		_, err = rawDiskSize("4K")
+		Expect(err).To(MatchError("disk size '4K' is below 1M"))
+	})
+
+	It("converts a RAW disk to a compressed qcow2 image", func() {
+		Expect(convertDisk(runner, "/build/disk.raw", "/out/image.qcow2", imginstall.FormatQCOW2)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"qemu-img", "convert", "-f", "raw", "-O", "qcow2", "-c", "/build/disk.raw", "/out/image.qcow2"},
+		})).To(Succeed())
+	})
+
+	It("converts a RAW disk to vmdk and vhdx images", func() {
+		Expect(convertDisk(runner, "/build/disk.raw", "/out/image.vmdk", imginstall.FormatVMDK)).To(Succeed())
+		Expect(convertDisk(runner, "/build/disk.raw", "/out/image.vhdx", imginstall.FormatVHDX)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"qemu-img", "convert", "-f", "raw", "-O", "vmdk", "-o", "subformat=streamOptimized", "/build/disk.raw", "/out/image.vmdk"},
+			{"qemu-img", "convert", "-f", "raw", "-O", "vhdx", "-o", "subformat=dynamic", "/build/disk.raw", "/out/image.vhdx"},
+		})).To(Succeed())
+	})
+
+	It("fails to convert to an unsupported format", func() {
+		Expect(convertDisk(runner, "/build/disk.raw", "/out/image.vdi", "vdi")).To(MatchError("unsupported disk image format 'vdi'"))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+
+	It("fails if qemu-img fails", func() {
+		runner.SideEffect = func(_ string, _ ...string) ([]byte, error) {
+			return []byte("no space left"), fmt.Errorf("exit status 1")
+		}
+		err := convertDisk(runner, "/build/disk.raw", "/out/image.qcow2", imginstall.FormatQCOW2)
+		Expect(err).To(MatchError("converting disk image to qcow2: no space left: exit status 1"))
+	})
+})
+
+var _ = Describe("ISO image", Label("build", "iso"), func() {
+	var runner *sysmock.Runner
+	var builder *Builder
+	var def *image.Definition
+	var output config.Output
+	var cleanup func()
+
+	BeforeEach(func() {
+		runner = sysmock.NewRunner()
+		fs, cleanFS, err := sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		cleanup = cleanFS
+		s, err := sys.NewSystem(
+			sys.WithFS(fs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		output, err = config.NewOutput(fs, "/build", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		builder = &Builder{
+			System: s,
+			ConfigManager: &configManagerMock{
+				rm: &resolver.ResolvedManifest{
+					CorePlatform: &core.ReleaseManifest{
+						Components: core.Components{
+							OperatingSystem: &core.OperatingSystem{
+								Image: core.Image{Base: "registry.org/my/os:latest"},
+							},
+						},
+					},
+				},
+			},
+		}
+		def = &image.Definition{
+			Image:         image.Image{ImageType: image.TypeISO, OutputImageName: "/out/image.iso"},
+			Configuration: &image.Configuration{},
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("fails to build an ISO without a target device", func() {
+		err := builder.Run(context.Background(), def, output)
+		Expect(err).To(MatchError("missing device configuration for ISO image type"))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Squashfs image", Label("build", "squashfs"), func() {
+	var runner *sysmock.Runner
+	var s *sys.System
+	var fs vfs.FS
+	var cleanup func()
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/build/squashfs-root/etc/os-release": "NAME=OS",
+			"/build/overlays/etc/hostname":        "host",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(fs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("applies the overlays and packs the root tree", func() {
+		Expect(packSquashfs(context.Background(), s, "/build/squashfs-root", "/build/overlays", "/out/os.squashfs", nil)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"rsync"},
+			{"mksquashfs", "/build/squashfs-root", "/out/os.squashfs"},
+		})).To(Succeed())
+	})
+
+	It("packs the root tree without overlays", func() {
+		Expect(packSquashfs(context.Background(), s, "/build/squashfs-root", "/build/missing", "/out/os.squashfs", nil)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"rsync"}})).NotTo(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"mksquashfs"}})).To(Succeed())
+	})
+
+	It("fails if mksquashfs fails", func() {
+		runner.SideEffect = func(cmd string, _ ...string) ([]byte, error) {
+			if cmd == "mksquashfs" {
+				return []byte{}, fmt.Errorf("no space left")
+			}
+			return []byte{}, nil
+		}
+		err := packSquashfs(context.Background(), s, "/build/squashfs-root", "/build/overlays", "/out/os.squashfs", nil)
+		Expect(err).To(MatchError(ContainSubstring("creating squashfs image")))
+	})
+})
+
+var _ = Describe("OS source", Label("build"), func() {
+	const osImage = "registry.org/my/os:latest"
+	var builder *Builder
+	var def *image.Definition
+	var cleanup func()
+
+	BeforeEach(func() {
+		fs, cleanFS, err := sysmock.TestFS(map[string]string{
+			"/airgap/index.yaml": osImage + ": sha256:0123abcd\n",
+			"/airgap/images/0123abcd-linux-arm64/etc/os-release": "NAME=OS",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		cleanup = cleanFS
+		s, err := sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+
+		builder = &Builder{System: s}
+		p, err := platform.Parse("linux/arm64")
+		Expect(err).NotTo(HaveOccurred())
+		def = &image.Definition{Image: image.Image{Platform: p}}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("uses the OCI image by default", func() {
+		src, err := builder.osSource(context.Background(), osImage, def)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(src.IsOCI()).To(BeTrue())
+		Expect(src.URI()).To(Equal(osImage))
+	})
+
+	It("uses the image of the containers storage for local builds", func() {
+		builder.Local = true
+		src, err := builder.osSource(context.Background(), osImage, def)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(src.IsContainersStorage()).To(BeTrue())
+		Expect(src.URI()).To(Equal(osImage))
+	})
+
+	It("uses the tree stored in the air-gap directory", func() {
+		var err error
+		builder.Cache, err = cache.New(builder.System, "/airgap", cache.WithOffline())
+		Expect(err).NotTo(HaveOccurred())
+
+		src, err := builder.osSource(context.Background(), osImage, def)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(src.IsDir()).To(BeTrue())
+		Expect(src.URI()).To(Equal("/airgap/images/0123abcd-linux-arm64"))
+
+		def.Image.Platform, err = platform.Parse("linux/amd64")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = builder.osSource(context.Background(), osImage, def)
+		Expect(err).To(MatchError(ContainSubstring("is not stored in '/airgap'")))
+	})
+})
+
+type configManagerMock struct {
+	rm *resolver.ResolvedManifest
+}
+
+func (c *configManagerMock) ConfigureComponents(_ context.Context, _ *image.Configuration, _ config.Output) (*resolver.ResolvedManifest, error) {
+	return c.rm, nil
+}
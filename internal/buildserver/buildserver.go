@@ -0,0 +1,735 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package buildserver lets teams share a single build host. Build jobs, made of an image definition
+// tarball and an optional release manifest URI, are submitted over an HTTP API authenticated with
+// per tenant bearer tokens. Jobs are queued, up to a limit per tenant, and executed one workspace each, their artifacts are then
+// served to the tenant which submitted them until the job expires.
+//
+// API:
+//
+//	POST /v1/jobs?type=<iso|raw>&platform=<platform>&manifestURI=<uri>  submits the tarball in the body
+//	GET  /v1/jobs                                                       lists the jobs of the tenant
+//	GET  /v1/jobs/{id}                                                  describes a job
+//	GET  /v1/jobs/{id}/artifacts/{name}                                 downloads an artifact of a finished job
+package buildserver
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/manifest/source"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// DefaultQueueSize is the number of jobs waiting to be executed before new ones are rejected
+	DefaultQueueSize = 32
+	// DefaultTenantQueueSize is the number of jobs of a tenant waiting to be executed before its new ones
+	// are rejected
+	DefaultTenantQueueSize = 8
+	// MaxDefinitionSize is the maximum size of a definition tarball
+	MaxDefinitionSize = 256 << 20
+	// DefaultMaxExtractedSize is the maximum size of a definition tarball once decompressed
+	DefaultMaxExtractedSize = 1 << 30
+	// DefaultRetention is how long finished jobs and their workspaces are kept
+	DefaultRetention = 24 * time.Hour
+
+	// maxCleanupInterval is the maximum period between two checks for expired jobs
+	maxCleanupInterval = 10 * time.Minute
+
+	configDir = "config"
+	outputDir = "output"
+	workDir   = "work"
+)
+
+// tenantReg matches valid tenant names, they are used as workspace directory names
+var tenantReg = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// idReg matches job IDs, they are used as workspace directory names within the tenant directory
+var idReg = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// Status is the state of a build job
+type Status string
+
+const (
+	Queued    Status = "queued"
+	Running   Status = "running"
+	Succeeded Status = "succeeded"
+	Failed    Status = "failed"
+)
+
+// Job is a build request of a tenant
+type Job struct {
+	ID          string     `json:"id"`
+	Tenant      string     `json:"tenant"`
+	Type        string     `json:"type"`
+	Platform    string     `json:"platform,omitempty"`
+	ManifestURI string     `json:"manifestURI,omitempty"`
+	Status      Status     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Artifacts   []string   `json:"artifacts,omitempty"`
+	Created     time.Time  `json:"created"`
+	Started     *time.Time `json:"started,omitempty"`
+	Finished    *time.Time `json:"finished,omitempty"`
+
+	workspace Workspace
+}
+
+// Workspace is the directory tree a job is executed in
+type Workspace struct {
+	// Root is the directory including the whole workspace, it is removed once the job expires
+	Root string
+	// ConfigDir is the image definition extracted from the submitted tarball
+	ConfigDir string
+	// OutputDir is where the build writes its artifacts, each regular file is served as an artifact
+	OutputDir string
+	// WorkDir is a scratch directory removed once the job is finished
+	WorkDir string
+}
+
+// BuildFunc builds the given job within its workspace
+type BuildFunc func(ctx context.Context, job Job, ws Workspace) error
+
+// Server queues and executes the build jobs submitted over its HTTP handler
+type Server struct {
+	s            *sys.System
+	workDir      string
+	build        BuildFunc
+	tokens       map[string]string
+	workers      int
+	retention    time.Duration
+	maxExtracted int64
+	queue        chan *Job
+	tenantQueue  int
+
+	mu     sync.Mutex
+	jobs   map[string]*Job
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	// reserved is the number of queue slots booked by each tenant for the jobs being submitted
+	reserved map[string]int
+	pending  int
+}
+
+type Opt func(*Server)
+
+// WithTokens sets the bearer token of each tenant allowed to use the server
+func WithTokens(tokens map[string]string) Opt {
+	return func(srv *Server) {
+		for tenant, token := range tokens {
+			srv.tokens[token] = tenant
+		}
+	}
+}
+
+// WithWorkers sets the number of jobs executed concurrently
+func WithWorkers(workers int) Opt {
+	return func(srv *Server) {
+		if workers > 0 {
+			srv.workers = workers
+		}
+	}
+}
+
+// WithQueueSize sets the number of jobs waiting to be executed before new ones are rejected
+func WithQueueSize(size int) Opt {
+	return func(srv *Server) {
+		if size > 0 {
+			srv.queue = make(chan *Job, size)
+		}
+	}
+}
+
+// WithTenantQueueSize sets the number of jobs of a tenant waiting to be executed before its new ones are rejected
+func WithTenantQueueSize(size int) Opt {
+	return func(srv *Server) {
+		if size > 0 {
+			srv.tenantQueue = size
+		}
+	}
+}
+
+// WithRetention sets how long finished jobs and their workspaces are kept
+func WithRetention(retention time.Duration) Opt {
+	return func(srv *Server) {
+		if retention > 0 {
+			srv.retention = retention
+		}
+	}
+}
+
+// WithMaxExtractedSize sets the maximum size of a definition tarball once decompressed
+func WithMaxExtractedSize(size int64) Opt {
+	return func(srv *Server) {
+		if size > 0 {
+			srv.maxExtracted = size
+		}
+	}
+}
+
+func New(s *sys.System, workDir string, build BuildFunc, opts ...Opt) *Server {
+	srv := &Server{
+		s:            s.WithComponent("buildserver"),
+		workDir:      workDir,
+		build:        build,
+		tokens:       map[string]string{},
+		workers:      1,
+		retention:    DefaultRetention,
+		maxExtracted: DefaultMaxExtractedSize,
+		queue:        make(chan *Job, DefaultQueueSize),
+		tenantQueue:  DefaultTenantQueueSize,
+		jobs:         map[string]*Job{},
+		reserved:     map[string]int{},
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+	return srv
+}
+
+// LoadTokens reads the tenant tokens from the given YAML file, mapping each tenant name to its token
+func LoadTokens(s *sys.System, file string) (map[string]string, error) {
+	data, err := s.FS().ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading tokens file '%s': %w", file, err)
+	}
+	tokens := map[string]string{}
+	err = yaml.Unmarshal(data, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("parsing tokens file '%s': %w", file, err)
+	}
+	for tenant, token := range tokens {
+		if !tenantReg.MatchString(tenant) {
+			return nil, fmt.Errorf("invalid tenant name '%s'", tenant)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("empty token for tenant '%s'", tenant)
+		}
+	}
+	return tokens, nil
+}
+
+// Start removes the workspaces left over by a previous server, as jobs are only kept in memory, and launches
+// the workers executing the queued jobs until the server is shut down. Cancelling the given context aborts
+// the running jobs and fails the queued ones.
+func (srv *Server) Start(ctx context.Context) {
+	srv.removeStaleWorkspaces()
+
+	for range srv.workers {
+		srv.wg.Add(1)
+		go func() {
+			defer srv.wg.Done()
+			for job := range srv.queue {
+				srv.run(ctx, job)
+			}
+		}()
+	}
+
+	srv.wg.Add(1)
+	go func() {
+		defer srv.wg.Done()
+		ticker := time.NewTicker(min(srv.retention, maxCleanupInterval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-srv.stop:
+				return
+			case now := <-ticker.C:
+				srv.expire(now)
+			}
+		}
+	}()
+
+	go func() {
+		srv.wg.Wait()
+		close(srv.done)
+	}()
+}
+
+// Shutdown stops accepting new jobs and waits until the queued and running ones are finished or
+// the given context is done.
+func (srv *Server) Shutdown(ctx context.Context) error {
+	srv.mu.Lock()
+	if !srv.closed {
+		srv.closed = true
+		close(srv.queue)
+		close(srv.stop)
+	}
+	srv.mu.Unlock()
+
+	select {
+	case <-srv.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Handler returns the HTTP handler of the API
+func (srv *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/jobs", srv.authenticated(srv.submit))
+	mux.HandleFunc("GET /v1/jobs", srv.authenticated(srv.list))
+	mux.HandleFunc("GET /v1/jobs/{id}", srv.authenticated(srv.get))
+	mux.HandleFunc("GET /v1/jobs/{id}/artifacts/{name}", srv.authenticated(srv.artifact))
+	return mux
+}
+
+func (srv *Server) authenticated(handler func(w http.ResponseWriter, r *http.Request, tenant string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			for known, tenant := range srv.tokens {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+					handler(w, r, tenant)
+					return
+				}
+			}
+		}
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}
+}
+
+func (srv *Server) submit(w http.ResponseWriter, r *http.Request, tenant string) {
+	query := r.URL.Query()
+	id, err := newID()
+	if err != nil {
+		srv.fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	job := &Job{
+		ID:          id,
+		Tenant:      tenant,
+		Type:        query.Get("type"),
+		Platform:    query.Get("platform"),
+		ManifestURI: query.Get("manifestURI"),
+		Status:      Queued,
+		Created:     time.Now().UTC(),
+	}
+	if _, err = installer.StringToMediaType(job.Type); err != nil {
+		srv.fail(w, http.StatusBadRequest, err)
+		return
+	}
+	if job.ManifestURI != "" {
+		// Only OCI manifests are accepted, a file URI would read the host filesystem
+		src, err := source.ParseFromURI(job.ManifestURI)
+		if err != nil {
+			srv.fail(w, http.StatusBadRequest, err)
+			return
+		}
+		if src.Type() != source.OCI {
+			srv.fail(w, http.StatusBadRequest, fmt.Errorf("only %s release manifest URIs are accepted", source.OCI))
+			return
+		}
+	}
+
+	// The queue slot is reserved before reading the definition, so rejected jobs are not extracted
+	code, err := srv.reserve(tenant)
+	if err != nil {
+		srv.fail(w, code, err)
+		return
+	}
+
+	root := filepath.Join(srv.workDir, tenant, id)
+	job.workspace = Workspace{
+		Root:      root,
+		ConfigDir: filepath.Join(root, configDir),
+		OutputDir: filepath.Join(root, outputDir),
+		WorkDir:   filepath.Join(root, workDir),
+	}
+	err = srv.extract(http.MaxBytesReader(w, r.Body, MaxDefinitionSize), job.workspace.ConfigDir)
+	if err == nil {
+		err = vfs.MkdirAll(srv.s.FS(), job.workspace.OutputDir, vfs.DirPerm)
+	}
+	if err == nil {
+		err = vfs.MkdirAll(srv.s.FS(), job.workspace.WorkDir, vfs.DirPerm)
+	}
+	if err != nil {
+		srv.mu.Lock()
+		srv.unreserve(tenant)
+		srv.mu.Unlock()
+		_ = srv.s.FS().RemoveAll(root)
+		srv.fail(w, http.StatusBadRequest, fmt.Errorf("extracting definition: %w", err))
+		return
+	}
+
+	srv.mu.Lock()
+	srv.unreserve(tenant)
+	if srv.closed {
+		srv.mu.Unlock()
+		_ = srv.s.FS().RemoveAll(root)
+		srv.fail(w, http.StatusServiceUnavailable, fmt.Errorf("server is shutting down"))
+		return
+	}
+	select {
+	case srv.queue <- job:
+		srv.jobs[id] = job
+		srv.mu.Unlock()
+	default:
+		srv.mu.Unlock()
+		_ = srv.s.FS().RemoveAll(root)
+		srv.fail(w, http.StatusServiceUnavailable, fmt.Errorf("job queue is full"))
+		return
+	}
+
+	srv.s.Logger().Info("Queued job '%s' of tenant '%s'", id, tenant)
+	srv.reply(w, http.StatusAccepted, srv.snapshot(job))
+}
+
+// reserve books a queue slot for a job of the given tenant. It fails with the HTTP status to reply with if
+// the server is shutting down, the queue is full or the tenant reached its maximum of queued jobs.
+func (srv *Server) reserve(tenant string) (int, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.closed {
+		return http.StatusServiceUnavailable, fmt.Errorf("server is shutting down")
+	}
+	if len(srv.queue)+srv.pending >= cap(srv.queue) {
+		return http.StatusServiceUnavailable, fmt.Errorf("job queue is full")
+	}
+	queued := srv.reserved[tenant]
+	for _, job := range srv.jobs {
+		if job.Tenant == tenant && job.Status == Queued {
+			queued++
+		}
+	}
+	if queued >= srv.tenantQueue {
+		return http.StatusTooManyRequests, fmt.Errorf("tenant already has %d queued jobs", queued)
+	}
+	srv.reserved[tenant]++
+	srv.pending++
+	return 0, nil
+}
+
+// unreserve frees a queue slot booked by reserve, the caller must hold the lock
+func (srv *Server) unreserve(tenant string) {
+	srv.pending--
+	srv.reserved[tenant]--
+	if srv.reserved[tenant] <= 0 {
+		delete(srv.reserved, tenant)
+	}
+}
+
+func (srv *Server) list(w http.ResponseWriter, _ *http.Request, tenant string) {
+	srv.mu.Lock()
+	jobs := []Job{}
+	for _, job := range srv.jobs {
+		if job.Tenant == tenant {
+			jobs = append(jobs, *job)
+		}
+	}
+	srv.mu.Unlock()
+
+	slices.SortFunc(jobs, func(a, b Job) int { return a.Created.Compare(b.Created) })
+	srv.reply(w, http.StatusOK, jobs)
+}
+
+func (srv *Server) get(w http.ResponseWriter, r *http.Request, tenant string) {
+	job := srv.lookup(r.PathValue("id"), tenant)
+	if job == nil {
+		http.NotFound(w, r)
+		return
+	}
+	srv.reply(w, http.StatusOK, srv.snapshot(job))
+}
+
+func (srv *Server) artifact(w http.ResponseWriter, r *http.Request, tenant string) {
+	job := srv.lookup(r.PathValue("id"), tenant)
+	if job == nil {
+		http.NotFound(w, r)
+		return
+	}
+	name := r.PathValue("name")
+	snap := srv.snapshot(job)
+	if snap.Finished == nil || !slices.Contains(snap.Artifacts, name) {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := srv.s.FS().Open(filepath.Join(job.workspace.OutputDir, name))
+	if err != nil {
+		srv.fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	_, err = io.Copy(w, f)
+	if err != nil {
+		srv.s.Logger().Warn("Serving artifact '%s' of job '%s' failed: %v", name, job.ID, err)
+	}
+}
+
+// lookup returns the job with the given ID, only if it belongs to the given tenant
+func (srv *Server) lookup(id, tenant string) *Job {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	job := srv.jobs[id]
+	if job == nil || job.Tenant != tenant {
+		return nil
+	}
+	return job
+}
+
+// snapshot returns a copy of the given job, safe to read while the job is executed
+func (srv *Server) snapshot(job *Job) Job {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	snap := *job
+	snap.Artifacts = slices.Clone(job.Artifacts)
+	return snap
+}
+
+func (srv *Server) update(job *Job, fn func(*Job)) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	fn(job)
+}
+
+// run executes the given job and collects its artifacts, the job fails without running once the
+// given context is cancelled
+func (srv *Server) run(ctx context.Context, job *Job) {
+	err := ctx.Err()
+	if err != nil {
+		err = fmt.Errorf("job aborted: %w", err)
+	} else {
+		now := time.Now().UTC()
+		srv.update(job, func(j *Job) {
+			j.Status = Running
+			j.Started = &now
+		})
+		srv.s.Logger().Info("Running job '%s' of tenant '%s'", job.ID, job.Tenant)
+		err = srv.build(ctx, srv.snapshot(job), job.workspace)
+	}
+
+	if rmErr := srv.s.FS().RemoveAll(job.workspace.WorkDir); rmErr != nil {
+		srv.s.Logger().Warn("Removing work directory of job '%s' failed: %v", job.ID, rmErr)
+	}
+	// Artifacts of failed jobs are listed too, they may include the build logs
+	artifacts, aErr := srv.artifacts(job.workspace.OutputDir)
+	if err == nil {
+		err = aErr
+	}
+
+	finished := time.Now().UTC()
+	srv.update(job, func(j *Job) {
+		j.Finished = &finished
+		j.Artifacts = artifacts
+		if err != nil {
+			j.Status = Failed
+			j.Error = err.Error()
+			return
+		}
+		j.Status = Succeeded
+	})
+	if err != nil {
+		srv.s.Logger().Error("Job '%s' of tenant '%s' failed: %v", job.ID, job.Tenant, err)
+		return
+	}
+	srv.s.Logger().Info("Job '%s' of tenant '%s' succeeded", job.ID, job.Tenant)
+}
+
+// expire forgets the jobs finished for longer than the retention period and removes their workspaces
+func (srv *Server) expire(now time.Time) {
+	srv.mu.Lock()
+	var expired []*Job
+	for id, job := range srv.jobs {
+		if job.Finished != nil && now.Sub(*job.Finished) >= srv.retention {
+			expired = append(expired, job)
+			delete(srv.jobs, id)
+		}
+	}
+	srv.mu.Unlock()
+
+	for _, job := range expired {
+		srv.s.Logger().Info("Removing expired job '%s' of tenant '%s'", job.ID, job.Tenant)
+		if err := srv.s.FS().RemoveAll(job.workspace.Root); err != nil {
+			srv.s.Logger().Warn("Removing workspace of job '%s' failed: %v", job.ID, err)
+		}
+	}
+}
+
+// removeStaleWorkspaces removes the job workspaces found in the work directory, only directories named
+// after a tenant and a job ID are removed
+func (srv *Server) removeStaleWorkspaces() {
+	tenants, err := srv.s.FS().ReadDir(srv.workDir)
+	if err != nil {
+		return
+	}
+	for _, tenant := range tenants {
+		if !tenant.IsDir() || !tenantReg.MatchString(tenant.Name()) {
+			continue
+		}
+		dir := filepath.Join(srv.workDir, tenant.Name())
+		jobs, err := srv.s.FS().ReadDir(dir)
+		if err != nil {
+			srv.s.Logger().Warn("Listing workspaces of tenant '%s' failed: %v", tenant.Name(), err)
+			continue
+		}
+		for _, job := range jobs {
+			if !job.IsDir() || !idReg.MatchString(job.Name()) {
+				continue
+			}
+			srv.s.Logger().Info("Removing stale workspace of job '%s' of tenant '%s'", job.Name(), tenant.Name())
+			if err = srv.s.FS().RemoveAll(filepath.Join(dir, job.Name())); err != nil {
+				srv.s.Logger().Warn("Removing stale workspace of job '%s' failed: %v", job.Name(), err)
+			}
+		}
+	}
+}
+
+// artifacts lists the regular files of the given output directory
+func (srv *Server) artifacts(dir string) ([]string, error) {
+	entries, err := srv.s.FS().ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing artifacts: %w", err)
+	}
+	var artifacts []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			artifacts = append(artifacts, entry.Name())
+		}
+	}
+	return artifacts, nil
+}
+
+// extract unpacks the given tar stream, optionally gzip compressed, into the given directory. Only
+// directories and regular files are extracted, entries escaping the directory are rejected. The
+// decompressed stream is limited to the maximum extracted size.
+func (srv *Server) extract(r io.Reader, dir string) error {
+	br := bufio.NewReader(r)
+	var reader io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	reader = &limitedReader{r: reader, n: srv.maxExtracted}
+
+	err := vfs.MkdirAll(srv.s.FS(), dir, vfs.DirPerm)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name := filepath.Clean(hdr.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("entry '%s' escapes the definition directory", hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = vfs.MkdirAll(srv.s.FS(), target, vfs.DirPerm)
+		case tar.TypeReg:
+			err = vfs.MkdirAll(srv.s.FS(), filepath.Dir(target), vfs.DirPerm)
+			if err == nil {
+				err = srv.writeFile(target, tr)
+			}
+		default:
+			return fmt.Errorf("entry '%s' is not a regular file or directory", hdr.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// limitedReader fails once more than n bytes are read, unlike io.LimitReader which silently truncates
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, fmt.Errorf("definition exceeds the maximum extracted size")
+	}
+	return n, err
+}
+
+func (srv *Server) writeFile(path string, r io.Reader) error {
+	f, err := srv.s.FS().Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+func (srv *Server) reply(w http.ResponseWriter, code int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		srv.s.Logger().Warn("Writing response failed: %v", err)
+	}
+}
+
+func (srv *Server) fail(w http.ResponseWriter, code int, err error) {
+	srv.reply(w, code, map[string]string{"error": err.Error()})
+}
+
+func newID() (string, error) {
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", fmt.Errorf("generating job ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
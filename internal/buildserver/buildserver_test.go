@@ -0,0 +1,349 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildserver_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/buildserver"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestBuildServerSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Build server test suite")
+}
+
+func definition(files map[string]string) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for name, content := range files {
+		Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})).To(Succeed())
+		_, err := tw.Write([]byte(content))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(tw.Close()).To(Succeed())
+	return buf
+}
+
+var _ = Describe("Build server", Label("buildserver"), func() {
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var srv *buildserver.Server
+	var ts *httptest.Server
+	var cancel context.CancelFunc
+	var buildErr error
+	var built []buildserver.Job
+	var release chan struct{}
+	var opts []buildserver.Opt
+
+	request := func(method, path, token string, body io.Reader) *http.Response {
+		req, err := http.NewRequest(method, ts.URL+path, body)
+		Expect(err).NotTo(HaveOccurred())
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := ts.Client().Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+	decode := func(res *http.Response, v any) {
+		defer res.Body.Close()
+		Expect(json.NewDecoder(res.Body).Decode(v)).To(Succeed())
+	}
+	jobStatus := func(id, token string) func() buildserver.Status {
+		return func() buildserver.Status {
+			var job buildserver.Job
+			decode(request(http.MethodGet, "/v1/jobs/"+id, token, nil), &job)
+			return job.Status
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		buildErr = nil
+		built = nil
+		release = nil
+		opts = nil
+		fs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/etc/tokens.yaml":                       "team-a: token-a\nteam-b: token-b\n",
+			"/work/team-a/0123456789abcdef/config/a": "",
+			"/work/team-a/keep/file":                 "",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	JustBeforeEach(func() {
+		tokens, err := buildserver.LoadTokens(s, "/etc/tokens.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		srv = buildserver.New(s, "/work", func(_ context.Context, job buildserver.Job, ws buildserver.Workspace) error {
+			if release != nil {
+				<-release
+			}
+			built = append(built, job)
+			if buildErr != nil {
+				return buildErr
+			}
+			data, err := fs.ReadFile(filepath.Join(ws.ConfigDir, "release.yaml"))
+			if err != nil {
+				return err
+			}
+			return fs.WriteFile(filepath.Join(ws.OutputDir, "image.raw"), data, vfs.FilePerm)
+		}, append(opts, buildserver.WithTokens(tokens))...)
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		srv.Start(ctx)
+		ts = httptest.NewServer(srv.Handler())
+	})
+	AfterEach(func() {
+		ts.Close()
+		cancel()
+		Expect(srv.Shutdown(context.Background())).To(Succeed())
+		cleanup()
+	})
+	It("rejects unauthenticated requests", func() {
+		res := request(http.MethodGet, "/v1/jobs", "", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+		res = request(http.MethodGet, "/v1/jobs", "unknown", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+	It("builds a submitted job and serves its artifacts", func() {
+		res := request(
+			http.MethodPost, "/v1/jobs?type=raw&platform=linux/amd64&manifestURI=oci://registry.example.com/release:1.0",
+			"token-a", definition(map[string]string{"release.yaml": "name: test\n"}),
+		)
+		Expect(res.StatusCode).To(Equal(http.StatusAccepted))
+		var job buildserver.Job
+		decode(res, &job)
+		Expect(job.Tenant).To(Equal("team-a"))
+
+		Eventually(jobStatus(job.ID, "token-a")).Should(Equal(buildserver.Succeeded))
+		Expect(built).To(HaveLen(1))
+		Expect(built[0].ManifestURI).To(Equal("oci://registry.example.com/release:1.0"))
+		Expect(built[0].Platform).To(Equal("linux/amd64"))
+
+		res = request(http.MethodGet, fmt.Sprintf("/v1/jobs/%s/artifacts/image.raw", job.ID), "token-a", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		data, err := io.ReadAll(res.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("name: test\n"))
+
+		var jobs []buildserver.Job
+		decode(request(http.MethodGet, "/v1/jobs", "token-a", nil), &jobs)
+		Expect(jobs).To(HaveLen(1))
+		Expect(jobs[0].Artifacts).To(Equal([]string{"image.raw"}))
+	})
+	It("isolates the jobs of each tenant", func() {
+		res := request(http.MethodPost, "/v1/jobs?type=iso", "token-a", definition(map[string]string{"release.yaml": ""}))
+		var job buildserver.Job
+		decode(res, &job)
+		Eventually(jobStatus(job.ID, "token-a")).Should(Equal(buildserver.Succeeded))
+
+		res = request(http.MethodGet, "/v1/jobs/"+job.ID, "token-b", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		res = request(http.MethodGet, fmt.Sprintf("/v1/jobs/%s/artifacts/image.raw", job.ID), "token-b", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+
+		var jobs []buildserver.Job
+		decode(request(http.MethodGet, "/v1/jobs", "token-b", nil), &jobs)
+		Expect(jobs).To(BeEmpty())
+		Expect(vfs.Exists(fs, filepath.Join("/work/team-a", job.ID, "config/release.yaml"))).To(BeTrue())
+	})
+	It("reports failed builds", func() {
+		buildErr = fmt.Errorf("manifest not found")
+		res := request(http.MethodPost, "/v1/jobs?type=iso", "token-a", definition(map[string]string{"release.yaml": ""}))
+		var job buildserver.Job
+		decode(res, &job)
+		Eventually(jobStatus(job.ID, "token-a")).Should(Equal(buildserver.Failed))
+
+		decode(request(http.MethodGet, "/v1/jobs/"+job.ID, "token-a", nil), &job)
+		Expect(job.Error).To(Equal("manifest not found"))
+	})
+	It("rejects definitions escaping their workspace", func() {
+		res := request(http.MethodPost, "/v1/jobs?type=iso", "token-a", definition(map[string]string{"../../etc/tokens.yaml": ""}))
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		data, err := fs.ReadFile("/etc/tokens.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("team-a"))
+		Expect(built).To(BeEmpty())
+	})
+	It("rejects unknown image types", func() {
+		res := request(http.MethodPost, "/v1/jobs?type=../../x", "token-a", definition(map[string]string{"release.yaml": ""}))
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(built).To(BeEmpty())
+	})
+	It("rejects release manifests read from the host", func() {
+		res := request(
+			http.MethodPost, "/v1/jobs?type=raw&manifestURI=file:///etc/tokens.yaml",
+			"token-a", definition(map[string]string{"release.yaml": ""}),
+		)
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(built).To(BeEmpty())
+	})
+	It("removes the workspaces left over by a previous server", func() {
+		Expect(vfs.Exists(fs, "/work/team-a/0123456789abcdef")).To(BeFalse())
+		Expect(vfs.Exists(fs, "/work/team-a/keep/file")).To(BeTrue())
+	})
+	It("drains the queued jobs on shutdown", func() {
+		release = make(chan struct{})
+		var ids []string
+		for range 2 {
+			res := request(http.MethodPost, "/v1/jobs?type=raw", "token-a", definition(map[string]string{"release.yaml": ""}))
+			Expect(res.StatusCode).To(Equal(http.StatusAccepted))
+			var job buildserver.Job
+			decode(res, &job)
+			ids = append(ids, job.ID)
+		}
+
+		done := make(chan error)
+		go func() { done <- srv.Shutdown(context.Background()) }()
+		Eventually(func() int {
+			res := request(http.MethodPost, "/v1/jobs?type=raw", "token-a", definition(map[string]string{"release.yaml": ""}))
+			res.Body.Close()
+			return res.StatusCode
+		}).Should(Equal(http.StatusServiceUnavailable))
+
+		close(release)
+		Eventually(done).Should(Receive(BeNil()))
+		for _, id := range ids {
+			Expect(jobStatus(id, "token-a")()).To(Equal(buildserver.Succeeded))
+		}
+	})
+	It("fails the queued jobs once the jobs are aborted", func() {
+		release = make(chan struct{})
+		var ids []string
+		for range 2 {
+			res := request(http.MethodPost, "/v1/jobs?type=raw", "token-a", definition(map[string]string{"release.yaml": ""}))
+			var job buildserver.Job
+			decode(res, &job)
+			ids = append(ids, job.ID)
+		}
+		Eventually(jobStatus(ids[0], "token-a")).Should(Equal(buildserver.Running))
+		cancel()
+		close(release)
+		Expect(srv.Shutdown(context.Background())).To(Succeed())
+
+		var job buildserver.Job
+		decode(request(http.MethodGet, "/v1/jobs/"+ids[1], "token-a", nil), &job)
+		Expect(job.Status).To(Equal(buildserver.Failed))
+		Expect(job.Error).To(ContainSubstring("job aborted"))
+	})
+	Context("with queue limits", func() {
+		BeforeEach(func() {
+			release = make(chan struct{})
+			opts = []buildserver.Opt{buildserver.WithQueueSize(2), buildserver.WithTenantQueueSize(1)}
+		})
+		AfterEach(func() {
+			close(release)
+		})
+		It("rejects the jobs of a tenant exceeding its queued jobs", func() {
+			var ids []string
+			for range 2 {
+				res := request(http.MethodPost, "/v1/jobs?type=raw", "token-a", definition(map[string]string{"release.yaml": ""}))
+				Expect(res.StatusCode).To(Equal(http.StatusAccepted))
+				var job buildserver.Job
+				decode(res, &job)
+				ids = append(ids, job.ID)
+				Eventually(jobStatus(ids[0], "token-a")).Should(Equal(buildserver.Running))
+			}
+
+			res := request(http.MethodPost, "/v1/jobs?type=raw", "token-a", definition(map[string]string{"release.yaml": ""}))
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusTooManyRequests))
+
+			res = request(http.MethodPost, "/v1/jobs?type=raw", "token-b", definition(map[string]string{"release.yaml": ""}))
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusAccepted))
+		})
+		It("rejects jobs before extracting their definition once the queue is full", func() {
+			res := request(http.MethodPost, "/v1/jobs?type=raw", "token-a", definition(map[string]string{"release.yaml": ""}))
+			var job buildserver.Job
+			decode(res, &job)
+			Eventually(jobStatus(job.ID, "token-a")).Should(Equal(buildserver.Running))
+			for _, token := range []string{"token-a", "token-b"} {
+				res = request(http.MethodPost, "/v1/jobs?type=raw", token, definition(map[string]string{"release.yaml": ""}))
+				res.Body.Close()
+				Expect(res.StatusCode).To(Equal(http.StatusAccepted))
+			}
+
+			entries, err := fs.ReadDir("/work/team-b")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+
+			res = request(http.MethodPost, "/v1/jobs?type=raw", "token-b", definition(map[string]string{"release.yaml": ""}))
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			entries, err = fs.ReadDir("/work/team-b")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+	})
+	Context("with limits", func() {
+		BeforeEach(func() {
+			opts = []buildserver.Opt{buildserver.WithMaxExtractedSize(4096), buildserver.WithRetention(50 * time.Millisecond)}
+		})
+		It("rejects definitions exceeding the maximum extracted size", func() {
+			buf := &bytes.Buffer{}
+			gz := gzip.NewWriter(buf)
+			_, err := io.Copy(gz, definition(map[string]string{"release.yaml": string(make([]byte, 1<<20))}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gz.Close()).To(Succeed())
+
+			res := request(http.MethodPost, "/v1/jobs?type=raw", "token-a", buf)
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(built).To(BeEmpty())
+		})
+		It("removes the expired jobs and their workspaces", func() {
+			res := request(http.MethodPost, "/v1/jobs?type=raw", "token-a", definition(map[string]string{"release.yaml": ""}))
+			var job buildserver.Job
+			decode(res, &job)
+
+			Eventually(func() int {
+				res := request(http.MethodGet, "/v1/jobs/"+job.ID, "token-a", nil)
+				res.Body.Close()
+				return res.StatusCode
+			}).Should(Equal(http.StatusNotFound))
+			Expect(vfs.Exists(fs, filepath.Join("/work/team-a", job.ID))).To(BeFalse())
+		})
+	})
+	It("rejects invalid tenant names", func() {
+		Expect(fs.WriteFile("/etc/tokens.yaml", []byte("../team: token\n"), vfs.FilePerm)).To(Succeed())
+		_, err := buildserver.LoadTokens(s, "/etc/tokens.yaml")
+		Expect(err).To(MatchError(ContainSubstring("invalid tenant name")))
+	})
+})
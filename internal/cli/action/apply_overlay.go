@@ -0,0 +1,118 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/install"
+	"github.com/suse/elemental/v3/pkg/requirements"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+func ApplyOverlay(ctx context.Context, cmd *cli.Command) (err error) {
+	var s *sys.System
+	args := &cmdpkg.ApplyOverlayArgs
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s = cmd.Root().Metadata["system"].(*sys.System)
+
+	s.Logger().Info("Starting apply-overlay action with args: %+v", args)
+
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	d, err := install.OpenDiskImage(s, args.Image, cleanup)
+	if err != nil {
+		s.Logger().Error("Opening disk image failed")
+		return err
+	}
+
+	err = digestApplyOverlaySetup(s, d, args)
+	if err != nil {
+		s.Logger().Error("Failed to collect apply-overlay setup")
+		return err
+	}
+
+	err = checkRequirements(s, "apply-overlay", requirements.DeploymentFeatures(d)...)
+	if err != nil {
+		return err
+	}
+
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	bootloader, err := bootloader.New(ctxCancel, d.BootConfig.Bootloader, s)
+	if err != nil {
+		s.Logger().Error("Parsing boot config failed")
+		return err
+	}
+
+	upgrader := upgrade.New(
+		ctxCancel, s, upgrade.WithBootloader(bootloader), upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+		upgrade.WithImageSync(false),
+	)
+
+	err = upgrader.Upgrade(d)
+	if err != nil {
+		s.Logger().Error("Applying overlay failed")
+		return err
+	}
+
+	s.Logger().Info("Overlay applied to disk image '%s'", args.Image)
+
+	result := newDeploymentResult(d)
+	result.Device = args.Image
+	return printer.FromCommand(cmd).Print(result, nil)
+}
+
+// digestApplyOverlaySetup sets the overlay tree and configuration script of the given disk image deployment.
+// EFI boot entries are dropped as they would be created on the host firmware.
+func digestApplyOverlaySetup(s *sys.System, d *deployment.Deployment, flags *cmdpkg.ApplyOverlayFlags) error {
+	if flags.Overlay != "" {
+		overlay, err := deployment.NewSrcFromURI(flags.Overlay)
+		if err != nil {
+			return fmt.Errorf("failed parsing overlay source URI ('%s'): %w", flags.Overlay, err)
+		}
+		d.OverlayTree = overlay
+	}
+	d.CfgScript = flags.ConfigScript
+
+	if d.Firmware != nil {
+		d.Firmware.BootEntries = nil
+	}
+
+	err := d.Sanitize(s)
+	if err != nil {
+		return fmt.Errorf("inconsistent deployment setup found: %w", err)
+	}
+	return nil
+}
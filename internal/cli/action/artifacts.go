@@ -0,0 +1,67 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/signature"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// signerFromFlags returns the signer of the given signing flags or nil if signing is not requested
+func signerFromFlags(flags cmdpkg.SigningFlags) *signature.Signer {
+	if flags == (cmdpkg.SigningFlags{}) {
+		return nil
+	}
+
+	return &signature.Signer{Tool: flags.Tool, Key: flags.Key}
+}
+
+// validateSigningFlags checks the signing flags before any artifact is generated
+func validateSigningFlags(flags cmdpkg.SigningFlags) error {
+	if sg := signerFromFlags(flags); sg != nil {
+		return sg.Validate()
+	}
+
+	return nil
+}
+
+// signingFeatures returns the requirements features of the given signing flags
+func signingFeatures(flags cmdpkg.SigningFlags) []string {
+	if sg := signerFromFlags(flags); sg != nil {
+		if sg.Tool == "" {
+			return []string{signature.GPGTool}
+		}
+		return []string{sg.Tool}
+	}
+
+	return nil
+}
+
+// publishArtifacts writes the SHA256SUMS file of the generated artifacts and signs them if requested
+func publishArtifacts(ctx context.Context, s *sys.System, flags cmdpkg.SigningFlags, artifacts ...string) error {
+	if err := signature.PublishArtifacts(ctx, s, signerFromFlags(flags), artifacts...); err != nil {
+		s.Logger().Error("Publishing artifacts checksums and signatures failed")
+		return fmt.Errorf("publishing artifacts: %w", err)
+	}
+
+	return nil
+}
@@ -23,16 +23,21 @@ import (
 	"os/signal"
 	"path/filepath"
 	"slices"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/urfave/cli/v3"
 
 	"github.com/suse/elemental/v3/internal/build"
 	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
 	"github.com/suse/elemental/v3/internal/config"
 	v0 "github.com/suse/elemental/v3/internal/config/v0"
 	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/inventory"
+	"github.com/suse/elemental/v3/pkg/cache"
 	"github.com/suse/elemental/v3/pkg/helm"
 	"github.com/suse/elemental/v3/pkg/http"
 	"github.com/suse/elemental/v3/pkg/sys"
@@ -40,6 +45,15 @@ import (
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
+type imageResult struct {
+	Image    string `yaml:"image"`
+	Type     string `yaml:"type"`
+	Platform string `yaml:"platform"`
+	Config   string `yaml:"config,omitempty"`
+	Images   string `yaml:"images,omitempty"`
+	Host     string `yaml:"host,omitempty"`
+}
+
 func Build(ctx context.Context, cmd *cli.Command) error {
 	args := &cmdpkg.BuildArgs
 
@@ -67,8 +81,86 @@ func Build(ctx context.Context, cmd *cli.Command) error {
 
 	logger.Info("Validated image configuration")
 
-	rootBuildPath := filepath.Join(args.BuildDir,
-		fmt.Sprintf("build-%s", time.Now().UTC().Format("2006-01-02T15-04-05")))
+	features := configurationFeatures(definition.Configuration, args.Signing, args.ConfextSigning)
+	if args.Local {
+		// Local OS images are read from the podman containers storage
+		features = append(features, "local")
+	}
+	if args.PreloadImages || args.VendorCharts || args.AirgapDir != "" {
+		// Helm charts are pulled, and rendered to list the images they reference
+		features = append(features, "helm")
+	}
+	if definition.Image.ImageType == image.TypeRAW && !definition.Configuration.Installation.RAW.Format.IsRAW() {
+		// RAW disk images are converted to the requested format
+		features = append(features, "qemu-img")
+	}
+	if err = checkRequirements(system, "build", features...); err != nil {
+		return err
+	}
+
+	definitions := []*image.Definition{definition}
+	var hosts []string
+	if args.Inventory != "" {
+		logger.Info("Reading inventory %s", args.Inventory)
+		definitions, hosts, err = inventoryDefinitions(system.FS(), args, definition)
+		if err != nil {
+			logger.Error("Applying inventory failed")
+			return err
+		}
+	}
+
+	buildCache, err := openCache(system, args.CacheDir, cmdpkg.RegistryConfig(cmd))
+	if args.AirgapDir != "" {
+		logger.Info("Reading artifacts from air-gap directory %s", args.AirgapDir)
+		buildCache, err = openAirgapDir(system, args.AirgapDir)
+	}
+	if err != nil {
+		logger.Error("Setting up build cache failed")
+		return err
+	}
+
+	var results []imageResult
+	for i, d := range definitions {
+		buildDir := fmt.Sprintf("build-%s", time.Now().UTC().Format("2006-01-02T15-04-05"))
+		result := imageResult{}
+		if hosts != nil {
+			buildDir = fmt.Sprintf("%s-%s", buildDir, hosts[i])
+			result.Host = hosts[i]
+			logger.Info("Building image of host %s", hosts[i])
+		}
+
+		if err = buildImage(ctxCancel, cmd, system, args, d, filepath.Join(args.BuildDir, buildDir), buildCache); err != nil {
+			return err
+		}
+
+		result.Image = d.Image.OutputImageName
+		result.Type = d.Image.ImageType
+		result.Platform = d.Image.Platform.String()
+		results = append(results, result)
+	}
+
+	var artifacts []string
+	for _, r := range results {
+		artifacts = append(artifacts, r.Image)
+	}
+
+	if err = publishArtifacts(ctxCancel, system, args.Signing, artifacts...); err != nil {
+		return err
+	}
+
+	if len(results) == 1 {
+		return printer.FromCommand(cmd).Print(results[0], nil)
+	}
+
+	return printer.FromCommand(cmd).Print(results, nil)
+}
+
+func buildImage(
+	ctx context.Context, cmd *cli.Command, system *sys.System, args *cmdpkg.BuildFlags, definition *image.Definition, rootBuildPath string,
+	buildCache *cache.Cache,
+) error {
+	logger := system.Logger()
+
 	output, err := config.NewOutput(system.FS(), rootBuildPath, "")
 	if err != nil {
 		logger.Error("Creating build directory failed")
@@ -87,36 +179,103 @@ func Build(ctx context.Context, cmd *cli.Command) error {
 		ValuesDir: v0.Dir(args.ConfigDir).HelmValuesDir(),
 	}
 
+	opts := append([]config.Opts{
+		config.WithDownloadFunc(http.Fetch),
+		config.WithLocal(args.Local),
+		config.WithRegistryConfig(cmdpkg.RegistryConfig(cmd)),
+		config.WithBuildInfo(args.ConfigDir, cmdpkg.Version()),
+		config.WithConfextSigning(args.ConfextSigning.Key, args.ConfextSigning.Certificate),
+		config.WithVendoredHelmCharts(args.VendorCharts),
+	}, cacheOpts(buildCache)...)
+	if args.PreloadImages || args.AirgapDir != "" {
+		// Air-gapped clusters can't pull the container images either
+		opts = append(opts, config.WithImagePreload(definition.Image.Platform))
+	}
+
 	configManager := config.NewManager(
 		system,
 		config.NewHelm(system.FS(), valuesResolver, logger, output.OverlaysDir()),
-		config.WithDownloadFunc(http.DownloadFile),
-		config.WithLocal(args.Local),
+		opts...,
 	)
 
 	builder := &build.Builder{
 		System:        system,
 		ConfigManager: configManager,
 		Local:         args.Local,
+		Registry:      cmdpkg.RegistryConfig(cmd),
+		Cache:         buildCache,
 	}
 
 	logger.Info("Starting build process for %s %s image", definition.Image.Platform.String(), definition.Image.ImageType)
-	if err = builder.Run(ctxCancel, definition, output); err != nil {
+	if err = builder.Run(ctx, definition, output); err != nil {
 		logger.Error("Build process failed")
 		return err
 	}
 
 	logger.Info("Build process complete")
+
 	return nil
 }
 
+// inventoryDefinitions applies the inventory to the image definition. It returns either a single
+// definition for the whole fleet or a definition for each host, along with the name of its host.
+func inventoryDefinitions(f vfs.FS, args *cmdpkg.BuildFlags, def *image.Definition) ([]*image.Definition, []string, error) {
+	inv, err := inventory.Parse(f, args.Inventory)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !args.PerHost {
+		conf, err := inv.Configure(def.Configuration, inv.Hosts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("configuring inventory hosts: %w", err)
+		}
+
+		if err = v0.Validate(conf); err != nil {
+			return nil, nil, fmt.Errorf("validating inventory configuration: %w", err)
+		}
+
+		return []*image.Definition{{Image: def.Image, Configuration: conf}}, nil, nil
+	}
+
+	// Images are configured separately, hence nodes of the same cluster require a common token
+	token := def.Configuration.Kubernetes.Token
+	if token == "" {
+		token = uuid.NewString()
+	}
+
+	var definitions []*image.Definition
+	var hosts []string
+	for _, h := range inv.Hosts {
+		conf, err := inv.Configure(def.Configuration, []inventory.Host{h})
+		if err != nil {
+			return nil, nil, fmt.Errorf("configuring host '%s': %w", h.Hostname, err)
+		}
+		conf.Kubernetes.Token = token
+		conf.Kubernetes.Host = h.Hostname
+
+		if err = v0.Validate(conf); err != nil {
+			return nil, nil, fmt.Errorf("validating configuration of host '%s': %w", h.Hostname, err)
+		}
+
+		img := def.Image
+		ext := filepath.Ext(img.OutputImageName)
+		img.OutputImageName = fmt.Sprintf("%s-%s%s", strings.TrimSuffix(img.OutputImageName, ext), h.Hostname, ext)
+
+		definitions = append(definitions, &image.Definition{Image: img, Configuration: conf})
+		hosts = append(hosts, h.Hostname)
+	}
+
+	return definitions, hosts, nil
+}
+
 func validateArgs(fs vfs.FS, args *cmdpkg.BuildFlags) error {
 	_, err := fs.Stat(args.ConfigDir)
 	if err != nil {
 		return fmt.Errorf("reading config directory: %w", err)
 	}
 
-	validImageTypes := []string{image.TypeRAW}
+	validImageTypes := []string{image.TypeRAW, image.TypeISO, image.TypeSquashfs}
 	if !slices.Contains(validImageTypes, args.ImageType) {
 		return fmt.Errorf("image type %q not supported", args.ImageType)
 	}
@@ -125,16 +284,32 @@ func validateArgs(fs vfs.FS, args *cmdpkg.BuildFlags) error {
 		return fmt.Errorf("malformed platform %q", args.Platform)
 	}
 
+	if args.NameTemplate != "" && args.OutputPath != "" {
+		if isDir, _ := vfs.IsDir(fs, args.OutputPath); !isDir {
+			return fmt.Errorf("name template requires the output path to be a directory")
+		}
+	}
+
+	if args.PerHost && args.Inventory == "" {
+		return fmt.Errorf("building an image per host requires an inventory")
+	}
+
+	if args.AirgapDir != "" && (args.CacheDir != "" || args.Local) {
+		return fmt.Errorf("air-gap directory can't be combined with a cache directory or local images")
+	}
+
+	if err := validateSigningFlags(args.Signing); err != nil {
+		return fmt.Errorf("invalid signing flags: %w", err)
+	}
+
+	if err := validateConfextSigningFlags(args.ConfextSigning); err != nil {
+		return fmt.Errorf("invalid confext signing flags: %w", err)
+	}
+
 	return nil
 }
 
 func parseImageDefinition(f vfs.FS, args *cmdpkg.BuildFlags) (*image.Definition, error) {
-	outputPath := args.OutputPath
-	if outputPath == "" {
-		imageName := fmt.Sprintf("image-%s.%s", time.Now().UTC().Format("2006-01-02T15-04-05"), args.ImageType)
-		outputPath = filepath.Join(args.BuildDir, imageName)
-	}
-
 	p, err := platform.Parse(args.Platform)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing platform %s", args.Platform)
@@ -145,6 +320,23 @@ func parseImageDefinition(f vfs.FS, args *cmdpkg.BuildFlags) (*image.Definition,
 		return nil, fmt.Errorf("parsing configuration directory %s: %w", args.ConfigDir, err)
 	}
 
+	outputPath := args.OutputPath
+	if outputPath == "" || args.NameTemplate != "" {
+		ext := args.ImageType
+		if format := conf.Installation.RAW.Format; args.ImageType == image.TypeRAW && !format.IsRAW() {
+			ext = string(format)
+		}
+		imageName := fmt.Sprintf("image-%s.%s", time.Now().UTC().Format("2006-01-02T15-04-05"), ext)
+		if args.NameTemplate != "" {
+			imageName = args.NameTemplate
+		}
+		outputDir := args.OutputPath
+		if outputDir == "" {
+			outputDir = args.BuildDir
+		}
+		outputPath = filepath.Join(outputDir, imageName)
+	}
+
 	return &image.Definition{
 		Image: image.Image{
 			ImageType:       args.ImageType,
@@ -26,13 +26,23 @@ import (
 	"github.com/urfave/cli/v3"
 
 	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
 	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/registry"
+	"github.com/suse/elemental/v3/pkg/requirements"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
+type mediaResult struct {
+	Image string                  `yaml:"image"`
+	Type  string                  `yaml:"type"`
+	OS    *deployment.ImageSource `yaml:"os,omitempty"`
+}
+
 func BuildInstaller(ctx context.Context, cmd *cli.Command) error {
 	var s *sys.System
 	args := &cmdpkg.InstallerArgs
@@ -51,7 +61,7 @@ func BuildInstaller(ctx context.Context, cmd *cli.Command) error {
 		stop()
 	}()
 
-	media, err := digestInstallerMedia(ctxCancel, s, args)
+	media, err := digestInstallerMedia(ctxCancel, s, args, cmdpkg.RegistryConfig(cmd))
 	if err != nil {
 		return fmt.Errorf("bad installer media setup: %w", err)
 	}
@@ -61,6 +71,17 @@ func BuildInstaller(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("failed to collect build setup: %w", err)
 	}
 
+	if err = validateSigningFlags(args.Signing); err != nil {
+		return fmt.Errorf("invalid signing flags: %w", err)
+	}
+
+	features := append(requirements.DeploymentFeatures(d), string(media.RootfsFormat()))
+	features = append(features, signingFeatures(args.Signing)...)
+	err = checkRequirements(s, "build-installer", features...)
+	if err != nil {
+		return err
+	}
+
 	s.Logger().Info("Running build process")
 
 	err = media.Build(d)
@@ -70,7 +91,15 @@ func BuildInstaller(ctx context.Context, cmd *cli.Command) error {
 
 	s.Logger().Info("Build complete")
 
-	return nil
+	// Netboot media is a directory tree including its own checksums file
+	if isDir, _ := vfs.IsDir(s.FS(), media.OutputFile()); !isDir {
+		if err = publishArtifacts(ctxCancel, s, args.Signing, media.OutputFile()); err != nil {
+			return err
+		}
+	}
+
+	result := mediaResult{Image: media.OutputFile(), Type: args.Type, OS: d.SourceOS}
+	return printer.FromCommand(cmd).Print(result, nil)
 }
 
 func digestInstallerDeploymentSetup(s *sys.System, flags *cmdpkg.InstallerFlags) (*deployment.Deployment, error) {
@@ -109,15 +138,26 @@ func digestInstallerDeploymentSetup(s *sys.System, flags *cmdpkg.InstallerFlags)
 	return d, err
 }
 
-func digestInstallerMedia(ctx context.Context, s *sys.System, flags *cmdpkg.InstallerFlags) (*installer.Media, error) {
+func digestInstallerMedia(ctx context.Context, s *sys.System, flags *cmdpkg.InstallerFlags, reg *registry.Config) (*installer.Media, error) {
 	mType, err := installer.StringToMediaType(flags.Type)
 	if err != nil {
 		return nil, err
 	}
 
+	rootfs := installer.SquashfsRootfs
+	if flags.RootfsFormat != "" {
+		rootfs, err = installer.ParseRootfsFormat(flags.RootfsFormat)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	media := installer.NewMedia(
 		ctx, s, mType,
-		installer.WithUnpackOpts(unpack.WithLocal(flags.Local), unpack.WithVerify(flags.Verify)),
+		installer.WithUnpackOpts(
+			unpack.WithLocal(flags.Local), unpack.WithVerify(flags.Verify), unpack.WithRegistryConfig(reg),
+		),
+		installer.WithNetbootURL(flags.NetbootURL), installer.WithRootfsFormat(rootfs),
 	)
 
 	if flags.Name != "" {
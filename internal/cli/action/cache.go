@@ -0,0 +1,60 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+
+	"github.com/suse/elemental/v3/internal/config"
+	"github.com/suse/elemental/v3/pkg/cache"
+	"github.com/suse/elemental/v3/pkg/registry"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// openCache opens the build cache at the given directory, a nil cache is returned if no directory is set
+func openCache(s *sys.System, dir string, reg *registry.Config) (*cache.Cache, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	c, err := cache.New(s, dir, cache.WithRegistryConfig(reg))
+	if err != nil {
+		return nil, fmt.Errorf("opening build cache at '%s': %w", dir, err)
+	}
+
+	return c, nil
+}
+
+// openAirgapDir opens the directory populated by the fetch-artifacts command as an offline cache
+func openAirgapDir(s *sys.System, dir string) (*cache.Cache, error) {
+	c, err := cache.New(s, dir, cache.WithOffline())
+	if err != nil {
+		return nil, fmt.Errorf("opening air-gap directory '%s': %w", dir, err)
+	}
+
+	return c, nil
+}
+
+// cacheOpts returns the config manager options using the given build cache, if any
+func cacheOpts(c *cache.Cache) []config.Opts {
+	if c == nil {
+		return nil
+	}
+
+	return []config.Opts{config.WithCache(c)}
+}
@@ -0,0 +1,299 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v3"
+	"go.yaml.in/yaml/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/internal/config"
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/channel"
+	"github.com/suse/elemental/v3/pkg/registry"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
+)
+
+type installedRelease struct {
+	Image           string `yaml:"image"`
+	Digest          string `yaml:"digest,omitempty"`
+	ReleaseManifest string `yaml:"releaseManifest,omitempty"`
+	Version         string `yaml:"version,omitempty"`
+}
+
+type availableUpgrade struct {
+	Image string `yaml:"image"`
+	// Digest is the config digest of the OS image for the platform of the system, as recorded in
+	// the deployment once installed
+	Digest          string `yaml:"digest"`
+	ReleaseManifest string `yaml:"releaseManifest,omitempty"`
+	Version         string `yaml:"version,omitempty"`
+	// Unsupported is the reason the upgrade is refused, if any
+	Unsupported string `yaml:"unsupported,omitempty"`
+}
+
+type checkUpgradeResult struct {
+	Installed installedRelease   `yaml:"installed"`
+	Available bool               `yaml:"available"`
+	Upgrades  []availableUpgrade `yaml:"upgrades"`
+}
+
+func CheckUpgrade(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+	args := &cmdpkg.CheckUpgradeArgs
+	reg := cmdpkg.RegistryConfig(cmd)
+
+	s.Logger().Debug("check-upgrade called with args: %+v", args)
+
+	d, err := deployment.Parse(s, "/")
+	if err != nil {
+		return fmt.Errorf("parsing deployment: %w", err)
+	} else if d == nil || d.SourceOS == nil {
+		return fmt.Errorf("deployment not found")
+	}
+
+	info, err := readBuildInfo(s)
+	if err != nil {
+		return err
+	}
+
+	installed := installedRelease{
+		Image:           d.SourceOS.URI(),
+		Digest:          d.SourceOS.GetDigest(),
+		ReleaseManifest: info.ReleaseManifest,
+		Version:         info.ReleaseVersion,
+	}
+
+	manifestURI := args.ReleaseManifest
+	if manifestURI == "" {
+		manifestURI = info.ReleaseManifest
+	}
+
+	var upgrades []availableUpgrade
+	switch {
+	case args.ChannelIndex != "":
+		upgrades, err = channelUpgrades(ctx, s, reg, installed, args.ChannelIndex, args.Channel, args.Local)
+	case manifestURI != "":
+		upgrades, err = manifestUpgrades(ctx, s, reg, installed, manifestURI, args.Local)
+	default:
+		upgrades, err = imageUpgrades(ctx, s, reg, d.SourceOS, installed, args.Local)
+	}
+	if err != nil {
+		s.Logger().Error("Checking available upgrades failed")
+		return err
+	}
+
+	result := checkUpgradeResult{Installed: installed, Upgrades: upgrades}
+	for _, u := range upgrades {
+		if u.Unsupported == "" {
+			result.Available = true
+		}
+	}
+
+	return printer.FromCommand(cmd).Print(result, func(out io.Writer) error {
+		return printUpgrades(result, out)
+	})
+}
+
+// readBuildInfo reads the build information of the installed image, images built without
+// a configuration directory have none
+func readBuildInfo(s *sys.System) (*config.BuildInfo, error) {
+	info := &config.BuildInfo{}
+
+	path := filepath.Join("/", image.BuildInfoPath())
+	if ok, _ := vfs.Exists(s.FS(), path); !ok {
+		s.Logger().Debug("build information not found at '%s'", path)
+		return info, nil
+	}
+
+	data, err := s.FS().ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading build information '%s': %w", path, err)
+	}
+	if err = yaml.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("unmarshalling build information '%s': %w", path, err)
+	}
+	return info, nil
+}
+
+// imageUpgrades checks whether the OS image reference of the installed deployment points to a new digest
+func imageUpgrades(
+	ctx context.Context, s *sys.System, reg *registry.Config, src *deployment.ImageSource, installed installedRelease, local bool,
+) ([]availableUpgrade, error) {
+	if !src.IsOCI() {
+		return nil, fmt.Errorf("installed OS image '%s' is not an OCI image, a release manifest or a channel index is required", src.String())
+	}
+
+	digest, err := imageDigest(ctx, s, reg, src.URI(), local)
+	if err != nil {
+		return nil, err
+	}
+
+	if installed.Digest != "" && digest == installed.Digest {
+		return nil, nil
+	}
+	return []availableUpgrade{{Image: src.URI(), Digest: digest}}, nil
+}
+
+// manifestUpgrades checks whether the release manifest at the given URI ships a different OS image than the
+// installed one. The upgrade is flagged as unsupported if the installed release is older than the minimum
+// version the release manifest supports upgrading from.
+func manifestUpgrades(ctx context.Context, s *sys.System, reg *registry.Config, installed installedRelease, uri string, local bool) ([]availableUpgrade, error) {
+	upgrade, err := manifestUpgrade(ctx, s, reg, uri, local)
+	if err != nil {
+		return nil, err
+	}
+
+	if !upgrade.newerThan(installed) {
+		return nil, nil
+	}
+
+	if installed.Version != "" && upgrade.Version != "" && upgrade.Version != installed.Version {
+		if err = channel.CheckUpgrade(installed.Version, upgrade.metadata); err != nil {
+			upgrade.Unsupported = err.Error()
+		}
+	}
+	return []availableUpgrade{upgrade.availableUpgrade}, nil
+}
+
+// channelUpgrades returns the releases of the supported upgrade path from the installed release to the
+// release of the given channel
+func channelUpgrades(
+	ctx context.Context, s *sys.System, reg *registry.Config, installed installedRelease, indexPath, ref string, local bool,
+) ([]availableUpgrade, error) {
+	if installed.Version == "" {
+		return nil, fmt.Errorf("installed release version is unknown, it is only recorded in images built from a release manifest")
+	}
+
+	data, err := s.FS().ReadFile(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading release channel index '%s': %w", indexPath, err)
+	}
+
+	index, err := channel.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := index.Resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := index.UpgradePath(installed.Version, target.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	var upgrades []availableUpgrade
+	for _, release := range path {
+		upgrade, err := manifestUpgrade(ctx, s, reg, release.Manifest, local)
+		if err != nil {
+			return nil, err
+		}
+		upgrade.Version = release.Version
+		upgrades = append(upgrades, upgrade.availableUpgrade)
+	}
+	return upgrades, nil
+}
+
+type manifestRelease struct {
+	availableUpgrade
+	metadata *api.Metadata
+}
+
+func (m manifestRelease) newerThan(installed installedRelease) bool {
+	if installed.Digest != "" {
+		return m.Digest != installed.Digest
+	}
+	return m.Image != installed.Image
+}
+
+// manifestUpgrade resolves the release manifest at the given URI and the digest of its OS image
+func manifestUpgrade(ctx context.Context, s *sys.System, reg *registry.Config, uri string, local bool) (manifestRelease, error) {
+	rm, err := resolveManifest(s, uri, local, reg)
+	if err != nil {
+		return manifestRelease{}, err
+	}
+
+	osImage := rm.CorePlatform.Components.OperatingSystem.Image.Base
+	digest, err := imageDigest(ctx, s, reg, osImage, local)
+	if err != nil {
+		return manifestRelease{}, err
+	}
+
+	release := manifestRelease{
+		availableUpgrade: availableUpgrade{Image: osImage, Digest: digest, ReleaseManifest: uri},
+		metadata:         rm.Metadata(),
+	}
+	if release.metadata != nil {
+		release.Version = release.metadata.Version
+	}
+	return release, nil
+}
+
+// imageDigest returns the config digest of the given OS image for the platform of the system, which
+// is the digest recorded in the deployment when the image is installed
+func imageDigest(ctx context.Context, s *sys.System, reg *registry.Config, imageRef string, local bool) (string, error) {
+	unpacker := unpack.NewOCIUnpacker(s, imageRef, unpack.WithLocalOCI(local), unpack.WithRegistryConfigOCI(reg))
+	return unpacker.Digest(ctx)
+}
+
+func printUpgrades(result checkUpgradeResult, out io.Writer) error {
+	installed := result.Installed.Image
+	if result.Installed.Digest != "" {
+		installed = fmt.Sprintf("%s (%s)", installed, result.Installed.Digest)
+	}
+	if result.Installed.Version != "" {
+		installed = fmt.Sprintf("%s, release %s", installed, result.Installed.Version)
+	}
+	fmt.Fprintf(out, "Installed: %s\n", installed)
+
+	if len(result.Upgrades) == 0 {
+		fmt.Fprintln(out, "No upgrades available")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tIMAGE\tDIGEST\tNOTES")
+	for _, u := range result.Upgrades {
+		version, notes := u.Version, "-"
+		if version == "" {
+			version = "-"
+		}
+		if u.Unsupported != "" {
+			notes = u.Unsupported
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", version, u.Image, u.Digest, notes)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,230 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	stdlog "log"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/cli/v3"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func coreManifest(host, version, minVersion string) string {
+	upgrade := ""
+	if minVersion != "" {
+		upgrade = fmt.Sprintf("  upgrade:\n    minVersion: %s\n", minVersion)
+	}
+	return fmt.Sprintf(`metadata:
+  name: suse-core-test
+  version: %s
+%scomponents:
+  operatingSystem:
+    image:
+      base: %s/os:%s
+      iso: %s/iso:%s
+`, version, upgrade, host, version, host, version)
+}
+
+// pushOSImage pushes an OS image of the given version to the registry and returns the
+// digest recorded in the deployment once installed
+func pushOSImage(host, version string) string {
+	layer, err := crane.Layer(map[string][]byte{"etc/os-release": []byte("VERSION_ID=" + version)})
+	Expect(err).NotTo(HaveOccurred())
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	Expect(err).NotTo(HaveOccurred())
+
+	ref, err := name.ParseReference(fmt.Sprintf("%s/os:%s", host, version), name.Insecure)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(remote.Write(ref, img)).To(Succeed())
+
+	digest, err := img.ConfigName()
+	Expect(err).NotTo(HaveOccurred())
+	return digest.String()
+}
+
+var _ = Describe("Check upgrade action", Label("check-upgrade"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+	var err error
+	var cliCmd *cli.Command
+	var out *bytes.Buffer
+	var result map[string]any
+	var srv *httptest.Server
+	var host, installedDigest, upgradeDigest, nextDigest string
+
+	manifestURI := func(path string) string {
+		raw, err := tfs.RawPath(path)
+		Expect(err).NotTo(HaveOccurred())
+		return "file://" + raw
+	}
+
+	checkUpgrade := func() error {
+		out.Reset()
+		if err := action.CheckUpgrade(context.Background(), cliCmd); err != nil {
+			return err
+		}
+		result = map[string]any{}
+		return yaml.Unmarshal(out.Bytes(), &result)
+	}
+
+	upgrades := func() []any {
+		list, _ := result["upgrades"].([]any)
+		return list
+	}
+
+	BeforeEach(func() {
+		cmd.CheckUpgradeArgs = cmd.CheckUpgradeFlags{Channel: "stable"}
+		out = &bytes.Buffer{}
+		srv = httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(stdlog.New(io.Discard, "", 0))))
+		host = strings.TrimPrefix(srv.URL, "http://")
+		installedDigest = pushOSImage(host, "1.0.0")
+		upgradeDigest = pushOSImage(host, "1.1.0")
+		nextDigest = pushOSImage(host, "1.2.0")
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/etc/elemental/deployment.yaml": fmt.Sprintf("sourceOS:\n  uri: oci://%s/os:1.0.0\n  digest: %s\n", host, installedDigest),
+			"/manifests/1.0.0.yaml":          coreManifest(host, "1.0.0", ""),
+			"/manifests/1.1.0.yaml":          coreManifest(host, "1.1.0", "1.0.0"),
+			"/manifests/1.2.0.yaml":          coreManifest(host, "1.2.0", "1.1.0"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		cliCmd = &cli.Command{
+			Metadata: map[string]any{
+				"system":            s,
+				printer.MetadataKey: printer.New(printer.YAML, out),
+			},
+		}
+		buildInfo := fmt.Sprintf("releaseManifest: %s\nreleaseVersion: 1.0.0\n", manifestURI("/manifests/1.1.0.yaml"))
+		Expect(tfs.WriteFile("/etc/elemental/build-info.yaml", []byte(buildInfo), vfs.FilePerm)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		srv.Close()
+		cleanup()
+	})
+
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.CheckUpgrade(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+
+	It("fails if the deployment file does not exist", func() {
+		Expect(tfs.Remove("/etc/elemental/deployment.yaml")).To(Succeed())
+		Expect(checkUpgrade()).To(MatchError("deployment not found"))
+	})
+
+	It("reports the OS image of the release manifest the image was built from", func() {
+		Expect(checkUpgrade()).To(Succeed())
+		Expect(result["available"]).To(BeTrue())
+		Expect(result["installed"]).To(HaveKeyWithValue("digest", installedDigest))
+		Expect(result["installed"]).To(HaveKeyWithValue("version", "1.0.0"))
+		Expect(upgrades()).To(HaveLen(1))
+		Expect(upgrades()[0]).To(HaveKeyWithValue("image", host+"/os:1.1.0"))
+		Expect(upgrades()[0]).To(HaveKeyWithValue("digest", upgradeDigest))
+		Expect(upgrades()[0]).To(HaveKeyWithValue("version", "1.1.0"))
+		Expect(upgrades()[0]).NotTo(HaveKey("unsupported"))
+	})
+
+	It("reports no upgrades if the release manifest ships the installed OS image", func() {
+		cmd.CheckUpgradeArgs.ReleaseManifest = manifestURI("/manifests/1.0.0.yaml")
+		Expect(checkUpgrade()).To(Succeed())
+		Expect(result["available"]).To(BeFalse())
+		Expect(upgrades()).To(BeEmpty())
+	})
+
+	It("reports no upgrades if the installed OS image reference is unchanged", func() {
+		Expect(tfs.Remove("/etc/elemental/build-info.yaml")).To(Succeed())
+		Expect(checkUpgrade()).To(Succeed())
+		Expect(result["available"]).To(BeFalse())
+		Expect(upgrades()).To(BeEmpty())
+
+		pushOSImage(host, "1.0.0-patched")
+		Expect(tfs.WriteFile("/etc/elemental/deployment.yaml", []byte(fmt.Sprintf(
+			"sourceOS:\n  uri: oci://%s/os:1.0.0-patched\n  digest: %s\n", host, installedDigest,
+		)), vfs.FilePerm)).To(Succeed())
+		Expect(checkUpgrade()).To(Succeed())
+		Expect(result["available"]).To(BeTrue())
+		Expect(upgrades()).To(HaveLen(1))
+		Expect(upgrades()[0]).To(HaveKeyWithValue("image", host+"/os:1.0.0-patched"))
+	})
+
+	It("flags unsupported skip-level upgrades of the release manifest", func() {
+		cmd.CheckUpgradeArgs.ReleaseManifest = manifestURI("/manifests/1.2.0.yaml")
+		Expect(checkUpgrade()).To(Succeed())
+		Expect(result["available"]).To(BeFalse())
+		Expect(upgrades()).To(HaveLen(1))
+		Expect(upgrades()[0]).To(HaveKeyWithValue("unsupported", ContainSubstring("requires at least '1.1.0'")))
+	})
+
+	It("reports the upgrade path to the release of a channel", func() {
+		index := fmt.Sprintf("releases:\n- version: 1.1.0\n  manifest: %s\n- version: 1.2.0\n  manifest: %s\n  minUpgradeVersion: 1.1.0\n",
+			manifestURI("/manifests/1.1.0.yaml"), manifestURI("/manifests/1.2.0.yaml"))
+		Expect(tfs.WriteFile("/etc/elemental/channels.yaml", []byte(index), vfs.FilePerm)).To(Succeed())
+		cmd.CheckUpgradeArgs.ChannelIndex = "/etc/elemental/channels.yaml"
+
+		Expect(checkUpgrade()).To(Succeed())
+		Expect(result["available"]).To(BeTrue())
+		Expect(upgrades()).To(HaveLen(2))
+		Expect(upgrades()[0]).To(HaveKeyWithValue("digest", upgradeDigest))
+		Expect(upgrades()[1]).To(HaveKeyWithValue("digest", nextDigest))
+		Expect(upgrades()[1]).To(HaveKeyWithValue("version", "1.2.0"))
+
+		cmd.CheckUpgradeArgs.Channel = "2.0.0"
+		Expect(checkUpgrade()).To(MatchError(ContainSubstring("resolving release '2.0.0'")))
+	})
+
+	It("requires the installed release version to use a channel index", func() {
+		Expect(tfs.Remove("/etc/elemental/build-info.yaml")).To(Succeed())
+		cmd.CheckUpgradeArgs.ChannelIndex = "/etc/elemental/channels.yaml"
+		Expect(checkUpgrade()).To(MatchError(ContainSubstring("installed release version is unknown")))
+	})
+
+	It("prints a text report", func() {
+		cliCmd.Metadata[printer.MetadataKey] = printer.New(printer.Text, out)
+		Expect(action.CheckUpgrade(context.Background(), cliCmd)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("Installed: " + host + "/os:1.0.0 (" + installedDigest + "), release 1.0.0"))
+		Expect(out.String()).To(MatchRegexp(`1\.1\.0 +%s/os:1\.1\.0 +%s`, host, upgradeDigest))
+	})
+})
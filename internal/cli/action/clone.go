@@ -0,0 +1,139 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/install"
+	"github.com/suse/elemental/v3/pkg/requirements"
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/transaction"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+type cloneResult struct {
+	Device   string `yaml:"device"`
+	Snapshot int    `yaml:"snapshot"`
+}
+
+func Clone(ctx context.Context, cmd *cli.Command) (err error) {
+	var s *sys.System
+	args := &cmdpkg.CloneArgs
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s = cmd.Root().Metadata["system"].(*sys.System)
+
+	s.Logger().Info("Starting clone action with args: %+v", args)
+
+	d, err := deployment.Parse(s, "/")
+	if err != nil {
+		return fmt.Errorf("parsing deployment: %w", err)
+	} else if d == nil {
+		return fmt.Errorf("deployment not found")
+	}
+
+	err = checkRequirements(s, "clone", requirements.DeploymentFeatures(d)...)
+	if err != nil {
+		return err
+	}
+
+	snaps, err := snapper.New(s).ListSnapshots("/", "root")
+	if err != nil {
+		return fmt.Errorf("listing snapshots: %w", err)
+	}
+	snapshotID := snaps.GetActive()
+
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	srcT := transaction.NewSnapper(ctxCancel, s)
+	_, err = srcT.Init(*d)
+	if err != nil {
+		return fmt.Errorf("initializing source transaction: %w", err)
+	}
+	snapRoot, err := srcT.MountSnapshot(snapshotID, cleanup)
+	if err != nil {
+		return fmt.Errorf("mounting active snapshot: %w", err)
+	}
+
+	clone, err := install.CloneDeployment(d, args.Target, snapRoot)
+	if err != nil {
+		return err
+	}
+	err = clone.Sanitize(s)
+	if err != nil {
+		return fmt.Errorf("inconsistent clone deployment: %w", err)
+	}
+
+	installer, err := initCloner(ctxCancel, s, clone)
+	if err != nil {
+		return fmt.Errorf("initiating installer components: %w", err)
+	}
+
+	err = installer.Clone(d, clone)
+	if err != nil {
+		s.Logger().Error("Clone failed")
+		return err
+	}
+
+	s.Logger().Info("Clone complete")
+
+	return printer.FromCommand(cmd).Print(cloneResult{Device: args.Target, Snapshot: snapshotID}, nil)
+}
+
+func initCloner(ctx context.Context, s *sys.System, d *deployment.Deployment) (*install.Installer, error) {
+	bootloader, err := bootloader.New(ctx, d.BootConfig.Bootloader, s)
+	if err != nil {
+		s.Logger().Error("Parsing boot config failed")
+		return nil, err
+	}
+
+	snapshotter, err := transaction.New(ctx, s, d, d.Snapshotter.Name)
+	if err != nil {
+		s.Logger().Error("Parsing snapshotter config failed")
+		return nil, err
+	}
+
+	upgrader := upgrade.New(
+		ctx, s, upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithBootloader(bootloader),
+		upgrade.WithSnapshotter(snapshotter),
+	)
+	return install.New(ctx, s, install.WithUpgrader(upgrader), install.WithBootloader(bootloader)), nil
+}
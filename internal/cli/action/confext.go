@@ -0,0 +1,133 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/extensions"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// ConfextActions are the actions of the confext subcommands
+var ConfextActions = cmdpkg.ConfextActions{
+	List:   ConfextList,
+	Apply:  ConfextApply,
+	Remove: ConfextRemove,
+}
+
+type confextResult struct {
+	Name  string `yaml:"name"`
+	Image string `yaml:"image"`
+}
+
+func ConfextList(_ context.Context, cmd *cli.Command) error {
+	s, err := confextSystem(cmd)
+	if err != nil {
+		return err
+	}
+
+	names, err := extensions.ListConfexts(s)
+	if err != nil {
+		return err
+	}
+
+	result := make([]confextResult, len(names))
+	for i, name := range names {
+		result[i] = confextResult{Name: name, Image: filepath.Join(extensions.ConfextsDir, name+".raw")}
+	}
+
+	return printer.FromCommand(cmd).Print(result, func(out io.Writer) error {
+		for _, name := range names {
+			fmt.Fprintln(out, name)
+		}
+		return nil
+	})
+}
+
+func ConfextApply(ctx context.Context, cmd *cli.Command) error {
+	args := &cmdpkg.ConfextArgs
+	s, err := confextSystem(cmd)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Args().Len() != 1 {
+		return fmt.Errorf("refer usage: %s", cmd.UsageText)
+	}
+	image := cmd.Args().First()
+
+	name := args.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(image), ".raw")
+	}
+	if err = extensions.ValidateConfextName(name); err != nil {
+		return err
+	}
+
+	s.Logger().Info("Applying confext '%s' from %s", name, image)
+	if err = extensions.InstallConfext(ctx, s, name, image); err != nil {
+		return fmt.Errorf("applying confext '%s': %w", name, err)
+	}
+	return nil
+}
+
+func ConfextRemove(_ context.Context, cmd *cli.Command) error {
+	s, err := confextSystem(cmd)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Args().Len() != 1 {
+		return fmt.Errorf("refer usage: %s", cmd.UsageText)
+	}
+	name := cmd.Args().First()
+	if err = extensions.ValidateConfextName(name); err != nil {
+		return err
+	}
+	return extensions.RemoveConfext(s, name)
+}
+
+// confextSystem returns the system of the command once the host requirements are verified
+func confextSystem(cmd *cli.Command) (*sys.System, error) {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return nil, fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	if err := checkRequirements(s, "confext"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// validateConfextSigningFlags checks the key and the certificate signing the confext images are set together
+func validateConfextSigningFlags(flags cmdpkg.ConfextSigningFlags) error {
+	if (flags.Key == "") != (flags.Certificate == "") {
+		return fmt.Errorf("both a key and a certificate are required")
+	}
+	return nil
+}
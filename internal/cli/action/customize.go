@@ -29,13 +29,16 @@ import (
 	"github.com/urfave/cli/v3"
 
 	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
 	"github.com/suse/elemental/v3/internal/config"
 	v0 "github.com/suse/elemental/v3/internal/config/v0"
 	"github.com/suse/elemental/v3/internal/customize"
 	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/cache"
 	"github.com/suse/elemental/v3/pkg/extractor"
 	"github.com/suse/elemental/v3/pkg/helm"
 	"github.com/suse/elemental/v3/pkg/http"
+	"github.com/suse/elemental/v3/pkg/registry"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/platform"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -50,6 +53,21 @@ func Customize(ctx context.Context, cmd *cli.Command) error {
 	fs := system.FS()
 	args := &cmdpkg.CustomizeArgs
 
+	if args.NameTemplate != "" && args.OutputPath != "" {
+		if isDir, _ := vfs.IsDir(fs, args.OutputPath); !isDir {
+			logger.Error("Output path %s is not a directory", args.OutputPath)
+			return fmt.Errorf("name template requires the output path to be a directory")
+		}
+	}
+
+	if err := validateSigningFlags(args.Signing); err != nil {
+		return fmt.Errorf("invalid signing flags: %w", err)
+	}
+
+	if err := validateConfextSigningFlags(args.ConfextSigning); err != nil {
+		return fmt.Errorf("invalid confext signing flags: %w", err)
+	}
+
 	imagePath, configPath := resolveOutputPaths(fs, args)
 	if imagePathExists, err := vfs.Exists(fs, imagePath); err == nil && imagePathExists {
 		logger.Error("Output image path %s already exists, will not overwrite", imagePath)
@@ -77,10 +95,19 @@ func Customize(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	features := configurationFeatures(def.Configuration, args.Signing, args.ConfextSigning)
+	if args.ImageList != "" || args.PreloadImages || args.VendorCharts {
+		// Helm charts are pulled, and rendered to list the images they reference
+		features = append(features, "helm")
+	}
+	if err = checkRequirements(system, "customize", features...); err != nil {
+		return err
+	}
+
 	ctxCancel, cancelFunc := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
 	defer cancelFunc()
 
-	customizeRunner, err := setupCustomizeRunner(ctxCancel, system, args, output)
+	customizeRunner, err := setupCustomizeRunner(ctxCancel, system, args, def, output, cmdpkg.RegistryConfig(cmd))
 	if err != nil {
 		logger.Error("Setting up customization runner failed")
 		return err
@@ -91,12 +118,27 @@ func Customize(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	return nil
+	if err = publishArtifacts(ctxCancel, system, args.Signing, def.Image.OutputImageName); err != nil {
+		return err
+	}
+
+	result := imageResult{
+		Image:    def.Image.OutputImageName,
+		Type:     def.Image.ImageType,
+		Platform: def.Image.Platform.String(),
+		Config:   configPath,
+		Images:   args.ImageList,
+	}
+	return printer.FromCommand(cmd).Print(result, nil)
 }
 
 func resolveOutputPaths(fs vfs.FS, args *cmdpkg.CustomizeFlags) (imagePath, configPath string) {
 	imagePath = args.OutputPath
-	imageName := fmt.Sprintf("image-%s.%s", time.Now().UTC().Format("2006-01-02T15-04-05"), args.MediaType)
+	defaultName := fmt.Sprintf("image-%s.%s", time.Now().UTC().Format("2006-01-02T15-04-05"), args.MediaType)
+	imageName := defaultName
+	if args.NameTemplate != "" {
+		imageName = args.NameTemplate
+	}
 
 	if imagePath == "" {
 		imagePath = filepath.Join(args.ConfigDir, imageName)
@@ -106,6 +148,10 @@ func resolveOutputPaths(fs vfs.FS, args *cmdpkg.CustomizeFlags) (imagePath, conf
 
 	if args.Mode == "split" {
 		imagePathBase := filepath.Base(imagePath)
+		if image.IsNameTemplate(imagePathBase) {
+			// the image name is only known once the release manifest is resolved
+			imagePathBase = defaultName
+		}
 		baseName := strings.TrimSuffix(imagePathBase, filepath.Ext(imagePathBase))
 
 		configPath = filepath.Join(filepath.Dir(imagePath), baseName+"-config")
@@ -118,21 +164,39 @@ func setupCustomizeRunner(
 	ctx context.Context,
 	s *sys.System,
 	args *cmdpkg.CustomizeFlags,
+	def *image.Definition,
 	output config.Output,
+	reg *registry.Config,
 ) (*customize.Runner, error) {
-	extr, err := setupFileExtractor(ctx, s, output, args.Local)
+	buildCache, err := openCache(s, args.CacheDir, reg)
+	if err != nil {
+		return nil, err
+	}
+
+	extr, err := setupFileExtractor(ctx, s, output, args.Local, reg, buildCache)
 	if err != nil {
 		return nil, fmt.Errorf("setting up file extractor: %w", err)
 	}
 
+	opts := append([]config.Opts{
+		config.WithImageList(args.ImageList),
+		config.WithVendoredHelmCharts(args.VendorCharts),
+		config.WithConfextSigning(args.ConfextSigning.Key, args.ConfextSigning.Certificate),
+	}, cacheOpts(buildCache)...)
+	if args.PreloadImages {
+		opts = append(opts, config.WithImagePreload(def.Image.Platform))
+	}
+
 	return &customize.Runner{
 		System:        s,
-		ConfigManager: setupConfigManager(s, args.ConfigDir, output, args.Local),
+		ConfigManager: setupConfigManager(s, args.ConfigDir, output, args.Local, reg, opts...),
 		FileExtractor: extr,
 	}, nil
 }
 
-func setupConfigManager(s *sys.System, configDir string, output config.Output, local bool) *config.Manager {
+func setupConfigManager(
+	s *sys.System, configDir string, output config.Output, local bool, reg *registry.Config, opts ...config.Opts,
+) *config.Manager {
 	valuesResolver := &helm.ValuesResolver{
 		FS:        s.FS(),
 		ValuesDir: v0.Dir(configDir).HelmValuesDir(),
@@ -141,12 +205,18 @@ func setupConfigManager(s *sys.System, configDir string, output config.Output, l
 	return config.NewManager(
 		s,
 		config.NewHelm(s.FS(), valuesResolver, s.Logger(), output.OverlaysDir()),
-		config.WithDownloadFunc(http.DownloadFile),
-		config.WithLocal(local),
+		append([]config.Opts{
+			config.WithDownloadFunc(http.Fetch),
+			config.WithLocal(local),
+			config.WithRegistryConfig(reg),
+			config.WithBuildInfo(configDir, cmdpkg.Version()),
+		}, opts...)...,
 	)
 }
 
-func setupFileExtractor(ctx context.Context, s *sys.System, outDir config.Output, local bool) (extr *extractor.OCIFileExtractor, err error) {
+func setupFileExtractor(
+	ctx context.Context, s *sys.System, outDir config.Output, local bool, reg *registry.Config, c *cache.Cache,
+) (extr *extractor.OCIFileExtractor, err error) {
 	const isoSearchGlob = "/iso/*default-iso*.iso"
 
 	if err := vfs.MkdirAll(s.FS(), outDir.ISOStoreDir(), vfs.DirPerm); err != nil {
@@ -159,6 +229,8 @@ func setupFileExtractor(ctx context.Context, s *sys.System, outDir config.Output
 		extractor.WithFS(s.FS()),
 		extractor.WithContext(ctx),
 		extractor.WithLocal(local),
+		extractor.WithRegistryConfig(reg),
+		extractor.WithCache(c),
 	)
 }
 
@@ -172,6 +244,9 @@ func digestCustomizeDefinition(f vfs.FS, args *cmdpkg.CustomizeFlags, imagePath
 	if err != nil {
 		return nil, fmt.Errorf("parsing configuration directory %s: %w", args.ConfigDir, err)
 	}
+	if args.ManifestURI != "" {
+		conf.Release.ManifestURI = args.ManifestURI
+	}
 
 	return &image.Definition{
 		Image: image.Image{
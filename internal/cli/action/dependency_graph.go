@@ -0,0 +1,60 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/internal/config"
+	v0 "github.com/suse/elemental/v3/internal/config/v0"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+func DependencyGraph(_ context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	system := cmd.Root().Metadata["system"].(*sys.System)
+	args := &cmdpkg.DependencyGraphArgs
+
+	conf, err := v0.Parse(system.FS(), v0.Dir(args.ConfigDir))
+	if err != nil {
+		system.Logger().Error("Parsing image configuration failed")
+		return err
+	}
+
+	rm, err := resolveManifest(system, conf.Release.ManifestURI, args.Local, cmdpkg.RegistryConfig(cmd))
+	if err != nil {
+		return fmt.Errorf("resolving release manifest at uri '%s': %w", conf.Release.ManifestURI, err)
+	}
+
+	graph, err := config.NewDependencyGraph(rm, conf)
+	if err != nil {
+		return err
+	}
+
+	return printer.FromCommand(cmd).Print(graph, func(out io.Writer) error {
+		return graph.WriteDOT(out)
+	})
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+func Disks(_ context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+	args := &cmdpkg.DisksArgs
+
+	disks, err := block.DiscoverDisks(s)
+	if err != nil {
+		s.Logger().Error("Discovering disks failed")
+		return err
+	}
+
+	p := printer.FromCommand(cmd)
+	if args.JSON {
+		p = printer.New(printer.JSON, p.Writer())
+	}
+	return p.Print(disks, func(out io.Writer) error {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PATH\tSIZE\tTYPE\tTABLE\tMODEL\tIN USE")
+		for _, d := range disks {
+			kind, table, inUse := "ssd", d.PartitionTable, "no"
+			if d.Rotational {
+				kind = "hdd"
+			}
+			if table == "" {
+				table = "-"
+			}
+			if d.InUse() {
+				inUse = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%dMiB\t%s\t%s\t%s\t%s\n", d.Path, d.Size>>20, kind, table, d.Model, inUse)
+		}
+		return w.Flush()
+	})
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+)
+
+const disksLsblk = `{"blockdevices": [
+  {"path": "/dev/sda", "type": "disk", "size": 480103981056, "model": "SAMSUNG MZ7LH480", "rota": false, "pttype": "gpt",
+   "children": [{"path": "/dev/sda1", "type": "part", "size": 536870912, "mountpoints": ["/boot/efi"]}]},
+  {"path": "/dev/sdb", "type": "disk", "size": 4000787030016, "model": "ST4000NM0035", "rota": true}
+]}`
+
+var _ = Describe("Disks action", Label("disks"), func() {
+	var cliCmd *cli.Command
+	var buffer *bytes.Buffer
+	var cleanup func()
+
+	BeforeEach(func() {
+		cmd.DisksArgs = cmd.DisksFlags{}
+		buffer = &bytes.Buffer{}
+		runner := sysmock.NewRunner()
+		runner.SideEffect = func(command string, _ ...string) ([]byte, error) {
+			if command == "lsblk" {
+				return []byte(disksLsblk), nil
+			}
+			return []byte{}, nil
+		}
+		tfs, c, err := sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		cleanup = c
+		s, err := sys.NewSystem(sys.WithFS(tfs), sys.WithRunner(runner), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+		cliCmd = &cli.Command{Metadata: map[string]any{"system": s}, Writer: buffer}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.Disks(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+	It("prints a table of the disks", func() {
+		Expect(action.Disks(context.Background(), cliCmd)).To(Succeed())
+		Expect(buffer.String()).To(MatchRegexp(`/dev/sda\s+457862MiB\s+ssd\s+gpt\s+SAMSUNG MZ7LH480\s+yes`))
+		Expect(buffer.String()).To(MatchRegexp(`/dev/sdb\s+3815447MiB\s+hdd\s+-\s+ST4000NM0035\s+no`))
+	})
+	It("prints the disks in JSON format", func() {
+		cmd.DisksArgs.JSON = true
+		Expect(action.Disks(context.Background(), cliCmd)).To(Succeed())
+		disks := []map[string]any{}
+		Expect(json.Unmarshal(buffer.Bytes(), &disks)).To(Succeed())
+		Expect(disks).To(HaveLen(2))
+		Expect(disks[0]["path"]).To(Equal("/dev/sda"))
+		Expect(disks[0]["usage"]).To(ConsistOf("/dev/sda1 is mounted at /boot/efi"))
+		Expect(disks[1]["rotational"]).To(BeTrue())
+	})
+})
@@ -0,0 +1,115 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	v0 "github.com/suse/elemental/v3/internal/config/v0"
+	"github.com/suse/elemental/v3/internal/doctor"
+	"github.com/suse/elemental/v3/pkg/manifest/source"
+	"github.com/suse/elemental/v3/pkg/registry"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+func Doctor(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+	args := &cmdpkg.DoctorArgs
+
+	registries, err := doctorRegistries(s, args, cmdpkg.RegistryConfig(cmd))
+	if err != nil {
+		s.Logger().Error("Collecting the registries to check failed")
+		return err
+	}
+
+	s.Logger().Info("Checking host environment")
+	report, err := doctor.New(
+		s, doctor.WithOperations(args.Operations...), doctor.WithRegistries(registries...),
+		doctor.WithWorkDir(args.WorkDir), doctor.WithMinFreeSpace(uint64(args.MinFreeSpace)),
+	).Run(ctx)
+	if err != nil {
+		s.Logger().Error("Checking host environment failed")
+		return err
+	}
+
+	err = printer.FromCommand(cmd).Print(report, func(out io.Writer) error {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, c := range report.Checks {
+			fmt.Fprintf(w, "[%s]\t%s\t%s\n", c.Status, c.Name, c.Detail)
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	if report.Failed() {
+		return fmt.Errorf("host environment checks failed")
+	}
+	return nil
+}
+
+// doctorRegistries returns the registry hosts to check, the given ones, the registry of the release manifest
+// of the configuration directory, if any, and their mirrors
+func doctorRegistries(s *sys.System, args *cmdpkg.DoctorFlags, regConf *registry.Config) ([]string, error) {
+	registries := slices.Clone(args.Registries)
+
+	if args.ConfigDir != "" {
+		conf, err := v0.Parse(s.FS(), v0.Dir(args.ConfigDir))
+		if err != nil {
+			return nil, fmt.Errorf("parsing configuration directory %s: %w", args.ConfigDir, err)
+		}
+		src, err := source.ParseFromURI(conf.Release.ManifestURI)
+		if err != nil {
+			return nil, err
+		}
+		if src.Type() == source.OCI {
+			ref, err := name.ParseReference(src.URI())
+			if err != nil {
+				return nil, err
+			}
+			registries = append(registries, ref.Context().RegistryStr())
+		}
+	}
+
+	if regConf != nil {
+		for _, reg := range slices.Clone(registries) {
+			for _, mirror := range regConf.Mirrors[reg] {
+				// Mirrors can include a repository prefix
+				host, _, _ := strings.Cut(mirror, "/")
+				registries = append(registries, host)
+			}
+		}
+	}
+
+	slices.Sort(registries)
+	return slices.Compact(registries), nil
+}
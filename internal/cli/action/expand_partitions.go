@@ -0,0 +1,110 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+type expandPartitionsResult struct {
+	Disks []string `yaml:"disks"`
+}
+
+func ExpandPartitions(_ context.Context, cmd *cli.Command) error {
+	var s *sys.System
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s = cmd.Root().Metadata["system"].(*sys.System)
+
+	s.Logger().Info("Starting expand-partitions action")
+
+	d, err := deployment.Parse(s, "/")
+	if err != nil {
+		s.Logger().Error("Failed to parse the deployment")
+		return fmt.Errorf("parsing deployment: %w", err)
+	} else if d == nil {
+		return fmt.Errorf("deployment not found")
+	}
+
+	err = setDiskDevices(s, d)
+	if err != nil {
+		s.Logger().Error("Failed to find the deployment disks")
+		return err
+	}
+
+	result := expandPartitionsResult{Disks: []string{}}
+	for _, disk := range d.Disks {
+		if !disk.ExpandPartitions {
+			continue
+		}
+		err = repart.ExpandDevicePartitions(s, disk)
+		if err != nil {
+			s.Logger().Error("Expanding partitions of disk '%s' failed", disk.Device)
+			return err
+		}
+		result.Disks = append(result.Disks, disk.Device)
+	}
+
+	if len(result.Disks) == 0 {
+		s.Logger().Info("No disk set to expand partitions")
+	} else {
+		s.Logger().Info("Partitions expansion completed")
+	}
+
+	return printer.FromCommand(cmd).Print(result, nil)
+}
+
+// setDiskDevices sets the device of the disks of the given deployment parsed from the running system,
+// as devices are not stored. Each disk is found from the partition UUIDs of its partitions.
+func setDiskDevices(s *sys.System, d *deployment.Deployment) error {
+	parts, err := lsblk.NewLsDevice(s).GetAllPartitions()
+	if err != nil {
+		return fmt.Errorf("listing partitions: %w", err)
+	}
+
+	for i, disk := range d.Disks {
+		if disk.Device != "" {
+			continue
+		}
+		var bPart *block.Partition
+		for _, part := range disk.Partitions {
+			if part.UUID == "" {
+				continue
+			}
+			if bPart = parts.GetByUUID(part.UUID); bPart != nil {
+				break
+			}
+		}
+		if bPart == nil {
+			return fmt.Errorf("no device found for disk %d", i)
+		}
+		disk.Device = bPart.Disk
+	}
+	return nil
+}
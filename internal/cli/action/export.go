@@ -0,0 +1,93 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/export"
+	"github.com/suse/elemental/v3/pkg/requirements"
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+type exportResult struct {
+	Snapshot int    `yaml:"snapshot"`
+	Format   string `yaml:"format"`
+	Output   string `yaml:"output"`
+}
+
+func Export(ctx context.Context, cmd *cli.Command) error {
+	var s *sys.System
+	args := &cmdpkg.ExportArgs
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s = cmd.Root().Metadata["system"].(*sys.System)
+
+	s.Logger().Info("Starting export action with args: %+v", args)
+
+	d, err := deployment.Parse(s, "/")
+	if err != nil {
+		return fmt.Errorf("parsing deployment: %w", err)
+	} else if d == nil {
+		return fmt.Errorf("deployment not found")
+	}
+
+	err = checkRequirements(s, "export", requirements.DeploymentFeatures(d)...)
+	if err != nil {
+		return err
+	}
+
+	snapshotID := args.SnapshotID
+	if snapshotID <= 0 {
+		snaps, err := snapper.New(s).ListSnapshots("/", "root")
+		if err != nil {
+			return fmt.Errorf("listing snapshots: %w", err)
+		}
+		snapshotID = snaps.GetActive()
+	}
+
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	exporter := export.New(ctxCancel, s, export.WithTag(args.Tag))
+	err = exporter.Export(d, snapshotID, export.Format(args.Format), args.Output)
+	if err != nil {
+		s.Logger().Error("Export failed")
+		return err
+	}
+
+	s.Logger().Info("Export completed")
+
+	result := exportResult{Snapshot: snapshotID, Format: args.Format, Output: args.Output}
+	return printer.FromCommand(cmd).Print(result, nil)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/internal/config"
+	v0 "github.com/suse/elemental/v3/internal/config/v0"
+	"github.com/suse/elemental/v3/pkg/cache"
+	"github.com/suse/elemental/v3/pkg/helm"
+	"github.com/suse/elemental/v3/pkg/http"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
+)
+
+type fetchResult struct {
+	Directory string `yaml:"directory"`
+	OSImage   string `yaml:"osImage"`
+	Digest    string `yaml:"digest"`
+	Platform  string `yaml:"platform"`
+}
+
+// FetchArtifacts stores all the artifacts a build of the image configuration pulls from the network
+// into the air-gap directory. The image components are configured into a throwaway output, exactly
+// as the build does, with the air-gap directory as cache, then the OS image is stored. The helm charts
+// and the container images required by the cluster are stored too, since air-gapped clusters can't
+// pull them.
+func FetchArtifacts(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	system := cmd.Root().Metadata["system"].(*sys.System)
+	logger := system.Logger()
+	fs := system.FS()
+	args := &cmdpkg.FetchArtifactsArgs
+	reg := cmdpkg.RegistryConfig(cmd)
+
+	p, err := platform.Parse(args.Platform)
+	if err != nil {
+		return fmt.Errorf("malformed platform %q", args.Platform)
+	}
+
+	conf, err := config.Parse(fs, args.ConfigDir)
+	if err != nil {
+		logger.Error("Parsing image configuration failed")
+		return fmt.Errorf("parsing configuration directory %s: %w", args.ConfigDir, err)
+	}
+
+	if err = checkRequirements(system, "fetch-artifacts"); err != nil {
+		return err
+	}
+
+	airgap, err := cache.New(system, args.AirgapDir, cache.WithRegistryConfig(reg), cache.WithMaxAge(0), cache.WithFileStore())
+	if err != nil {
+		return fmt.Errorf("opening air-gap directory '%s': %w", args.AirgapDir, err)
+	}
+
+	workDir, err := vfs.TempDir(fs, "", "fetch-artifacts-")
+	if err != nil {
+		return fmt.Errorf("creating working directory: %w", err)
+	}
+	defer func() {
+		_ = vfs.ForceRemoveAll(fs, workDir)
+	}()
+
+	output, err := config.NewOutput(fs, workDir, "")
+	if err != nil {
+		return fmt.Errorf("creating working directory: %w", err)
+	}
+
+	ctxCancel, cancelFunc := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer cancelFunc()
+
+	valuesResolver := &helm.ValuesResolver{
+		FS:        fs,
+		ValuesDir: v0.Dir(args.ConfigDir).HelmValuesDir(),
+	}
+	configManager := config.NewManager(
+		system,
+		config.NewHelm(fs, valuesResolver, logger, output.OverlaysDir()),
+		config.WithDownloadFunc(http.Fetch),
+		config.WithRegistryConfig(reg),
+		config.WithBuildInfo(args.ConfigDir, cmdpkg.Version()),
+		config.WithCache(airgap),
+		config.WithFetchOnly(),
+		config.WithImagePreload(p),
+	)
+
+	logger.Info("Fetching the artifacts of the image components")
+	rm, err := configManager.ConfigureComponents(ctxCancel, conf, output)
+	if err != nil {
+		logger.Error("Fetching the artifacts of the image components failed")
+		return err
+	}
+
+	osImage := rm.CorePlatform.Components.OperatingSystem.Image.Base
+	logger.Info("Fetching OS image %s", osImage)
+	_, digest, err := airgap.Store(ctxCancel, osImage, p.String(), func(ctx context.Context, dest string) (string, error) {
+		unpacker := unpack.NewOCIUnpacker(system, osImage, unpack.WithRegistryConfigOCI(reg), unpack.WithPlatformRefOCI(p.String()))
+		return unpacker.Unpack(ctx, dest)
+	})
+	if err != nil {
+		logger.Error("Fetching OS image failed")
+		return err
+	}
+
+	return printer.FromCommand(cmd).Print(fetchResult{
+		Directory: args.AirgapDir,
+		OSImage:   osImage,
+		Digest:    digest,
+		Platform:  p.String(),
+	}, nil)
+}
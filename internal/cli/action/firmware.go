@@ -0,0 +1,124 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// FirmwareActions are the actions of the firmware subcommands
+var FirmwareActions = cmdpkg.FirmwareActions{
+	List:      FirmwareList,
+	Prune:     FirmwarePrune,
+	BootOrder: FirmwareBootOrder,
+	BootNext:  FirmwareBootNext,
+}
+
+func FirmwareList(_ context.Context, cmd *cli.Command) error {
+	manager, err := firmwareManager(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := manager.BootConfig()
+	if err != nil {
+		return err
+	}
+
+	return printer.FromCommand(cmd).Print(cfg, func(out io.Writer) error {
+		fmt.Fprintf(out, "BootCurrent: %s\n", cfg.Current)
+		if cfg.Next != "" {
+			fmt.Fprintf(out, "BootNext: %s\n", cfg.Next)
+		}
+		fmt.Fprintf(out, "BootOrder: %s\n", strings.Join(cfg.Order, ","))
+		for _, entry := range cfg.Entries {
+			active := " "
+			if entry.Active {
+				active = "*"
+			}
+			fmt.Fprintf(out, "Boot%s%s %s\t%s\n", entry.Number, active, entry.Label, entry.DevicePath)
+		}
+		return nil
+	})
+}
+
+func FirmwarePrune(_ context.Context, cmd *cli.Command) error {
+	manager, err := firmwareManager(cmd)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := manager.PruneBootEntries(cmdpkg.FirmwareArgs.Label)
+	if err != nil {
+		return err
+	}
+
+	return printer.FromCommand(cmd).Print(deleted, func(out io.Writer) error {
+		for _, entry := range deleted {
+			fmt.Fprintf(out, "Deleted Boot%s %s\n", entry.Number, entry.Label)
+		}
+		return nil
+	})
+}
+
+func FirmwareBootOrder(_ context.Context, cmd *cli.Command) error {
+	manager, err := firmwareManager(cmd)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Args().Len() == 0 {
+		return fmt.Errorf("refer usage: %s", cmd.UsageText)
+	}
+	return manager.SetBootOrder(cmd.Args().Slice())
+}
+
+func FirmwareBootNext(_ context.Context, cmd *cli.Command) error {
+	manager, err := firmwareManager(cmd)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Args().Len() != 1 {
+		return fmt.Errorf("refer usage: %s", cmd.UsageText)
+	}
+	return manager.SetBootNext(cmd.Args().First())
+}
+
+// firmwareManager returns the EFI boot manager once the host requirements are verified
+func firmwareManager(cmd *cli.Command) (*firmware.EfiBootManager, error) {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return nil, fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	if err := checkRequirements(s, "firmware"); err != nil {
+		return nil, err
+	}
+	return firmware.NewEfiBootManager(s), nil
+}
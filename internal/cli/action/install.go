@@ -20,20 +20,31 @@ package action
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
 	"syscall"
 
 	"github.com/urfave/cli/v3"
 	"go.yaml.in/yaml/v3"
 
 	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/i18n"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/block"
 	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/clevis"
 	"github.com/suse/elemental/v3/pkg/crypto"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/fips"
 	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/http"
 	"github.com/suse/elemental/v3/pkg/install"
 	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/registry"
+	"github.com/suse/elemental/v3/pkg/requirements"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/transaction"
@@ -41,6 +52,27 @@ import (
 	"github.com/suse/elemental/v3/pkg/upgrade"
 )
 
+const autoInstallPrefix = "elemental.install."
+
+// deploymentResult is the structured result of the actions deploying an OS image
+type deploymentResult struct {
+	Device      string                  `yaml:"device,omitempty"`
+	OS          *deployment.ImageSource `yaml:"os"`
+	Overlay     *deployment.ImageSource `yaml:"overlay,omitempty"`
+	Controllers []*block.Controller     `yaml:"controllers,omitempty"`
+}
+
+func newDeploymentResult(d *deployment.Deployment) deploymentResult {
+	result := deploymentResult{OS: d.SourceOS}
+	if disk := d.GetSystemDisk(); disk != nil {
+		result.Device = disk.Device
+	}
+	if d.OverlayTree != nil && !d.OverlayTree.IsEmpty() {
+		result.Overlay = d.OverlayTree
+	}
+	return result
+}
+
 func Install(ctx context.Context, cmd *cli.Command) error {
 	var s *sys.System
 	args := &cmdpkg.InstallArgs
@@ -52,12 +84,30 @@ func Install(ctx context.Context, cmd *cli.Command) error {
 	s.Logger().Info("Starting install action")
 	s.Logger().Debug("Install action called with args: %+v", args)
 
-	d, err := digestInstallSetup(s, args)
+	downloadDir, err := vfs.TempDir(s.FS(), "", "elemental-install")
+	if err != nil {
+		return fmt.Errorf("creating download directory: %w", err)
+	}
+	defer func() { _ = s.FS().RemoveAll(downloadDir) }()
+
+	d, err := digestInstallSetup(ctx, s, args, downloadDir)
 	if err != nil {
 		s.Logger().Error("Failed to collect installation setup")
 		return err
 	}
 
+	err = checkRequirements(s, "install", requirements.DeploymentFeatures(d)...)
+	if err != nil {
+		return err
+	}
+
+	controllers := checkDiskControllers(s, d)
+
+	err = confirmTargets(cmd, s, args.Yes, i18n.ConfirmInstall, targetDevices(d)...)
+	if err != nil {
+		return err
+	}
+
 	s.Logger().Info("Checked configuration, running installation process")
 
 	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
@@ -68,7 +118,7 @@ func Install(ctx context.Context, cmd *cli.Command) error {
 		stop()
 	}()
 
-	installer, err := initInstaller(ctxCancel, s, d, args)
+	installer, err := initInstaller(ctxCancel, s, d, args, cmdpkg.RegistryConfig(cmd))
 	if err != nil {
 		return fmt.Errorf("initiating installer components: %w", err)
 	}
@@ -81,11 +131,53 @@ func Install(ctx context.Context, cmd *cli.Command) error {
 
 	s.Logger().Info("Installation complete")
 
-	return nil
+	result := newDeploymentResult(d)
+	if len(controllers) > 0 {
+		result.Controllers = controllers
+	}
+	return printer.FromCommand(cmd).Print(result, nil)
+}
+
+// targetDevices returns the devices of all the disks of the deployment
+func targetDevices(d *deployment.Deployment) []string {
+	devices := make([]string, 0, len(d.Disks))
+	for _, disk := range d.Disks {
+		devices = append(devices, disk.Device)
+	}
+	return devices
+}
+
+// confirmTargets asks for the confirmation of a destructive action on the given devices, only the devices
+// holding a partition table or in use require it. Devices which can't be inspected are assumed to hold data.
+func confirmTargets(cmd *cli.Command, s *sys.System, yes bool, prompt string, devices ...string) error {
+	if yes {
+		return nil
+	}
+
+	disks, err := block.DiscoverDisks(s)
+	if err != nil {
+		s.Logger().Warn("Could not inspect target devices, assuming they hold data: %v", err)
+	}
+
+	targets := []string{}
+	for _, device := range devices {
+		i := slices.IndexFunc(disks, func(disk block.Disk) bool { return disk.Path == device })
+		if i >= 0 && disks[i].PartitionTable == "" && !disks[i].InUse() {
+			s.Logger().Debug("Device %s holds no partition table and is not in use, no confirmation required", device)
+			continue
+		}
+		targets = append(targets, device)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	return cmdpkg.ConfirmDestructive(cmd, yes, prompt, strings.Join(targets, " "))
 }
 
-func initInstaller(ctx context.Context, s *sys.System, d *deployment.Deployment, args *cmdpkg.InstallFlags) (*install.Installer, error) {
-	bootloader, err := bootloader.New(d.BootConfig.Bootloader, s)
+func initInstaller(
+	ctx context.Context, s *sys.System, d *deployment.Deployment, args *cmdpkg.InstallFlags, reg *registry.Config,
+) (*install.Installer, error) {
+	bootloader, err := bootloader.New(ctx, d.BootConfig.Bootloader, s)
 	if err != nil {
 		s.Logger().Error("Parsing boot config failed")
 		return nil, err
@@ -97,17 +189,24 @@ func initInstaller(ctx context.Context, s *sys.System, d *deployment.Deployment,
 		return nil, err
 	}
 
-	unpackOpts := []unpack.Opt{unpack.WithVerify(args.Verify), unpack.WithLocal(args.Local)}
+	unpackOpts := []unpack.Opt{
+		unpack.WithVerify(args.Verify), unpack.WithLocal(args.Local), unpack.WithConcurrency(args.Concurrency),
+		unpack.WithRegistryConfig(reg),
+	}
 	manager := firmware.NewEfiBootManager(s)
 	upgrader := upgrade.New(
 		ctx, s, upgrade.WithBootManager(manager), upgrade.WithBootloader(bootloader),
 		upgrade.WithSnapshotter(snapshotter),
+		upgrade.WithRegistryConfig(reg), upgrade.WithLocalImage(args.Local),
+		upgrade.WithSkipSignatureVerification(args.Signature.Skip),
 		upgrade.WithUnpackOpts(unpackOpts...),
+		upgrade.WithVolumeSealing(true),
 	)
 	installer := install.New(
 		ctx, s, install.WithUpgrader(upgrader),
 		install.WithUnpackOpts(unpackOpts...),
 		install.WithBootloader(bootloader),
+		install.WithForce(args.Force),
 	)
 	return installer, nil
 }
@@ -126,13 +225,27 @@ func loadDescriptionFile(s *sys.System, file string, d *deployment.Deployment) e
 	return nil
 }
 
+// defaultBootEntry returns the default EFI boot entry pointing to the EFI partition of the given
+// deployment, which is not necessarily on the system disk. Returns nil if there is no EFI partition.
+func defaultBootEntry(s *sys.System, d *deployment.Deployment) *firmware.EfiBootEntry {
+	disk := d.GetEfiDisk()
+	if disk == nil {
+		return nil
+	}
+	entry := firmware.DefaultBootEntry(s.Platform(), disk.Device)
+	// efibootmgr defaults to the first partition of the disk
+	for i, part := range disk.Partitions {
+		if part.Role == deployment.EFI && i > 0 {
+			entry.Part = i + 1
+		}
+	}
+	return entry
+}
+
 // setBootloader configures the bootloader for the given deployment with the given flags
 func setBootloader(s *sys.System, d *deployment.Deployment, bootloaderType, cmdline string, createEntry bool) {
-	disk := d.GetSystemDisk()
-	if createEntry && disk != nil {
-		d.Firmware.BootEntries = []*firmware.EfiBootEntry{
-			firmware.DefaultBootEntry(s.Platform(), disk.Device),
-		}
+	if entry := defaultBootEntry(s, d); createEntry && entry != nil {
+		d.Firmware.BootEntries = []*firmware.EfiBootEntry{entry}
 	}
 
 	if d.BootConfig == nil {
@@ -149,12 +262,147 @@ func setBootloader(s *sys.System, d *deployment.Deployment, bootloaderType, cmdl
 	if d.IsFipsEnabled() {
 		d.BootConfig.KernelCmdline = fips.AppendCommandLine(d.BootConfig.KernelCmdline)
 	}
+
+	if d.IsNetworkUnlockEnabled() {
+		d.BootConfig.KernelCmdline = clevis.AppendCommandLine(d.BootConfig.KernelCmdline, d.Security.NetworkUnlock)
+	}
+}
+
+// applySignatureFlags sets the signature verification of the given image source if any signature flag is given
+func applySignatureFlags(src *deployment.ImageSource, flags cmdpkg.SignatureFlags) {
+	if flags == (cmdpkg.SignatureFlags{Skip: flags.Skip}) {
+		return
+	}
+	src.VerifySignature = &deployment.SignatureVerification{
+		Tool:     flags.Tool,
+		Key:      flags.Key,
+		Identity: flags.Identity,
+		Issuer:   flags.Issuer,
+	}
+}
+
+// autoInstallFlags returns a copy of the given flags where the unset ones are defined by the
+// 'elemental.install.*' kernel arguments of the running system
+func autoInstallFlags(s *sys.System, flags cmdpkg.InstallFlags) (*cmdpkg.InstallFlags, error) {
+	cmdline, err := s.FS().ReadFile("/proc/cmdline")
+	if err != nil {
+		return nil, fmt.Errorf("reading kernel command line: %w", err)
+	}
+
+	params := map[string]*string{
+		"target":      &flags.Target,
+		"image":       &flags.OperatingSystemImage,
+		"description": &flags.Description,
+		"config":      &flags.ConfigScript,
+		"overlay":     &flags.Overlay,
+		"ignition":    &flags.Ignition,
+		"combustion":  &flags.Combustion,
+		"cloud-init":  &flags.CloudInit,
+	}
+	for _, arg := range strings.Fields(string(cmdline)) {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			continue
+		}
+		key, ok = strings.CutPrefix(key, autoInstallPrefix)
+		if !ok {
+			continue
+		}
+		flag, ok := params[key]
+		if !ok {
+			s.Logger().Warn("Ignoring unknown kernel argument '%s%s'", autoInstallPrefix, key)
+			continue
+		}
+		if *flag == "" {
+			*flag = strings.Trim(value, `"`)
+		}
+	}
+	return &flags, nil
+}
+
+// fetchRemoteFile downloads the given HTTP(S) URL to the given path and returns the path. A SHA256 checksum of
+// the file can be set as the URL fragment, e.g. 'https://host/install.yaml#sha256=<checksum>'. Any other URI is
+// returned unchanged.
+func fetchRemoteFile(ctx context.Context, s *sys.System, uri, path string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return uri, nil
+	}
+
+	u.Fragment = ""
+	s.Logger().Info("Downloading '%s'", u.String())
+	err = http.Fetch(ctx, s.FS(), uri, path)
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// fetchInstallFiles returns a copy of the given flags where the HTTP(S) URLs of the description file,
+// the configuration script and the overlay tarball are replaced by their downloaded files
+func fetchInstallFiles(ctx context.Context, s *sys.System, flags cmdpkg.InstallFlags, dir string) (*cmdpkg.InstallFlags, error) {
+	var err error
+
+	flags.Description, err = fetchRemoteFile(ctx, s, flags.Description, filepath.Join(dir, "install.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("fetching description file: %w", err)
+	}
+
+	script := filepath.Join(dir, "config.sh")
+	flags.ConfigScript, err = fetchRemoteFile(ctx, s, flags.ConfigScript, script)
+	if err != nil {
+		return nil, fmt.Errorf("fetching configuration script: %w", err)
+	}
+	if flags.ConfigScript == script {
+		err = s.FS().Chmod(script, 0700)
+		if err != nil {
+			return nil, fmt.Errorf("setting configuration script permissions: %w", err)
+		}
+	}
+
+	overlay := filepath.Join(dir, "overlay.tar")
+	flags.Overlay, err = fetchRemoteFile(ctx, s, flags.Overlay, overlay)
+	if err != nil {
+		return nil, fmt.Errorf("fetching overlay tarball: %w", err)
+	}
+	if flags.Overlay == overlay {
+		flags.Overlay = fmt.Sprintf("%s://%s", deployment.Tar, overlay)
+	}
+
+	flags.Ignition, err = fetchRemoteFile(ctx, s, flags.Ignition, filepath.Join(dir, "config.ign"))
+	if err != nil {
+		return nil, fmt.Errorf("fetching ignition configuration: %w", err)
+	}
+	flags.Combustion, err = fetchRemoteFile(ctx, s, flags.Combustion, filepath.Join(dir, "combustion"))
+	if err != nil {
+		return nil, fmt.Errorf("fetching combustion script: %w", err)
+	}
+	flags.CloudInit, err = fetchRemoteFile(ctx, s, flags.CloudInit, filepath.Join(dir, "user-data"))
+	if err != nil {
+		return nil, fmt.Errorf("fetching cloud-init user data: %w", err)
+	}
+
+	return &flags, nil
 }
 
-// digestInstallSetup produces the Deployment object required to describe the installation parameters
-func digestInstallSetup(s *sys.System, flags *cmdpkg.InstallFlags) (*deployment.Deployment, error) {
+// digestInstallSetup produces the Deployment object required to describe the installation parameters.
+// Remote files referenced by HTTP(S) URLs are downloaded to the given directory.
+func digestInstallSetup(ctx context.Context, s *sys.System, flags *cmdpkg.InstallFlags, downloadDir string) (*deployment.Deployment, error) {
 	d := deployment.DefaultDeployment()
 
+	if flags.Auto {
+		autoFlags, err := autoInstallFlags(s, *flags)
+		if err != nil {
+			return nil, err
+		}
+		flags = autoFlags
+	}
+
+	flags, err := fetchInstallFiles(ctx, s, *flags, downloadDir)
+	if err != nil {
+		return nil, err
+	}
+
 	// Given flags always have precedence compared to in-place configuration of live media
 	if flags.Description != "" {
 		err := loadDescriptionFile(s, flags.Description, d)
@@ -170,7 +418,7 @@ func digestInstallSetup(s *sys.System, flags *cmdpkg.InstallFlags) (*deployment.
 		}
 	}
 
-	err := applyInstallFlags(s, d, flags)
+	err = applyInstallFlags(s, d, flags)
 	if err != nil {
 		return nil, fmt.Errorf("defining the deployment details: %w", err)
 	}
@@ -183,14 +431,29 @@ func applyInstallFlags(s *sys.System, d *deployment.Deployment, flags *cmdpkg.In
 	if flags.Target != "" && disk != nil {
 		disk.Device = flags.Target
 	}
+	err := resolveDiskSelectors(s, d)
+	if err != nil {
+		return err
+	}
+	if flags.Wipe != "" {
+		for _, disk := range d.Disks {
+			disk.Wipe = deployment.WipePolicy(flags.Wipe)
+		}
+	}
 
 	if flags.OperatingSystemImage != "" {
 		srcOS, err := deployment.NewSrcFromURI(flags.OperatingSystemImage)
 		if err != nil {
 			return fmt.Errorf("failed parsing OS source URI ('%s'): %w", flags.OperatingSystemImage, err)
 		}
+		if d.SourceOS != nil {
+			srcOS.VerifySignature = d.SourceOS.VerifySignature
+		}
 		d.SourceOS = srcOS
 	}
+	if d.SourceOS != nil {
+		applySignatureFlags(d.SourceOS, flags.Signature)
+	}
 
 	if flags.Overlay != "" {
 		overlay, err := deployment.NewSrcFromURI(flags.Overlay)
@@ -204,6 +467,11 @@ func applyInstallFlags(s *sys.System, d *deployment.Deployment, flags *cmdpkg.In
 		d.CfgScript = flags.ConfigScript
 	}
 
+	err = applyFirstBootFlags(d, flags)
+	if err != nil {
+		return err
+	}
+
 	if flags.CryptoPolicy != "" {
 		cryptoPolicy := crypto.Policy(flags.CryptoPolicy)
 		if cryptoPolicy.IsValid() {
@@ -216,9 +484,12 @@ func applyInstallFlags(s *sys.System, d *deployment.Deployment, flags *cmdpkg.In
 	if flags.Snapshotter != "" {
 		d.Snapshotter.Name = flags.Snapshotter
 
-		if d.Snapshotter.Name == "overwrite" {
+		switch d.Snapshotter.Name {
+		case "overwrite":
 			s.Logger().Warn("'overwrite' snapshotter is a debugging tool and should not be used for production installation")
-
+			fallthrough
+		case "overlay":
+			// Neither of them relies on btrfs subvolumes
 			sysPart := d.GetSystemPartition()
 			if sysPart != nil {
 				sysPart.FileSystem = deployment.Ext4
@@ -227,9 +498,65 @@ func applyInstallFlags(s *sys.System, d *deployment.Deployment, flags *cmdpkg.In
 		}
 	}
 
-	err := d.Sanitize(s)
+	err = d.Sanitize(s)
 	if err != nil {
 		return fmt.Errorf("inconsistent deployment setup found: %w", err)
 	}
 	return nil
 }
+
+// applyFirstBootFlags sets the first boot configurations of the given deployment from the given flags,
+// relative paths are resolved from the current working directory. A config partition is added to hold
+// ignition and combustion configurations if the deployment has none.
+func applyFirstBootFlags(d *deployment.Deployment, flags *cmdpkg.InstallFlags) error {
+	if flags.Ignition == "" && flags.Combustion == "" && flags.CloudInit == "" {
+		return nil
+	}
+	if d.FirstBoot == nil {
+		d.FirstBoot = &deployment.FirstBootConfig{}
+	}
+	if (flags.Ignition != "" || flags.Combustion != "") && d.GetConfigPartition() == nil {
+		deployment.WithConfigPartition(0)(d)
+	}
+	for _, f := range []struct {
+		flag  string
+		value *string
+	}{
+		{flags.Ignition, &d.FirstBoot.Ignition},
+		{flags.Combustion, &d.FirstBoot.Combustion},
+		{flags.CloudInit, &d.FirstBoot.CloudInit},
+	} {
+		if f.flag == "" {
+			continue
+		}
+		path, err := filepath.Abs(f.flag)
+		if err != nil {
+			return fmt.Errorf("resolving path '%s': %w", f.flag, err)
+		}
+		*f.value = path
+	}
+	return nil
+}
+
+// resolveDiskSelectors replaces the disk selectors set as devices of the disks of the given deployment
+// by the paths of the disks they select. A disk is never selected twice.
+func resolveDiskSelectors(s *sys.System, d *deployment.Deployment) error {
+	selected := []string{}
+	for _, disk := range d.Disks {
+		if disk.Device != "" && !block.IsDiskSelector(disk.Device) {
+			selected = append(selected, disk.Device)
+		}
+	}
+	for _, disk := range d.Disks {
+		if !block.IsDiskSelector(disk.Device) {
+			continue
+		}
+		device, err := block.SelectDisk(s, disk.Device, selected...)
+		if err != nil {
+			return err
+		}
+		disk.Device = device
+		selected = append(selected, device)
+	}
+	return nil
+}
@@ -20,6 +20,10 @@ package action_test
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -109,4 +113,61 @@ var _ = Describe("Install action", Label("install"), func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("image source type not supported"))
 	})
+	It("fails if no disk matches the target selector", func() {
+		runner := sysmock.NewRunner()
+		runner.SideEffect = func(command string, _ ...string) ([]byte, error) {
+			if command == "lsblk" {
+				return []byte(`{"blockdevices": [{"path": "/dev/sda", "type": "disk", "size": 480103981056, "model": "SAMSUNG MZ7LH480"}]}`), nil
+			}
+			return []byte{}, nil
+		}
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithRunner(runner), sys.WithLogger(log.New(log.WithBuffer(buffer))))
+		Expect(err).NotTo(HaveOccurred())
+		cliCmd.Metadata["system"] = s
+
+		cmd.InstallArgs.Target = "model:INTEL*"
+		cmd.InstallArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		err = action.Install(context.Background(), cliCmd)
+		Expect(err).To(MatchError(ContainSubstring("selecting disk 'model:INTEL*': no disk matches the selector")))
+	})
+	It("downloads the description file from the given URL", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(badConfig))
+		}))
+		defer server.Close()
+		sum := sha256.Sum256([]byte(badConfig))
+
+		cmd.InstallArgs.Target = "/dev/device"
+		cmd.InstallArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		cmd.InstallArgs.Description = server.URL + "/install.yaml#sha256=" + hex.EncodeToString(sum[:])
+		err = action.Install(context.Background(), cliCmd)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("inconsistent deployment"))
+		Expect(buffer.String()).To(ContainSubstring("Loaded deployment description file"))
+	})
+	It("fails to download the description file with an unsupported checksum", func() {
+		cmd.InstallArgs.Target = "/dev/device"
+		cmd.InstallArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		cmd.InstallArgs.Description = "https://example.com/install.yaml#md5=0123"
+		err = action.Install(context.Background(), cliCmd)
+		Expect(err).To(MatchError(ContainSubstring("unsupported checksum 'md5=0123'")))
+	})
+	It("reads the installation parameters from the kernel command line", func() {
+		Expect(vfs.MkdirAll(tfs, "/proc", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile("/proc/cmdline", []byte(
+			"quiet elemental.install.target=/dev/device elemental.install.image=\"my.registry.org/my/image:test\" "+
+				"elemental.install.description=/configDir/bad_config.yaml elemental.install.unknown=value\n",
+		), vfs.FilePerm)).To(Succeed())
+		cmd.InstallArgs.Auto = true
+		err = action.Install(context.Background(), cliCmd)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("inconsistent deployment"))
+		Expect(buffer.String()).To(ContainSubstring("Loaded deployment description file: /configDir/bad_config.yaml"))
+		Expect(buffer.String()).To(ContainSubstring("Ignoring unknown kernel argument 'elemental.install.unknown'"))
+	})
+	It("fails to read the installation parameters if the kernel command line is not readable", func() {
+		cmd.InstallArgs.Auto = true
+		err = action.Install(context.Background(), cliCmd)
+		Expect(err).To(MatchError(ContainSubstring("reading kernel command line")))
+	})
 })
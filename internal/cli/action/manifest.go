@@ -0,0 +1,222 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/internal/config"
+	"github.com/suse/elemental/v3/pkg/manifest/authoring"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/manifest/source"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// ManifestActions are the actions of the manifest subcommands
+var ManifestActions = cmdpkg.ManifestActions{
+	Lint: ManifestLint,
+	Pack: ManifestPack,
+	Push: ManifestPush,
+}
+
+func ManifestLint(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	if cmd.Args().Len() != 1 {
+		return fmt.Errorf("refer usage: %s", cmd.UsageText)
+	}
+
+	output, err := config.NewOutput(s.FS(), "", "")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rmErr := output.Cleanup(s.FS()); rmErr != nil {
+			s.Logger().Error("Cleaning up working directory failed: %v", rmErr)
+		}
+	}()
+
+	reader, err := manifestSourceReader(s.FS(), output, false, cmdpkg.RegistryConfig(cmd))
+	if err != nil {
+		return err
+	}
+
+	data, err := readManifestURI(s, reader, cmd.Args().First())
+	if err != nil {
+		return err
+	}
+
+	report := lintManifest(ctx, cmd, s, data, reader)
+	err = printer.FromCommand(cmd).Print(report, func(out io.Writer) error {
+		for _, e := range report.Errors {
+			fmt.Fprintf(out, "error: %s\n", e)
+		}
+		for _, w := range report.Warnings {
+			fmt.Fprintf(out, "warning: %s\n", w)
+		}
+		if report.OK() {
+			fmt.Fprintf(out, "%s release manifest is valid\n", report.Kind)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !report.OK() {
+		return fmt.Errorf("invalid release manifest '%s'", cmd.Args().First())
+	}
+	return nil
+}
+
+func ManifestPack(ctx context.Context, cmd *cli.Command) error {
+	s, data, err := readManifest(cmd, 1)
+	if err != nil {
+		return err
+	}
+	args := &cmdpkg.ManifestArgs
+
+	if err = checkManifest(ctx, cmd, s, data); err != nil {
+		return err
+	}
+
+	img, err := authoring.Image(data)
+	if err != nil {
+		return err
+	}
+	s.Logger().Info("Writing release manifest image '%s' to '%s'", args.Tag, args.Output)
+	return authoring.WriteImage(s.FS(), img, args.Tag, args.Output)
+}
+
+func ManifestPush(ctx context.Context, cmd *cli.Command) error {
+	s, data, err := readManifest(cmd, 2)
+	if err != nil {
+		return err
+	}
+	imageRef := cmd.Args().Get(1)
+
+	if err = checkManifest(ctx, cmd, s, data); err != nil {
+		return err
+	}
+
+	img, err := authoring.Image(data)
+	if err != nil {
+		return err
+	}
+	s.Logger().Info("Pushing release manifest image '%s'", imageRef)
+	digest, err := authoring.PushImage(ctx, img, imageRef, cmdpkg.RegistryConfig(cmd))
+	if err != nil {
+		return err
+	}
+	s.Logger().Info("Pushed release manifest image '%s@%s'", imageRef, digest)
+	return nil
+}
+
+// readManifest checks the number of positional arguments and reads the manifest file given as
+// the first one
+func readManifest(cmd *cli.Command, nArgs int) (*sys.System, []byte, error) {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return nil, nil, fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	if cmd.Args().Len() != nArgs {
+		return nil, nil, fmt.Errorf("refer usage: %s", cmd.UsageText)
+	}
+	data, err := s.FS().ReadFile(cmd.Args().First())
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading release manifest: %w", err)
+	}
+	return s, data, nil
+}
+
+// readManifestURI reads the release manifest from a local file or from a file:// or oci:// URI,
+// arguments without scheme which are not local files are considered OCI images
+func readManifestURI(s *sys.System, reader resolver.SourceReader, arg string) ([]byte, error) {
+	srcType, err := argSourceType(s, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := arg
+	if !strings.Contains(arg, "://") {
+		if srcType == source.File {
+			data, err := s.FS().ReadFile(arg)
+			if err != nil {
+				return nil, fmt.Errorf("reading release manifest: %w", err)
+			}
+			return data, nil
+		}
+		uri = fmt.Sprintf("%s://%s", srcType, arg)
+	}
+
+	src, err := source.ParseFromURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("parsing release manifest uri '%s': %w", uri, err)
+	}
+	data, err := reader.Read(src)
+	if err != nil {
+		return nil, fmt.Errorf("reading release manifest '%s': %w", uri, err)
+	}
+	return data, nil
+}
+
+// lintManifest lints the given manifest. The artifacts are resolved unless skipped, and so is the
+// core platform manifest of solution manifests if a reader is given.
+func lintManifest(ctx context.Context, cmd *cli.Command, s *sys.System, data []byte, reader resolver.SourceReader) *authoring.Report {
+	var opts []authoring.LinterOpt
+	if !cmdpkg.ManifestArgs.SkipArtifacts {
+		s.Logger().Info("Resolving the artifacts referenced by the release manifest")
+		opts = append(opts, authoring.WithArtifactChecker(authoring.NewRegistryChecker(cmdpkg.RegistryConfig(cmd))))
+
+		if reader != nil {
+			opts = append(opts, authoring.WithCoreReader(func(_ context.Context, image string) ([]byte, error) {
+				src, err := source.ParseFromURI(fmt.Sprintf("%s://%s", source.OCI, image))
+				if err != nil {
+					return nil, err
+				}
+				return reader.Read(src)
+			}))
+		}
+	}
+	return authoring.NewLinter(opts...).Lint(ctx, data)
+}
+
+// checkManifest lints the manifest logging all the findings and fails if it is not valid
+func checkManifest(ctx context.Context, cmd *cli.Command, s *sys.System, data []byte) error {
+	report := lintManifest(ctx, cmd, s, data, nil)
+	for _, w := range report.Warnings {
+		s.Logger().Warn("%s", w)
+	}
+	for _, e := range report.Errors {
+		s.Logger().Error("%s", e)
+	}
+	if !report.OK() {
+		return fmt.Errorf("invalid release manifest '%s'", cmd.Args().First())
+	}
+	return nil
+}
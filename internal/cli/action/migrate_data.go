@@ -0,0 +1,147 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/migrate"
+	"github.com/suse/elemental/v3/pkg/requirements"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/unpack"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+func MigrateData(ctx context.Context, cmd *cli.Command) error {
+	var s *sys.System
+	args := &cmdpkg.MigrateDataArgs
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s = cmd.Root().Metadata["system"].(*sys.System)
+
+	s.Logger().Info("Starting migrate-data action with args: %+v", args)
+
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	d, migrator, err := digestMigrateDataSetup(ctxCancel, s, args)
+	if err != nil {
+		s.Logger().Error("Failed to collect migrate-data setup")
+		return err
+	}
+
+	err = checkRequirements(s, "migrate-data", requirements.DeploymentFeatures(d)...)
+	if err != nil {
+		return err
+	}
+
+	bootloader, err := bootloader.New(ctxCancel, d.BootConfig.Bootloader, s)
+	if err != nil {
+		s.Logger().Error("Parsing boot config failed")
+		return err
+	}
+
+	err = migrator.Populate(d)
+	if err != nil {
+		s.Logger().Error("Populating the data partition failed")
+		return err
+	}
+
+	reg := cmdpkg.RegistryConfig(cmd)
+	upgrader := upgrade.New(
+		ctxCancel, s, upgrade.WithBootloader(bootloader), upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+		upgrade.WithImageSync(args.OperatingSystemImage != ""),
+		upgrade.WithRegistryConfig(reg), upgrade.WithLocalImage(args.Local),
+		upgrade.WithUnpackOpts(
+			unpack.WithVerify(args.Verify), unpack.WithLocal(args.Local),
+			unpack.WithRegistryConfig(reg),
+		),
+		upgrade.WithHooks(upgrade.StageAfterMerge, migrator.Hook()),
+	)
+
+	err = upgrader.Upgrade(d)
+	if err != nil {
+		s.Logger().Error("Data migration failed")
+		if rErr := migrator.Revert(); rErr != nil {
+			s.Logger().Error("Removing the data partition failed: %v", rErr)
+		}
+		return err
+	}
+
+	s.Logger().Info("Data of '%s' migrated, the partition changes are mounted from the next boot on", args.Path)
+
+	return printer.FromCommand(cmd).Print(newDeploymentResult(d), nil)
+}
+
+// digestMigrateDataSetup parses the deployment of the running system and plans the migration of the
+// volume defined by the given flags
+func digestMigrateDataSetup(
+	ctx context.Context, s *sys.System, flags *cmdpkg.MigrateDataFlags,
+) (*deployment.Deployment, *migrate.Migrator, error) {
+	d, err := deployment.Parse(s, "/")
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing deployment: %w", err)
+	} else if d == nil {
+		return nil, nil, fmt.Errorf("deployment not found")
+	}
+
+	err = setDiskDevices(s, d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if flags.OperatingSystemImage != "" {
+		srcOS, err := deployment.NewSrcFromURI(flags.OperatingSystemImage)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed parsing OS source URI ('%s'): %w", flags.OperatingSystemImage, err)
+		}
+		if d.SourceOS != nil {
+			srcOS.VerifySignature = d.SourceOS.VerifySignature
+		}
+		d.SourceOS = srcOS
+	}
+
+	fs, err := deployment.ParseFileSystem(flags.FileSystem)
+	if err != nil {
+		return nil, nil, err
+	}
+	migrator := migrate.New(ctx, s, migrate.Volume{
+		Path: flags.Path, Label: flags.Label, Size: deployment.MiB(flags.Size), FileSystem: fs,
+	})
+	err = migrator.Plan(d)
+	if err != nil {
+		return nil, nil, fmt.Errorf("planning data migration: %w", err)
+	}
+
+	err = d.Sanitize(s, deployment.CheckDiskDevice)
+	if err != nil {
+		return nil, nil, fmt.Errorf("inconsistent deployment setup found: %w", err)
+	}
+	return d, migrator, nil
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/olekukonko/tablewriter/renderer"
 	"github.com/olekukonko/tablewriter/tw"
 	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
 	"github.com/suse/elemental/v3/internal/config"
 	"github.com/suse/elemental/v3/pkg/extractor"
 	"github.com/suse/elemental/v3/pkg/manifest/api"
@@ -37,6 +38,7 @@ import (
 	"github.com/suse/elemental/v3/pkg/manifest/api/solution"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
 	"github.com/suse/elemental/v3/pkg/manifest/source"
+	"github.com/suse/elemental/v3/pkg/registry"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/urfave/cli/v3"
@@ -57,6 +59,12 @@ type basicInfo struct {
 	Source       string
 }
 
+type releaseInfoResult struct {
+	Source   string                    `yaml:"source"`
+	Core     *core.ReleaseManifest     `yaml:"core"`
+	Solution *solution.ReleaseManifest `yaml:"solution,omitempty"`
+}
+
 func ReleaseInfo(_ context.Context, cmd *cli.Command) error {
 	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
 		return fmt.Errorf("error setting up initial configuration")
@@ -88,21 +96,24 @@ func ReleaseInfo(_ context.Context, cmd *cli.Command) error {
 			return fmt.Errorf("invalid OCI image reference: %w", err)
 		}
 	}
-	resolved, err := resolveManifest(system, uri, args.Local)
+	resolved, err := resolveManifest(system, uri, args.Local, cmdpkg.RegistryConfig(cmd))
 	if err != nil {
 		return err
 	}
 
 	markdown = args.Markdown
 
-	out := cmd.Writer
-	if out == nil {
-		out = cmd.Root().Writer
+	result := releaseInfoResult{
+		Source:   uri,
+		Core:     resolved.CorePlatform,
+		Solution: resolved.SolutionExtension,
 	}
-	return printManifest(resolved, uri, out)
+	return printer.FromCommand(cmd).Print(result, func(out io.Writer) error {
+		return printManifest(resolved, uri, out)
+	})
 }
 
-func resolveManifest(system *sys.System, uri string, local bool) (*resolver.ResolvedManifest, error) {
+func resolveManifest(system *sys.System, uri string, local bool, reg *registry.Config) (*resolver.ResolvedManifest, error) {
 	output, err := config.NewOutput(system.FS(), "", "")
 	if err != nil {
 		return nil, err
@@ -114,7 +125,7 @@ func resolveManifest(system *sys.System, uri string, local bool) (*resolver.Reso
 		}
 	}()
 
-	res, err := manifestResolver(system.FS(), output, local)
+	res, err := manifestResolver(system.FS(), output, local, reg)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +158,18 @@ func argSourceType(s *sys.System, arg string) (source.ReleaseManifestSourceType,
 	return source.OCI, nil
 }
 
-func manifestResolver(fs vfs.FS, out config.Output, local bool) (*resolver.Resolver, error) {
+func manifestResolver(fs vfs.FS, out config.Output, local bool, reg *registry.Config) (*resolver.Resolver, error) {
+	reader, err := manifestSourceReader(fs, out, local, reg)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolver.New(reader), nil
+}
+
+// manifestSourceReader returns a reader of release manifests from files and OCI images, images
+// are extracted into the release manifests store of the given output
+func manifestSourceReader(fs vfs.FS, out config.Output, local bool, reg *registry.Config) (*source.ReleaseManifestReader, error) {
 	const (
 		globPattern = "release_manifest*.yaml"
 	)
@@ -162,12 +184,15 @@ func manifestResolver(fs vfs.FS, out config.Output, local bool) (*resolver.Resol
 		return nil, fmt.Errorf("creating release manifest store '%s': %w", manifestsDir, err)
 	}
 
-	extr, err := extractor.New(searchPaths, extractor.WithStore(manifestsDir), extractor.WithLocal(local), extractor.WithFS(fs))
+	extr, err := extractor.New(
+		searchPaths, extractor.WithStore(manifestsDir), extractor.WithLocal(local),
+		extractor.WithFS(fs), extractor.WithRegistryConfig(reg),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("initializing OCI release manifest extractor: %w", err)
 	}
 
-	return resolver.New(source.NewReader(extr)), nil
+	return source.NewReader(extr), nil
 }
 
 func printManifest(manifest *resolver.ResolvedManifest, arg string, out io.Writer) error {
@@ -0,0 +1,95 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"strings"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/requirements"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// checkRequirements verifies up front that the host provides all the commands required
+// by the operation and the given features
+func checkRequirements(s *sys.System, operation string, features ...string) error {
+	prober, err := requirements.NewProber()
+	if err != nil {
+		return err
+	}
+
+	s.Logger().Debug("Checking host requirements for %s with features: %v", operation, features)
+	err = prober.Check(operation, features...)
+	if err != nil {
+		s.Logger().Error("Host does not satisfy the %s requirements", operation)
+		return fmt.Errorf("checking host requirements: %w", err)
+	}
+	return nil
+}
+
+// configurationFeatures returns the features in use by the given image configuration and build flags
+func configurationFeatures(
+	conf *image.Configuration, signing cmdpkg.SigningFlags, confextSigning cmdpkg.ConfextSigningFlags,
+) []string {
+	features := signingFeatures(signing)
+
+	if len(conf.Confexts) > 0 {
+		if confextSigning.Key != "" {
+			features = append(features, "confext-signed")
+		} else {
+			features = append(features, "confext")
+		}
+	}
+	if len(conf.Kubernetes.Kustomizations) > 0 {
+		features = append(features, "kustomize")
+	}
+	return features
+}
+
+// checkDiskControllers reports the storage controller of each target disk of the deployment and warns
+// about disks which are members of a fake-RAID set. Detection is best effort, failures are only logged.
+func checkDiskControllers(s *sys.System, d *deployment.Deployment) []*block.Controller {
+	controllers := []*block.Controller{}
+	for _, disk := range d.Disks {
+		// Disks with a size are raw disk images created by the installation
+		if disk.Device == "" || disk.Size > 0 {
+			continue
+		}
+		c, err := block.DetectController(s, disk.Device)
+		if err != nil {
+			s.Logger().Debug("Could not detect the controller of disk '%s': %v", disk.Device, err)
+			continue
+		}
+		s.Logger().Info(
+			"Disk '%s' controller: mode=%s driver=%s vendor=%s model=%s",
+			c.Disk, c.Mode, c.Driver, c.Vendor, c.Model,
+		)
+		if c.Mode == block.FakeRAID {
+			s.Logger().Warn(
+				"Disk '%s' is a single member of a '%s' firmware RAID set, installing onto it breaks the RAID set. "+
+					"Install onto the assembled RAID device instead: %s", c.Disk, c.RaidType, strings.Join(c.RaidSets, ", "),
+			)
+		}
+		controllers = append(controllers, c)
+	}
+	return controllers
+}
@@ -26,11 +26,14 @@ import (
 	"github.com/urfave/cli/v3"
 
 	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/i18n"
+	"github.com/suse/elemental/v3/internal/cli/printer"
 	"github.com/suse/elemental/v3/pkg/block"
 	"github.com/suse/elemental/v3/pkg/block/lsblk"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/install"
 	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/requirements"
 	"github.com/suse/elemental/v3/pkg/sys"
 )
 
@@ -51,6 +54,16 @@ func Reset(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	err = checkRequirements(s, "reset", requirements.DeploymentFeatures(d)...)
+	if err != nil {
+		return err
+	}
+
+	err = confirmTargets(cmd, s, args.Yes, i18n.ConfirmReset, targetDevices(d)...)
+	if err != nil {
+		return err
+	}
+
 	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
 	defer stop()
 
@@ -59,7 +72,7 @@ func Reset(ctx context.Context, cmd *cli.Command) error {
 		stop()
 	}()
 
-	installer, err := initInstaller(ctxCancel, s, d, args)
+	installer, err := initInstaller(ctxCancel, s, d, args, cmdpkg.RegistryConfig(cmd))
 	if err != nil {
 		return fmt.Errorf("initiating installer components: %w", err)
 	}
@@ -74,7 +87,7 @@ func Reset(ctx context.Context, cmd *cli.Command) error {
 
 	s.Logger().Info("Reset complete")
 
-	return nil
+	return printer.FromCommand(cmd).Print(newDeploymentResult(d), nil)
 }
 
 // digestResetSetup produces the Deployment object required to describe the installation parameters
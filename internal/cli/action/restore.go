@@ -0,0 +1,104 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/restore"
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+type restoreResult struct {
+	Snapshot int    `yaml:"snapshot"`
+	Path     string `yaml:"path"`
+	Target   string `yaml:"target"`
+}
+
+func Restore(ctx context.Context, cmd *cli.Command) error {
+	var s *sys.System
+	args := &cmdpkg.RestoreArgs
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s = cmd.Root().Metadata["system"].(*sys.System)
+
+	s.Logger().Info("Starting restore action with args: %+v", args)
+
+	d, err := deployment.Parse(s, "/")
+	if err != nil {
+		return fmt.Errorf("parsing deployment: %w", err)
+	} else if d == nil {
+		return fmt.Errorf("deployment not found")
+	}
+
+	target := "/"
+	opts := []restore.Option{}
+	if args.Pending {
+		pending, err := pendingSnapshot(s)
+		if err != nil {
+			s.Logger().Error("Failed to find the pending snapshot")
+			return err
+		}
+		opts = append(opts, restore.WithPendingSnapshot(pending))
+		target = fmt.Sprintf("/.snapshots/%d/snapshot", pending)
+	}
+
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	restorer := restore.New(ctxCancel, s, opts...)
+	err = restorer.Restore(d, args.SnapshotID, args.Path)
+	if err != nil {
+		s.Logger().Error("Restore failed")
+		return err
+	}
+
+	s.Logger().Info("Restore completed")
+
+	result := restoreResult{Snapshot: args.SnapshotID, Path: args.Path, Target: target}
+	return printer.FromCommand(cmd).Print(result, nil)
+}
+
+// pendingSnapshot returns the ID of the snapshot set as default for the next boot,
+// it fails if the default snapshot is already the active one.
+func pendingSnapshot(s *sys.System) (int, error) {
+	snaps, err := snapper.New(s).ListSnapshots("/", "root")
+	if err != nil {
+		return 0, fmt.Errorf("listing snapshots: %w", err)
+	}
+	pending := snaps.GetDefault()
+	if pending == 0 || pending == snaps.GetActive() {
+		return 0, fmt.Errorf("no pending snapshot found")
+	}
+	return pending, nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/i18n"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+type restorePartitionsResult struct {
+	Device string `yaml:"device"`
+	Source string `yaml:"source"`
+}
+
+func RestorePartitions(ctx context.Context, cmd *cli.Command) (err error) {
+	var s *sys.System
+	args := &cmdpkg.RestorePartitionsArgs
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s = cmd.Root().Metadata["system"].(*sys.System)
+
+	s.Logger().Info("Starting restore-partitions action with args: %+v", args)
+
+	err = confirmTargets(cmd, s, args.Yes, i18n.ConfirmRestorePartitions, args.Device)
+	if err != nil {
+		return err
+	}
+
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	bDev := lsblk.NewLsDevice(s)
+	backupDir := args.BackupDir
+	source := backupDir
+	if backupDir == "" {
+		backupDir, source, err = fetchPartitionBackup(ctx, s, cleanup, bDev, args.Device)
+		if err != nil {
+			s.Logger().Error("Failed to fetch the partition table backup")
+			return err
+		}
+	}
+
+	err = repart.RestoreDevice(s, bDev, args.Device, backupDir)
+	if err != nil {
+		s.Logger().Error("Restoring partition table failed")
+		return err
+	}
+
+	s.Logger().Info("Partition table restore completed")
+
+	result := restorePartitionsResult{Device: args.Device, Source: source}
+	return printer.FromCommand(cmd).Print(result, nil)
+}
+
+// fetchPartitionBackup copies the partition table backup stored in the recovery or config partition of the
+// given device into a temporary directory, so it remains available once the partition table is overwritten.
+// It returns the temporary directory and the path of the partition it was copied from.
+func fetchPartitionBackup(
+	ctx context.Context, s *sys.System, cleanup *cleanstack.CleanStack, bDev block.Device, device string,
+) (string, string, error) {
+	parts, err := bDev.GetDevicePartitions(device)
+	if err != nil {
+		return "", "", fmt.Errorf("listing partitions of device '%s': %w", device, err)
+	}
+	part := parts.GetByLabel(deployment.RecoveryLabel)
+	if part == nil {
+		part = parts.GetByLabel(deployment.ConfigLabel)
+	}
+	if part == nil {
+		return "", "", fmt.Errorf("no recovery or config partition found in '%s', the backup directory must be provided", device)
+	}
+
+	backupDir, err := vfs.TempDir(s.FS(), "", "elemental_partbackup")
+	if err != nil {
+		return "", "", fmt.Errorf("creating temporary directory: %w", err)
+	}
+	cleanup.Push(func() error { return s.FS().RemoveAll(backupDir) })
+
+	mountPoint, err := vfs.TempDir(s.FS(), "", "elemental_"+part.Label)
+	if err != nil {
+		return "", "", fmt.Errorf("creating temporary mount point: %w", err)
+	}
+	defer func() { _ = s.FS().RemoveAll(mountPoint) }()
+
+	err = s.Mounter().Mount(part.Path, mountPoint, "", []string{"ro"})
+	if err != nil {
+		return "", "", fmt.Errorf("mounting partition '%s': %w", part.Path, err)
+	}
+	defer func() {
+		if uErr := s.Mounter().Unmount(mountPoint); uErr != nil {
+			s.Logger().Warn("Failed unmounting '%s': %v", mountPoint, uErr)
+		}
+	}()
+
+	err = vfs.CopyDirContext(ctx, s.FS(), filepath.Join(mountPoint, repart.BackupDir), backupDir, false, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("copying partition table backup from '%s': %w", part.Path, err)
+	}
+	return backupDir, part.Path, nil
+}
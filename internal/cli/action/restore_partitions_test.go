@@ -0,0 +1,128 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const lsblkNoRecovery = `{
+	"blockdevices": [
+		{"label": "EFI", "path": "/dev/sda1", "pkname": "/dev/sda", "type": "part"},
+		{"label": "SYSTEM", "path": "/dev/sda2", "pkname": "/dev/sda", "type": "part"}
+	]
+}`
+
+var _ = Describe("Restore partitions action", Label("restore-partitions"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var runner *sysmock.Runner
+	var cleanup func()
+	var err error
+	var cliCmd *cli.Command
+	var buffer *bytes.Buffer
+
+	BeforeEach(func() {
+		cmd.RestorePartitionsArgs = cmd.RestorePartitionsFlags{Device: "/dev/sda", Yes: true}
+		buffer = &bytes.Buffer{}
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/backup/sda.gpt": "",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithBuffer(buffer))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		cliCmd = &cli.Command{
+			Metadata: map[string]any{
+				"system": s,
+			},
+		}
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "lsblk" {
+				return []byte(lsblkNoRecovery), nil
+			}
+			return []byte{}, nil
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.RestorePartitions(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+	It("restores the partition table from the given backup directory", func() {
+		cmd.RestorePartitionsArgs.BackupDir = "/backup"
+		Expect(action.RestorePartitions(context.Background(), cliCmd)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"sgdisk", "--load-backup=/backup/sda.gpt", "/dev/sda"},
+		})).To(Succeed())
+	})
+	It("restores the partition table once the device is typed to confirm it", func() {
+		cmd.RestorePartitionsArgs.Yes = false
+		cmd.RestorePartitionsArgs.BackupDir = "/backup"
+		cliCmd.Reader = strings.NewReader("/dev/sda\n")
+		cliCmd.ErrWriter = &bytes.Buffer{}
+		Expect(action.RestorePartitions(context.Background(), cliCmd)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"sgdisk"}})).To(Succeed())
+	})
+	It("does not restore the partition table if the typed device does not match", func() {
+		cmd.RestorePartitionsArgs.Yes = false
+		cmd.RestorePartitionsArgs.BackupDir = "/backup"
+		cliCmd.Reader = strings.NewReader("yes\n")
+		cliCmd.ErrWriter = &bytes.Buffer{}
+		err = action.RestorePartitions(context.Background(), cliCmd)
+		Expect(err).To(MatchError(cmd.ErrNotConfirmed))
+		Expect(runner.IncludesCmds([][]string{{"sgdisk"}})).NotTo(Succeed())
+	})
+	It("restores the partition table without confirmation if the device is empty and not in use", func() {
+		cmd.RestorePartitionsArgs.Yes = false
+		cmd.RestorePartitionsArgs.BackupDir = "/backup"
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "lsblk" {
+				return []byte(`{"blockdevices": [{"path": "/dev/sda", "type": "disk", "size": 1000}]}`), nil
+			}
+			return []byte{}, nil
+		}
+		Expect(action.RestorePartitions(context.Background(), cliCmd)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"sgdisk"}})).To(Succeed())
+	})
+	It("fails if there is no recovery or config partition to read the backup from", func() {
+		err = action.RestorePartitions(context.Background(), cliCmd)
+		Expect(err).To(MatchError(ContainSubstring("no recovery or config partition found in '/dev/sda'")))
+		Expect(runner.IncludesCmds([][]string{{"sgdisk"}})).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,96 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const snapperList = `{
+  "root": [
+    {"number": 1, "default": false, "active": true},
+    {"number": 2, "default": false, "active": false}
+  ]
+}`
+
+var _ = Describe("Restore action", Label("restore"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var runner *sysmock.Runner
+	var cleanup func()
+	var err error
+	var cliCmd *cli.Command
+	var buffer *bytes.Buffer
+
+	BeforeEach(func() {
+		cmd.RestoreArgs = cmd.RestoreFlags{SnapshotID: 1, Path: "/etc/hosts"}
+		buffer = &bytes.Buffer{}
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/etc/elemental/deployment.yaml": badConfig,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithBuffer(buffer))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		cliCmd = &cli.Command{
+			Metadata: map[string]any{
+				"system": s,
+			},
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.Restore(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+	It("fails if the deployment file does not exist", func() {
+		Expect(tfs.RemoveAll("/etc/elemental")).To(Succeed())
+		err = action.Restore(context.Background(), cliCmd)
+		Expect(err).To(MatchError("deployment not found"))
+	})
+	It("fails to restore into a pending snapshot if there is none", func() {
+		cmd.RestoreArgs.Pending = true
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "snapper" {
+				return []byte(snapperList), nil
+			}
+			return []byte{}, nil
+		}
+		err = action.Restore(context.Background(), cliCmd)
+		Expect(err).To(MatchError("no pending snapshot found"))
+	})
+})
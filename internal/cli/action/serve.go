@@ -0,0 +1,225 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/buildserver"
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+func Serve(ctx context.Context, cmd *cli.Command) error {
+	var s *sys.System
+	args := &cmdpkg.ServeArgs
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s = cmd.Root().Metadata["system"].(*sys.System)
+
+	s.Logger().Info("Starting serve action with args: %+v", args)
+
+	var features []string
+	if args.JobUser != "" {
+		features = append(features, "job-user")
+	}
+	if err := checkRequirements(s, "serve", features...); err != nil {
+		return err
+	}
+
+	tokens, err := buildserver.LoadTokens(s, args.TokensFile)
+	if err != nil {
+		s.Logger().Error("Loading tenant tokens failed")
+		return err
+	}
+
+	build, err := sandboxedJob(s, cmd, args)
+	if err != nil {
+		s.Logger().Error("Setting up the job sandbox failed")
+		return err
+	}
+
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	// Jobs are not cancelled by the signals, they are drained once the API is shut down
+	buildCtx, cancelBuilds := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelBuilds()
+
+	srv := buildserver.New(
+		s, args.WorkDir, build, buildserver.WithTokens(tokens),
+		buildserver.WithWorkers(args.Workers), buildserver.WithTenantQueueSize(args.TenantQueue),
+		buildserver.WithRetention(args.Retention),
+	)
+	srv.Start(buildCtx)
+
+	httpSrv := &http.Server{Addr: args.Listen, Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctxCancel.Done()
+		if err := httpSrv.Shutdown(context.Background()); err != nil {
+			s.Logger().Warn("Shutting down the API failed: %v", err)
+		}
+	}()
+
+	s.Logger().Info("Serving the build API on '%s'", args.Listen)
+	err = httpSrv.ListenAndServeTLS(args.TLSCert, args.TLSKey)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.Logger().Error("Serving the build API failed")
+		cancelBuilds()
+		_ = srv.Shutdown(context.Background())
+		return err
+	}
+
+	s.Logger().Info("Waiting for the queued and running jobs to finish")
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), args.DrainTimeout)
+	defer cancelDrain()
+	if err = srv.Shutdown(drainCtx); err != nil {
+		s.Logger().Warn("Jobs did not finish within %s, aborting them", args.DrainTimeout)
+		cancelBuilds()
+		_ = srv.Shutdown(context.Background())
+	}
+	s.Logger().Info("Build server stopped")
+	return nil
+}
+
+// sandboxedJob returns the build function customizing the installer media of a build job in a transient
+// systemd service. The service sees the host filesystem read-only, its own workspace being the only
+// writable path, while the home directories, the workspaces of other jobs and the secrets of the server
+// are hidden. It can't gain privileges, access devices, kernel modules and tunables, and runs as the job
+// user without any capability if set, or as root with the capabilities required to handle files owned by
+// other users otherwise. The manifest URI of the job, if any, overrides the one of the definition.
+// jobCapabilities are the capabilities of jobs built as root, required to read and write the files of other
+// users the definitions and the installer media include
+const jobCapabilities = "CAP_CHOWN CAP_DAC_OVERRIDE CAP_DAC_READ_SEARCH CAP_FOWNER CAP_FSETID CAP_SETFCAP"
+
+func sandboxedJob(s *sys.System, cmd *cli.Command, args *cmdpkg.ServeFlags) (buildserver.BuildFunc, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("resolving the executable path: %w", err)
+	}
+	workDir, err := filepath.Abs(args.WorkDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving the work directory: %w", err)
+	}
+
+	var globalArgs []string
+	var readOnly []string
+	for _, flag := range []string{"registry-config", "registry-auth-file"} {
+		if file := cmd.Root().String(flag); file != "" {
+			if file, err = filepath.Abs(file); err != nil {
+				return nil, fmt.Errorf("resolving the --%s path: %w", flag, err)
+			}
+			globalArgs = append(globalArgs, "--"+flag, file)
+			readOnly = append(readOnly, file)
+		}
+	}
+	var hidden []string
+	for _, file := range []string{args.TokensFile, args.TLSKey} {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return nil, fmt.Errorf("resolving the path of '%s': %w", file, err)
+		}
+		hidden = append(hidden, path)
+	}
+
+	return func(ctx context.Context, job buildserver.Job, ws buildserver.Workspace) error {
+		unit := fmt.Sprintf("elemental-build-%s", job.ID)
+		properties := []string{
+			"ProtectSystem=strict",
+			"ProtectHome=tmpfs",
+			"PrivateTmp=yes",
+			fmt.Sprintf("TemporaryFileSystem=%s:ro", workDir),
+			fmt.Sprintf("BindPaths=%s", ws.Root),
+			fmt.Sprintf("Environment=TMPDIR=%s", ws.WorkDir),
+			"NoNewPrivileges=yes",
+			"PrivateDevices=yes",
+			"ProtectKernelModules=yes",
+			"ProtectKernelTunables=yes",
+		}
+		if args.JobUser != "" {
+			properties = append(properties, "User="+args.JobUser, "CapabilityBoundingSet=")
+		} else {
+			properties = append(properties, "CapabilityBoundingSet="+jobCapabilities)
+		}
+		for _, file := range readOnly {
+			properties = append(properties, fmt.Sprintf("BindReadOnlyPaths=%s", file))
+		}
+		for _, file := range hidden {
+			properties = append(properties, fmt.Sprintf("InaccessiblePaths=-%s", file))
+		}
+		if args.Local {
+			// Local images are mounted from the containers storage
+			properties = append(properties, "ReadWritePaths=-/var/lib/containers/storage", "CapabilityBoundingSet=CAP_SYS_ADMIN")
+		} else {
+			properties = append(properties, "RestrictNamespaces=yes")
+		}
+
+		if args.JobUser != "" {
+			_, err := s.Runner().RunContext(ctx, "chown", "-R", "--", args.JobUser+":", ws.Root)
+			if err != nil {
+				return fmt.Errorf("handing the workspace over to user '%s': %w", args.JobUser, err)
+			}
+		}
+
+		runArgs := []string{"--quiet", "--wait", "--collect", "--service-type=exec", "--unit=" + unit}
+		for _, property := range properties {
+			runArgs = append(runArgs, "--property="+property)
+		}
+
+		platform := job.Platform
+		if platform == "" {
+			platform = fmt.Sprintf("linux/%s", runtime.GOARCH)
+		}
+		runArgs = append(runArgs, "--", exe, "--log-file", filepath.Join(ws.OutputDir, "build.log"))
+		runArgs = append(runArgs, globalArgs...)
+		runArgs = append(runArgs,
+			"customize", "--config-dir", ws.ConfigDir, "--type", job.Type, "--platform", platform,
+			"--output", filepath.Join(ws.OutputDir, "image."+job.Type),
+		)
+		if job.ManifestURI != "" {
+			runArgs = append(runArgs, "--manifest-uri", job.ManifestURI)
+		}
+		if args.Local {
+			runArgs = append(runArgs, "--local")
+		}
+
+		_, err := s.Runner().RunContext(ctx, "systemd-run", runArgs...)
+		if err != nil && ctx.Err() != nil {
+			// Stopping the client does not stop the service it waits for
+			if _, sErr := s.Runner().Run("systemctl", "stop", unit+".service"); sErr != nil {
+				s.Logger().Warn("Stopping the service of job '%s' failed: %v", job.ID, sErr)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("building image, see build.log for details: %w", err)
+		}
+		return nil
+	}, nil
+}
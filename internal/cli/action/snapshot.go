@@ -0,0 +1,183 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+// SnapshotActions are the actions of the snapshot subcommands
+var SnapshotActions = cmdpkg.SnapshotActions{
+	List:   SnapshotList,
+	Delete: SnapshotDelete,
+	Tag:    SnapshotTag,
+	Diff:   SnapshotDiff,
+}
+
+type snapshotResult struct {
+	Number      int               `yaml:"number"`
+	Default     bool              `yaml:"default"`
+	Active      bool              `yaml:"active"`
+	Date        string            `yaml:"date,omitempty"`
+	Description string            `yaml:"description,omitempty"`
+	UserData    map[string]string `yaml:"userdata,omitempty"`
+}
+
+type changeResult struct {
+	Status string `yaml:"status"`
+	Path   string `yaml:"path"`
+}
+
+func SnapshotList(_ context.Context, cmd *cli.Command) error {
+	manager, err := snapshotManager(cmd)
+	if err != nil {
+		return err
+	}
+
+	snaps, err := manager.List()
+	if err != nil {
+		return err
+	}
+
+	result := make([]snapshotResult, len(snaps))
+	for i, snap := range snaps {
+		result[i] = snapshotResult{
+			Number: snap.Number, Default: snap.Default, Active: snap.Active,
+			Date: snap.Date, Description: snap.Description, UserData: snap.UserData,
+		}
+	}
+
+	return printer.FromCommand(cmd).Print(result, func(out io.Writer) error {
+		for _, snap := range snaps {
+			flags := ""
+			if snap.Active {
+				flags += "-"
+			}
+			if snap.Default {
+				flags += "+"
+			}
+			fmt.Fprintf(out, "%d%s\t%s\t%s\t%s\n", snap.Number, flags, snap.Date, snap.Description, snap.UserData)
+		}
+		return nil
+	})
+}
+
+func SnapshotDelete(_ context.Context, cmd *cli.Command) error {
+	manager, err := snapshotManager(cmd)
+	if err != nil {
+		return err
+	}
+
+	ids, err := snapshotIDs(cmd, 1)
+	if err != nil {
+		return err
+	}
+	return manager.Delete(ids[0])
+}
+
+func SnapshotTag(_ context.Context, cmd *cli.Command) error {
+	args := &cmdpkg.SnapshotArgs
+	manager, err := snapshotManager(cmd)
+	if err != nil {
+		return err
+	}
+
+	ids, err := snapshotIDs(cmd, 1)
+	if err != nil {
+		return err
+	}
+
+	tags := args.Tags
+	if args.Important {
+		tags = append(tags, "important")
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("no tags given, use --important or --tag")
+	}
+	return manager.Tag(ids[0], args.Remove, tags...)
+}
+
+func SnapshotDiff(_ context.Context, cmd *cli.Command) error {
+	manager, err := snapshotManager(cmd)
+	if err != nil {
+		return err
+	}
+
+	ids, err := snapshotIDs(cmd, 2)
+	if err != nil {
+		return err
+	}
+
+	changes, err := manager.Diff(ids[0], ids[1])
+	if err != nil {
+		return err
+	}
+
+	result := make([]changeResult, len(changes))
+	for i, change := range changes {
+		result[i] = changeResult{Status: change.Status, Path: change.Path}
+	}
+
+	return printer.FromCommand(cmd).Print(result, func(out io.Writer) error {
+		for _, change := range changes {
+			fmt.Fprintf(out, "%s %s\n", change.Status, change.Path)
+		}
+		return nil
+	})
+}
+
+// snapshotIDs parses the given number of snapshot IDs from the command arguments
+func snapshotIDs(cmd *cli.Command, n int) ([]int, error) {
+	if cmd.Args().Len() != n {
+		return nil, fmt.Errorf("refer usage: %s", cmd.UsageText)
+	}
+
+	ids := make([]int, n)
+	for i, arg := range cmd.Args().Slice() {
+		id, err := strconv.Atoi(strings.TrimSpace(arg))
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid snapshot ID '%s'", arg)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// snapshotManager returns the snapshot manager of the host once the host requirements are verified
+func snapshotManager(cmd *cli.Command) (*transaction.SnapshotManager, error) {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return nil, fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	if err := checkRequirements(s, "snapshot"); err != nil {
+		return nil, err
+	}
+	return transaction.NewSnapshotManager(s, "/"), nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/i18n"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/kexec"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/takeover"
+)
+
+type takeoverResult struct {
+	InstallerISO string   `yaml:"installerISO"`
+	Devices      []string `yaml:"devices"`
+	Reboot       bool     `yaml:"reboot"`
+}
+
+func Takeover(ctx context.Context, cmd *cli.Command) error {
+	var s *sys.System
+	args := &cmdpkg.TakeoverArgs
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s = cmd.Root().Metadata["system"].(*sys.System)
+
+	s.Logger().Info("Starting takeover action with args: %+v", args)
+
+	err := checkRequirements(s, "takeover")
+	if err != nil {
+		return err
+	}
+
+	confirm := func(d *deployment.Deployment) error {
+		return confirmTargets(cmd, s, args.Yes, i18n.ConfirmTakeover, targetDevices(d)...)
+	}
+	d, err := takeover.New(ctx, s, takeover.WithConfirm(confirm)).Stage(args.InstallerISO, args.KernelCmdline)
+	if err != nil {
+		s.Logger().Error("Staging takeover failed")
+		return err
+	}
+
+	result := takeoverResult{InstallerISO: args.InstallerISO, Reboot: args.Reboot}
+	for _, disk := range d.Disks {
+		result.Devices = append(result.Devices, disk.Device)
+	}
+	s.Logger().Warn("Booting the staged installer will wipe devices %v", result.Devices)
+
+	err = printer.FromCommand(cmd).Print(result, nil)
+	if err != nil {
+		return err
+	}
+
+	if !args.Reboot {
+		s.Logger().Info("Takeover staged, run 'systemctl kexec' to boot the installer")
+		return nil
+	}
+	return kexec.Reboot(s)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+)
+
+var _ = Describe("Takeover action", Label("takeover"), func() {
+	var s *sys.System
+	var runner *sysmock.Runner
+	var cleanup func()
+	var err error
+	var cliCmd *cli.Command
+
+	BeforeEach(func() {
+		// Provide the host commands checked by the requirements of the action
+		binDir := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(binDir, "kexec"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+		DeferCleanup(os.Setenv, "PATH", os.Getenv("PATH"))
+		Expect(os.Setenv("PATH", binDir)).To(Succeed())
+
+		cmd.TakeoverArgs = cmd.TakeoverFlags{InstallerISO: "/iso/installer.iso", Reboot: true}
+		runner = sysmock.NewRunner()
+		tfs, cleanFS, err := sysmock.TestFS(map[string]string{
+			"/etc/elemental/deployment.yaml": badConfig,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		cleanup = cleanFS
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithBuffer(&bytes.Buffer{}))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		cliCmd = &cli.Command{
+			Metadata: map[string]any{
+				"system": s,
+			},
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.Takeover(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+	It("fails if kexec is not installed", func() {
+		Expect(os.Setenv("PATH", GinkgoT().TempDir())).To(Succeed())
+		err = action.Takeover(context.Background(), cliCmd)
+		Expect(err).To(MatchError(ContainSubstring("feature 'base' requires 'kexec'")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("does not reboot if the host is already an elemental deployment", func() {
+		err = action.Takeover(context.Background(), cliCmd)
+		Expect(err).To(MatchError(ContainSubstring("already an elemental deployment")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+})
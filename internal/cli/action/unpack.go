@@ -26,10 +26,17 @@ import (
 	"github.com/urfave/cli/v3"
 
 	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
+type unpackResult struct {
+	Image  string `yaml:"image"`
+	Digest string `yaml:"digest,omitempty"`
+	Target string `yaml:"target"`
+}
+
 func Unpack(ctx context.Context, cmd *cli.Command) error {
 	var s *sys.System
 	args := &cmdpkg.UnpackArgs
@@ -43,7 +50,9 @@ func Unpack(ctx context.Context, cmd *cli.Command) error {
 	unpacker := unpack.NewOCIUnpacker(s, args.Image,
 		unpack.WithLocalOCI(args.Local),
 		unpack.WithPlatformRefOCI(args.Platform),
-		unpack.WithVerifyOCI(args.Verify))
+		unpack.WithVerifyOCI(args.Verify),
+		unpack.WithConcurrencyOCI(args.Concurrency),
+		unpack.WithRegistryConfigOCI(cmdpkg.RegistryConfig(cmd)))
 
 	ctxSignal, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
 	defer stop()
@@ -53,7 +62,7 @@ func Unpack(ctx context.Context, cmd *cli.Command) error {
 		stop()
 	}()
 
-	_, err := unpacker.Unpack(ctxSignal, args.TargetDir)
+	digest, err := unpacker.Unpack(ctxSignal, args.TargetDir)
 	if err != nil {
 		s.Logger().Error("Failed to unpack image %s", args.Image)
 		return err
@@ -61,5 +70,6 @@ func Unpack(ctx context.Context, cmd *cli.Command) error {
 
 	s.Logger().Info("Image %s unpacked", args.Image)
 
-	return nil
+	result := unpackResult{Image: args.Image, Digest: digest, Target: args.TargetDir}
+	return printer.FromCommand(cmd).Print(result, nil)
 }
@@ -26,12 +26,19 @@ import (
 	"github.com/urfave/cli/v3"
 
 	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
 	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/kexec"
+	"github.com/suse/elemental/v3/pkg/lifecycle"
+	"github.com/suse/elemental/v3/pkg/relayout"
+	"github.com/suse/elemental/v3/pkg/requirements"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/transaction"
 	"github.com/suse/elemental/v3/pkg/unpack"
 	"github.com/suse/elemental/v3/pkg/upgrade"
+	"github.com/suse/elemental/v3/pkg/watchdog"
 )
 
 func Upgrade(ctx context.Context, cmd *cli.Command) error {
@@ -44,12 +51,24 @@ func Upgrade(ctx context.Context, cmd *cli.Command) error {
 
 	s.Logger().Info("Starting upgrade action with args: %+v", args)
 
-	d, err := digestUpgradeSetup(s, args)
+	d, relayouter, err := digestUpgradeSetup(ctx, s, args)
 	if err != nil {
 		s.Logger().Error("Failed to collect upgrade setup")
 		return err
 	}
 
+	features := requirements.DeploymentFeatures(d)
+	if args.Kexec {
+		features = append(features, "kexec")
+	}
+	if args.Layout != "" {
+		features = append(features, "layout")
+	}
+	err = checkRequirements(s, "upgrade", features...)
+	if err != nil {
+		return err
+	}
+
 	s.Logger().Info("Checked configuration, running upgrade process")
 
 	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
@@ -60,17 +79,83 @@ func Upgrade(ctx context.Context, cmd *cli.Command) error {
 		stop()
 	}()
 
-	bootloader, err := bootloader.New(d.BootConfig.Bootloader, s)
+	bootloader, err := bootloader.New(ctxCancel, d.BootConfig.Bootloader, s)
 	if err != nil {
 		s.Logger().Error("Parsing boot config failed")
 		return err
 	}
 
+	snapshotter, err := transaction.New(ctxCancel, s, d, d.Snapshotter.Name)
+	if err != nil {
+		s.Logger().Error("Parsing snapshotter config failed")
+		return err
+	}
+
 	manager := firmware.NewEfiBootManager(s)
-	upgrader := upgrade.New(
-		ctxCancel, s, upgrade.WithBootloader(bootloader), upgrade.WithBootManager(manager),
-		upgrade.WithUnpackOpts(unpack.WithVerify(args.Verify), unpack.WithLocal(args.Local)),
-	)
+	reg := cmdpkg.RegistryConfig(cmd)
+	opts := []upgrade.Option{
+		upgrade.WithBootloader(bootloader), upgrade.WithBootManager(manager), upgrade.WithKexec(args.Kexec),
+		upgrade.WithSnapshotter(snapshotter),
+		upgrade.WithRegistryConfig(reg), upgrade.WithLocalImage(args.Local),
+		upgrade.WithSkipSignatureVerification(args.Signature.Skip),
+		upgrade.WithUnpackOpts(
+			unpack.WithVerify(args.Verify), unpack.WithLocal(args.Local), unpack.WithConcurrency(args.Concurrency),
+			unpack.WithRegistryConfig(reg),
+		),
+	}
+	if args.AllowDowngrade {
+		s.Logger().Warn("Downgrades allowed, the OS version of the new snapshot is not checked")
+	} else {
+		opts = append(opts, upgrade.WithValidators(upgrade.NewOSReleaseValidator(s, "/")))
+	}
+	if args.Watchdog {
+		opts = append(opts, upgrade.WithWatchdog(watchdog.DefaultDevice, args.WatchdogTimeout))
+	}
+	if args.HooksDir != "" {
+		hooks, err := upgrade.LoadScriptHooks(s, args.HooksDir)
+		if err != nil {
+			s.Logger().Error("Loading upgrade hooks failed")
+			return err
+		}
+		for stage, stageHooks := range hooks {
+			opts = append(opts, upgrade.WithHooks(stage, stageHooks...))
+		}
+		validators, err := upgrade.LoadScriptValidators(s, args.HooksDir)
+		if err != nil {
+			s.Logger().Error("Loading upgrade validators failed")
+			return err
+		}
+		opts = append(opts, upgrade.WithValidators(validators...))
+	}
+	if args.LifecycleSocket != "" {
+		listener, err := lifecycle.ActivatedListener(s, args.LifecycleSocket)
+		if err != nil {
+			s.Logger().Error("Checking lifecycle socket activation failed")
+			return err
+		}
+		srv := lifecycle.NewServer(s, args.LifecycleSocket, lifecycle.WithListener(listener))
+		if err = srv.Start(); err != nil {
+			s.Logger().Error("Starting lifecycle socket failed")
+			return err
+		}
+		defer func() {
+			if err := srv.Close(); err != nil {
+				s.Logger().Warn("Closing lifecycle socket failed: %v", err)
+			}
+		}()
+		for _, stage := range upgrade.Stages {
+			opts = append(opts, upgrade.WithHooks(stage, srv))
+		}
+	}
+	if relayouter != nil {
+		err = relayouter.Apply()
+		if err != nil {
+			s.Logger().Error("Applying layout changes failed")
+			return err
+		}
+		opts = append(opts, upgrade.WithHooks(upgrade.StageAfterMerge, relayouter.Hook()))
+	}
+	upgrader := upgrade.New(ctxCancel, s, opts...)
 
 	err = upgrader.Upgrade(d)
 	if err != nil {
@@ -80,27 +165,35 @@ func Upgrade(ctx context.Context, cmd *cli.Command) error {
 
 	s.Logger().Info("Upgrade completed")
 
-	return nil
+	err = printer.FromCommand(cmd).Print(newDeploymentResult(d), nil)
+	if err != nil || !args.Kexec {
+		return err
+	}
+	return kexec.Reboot(s)
 }
 
-func digestUpgradeSetup(s *sys.System, flags *cmdpkg.UpgradeFlags) (*deployment.Deployment, error) {
+func digestUpgradeSetup(ctx context.Context, s *sys.System, flags *cmdpkg.UpgradeFlags) (*deployment.Deployment, *relayout.Relayouter, error) {
 	d, err := deployment.Parse(s, "/")
 	if err != nil {
-		return nil, fmt.Errorf("parsing deployment: %w", err)
+		return nil, nil, fmt.Errorf("parsing deployment: %w", err)
 	} else if d == nil {
-		return nil, fmt.Errorf("deployment not found")
+		return nil, nil, fmt.Errorf("deployment not found")
 	}
 
 	srcOS, err := deployment.NewSrcFromURI(flags.OperatingSystemImage)
 	if err != nil {
-		return nil, fmt.Errorf("failed parsing OS source URI ('%s'): %w", flags.OperatingSystemImage, err)
+		return nil, nil, fmt.Errorf("failed parsing OS source URI ('%s'): %w", flags.OperatingSystemImage, err)
+	}
+	if d.SourceOS != nil {
+		srcOS.VerifySignature = d.SourceOS.VerifySignature
 	}
+	applySignatureFlags(srcOS, flags.Signature)
 	d.SourceOS = srcOS
 
 	if flags.Overlay != "" {
 		overlay, err := deployment.NewSrcFromURI(flags.Overlay)
 		if err != nil {
-			return nil, fmt.Errorf("failed parsing overlay source URI ('%s'): %w", flags.Overlay, err)
+			return nil, nil, fmt.Errorf("failed parsing overlay source URI ('%s'): %w", flags.Overlay, err)
 		}
 		d.OverlayTree = overlay
 	}
@@ -109,18 +202,59 @@ func digestUpgradeSetup(s *sys.System, flags *cmdpkg.UpgradeFlags) (*deployment.
 		d.CfgScript = flags.ConfigScript
 	}
 
-	if flags.CreateBootEntry {
+	if d.Snapshotter == nil {
+		d.Snapshotter = &deployment.SnapshotterConfig{Name: "snapper"}
+	}
+	if flags.Delta {
+		if d.Snapshotter.Name != "snapper" {
+			return nil, nil, fmt.Errorf("delta upgrades are not supported by the '%s' snapshotter", d.Snapshotter.Name)
+		}
+		d.Snapshotter.Delta = true
+	}
+
+	if entry := defaultBootEntry(s, d); flags.CreateBootEntry && entry != nil {
 		if d.Firmware == nil {
 			d.Firmware = &deployment.FirmwareConfig{}
 		}
-		d.Firmware.BootEntries = []*firmware.EfiBootEntry{
-			firmware.DefaultBootEntry(s.Platform(), d.Disks[0].Device),
+		d.Firmware.BootEntries = []*firmware.EfiBootEntry{entry}
+	}
+
+	var relayouter *relayout.Relayouter
+	if flags.Layout != "" {
+		relayouter, err = planLayout(ctx, s, d, flags.Layout)
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
 	err = d.Sanitize(s, deployment.CheckDiskDevice)
 	if err != nil {
-		return nil, fmt.Errorf("inconsistent deployment setup found: %w", err)
+		return nil, nil, fmt.Errorf("inconsistent deployment setup found: %w", err)
+	}
+	return d, relayouter, nil
+}
+
+// planLayout plans the layout changes required to apply the disk layout of the given deployment description
+// file to the deployment of the running system
+func planLayout(ctx context.Context, s *sys.System, d *deployment.Deployment, file string) (*relayout.Relayouter, error) {
+	target := &deployment.Deployment{}
+	err := loadDescriptionFile(s, file, target)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setDiskDevices(s, d)
+	if err != nil {
+		return nil, err
+	}
+
+	relayouter := relayout.New(ctx, s)
+	err = relayouter.Plan(d, target.Disks)
+	if err != nil {
+		return nil, fmt.Errorf("planning layout changes: %w", err)
+	}
+	for _, change := range relayouter.Changes() {
+		s.Logger().Info("Planned layout change: %s partition '%s' of disk '%s'", change.Type, change.Partition.Label, change.Disk.Device)
 	}
-	return d, nil
+	return relayouter, nil
 }
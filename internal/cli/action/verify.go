@@ -0,0 +1,72 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/integrity"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+func Verify(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+	args := &cmdpkg.VerifyArgs
+
+	d, err := deployment.Parse(s, args.Root)
+	if err != nil {
+		s.Logger().Error("Failed reading deployment file")
+		return err
+	}
+	if d == nil {
+		d = &deployment.Deployment{}
+	}
+
+	s.Logger().Info("Verifying system integrity")
+	mismatches, err := integrity.Verify(ctx, s, args.Root, integrity.ExcludedPaths(d)...)
+	if err != nil {
+		s.Logger().Error("Verifying system integrity failed")
+		return err
+	}
+
+	err = printer.FromCommand(cmd).Print(mismatches, func(out io.Writer) error {
+		for _, m := range mismatches {
+			fmt.Fprintf(out, "%s\t%s\n", m.Status, m.Path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d files do not match the checksums manifest", len(mismatches))
+	}
+	s.Logger().Info("System integrity verified")
+	return nil
+}
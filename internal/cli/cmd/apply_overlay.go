@@ -0,0 +1,66 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type ApplyOverlayFlags struct {
+	Image        string
+	ConfigScript string
+	Overlay      string
+}
+
+var ApplyOverlayArgs ApplyOverlayFlags
+
+func NewApplyOverlayCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:      "apply-overlay",
+		Usage:     "Apply an overlay tree and configuration script to an installed RAW disk image in a new snapshot",
+		UsageText: fmt.Sprintf("%s apply-overlay --image FILE [--overlay URI] [--config FILE]", appName),
+		Action:    action,
+		Before: func(ctx context.Context, _ *cli.Command) (context.Context, error) {
+			if ApplyOverlayArgs.Overlay == "" && ApplyOverlayArgs.ConfigScript == "" {
+				return ctx, cli.Exit("Error: at least one of --overlay or --config is required.", 1)
+			}
+			return ctx, nil
+		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "image",
+				Usage:       "RAW disk image with an installed OS to update",
+				Destination: &ApplyOverlayArgs.Image,
+				Required:    true,
+			},
+			&cli.StringFlag{
+				Name:        configFlg,
+				Usage:       configDesc,
+				Destination: &ApplyOverlayArgs.ConfigScript,
+			},
+			&cli.StringFlag{
+				Name:        overlayFlg,
+				Usage:       overlayDesc,
+				Destination: &ApplyOverlayArgs.Overlay,
+			},
+		},
+	}
+}
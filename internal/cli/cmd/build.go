@@ -26,12 +26,21 @@ import (
 )
 
 type BuildFlags struct {
-	ImageType  string
-	Platform   string
-	ConfigDir  string
-	BuildDir   string
-	OutputPath string
-	Local      bool
+	ImageType      string
+	Platform       string
+	ConfigDir      string
+	BuildDir       string
+	OutputPath     string
+	NameTemplate   string
+	Inventory      string
+	PerHost        bool
+	Local          bool
+	CacheDir       string
+	AirgapDir      string
+	PreloadImages  bool
+	VendorCharts   bool
+	Signing        SigningFlags
+	ConfextSigning ConfextSigningFlags
 }
 
 var BuildArgs BuildFlags
@@ -43,10 +52,10 @@ func NewBuildCommand(appName string, action func(context.Context, *cli.Command)
 		UsageText: fmt.Sprintf("[DEPRECATED] %s build [OPTIONS]", appName),
 		Action:    action,
 		Hidden:    true,
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:        "image-type",
-				Usage:       "Type of image artifact to build (RAW or ISO)",
+				Usage:       "Type of image artifact to build (RAW, ISO or squashfs)",
 				Destination: &BuildArgs.ImageType,
 				Required:    true,
 			},
@@ -75,11 +84,46 @@ func NewBuildCommand(appName string, action func(context.Context, *cli.Command)
 				Destination: &BuildArgs.OutputPath,
 				DefaultText: "image-<timestamp>.<image-type>",
 			},
+			&cli.StringFlag{
+				Name:        nameTemplateFlg,
+				Usage:       nameTemplateDesc + ". Requires --" + outputFlg + " to be a directory, if set",
+				Destination: &BuildArgs.NameTemplate,
+			},
+			&cli.StringFlag{
+				Name:        "inventory",
+				Usage:       "Path to a YAML or CSV inventory of the hosts to provision, with their hostnames, MAC addresses and roles",
+				Destination: &BuildArgs.Inventory,
+			},
+			&cli.BoolFlag{
+				Name:        "per-host",
+				Usage:       "Build an image for each host of the inventory instead of a single image for the whole fleet",
+				Destination: &BuildArgs.PerHost,
+			},
 			&cli.BoolFlag{
 				Name:        localFlg,
-				Usage:       localDesc,
+				Usage:       localDesc + ", the OS image is read from the podman containers storage",
 				Destination: &BuildArgs.Local,
 			},
-		},
+			&cli.StringFlag{
+				Name:        cacheDirFlg,
+				Usage:       cacheDirDesc,
+				Destination: &BuildArgs.CacheDir,
+			},
+			&cli.StringFlag{
+				Name:        airgapDirFlg,
+				Usage:       "Read all the artifacts from the given directory, populated by the fetch-artifacts command, instead of the network",
+				Destination: &BuildArgs.AirgapDir,
+			},
+			&cli.BoolFlag{
+				Name:        preloadImagesFlg,
+				Usage:       preloadImagesDesc + ", always set with --" + airgapDirFlg,
+				Destination: &BuildArgs.PreloadImages,
+			},
+			&cli.BoolFlag{
+				Name:        vendorChartsFlg,
+				Usage:       vendorChartsDesc + ", always set with --" + airgapDirFlg,
+				Destination: &BuildArgs.VendorCharts,
+			},
+		}, append(signingFlags(&BuildArgs.Signing), confextSigningFlags(&BuildArgs.ConfextSigning)...)...),
 	}
 }
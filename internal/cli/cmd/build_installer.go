@@ -36,6 +36,9 @@ type InstallerFlags struct {
 	Label                string
 	KernelCmdLine        string
 	Type                 string
+	NetbootURL           string
+	RootfsFormat         string
+	Signing              SigningFlags
 }
 
 var InstallerArgs InstallerFlags
@@ -46,7 +49,7 @@ func NewBuildInstallerCommand(appName string, action func(context.Context, *cli.
 		Usage:     "Build an installer media",
 		UsageText: fmt.Sprintf("%s build-installer [OPTIONS]", appName),
 		Action:    action,
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:        "install-config",
 				Usage:       configDesc,
@@ -123,10 +126,21 @@ func NewBuildInstallerCommand(appName string, action func(context.Context, *cli.
 			},
 			&cli.StringFlag{
 				Name:        "type",
-				Usage:       "Type of the installer media, 'iso' or 'raw'",
+				Usage:       "Type of the installer media, 'iso', 'raw' or 'netboot'",
 				Destination: &InstallerArgs.Type,
 				Required:    true,
 			},
-		},
+			&cli.StringFlag{
+				Name:        "rootfs-format",
+				Value:       "squashfs",
+				Usage:       "Filesystem of the live root image, 'squashfs', 'erofs' or 'ext4'",
+				Destination: &InstallerArgs.RootfsFormat,
+			},
+			&cli.StringFlag{
+				Name:        "netboot-url",
+				Usage:       "Base HTTP(S) URL the netboot media is served from, required for 'netboot' type",
+				Destination: &InstallerArgs.NetbootURL,
+			},
+		}, signingFlags(&InstallerArgs.Signing)...),
 	}
 }
@@ -0,0 +1,69 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type CheckUpgradeFlags struct {
+	ReleaseManifest string
+	ChannelIndex    string
+	Channel         string
+	Local           bool
+}
+
+var CheckUpgradeArgs CheckUpgradeFlags
+
+func NewCheckUpgradeCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:  "check-upgrade",
+		Usage: "Reports the OS images available to upgrade the installed system",
+		Description: "Compares the OS image of the installed deployment with the one of its release manifest, or of the " +
+			"releases of a channel index, and reports the available upgrades along with their digests. " +
+			"Use the --output-format flag for a machine readable report.",
+		UsageText: fmt.Sprintf("%s check-upgrade [OPTIONS]", appName),
+		Action:    action,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "release-manifest",
+				Usage:       "URI of the release manifest to check (file:// or oci://), defaults to the release manifest the installed image was built from",
+				Destination: &CheckUpgradeArgs.ReleaseManifest,
+			},
+			&cli.StringFlag{
+				Name:        "channel-index",
+				Usage:       "Path to a release channel index, upgrades are computed along the supported upgrade path to the release of the selected channel",
+				Destination: &CheckUpgradeArgs.ChannelIndex,
+			},
+			&cli.StringFlag{
+				Name:        "channel",
+				Usage:       "Channel or pinned release version of the channel index to upgrade to",
+				Value:       "stable",
+				Destination: &CheckUpgradeArgs.Channel,
+			},
+			&cli.BoolFlag{
+				Name:        localFlg,
+				Usage:       localDesc,
+				Destination: &CheckUpgradeArgs.Local,
+			},
+		},
+	}
+}
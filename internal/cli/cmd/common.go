@@ -17,27 +17,51 @@ limitations under the License.
 
 package cmd
 
+import "github.com/urfave/cli/v3"
+
 const (
 	// --local flag name and description
 	localFlg  = "local"
 	localDesc = "Load OCI images from the local container storage instead of a remote registry"
 
+	// --cache-dir flag name and description
+	cacheDirFlg  = "cache-dir"
+	cacheDirDesc = "Directory caching the OCI images, layers and helm charts pulled by builds, images are keyed by digest and platform so updated images are pulled again"
+
+	// --airgap-dir flag name
+	airgapDirFlg = "airgap-dir"
+
+	// --preload-images flag name and description
+	preloadImagesFlg  = "preload-images"
+	preloadImagesDesc = "Preload the container images required by the cluster into the image"
+
+	// --vendor-helm-charts flag name and description
+	vendorChartsFlg  = "vendor-helm-charts"
+	vendorChartsDesc = "Embed the archives of the helm charts into the image, so the cluster installs them without accessing their repositories"
+
+	// --unpack-concurrency flag name and description
+	concurrencyFlg  = "unpack-concurrency"
+	concurrencyDesc = "Number of OCI image layers downloaded in parallel"
+
 	// --verify flag name and description
 	verifyFlg  = "verify"
 	verifyDesc = "Verify OCI ssl"
 
 	// --os-image flag name and description
 	osImgFlg  = "os-image"
-	osImgDesc = "URI to the image containing the operating system"
+	osImgDesc = "URI to the image containing the operating system (oci://, dir://, raw://, squash://, tar://, containers-storage:// or containerd://, tarballs can be compressed and served over HTTP(S))"
 
 	// --config flag name and description
 	configFlg  = "config"
-	configDesc = "Path to OS image post-commit script"
+	configDesc = "Path to OS image configuration script, executed chrooted into the new snapshot before it is committed"
 
 	// --overlay flag name and description
 	overlayFlg  = "overlay"
 	overlayDesc = "URI of the overlay content for the OS image"
 
+	// remoteDesc is appended to the description of flags accepting HTTP(S) URLs
+	remoteDesc = ". HTTP(S) URLs are downloaded, a '#sha256=<checksum>' suffix verifies the downloaded file"
+
 	// --create-boot-entry flag name and description
 	createBootFlg  = "create-boot-entry"
 	createBootDesc = "Create EFI boot entry"
@@ -53,4 +77,109 @@ const (
 	// --output flag name and description
 	outputFlg  = "output"
 	outputDesc = "File/Path for the generated files"
+
+	// --name-template flag name and description
+	nameTemplateFlg  = "name-template"
+	nameTemplateDesc = "Template of the output image file name, e.g. '{{.Name}}-{{.Version}}-{{.Arch}}-{{.Date}}.raw'. " +
+		"Available fields are Name and Version of the release, Arch, Date and Type"
+
+	// --yes flag name and description
+	yesFlg  = "yes"
+	yesDesc = "Do not ask for confirmation before destroying the data of the target devices"
+
+	// --output-format global flag name
+	outputFormatFlg = "output-format"
+
+	// --progress global flag name
+	progressFlg = "progress"
+
+	// --locale global flag name
+	localeFlg = "locale"
+
+	// --audit-log global flag name
+	auditLogFlg = "audit-log"
 )
+
+// SignatureFlags define the signature verification of the OS image
+type SignatureFlags struct {
+	Tool     string
+	Key      string
+	Identity string
+	Issuer   string
+	Skip     bool
+}
+
+// signatureFlags returns the flags to verify the OS image signature stored in the given destination
+func signatureFlags(dest *SignatureFlags) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "signature-tool",
+			Usage:       "Tool verifying the OS image signature [cosign, notation]",
+			Destination: &dest.Tool,
+		},
+		&cli.StringFlag{
+			Name:        "signature-key",
+			Usage:       "Path or KMS URI of the cosign public key verifying the OS image signature",
+			Destination: &dest.Key,
+		},
+		&cli.StringFlag{
+			Name:        "signature-identity",
+			Usage:       "Certificate identity of the keyless cosign OS image signature",
+			Destination: &dest.Identity,
+		},
+		&cli.StringFlag{
+			Name:        "signature-issuer",
+			Usage:       "Certificate OIDC issuer of the keyless cosign OS image signature",
+			Destination: &dest.Issuer,
+		},
+		&cli.BoolFlag{
+			Name:        "skip-signature-verification",
+			Usage:       "Deploy the OS image without verifying its signature, even if the deployment requires it. Signatures of local images can't be verified",
+			Destination: &dest.Skip,
+		},
+	}
+}
+
+// SigningFlags define the signing of the generated artifacts
+type SigningFlags struct {
+	Tool string
+	Key  string
+}
+
+// signingFlags returns the flags to sign the generated artifacts stored in the given destination
+func signingFlags(dest *SigningFlags) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "sign-tool",
+			Usage:       "Tool signing the generated artifacts and their SHA256SUMS file [gpg, cosign], defaults to gpg if --sign-key is set",
+			Destination: &dest.Tool,
+		},
+		&cli.StringFlag{
+			Name:        "sign-key",
+			Usage:       "GPG key ID or path or KMS URI of the cosign private key signing the generated artifacts",
+			Destination: &dest.Key,
+		},
+	}
+}
+
+// ConfextSigningFlags define the signing of the configuration extension images
+type ConfextSigningFlags struct {
+	Key         string
+	Certificate string
+}
+
+// confextSigningFlags returns the flags to sign the configuration extension images stored in the given destination
+func confextSigningFlags(dest *ConfextSigningFlags) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "confext-key",
+			Usage:       "Path to the private key signing the verity hash of the configuration extension images, unsigned EROFS images are built if not set",
+			Destination: &dest.Key,
+		},
+		&cli.StringFlag{
+			Name:        "confext-cert",
+			Usage:       "Path to the certificate of the key signing the configuration extension images",
+			Destination: &dest.Certificate,
+		},
+	}
+}
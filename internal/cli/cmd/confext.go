@@ -0,0 +1,73 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type ConfextFlags struct {
+	Name string
+}
+
+var ConfextArgs ConfextFlags
+
+// ConfextActions groups the actions of the confext subcommands
+type ConfextActions struct {
+	List   func(context.Context, *cli.Command) error
+	Apply  func(context.Context, *cli.Command) error
+	Remove func(context.Context, *cli.Command) error
+}
+
+func NewConfextCommand(appName string, actions ConfextActions) *cli.Command {
+	return &cli.Command{
+		Name:      "confext",
+		Usage:     "Manage the configuration extensions of the system",
+		UsageText: fmt.Sprintf("%s confext <command> [OPTIONS]", appName),
+		Commands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "List the installed configuration extensions",
+				UsageText: fmt.Sprintf("%s confext list", appName),
+				Action:    actions.List,
+			},
+			{
+				Name:      "apply",
+				Usage:     "Install or replace a configuration extension and activate it, the previous one is restored on failure",
+				UsageText: fmt.Sprintf("%s confext apply <image> [OPTIONS]", appName),
+				Action:    actions.Apply,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "name",
+						Usage:       "Name of the configuration extension, defaults to the image file name without the .raw suffix",
+						Destination: &ConfextArgs.Name,
+					},
+				},
+			},
+			{
+				Name:      "remove",
+				Usage:     "Deactivate and remove a configuration extension",
+				UsageText: fmt.Sprintf("%s confext remove <name>", appName),
+				Action:    actions.Remove,
+			},
+		},
+	}
+}
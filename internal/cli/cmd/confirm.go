@@ -0,0 +1,80 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/i18n"
+)
+
+// ErrNotConfirmed is returned when the user does not confirm a destructive action
+var ErrNotConfirmed = errors.New("destructive action not confirmed")
+
+// yesFlag returns the flag skipping the confirmation of destructive actions stored in the given destination
+func yesFlag(dest *bool) cli.Flag {
+	return &cli.BoolFlag{
+		Name:        yesFlg,
+		Aliases:     []string{"y"},
+		Usage:       yesDesc,
+		Destination: dest,
+	}
+}
+
+// ConfirmDestructive asks the user to type the target of a destructive action before it runs. The prompt is the
+// given message of the catalog formatted with the target. Nothing is asked if yes is set. Non interactive sessions
+// can't confirm, hence they fail unless yes is set.
+func ConfirmDestructive(cmd *cli.Command, yes bool, prompt, target string) error {
+	if yes {
+		return nil
+	}
+
+	in, out := cmd.Root().Reader, cmd.Root().ErrWriter
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+
+	if f, ok := in.(*os.File); ok {
+		if fi, err := f.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+			return fmt.Errorf("%w: the destructive action on %s requires an interactive confirmation or the --%s flag", ErrNotConfirmed, target, yesFlg)
+		}
+	}
+
+	_, err := fmt.Fprint(out, i18n.FromCommand(cmd).T(prompt, target))
+	if err != nil {
+		return err
+	}
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading confirmation: %w", err)
+	}
+	if strings.TrimSpace(answer) != target {
+		return fmt.Errorf("%w: typed answer does not match %s", ErrNotConfirmed, target)
+	}
+	return nil
+}
@@ -28,12 +28,20 @@ import (
 )
 
 type CustomizeFlags struct {
-	ConfigDir  string
-	OutputPath string
-	Mode       string
-	Platform   string
-	MediaType  string
-	Local      bool
+	ConfigDir      string
+	ManifestURI    string
+	OutputPath     string
+	NameTemplate   string
+	Mode           string
+	Platform       string
+	MediaType      string
+	Local          bool
+	ImageList      string
+	PreloadImages  bool
+	VendorCharts   bool
+	CacheDir       string
+	Signing        SigningFlags
+	ConfextSigning ConfextSigningFlags
 }
 
 var CustomizeArgs CustomizeFlags
@@ -52,7 +60,7 @@ func NewCustomizeCommand(appName string, action func(context.Context, *cli.Comma
 			return ctx, nil
 		},
 		Action: action,
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:        "type",
 				Usage:       "Type of the installer media, 'iso' or 'raw'",
@@ -72,6 +80,11 @@ func NewCustomizeCommand(appName string, action func(context.Context, *cli.Comma
 				Destination: &CustomizeArgs.OutputPath,
 				DefaultText: "image-<timestamp>.<image-type>",
 			},
+			&cli.StringFlag{
+				Name:        nameTemplateFlg,
+				Usage:       nameTemplateDesc + ". Requires --" + outputFlg + " to be a directory, if set",
+				Destination: &CustomizeArgs.NameTemplate,
+			},
 			&cli.StringFlag{
 				Name: "mode",
 				Usage: "Customization mode, 'embedded' (config partition within image) or " +
@@ -90,6 +103,31 @@ func NewCustomizeCommand(appName string, action func(context.Context, *cli.Comma
 				Usage:       localDesc,
 				Destination: &CustomizeArgs.Local,
 			},
-		},
+			&cli.StringFlag{
+				Name:        cacheDirFlg,
+				Usage:       cacheDirDesc,
+				Destination: &CustomizeArgs.CacheDir,
+			},
+			&cli.StringFlag{
+				Name:        "manifest-uri",
+				Usage:       "URI of the release manifest (file:// or oci://), overrides the one of the configuration directory",
+				Destination: &CustomizeArgs.ManifestURI,
+			},
+			&cli.StringFlag{
+				Name:        "image-list",
+				Usage:       "Write the list of container images required by the cluster to the given file, so they can be mirrored for air-gapped deployments",
+				Destination: &CustomizeArgs.ImageList,
+			},
+			&cli.BoolFlag{
+				Name:        preloadImagesFlg,
+				Usage:       preloadImagesDesc,
+				Destination: &CustomizeArgs.PreloadImages,
+			},
+			&cli.BoolFlag{
+				Name:        vendorChartsFlg,
+				Usage:       vendorChartsDesc,
+				Destination: &CustomizeArgs.VendorCharts,
+			},
+		}, append(signingFlags(&CustomizeArgs.Signing), confextSigningFlags(&CustomizeArgs.ConfextSigning)...)...),
 	}
 }
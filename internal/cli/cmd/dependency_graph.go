@@ -0,0 +1,57 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type DependencyGraphFlags struct {
+	ConfigDir string
+	Local     bool
+}
+
+var DependencyGraphArgs DependencyGraphFlags
+
+func NewDependencyGraphCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:  "dependency-graph",
+		Usage: "Print the dependency graph of the Helm charts and systemd extensions enabled by an image configuration",
+		Description: "Resolves the release manifest of the image configuration and prints the enabled components, the reason " +
+			"each of them is enabled and their dependencies. The graph is printed in the DOT language unless a json or yaml " +
+			"output format is requested.",
+		UsageText: fmt.Sprintf("%s dependency-graph [OPTIONS]", appName),
+		Action:    action,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "config-dir",
+				Usage:       "Full path to the image configuration directory",
+				Destination: &DependencyGraphArgs.ConfigDir,
+				Value:       "/config",
+			},
+			&cli.BoolFlag{
+				Name:        localFlg,
+				Usage:       localDesc,
+				Destination: &DependencyGraphArgs.Local,
+			},
+		},
+	}
+}
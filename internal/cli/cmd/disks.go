@@ -0,0 +1,50 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type DisksFlags struct {
+	JSON bool
+}
+
+var DisksArgs DisksFlags
+
+func NewDisksCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:  "disks",
+		Usage: "List the disks of the host",
+		Description: "Lists the whole disks of the host with their size, model, serial, WWN, transport, whether they are " +
+			"rotational, removable or read-only, their partition table type and why they are in use, if they are: mounted " +
+			"filesystems, swap, LVM physical volumes, RAID members or open encrypted volumes.",
+		UsageText: fmt.Sprintf("%s disks [OPTIONS]", appName),
+		Action:    action,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:        "json",
+				Usage:       "Print the disks in JSON format, same as '--" + outputFormatFlg + " json'",
+				Destination: &DisksArgs.JSON,
+			},
+		},
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type DoctorFlags struct {
+	ConfigDir    string
+	Operations   []string
+	Registries   []string
+	WorkDir      string
+	MinFreeSpace uint
+}
+
+var DoctorArgs DoctorFlags
+
+func NewDoctorCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:  "doctor",
+		Usage: "Check the host environment is suitable to build images and install systems",
+		Description: "Checks the required commands and kernel modules are available, the privileges, namespaces and cgroups " +
+			"needed to mount and chroot, the free space of the work directory and the network reachability of the registries " +
+			"of the image configuration. It prints a pass or fail report and fails if any check fails.",
+		UsageText: fmt.Sprintf("%s doctor [OPTIONS]", appName),
+		Action:    action,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "config-dir",
+				Usage:       "Full path to the image configuration directory, its registries are checked if set",
+				Destination: &DoctorArgs.ConfigDir,
+			},
+			&cli.StringSliceFlag{
+				Name:        "operation",
+				Usage:       "Operation whose required commands are checked, can be repeated",
+				Value:       []string{"install", "build-installer"},
+				Destination: &DoctorArgs.Operations,
+			},
+			&cli.StringSliceFlag{
+				Name:        "registry",
+				Usage:       "Additional registry host to check for network reachability, can be repeated",
+				Destination: &DoctorArgs.Registries,
+			},
+			&cli.StringFlag{
+				Name:        "work-dir",
+				Usage:       "Directory where images are built, its free space is checked",
+				Value:       ".",
+				Destination: &DoctorArgs.WorkDir,
+			},
+			&cli.UintFlag{
+				Name:        "min-free-space",
+				Usage:       "Minimum free space in MiB required in the work directory",
+				Value:       20480,
+				Destination: &DoctorArgs.MinFreeSpace,
+			},
+		},
+	}
+}
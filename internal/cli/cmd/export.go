@@ -0,0 +1,68 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type ExportFlags struct {
+	SnapshotID int
+	Format     string
+	Output     string
+	Tag        string
+}
+
+var ExportArgs ExportFlags
+
+func NewExportCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:      "export",
+		Usage:     "Exports a snapshot as an OCI image or tarball",
+		UsageText: fmt.Sprintf("%s export [OPTIONS]", appName),
+		Action:    action,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:        "snapshot",
+				Usage:       "ID of the snapshot to export, defaults to the active snapshot",
+				Destination: &ExportArgs.SnapshotID,
+			},
+			&cli.StringFlag{
+				Name:        "format",
+				Usage:       "Export format, oci writes an OCI image layout archive [oci, tar]",
+				Value:       "oci",
+				Destination: &ExportArgs.Format,
+			},
+			&cli.StringFlag{
+				Name:        outputFlg,
+				Aliases:     []string{"o"},
+				Usage:       "File the exported snapshot is written to",
+				Destination: &ExportArgs.Output,
+				Required:    true,
+			},
+			&cli.StringFlag{
+				Name:        "tag",
+				Usage:       "Image reference of the exported OCI image, defaults to 'localhost/elemental-snapshot:<ID>'",
+				Destination: &ExportArgs.Tag,
+			},
+		},
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/urfave/cli/v3"
+)
+
+type FetchArtifactsFlags struct {
+	ConfigDir string
+	AirgapDir string
+	Platform  string
+}
+
+var FetchArtifactsArgs FetchArtifactsFlags
+
+func NewFetchArtifactsCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:  "fetch-artifacts",
+		Usage: "Fetch the artifacts required to build an image configuration into a directory for air-gapped builds",
+		Description: "Resolves the release manifest of the image configuration and stores the OS image, the systemd extensions, " +
+			"the Kubernetes artifacts and the remote manifests it requires into the given directory. The directory can then be " +
+			"passed to the --airgap-dir option of the build command to build the image without any network access.",
+		UsageText: fmt.Sprintf("%s fetch-artifacts [OPTIONS]", appName),
+		Action:    action,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "config-dir",
+				Usage:       "Full path to the image configuration directory",
+				Destination: &FetchArtifactsArgs.ConfigDir,
+				Required:    true,
+			},
+			&cli.StringFlag{
+				Name:        airgapDirFlg,
+				Usage:       "Directory to store the fetched artifacts into",
+				Destination: &FetchArtifactsArgs.AirgapDir,
+				Required:    true,
+			},
+			&cli.StringFlag{
+				Name:        platformFlg,
+				Usage:       platformDesc,
+				Destination: &FetchArtifactsArgs.Platform,
+				Value:       fmt.Sprintf("linux/%s", runtime.GOARCH),
+			},
+		},
+	}
+}
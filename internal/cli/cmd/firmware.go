@@ -0,0 +1,83 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/pkg/firmware"
+)
+
+type FirmwareFlags struct {
+	Label string
+}
+
+var FirmwareArgs FirmwareFlags
+
+// FirmwareActions groups the actions of the firmware subcommands
+type FirmwareActions struct {
+	List      func(context.Context, *cli.Command) error
+	Prune     func(context.Context, *cli.Command) error
+	BootOrder func(context.Context, *cli.Command) error
+	BootNext  func(context.Context, *cli.Command) error
+}
+
+func NewFirmwareCommand(appName string, actions FirmwareActions) *cli.Command {
+	return &cli.Command{
+		Name:      "firmware",
+		Usage:     "Manage the EFI boot entries of the system",
+		UsageText: fmt.Sprintf("%s firmware <command> [OPTIONS]", appName),
+		Commands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "List the EFI boot entries, boot order and next boot",
+				UsageText: fmt.Sprintf("%s firmware list", appName),
+				Action:    actions.List,
+			},
+			{
+				Name:      "prune",
+				Usage:     "Delete stale boot entries left behind by previous installations",
+				UsageText: fmt.Sprintf("%s firmware prune [OPTIONS]", appName),
+				Action:    actions.Prune,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "label",
+						Value:       firmware.EfiBootEntryName,
+						Usage:       "Label of the boot entries to prune",
+						Destination: &FirmwareArgs.Label,
+					},
+				},
+			},
+			{
+				Name:      "boot-order",
+				Usage:     "Set the boot order",
+				UsageText: fmt.Sprintf("%s firmware boot-order <bootnum> [<bootnum>...]", appName),
+				Action:    actions.BootOrder,
+			},
+			{
+				Name:      "boot-next",
+				Usage:     "Set the boot entry used for the next boot only",
+				UsageText: fmt.Sprintf("%s firmware boot-next <bootnum>", appName),
+				Action:    actions.BootNext,
+			},
+		},
+	}
+}
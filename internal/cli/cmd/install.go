@@ -35,8 +35,17 @@ type InstallFlags struct {
 	KernelCmdline        string
 	Verify               bool
 	Local                bool
+	Concurrency          int
+	Signature            SignatureFlags
 	CryptoPolicy         string
 	Snapshotter          string
+	Auto                 bool
+	Ignition             string
+	Combustion           string
+	CloudInit            string
+	Wipe                 string
+	Force                bool
+	Yes                  bool
 }
 
 var InstallArgs InstallFlags
@@ -47,16 +56,16 @@ func NewInstallCommand(appName string, action func(context.Context, *cli.Command
 		Usage:     "Install an OCI image on a target system",
 		UsageText: fmt.Sprintf("%s install [OPTIONS]", appName),
 		Action:    action,
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:        configFlg,
-				Usage:       configDesc,
+				Usage:       configDesc + remoteDesc,
 				Destination: &InstallArgs.ConfigScript,
 			},
 			&cli.StringFlag{
 				Name:        "description",
 				Aliases:     []string{"d"},
-				Usage:       "Description file to read installation details",
+				Usage:       "Description file to read installation details" + remoteDesc,
 				Destination: &InstallArgs.Description,
 			},
 			&cli.StringFlag{
@@ -66,13 +75,13 @@ func NewInstallCommand(appName string, action func(context.Context, *cli.Command
 			},
 			&cli.StringFlag{
 				Name:        overlayFlg,
-				Usage:       overlayDesc,
+				Usage:       overlayDesc + remoteDesc + " as a tarball",
 				Destination: &InstallArgs.Overlay,
 			},
 			&cli.StringFlag{
 				Name:        "target",
 				Aliases:     []string{"t"},
-				Usage:       "Target device for the installation process",
+				Usage:       "Target device for the installation process, either a device path or a disk selector such as 'largest' or 'model:Samsung*'",
 				Destination: &InstallArgs.Target,
 			},
 			&cli.BoolFlag{
@@ -104,6 +113,12 @@ func NewInstallCommand(appName string, action func(context.Context, *cli.Command
 				Usage:       localDesc,
 				Destination: &InstallArgs.Local,
 			},
+			&cli.IntFlag{
+				Name:        concurrencyFlg,
+				Usage:       concurrencyDesc,
+				Value:       1,
+				Destination: &InstallArgs.Concurrency,
+			},
 			&cli.StringFlag{
 				Name:        "crypto-policy",
 				Usage:       "Set the crypto policy of the installed system [default, fips]",
@@ -111,10 +126,41 @@ func NewInstallCommand(appName string, action func(context.Context, *cli.Command
 			},
 			&cli.StringFlag{
 				Name:        "snapshotter",
-				Usage:       "Snapshotter [snapper, overwrite]",
+				Usage:       "Snapshotter [snapper, overlay, overwrite]",
 				Value:       "snapper",
 				Destination: &InstallArgs.Snapshotter,
 			},
-		},
+			&cli.BoolFlag{
+				Name:        "auto",
+				Usage:       "Read unset installation parameters from 'elemental.install.*' kernel arguments [target, image, description, config, overlay, ignition, combustion, cloud-init]",
+				Destination: &InstallArgs.Auto,
+			},
+			&cli.StringFlag{
+				Name:        "ignition",
+				Usage:       "Ignition configuration file applied on first boot, requires a config partition" + remoteDesc,
+				Destination: &InstallArgs.Ignition,
+			},
+			&cli.StringFlag{
+				Name:        "combustion",
+				Usage:       "Combustion script, or directory including it, run on first boot, requires a config partition" + remoteDesc,
+				Destination: &InstallArgs.Combustion,
+			},
+			&cli.StringFlag{
+				Name:        "cloud-init",
+				Usage:       "Cloud-init user data file, or NoCloud seed directory, applied on first boot" + remoteDesc,
+				Destination: &InstallArgs.CloudInit,
+			},
+			&cli.StringFlag{
+				Name:        "wipe",
+				Usage:       "Wipe policy of the target disks [table, zap-all, discard, secure-discard, keep-data]",
+				Destination: &InstallArgs.Wipe,
+			},
+			&cli.BoolFlag{
+				Name:        "force",
+				Usage:       "Install even if the target disks have mounted filesystems",
+				Destination: &InstallArgs.Force,
+			},
+			yesFlag(&InstallArgs.Yes),
+		}, signatureFlags(&InstallArgs.Signature)...),
 	}
 }
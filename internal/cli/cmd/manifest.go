@@ -0,0 +1,93 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type ManifestFlags struct {
+	SkipArtifacts bool
+	Output        string
+	Tag           string
+}
+
+var ManifestArgs ManifestFlags
+
+// ManifestActions groups the actions of the manifest subcommands
+type ManifestActions struct {
+	Lint func(context.Context, *cli.Command) error
+	Pack func(context.Context, *cli.Command) error
+	Push func(context.Context, *cli.Command) error
+}
+
+func NewManifestCommand(appName string, actions ManifestActions) *cli.Command {
+	skipArtifacts := &cli.BoolFlag{
+		Name:        "skip-artifacts",
+		Usage:       "Validate the manifest without resolving the referenced images and URLs",
+		Destination: &ManifestArgs.SkipArtifacts,
+	}
+
+	return &cli.Command{
+		Name:      "manifest",
+		Usage:     "Validate, package and publish release manifests",
+		UsageText: fmt.Sprintf("%s manifest <command> [OPTIONS] <manifest-file>", appName),
+		Commands: []*cli.Command{
+			{
+				Name:      "lint",
+				Usage:     "Validate a release manifest, from a file or a file:// or oci:// URI, and resolve all its referenced artifacts",
+				UsageText: fmt.Sprintf("%s manifest lint [OPTIONS] <manifest-file|manifest-uri>", appName),
+				Action:    actions.Lint,
+				Flags:     []cli.Flag{skipArtifacts},
+			},
+			{
+				Name:      "pack",
+				Usage:     "Validate a release manifest and package it as an OCI image tarball",
+				UsageText: fmt.Sprintf("%s manifest pack [OPTIONS] --output <file> --tag <image> <manifest-file>", appName),
+				Action:    actions.Pack,
+				Flags: []cli.Flag{
+					skipArtifacts,
+					&cli.StringFlag{
+						Name:        "output",
+						Aliases:     []string{"o"},
+						Usage:       "Path of the OCI image tarball to write",
+						Destination: &ManifestArgs.Output,
+						Required:    true,
+					},
+					&cli.StringFlag{
+						Name:        "tag",
+						Aliases:     []string{"t"},
+						Usage:       "Image reference of the OCI image tarball",
+						Destination: &ManifestArgs.Tag,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:      "push",
+				Usage:     "Validate a release manifest and push it as an OCI image",
+				UsageText: fmt.Sprintf("%s manifest push [OPTIONS] <manifest-file> <image>", appName),
+				Action:    actions.Push,
+				Flags:     []cli.Flag{skipArtifacts},
+			},
+		},
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type MigrateDataFlags struct {
+	Path                 string
+	Label                string
+	Size                 uint
+	FileSystem           string
+	OperatingSystemImage string
+	Verify               bool
+	Local                bool
+}
+
+var MigrateDataArgs MigrateDataFlags
+
+func NewMigrateDataCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:      "migrate-data",
+		Usage:     "Move a shared RW volume of the system partition onto a new dedicated partition, or resize its dedicated partition, in a new snapshot",
+		UsageText: fmt.Sprintf("%s migrate-data [OPTIONS]", appName),
+		Action:    action,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "path",
+				Usage:       "Path of the RW volume to move",
+				Value:       "/var",
+				Destination: &MigrateDataArgs.Path,
+			},
+			&cli.StringFlag{
+				Name:        "label",
+				Usage:       "Label of the new partition, defaults to the upper cased volume name",
+				Destination: &MigrateDataArgs.Label,
+			},
+			&cli.UintFlag{
+				Name:        "size",
+				Usage:       "Size of the new or resized partition in MiB, 0 takes all the remaining disk space",
+				Destination: &MigrateDataArgs.Size,
+			},
+			&cli.StringFlag{
+				Name:        "filesystem",
+				Usage:       "Filesystem of the new partition (xfs, ext4 or btrfs)",
+				Value:       "xfs",
+				Destination: &MigrateDataArgs.FileSystem,
+			},
+			&cli.StringFlag{
+				Name:        osImgFlg,
+				Usage:       osImgDesc + ", the new snapshot keeps the current OS if not set",
+				Destination: &MigrateDataArgs.OperatingSystemImage,
+			},
+			&cli.BoolFlag{
+				Name:        verifyFlg,
+				Value:       true,
+				Usage:       verifyDesc,
+				Destination: &MigrateDataArgs.Verify,
+			},
+			&cli.BoolFlag{
+				Name:        localFlg,
+				Usage:       localDesc,
+				Destination: &MigrateDataArgs.Local,
+			},
+		},
+	}
+}
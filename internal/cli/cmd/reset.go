@@ -93,6 +93,13 @@ func NewResetCommand(appName string, action func(context.Context, *cli.Command)
 				Usage:       localDesc,
 				Destination: &InstallArgs.Local,
 			},
+			&cli.IntFlag{
+				Name:        concurrencyFlg,
+				Usage:       concurrencyDesc,
+				Value:       1,
+				Destination: &InstallArgs.Concurrency,
+			},
+			yesFlag(&InstallArgs.Yes),
 		},
 	}
 }
@@ -0,0 +1,62 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli/v3"
+)
+
+type RestoreFlags struct {
+	SnapshotID int
+	Path       string
+	Pending    bool
+}
+
+var RestoreArgs RestoreFlags
+
+func NewRestoreCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:      "restore",
+		Usage:     "Restores a file or directory from a previous snapshot",
+		UsageText: fmt.Sprintf("%s restore [OPTIONS] SNAPSHOT_ID PATH", appName),
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			if cmd.Args().Len() != 2 {
+				return ctx, fmt.Errorf("expected SNAPSHOT_ID and PATH arguments")
+			}
+			id, err := strconv.Atoi(cmd.Args().Get(0))
+			if err != nil || id <= 0 {
+				return ctx, fmt.Errorf("invalid snapshot ID '%s'", cmd.Args().Get(0))
+			}
+			RestoreArgs.SnapshotID = id
+			RestoreArgs.Path = cmd.Args().Get(1)
+			return ctx, nil
+		},
+		Action: action,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:        "pending",
+				Usage:       "Restore into the pending snapshot of a not yet booted upgrade instead of the running system",
+				Destination: &RestoreArgs.Pending,
+			},
+		},
+	}
+}
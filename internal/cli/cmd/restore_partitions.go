@@ -0,0 +1,57 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type RestorePartitionsFlags struct {
+	Device    string
+	BackupDir string
+	Yes       bool
+}
+
+var RestorePartitionsArgs RestorePartitionsFlags
+
+func NewRestorePartitionsCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:      "restore-partitions",
+		Usage:     "Restores the partition table and LUKS headers of a disk from the backup taken before the last install or reset",
+		UsageText: fmt.Sprintf("%s restore-partitions [OPTIONS] DEVICE", appName),
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			if cmd.Args().Len() != 1 {
+				return ctx, fmt.Errorf("expected a single DEVICE argument")
+			}
+			RestorePartitionsArgs.Device = cmd.Args().First()
+			return ctx, nil
+		},
+		Action: action,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "backup-dir",
+				Usage:       "Directory including the backup files, defaults to the backup stored in the recovery or config partition of the device",
+				Destination: &RestorePartitionsArgs.BackupDir,
+			},
+			yesFlag(&RestorePartitionsArgs.Yes),
+		},
+	}
+}
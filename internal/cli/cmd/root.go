@@ -19,20 +19,31 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/urfave/cli/v3"
 
+	"github.com/suse/elemental/v3/internal/cli/i18n"
+	"github.com/suse/elemental/v3/internal/cli/printer"
 	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/progress"
+	"github.com/suse/elemental/v3/pkg/proxy"
+	"github.com/suse/elemental/v3/pkg/registry"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/runner"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
 const Usage = "Install and upgrade immutable operating systems"
 
+// RegistryMetadataKey is the root command metadata key holding the OCI registries configuration
+const RegistryMetadataKey = "registry"
+
 var (
-	logFile *os.File
+	logFile   *os.File
+	auditFile *os.File
 )
 
 func GlobalFlags() []cli.Flag {
@@ -45,11 +56,80 @@ func GlobalFlags() []cli.Flag {
 			Name:  "log-file",
 			Usage: "Save logs to file, accepts path to file or stdout/stderr",
 		},
+		&cli.StringFlag{
+			Name:  "log-format",
+			Usage: "Format of the logs [text, json]",
+			Value: "text",
+			Validator: func(f string) error {
+				_, err := logFormat(f)
+				return err
+			},
+		},
+		&cli.StringFlag{
+			Name:  auditLogFlg,
+			Usage: "Append a JSON record of every executed command to the given file",
+		},
+		&cli.StringFlag{
+			Name:  outputFormatFlg,
+			Usage: "Format of the command results printed to stdout [text, json, yaml]",
+			Value: string(printer.Text),
+			Validator: func(f string) error {
+				_, err := printer.ParseFormat(f)
+				return err
+			},
+		},
+		&cli.StringFlag{
+			Name:  progressFlg,
+			Usage: "Progress reporting of long running operations to stderr [bar, json, none], defaults to bar on terminals",
+			Validator: func(p string) error {
+				_, err := progressReporter(p)
+				return err
+			},
+		},
+		&cli.StringFlag{
+			Name:  localeFlg,
+			Usage: "Language of the interactive prompts, defaults to the locale of the environment (LC_ALL, LC_MESSAGES, LANG)",
+		},
+		&cli.StringFlag{
+			Name:  "registry-config",
+			Usage: "Path to a YAML file defining OCI registry mirrors and insecure registries",
+		},
+		&cli.StringFlag{
+			Name:  "registry-auth-file",
+			Usage: "Path to a docker-style config.json file with OCI registry credentials",
+		},
+		&cli.StringSliceFlag{
+			Name:  "registry-auth",
+			Usage: "OCI registry credentials in 'registry=username:password' format, can be repeated",
+		},
 	}
 }
 
 func Setup(ctx context.Context, cmd *cli.Command) (context.Context, error) {
-	s, err := sys.NewSystem()
+	reporter, err := progressReporter(cmd.String(progressFlg))
+	if err != nil {
+		return ctx, err
+	}
+
+	opts := []sys.SystemOpts{sys.WithProgress(reporter)}
+	if path := cmd.String(auditLogFlg); path != "" {
+		auditFile, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return ctx, fmt.Errorf("opening audit log '%s': %w", path, err)
+		}
+		// An existing audit log keeps its mode on open, make sure it is not readable by others
+		if err = auditFile.Chmod(0600); err != nil {
+			return ctx, fmt.Errorf("setting audit log '%s' permissions: %w", path, err)
+		}
+
+		logger := log.New()
+		opts = append(opts,
+			sys.WithLogger(logger),
+			sys.WithRunner(runner.NewRunner(runner.WithLogger(logger), runner.WithAuditLog(auditFile))),
+		)
+	}
+
+	s, err := sys.NewSystem(opts...)
 	if err != nil {
 		return ctx, err
 	}
@@ -62,18 +142,172 @@ func Setup(ctx context.Context, cmd *cli.Command) (context.Context, error) {
 		return ctx, err
 	}
 
+	logFmt, err := logFormat(cmd.String("log-format"))
+	if err != nil {
+		return ctx, err
+	}
+	s.Logger().SetFormat(logFmt)
+
+	format, err := printer.ParseFormat(cmd.String(outputFormatFlg))
+	if err != nil {
+		return ctx, err
+	}
+
 	if cmd.Root().Metadata == nil {
 		cmd.Root().Metadata = map[string]any{}
 	}
 	cmd.Root().Metadata["system"] = s
+
+	reg, err := SetupRegistry(s, cmd)
+	if err != nil {
+		return ctx, err
+	}
+	cmd.Root().Metadata[RegistryMetadataKey] = reg
+	cmd.Root().Metadata[printer.MetadataKey] = printer.New(format, cmd.Root().Writer)
+
+	locale := cmd.String(localeFlg)
+	if locale == "" {
+		locale = i18n.LocaleFromEnv()
+	}
+	catalog, err := i18n.New(s.FS(), locale)
+	if err != nil {
+		s.Logger().Warn("Failed loading messages catalogs from %s, using the built-in ones: %v", i18n.CatalogDir, err)
+		catalog, err = i18n.New(nil, locale)
+		if err != nil {
+			return ctx, fmt.Errorf("loading built-in messages catalog: %w", err)
+		}
+	}
+	cmd.Root().Metadata[i18n.MetadataKey] = catalog
+	return ctx, nil
+}
+
+// WithProxy sets the given network command to configure, before running, the proxy set by the kernel
+// command line or by the WPAD URL of the DHCP lease, unless it is already set in the environment. A failed
+// detection is not fatal, the network operations are then run without a proxy.
+func WithProxy(c *cli.Command) *cli.Command {
+	before := c.Before
+	c.Before = func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+		if before != nil {
+			var err error
+			if ctx, err = before(ctx, cmd); err != nil {
+				return ctx, err
+			}
+		}
+		return detectProxy(ctx, cmd)
+	}
+	return c
+}
+
+func detectProxy(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	s := cmd.Root().Metadata["system"].(*sys.System)
+	cfg, err := proxy.Detect(ctx, s)
+	if err != nil {
+		s.Logger().Warn("Could not detect proxy settings: %v", err)
+		return ctx, nil
+	}
+	if cfg != nil {
+		if err = cfg.Apply(); err != nil {
+			return ctx, fmt.Errorf("setting proxy: %w", err)
+		}
+	}
 	return ctx, nil
 }
 
+// RedactError masks in the message of the given error the secrets registered to the logger of the system
+// set up by the given root command, such as registry passwords or join tokens. Only the well known secret
+// patterns are masked if the system was not set up.
+func RedactError(root *cli.Command, err error) string {
+	if s, ok := root.Metadata["system"].(*sys.System); ok && s != nil {
+		return s.Logger().Redact(err.Error())
+	}
+	return log.Redact(err.Error())
+}
+
 func Teardown(_ context.Context, _ *cli.Command) error {
+	var err error
+	if auditFile != nil {
+		err = auditFile.Close()
+	}
+
 	if logFile != nil {
-		return logFile.Close()
+		err = errors.Join(err, logFile.Close())
 	}
 
+	return err
+}
+
+func logFormat(f string) (log.Format, error) {
+	switch f {
+	case "", "text":
+		return log.Text, nil
+	case "json":
+		return log.JSON, nil
+	default:
+		return log.Text, fmt.Errorf("unsupported log format '%s', supported formats: text, json", f)
+	}
+}
+
+// progressReporter returns the progress reporter of the given kind writing to stderr. If no kind is
+// given progress bars are drawn only if stderr is a terminal.
+func progressReporter(kind string) (progress.Reporter, error) {
+	switch kind {
+	case "":
+		if fi, err := os.Stderr.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+			return progress.NewBar(os.Stderr), nil
+		}
+		return progress.Discard, nil
+	case "bar":
+		return progress.NewBar(os.Stderr), nil
+	case "json":
+		return progress.NewJSON(os.Stderr), nil
+	case "none":
+		return progress.Discard, nil
+	default:
+		return nil, fmt.Errorf("unsupported progress reporting '%s', supported values: bar, json, none", kind)
+	}
+}
+
+// SetupRegistry loads the OCI registries configuration from the global registry flags. It returns
+// nil if none of them is set.
+func SetupRegistry(s *sys.System, cmd *cli.Command) (*registry.Config, error) {
+	cfgFile, authFile, auths := cmd.String("registry-config"), cmd.String("registry-auth-file"), cmd.StringSlice("registry-auth")
+	if cfgFile == "" && authFile == "" && len(auths) == 0 {
+		return nil, nil
+	}
+
+	reg := &registry.Config{}
+	if cfgFile != "" {
+		var err error
+		reg, err = registry.LoadConfig(s.FS(), cfgFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if authFile != "" {
+		err := reg.LoadAuthFile(s.FS(), authFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, auth := range auths {
+		err := reg.AddAuth(auth)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.Logger().RegisterSecret(reg.Secrets()...)
+	return reg, nil
+}
+
+// RegistryConfig returns the OCI registries configuration stored in the root command metadata, if any
+func RegistryConfig(cmd *cli.Command) *registry.Config {
+	if md := cmd.Root().Metadata; md != nil {
+		if reg, ok := md[RegistryMetadataKey].(*registry.Config); ok {
+			return reg
+		}
+	}
 	return nil
 }
 
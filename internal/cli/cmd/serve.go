@@ -0,0 +1,126 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+type ServeFlags struct {
+	Listen       string
+	WorkDir      string
+	TokensFile   string
+	TLSCert      string
+	TLSKey       string
+	Workers      int
+	TenantQueue  int
+	JobUser      string
+	Retention    time.Duration
+	DrainTimeout time.Duration
+	Local        bool
+}
+
+var ServeArgs ServeFlags
+
+func NewServeCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:      "serve",
+		Usage:     "Run a build server executing the build jobs submitted by multiple tenants over an authenticated HTTP API",
+		UsageText: fmt.Sprintf("%s serve [OPTIONS]", appName),
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			if len(cmd.Root().StringSlice("registry-auth")) > 0 {
+				return ctx, fmt.Errorf("--registry-auth is not supported by the build server, use --registry-auth-file instead")
+			}
+			if ServeArgs.JobUser != "" && ServeArgs.Local {
+				return ctx, fmt.Errorf("--job-user is not supported with --%s, local images are read from the containers storage of root", localFlg)
+			}
+			return ctx, nil
+		},
+		Action: action,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "listen",
+				Usage:       "Address the API listens on",
+				Value:       ":8443",
+				Destination: &ServeArgs.Listen,
+			},
+			&cli.StringFlag{
+				Name:        "work-dir",
+				Usage:       "Directory including the workspace of each job, their definitions and artifacts",
+				Value:       "/var/lib/elemental/buildserver",
+				Destination: &ServeArgs.WorkDir,
+			},
+			&cli.StringFlag{
+				Name:        "tokens-file",
+				Usage:       "YAML file mapping each tenant name to its API bearer token",
+				Required:    true,
+				Destination: &ServeArgs.TokensFile,
+			},
+			&cli.StringFlag{
+				Name:        "tls-cert",
+				Usage:       "TLS certificate of the API",
+				Required:    true,
+				Destination: &ServeArgs.TLSCert,
+			},
+			&cli.StringFlag{
+				Name:        "tls-key",
+				Usage:       "TLS private key of the API",
+				Required:    true,
+				Destination: &ServeArgs.TLSKey,
+			},
+			&cli.IntFlag{
+				Name:        "workers",
+				Usage:       "Number of jobs built concurrently",
+				Value:       1,
+				Destination: &ServeArgs.Workers,
+			},
+			&cli.IntFlag{
+				Name:        "tenant-queue-size",
+				Usage:       "Number of queued jobs of each tenant before its new jobs are rejected",
+				Value:       8,
+				Destination: &ServeArgs.TenantQueue,
+			},
+			&cli.StringFlag{
+				Name:        "job-user",
+				Usage:       "Unprivileged user the jobs are built as, owning their workspaces. The registry configuration must be readable by it",
+				Destination: &ServeArgs.JobUser,
+			},
+			&cli.DurationFlag{
+				Name:        "retention",
+				Usage:       "How long finished jobs and their artifacts are kept",
+				Value:       24 * time.Hour,
+				Destination: &ServeArgs.Retention,
+			},
+			&cli.DurationFlag{
+				Name:        "drain-timeout",
+				Usage:       "How long the queued and running jobs are waited for on shutdown before aborting them",
+				Value:       time.Hour,
+				Destination: &ServeArgs.DrainTimeout,
+			},
+			&cli.BoolFlag{
+				Name:        localFlg,
+				Usage:       localDesc,
+				Destination: &ServeArgs.Local,
+			},
+		},
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type SnapshotFlags struct {
+	Important bool
+	Tags      []string
+	Remove    bool
+}
+
+var SnapshotArgs SnapshotFlags
+
+// SnapshotActions groups the actions of the snapshot subcommands
+type SnapshotActions struct {
+	List   func(context.Context, *cli.Command) error
+	Delete func(context.Context, *cli.Command) error
+	Tag    func(context.Context, *cli.Command) error
+	Diff   func(context.Context, *cli.Command) error
+}
+
+func NewSnapshotCommand(appName string, actions SnapshotActions) *cli.Command {
+	return &cli.Command{
+		Name:      "snapshot",
+		Usage:     "Manage the snapshots of the system",
+		UsageText: fmt.Sprintf("%s snapshot <command> [OPTIONS]", appName),
+		Commands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "List the snapshots",
+				UsageText: fmt.Sprintf("%s snapshot list", appName),
+				Action:    actions.List,
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a snapshot, the active and default snapshots can't be deleted",
+				UsageText: fmt.Sprintf("%s snapshot delete <id>", appName),
+				Action:    actions.Delete,
+			},
+			{
+				Name:      "tag",
+				Usage:     "Tag a snapshot, tagged snapshots are kept by the retention policy if configured so",
+				UsageText: fmt.Sprintf("%s snapshot tag <id> [OPTIONS]", appName),
+				Action:    actions.Tag,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:        "important",
+						Usage:       "Tag the snapshot as important",
+						Destination: &SnapshotArgs.Important,
+					},
+					&cli.StringSliceFlag{
+						Name:        "tag",
+						Usage:       "Custom tag to set, can be repeated",
+						Destination: &SnapshotArgs.Tags,
+					},
+					&cli.BoolFlag{
+						Name:        "remove",
+						Usage:       "Remove the given tags instead of setting them",
+						Destination: &SnapshotArgs.Remove,
+					},
+				},
+			},
+			{
+				Name:      "diff",
+				Usage:     "List the files changed between two snapshots",
+				UsageText: fmt.Sprintf("%s snapshot diff <id> <id>", appName),
+				Action:    actions.Diff,
+			},
+		},
+	}
+}
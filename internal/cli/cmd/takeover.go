@@ -0,0 +1,63 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type TakeoverFlags struct {
+	InstallerISO  string
+	KernelCmdline string
+	Reboot        bool
+	Yes           bool
+}
+
+var TakeoverArgs TakeoverFlags
+
+func NewTakeoverCommand(appName string, action func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:      "takeover",
+		Usage:     "Stages an installer ISO to replace the currently running operating system",
+		UsageText: fmt.Sprintf("%s takeover [OPTIONS] INSTALLER_ISO", appName),
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			if cmd.Args().Len() != 1 {
+				return ctx, fmt.Errorf("expected a single INSTALLER_ISO argument")
+			}
+			TakeoverArgs.InstallerISO = cmd.Args().First()
+			return ctx, nil
+		},
+		Action: action,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        cmdlineFlg,
+				Usage:       "Additional kernel cmdline for the live installer",
+				Destination: &TakeoverArgs.KernelCmdline,
+			},
+			&cli.BoolFlag{
+				Name:        "reboot",
+				Usage:       "Reboot into the live installer once staged, otherwise it boots on the next 'systemctl kexec'",
+				Destination: &TakeoverArgs.Reboot,
+			},
+			yesFlag(&TakeoverArgs.Yes),
+		},
+	}
+}
@@ -26,11 +26,12 @@ import (
 )
 
 type UnpackFlags struct {
-	Image     string
-	TargetDir string
-	Platform  string
-	Local     bool
-	Verify    bool
+	Image       string
+	TargetDir   string
+	Platform    string
+	Local       bool
+	Concurrency int
+	Verify      bool
 }
 
 var UnpackArgs UnpackFlags
@@ -72,6 +73,12 @@ func NewUnpackImageCommand(appName string, action func(context.Context, *cli.Com
 				Usage:       localDesc,
 				Destination: &UnpackArgs.Local,
 			},
+			&cli.IntFlag{
+				Name:        concurrencyFlg,
+				Usage:       concurrencyDesc,
+				Value:       1,
+				Destination: &UnpackArgs.Concurrency,
+			},
 		},
 	}
 }
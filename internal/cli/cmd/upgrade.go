@@ -20,8 +20,11 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/pkg/watchdog"
 )
 
 type UpgradeFlags struct {
@@ -31,6 +34,16 @@ type UpgradeFlags struct {
 	Verify               bool
 	CreateBootEntry      bool
 	Local                bool
+	Concurrency          int
+	Signature            SignatureFlags
+	Delta                bool
+	Kexec                bool
+	Watchdog             bool
+	WatchdogTimeout      time.Duration
+	HooksDir             string
+	LifecycleSocket      string
+	Layout               string
+	AllowDowngrade       bool
 }
 
 var UpgradeArgs UpgradeFlags
@@ -41,7 +54,7 @@ func NewUpgradeCommand(appName string, action func(context.Context, *cli.Command
 		Usage:     "Upgrade system from an OS image",
 		UsageText: fmt.Sprintf("%s upgrade [OPTIONS]", appName),
 		Action:    action,
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:        osImgFlg,
 				Usage:       osImgDesc,
@@ -74,6 +87,54 @@ func NewUpgradeCommand(appName string, action func(context.Context, *cli.Command
 				Usage:       localDesc,
 				Destination: &UpgradeArgs.Local,
 			},
-		},
+			&cli.IntFlag{
+				Name:        concurrencyFlg,
+				Usage:       concurrencyDesc,
+				Value:       1,
+				Destination: &UpgradeArgs.Concurrency,
+			},
+			&cli.BoolFlag{
+				Name:        "delta",
+				Usage:       "Only apply the new layers of the OS image on top of the current snapshot, requires the snapper snapshotter and an image built on top of the installed one",
+				Destination: &UpgradeArgs.Delta,
+			},
+			&cli.BoolFlag{
+				Name:        "kexec",
+				Usage:       "Reboot into the upgraded snapshot with kexec, skipping firmware initialization",
+				Destination: &UpgradeArgs.Kexec,
+			},
+			&cli.BoolFlag{
+				Name:        "watchdog",
+				Usage:       "Arm the hardware watchdog while switching to the upgraded snapshot and fall back to the previous one if its first boot hangs",
+				Destination: &UpgradeArgs.Watchdog,
+			},
+			&cli.DurationFlag{
+				Name:        "watchdog-timeout",
+				Usage:       "Time without progress after which the watchdog resets the system",
+				Value:       watchdog.DefaultTimeout,
+				Destination: &UpgradeArgs.WatchdogTimeout,
+			},
+			&cli.StringFlag{
+				Name:        "hooks-dir",
+				Usage:       "Directory of the scripts executed at each upgrade stage, from its 'before-sync.d', 'after-merge.d', 'before-commit.d' and 'after-commit.d' subdirectories, and of the scripts validating the new snapshot before committing it, from its 'validate.d' subdirectory",
+				Value:       "/etc/elemental/hooks",
+				Destination: &UpgradeArgs.HooksDir,
+			},
+			&cli.BoolFlag{
+				Name:        "allow-downgrade",
+				Usage:       "Allow upgrading to an OS image older than the installed one, by default an older os-release VERSION_ID aborts the upgrade",
+				Destination: &UpgradeArgs.AllowDowngrade,
+			},
+			&cli.StringFlag{
+				Name:        "layout",
+				Usage:       "Deployment description file with the updated disk layout, the last data partition of a disk can be grown or shrunk and new data partitions can be added at the end of a disk",
+				Destination: &UpgradeArgs.Layout,
+			},
+			&cli.StringFlag{
+				Name:        "lifecycle-socket",
+				Usage:       "Unix socket notifying node-local agents about each upgrade stage and letting them veto or delay the upgrade before it is committed, e.g. /run/elemental/lifecycle.sock. A socket passed by systemd socket activation is used and kept after the upgrade",
+				Destination: &UpgradeArgs.LifecycleSocket,
+			},
+		}, signatureFlags(&UpgradeArgs.Signature)...),
 	}
 }
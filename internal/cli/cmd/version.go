@@ -20,8 +20,11 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/printer"
 )
 
 var (
@@ -30,21 +33,40 @@ var (
 	gitCommit = ""
 )
 
+type versionResult struct {
+	Version string `yaml:"version"`
+	Commit  string `yaml:"commit"`
+}
+
+// Version returns the version of the program including the short git commit, if known
+func Version() string {
+	if gitCommit == "" {
+		return version
+	}
+	commit := gitCommit
+	if len(commit) > 7 {
+		commit = gitCommit[:7]
+	}
+	return fmt.Sprintf("%s+g%s", version, commit)
+}
+
 func NewVersionCommand(appName string) *cli.Command {
 	return &cli.Command{
 		Name:      "version",
 		Aliases:   []string{"v"},
 		Usage:     "Inspect program version",
 		UsageText: fmt.Sprintf("%s version", appName),
-		Action: func(_ context.Context, _ *cli.Command) error {
+		Action: func(_ context.Context, cmd *cli.Command) error {
 			commit := gitCommit
 			if len(commit) > 7 {
 				commit = gitCommit[:7]
 			}
 
-			fmt.Printf("%s+g%s\n", version, commit)
-
-			return nil
+			result := versionResult{Version: version, Commit: gitCommit}
+			return printer.FromCommand(cmd).Print(result, func(w io.Writer) error {
+				_, err := fmt.Fprintf(w, "%s+g%s\n", version, commit)
+				return err
+			})
 		},
 	}
 }
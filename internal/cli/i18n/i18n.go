@@ -0,0 +1,203 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i18n
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/text/language"
+
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// MetadataKey is the root command metadata key holding the messages catalog
+const MetadataKey = "i18n"
+
+// CatalogDir is the directory of additional catalogs shipped with the OS image, named after their
+// language, e.g. 'de.yaml'. Messages defined there take precedence over the built-in ones.
+const CatalogDir = "/usr/share/elemental/locale"
+
+// Keys of the user facing messages. Log messages are not translated.
+const (
+	ConfirmInstall           = "confirm.install"
+	ConfirmReset             = "confirm.reset"
+	ConfirmRestorePartitions = "confirm.restore-partitions"
+	ConfirmTakeover          = "confirm.takeover"
+)
+
+//go:embed locales/*.yaml
+var locales embed.FS
+
+// Catalog holds the user facing messages of the selected language
+type Catalog struct {
+	lang     language.Tag
+	messages map[string]string
+	fallback map[string]string
+}
+
+// New returns the catalog best matching the given locale, e.g. 'de_DE.UTF-8'. English is used
+// if no catalog matches the locale. Messages missing in the matched catalog fall back to English.
+func New(fsys vfs.FS, locale string) (*Catalog, error) {
+	catalogs := map[language.Tag]map[string]string{}
+
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		err = loadCatalog(catalogs, entry.Name(), func() ([]byte, error) {
+			return locales.ReadFile(filepath.Join("locales", entry.Name()))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fsys != nil {
+		entries, err = fsys.ReadDir(CatalogDir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading catalogs directory: %w", err)
+		}
+		for _, entry := range entries {
+			err = loadCatalog(catalogs, entry.Name(), func() ([]byte, error) {
+				return fsys.ReadFile(filepath.Join(CatalogDir, entry.Name()))
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// English goes first so the matcher falls back to it
+	tags := []language.Tag{language.English}
+	for tag := range catalogs {
+		if tag != language.English {
+			tags = append(tags, tag)
+		}
+	}
+
+	_, idx, _ := language.NewMatcher(tags).Match(parseLocale(locale))
+	return &Catalog{
+		lang:     tags[idx],
+		messages: catalogs[tags[idx]],
+		fallback: catalogs[language.English],
+	}, nil
+}
+
+// Default returns the built-in catalog matching the locale of the environment
+func Default() *Catalog {
+	c, err := New(nil, LocaleFromEnv())
+	if err != nil {
+		return &Catalog{lang: language.English}
+	}
+	return c
+}
+
+// FromCommand returns the catalog stored in the root command metadata. It defaults to
+// the built-in catalog matching the locale of the environment if none is set.
+func FromCommand(cmd *cli.Command) *Catalog {
+	if md := cmd.Root().Metadata; md != nil {
+		if c, ok := md[MetadataKey].(*Catalog); ok && c != nil {
+			return c
+		}
+	}
+	return Default()
+}
+
+// LocaleFromEnv returns the messages locale set by the environment following the POSIX precedence
+func LocaleFromEnv() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if l := os.Getenv(env); l != "" {
+			return l
+		}
+	}
+	return ""
+}
+
+// Language returns the language of the catalog
+func (c Catalog) Language() string {
+	return c.lang.String()
+}
+
+// T returns the message of the given key formatted with the given arguments. The key itself
+// is returned if the message is not defined in any catalog.
+func (c Catalog) T(key string, args ...any) string {
+	msg, ok := c.messages[key]
+	if !ok {
+		msg, ok = c.fallback[key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// loadCatalog parses the catalog file of the given name and merges its messages into the given catalogs
+func loadCatalog(catalogs map[language.Tag]map[string]string, name string, read func() ([]byte, error)) error {
+	lang, ok := strings.CutSuffix(name, ".yaml")
+	if !ok {
+		return nil
+	}
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return fmt.Errorf("invalid catalog language '%s': %w", lang, err)
+	}
+
+	data, err := read()
+	if err != nil {
+		return fmt.Errorf("reading catalog '%s': %w", name, err)
+	}
+	messages := map[string]string{}
+	if err = yaml.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("parsing catalog '%s': %w", name, err)
+	}
+
+	if catalogs[tag] == nil {
+		catalogs[tag] = map[string]string{}
+	}
+	for key, msg := range messages {
+		catalogs[tag][key] = msg
+	}
+	return nil
+}
+
+// parseLocale converts a POSIX locale such as 'pt_BR.UTF-8@euro' to a language tag
+func parseLocale(locale string) language.Tag {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	switch locale {
+	case "", "C", "POSIX":
+		return language.English
+	}
+	tag, err := language.Parse(strings.ReplaceAll(locale, "_", "-"))
+	if err != nil {
+		return language.English
+	}
+	return tag
+}
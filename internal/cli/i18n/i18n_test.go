@@ -0,0 +1,96 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i18n_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/i18n"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestI18nSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "I18n test suite")
+}
+
+var _ = Describe("Catalog", Label("i18n"), func() {
+	var tfs vfs.FS
+	var cleanup func()
+	var err error
+
+	BeforeEach(func() {
+		tfs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("selects the catalog matching a POSIX locale", func() {
+		c, err := i18n.New(tfs, "de_DE.UTF-8")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Language()).To(Equal("de"))
+		Expect(c.T(i18n.ConfirmInstall, "/dev/sda")).To(ContainSubstring("Geben Sie '/dev/sda' ein"))
+	})
+	It("falls back to English for unknown or POSIX locales", func() {
+		for _, locale := range []string{"", "C", "POSIX", "ja_JP.UTF-8", "invalid"} {
+			c, err := i18n.New(tfs, locale)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Language()).To(Equal("en"))
+			Expect(c.T(i18n.ConfirmReset, "/dev/sda")).To(HavePrefix("This will wipe /dev/sda"))
+		}
+	})
+	It("returns the key of undefined messages", func() {
+		c, err := i18n.New(tfs, "es")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.T("undefined.message")).To(Equal("undefined.message"))
+	})
+	It("loads additional catalogs from the catalogs directory", func() {
+		Expect(vfs.MkdirAll(tfs, i18n.CatalogDir, vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile(i18n.CatalogDir+"/fr.yaml", []byte("confirm.install: \"Tapez '%[1]s'\"\n"), vfs.FilePerm)).To(Succeed())
+		Expect(tfs.WriteFile(i18n.CatalogDir+"/de.yaml", []byte("confirm.reset: \"Zurücksetzen %[1]s\"\n"), vfs.FilePerm)).To(Succeed())
+
+		c, err := i18n.New(tfs, "fr_FR")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Language()).To(Equal("fr"))
+		Expect(c.T(i18n.ConfirmInstall, "/dev/sda")).To(Equal("Tapez '/dev/sda'"))
+		Expect(c.T(i18n.ConfirmReset, "/dev/sda")).To(HavePrefix("This will wipe /dev/sda"))
+
+		c, err = i18n.New(tfs, "de")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.T(i18n.ConfirmReset, "/dev/sda")).To(Equal("Zurücksetzen /dev/sda"))
+		Expect(c.T(i18n.ConfirmInstall, "/dev/sda")).To(HavePrefix("Dies löscht /dev/sda"))
+	})
+	It("fails on invalid catalogs", func() {
+		Expect(vfs.MkdirAll(tfs, i18n.CatalogDir, vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile(i18n.CatalogDir+"/de.yaml", []byte("- not a map"), vfs.FilePerm)).To(Succeed())
+		_, err = i18n.New(tfs, "de")
+		Expect(err).To(MatchError(ContainSubstring("parsing catalog 'de.yaml'")))
+	})
+	It("returns the catalog stored in the command metadata", func() {
+		c, err := i18n.New(tfs, "es_ES")
+		Expect(err).NotTo(HaveOccurred())
+		cmd := &cli.Command{Metadata: map[string]any{i18n.MetadataKey: c}}
+		Expect(i18n.FromCommand(cmd).Language()).To(Equal("es"))
+	})
+})
@@ -0,0 +1,132 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v3"
+	"go.yaml.in/yaml/v3"
+)
+
+// MetadataKey is the root command metadata key holding the command results printer
+const MetadataKey = "printer"
+
+type Format string
+
+const (
+	Text Format = "text"
+	JSON Format = "json"
+	YAML Format = "yaml"
+)
+
+func ParseFormat(f string) (Format, error) {
+	switch Format(f) {
+	case "", Text:
+		return Text, nil
+	case JSON:
+		return JSON, nil
+	case YAML:
+		return YAML, nil
+	default:
+		return "", fmt.Errorf("unsupported output format '%s', supported formats: %s, %s, %s", f, Text, JSON, YAML)
+	}
+}
+
+// Printer writes command results to the given writer using the configured format.
+// Logs are not affected by the output format, they keep going to the logger output.
+type Printer struct {
+	format Format
+	out    io.Writer
+}
+
+func New(format Format, out io.Writer) *Printer {
+	if out == nil {
+		out = os.Stdout
+	}
+	return &Printer{format: format, out: out}
+}
+
+// FromCommand returns the printer stored in the root command metadata. It defaults
+// to a text printer over the command writer if none is set.
+func FromCommand(cmd *cli.Command) *Printer {
+	if md := cmd.Root().Metadata; md != nil {
+		if p, ok := md[MetadataKey].(*Printer); ok && p != nil {
+			return p
+		}
+	}
+	out := cmd.Writer
+	if out == nil {
+		out = cmd.Root().Writer
+	}
+	return New(Text, out)
+}
+
+func (p Printer) Format() Format {
+	return p.format
+}
+
+func (p Printer) Writer() io.Writer {
+	return p.out
+}
+
+// Print writes the given result in the configured format. The result is serialized
+// using its yaml tags for both JSON and YAML formats, so result types only need to
+// define yaml tags. The text function is used for the text format, a nil text function
+// prints nothing.
+func (p Printer) Print(result any, text func(io.Writer) error) error {
+	switch p.format {
+	case JSON:
+		data, err := toJSON(result)
+		if err != nil {
+			return fmt.Errorf("marshalling result to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(p.out, string(data))
+		return err
+	case YAML:
+		data, err := yaml.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("marshalling result to YAML: %w", err)
+		}
+		_, err = p.out.Write(data)
+		return err
+	default:
+		if text == nil {
+			return nil
+		}
+		return text(p.out)
+	}
+}
+
+// toJSON converts the given value to JSON honoring its yaml tags and custom yaml marshallers
+func toJSON(v any) ([]byte, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic any
+	if err = yaml.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(generic, "", "  ")
+}
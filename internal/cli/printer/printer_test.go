@@ -0,0 +1,108 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printer_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/printer"
+	"github.com/suse/elemental/v3/pkg/deployment"
+)
+
+func TestPrinterSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Printer test suite")
+}
+
+type result struct {
+	Name   string                  `yaml:"name"`
+	Size   int                     `yaml:"size,omitempty"`
+	Source *deployment.ImageSource `yaml:"source"`
+}
+
+var _ = Describe("Printer", Label("printer"), func() {
+	var buffer *bytes.Buffer
+	var res result
+	var text func(io.Writer) error
+
+	BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		src := deployment.NewOCISrc("registry.org/my/os:latest")
+		src.SetDigest("sha256:abcd")
+		res = result{Name: "test", Source: src}
+		text = func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, "plain text")
+			return err
+		}
+	})
+	It("parses supported formats", func() {
+		f, err := printer.ParseFormat("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f).To(Equal(printer.Text))
+		f, err = printer.ParseFormat("json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f).To(Equal(printer.JSON))
+		f, err = printer.ParseFormat("yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f).To(Equal(printer.YAML))
+		_, err = printer.ParseFormat("xml")
+		Expect(err).To(HaveOccurred())
+	})
+	It("prints text results using the given text function", func() {
+		Expect(printer.New(printer.Text, buffer).Print(res, text)).To(Succeed())
+		Expect(buffer.String()).To(Equal("plain text\n"))
+	})
+	It("prints nothing for text format without text function", func() {
+		Expect(printer.New(printer.Text, buffer).Print(res, nil)).To(Succeed())
+		Expect(buffer.String()).To(BeEmpty())
+	})
+	It("prints JSON results honoring yaml tags and marshallers", func() {
+		Expect(printer.New(printer.JSON, buffer).Print(res, text)).To(Succeed())
+		Expect(buffer.String()).To(MatchJSON(`{
+			"name": "test",
+			"source": {"uri": "oci://registry.org/my/os:latest", "digest": "sha256:abcd"}
+		}`))
+	})
+	It("prints YAML results", func() {
+		Expect(printer.New(printer.YAML, buffer).Print(res, text)).To(Succeed())
+		Expect(buffer.String()).To(MatchYAML(`
+name: test
+source:
+  uri: oci://registry.org/my/os:latest
+  digest: sha256:abcd
+`))
+	})
+	It("gets the printer from the command metadata", func() {
+		p := printer.New(printer.JSON, buffer)
+		cmd := &cli.Command{Metadata: map[string]any{printer.MetadataKey: p}}
+		Expect(printer.FromCommand(cmd)).To(BeIdenticalTo(p))
+	})
+	It("defaults to a text printer over the command writer", func() {
+		cmd := &cli.Command{Writer: buffer}
+		p := printer.FromCommand(cmd)
+		Expect(p.Format()).To(Equal(printer.Text))
+		Expect(p.Writer()).To(BeIdenticalTo(buffer))
+	})
+})
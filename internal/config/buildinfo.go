@@ -0,0 +1,132 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// BuildInfo records the inputs an image was built from, so a running system can be traced back to them
+type BuildInfo struct {
+	// DefinitionDigest is the digest of the files of the configuration directory
+	DefinitionDigest string `yaml:"definitionDigest"`
+	// ManifestDigest is the digest of the resolved release manifests, which pin the version of every component
+	ManifestDigest  string    `yaml:"manifestDigest"`
+	ReleaseManifest string    `yaml:"releaseManifest,omitempty"`
+	ReleaseVersion  string    `yaml:"releaseVersion,omitempty"`
+	BuilderVersion  string    `yaml:"builderVersion"`
+	BuildTime       time.Time `yaml:"buildTime"`
+}
+
+// WithBuildInfo writes the build information of the given configuration directory and builder version
+// into the overlays, at image.BuildInfoPath
+func WithBuildInfo(configDir, builderVersion string) Opts {
+	return func(m *Manager) {
+		m.configDir = configDir
+		m.builderVersion = builderVersion
+	}
+}
+
+// writeBuildInfo writes the build information into the overlays. It is skipped if no configuration
+// directory is set.
+func (m *Manager) writeBuildInfo(ctx context.Context, conf *image.Configuration, rm *resolver.ResolvedManifest, output Output) error {
+	if m.configDir == "" {
+		return nil
+	}
+
+	defDigest, err := DefinitionDigest(ctx, m.system.FS(), m.configDir)
+	if err != nil {
+		return fmt.Errorf("computing definition digest: %w", err)
+	}
+
+	rmData, err := yaml.Marshal(rm)
+	if err != nil {
+		return fmt.Errorf("serializing resolved release manifest: %w", err)
+	}
+
+	info := BuildInfo{
+		DefinitionDigest: defDigest,
+		ManifestDigest:   digest(rmData),
+		ReleaseManifest:  conf.Release.ManifestURI,
+		BuilderVersion:   m.builderVersion,
+		BuildTime:        time.Now().UTC().Truncate(time.Second),
+	}
+	if md := rm.Metadata(); md != nil {
+		info.ReleaseVersion = md.Version
+	}
+	data, err := yaml.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("serializing build information: %w", err)
+	}
+
+	infoFile := filepath.Join(output.OverlaysDir(), image.BuildInfoPath())
+	if err = vfs.MkdirAll(m.system.FS(), filepath.Dir(infoFile), vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating build information directory in overlays: %w", err)
+	}
+	if err = m.system.FS().WriteFile(infoFile, data, vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing build information: %w", err)
+	}
+
+	m.system.Logger().Info("Build information written")
+	return nil
+}
+
+// DefinitionDigest returns the digest of the regular files of the given configuration directory,
+// including their paths relative to it
+func DefinitionDigest(ctx context.Context, fsys vfs.FS, configDir string) (string, error) {
+	h := sha256.New()
+	err := vfs.WalkDirContext(ctx, fsys, configDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(configDir, path)
+		if err != nil {
+			return err
+		}
+		data, err := fsys.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(h, "%s %s\n", digest(data), rel)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
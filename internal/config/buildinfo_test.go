@@ -0,0 +1,113 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/release"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("BuildInfo", func() {
+	var output = Output{
+		RootPath: "/_out",
+	}
+
+	var fs vfs.FS
+	var cleanup func()
+	var system *sys.System
+	var conf *image.Configuration
+
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/config/install.yaml": "bootloader: grub\n",
+			"/config/release.yaml": "manifestURI: oci://registry.example.com/release:1.0\n",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		system, err = sys.NewSystem(
+			sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		conf = &image.Configuration{
+			Release: release.Release{ManifestURI: "oci://registry.example.com/release:1.0"},
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("Writes the build information into the overlays", func() {
+		m := NewManager(system, nil, WithBuildInfo("/config", "v3.1.0+gabcdef0"))
+		rm := &resolver.ResolvedManifest{
+			CorePlatform: &core.ReleaseManifest{Metadata: &api.Metadata{Name: "core", Version: "1.0.0"}},
+		}
+		Expect(m.writeBuildInfo(context.Background(), conf, rm, output)).To(Succeed())
+
+		data, err := fs.ReadFile(filepath.Join(output.OverlaysDir(), image.BuildInfoPath()))
+		Expect(err).ToNot(HaveOccurred())
+
+		info := BuildInfo{}
+		Expect(yaml.Unmarshal(data, &info)).To(Succeed())
+		Expect(info.DefinitionDigest).To(HavePrefix("sha256:"))
+		Expect(info.ManifestDigest).To(HavePrefix("sha256:"))
+		Expect(info.ReleaseManifest).To(Equal("oci://registry.example.com/release:1.0"))
+		Expect(info.ReleaseVersion).To(Equal("1.0.0"))
+		Expect(info.BuilderVersion).To(Equal("v3.1.0+gabcdef0"))
+		Expect(info.BuildTime.IsZero()).To(BeFalse())
+	})
+
+	It("Skips the build information without configuration directory", func() {
+		m := NewManager(system, nil)
+		Expect(m.writeBuildInfo(context.Background(), conf, &resolver.ResolvedManifest{}, output)).To(Succeed())
+
+		exists, _ := vfs.Exists(fs, filepath.Join(output.OverlaysDir(), image.BuildInfoPath()))
+		Expect(exists).To(BeFalse())
+	})
+
+	It("Computes a definition digest tracking the configuration files", func() {
+		digest, err := DefinitionDigest(context.Background(), fs, "/config")
+		Expect(err).ToNot(HaveOccurred())
+
+		again, err := DefinitionDigest(context.Background(), fs, "/config")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(again).To(Equal(digest))
+
+		Expect(fs.WriteFile("/config/install.yaml", []byte("bootloader: none\n"), vfs.FilePerm)).To(Succeed())
+		changed, err := DefinitionDigest(context.Background(), fs, "/config")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).ToNot(Equal(digest))
+	})
+})
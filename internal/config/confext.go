@@ -0,0 +1,71 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/extensions"
+)
+
+const confextPresetName = "50-elemental-confext.preset"
+
+// configureConfexts packages the configuration trees of the definition into confext images in the overlays
+// and enables the systemd-confext service merging them into /etc at boot.
+func (m *Manager) configureConfexts(ctx context.Context, conf *image.Configuration, output Output) error {
+	if len(conf.Confexts) == 0 {
+		m.system.Logger().Info("Configuration extensions not provided, skipping.")
+		return nil
+	}
+
+	if m.fetchOnly {
+		m.system.Logger().Info("Fetching artifacts only, skipping packaging configuration extensions.")
+		return nil
+	}
+
+	confextsDir := filepath.Join(output.OverlaysDir(), image.ConfextsPath())
+	for _, tree := range conf.Confexts {
+		name := filepath.Base(tree)
+		m.system.Logger().Info("Packaging configuration extension '%s'", name)
+
+		if _, err := extensions.BuildConfext(
+			ctx, m.system, name, tree, confextsDir, m.confextSigning, imagesCompression(conf),
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := m.enableServices(output, confextPresetName, "systemd-confext.service"); err != nil {
+		return fmt.Errorf("enabling systemd-confext service: %w", err)
+	}
+
+	m.system.Logger().Info("Configuration extensions configured")
+	return nil
+}
+
+// imagesCompression returns the compression of the images set in the installation of the given configuration, if any
+func imagesCompression(conf *image.Configuration) *deployment.Compression {
+	if conf.Installation.Compression == nil {
+		return nil
+	}
+	return conf.Installation.Compression.Images
+}
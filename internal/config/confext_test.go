@@ -0,0 +1,110 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Confexts", func() {
+	var output = Output{
+		RootPath: "/_out",
+	}
+
+	var system *sys.System
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var err error
+
+	BeforeEach(func() {
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/config/confexts/motd/etc/motd":  "welcome\n",
+			"/config/confexts/sshd/etc/ssh/a": "",
+			"/config/confexts/bad/usr/bin/b":  "",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		runner = sysmock.NewRunner()
+		system, err = sys.NewSystem(
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithFS(fs),
+			sys.WithRunner(runner),
+		)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("Skips configuration", func() {
+		m := NewManager(system, nil)
+		Expect(m.configureConfexts(context.Background(), &image.Configuration{}, output)).To(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+
+		exists, _ := vfs.Exists(fs, filepath.Join(output.OverlaysDir(), image.SystemdPresetPath(), confextPresetName))
+		Expect(exists).To(BeFalse())
+	})
+
+	It("Packages signed confext images and enables systemd-confext", func() {
+		m := NewManager(system, nil, WithConfextSigning("/keys/confext.key", "/keys/confext.crt"))
+		conf := &image.Configuration{
+			Confexts: []string{"/config/confexts/motd", "/config/confexts/sshd"},
+		}
+		Expect(m.configureConfexts(context.Background(), conf, output)).To(Succeed())
+
+		confextsDir := filepath.Join(output.OverlaysDir(), image.ConfextsPath())
+		cmds := runner.GetCmds()
+		Expect(cmds).To(HaveLen(2))
+		Expect(cmds[0][0]).To(Equal("systemd-repart"))
+		Expect(cmds[0]).To(ContainElements("--private-key=/keys/confext.key", filepath.Join(confextsDir, "motd.raw")))
+		Expect(cmds[1]).To(ContainElement(filepath.Join(confextsDir, "sshd.raw")))
+
+		data, err := fs.ReadFile(filepath.Join(output.OverlaysDir(), image.SystemdPresetPath(), confextPresetName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("enable systemd-confext.service\n"))
+	})
+
+	It("Skips packaging when only fetching artifacts", func() {
+		m := NewManager(system, nil, WithFetchOnly())
+		conf := &image.Configuration{
+			Confexts: []string{"/config/confexts/motd"},
+		}
+		Expect(m.configureConfexts(context.Background(), conf, output)).To(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+
+	It("Fails on configuration trees without /etc", func() {
+		m := NewManager(system, nil)
+		conf := &image.Configuration{
+			Confexts: []string{"/config/confexts/bad"},
+		}
+		Expect(m.configureConfexts(context.Background(), conf, output)).To(MatchError(ContainSubstring("invalid confext 'bad'")))
+	})
+})
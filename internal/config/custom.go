@@ -18,6 +18,7 @@ limitations under the License.
 package config
 
 import (
+	"context"
 	_ "embed"
 	"fmt"
 	"path/filepath"
@@ -33,7 +34,7 @@ var (
 	catalystScript string
 )
 
-func (m *Manager) configureCustomScripts(conf *image.Configuration, output Output) error {
+func (m *Manager) configureCustomScripts(ctx context.Context, conf *image.Configuration, output Output) error {
 	if conf.Custom.ScriptsDir == "" {
 		m.system.Logger().Info("Custom configuration scripts not provided, skipping.")
 		return nil
@@ -57,11 +58,11 @@ func (m *Manager) configureCustomScripts(conf *image.Configuration, output Outpu
 		return nil
 	}
 
-	if err := vfs.CopyDir(fs, conf.Custom.ScriptsDir, catalystDir, false, appendScript); err != nil {
+	if err := vfs.CopyDirContext(ctx, fs, conf.Custom.ScriptsDir, catalystDir, false, appendScript); err != nil {
 		return err
 	}
 
-	if err := vfs.CopyDir(fs, conf.Custom.FilesDir, catalystDir, true, nil); err != nil {
+	if err := vfs.CopyDirContext(ctx, fs, conf.Custom.FilesDir, catalystDir, true, nil); err != nil {
 		return err
 	}
 
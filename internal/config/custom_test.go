@@ -18,6 +18,7 @@ limitations under the License.
 package config
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 
@@ -66,7 +67,7 @@ var _ = Describe("Custom", func() {
 	})
 
 	It("Skips configuration", func() {
-		err := m.configureCustomScripts(&image.Configuration{}, Output{})
+		err := m.configureCustomScripts(context.Background(), &image.Configuration{}, Output{})
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(vfs.Exists(fs, catalystScriptPath)).To(BeFalse())
@@ -87,7 +88,7 @@ var _ = Describe("Custom", func() {
 			},
 		}
 
-		err = manager.configureCustomScripts(conf, output)
+		err = manager.configureCustomScripts(context.Background(), conf, output)
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(ContainSubstring("creating catalyst directory in overlays:")))
 
@@ -101,7 +102,7 @@ var _ = Describe("Custom", func() {
 			},
 		}
 
-		err := m.configureCustomScripts(conf, output)
+		err := m.configureCustomScripts(context.Background(), conf, output)
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(ContainSubstring("/etc/non-existing: no such file or directory")))
 
@@ -118,7 +119,7 @@ var _ = Describe("Custom", func() {
 			},
 		}
 
-		err = m.configureCustomScripts(conf, output)
+		err = m.configureCustomScripts(context.Background(), conf, output)
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError("directories under /etc/custom/scripts are not supported"))
 
@@ -133,7 +134,7 @@ var _ = Describe("Custom", func() {
 			},
 		}
 
-		err := m.configureCustomScripts(conf, output)
+		err := m.configureCustomScripts(context.Background(), conf, output)
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(ContainSubstring("/etc/non-existing: no such file or directory")))
 
@@ -148,7 +149,7 @@ var _ = Describe("Custom", func() {
 			},
 		}
 
-		Expect(m.configureCustomScripts(conf, output)).To(Succeed())
+		Expect(m.configureCustomScripts(context.Background(), conf, output)).To(Succeed())
 
 		contents, err := fs.ReadFile(catalystScriptPath)
 		Expect(err).NotTo(HaveOccurred())
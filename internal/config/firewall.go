@@ -0,0 +1,258 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"net/netip"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/firewall"
+	"github.com/suse/elemental/v3/internal/template"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const firewallPresetName = "50-elemental-firewall.preset"
+
+var (
+	//go:embed templates/firewalld-zone.xml.tpl
+	firewalldZoneTpl string
+
+	//go:embed templates/firewalld-default-zone.conf.tpl
+	firewalldDefaultZoneTpl string
+
+	//go:embed templates/nftables.conf.tpl
+	nftablesConfTpl string
+)
+
+// configureFirewall renders the firewall zones of the OS definition into the overlays
+// and enables the service of the selected backend.
+func (m *Manager) configureFirewall(conf *image.Configuration, output Output) error {
+	fw := conf.OS.Firewall
+	if fw == nil || len(fw.Zones) == 0 {
+		m.system.Logger().Info("Firewall configuration not provided, skipping.")
+		return nil
+	}
+
+	vip := conf.Kubernetes.Network.APIVIP4 != "" || conf.Kubernetes.Network.APIVIP6 != ""
+
+	var service string
+	var err error
+
+	switch fw.GetBackend() {
+	case firewall.Firewalld:
+		service = "firewalld.service"
+		err = m.writeFirewalldZones(fw, vip, output)
+	case firewall.NFTables:
+		service = "nftables.service"
+		err = m.writeNFTablesConfig(fw, vip, output)
+	default:
+		err = fmt.Errorf("unsupported firewall backend '%s'", fw.Backend)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = m.enableServices(output, firewallPresetName, service); err != nil {
+		return fmt.Errorf("enabling firewall service: %w", err)
+	}
+
+	m.system.Logger().Info("Firewall configuration written for %s", fw.GetBackend())
+
+	return nil
+}
+
+func zonePorts(z firewall.Zone, vip bool) []firewall.Port {
+	ports := slices.Clone(z.Ports)
+	if z.Kubernetes {
+		for _, p := range firewall.KubernetesPorts(vip) {
+			if !slices.Contains(ports, p) {
+				ports = append(ports, p)
+			}
+		}
+	}
+
+	return ports
+}
+
+func (m *Manager) writeFirewalldZones(fw *firewall.Firewall, vip bool, output Output) error {
+	fs := m.system.FS()
+
+	zonesDir := filepath.Join(output.OverlaysDir(), image.FirewalldZonesPath())
+	if err := vfs.MkdirAll(fs, zonesDir, vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating firewalld zones directory in overlays: %w", err)
+	}
+
+	for _, z := range fw.Zones {
+		target := string(z.GetTarget())
+		if z.GetTarget() == firewall.TargetReject {
+			target = "%%REJECT%%"
+		}
+
+		values := struct {
+			Name       string
+			Target     string
+			Interfaces []string
+			Sources    []string
+			Services   []string
+			Ports      []firewall.Port
+		}{
+			Name:       z.Name,
+			Target:     target,
+			Interfaces: z.Interfaces,
+			Sources:    z.Sources,
+			Services:   z.Services,
+			Ports:      zonePorts(z, vip),
+		}
+
+		data, err := template.Parse(z.Name, firewalldZoneTpl, &values)
+		if err != nil {
+			return fmt.Errorf("parsing firewalld zone template for '%s': %w", z.Name, err)
+		}
+
+		zoneFile := filepath.Join(zonesDir, fmt.Sprintf("%s.xml", z.Name))
+		if err = fs.WriteFile(zoneFile, []byte(data), vfs.FilePerm); err != nil {
+			return fmt.Errorf("writing firewalld zone '%s': %w", z.Name, err)
+		}
+	}
+
+	if fw.DefaultZone == "" {
+		return nil
+	}
+
+	// firewalld.conf has no drop-in directory, the default zone is merged into the one of the OS
+	// by a drop-in of the firewalld service, so the rest of its settings are kept
+	data, err := template.Parse("firewalld-default-zone", firewalldDefaultZoneTpl, fw)
+	if err != nil {
+		return fmt.Errorf("parsing firewalld default zone template: %w", err)
+	}
+
+	dropIn := filepath.Join(output.OverlaysDir(), image.FirewalldDropInPath())
+	if err = vfs.MkdirAll(fs, filepath.Dir(dropIn), vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating firewalld service drop-in directory in overlays: %w", err)
+	}
+	if err = fs.WriteFile(dropIn, []byte(data), vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing firewalld service drop-in: %w", err)
+	}
+
+	return nil
+}
+
+type nftZone struct {
+	Name       string
+	Interfaces []string
+	Sources4   []string
+	Sources6   []string
+	Ports      []string
+	Verdict    string
+}
+
+func (m *Manager) writeNFTablesConfig(fw *firewall.Firewall, vip bool, output Output) error {
+	var zones []nftZone
+
+	for _, z := range fw.Zones {
+		zone := nftZone{
+			Name:    z.Name,
+			Ports:   nftPortRules(zonePorts(z, vip)),
+			Verdict: "reject",
+		}
+
+		switch z.GetTarget() {
+		case firewall.TargetAccept:
+			zone.Verdict = "accept"
+		case firewall.TargetDrop:
+			zone.Verdict = "drop"
+		}
+
+		for _, i := range z.Interfaces {
+			zone.Interfaces = append(zone.Interfaces, fmt.Sprintf("%q", i))
+		}
+
+		for _, s := range z.Sources {
+			addr, err := netip.ParseAddr(strings.Split(s, "/")[0])
+			if err != nil {
+				return fmt.Errorf("parsing source '%s' of zone '%s': %w", s, z.Name, err)
+			}
+
+			if addr.Is4() {
+				zone.Sources4 = append(zone.Sources4, s)
+			} else {
+				zone.Sources6 = append(zone.Sources6, s)
+			}
+		}
+
+		zones = append(zones, zone)
+	}
+
+	// Traffic not matched by any zone is left unfiltered unless a default zone is defined
+	policy := "accept"
+	if fw.DefaultZone != "" {
+		policy = "drop"
+	}
+
+	values := struct {
+		Policy      string
+		DefaultZone string
+		Zones       []nftZone
+	}{
+		Policy:      policy,
+		DefaultZone: fw.DefaultZone,
+		Zones:       zones,
+	}
+
+	data, err := template.Parse("nftables", nftablesConfTpl, &values)
+	if err != nil {
+		return fmt.Errorf("parsing nftables template: %w", err)
+	}
+
+	confFile := filepath.Join(output.OverlaysDir(), image.NFTablesConfigPath())
+	if err = vfs.MkdirAll(m.system.FS(), filepath.Dir(confFile), vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating nftables configuration directory in overlays: %w", err)
+	}
+
+	if err = m.system.FS().WriteFile(confFile, []byte(data), vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing nftables configuration: %w", err)
+	}
+
+	return nil
+}
+
+// nftPortRules groups the ports by protocol into nftables matches, e.g. 'tcp dport { 80, 6443 }'
+func nftPortRules(ports []firewall.Port) []string {
+	byProtocol := map[string][]string{}
+	var protocols []string
+
+	for _, p := range ports {
+		protocol := p.Protocol()
+		if _, ok := byProtocol[protocol]; !ok {
+			protocols = append(protocols, protocol)
+		}
+		byProtocol[protocol] = append(byProtocol[protocol], p.Number())
+	}
+
+	var rules []string
+	for _, protocol := range protocols {
+		rules = append(rules, fmt.Sprintf("%s dport { %s }", protocol, strings.Join(byProtocol[protocol], ", ")))
+	}
+
+	return rules
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/firewall"
+	"github.com/suse/elemental/v3/internal/image/kubernetes"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Firewall", func() {
+	var output = Output{
+		RootPath: "/_out",
+	}
+
+	var m *Manager
+	var fs vfs.FS
+	var cleanup func()
+	var err error
+	var conf *image.Configuration
+
+	BeforeEach(func() {
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		system, err := sys.NewSystem(
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithFS(fs),
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		m = NewManager(system, nil)
+
+		conf = &image.Configuration{
+			Kubernetes: kubernetes.Kubernetes{
+				Network: kubernetes.Network{APIVIP4: "192.168.120.100"},
+			},
+			OS: image.OperatingSystem{
+				Firewall: &firewall.Firewall{
+					DefaultZone: "public",
+					Zones: []firewall.Zone{
+						{
+							Name:       "public",
+							Interfaces: []string{"eth0"},
+							Services:   []string{"ssh"},
+							Ports:      []firewall.Port{"80/tcp"},
+						},
+						{
+							Name:       "cluster",
+							Target:     firewall.TargetAccept,
+							Sources:    []string{"192.168.120.0/24", "fd00::/64"},
+							Kubernetes: true,
+						},
+					},
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("Skips configuration", func() {
+		Expect(m.configureFirewall(&image.Configuration{}, output)).To(Succeed())
+
+		exists, _ := vfs.Exists(fs, filepath.Join(output.OverlaysDir(), "etc"))
+		Expect(exists).To(BeFalse())
+	})
+
+	It("Writes firewalld zones", func() {
+		Expect(m.configureFirewall(conf, output)).To(Succeed())
+
+		zonesDir := filepath.Join(output.OverlaysDir(), image.FirewalldZonesPath())
+
+		data, err := fs.ReadFile(filepath.Join(zonesDir, "public.xml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`<zone target="%%REJECT%%">`))
+		Expect(string(data)).To(ContainSubstring(`<interface name="eth0"/>`))
+		Expect(string(data)).To(ContainSubstring(`<service name="ssh"/>`))
+		Expect(string(data)).To(ContainSubstring(`<port protocol="tcp" port="80"/>`))
+		Expect(string(data)).ToNot(ContainSubstring(`port="6443"`))
+
+		data, err = fs.ReadFile(filepath.Join(zonesDir, "cluster.xml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`<zone target="ACCEPT">`))
+		Expect(string(data)).To(ContainSubstring(`<source address="fd00::/64"/>`))
+		Expect(string(data)).To(ContainSubstring(`<port protocol="tcp" port="6443"/>`))
+		Expect(string(data)).To(ContainSubstring(`<port protocol="tcp" port="30000-32767"/>`))
+		Expect(string(data)).To(ContainSubstring(`<port protocol="udp" port="7946"/>`))
+
+		Expect(vfs.Exists(fs, filepath.Join(filepath.Dir(zonesDir), "firewalld.conf"))).To(BeFalse())
+		data, err = fs.ReadFile(filepath.Join(output.OverlaysDir(), image.FirewalldDropInPath()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`sed -i "s/^DefaultZone=.*/DefaultZone=public/" /etc/firewalld/firewalld.conf`))
+		Expect(string(data)).To(ContainSubstring(`echo "DefaultZone=public" >> /etc/firewalld/firewalld.conf`))
+
+		data, err = fs.ReadFile(filepath.Join(output.OverlaysDir(), image.SystemdPresetPath(), firewallPresetName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("enable firewalld.service\n"))
+	})
+
+	It("Writes an nftables ruleset", func() {
+		conf.OS.Firewall.Backend = firewall.NFTables
+		conf.OS.Firewall.Zones[0].Services = nil
+
+		Expect(m.configureFirewall(conf, output)).To(Succeed())
+
+		data, err := fs.ReadFile(filepath.Join(output.OverlaysDir(), image.NFTablesConfigPath()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("policy drop;"))
+		Expect(string(data)).To(ContainSubstring(`iifname { "eth0" } jump zone_public`))
+		Expect(string(data)).To(ContainSubstring("ip saddr { 192.168.120.0/24 } jump zone_cluster"))
+		Expect(string(data)).To(ContainSubstring("ip6 saddr { fd00::/64 } jump zone_cluster"))
+		Expect(string(data)).To(ContainSubstring("\t\tjump zone_public\n"))
+		Expect(string(data)).To(ContainSubstring("tcp dport { 80 } accept\n\t\treject"))
+		Expect(string(data)).To(ContainSubstring("tcp dport { 6443, 9345, 10250, 2379-2381, 30000-32767, 9099, 7946 } accept"))
+		Expect(string(data)).To(ContainSubstring("udp dport { 30000-32767, 8472, 7946 } accept\n\t\taccept"))
+
+		data, err = fs.ReadFile(filepath.Join(output.OverlaysDir(), image.SystemdPresetPath(), firewallPresetName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("enable nftables.service\n"))
+	})
+})
@@ -0,0 +1,47 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/firstboot"
+)
+
+// configureFirstBoot writes the combustion configuration into the firstboot configuration directory, which
+// is installed into the config partition, and the cloud-init NoCloud seed into the overlays. The Ignition
+// configuration is merged with the generated one, see configureIgnition.
+func (m *Manager) configureFirstBoot(ctx context.Context, conf *image.Configuration, output Output) error {
+	if conf.FirstBoot.CombustionDir != "" {
+		m.system.Logger().Info("Configuring combustion")
+		if err := firstboot.WriteCombustion(ctx, m.system, conf.FirstBoot.CombustionDir, output.FirstbootConfigDir()); err != nil {
+			return fmt.Errorf("writing combustion configuration: %w", err)
+		}
+	}
+
+	if conf.FirstBoot.CloudInitDir != "" {
+		m.system.Logger().Info("Configuring cloud-init")
+		if err := firstboot.WriteCloudInit(ctx, m.system, conf.FirstBoot.CloudInitDir, output.OverlaysDir()); err != nil {
+			return fmt.Errorf("writing cloud-init configuration: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/firstboot"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("First boot", func() {
+	var output = Output{
+		RootPath: "/_out",
+	}
+
+	var m *Manager
+	var fs vfs.FS
+	var cleanup func()
+	var err error
+
+	BeforeEach(func() {
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/config/firstboot/combustion/script":    "#!/bin/bash",
+			"/config/firstboot/combustion/setup.sh":  "echo setup",
+			"/config/firstboot/cloud-init/user-data": "#cloud-config",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		system, err := sys.NewSystem(
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithFS(fs),
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		m = NewManager(system, nil)
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("Skips configuration", func() {
+		Expect(m.configureFirstBoot(context.Background(), &image.Configuration{}, output)).To(Succeed())
+		Expect(vfs.Exists(fs, output.FirstbootConfigDir())).To(BeFalse())
+	})
+
+	It("Writes combustion into the config partition and cloud-init into the overlays", func() {
+		conf := &image.Configuration{
+			FirstBoot: image.FirstBoot{
+				CombustionDir: "/config/firstboot/combustion",
+				CloudInitDir:  "/config/firstboot/cloud-init",
+			},
+		}
+
+		Expect(m.configureFirstBoot(context.Background(), conf, output)).To(Succeed())
+
+		combustionDir := filepath.Join(output.FirstbootConfigDir(), firstboot.CombustionDir)
+		Expect(vfs.Exists(fs, filepath.Join(combustionDir, "script"))).To(BeTrue())
+		Expect(vfs.Exists(fs, filepath.Join(combustionDir, "setup.sh"))).To(BeTrue())
+
+		seedDir := filepath.Join(output.OverlaysDir(), firstboot.CloudInitSeedDir)
+		data, err := fs.ReadFile(filepath.Join(seedDir, "user-data"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("#cloud-config"))
+		Expect(vfs.Exists(fs, filepath.Join(seedDir, "meta-data"))).To(BeTrue())
+	})
+
+	It("Fails to copy a missing cloud-init directory", func() {
+		conf := &image.Configuration{
+			FirstBoot: image.FirstBoot{CloudInitDir: "/config/missing"},
+		}
+
+		err := m.configureFirstBoot(context.Background(), conf, output)
+		Expect(err).To(MatchError(ContainSubstring("writing cloud-init configuration")))
+	})
+})
@@ -0,0 +1,162 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/release"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+)
+
+const (
+	SourceCore       = "core"
+	SourceSolution   = "solution"
+	SourceDefinition = "definition"
+
+	// ReasonEnabled marks components explicitly enabled in the image definition
+	ReasonEnabled = "enabled"
+	// ReasonRequired marks extensions required by the release manifest
+	ReasonRequired = "required"
+	// ReasonDependency marks components pulled in by another component
+	ReasonDependency = "dependency"
+)
+
+// DependencyGraph is the graph of the Helm charts and systemd extensions enabled by an image
+// definition, edges point from a component to its dependencies
+type DependencyGraph struct {
+	Nodes []GraphNode `yaml:"nodes"`
+	Edges []GraphEdge `yaml:"edges,omitempty"`
+}
+
+type GraphNode struct {
+	ID      string             `yaml:"id"`
+	Type    api.DependencyType `yaml:"type"`
+	Name    string             `yaml:"name"`
+	Source  string             `yaml:"source"`
+	Reasons []string           `yaml:"reasons"`
+}
+
+type GraphEdge struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+func nodeID(t api.DependencyType, name string) string {
+	return fmt.Sprintf("%s/%s", t, name)
+}
+
+// NewDependencyGraph resolves the Helm charts and systemd extensions enabled by the given configuration
+// from the release manifest and returns them with the reasons that got them enabled
+func NewDependencyGraph(rm *resolver.ResolvedManifest, conf *image.Configuration) (*DependencyGraph, error) {
+	g := &DependencyGraph{}
+
+	charts, _, err := enabledHelmCharts(rm, conf.Release.Components.HelmCharts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("filtering enabled helm charts: %w", err)
+	}
+	for _, c := range charts {
+		reason := ReasonDependency
+		if slices.ContainsFunc(conf.Release.Components.HelmCharts, func(e release.HelmChart) bool { return e.Name == c.Chart }) {
+			reason = ReasonEnabled
+		}
+		g.Nodes = append(g.Nodes, GraphNode{
+			ID: nodeID(api.DependencyTypeHelm, c.Chart), Type: api.DependencyTypeHelm, Name: c.Chart,
+			Source: chartSource(rm, c.Chart), Reasons: []string{reason},
+		})
+		for _, d := range c.DependsOn {
+			g.Edges = append(g.Edges, GraphEdge{From: nodeID(api.DependencyTypeHelm, c.Chart), To: nodeID(d.Type, d.Name)})
+		}
+	}
+
+	if conf.Kubernetes.Helm != nil {
+		for _, c := range conf.Kubernetes.Helm.Charts {
+			g.Nodes = append(g.Nodes, GraphNode{
+				ID: nodeID(api.DependencyTypeHelm, c.Name), Type: api.DependencyTypeHelm, Name: c.Name,
+				Source: SourceDefinition, Reasons: []string{ReasonEnabled},
+			})
+		}
+	}
+
+	extensions, err := enabledExtensions(rm, conf, log.New(log.WithDiscardAll()))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range extensions {
+		var reasons []string
+		if e.Required {
+			reasons = append(reasons, ReasonRequired)
+		}
+		if isExtensionExplicitlyEnabled(e.Name, conf) {
+			reasons = append(reasons, ReasonEnabled)
+		}
+		id := nodeID(api.DependencyTypeExtension, e.Name)
+		if slices.ContainsFunc(g.Edges, func(edge GraphEdge) bool { return edge.To == id }) {
+			reasons = append(reasons, ReasonDependency)
+		}
+		g.Nodes = append(g.Nodes, GraphNode{
+			ID: id, Type: api.DependencyTypeExtension, Name: e.Name, Source: extensionSource(rm, e.Name), Reasons: reasons,
+		})
+	}
+
+	return g, nil
+}
+
+// chartSource returns the release manifest defining the given chart, solution charts take precedence
+func chartSource(rm *resolver.ResolvedManifest, name string) string {
+	if rm.SolutionExtension != nil && rm.SolutionExtension.Components.Helm != nil &&
+		slices.ContainsFunc(rm.SolutionExtension.Components.Helm.Charts, func(c *api.HelmChart) bool { return c.Chart == name }) {
+		return SourceSolution
+	}
+	return SourceCore
+}
+
+func extensionSource(rm *resolver.ResolvedManifest, name string) string {
+	if slices.ContainsFunc(rm.CorePlatform.Components.Systemd.Extensions, func(e api.SystemdExtension) bool { return e.Name == name }) {
+		return SourceCore
+	}
+	return SourceSolution
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language
+func (g DependencyGraph) WriteDOT(w io.Writer) error {
+	shapes := map[api.DependencyType]string{api.DependencyTypeHelm: "box", api.DependencyTypeExtension: "ellipse"}
+
+	_, err := fmt.Fprintln(w, "digraph dependencies {")
+	if err != nil {
+		return err
+	}
+	for _, n := range g.Nodes {
+		_, err = fmt.Fprintf(w, "  %q [label=%q, shape=%s];\n", n.ID, fmt.Sprintf("%s\n%s %v", n.Name, n.Source, n.Reasons), shapes[n.Type])
+		if err != nil {
+			return err
+		}
+	}
+	for _, e := range g.Edges {
+		if _, err = fmt.Fprintf(w, "  %q -> %q;\n", e.From, e.To); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w, "}")
+	return err
+}
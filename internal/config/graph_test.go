@@ -0,0 +1,120 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/kubernetes"
+	"github.com/suse/elemental/v3/internal/image/release"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/api/solution"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+)
+
+var _ = Describe("Dependency graph", func() {
+	var rm *resolver.ResolvedManifest
+	var conf *image.Configuration
+
+	BeforeEach(func() {
+		rm = &resolver.ResolvedManifest{
+			CorePlatform: &core.ReleaseManifest{
+				Components: core.Components{
+					Systemd: api.Systemd{
+						Extensions: []api.SystemdExtension{
+							{Name: "base", Image: "base-ext", Required: true},
+							{Name: "storage", Image: "storage-ext"},
+							{Name: "unused", Image: "unused-ext"},
+						},
+					},
+					Helm: &api.Helm{
+						Charts: []*api.HelmChart{
+							{Chart: "cert-manager", Version: "1.0"},
+							{Chart: "longhorn", Version: "1.0", DependsOn: []api.HelmChartDependency{
+								{Name: "storage", Type: api.DependencyTypeExtension},
+							}},
+						},
+					},
+				},
+			},
+			SolutionExtension: &solution.ReleaseManifest{
+				Components: solution.Components{
+					Helm: &api.Helm{
+						Charts: []*api.HelmChart{
+							{Chart: "rancher", Version: "2.0", DependsOn: []api.HelmChartDependency{
+								{Name: "cert-manager", Type: api.DependencyTypeHelm},
+							}},
+						},
+					},
+				},
+			},
+		}
+		conf = &image.Configuration{
+			Release: release.Release{
+				Components: release.Components{
+					HelmCharts:        []release.HelmChart{{Name: "rancher"}, {Name: "longhorn"}},
+					SystemdExtensions: []release.SystemdExtension{{Name: "storage"}},
+				},
+			},
+			Kubernetes: kubernetes.Kubernetes{
+				Helm: &kubernetes.Helm{Charts: []*kubernetes.HelmChart{{Name: "custom"}}},
+			},
+		}
+	})
+
+	It("resolves the enabled components and the reasons to enable them", func() {
+		g, err := NewDependencyGraph(rm, conf)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(g.Nodes).To(Equal([]GraphNode{
+			{ID: "helm/cert-manager", Type: api.DependencyTypeHelm, Name: "cert-manager", Source: SourceCore, Reasons: []string{ReasonDependency}},
+			{ID: "helm/rancher", Type: api.DependencyTypeHelm, Name: "rancher", Source: SourceSolution, Reasons: []string{ReasonEnabled}},
+			{ID: "helm/longhorn", Type: api.DependencyTypeHelm, Name: "longhorn", Source: SourceCore, Reasons: []string{ReasonEnabled}},
+			{ID: "helm/custom", Type: api.DependencyTypeHelm, Name: "custom", Source: SourceDefinition, Reasons: []string{ReasonEnabled}},
+			{ID: "sysext/base", Type: api.DependencyTypeExtension, Name: "base", Source: SourceCore, Reasons: []string{ReasonRequired}},
+			{ID: "sysext/storage", Type: api.DependencyTypeExtension, Name: "storage", Source: SourceCore, Reasons: []string{ReasonEnabled, ReasonDependency}},
+		}))
+		Expect(g.Edges).To(ConsistOf(
+			GraphEdge{From: "helm/rancher", To: "helm/cert-manager"},
+			GraphEdge{From: "helm/longhorn", To: "sysext/storage"},
+		))
+	})
+
+	It("writes the graph in the DOT language", func() {
+		g, err := NewDependencyGraph(rm, conf)
+		Expect(err).NotTo(HaveOccurred())
+
+		var out bytes.Buffer
+		Expect(g.WriteDOT(&out)).To(Succeed())
+		Expect(out.String()).To(HavePrefix("digraph dependencies {\n"))
+		Expect(out.String()).To(ContainSubstring(`"helm/rancher" [label="rancher\nsolution [enabled]", shape=box];`))
+		Expect(out.String()).To(ContainSubstring(`"helm/rancher" -> "helm/cert-manager";`))
+		Expect(out.String()).To(HaveSuffix("}\n"))
+	})
+
+	It("fails if an enabled chart is missing in the release manifest", func() {
+		conf.Release.Components.HelmCharts = append(conf.Release.Components.HelmCharts, release.HelmChart{Name: "missing"})
+		_, err := NewDependencyGraph(rm, conf)
+		Expect(err).To(MatchError(ContainSubstring("adding helm chart 'missing'")))
+	})
+})
@@ -18,6 +18,7 @@ limitations under the License.
 package config
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/url"
@@ -174,7 +175,7 @@ func (h *Helm) retrieveHelmCharts(rm *resolver.ResolvedManifest, conf *image.Con
 		}
 	}
 
-	return crds, generateHelmSecrets(authMap), nil
+	return crds, generateHelmSecrets(h.Logger, authMap), nil
 }
 
 func createAuthMap(charts []*api.HelmChart, repositories map[string]string, conf *image.Configuration) (map[string]*auth.HelmAuth, error) {
@@ -241,10 +242,13 @@ func createAuthMap(charts []*api.HelmChart, repositories map[string]string, conf
 	return authMap, nil
 }
 
-func generateHelmSecrets(authMap map[string]*auth.HelmAuth) []*helm.Secret {
+func generateHelmSecrets(logger log.Logger, authMap map[string]*auth.HelmAuth) []*helm.Secret {
 	var secrets []*helm.Secret
 
 	for chart, creds := range authMap {
+		if logger != nil {
+			logger.RegisterSecret(creds.Credentials.Password)
+		}
 		secrets = append(secrets, NewSecret(chart, creds))
 	}
 
@@ -270,6 +274,82 @@ func (h *Helm) appendHelmChart(chart helmChart, repositories, valueFiles map[str
 	return nil
 }
 
+// vendorHelmCharts pulls the archives of the given HelmChart resources, relative to the overlays
+// directory, and embeds them into the resources
+func (m *Manager) vendorHelmCharts(ctx context.Context, conf *image.Configuration, rm *resolver.ResolvedManifest, output Output, charts []string) error {
+	enabled, repositories, err := enabledHelmCharts(rm, conf.Release.Components.HelmCharts, nil)
+	if err != nil {
+		return fmt.Errorf("filtering enabled helm charts: %w", err)
+	}
+
+	authMap, err := createAuthMap(enabled, repositories, conf)
+	if err != nil {
+		return fmt.Errorf("creating helm chart auth map: %w", err)
+	}
+
+	for _, chart := range charts {
+		path := filepath.Join(output.OverlaysDir(), chart)
+		data, err := m.system.FS().ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading helm chart resource '%s': %w", path, err)
+		}
+
+		crd := &helm.CRD{}
+		if err = yaml.Unmarshal(data, crd); err != nil {
+			return fmt.Errorf("parsing helm chart resource '%s': %w", path, err)
+		}
+
+		var username, password string
+		if a := authMap[crd.Metadata.Name]; a != nil {
+			username, password = a.Credentials.Username, a.Credentials.Password
+		}
+
+		m.system.Logger().Info("Vendoring helm chart %s %s", crd.Spec.Chart, crd.Spec.Version)
+		if err = m.embedChart(ctx, crd, username, password); err != nil {
+			return err
+		}
+
+		if data, err = yaml.Marshal(crd); err != nil {
+			return fmt.Errorf("marshaling helm chart %s: %w", crd.Metadata.Name, err)
+		}
+		if err = m.system.FS().WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("writing helm chart: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// embedChart pulls the archive of the chart deployed by the given HelmChart resource, through the build
+// cache if any, and embeds it into the resource
+func (m *Manager) embedChart(ctx context.Context, crd *helm.CRD, username, password string) error {
+	dir, err := vfs.TempDir(m.system.FS(), "", "elemental-helm-chart-")
+	if err != nil {
+		return fmt.Errorf("creating chart directory: %w", err)
+	}
+	defer func() {
+		_ = vfs.ForceRemoveAll(m.system.FS(), dir)
+	}()
+
+	archive := filepath.Join(dir, "chart.tgz")
+	pull := func(ctx context.Context, path string) error {
+		return helm.Pull(ctx, m.system, crd, username, password, path)
+	}
+	if m.cache != nil {
+		err = m.cache.Chart(ctx, crd.Spec.Repo, crd.Spec.Chart, crd.Spec.Version, archive, pull)
+	} else {
+		err = pull(ctx, archive)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = helm.Embed(m.system, crd, archive); err != nil {
+		return fmt.Errorf("embedding helm chart '%s': %w", crd.Spec.Chart, err)
+	}
+	return nil
+}
+
 func enabledHelmCharts(rm *resolver.ResolvedManifest, enabled []release.HelmChart, logger log.Logger) ([]*api.HelmChart, map[string]string, error) {
 	coreCharts, solutionCharts := map[string]*api.HelmChart{}, map[string]*api.HelmChart{}
 	repositories := map[string]string{}
@@ -295,17 +375,31 @@ func enabledHelmCharts(rm *resolver.ResolvedManifest, enabled []release.HelmChar
 	}
 
 	var charts []*api.HelmChart
-	var addChart func(name string) error
+	var addChart func(name string, path []string) error
+
+	// Add a chart and its dependencies, avoiding duplicates. Dependencies are resolved across
+	// the core and solution releases, prioritizing charts from solution releases over core ones.
+	// The path holds the charts depending on the given one, to detect dependency cycles.
+	addChart = func(name string, path []string) error {
+		if slices.ContainsFunc(charts, func(c *api.HelmChart) bool {
+			return c.GetName() == name
+		}) {
+			return nil
+		}
+
+		if slices.Contains(path, name) {
+			return fmt.Errorf("dependency cycle %s", strings.Join(append(path, name), " -> "))
+		}
 
-	// Add a chart and its direct dependencies, avoiding duplicates.
-	// Prioritize charts from solution releases over core ones.
-	addChart = func(name string) error {
 		source := "solution"
 
 		chart, ok := solutionCharts[name]
 		if !ok {
 			chart, ok = coreCharts[name]
-			if !ok {
+			if !ok && len(path) > 0 {
+				return fmt.Errorf("helm chart '%s' required by '%s' is not defined in the core nor the solution release",
+					name, path[len(path)-1])
+			} else if !ok {
 				return fmt.Errorf("helm chart does not exist")
 			}
 			source = "core"
@@ -315,17 +409,11 @@ func enabledHelmCharts(rm *resolver.ResolvedManifest, enabled []release.HelmChar
 			logger.Info("Using Helm chart %s from %s release", name, source)
 		}
 
-		if slices.ContainsFunc(charts, func(c *api.HelmChart) bool {
-			return c.GetName() == name
-		}) {
-			return nil
-		}
-
 		// Check for dependencies and add them first.
 		for _, d := range chart.DependsOn {
 			if d.Type == api.DependencyTypeHelm {
-				if err := addChart(d.Name); err != nil {
-					return fmt.Errorf("adding dependent helm chart '%s': %w", d.Name, err)
+				if err := addChart(d.Name, append(slices.Clone(path), name)); err != nil {
+					return err
 				}
 			}
 		}
@@ -337,7 +425,7 @@ func enabledHelmCharts(rm *resolver.ResolvedManifest, enabled []release.HelmChar
 	}
 
 	for _, e := range enabled {
-		if err := addChart(e.Name); err != nil {
+		if err := addChart(e.Name, nil); err != nil {
 			return nil, nil, fmt.Errorf("adding helm chart '%s': %w", e.Name, err)
 		}
 	}
@@ -856,9 +856,69 @@ spec:
 		It("Fails to find non-existing dependency Helm chart", func() {
 			charts, repositories, err := enabledHelmCharts(rm, []release.HelmChart{{Name: "longhorn"}}, logger)
 			Expect(err).To(HaveOccurred())
-			Expect(err).To(MatchError("adding helm chart 'longhorn': adding dependent helm chart 'longhorn-crd': helm chart does not exist"))
+			Expect(err).To(MatchError("adding helm chart 'longhorn': helm chart 'longhorn-crd' required by 'longhorn' is not defined in the core nor the solution release"))
 			Expect(charts).To(BeNil())
 			Expect(repositories).To(BeNil())
 		})
+
+		It("Resolves dependencies on charts of the core release", func() {
+			rm := &resolver.ResolvedManifest{
+				CorePlatform: &core.ReleaseManifest{
+					Components: core.Components{
+						Helm: &api.Helm{
+							Charts: []*api.HelmChart{{Chart: "cert-manager", Repository: "core"}},
+						},
+					},
+				},
+				SolutionExtension: &solution.ReleaseManifest{
+					Components: solution.Components{
+						Helm: &api.Helm{
+							Charts: []*api.HelmChart{
+								{Chart: "rancher", Repository: "solution", DependsOn: []api.HelmChartDependency{
+									{Name: "cert-manager", Type: "helm"},
+									{Name: "rancher-crd", Type: "helm"},
+								}},
+								{Chart: "rancher-crd", Repository: "solution", DependsOn: []api.HelmChartDependency{
+									{Name: "cert-manager", Type: "helm"},
+								}},
+							},
+						},
+					},
+				},
+			}
+
+			charts, _, err := enabledHelmCharts(rm, []release.HelmChart{{Name: "rancher"}}, logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(charts).To(HaveLen(3))
+			Expect(charts[0].Chart).To(Equal("cert-manager"))
+			Expect(charts[1].Chart).To(Equal("rancher-crd"))
+			Expect(charts[2].Chart).To(Equal("rancher"))
+		})
+
+		It("Fails on dependency cycles across releases", func() {
+			rm := &resolver.ResolvedManifest{
+				CorePlatform: &core.ReleaseManifest{
+					Components: core.Components{
+						Helm: &api.Helm{
+							Charts: []*api.HelmChart{{Chart: "cert-manager", DependsOn: []api.HelmChartDependency{
+								{Name: "rancher", Type: "helm"},
+							}}},
+						},
+					},
+				},
+				SolutionExtension: &solution.ReleaseManifest{
+					Components: solution.Components{
+						Helm: &api.Helm{
+							Charts: []*api.HelmChart{{Chart: "rancher", DependsOn: []api.HelmChartDependency{
+								{Name: "cert-manager", Type: "helm"},
+							}}},
+						},
+					},
+				},
+			}
+
+			_, _, err := enabledHelmCharts(rm, []release.HelmChart{{Name: "rancher"}}, logger)
+			Expect(err).To(MatchError("adding helm chart 'rancher': dependency cycle rancher -> cert-manager -> rancher"))
+		})
 	})
 })
@@ -20,7 +20,9 @@ package config
 import (
 	_ "embed"
 	"fmt"
+	"maps"
 	"path/filepath"
+	"slices"
 
 	"github.com/coreos/butane/base/v0_6"
 	"github.com/coreos/ignition/v2/config/util"
@@ -65,11 +67,13 @@ var (
 
 // configureIgnition writes the Ignition configuration file including:
 // * Predefined Butane configuration
+// * Predefined Ignition configuration
 // * Kubernetes configuration and deployment files
 // * Systemd extensions
 // * Kubernetes distribution installation
 func (m *Manager) configureIgnition(conf *image.Configuration, output Output, k8sScript, k8sConfScript string, ext []api.SystemdExtension) error {
 	if len(conf.ButaneConfig) == 0 &&
+		conf.FirstBoot.IgnitionFile == "" &&
 		k8sScript == "" &&
 		k8sConfScript == "" &&
 		len(ext) == 0 {
@@ -98,6 +102,16 @@ func (m *Manager) configureIgnition(conf *image.Configuration, output Output, k8
 		m.system.Logger().Info("No butane configuration to translate into Ignition syntax")
 	}
 
+	if conf.FirstBoot.IgnitionFile != "" {
+		m.system.Logger().Info("Merging Ignition configuration")
+
+		ignitionBytes, err := m.system.FS().ReadFile(conf.FirstBoot.IgnitionFile)
+		if err != nil {
+			return fmt.Errorf("reading ignition configuration: %w", err)
+		}
+		config.MergeInlineIgnition(string(ignitionBytes))
+	}
+
 	if k8sScript != "" {
 		initHostname := "*"
 		if len(conf.Kubernetes.Nodes) > 0 {
@@ -251,6 +265,25 @@ func appendRke2Configuration(s *sys.System, config *butane.Config, k *kubernetes
 		})
 	}
 
+	for _, path := range slices.Sorted(maps.Keys(c.RegistryCerts)) {
+		config.Storage.Files = append(config.Storage.Files, v0_6.File{
+			Path:     path,
+			Contents: v0_6.Resource{Inline: util.StrToPtr(c.RegistryCerts[path])},
+		})
+	}
+
+	for _, hostname := range slices.Sorted(maps.Keys(c.NodeConfigs)) {
+		nodeBytes, err := marshalConfig(c.NodeConfigs[hostname])
+		if err != nil {
+			return fmt.Errorf("failed marshaling config of node '%s': %w", hostname, err)
+		}
+
+		config.Storage.Files = append(config.Storage.Files, v0_6.File{
+			Path:     filepath.Join(k8sPath, "nodes", hostname+".yaml"),
+			Contents: v0_6.Resource{Inline: util.StrToPtr(string(nodeBytes))},
+		})
+	}
+
 	if k.Network.APIVIP4 != "" || k.Network.APIVIP6 != "" {
 		manifestsPath := filepath.Join("/", image.KubernetesManifestsPath())
 
@@ -111,6 +111,24 @@ passwd:
 		Expect(ignition).To(ContainSubstring("merge"))
 	})
 
+	It("Merges the given Ignition configuration file", func() {
+		Expect(fs.WriteFile("/etc/config.ign", []byte(`{"ignition":{"version":"3.5.0"}}`), vfs.FilePerm)).To(Succeed())
+		conf := &image.Configuration{
+			FirstBoot: image.FirstBoot{IgnitionFile: "/etc/config.ign"},
+		}
+
+		ignitionFile := filepath.Join(output.FirstbootConfigDir(), image.IgnitionFilePath())
+
+		Expect(m.configureIgnition(conf, output, "", "", nil)).To(Succeed())
+		ignition, err := system.FS().ReadFile(ignitionFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ignition).To(ContainSubstring("merge"))
+		Expect(ignition).To(ContainSubstring("3.5.0"))
+
+		conf.FirstBoot.IgnitionFile = "/etc/missing.ign"
+		Expect(m.configureIgnition(conf, output, "", "", nil)).To(MatchError(ContainSubstring("reading ignition configuration")))
+	})
+
 	It("Configures kubernetes via Ignition with the given k8s script", func() {
 		// includes registries configuration
 		conf := &image.Configuration{
@@ -138,6 +156,27 @@ passwd:
 		Expect(ignition).To(ContainSubstring("/var/lib/elemental/kubernetes/registries.yaml"))
 	})
 
+	It("Writes the configuration of each Kubernetes node via Ignition", func() {
+		conf := &image.Configuration{
+			Kubernetes: kubernetes.Kubernetes{
+				Network: kubernetes.Network{APIVIP4: "192.168.122.50"},
+				Nodes: kubernetes.Nodes{
+					{Hostname: "server01", Type: kubernetes.NodeTypeServer},
+					{Hostname: "agent01", Type: kubernetes.NodeTypeAgent, Labels: []string{"zone=a"}},
+				},
+			},
+		}
+		ignitionFile := filepath.Join(output.FirstbootConfigDir(), image.IgnitionFilePath())
+
+		k8sConfScript := filepath.Join(output.OverlaysDir(), "path/to/k8s/conf_script.sh")
+
+		Expect(m.configureIgnition(conf, output, "", k8sConfScript, nil)).To(Succeed())
+		ignition, err := system.FS().ReadFile(ignitionFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ignition).To(ContainSubstring("/var/lib/elemental/kubernetes/nodes/agent01.yaml"))
+		Expect(ignition).To(ContainSubstring("/var/lib/elemental/kubernetes/nodes/server01.yaml"))
+	})
+
 	It("Writes systemd extension via Ignition", func() {
 		conf := &image.Configuration{}
 		ext := []api.SystemdExtension{{Name: "ext1", Image: "ext1-image"}}
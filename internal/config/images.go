@@ -0,0 +1,259 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	gcache "github.com/google/go-containerregistry/pkg/v1/cache"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/cache"
+	"github.com/suse/elemental/v3/pkg/helm"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/registry"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// preloadedImagesFile is the archive of preloaded container images imported by RKE2 on startup
+const preloadedImagesFile = "elemental-images.tar"
+
+type pullFunc func(ctx context.Context, images []string, p *platform.Platform, path string) error
+
+// configureContainerImages lists the container images required by the cluster and optionally
+// preloads them into the image
+func (m *Manager) configureContainerImages(ctx context.Context, conf *image.Configuration, rm *resolver.ResolvedManifest, output Output) error {
+	if m.imageList == "" && m.preloadPlatform == nil {
+		return nil
+	}
+
+	if !isKubernetesEnabled(conf) {
+		m.system.Logger().Info("Kubernetes is not enabled, skipping container images listing")
+		return nil
+	}
+
+	images, err := m.containerImages(ctx, conf, rm, output)
+	if err != nil {
+		return err
+	}
+	m.system.Logger().Info("Found %d container images required by the cluster", len(images))
+
+	if m.imageList != "" {
+		data := []byte{}
+		if len(images) > 0 {
+			data = []byte(strings.Join(images, "\n") + "\n")
+		}
+		if err = m.system.FS().WriteFile(m.imageList, data, 0o644); err != nil {
+			return fmt.Errorf("writing container image list: %w", err)
+		}
+	}
+
+	if m.preloadPlatform != nil && len(images) > 0 {
+		imagesDir := filepath.Join(output.OverlaysDir(), image.KubernetesImagesPath())
+		if err = vfs.MkdirAll(m.system.FS(), imagesDir, vfs.DirPerm); err != nil {
+			return fmt.Errorf("creating preloaded images directory: %w", err)
+		}
+
+		m.system.Logger().Info("Preloading container images")
+		if err = m.pullImages(ctx, images, m.preloadPlatform, filepath.Join(imagesDir, preloadedImagesFile)); err != nil {
+			return fmt.Errorf("preloading container images: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// containerImages returns the sorted list of container images required by the Kubernetes distribution
+// and declared by the enabled helm charts of the release manifest, and referenced by the rendered helm
+// charts and Kubernetes manifests
+func (m *Manager) containerImages(ctx context.Context, conf *image.Configuration, rm *resolver.ResolvedManifest, output Output) ([]string, error) {
+	images := map[string]bool{}
+
+	if rm.CorePlatform != nil && rm.CorePlatform.Components.Kubernetes != nil {
+		for _, img := range rm.CorePlatform.Components.Kubernetes.Images {
+			images[img] = true
+		}
+	}
+
+	charts, _, err := enabledHelmCharts(rm, conf.Release.Components.HelmCharts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("filtering enabled helm charts: %w", err)
+	}
+	for _, chart := range charts {
+		for _, img := range chart.Images {
+			images[img.Image] = true
+		}
+	}
+
+	for _, dir := range []string{image.HelmPath(), image.KubernetesManifestsPath()} {
+		dir = filepath.Join(output.OverlaysDir(), dir)
+		if ok, _ := vfs.Exists(m.system.FS(), dir); !ok {
+			continue
+		}
+
+		entries, err := m.system.FS().ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("reading directory '%s': %w", dir, err)
+		}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			data, err := m.system.FS().ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("reading manifest '%s': %w", path, err)
+			}
+			if err = m.scanManifest(ctx, data, images); err != nil {
+				return nil, fmt.Errorf("scanning manifest '%s': %w", path, err)
+			}
+		}
+	}
+
+	list := make([]string, 0, len(images))
+	for img := range images {
+		list = append(list, img)
+	}
+	slices.Sort(list)
+	return list, nil
+}
+
+// scanManifest adds the images referenced by the resources of the given manifest, HelmChart
+// resources are rendered and their resulting resources scanned. Charts not vendored are pulled
+// through the build cache if any, so they are rendered from the air-gap directory when offline.
+func (m *Manager) scanManifest(ctx context.Context, data []byte, images map[string]bool) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc map[string]any
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		apiVersion, _ := doc["apiVersion"].(string)
+		kind, _ := doc["kind"].(string)
+		if !helm.IsHelmChart(apiVersion, kind) {
+			collectImages(doc, images)
+			continue
+		}
+
+		raw, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		crd := &helm.CRD{}
+		if err = yaml.Unmarshal(raw, crd); err != nil {
+			return fmt.Errorf("parsing HelmChart resource: %w", err)
+		}
+		if crd.Spec.RepositoryAuthSecret != nil {
+			m.system.Logger().Warn("Skipping rendering of helm chart '%s' from an authenticated repository", crd.Spec.Chart)
+			continue
+		}
+
+		if crd.Spec.ChartContent == "" && m.cache != nil {
+			if err = m.embedChart(ctx, crd, "", ""); err != nil {
+				return err
+			}
+		}
+
+		rendered, err := helm.Template(ctx, m.system, crd)
+		if err != nil {
+			return err
+		}
+		if err = m.scanManifest(ctx, rendered, images); err != nil {
+			return fmt.Errorf("scanning rendered helm chart '%s': %w", crd.Spec.Chart, err)
+		}
+	}
+}
+
+// collectImages adds the values of all the 'image' fields found in the given resource
+func collectImages(node any, images map[string]bool) {
+	switch n := node.(type) {
+	case map[string]any:
+		for key, value := range n {
+			if img, ok := value.(string); ok && key == "image" && img != "" {
+				images[img] = true
+				continue
+			}
+			collectImages(value, images)
+		}
+	case []any:
+		for _, value := range n {
+			collectImages(value, images)
+		}
+	}
+}
+
+// pullImagesFunc returns a pullFunc writing the given images for the platform into a single
+// archive, images are pulled from the configured registry mirrors first and their layers
+// are read from the build cache, if any
+func pullImagesFunc(reg *registry.Config, c *cache.Cache) pullFunc {
+	return func(ctx context.Context, images []string, p *platform.Platform, path string) error {
+		archive := map[name.Reference]v1.Image{}
+		for _, img := range images {
+			ref, err := name.ParseReference(img)
+			if err != nil {
+				return fmt.Errorf("parsing image reference '%s': %w", img, err)
+			}
+
+			refs, err := reg.References(img)
+			if err != nil {
+				return fmt.Errorf("parsing image reference '%s': %w", img, err)
+			}
+
+			var pulled v1.Image
+			for _, r := range refs {
+				pulled, err = remote.Image(r,
+					remote.WithTransport(reg.Transport(r.Context().RegistryStr())),
+					remote.WithAuthFromKeychain(reg.Keychain()),
+					remote.WithPlatform(v1.Platform{OS: p.OS, Architecture: p.GolangArch}),
+					remote.WithContext(ctx),
+				)
+				if err == nil {
+					break
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("pulling image '%s': %w", img, err)
+			}
+			if c != nil {
+				pulled = gcache.Image(pulled, gcache.NewFilesystemCache(c.LayersDir()))
+			}
+			archive[ref] = pulled
+		}
+
+		return tarball.MultiRefWriteToFile(path, archive)
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/release"
+	"github.com/suse/elemental/v3/pkg/cache"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const deploymentManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: apache
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: registry.example.com/busybox:1.36
+      containers:
+      - name: apache
+        image: registry.example.com/httpd:2.4
+---
+apiVersion: helm.cattle.io/v1
+kind: HelmChart
+metadata:
+  name: metallb
+spec:
+  chart: metallb
+  version: 0.14.9
+  repo: https://metallb.github.io/metallb
+---
+apiVersion: helm.cattle.io/v1
+kind: HelmChart
+metadata:
+  name: private
+spec:
+  chart: private
+  version: 1.0.0
+  repo: https://charts.example.com
+  authSecret:
+    name: private-auth
+`
+
+const renderedChart = `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: speaker
+spec:
+  template:
+    spec:
+      containers:
+      - name: speaker
+        image: quay.io/metallb/speaker:v0.14.9
+`
+
+var _ = Describe("Container images", Label("images"), func() {
+	var output = Output{
+		RootPath: "/_out",
+	}
+
+	var system *sys.System
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var err error
+	var conf *image.Configuration
+	var rm *resolver.ResolvedManifest
+
+	BeforeEach(func() {
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		runner = sysmock.NewRunner()
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "helm" {
+				return []byte(renderedChart), nil
+			}
+			return []byte{}, nil
+		}
+
+		system, err = sys.NewSystem(
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithFS(fs),
+			sys.WithRunner(runner),
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		manifestsDir := filepath.Join(output.OverlaysDir(), image.KubernetesManifestsPath())
+		Expect(vfs.MkdirAll(fs, manifestsDir, vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(manifestsDir, "apache.yaml"), []byte(deploymentManifest), 0o644)).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(manifestsDir, "README"), []byte("image: ignored"), 0o644)).To(Succeed())
+
+		rm = &resolver.ResolvedManifest{
+			CorePlatform: &core.ReleaseManifest{
+				Components: core.Components{
+					Kubernetes: &core.Kubernetes{
+						Version: "v1.35.0+rke2r1", Image: "registry.example.com/rke2:1.35",
+						Images: []string{"registry.example.com/rke2/hardened-kubernetes:v1.35.0-rke2r1"},
+					},
+					Helm: &api.Helm{
+						Charts: []*api.HelmChart{
+							{Chart: "cert-manager", Version: "1.0", Images: []api.HelmChartImage{
+								{Name: "controller", Image: "quay.io/jetstack/cert-manager-controller:v1.0"},
+							}},
+							{Chart: "longhorn", Version: "1.0", Images: []api.HelmChartImage{
+								{Name: "manager", Image: "docker.io/longhornio/longhorn-manager:v1.0"},
+							}},
+						},
+					},
+				},
+			},
+		}
+		conf = &image.Configuration{
+			Release: release.Release{
+				Components: release.Components{
+					HelmCharts: []release.HelmChart{{Name: "cert-manager"}},
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("Does nothing if neither listing nor preloading is requested", func() {
+		m := NewManager(system, nil)
+		Expect(m.configureContainerImages(context.Background(), conf, rm, output)).To(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+
+	It("Does nothing if Kubernetes is not enabled", func() {
+		m := NewManager(system, nil, WithImageList("/images.txt"))
+		Expect(m.configureContainerImages(context.Background(), &image.Configuration{}, rm, output)).To(Succeed())
+		Expect(vfs.Exists(fs, "/images.txt")).To(BeFalse())
+	})
+
+	It("Writes the list of required container images", func() {
+		m := NewManager(system, nil, WithImageList("/images.txt"))
+		Expect(m.configureContainerImages(context.Background(), conf, rm, output)).To(Succeed())
+
+		data, err := fs.ReadFile("/images.txt")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(
+			"quay.io/jetstack/cert-manager-controller:v1.0\n" +
+				"quay.io/metallb/speaker:v0.14.9\n" +
+				"registry.example.com/busybox:1.36\n" +
+				"registry.example.com/httpd:2.4\n" +
+				"registry.example.com/rke2/hardened-kubernetes:v1.35.0-rke2r1\n",
+		))
+
+		Expect(runner.CmdsMatch([][]string{
+			{"helm", "template", "metallb", "metallb", "--version", "0.14.9", "--repo", "https://metallb.github.io/metallb"},
+		})).To(Succeed())
+	})
+
+	It("Preloads the required container images", func() {
+		var pulled []string
+		var archive string
+		pull := func(ctx context.Context, images []string, p *platform.Platform, path string) error {
+			pulled = images
+			archive = path
+			return nil
+		}
+
+		p, err := platform.Parse("linux/x86_64")
+		Expect(err).ToNot(HaveOccurred())
+
+		m := NewManager(system, nil, WithPullFunc(pull), WithImagePreload(p))
+		Expect(m.configureContainerImages(context.Background(), conf, rm, output)).To(Succeed())
+		Expect(pulled).To(HaveLen(5))
+		Expect(pulled).To(ContainElement("registry.example.com/rke2/hardened-kubernetes:v1.35.0-rke2r1"))
+		Expect(archive).To(Equal("/_out/overlays/var/lib/rancher/rke2/agent/images/elemental-images.tar"))
+		Expect(vfs.Exists(fs, filepath.Dir(archive))).To(BeTrue())
+	})
+
+	It("Fails if images cannot be preloaded", func() {
+		pull := func(ctx context.Context, images []string, p *platform.Platform, path string) error {
+			return fmt.Errorf("pull error")
+		}
+
+		p, err := platform.Parse("linux/x86_64")
+		Expect(err).ToNot(HaveOccurred())
+
+		m := NewManager(system, nil, WithPullFunc(pull), WithImagePreload(p))
+		err = m.configureContainerImages(context.Background(), conf, rm, output)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("preloading container images: pull error"))
+	})
+
+	It("Stores the charts and images into the air-gap directory and reads them offline", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "helm" && args[0] == "pull" {
+				dest := args[slices.Index(args, "--destination")+1]
+				return nil, fs.WriteFile(filepath.Join(dest, "metallb-0.14.9.tgz"), []byte("chart"), vfs.FilePerm)
+			}
+			return []byte(renderedChart), nil
+		}
+		pull := func(ctx context.Context, images []string, p *platform.Platform, path string) error {
+			return fs.WriteFile(path, []byte("images"), vfs.FilePerm)
+		}
+		p, err := platform.Parse("linux/x86_64")
+		Expect(err).ToNot(HaveOccurred())
+
+		airgap, err := cache.New(system, "/airgap", cache.WithDigestResolver(func(context.Context, string) (string, error) {
+			return "", fmt.Errorf("no registry")
+		}), cache.WithFileStore())
+		Expect(err).ToNot(HaveOccurred())
+		m := NewManager(system, nil, WithPullFunc(pull), WithImagePreload(p), WithCache(airgap), WithFetchOnly())
+		Expect(m.configureContainerImages(context.Background(), conf, rm, output)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{{"helm", "pull", "metallb"}, {"helm", "template", "metallb"}})).To(Succeed())
+
+		archive := "/_out/overlays/var/lib/rancher/rke2/agent/images/elemental-images.tar"
+		Expect(fs.Remove(archive)).To(Succeed())
+		runner.ClearCmds()
+
+		offline, err := cache.New(system, "/airgap", cache.WithOffline())
+		Expect(err).ToNot(HaveOccurred())
+		m = NewManager(system, nil, WithPullFunc(func(context.Context, []string, *platform.Platform, string) error {
+			return fmt.Errorf("no network")
+		}), WithImagePreload(p), WithCache(offline))
+		Expect(m.configureContainerImages(context.Background(), conf, rm, output)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"helm", "pull"}})).NotTo(Succeed())
+		Expect(runner.GetCmds()[0]).NotTo(ContainElement("--repo"))
+
+		data, err := fs.ReadFile(archive)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("images"))
+	})
+
+	It("Fails if a helm chart cannot be rendered", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			return []byte("chart not found"), fmt.Errorf("helm error")
+		}
+
+		m := NewManager(system, nil, WithImageList("/images.txt"))
+		err := m.configureContainerImages(context.Background(), conf, rm, output)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("rendering helm chart 'metallb'"))
+	})
+})
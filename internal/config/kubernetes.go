@@ -22,6 +22,9 @@ import (
 	_ "embed"
 	"fmt"
 	"path/filepath"
+	"slices"
+
+	"go.yaml.in/yaml/v3"
 
 	"github.com/suse/elemental/v3/internal/image"
 	"github.com/suse/elemental/v3/internal/image/kubernetes"
@@ -41,8 +44,12 @@ var k8sResDeployScriptTpl string
 //go:embed templates/k8s_conf_deploy.sh.tpl
 var k8sConfDeployScriptTpl string
 
+// kustomizationFiles are the names of the kustomization file kustomize looks for, by priority
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
 func needsManifestsSetup(conf *image.Configuration, additionalManifests map[string][]byte) bool {
-	return len(conf.Kubernetes.RemoteManifests) > 0 || len(conf.Kubernetes.LocalManifests) > 0 || conf.Kubernetes.Network.IsHA() || additionalManifests != nil
+	return len(conf.Kubernetes.RemoteManifests) > 0 || len(conf.Kubernetes.LocalManifests) > 0 ||
+		len(conf.Kubernetes.Kustomizations) > 0 || conf.Kubernetes.Network.IsHA() || additionalManifests != nil
 }
 
 func needsHelmChartsSetup(conf *image.Configuration) bool {
@@ -76,6 +83,14 @@ func (m *Manager) configureKubernetes(
 		if err != nil {
 			return "", "", fmt.Errorf("configuring helm charts: %w", err)
 		}
+
+		if m.vendorCharts {
+			if err = m.vendorHelmCharts(ctx, conf, manifest, output, runtimeHelmCharts); err != nil {
+				return "", "", fmt.Errorf("vendoring helm charts: %w", err)
+			}
+			// Vendored charts don't access their repositories, hence no credentials are needed
+			additionalManifests = nil
+		}
 	}
 
 	var runtimeManifestsDir string
@@ -119,7 +134,7 @@ func (m *Manager) setupManifests(ctx context.Context, k *kubernetes.Kubernetes,
 	}
 
 	for _, manifest := range k.RemoteManifests {
-		path := filepath.Join(manifestsDir, filepath.Base(manifest))
+		path := filepath.Join(manifestsDir, remoteFileName(manifest))
 
 		if err := m.downloadFile(ctx, fs, manifest, path); err != nil {
 			return "", fmt.Errorf("downloading remote Kubernetes manifest '%s': %w", manifest, err)
@@ -128,11 +143,34 @@ func (m *Manager) setupManifests(ctx context.Context, k *kubernetes.Kubernetes,
 
 	for _, manifest := range k.LocalManifests {
 		overlayPath := filepath.Join(manifestsDir, filepath.Base(manifest))
-		if err := vfs.CopyFile(fs, manifest, overlayPath); err != nil {
+		if err := vfs.CopyFileContext(ctx, fs, manifest, overlayPath); err != nil {
 			return "", fmt.Errorf("copying local manifest '%s' to '%s': %w", manifest, overlayPath, err)
 		}
 	}
 
+	for _, dir := range k.Kustomizations {
+		m.system.Logger().Info("Building kustomization %s", dir)
+
+		if m.offline() {
+			remote, err := remoteKustomizeResource(fs, dir, map[string]bool{})
+			if err != nil {
+				return "", fmt.Errorf("reading kustomization '%s': %w", dir, err)
+			} else if remote != "" {
+				return "", fmt.Errorf("kustomization '%s' references the remote resource '%s', which can't be fetched offline", dir, remote)
+			}
+		}
+
+		out, err := m.system.Runner().RunContext(ctx, "kustomize", "build", dir)
+		if err != nil {
+			return "", fmt.Errorf("building kustomization '%s': %w", dir, err)
+		}
+
+		overlayPath := filepath.Join(manifestsDir, fmt.Sprintf("kustomize-%s.yaml", filepath.Base(dir)))
+		if err = fs.WriteFile(overlayPath, out, 0o644); err != nil {
+			return "", fmt.Errorf("writing kustomization output '%s': %w", overlayPath, err)
+		}
+	}
+
 	for name, manifest := range additionalManifests {
 		secretPath := filepath.Join(manifestsDir, filepath.Base(name))
 		if err := fs.WriteFile(secretPath, manifest, 0o644); err != nil {
@@ -143,6 +181,68 @@ func (m *Manager) setupManifests(ctx context.Context, k *kubernetes.Kubernetes,
 	return relativeManifestsPath, nil
 }
 
+// remoteKustomizeResource returns the first resource, base or component of the kustomization in the given
+// directory, or in its local bases, which is not a local path, hence fetched by kustomize. Helm charts
+// inflated from a repository are remote too. An empty string is returned if all resources are local.
+func remoteKustomizeResource(fs vfs.FS, dir string, visited map[string]bool) (string, error) {
+	if visited[dir] {
+		return "", nil
+	}
+	visited[dir] = true
+
+	var data []byte
+	for _, name := range kustomizationFiles {
+		content, err := fs.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			data = content
+			break
+		}
+	}
+	if data == nil {
+		// not a kustomization, kustomize reports missing files on its own
+		return "", nil
+	}
+
+	var k struct {
+		Resources  []string `yaml:"resources"`
+		Bases      []string `yaml:"bases"`
+		Components []string `yaml:"components"`
+		HelmCharts []struct {
+			Name string `yaml:"name"`
+			Repo string `yaml:"repo"`
+		} `yaml:"helmCharts"`
+	}
+	if err := yaml.Unmarshal(data, &k); err != nil {
+		return "", fmt.Errorf("parsing kustomization file: %w", err)
+	}
+
+	for _, chart := range k.HelmCharts {
+		if chart.Repo != "" {
+			return fmt.Sprintf("%s/%s", chart.Repo, chart.Name), nil
+		}
+	}
+
+	for _, resource := range slices.Concat(k.Resources, k.Bases, k.Components) {
+		path := resource
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		if ok, _ := vfs.Exists(fs, path); !ok {
+			return resource, nil
+		}
+		if isDir, _ := vfs.IsDir(fs, path); !isDir {
+			continue
+		}
+
+		if remote, err := remoteKustomizeResource(fs, path, visited); err != nil || remote != "" {
+			return remote, err
+		}
+	}
+
+	return "", nil
+}
+
 func writeK8sResDeployScript(fs vfs.FS, output Output, runtimeManifestsDir string, runtimeHelmCharts []string) (string, error) {
 	values := struct {
 		HelmCharts   []string
@@ -191,6 +291,7 @@ func writeK8sConfigDeployScript(fs vfs.FS, output Output, k kubernetes.Kubernete
 
 	values := struct {
 		Nodes         kubernetes.Nodes
+		NodeIPs       map[string]string
 		APIVIP4       string
 		APIVIP6       string
 		APIHost       string
@@ -200,6 +301,7 @@ func writeK8sConfigDeployScript(fs vfs.FS, output Output, k kubernetes.Kubernete
 		InstallScript string
 	}{
 		Nodes:         k.Nodes,
+		NodeIPs:       map[string]string{},
 		APIVIP4:       k.Network.APIVIP4,
 		APIVIP6:       k.Network.APIVIP6,
 		APIHost:       k.Network.APIHost,
@@ -213,6 +315,12 @@ func writeK8sConfigDeployScript(fs vfs.FS, output Output, k kubernetes.Kubernete
 		values.InitNode = *initNode
 	}
 
+	for _, node := range k.Nodes {
+		if ip := node.NodeIP(k.Network.PrioritizeIPv6()); ip != "" {
+			values.NodeIPs[node.Hostname] = ip
+		}
+	}
+
 	data, err := template.Parse(k8sConfDeployScriptName, k8sConfDeployScriptTpl, &values)
 	if err != nil {
 		return "", fmt.Errorf("parsing deployment template: %w", err)
@@ -19,15 +19,21 @@ package config
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"path/filepath"
+	"slices"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"go.yaml.in/yaml/v3"
+
 	"github.com/suse/elemental/v3/internal/image"
 	"github.com/suse/elemental/v3/internal/image/auth"
 	"github.com/suse/elemental/v3/internal/image/kubernetes"
 	"github.com/suse/elemental/v3/internal/image/release"
+	"github.com/suse/elemental/v3/pkg/cache"
+	"github.com/suse/elemental/v3/pkg/helm"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/manifest/api/core"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
@@ -54,6 +60,15 @@ var _ = Describe("Kubernetes", func() {
 			Expect(needsManifestsSetup(conf, additionalManifests)).To(BeTrue())
 		})
 
+		It("Requires manifests setup if kustomizations are provided", func() {
+			conf := &image.Configuration{
+				Kubernetes: kubernetes.Kubernetes{
+					Kustomizations: []string{"/kustomize/apache"},
+				},
+			}
+			Expect(needsManifestsSetup(conf, nil)).To(BeTrue())
+		})
+
 		It("Requires manifests setup if there are runtime secrets", func() {
 			conf := &image.Configuration{}
 			additionalManifests := make(map[string][]byte)
@@ -304,6 +319,147 @@ var _ = Describe("Kubernetes", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("Writes the output of the kustomizations", func() {
+			runner := sysmock.NewRunner()
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if args[1] == "/kustomize/broken" {
+					return nil, fmt.Errorf("missing kustomization file")
+				}
+				return []byte("kind: Deployment\n"), nil
+			}
+			system, err = sys.NewSystem(
+				sys.WithLogger(log.New(log.WithDiscardAll())),
+				sys.WithFS(fs),
+				sys.WithRunner(runner),
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			m := NewManager(system, nil)
+			k := &kubernetes.Kubernetes{Kustomizations: []string{"/kustomize/apache"}}
+
+			dir, err := m.setupManifests(context.Background(), k, nil, output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runner.CmdsMatch([][]string{{"kustomize", "build", "/kustomize/apache"}})).To(Succeed())
+
+			data, err := fs.ReadFile(filepath.Join(output.OverlaysDir(), dir, "kustomize-apache.yaml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("kind: Deployment\n"))
+
+			k.Kustomizations = []string{"/kustomize/broken"}
+			_, err = m.setupManifests(context.Background(), k, nil, output)
+			Expect(err).To(MatchError("building kustomization '/kustomize/broken': missing kustomization file"))
+		})
+
+		It("Rejects kustomizations with remote resources offline", func() {
+			runner := sysmock.NewRunner()
+			system, err = sys.NewSystem(
+				sys.WithLogger(log.New(log.WithDiscardAll())),
+				sys.WithFS(fs),
+				sys.WithRunner(runner),
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vfs.MkdirAll(fs, "/airgap", vfs.DirPerm)).To(Succeed())
+			Expect(vfs.MkdirAll(fs, "/kustomize/app/base", vfs.DirPerm)).To(Succeed())
+			Expect(fs.WriteFile("/kustomize/app/kustomization.yaml", []byte("resources: [base, deployment.yaml]\n"), 0o644)).To(Succeed())
+			Expect(fs.WriteFile("/kustomize/app/deployment.yaml", []byte("kind: Deployment\n"), 0o644)).To(Succeed())
+			Expect(fs.WriteFile("/kustomize/app/base/kustomization.yaml", []byte("resources: [service.yaml]\n"), 0o644)).To(Succeed())
+			Expect(fs.WriteFile("/kustomize/app/base/service.yaml", []byte("kind: Service\n"), 0o644)).To(Succeed())
+
+			airgap, err := cache.New(system, "/airgap", cache.WithOffline())
+			Expect(err).ToNot(HaveOccurred())
+			m := NewManager(system, nil, WithCache(airgap))
+			k := &kubernetes.Kubernetes{Kustomizations: []string{"/kustomize/app"}}
+
+			_, err = m.setupManifests(context.Background(), k, nil, output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runner.CmdsMatch([][]string{{"kustomize", "build", "/kustomize/app"}})).To(Succeed())
+
+			remote := "https://github.com/example/app//config?ref=v1.0.0"
+			Expect(fs.WriteFile("/kustomize/app/base/kustomization.yaml", []byte("resources:\n- service.yaml\n- "+remote+"\n"), 0o644)).To(Succeed())
+			_, err = m.setupManifests(context.Background(), k, nil, output)
+			Expect(err).To(MatchError("kustomization '/kustomize/app' references the remote resource '" + remote + "', which can't be fetched offline"))
+
+			Expect(fs.WriteFile("/kustomize/app/base/kustomization.yaml", []byte("helmCharts:\n- name: metallb\n  repo: https://metallb.github.io/metallb\n"), 0o644)).To(Succeed())
+			_, err = m.setupManifests(context.Background(), k, nil, output)
+			Expect(err).To(MatchError(ContainSubstring("remote resource 'https://metallb.github.io/metallb/metallb'")))
+		})
+
+		It("Vendors the helm charts into their resources", func() {
+			runner := sysmock.NewRunner()
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				dest := args[slices.Index(args, "--destination")+1]
+				return nil, fs.WriteFile(filepath.Join(dest, "rancher-2.12.0.tgz"), []byte("chart"), 0o644)
+			}
+			system, err = sys.NewSystem(
+				sys.WithLogger(log.New(log.WithDiscardAll())),
+				sys.WithFS(fs),
+				sys.WithRunner(runner),
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			chartPath := filepath.Join(output.OverlaysDir(), image.HelmPath(), "rancher.yaml")
+			helmMock := &helmConfiguratorMock{
+				configureFunc: func(conf *image.Configuration, manifest *resolver.ResolvedManifest) ([]string, map[string][]byte, error) {
+					crd := helm.NewCRD("cattle-system", "rancher", "2.12.0", "", "https://charts.example.com", true, false)
+					data, err := yaml.Marshal(crd)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vfs.MkdirAll(fs, filepath.Dir(chartPath), vfs.DirPerm)).To(Succeed())
+					Expect(fs.WriteFile(chartPath, data, 0o644)).To(Succeed())
+					return []string{filepath.Join("/", image.HelmPath(), "rancher.yaml")},
+						map[string][]byte{"rancher-auth-priority.yaml": []byte("kind: Secret\n")}, nil
+				},
+			}
+
+			unpackFunc := func(ctx context.Context, imageRef, destDir string) error {
+				return fs.WriteFile(filepath.Join(destDir, "install.sh"), []byte("#!/bin/sh\necho test"), 0755)
+			}
+
+			m := NewManager(system, helmMock, WithUnpackFunc(unpackFunc), WithVendoredHelmCharts(true))
+
+			manifest := &resolver.ResolvedManifest{
+				CorePlatform: &core.ReleaseManifest{
+					Components: core.Components{
+						Kubernetes: &core.Kubernetes{
+							Version: "v1.35.0+rke2r1",
+							Image:   "registry.example.com/rke2:1.35_1.0",
+						},
+					},
+				},
+			}
+			conf := &image.Configuration{
+				Kubernetes: kubernetes.Kubernetes{
+					Helm: &kubernetes.Helm{
+						Charts: []*kubernetes.HelmChart{{
+							Name: "rancher", RepositoryName: "rancher", Version: "2.12.0", TargetNamespace: "cattle-system",
+						}},
+						Repositories: []*kubernetes.HelmRepository{{
+							Name: "rancher", URL: "https://charts.example.com",
+							Credentials: &auth.Credentials{Username: "user", Password: "pass"},
+						}},
+					},
+				},
+			}
+
+			_, _, err = m.configureKubernetes(context.Background(), conf, manifest, output)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(runner.MatchMilestones([][]string{
+				{"helm", "pull", "rancher", "--destination"},
+			})).To(Succeed())
+			Expect(runner.GetCmds()[0]).To(ContainElements("--repo", "https://charts.example.com", "--username", "user", "--password", "pass"))
+
+			data, err := fs.ReadFile(chartPath)
+			Expect(err).NotTo(HaveOccurred())
+			crd := &helm.CRD{}
+			Expect(yaml.Unmarshal(data, crd)).To(Succeed())
+			Expect(crd.Spec.ChartContent).To(Equal(base64.StdEncoding.EncodeToString([]byte("chart"))))
+			Expect(crd.Spec.Repo).To(BeEmpty())
+			Expect(crd.Spec.RepositoryAuthSecret).To(BeNil())
+
+			Expect(vfs.Exists(fs, filepath.Join(output.OverlaysDir(), image.KubernetesManifestsPath(), "rancher-auth-priority.yaml"))).To(BeFalse())
+		})
+
 		It("Succeeds to configure RKE2 without additional resources", func() {
 			dlFunc := func(ctx context.Context, fs vfs.FS, url, path string) error {
 				return nil
@@ -388,6 +544,33 @@ var _ = Describe("Kubernetes", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(string(b)).To(ContainSubstring(`if [[ "${HOSTNAME}" = "server01" ]]; then`))
 			Expect(string(b)).To(ContainSubstring("CONFIGFILE=/var/lib/elemental/kubernetes/init.yaml"))
+			Expect(string(b)).To(ContainSubstring(`NODEFILE="/var/lib/elemental/kubernetes/nodes/${HOSTNAME}.yaml"`))
+		})
+
+		It("Sets the node IP addresses of the nodes", func() {
+			conf := kubernetes.Kubernetes{
+				Network: kubernetes.Network{
+					ClusterCIDR: []string{"fd00:42::/56", "10.42.0.0/16"},
+				},
+				Nodes: kubernetes.Nodes{
+					{Hostname: "server01", Type: kubernetes.NodeTypeServer, IP4: "10.0.0.1", IP6: "fd00::1"},
+					{Hostname: "agent01", Type: kubernetes.NodeTypeAgent},
+				},
+			}
+
+			confScript, err := writeK8sConfigDeployScript(
+				fs,
+				output,
+				conf,
+				"/opt/k8s/install",
+				"/opt/k8s/install/install.sh",
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := fs.ReadFile(filepath.Join(output.OverlaysDir(), confScript))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("nodeips[server01]=fd00::1,10.0.0.1"))
+			Expect(string(b)).NotTo(ContainSubstring("nodeips[agent01]"))
 		})
 
 		It("Succeeds to configure RKE2 with additional resources and auth", func() {
@@ -21,13 +21,18 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/cache"
+	"github.com/suse/elemental/v3/pkg/extensions"
 	"github.com/suse/elemental/v3/pkg/extractor"
 	"github.com/suse/elemental/v3/pkg/http"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
 	"github.com/suse/elemental/v3/pkg/manifest/source"
+	"github.com/suse/elemental/v3/pkg/registry"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
@@ -44,13 +49,25 @@ type releaseManifestResolver interface {
 }
 
 type Manager struct {
-	system *sys.System
-	local  bool
+	system   *sys.System
+	local    bool
+	registry *registry.Config
+	cache    *cache.Cache
 
 	rmResolver   releaseManifestResolver
 	downloadFile downloadFunc
 	unpackImage  unpackFunc
+	pullImages   pullFunc
 	helm         helmConfigurator
+
+	imageList       string
+	preloadPlatform *platform.Platform
+	vendorCharts    bool
+	confextSigning  *extensions.Signing
+	fetchOnly       bool
+
+	configDir      string
+	builderVersion string
 }
 
 type Opts func(m *Manager)
@@ -73,12 +90,74 @@ func WithUnpackFunc(u unpackFunc) Opts {
 	}
 }
 
+func WithPullFunc(p pullFunc) Opts {
+	return func(m *Manager) {
+		m.pullImages = p
+	}
+}
+
+// WithImageList writes the list of container images required by the cluster to the given path
+func WithImageList(path string) Opts {
+	return func(m *Manager) {
+		m.imageList = path
+	}
+}
+
+// WithImagePreload preloads the container images required by the cluster for the given platform
+// into the image, so the cluster can start without pulling them
+func WithImagePreload(p *platform.Platform) Opts {
+	return func(m *Manager) {
+		m.preloadPlatform = p
+	}
+}
+
+// WithVendoredHelmCharts embeds the archives of the helm charts into their HelmChart resources,
+// so the cluster installs them without accessing their repositories
+func WithVendoredHelmCharts(vendor bool) Opts {
+	return func(m *Manager) {
+		m.vendorCharts = vendor
+	}
+}
+
+// WithConfextSigning signs the verity hash of the confext images with the given private key and certificate
+func WithConfextSigning(privateKey, certificate string) Opts {
+	return func(m *Manager) {
+		if privateKey != "" {
+			m.confextSigning = &extensions.Signing{PrivateKey: privateKey, Certificate: certificate}
+		}
+	}
+}
+
+// WithFetchOnly only fetches the artifacts of the image components, steps producing
+// artifacts locally, such as packaging the confext images, are skipped
+func WithFetchOnly() Opts {
+	return func(m *Manager) {
+		m.fetchOnly = true
+	}
+}
+
 func WithLocal(local bool) Opts {
 	return func(m *Manager) {
 		m.local = local
 	}
 }
 
+// WithRegistryConfig sets the credentials, mirrors and insecure registries used to fetch OCI images
+func WithRegistryConfig(c *registry.Config) Opts {
+	return func(m *Manager) {
+		m.registry = c
+	}
+}
+
+// WithCache reuses the OCI images, layers and helm charts stored in the given build cache and
+// records the downloaded files and preloaded images archives into it if it has a file store.
+// Helm charts are always vendored with an offline cache.
+func WithCache(c *cache.Cache) Opts {
+	return func(m *Manager) {
+		m.cache = c
+	}
+}
+
 func NewManager(sys *sys.System, helm helmConfigurator, opts ...Opts) *Manager {
 	m := &Manager{
 		system: sys,
@@ -90,17 +169,36 @@ func NewManager(sys *sys.System, helm helmConfigurator, opts ...Opts) *Manager {
 	}
 
 	if m.downloadFile == nil {
-		m.downloadFile = http.DownloadFile
+		m.downloadFile = http.Fetch
 	}
 
 	if m.unpackImage == nil {
-		m.unpackImage = func(ctx context.Context, imageRef, destDir string) error {
-			unpacker := unpack.NewOCIUnpacker(sys, imageRef, unpack.WithLocalOCI(m.local))
-			_, err := unpacker.Unpack(ctx, destDir)
-			return err
+		m.unpackImage = m.unpackOCI
+	}
+
+	if m.pullImages == nil {
+		m.pullImages = pullImagesFunc(m.registry, m.cache)
+	}
+
+	if m.cache != nil {
+		download := cache.DownloadFunc(m.downloadFile)
+		m.downloadFile = func(ctx context.Context, _ vfs.FS, url, path string) error {
+			return m.cache.Download(ctx, url, path, download)
+		}
+
+		pull := m.pullImages
+		m.pullImages = func(ctx context.Context, images []string, p *platform.Platform, path string) error {
+			return m.cache.ImageArchive(ctx, images, p.String(), path, func(ctx context.Context, path string) error {
+				return pull(ctx, images, p, path)
+			})
 		}
 	}
 
+	if m.fetchOnly || m.offline() {
+		// Air-gapped clusters can't reach the chart repositories, charts are stored and vendored
+		m.vendorCharts = true
+	}
+
 	return m
 }
 
@@ -108,7 +206,7 @@ func NewManager(sys *sys.System, helm helmConfigurator, opts ...Opts) *Manager {
 // and returns the resolved release manifest from said configuration.
 func (m *Manager) ConfigureComponents(ctx context.Context, conf *image.Configuration, output Output) (rm *resolver.ResolvedManifest, err error) {
 	if m.rmResolver == nil {
-		defaultResolver, err := defaultManifestResolver(m.system.FS(), output, m.local)
+		defaultResolver, err := defaultManifestResolver(ctx, m.system.FS(), output, m.local, m.registry, m.cache)
 		if err != nil {
 			return nil, fmt.Errorf("using default release manifest resolver: %w", err)
 		}
@@ -120,19 +218,43 @@ func (m *Manager) ConfigureComponents(ctx context.Context, conf *image.Configura
 		return nil, fmt.Errorf("resolving release manifest at uri '%s': %w", conf.Release.ManifestURI, err)
 	}
 
-	if err = m.configureNetworkOnFirstboot(conf, output); err != nil {
+	if err = applyOverrides(rm, conf.Release.Overrides, m.system.Logger()); err != nil {
+		return nil, fmt.Errorf("applying release manifest overrides: %w", err)
+	}
+
+	if err = m.configureNetworkOnFirstboot(ctx, conf, output); err != nil {
 		return nil, fmt.Errorf("configuring network: %w", err)
 	}
 
-	if err = m.configureCustomScripts(conf, output); err != nil {
+	if err = m.configureNetworkConnections(conf, output); err != nil {
+		return nil, fmt.Errorf("configuring network connections: %w", err)
+	}
+
+	if err = m.configureFirewall(conf, output); err != nil {
+		return nil, fmt.Errorf("configuring firewall: %w", err)
+	}
+
+	if err = m.configureTimeSync(conf, output); err != nil {
+		return nil, fmt.Errorf("configuring time synchronization: %w", err)
+	}
+
+	if err = m.configureCustomScripts(ctx, conf, output); err != nil {
 		return nil, fmt.Errorf("configuring custom scripts: %w", err)
 	}
 
+	if err = m.configureFirstBoot(ctx, conf, output); err != nil {
+		return nil, fmt.Errorf("configuring first boot: %w", err)
+	}
+
 	k8sScript, k8sConfScript, err := m.configureKubernetes(ctx, conf, rm, output)
 	if err != nil {
 		return nil, fmt.Errorf("configuring kubernetes: %w", err)
 	}
 
+	if err = m.configureContainerImages(ctx, conf, rm, output); err != nil {
+		return nil, fmt.Errorf("configuring container images: %w", err)
+	}
+
 	extensions, err := enabledExtensions(rm, conf, m.system.Logger())
 	if err != nil {
 		return nil, fmt.Errorf("filtering enabled systemd extensions: %w", err)
@@ -144,14 +266,22 @@ func (m *Manager) ConfigureComponents(ctx context.Context, conf *image.Configura
 		}
 	}
 
+	if err = m.configureConfexts(ctx, conf, output); err != nil {
+		return nil, fmt.Errorf("configuring confexts: %w", err)
+	}
+
 	if err = m.configureIgnition(conf, output, k8sScript, k8sConfScript, extensions); err != nil {
 		return nil, fmt.Errorf("configuring ignition: %w", err)
 	}
 
+	if err = m.writeBuildInfo(ctx, conf, rm, output); err != nil {
+		return nil, fmt.Errorf("writing build information: %w", err)
+	}
+
 	return rm, nil
 }
 
-func defaultManifestResolver(fs vfs.FS, out Output, local bool) (res *resolver.Resolver, err error) {
+func defaultManifestResolver(ctx context.Context, fs vfs.FS, out Output, local bool, reg *registry.Config, c *cache.Cache) (res *resolver.Resolver, err error) {
 	const (
 		globPattern = "release_manifest*.yaml"
 	)
@@ -166,10 +296,54 @@ func defaultManifestResolver(fs vfs.FS, out Output, local bool) (res *resolver.R
 		return nil, fmt.Errorf("creating release manifest store '%s': %w", manifestsDir, err)
 	}
 
-	extr, err := extractor.New(searchPaths, extractor.WithStore(manifestsDir), extractor.WithLocal(local))
+	extr, err := extractor.New(
+		searchPaths, extractor.WithStore(manifestsDir), extractor.WithLocal(local), extractor.WithRegistryConfig(reg),
+		extractor.WithCache(c), extractor.WithContext(ctx),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("initializing OCI release manifest extractor: %w", err)
 	}
 
 	return resolver.New(source.NewReader(extr)), nil
 }
+
+// offline returns true if the artifacts are only read from an offline cache, such as an air-gap directory
+func (m *Manager) offline() bool {
+	return m.cache != nil && m.cache.Offline()
+}
+
+// unpackOCI unpacks the given image to destDir, through the build cache if any
+func (m *Manager) unpackOCI(ctx context.Context, imageRef, destDir string) error {
+	unpacker := unpack.NewOCIUnpacker(
+		m.system, imageRef, unpack.WithLocalOCI(m.local), unpack.WithRegistryConfigOCI(m.registry),
+	)
+
+	if m.cache == nil || m.local {
+		_, err := unpacker.Unpack(ctx, destDir)
+		return err
+	}
+
+	_, err := m.cache.Unpack(ctx, imageRef, destDir, func(ctx context.Context, dest string) (string, error) {
+		return unpacker.Unpack(ctx, dest)
+	})
+	return err
+}
+
+// enableServices writes a systemd preset file with the given name to the overlays enabling the given services
+func (m *Manager) enableServices(output Output, presetName string, services ...string) error {
+	presetDir := filepath.Join(output.OverlaysDir(), image.SystemdPresetPath())
+	if err := vfs.MkdirAll(m.system.FS(), presetDir, vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating systemd preset directory in overlays: %w", err)
+	}
+
+	var preset strings.Builder
+	for _, service := range services {
+		fmt.Fprintf(&preset, "enable %s\n", service)
+	}
+
+	if err := m.system.FS().WriteFile(filepath.Join(presetDir, presetName), []byte(preset.String()), vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing systemd preset '%s': %w", presetName, err)
+	}
+
+	return nil
+}
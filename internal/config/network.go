@@ -18,18 +18,45 @@ limitations under the License.
 package config
 
 import (
+	"context"
+	_ "embed"
 	"fmt"
+	"net/netip"
 	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
 
 	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/network"
+	"github.com/suse/elemental/v3/internal/template"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
+const (
+	networkPresetName      = "50-elemental-network.preset"
+	networkServiceName     = "elemental-network.service"
+	networkApplyScriptName = "apply-host.sh"
+	networkHostMACsName    = "macs"
+	networkHostnameName    = "hostname"
+)
+
+var (
+	//go:embed templates/nmconnection.tpl
+	nmConnectionTpl string
+
+	//go:embed templates/elemental-network.service
+	networkServiceUnit string
+
+	//go:embed templates/elemental-network.sh
+	networkApplyScript string
+)
+
 func needsNetworkSetup(conf *image.Configuration) bool {
 	return conf.Network.CustomScript != "" || conf.Network.ConfigDir != ""
 }
 
-func (m *Manager) configureNetworkOnFirstboot(conf *image.Configuration, output Output) error {
+func (m *Manager) configureNetworkOnFirstboot(ctx context.Context, conf *image.Configuration, output Output) error {
 	if !needsNetworkSetup(conf) {
 		m.system.Logger().Info("Network configuration not provided, skipping.")
 		return nil
@@ -41,11 +68,189 @@ func (m *Manager) configureNetworkOnFirstboot(conf *image.Configuration, output
 	}
 
 	if conf.Network.CustomScript != "" {
-		if err := vfs.CopyFile(m.system.FS(), conf.Network.CustomScript, netDir); err != nil {
+		if err := vfs.CopyFileContext(ctx, m.system.FS(), conf.Network.CustomScript, netDir); err != nil {
 			return fmt.Errorf("copying custom network script: %w", err)
 		}
-	} else if err := vfs.CopyDir(m.system.FS(), conf.Network.ConfigDir, netDir, false, nil); err != nil {
+	} else if err := vfs.CopyDirContext(ctx, m.system.FS(), conf.Network.ConfigDir, netDir, false, nil); err != nil {
 		return fmt.Errorf("copying network config: %w", err)
 	}
 	return nil
 }
+
+// configureNetworkConnections renders the hosts of the OS network definition as NetworkManager
+// connections into the overlays. A single host is written in place, multiple hosts are stored
+// separately and the ones matching the MAC addresses of the node are applied on first boot.
+func (m *Manager) configureNetworkConnections(conf *image.Configuration, output Output) error {
+	n := conf.OS.Network
+	if n == nil || len(n.Hosts) == 0 {
+		m.system.Logger().Info("Network connections not provided, skipping.")
+		return nil
+	}
+
+	fs := m.system.FS()
+
+	if len(n.Hosts) == 1 {
+		connDir := filepath.Join(output.OverlaysDir(), image.NetworkConnectionsPath())
+		if err := m.writeHostConnections(n.Hosts[0], connDir); err != nil {
+			return err
+		}
+
+		if hostname := n.Hosts[0].Hostname; hostname != "" {
+			hostnameFile := filepath.Join(output.OverlaysDir(), image.HostnamePath())
+			if err := fs.WriteFile(hostnameFile, []byte(hostname+"\n"), vfs.FilePerm); err != nil {
+				return fmt.Errorf("writing hostname: %w", err)
+			}
+		}
+
+		m.system.Logger().Info("Network connections written")
+		return nil
+	}
+
+	hostsDir := filepath.Join(output.OverlaysDir(), image.NetworkHostsPath())
+	for i, h := range n.Hosts {
+		name := h.Hostname
+		if name == "" {
+			// Hostnames can't include underscores, hence this can't collide with the name of another host
+			name = fmt.Sprintf("_host-%d", i)
+		}
+
+		hostDir := filepath.Join(hostsDir, name)
+		if err := m.writeHostConnections(h, hostDir); err != nil {
+			return err
+		}
+
+		macs := strings.Join(h.MACAddresses(), "\n") + "\n"
+		if err := fs.WriteFile(filepath.Join(hostDir, networkHostMACsName), []byte(macs), vfs.FilePerm); err != nil {
+			return fmt.Errorf("writing MAC addresses of host '%s': %w", name, err)
+		}
+
+		if h.Hostname != "" {
+			if err := fs.WriteFile(filepath.Join(hostDir, networkHostnameName), []byte(h.Hostname+"\n"), vfs.FilePerm); err != nil {
+				return fmt.Errorf("writing hostname of host '%s': %w", name, err)
+			}
+		}
+	}
+
+	if err := fs.WriteFile(filepath.Join(hostsDir, networkApplyScriptName), []byte(networkApplyScript), 0744); err != nil {
+		return fmt.Errorf("writing network apply script: %w", err)
+	}
+
+	unitDir := filepath.Join(output.OverlaysDir(), "etc", "systemd", "system")
+	if err := vfs.MkdirAll(fs, unitDir, vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating systemd units directory in overlays: %w", err)
+	}
+
+	if err := fs.WriteFile(filepath.Join(unitDir, networkServiceName), []byte(networkServiceUnit), vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing network service: %w", err)
+	}
+
+	if err := m.enableServices(output, networkPresetName, networkServiceName); err != nil {
+		return fmt.Errorf("enabling network service: %w", err)
+	}
+
+	m.system.Logger().Info("Network connections written for %d hosts", len(n.Hosts))
+
+	return nil
+}
+
+func (m *Manager) writeHostConnections(h network.Host, dir string) error {
+	fs := m.system.FS()
+
+	if err := vfs.MkdirAll(fs, dir, vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating network connections directory in overlays: %w", err)
+	}
+
+	for _, i := range h.Interfaces {
+		data, err := template.Parse("nmconnection", nmConnectionTpl, connectionValues(h, i))
+		if err != nil {
+			return fmt.Errorf("parsing connection template of interface '%s': %w", i.Name, err)
+		}
+
+		// NetworkManager ignores connection files readable by other users
+		connFile := filepath.Join(dir, i.Name+".nmconnection")
+		if err = fs.WriteFile(connFile, []byte(data), 0600); err != nil {
+			return fmt.Errorf("writing connection of interface '%s': %w", i.Name, err)
+		}
+	}
+
+	return nil
+}
+
+type ipValues struct {
+	Family    string
+	Method    string
+	Addresses []string
+	Gateway   string
+	DNS       []string
+}
+
+type nmConnection struct {
+	Name          string
+	UUID          string
+	Type          network.InterfaceType
+	InterfaceName string
+	MACAddress    string
+	MTU           int
+	Controller    string
+	Bond          *network.BondConfig
+	VLAN          *network.VLANConfig
+	IP            []ipValues
+}
+
+// connectionUUID returns a stable connection UUID, so bonds and VLANs can refer to
+// connections bound by MAC address regardless of the name of the interface
+func connectionUUID(h network.Host, name string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(h.Hostname+"/"+name)).String()
+}
+
+func connectionValues(h network.Host, i network.Interface) *nmConnection {
+	values := &nmConnection{
+		Name:       i.Name,
+		UUID:       connectionUUID(h, i.Name),
+		Type:       i.GetType(),
+		MACAddress: strings.ToLower(i.MACAddress),
+		MTU:        i.MTU,
+		Bond:       i.Bond,
+	}
+
+	if values.MACAddress == "" {
+		values.InterfaceName = i.Name
+	}
+
+	if i.VLAN != nil {
+		values.VLAN = &network.VLANConfig{ID: i.VLAN.ID, Parent: connectionUUID(h, i.VLAN.Parent)}
+	}
+
+	if i.Controller != "" {
+		// Ports of a bond have no IP settings of their own
+		values.Controller = connectionUUID(h, i.Controller)
+		return values
+	}
+
+	v4 := ipValues{Family: "ipv4", Method: "auto", Gateway: i.Gateway4}
+	v6 := ipValues{Family: "ipv6", Method: "auto", Gateway: i.Gateway6}
+
+	if len(i.Addresses) > 0 {
+		v4.Method, v6.Method = "disabled", "disabled"
+
+		for _, a := range i.Addresses {
+			prefix, err := netip.ParsePrefix(a)
+			if err != nil {
+				continue
+			}
+
+			ip := &v6
+			if prefix.Addr().Is4() {
+				ip = &v4
+			}
+			ip.Method = "manual"
+			ip.Addresses = append(ip.Addresses, fmt.Sprintf("address%d=%s", len(ip.Addresses)+1, a))
+		}
+
+	}
+	v4.DNS, v6.DNS = h.NameServers(i)
+
+	values.IP = []ipValues{v4, v6}
+
+	return values
+}
@@ -18,12 +18,15 @@ limitations under the License.
 package config
 
 import (
+	"context"
+	"os"
 	"path/filepath"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/network"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
@@ -67,7 +70,7 @@ var _ = Describe("Network", func() {
 	})
 
 	It("Skips configuration", func() {
-		err := m.configureNetworkOnFirstboot(&image.Configuration{}, Output{})
+		err := m.configureNetworkOnFirstboot(context.Background(), &image.Configuration{}, Output{})
 		Expect(err).NotTo(HaveOccurred())
 	})
 
@@ -78,7 +81,7 @@ var _ = Describe("Network", func() {
 			},
 		}
 
-		err := m.configureNetworkOnFirstboot(conf, output)
+		err := m.configureNetworkOnFirstboot(context.Background(), conf, output)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("copying custom network script: stat"))
 		Expect(err.Error()).To(ContainSubstring("/etc/custom.sh: no such file or directory"))
@@ -91,7 +94,7 @@ var _ = Describe("Network", func() {
 			},
 		}
 
-		err := m.configureNetworkOnFirstboot(conf, output)
+		err := m.configureNetworkOnFirstboot(context.Background(), conf, output)
 		Expect(err).NotTo(HaveOccurred())
 
 		// Verify script contents
@@ -112,13 +115,13 @@ var _ = Describe("Network", func() {
 			},
 		}
 
-		err := m.configureNetworkOnFirstboot(conf, output)
+		err := m.configureNetworkOnFirstboot(context.Background(), conf, output)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("copying network config: stat"))
 		Expect(err.Error()).To(ContainSubstring("/etc/missing: no such file or directory"))
 
 		conf.Network.ConfigDir = "/etc/network"
-		err = m.configureNetworkOnFirstboot(conf, output)
+		err = m.configureNetworkOnFirstboot(context.Background(), conf, output)
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError("copying network config: directories under /etc/network are not supported"))
 	})
@@ -130,7 +133,7 @@ var _ = Describe("Network", func() {
 			},
 		}
 
-		err := m.configureNetworkOnFirstboot(conf, output)
+		err := m.configureNetworkOnFirstboot(context.Background(), conf, output)
 		Expect(err).ToNot(HaveOccurred())
 
 		netDir := filepath.Join(output.CatalystConfigDir(), "network")
@@ -146,3 +149,165 @@ var _ = Describe("Network", func() {
 		Expect(string(contents)).To(Equal("qemu: true"))
 	})
 })
+
+var _ = Describe("Network connections", func() {
+	var output = Output{
+		RootPath: "/_out",
+	}
+
+	var m *Manager
+	var fs vfs.FS
+	var cleanup func()
+	var err error
+
+	BeforeEach(func() {
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		system, err := sys.NewSystem(
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithFS(fs),
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		m = NewManager(system, nil)
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("Skips configuration", func() {
+		Expect(m.configureNetworkConnections(&image.Configuration{}, output)).To(Succeed())
+
+		exists, _ := vfs.Exists(fs, filepath.Join(output.OverlaysDir(), image.NetworkConnectionsPath()))
+		Expect(exists).To(BeFalse())
+	})
+
+	It("Writes the connections of a single host in place", func() {
+		host := network.Host{
+			Hostname: "node1",
+			DNS:      []string{"192.168.120.1", "fd00::1"},
+			Interfaces: []network.Interface{
+				{Name: "eth0", MACAddress: "52:54:00:AA:00:01", Controller: "bond0"},
+				{Name: "bond0", Type: network.Bond, Bond: &network.BondConfig{Mode: "active-backup", MIIMon: 100}, MTU: 9000},
+				{
+					Name: "bond0.10", Type: network.VLAN, VLAN: &network.VLANConfig{ID: 10, Parent: "bond0"},
+					Addresses: []string{"192.168.120.10/24"}, Gateway4: "192.168.120.1",
+				},
+			},
+		}
+		conf := &image.Configuration{OS: image.OperatingSystem{Network: &network.Network{Hosts: []network.Host{host}}}}
+
+		Expect(m.configureNetworkConnections(conf, output)).To(Succeed())
+
+		connDir := filepath.Join(output.OverlaysDir(), image.NetworkConnectionsPath())
+		bondUUID := connectionUUID(host, "bond0")
+
+		data, err := fs.ReadFile(filepath.Join(connDir, "eth0.nmconnection"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(`# Generated by elemental from the image definition.
+[connection]
+id=eth0
+uuid=` + connectionUUID(host, "eth0") + `
+type=ethernet
+controller=` + bondUUID + `
+port-type=bond
+
+[ethernet]
+mac-address=52:54:00:aa:00:01
+`))
+
+		info, err := fs.Stat(filepath.Join(connDir, "eth0.nmconnection"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		data, err = fs.ReadFile(filepath.Join(connDir, "bond0.nmconnection"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(`# Generated by elemental from the image definition.
+[connection]
+id=bond0
+uuid=` + bondUUID + `
+type=bond
+interface-name=bond0
+
+[ethernet]
+mtu=9000
+
+[bond]
+mode=active-backup
+miimon=100
+
+[ipv4]
+method=auto
+
+[ipv6]
+method=auto
+dns=fd00::1;
+`))
+
+		data, err = fs.ReadFile(filepath.Join(connDir, "bond0.10.nmconnection"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(`# Generated by elemental from the image definition.
+[connection]
+id=bond0.10
+uuid=` + connectionUUID(host, "bond0.10") + `
+type=vlan
+interface-name=bond0.10
+
+[vlan]
+id=10
+parent=` + bondUUID + `
+
+[ipv4]
+method=manual
+address1=192.168.120.10/24
+gateway=192.168.120.1
+dns=192.168.120.1;
+
+[ipv6]
+method=disabled
+`))
+
+		data, err = fs.ReadFile(filepath.Join(output.OverlaysDir(), image.HostnamePath()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("node1\n"))
+
+		exists, _ := vfs.Exists(fs, filepath.Join(output.OverlaysDir(), image.NetworkHostsPath()))
+		Expect(exists).To(BeFalse())
+	})
+
+	It("Writes the connections of multiple hosts to be applied on first boot", func() {
+		conf := &image.Configuration{OS: image.OperatingSystem{Network: &network.Network{Hosts: []network.Host{
+			{Hostname: "node1", Interfaces: []network.Interface{{Name: "eth0", MACAddress: "52:54:00:aa:00:01"}}},
+			{Interfaces: []network.Interface{
+				{Name: "eth0", MACAddress: "52:54:00:aa:00:02"},
+				{Name: "eth1", MACAddress: "52:54:00:aa:00:03"},
+			}},
+		}}}}
+
+		Expect(m.configureNetworkConnections(conf, output)).To(Succeed())
+
+		hostsDir := filepath.Join(output.OverlaysDir(), image.NetworkHostsPath())
+		Expect(vfs.Exists(fs, filepath.Join(hostsDir, "node1", "eth0.nmconnection"))).To(BeTrue())
+		Expect(vfs.Exists(fs, filepath.Join(hostsDir, "_host-1", "eth1.nmconnection"))).To(BeTrue())
+		Expect(vfs.Exists(fs, filepath.Join(hostsDir, "_host-1", networkHostnameName))).To(BeFalse())
+		Expect(vfs.Exists(fs, filepath.Join(hostsDir, networkApplyScriptName))).To(BeTrue())
+
+		data, err := fs.ReadFile(filepath.Join(hostsDir, "_host-1", networkHostMACsName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("52:54:00:aa:00:02\n52:54:00:aa:00:03\n"))
+
+		data, err = fs.ReadFile(filepath.Join(hostsDir, "node1", networkHostnameName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("node1\n"))
+
+		Expect(vfs.Exists(fs, filepath.Join(output.OverlaysDir(), "etc", "systemd", "system", networkServiceName))).To(BeTrue())
+		data, err = fs.ReadFile(filepath.Join(output.OverlaysDir(), image.SystemdPresetPath(), networkPresetName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("enable elemental-network.service\n"))
+
+		exists, _ := vfs.Exists(fs, filepath.Join(output.OverlaysDir(), image.NetworkConnectionsPath()))
+		Expect(exists).To(BeFalse())
+	})
+})
@@ -0,0 +1,126 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/suse/elemental/v3/internal/image/release"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+)
+
+// applyOverrides replaces the component versions of the resolved release manifest with the ones
+// pinned in the image definition. Every override is logged as a warning since the resulting set
+// of components is not the one validated for the release.
+func applyOverrides(rm *resolver.ResolvedManifest, o *release.Overrides, logger log.Logger) error {
+	if o == nil {
+		return nil
+	}
+
+	components := &rm.CorePlatform.Components
+	if o.OperatingSystem != nil {
+		image := &components.OperatingSystem.Image
+		if o.OperatingSystem.Base != "" {
+			logger.Warn("Overriding release manifest OS image '%s' with '%s'", image.Base, o.OperatingSystem.Base)
+			image.Base = o.OperatingSystem.Base
+		}
+		if o.OperatingSystem.ISO != "" {
+			logger.Warn("Overriding release manifest OS ISO image '%s' with '%s'", image.ISO, o.OperatingSystem.ISO)
+			image.ISO = o.OperatingSystem.ISO
+		}
+	}
+
+	if o.Kubernetes != nil {
+		if components.Kubernetes == nil {
+			return fmt.Errorf("overriding kubernetes: not defined in the release manifest")
+		}
+		logger.Warn(
+			"Overriding release manifest Kubernetes version '%s' with '%s'", components.Kubernetes.Version, o.Kubernetes.Version,
+		)
+		components.Kubernetes.Version = o.Kubernetes.Version
+		components.Kubernetes.Image = o.Kubernetes.Image
+		if len(components.Kubernetes.Images) > 0 {
+			logger.Warn("Dropping the Kubernetes container images of the release manifest, they belong to the overridden version")
+			components.Kubernetes.Images = nil
+		}
+	}
+
+	charts := releaseHelmCharts(rm)
+	for _, override := range o.HelmCharts {
+		chart, ok := charts[override.Name]
+		if !ok {
+			return fmt.Errorf("overriding helm chart '%s': not defined in the release manifest", override.Name)
+		}
+		logger.Warn(
+			"Overriding release manifest version '%s' of helm chart '%s' with '%s'", chart.Version, chart.Chart, override.Version,
+		)
+		chart.Version = override.Version
+	}
+
+	extensions := releaseExtensions(rm)
+	for _, override := range o.SystemdExtensions {
+		extension, ok := extensions[override.Name]
+		if !ok {
+			return fmt.Errorf("overriding systemd extension '%s': not defined in the release manifest", override.Name)
+		}
+		logger.Warn(
+			"Overriding release manifest image '%s' of systemd extension '%s' with '%s'", extension.Image, extension.Name, override.Image,
+		)
+		extension.Image = override.Image
+	}
+
+	return nil
+}
+
+// releaseHelmCharts returns the helm charts of the core platform and the solution extension indexed by chart name
+func releaseHelmCharts(rm *resolver.ResolvedManifest) map[string]*api.HelmChart {
+	charts := map[string]*api.HelmChart{}
+	for _, h := range []*api.Helm{rm.CorePlatform.Components.Helm, solutionHelm(rm)} {
+		if h == nil {
+			continue
+		}
+		for _, c := range h.Charts {
+			charts[c.Chart] = c
+		}
+	}
+	return charts
+}
+
+func solutionHelm(rm *resolver.ResolvedManifest) *api.Helm {
+	if rm.SolutionExtension == nil {
+		return nil
+	}
+	return rm.SolutionExtension.Components.Helm
+}
+
+// releaseExtensions returns the systemd extensions of the core platform and the solution extension indexed by name
+func releaseExtensions(rm *resolver.ResolvedManifest) map[string]*api.SystemdExtension {
+	extensions := map[string]*api.SystemdExtension{}
+	systemd := []*api.Systemd{&rm.CorePlatform.Components.Systemd}
+	if rm.SolutionExtension != nil {
+		systemd = append(systemd, &rm.SolutionExtension.Components.Systemd)
+	}
+	for _, s := range systemd {
+		for i := range s.Extensions {
+			extensions[s.Extensions[i].Name] = &s.Extensions[i]
+		}
+	}
+	return extensions
+}
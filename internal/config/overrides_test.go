@@ -0,0 +1,101 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/image/release"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/api/solution"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+)
+
+var _ = Describe("Release manifest overrides", func() {
+	logger := log.New(log.WithDiscardAll())
+	var rm *resolver.ResolvedManifest
+
+	BeforeEach(func() {
+		rm = &resolver.ResolvedManifest{
+			CorePlatform: &core.ReleaseManifest{
+				Components: core.Components{
+					OperatingSystem: &core.OperatingSystem{
+						Image: core.Image{Base: "registry.suse.com/os:6.2", ISO: "registry.suse.com/iso:6.2"},
+					},
+					Kubernetes: &core.Kubernetes{
+						Version: "v1.33.1+rke2r1", Image: "registry.suse.com/rke2:1.33",
+						Images: []string{"registry.suse.com/rke2/kubernetes:v1.33.1"},
+					},
+					Systemd: api.Systemd{
+						Extensions: []api.SystemdExtension{{Name: "rke2", Image: "registry.suse.com/rke2-ext:1.33"}},
+					},
+					Helm: &api.Helm{
+						Charts: []*api.HelmChart{{Chart: "metallb", Version: "0.15.0"}},
+					},
+				},
+			},
+			SolutionExtension: &solution.ReleaseManifest{
+				Components: solution.Components{
+					Helm: &api.Helm{
+						Charts: []*api.HelmChart{{Chart: "rancher", Version: "2.12.0"}},
+					},
+				},
+			},
+		}
+	})
+
+	It("Keeps the release manifest without overrides", func() {
+		Expect(applyOverrides(rm, nil, logger)).To(Succeed())
+		Expect(rm.CorePlatform.Components.Kubernetes.Version).To(Equal("v1.33.1+rke2r1"))
+	})
+
+	It("Pins component versions", func() {
+		overrides := &release.Overrides{
+			OperatingSystem:   &release.OperatingSystemOverride{Base: "registry.suse.com/os:6.2-hotfix"},
+			Kubernetes:        &release.KubernetesOverride{Version: "v1.32.5+rke2r1", Image: "registry.suse.com/rke2:1.32"},
+			HelmCharts:        []release.HelmChartOverride{{Name: "rancher", Version: "2.11.3"}},
+			SystemdExtensions: []release.SystemdExtensionOverride{{Name: "rke2", Image: "registry.suse.com/rke2-ext:1.32"}},
+		}
+		Expect(applyOverrides(rm, overrides, logger)).To(Succeed())
+
+		components := rm.CorePlatform.Components
+		Expect(components.OperatingSystem.Image.Base).To(Equal("registry.suse.com/os:6.2-hotfix"))
+		Expect(components.OperatingSystem.Image.ISO).To(Equal("registry.suse.com/iso:6.2"))
+		Expect(components.Kubernetes.Version).To(Equal("v1.32.5+rke2r1"))
+		Expect(components.Kubernetes.Image).To(Equal("registry.suse.com/rke2:1.32"))
+		Expect(components.Kubernetes.Images).To(BeEmpty())
+		Expect(components.Systemd.Extensions[0].Image).To(Equal("registry.suse.com/rke2-ext:1.32"))
+		Expect(components.Helm.Charts[0].Version).To(Equal("0.15.0"))
+		Expect(rm.SolutionExtension.Components.Helm.Charts[0].Version).To(Equal("2.11.3"))
+	})
+
+	It("Fails to override components missing in the release manifest", func() {
+		err := applyOverrides(rm, &release.Overrides{HelmCharts: []release.HelmChartOverride{{Name: "missing", Version: "1.0"}}}, logger)
+		Expect(err).To(MatchError("overriding helm chart 'missing': not defined in the release manifest"))
+
+		err = applyOverrides(rm, &release.Overrides{SystemdExtensions: []release.SystemdExtensionOverride{{Name: "missing", Image: "ext"}}}, logger)
+		Expect(err).To(MatchError("overriding systemd extension 'missing': not defined in the release manifest"))
+
+		rm.CorePlatform.Components.Kubernetes = nil
+		err = applyOverrides(rm, &release.Overrides{Kubernetes: &release.KubernetesOverride{Version: "v1", Image: "rke2"}}, logger)
+		Expect(err).To(MatchError("overriding kubernetes: not defined in the release manifest"))
+	})
+})
@@ -32,7 +32,6 @@ import (
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
 	"github.com/suse/elemental/v3/pkg/rsync"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
-	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
 func (m *Manager) downloadSystemExtensions(ctx context.Context, extensions []api.SystemdExtension, output Output) error {
@@ -49,7 +48,7 @@ func (m *Manager) downloadSystemExtensions(ctx context.Context, extensions []api
 			extension.Name, extension.Image)
 
 		if isRemoteURL(extension.Image) {
-			extensionPath := filepath.Join(extensionsDir, filepath.Base(extension.Image))
+			extensionPath := filepath.Join(extensionsDir, remoteFileName(extension.Image))
 			if err := m.downloadFile(ctx, fs, extension.Image, extensionPath); err != nil {
 				return fmt.Errorf("downloading systemd extension %s: %w", extension.Name, err)
 			}
@@ -74,6 +73,16 @@ func isRemoteURL(s string) bool {
 	return u.Scheme == "http" || u.Scheme == "https"
 }
 
+// remoteFileName returns the file name of the given URL, ignoring its query and checksum fragment
+func remoteFileName(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return filepath.Base(s)
+	}
+
+	return filepath.Base(u.Path)
+}
+
 func (m *Manager) unpackExtension(ctx context.Context, extension api.SystemdExtension, extensionsDir string) error {
 	fs := m.system.FS()
 
@@ -85,8 +94,7 @@ func (m *Manager) unpackExtension(ctx context.Context, extension api.SystemdExte
 		_ = fs.RemoveAll(tempDir)
 	}()
 
-	unpacker := unpack.NewOCIUnpacker(m.system, extension.Image, unpack.WithLocalOCI(m.local))
-	if _, err = unpacker.Unpack(ctx, tempDir); err != nil {
+	if err = m.unpackOCI(ctx, extension.Image, tempDir); err != nil {
 		return fmt.Errorf("unpacking extension: %w", err)
 	}
 
@@ -99,7 +107,7 @@ func (m *Manager) unpackExtension(ctx context.Context, extension api.SystemdExte
 		entry := entries[0]
 		if !entry.IsDir() {
 			file := filepath.Join(tempDir, entry.Name())
-			if err = vfs.CopyFile(fs, file, extensionsDir); err != nil {
+			if err = vfs.CopyFileContext(ctx, fs, file, extensionsDir); err != nil {
 				return fmt.Errorf("copying extension file %s: %w", file, err)
 			}
 
@@ -0,0 +1,15 @@
+# Generated by elemental from the image definition.
+{{- range .Servers }}
+{{ if .Pool }}pool{{ else }}server{{ end }} {{ .Address }} iburst{{ if .NTS }} nts{{ end }}{{ if .Prefer }} prefer{{ end }}
+{{- end }}
+{{- with .MakeStep }}
+makestep {{ .Threshold }} {{ .Limit }}
+{{- end }}
+{{- if .MinSources }}
+minsources {{ .MinSources }}
+{{- end }}
+{{- if .Hardened }}
+authselectmode require
+ntsdumpdir /var/lib/chrony
+cmdport 0
+{{- end }}
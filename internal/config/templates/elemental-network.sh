@@ -0,0 +1,27 @@
+#!/bin/bash
+# Installs the NetworkManager connections of the host whose MAC addresses
+# match the network cards of this node. Generated by elemental.
+set -euo pipefail
+
+HOSTS_DIR=/etc/elemental/network
+CONNECTIONS_DIR=/etc/NetworkManager/system-connections
+
+macs=$(cat /sys/class/net/*/address | tr '[:upper:]' '[:lower:]')
+
+for host in "${HOSTS_DIR}"/*/; do
+  while read -r mac; do
+    if grep -qxF "${mac}" <<< "${macs}"; then
+      echo "Applying network configuration of host $(basename "${host}")"
+      mkdir -p "${CONNECTIONS_DIR}"
+      install -m 0600 "${host}"*.nmconnection "${CONNECTIONS_DIR}/"
+      if [ -f "${host}hostname" ]; then
+        install -m 0644 "${host}hostname" /etc/hostname
+        hostname -F /etc/hostname
+      fi
+      touch "${HOSTS_DIR}/.applied"
+      exit 0
+    fi
+  done < "${host}macs"
+done
+
+echo "No network configuration matches the MAC addresses of this node, skipping"
@@ -0,0 +1,3 @@
+# Sets the default zone of the image definition in firewalld.conf, keeping the rest of its settings
+[Service]
+ExecStartPre=/bin/sh -c 'if grep -q "^DefaultZone=" /etc/firewalld/firewalld.conf; then sed -i "s/^DefaultZone=.*/DefaultZone={{ .DefaultZone }}/" /etc/firewalld/firewalld.conf; else echo "DefaultZone={{ .DefaultZone }}" >> /etc/firewalld/firewalld.conf; fi'
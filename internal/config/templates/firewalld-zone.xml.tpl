@@ -0,0 +1,17 @@
+<?xml version="1.0" encoding="utf-8"?>
+<zone{{ if .Target }} target="{{ .Target }}"{{ end }}>
+  <short>{{ .Name }}</short>
+  <description>Generated by elemental from the image definition.</description>
+{{- range .Interfaces }}
+  <interface name="{{ . }}"/>
+{{- end }}
+{{- range .Sources }}
+  <source address="{{ . }}"/>
+{{- end }}
+{{- range .Services }}
+  <service name="{{ . }}"/>
+{{- end }}
+{{- range .Ports }}
+  <port protocol="{{ .Protocol }}" port="{{ .Number }}"/>
+{{- end }}
+</zone>
@@ -8,6 +8,12 @@ declare -A hosts
 hosts[{{ .Hostname }}]={{ .Type }}
 {{- end }}
 
+declare -A nodeips
+
+{{- range $hostname, $ip := .NodeIPs }}
+nodeips[{{ $hostname }}]={{ $ip }}
+{{- end }}
+
 # This is to support both static and DHCP configurations
 HOSTNAME=$(</etc/hostname)
 [[ -z "${HOSTNAME}" ]] \
@@ -24,12 +30,25 @@ if [[ "${HOSTNAME}" = "{{ .InitNode.Hostname }}" ]]; then
 fi
 {{- end }}
 
+# Nodes of the cluster definition get their complete configuration rendered at build time
+NODEFILE="{{ .KubernetesDir }}/nodes/${HOSTNAME}.yaml"
+if [[ -e "${NODEFILE}" ]]; then
+  echo "Using configuration of node ${HOSTNAME}"
+  CONFIGFILE="${NODEFILE}"
+fi
+
 # Better to append if a file exist
 # Useful if some custom configuration are done at boot
 mkdir -p /etc/rancher/rke2
 echo "Copying RKE2 config file ${CONFIGFILE}"
 cat ${CONFIGFILE} >> /etc/rancher/rke2/config.yaml
 
+NODEIP="${nodeips[${HOSTNAME}]:-}"
+if [[ -n "${NODEIP}" ]] && ! grep -q "^node-ip:" /etc/rancher/rke2/config.yaml; then
+  echo "Setting node IP ${NODEIP}"
+  echo "node-ip: \"${NODEIP}\"" >> /etc/rancher/rke2/config.yaml
+fi
+
 if [[ -e "${REGFILE}" ]]; then
   cp "${REGFILE}" /etc/rancher/rke2/registries.yaml
 fi
@@ -0,0 +1,39 @@
+#!/usr/sbin/nft -f
+# Generated by elemental from the image definition.
+
+flush ruleset
+
+table inet filter {
+	chain input {
+		type filter hook input priority filter; policy {{ .Policy }};
+
+		ct state established,related accept
+		ct state invalid drop
+		iif "lo" accept
+		meta l4proto { icmp, ipv6-icmp } accept
+{{- range .Zones }}
+{{- $zone := .Name }}
+{{- if .Interfaces }}
+		iifname { {{ join .Interfaces ", " }} } jump zone_{{ $zone }}
+{{- end }}
+{{- if .Sources4 }}
+		ip saddr { {{ join .Sources4 ", " }} } jump zone_{{ $zone }}
+{{- end }}
+{{- if .Sources6 }}
+		ip6 saddr { {{ join .Sources6 ", " }} } jump zone_{{ $zone }}
+{{- end }}
+{{- end }}
+{{- if .DefaultZone }}
+		jump zone_{{ .DefaultZone }}
+{{- end }}
+	}
+{{- range .Zones }}
+
+	chain zone_{{ .Name }} {
+{{- range .Ports }}
+		{{ . }} accept
+{{- end }}
+		{{ .Verdict }}
+	}
+{{- end }}
+}
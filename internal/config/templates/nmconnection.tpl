@@ -0,0 +1,50 @@
+# Generated by elemental from the image definition.
+[connection]
+id={{ .Name }}
+uuid={{ .UUID }}
+type={{ .Type }}
+{{- with .InterfaceName }}
+interface-name={{ . }}
+{{- end }}
+{{- with .Controller }}
+controller={{ . }}
+port-type=bond
+{{- end }}
+{{- if or .MACAddress .MTU }}
+
+[ethernet]
+{{- with .MACAddress }}
+mac-address={{ . }}
+{{- end }}
+{{- with .MTU }}
+mtu={{ . }}
+{{- end }}
+{{- end }}
+{{- with .Bond }}
+
+[bond]
+mode={{ .Mode }}
+{{- with .MIIMon }}
+miimon={{ . }}
+{{- end }}
+{{- end }}
+{{- with .VLAN }}
+
+[vlan]
+id={{ .ID }}
+parent={{ .Parent }}
+{{- end }}
+{{- range .IP }}
+
+[{{ .Family }}]
+method={{ .Method }}
+{{- range .Addresses }}
+{{ . }}
+{{- end }}
+{{- with .Gateway }}
+gateway={{ . }}
+{{- end }}
+{{- with .DNS }}
+dns={{ join . ";" }};
+{{- end }}
+{{- end }}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/timesync"
+	"github.com/suse/elemental/v3/internal/template"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const timeSyncPresetName = "50-elemental-timesync.preset"
+
+//go:embed templates/chrony.conf.tpl
+var chronyConfTpl string
+
+// configureTimeSync renders the chrony configuration of the OS definition into the overlays
+// and enables the chronyd service.
+func (m *Manager) configureTimeSync(conf *image.Configuration, output Output) error {
+	ts := conf.OS.TimeSync
+	if ts == nil {
+		m.system.Logger().Info("Time synchronization configuration not provided, skipping.")
+		return nil
+	}
+
+	type makeStep struct {
+		Threshold string
+		Limit     int
+	}
+
+	values := struct {
+		Servers    []timesync.Server
+		MakeStep   *makeStep
+		MinSources int
+		Hardened   bool
+	}{
+		Servers:    ts.Servers,
+		MinSources: ts.GetMinSources(),
+		Hardened:   ts.Hardened,
+	}
+
+	if step := ts.GetMakeStep(); step != nil {
+		values.MakeStep = &makeStep{
+			Threshold: strconv.FormatFloat(step.Threshold, 'f', -1, 64),
+			Limit:     step.Limit,
+		}
+	}
+
+	data, err := template.Parse("chrony", chronyConfTpl, &values)
+	if err != nil {
+		return fmt.Errorf("parsing chrony template: %w", err)
+	}
+
+	confFile := filepath.Join(output.OverlaysDir(), image.ChronyConfigPath())
+	if err = vfs.MkdirAll(m.system.FS(), filepath.Dir(confFile), vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating chrony configuration directory in overlays: %w", err)
+	}
+
+	if err = m.system.FS().WriteFile(confFile, []byte(data), vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing chrony configuration: %w", err)
+	}
+
+	if err = m.enableServices(output, timeSyncPresetName, "chronyd.service"); err != nil {
+		return fmt.Errorf("enabling chronyd service: %w", err)
+	}
+
+	m.system.Logger().Info("Time synchronization configuration written")
+
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/timesync"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("TimeSync", func() {
+	var output = Output{
+		RootPath: "/_out",
+	}
+
+	var m *Manager
+	var fs vfs.FS
+	var cleanup func()
+	var err error
+
+	BeforeEach(func() {
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		system, err := sys.NewSystem(
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithFS(fs),
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		m = NewManager(system, nil)
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("Skips configuration", func() {
+		Expect(m.configureTimeSync(&image.Configuration{}, output)).To(Succeed())
+
+		exists, _ := vfs.Exists(fs, filepath.Join(output.OverlaysDir(), image.ChronyConfigPath()))
+		Expect(exists).To(BeFalse())
+	})
+
+	It("Writes chrony configuration", func() {
+		conf := &image.Configuration{
+			OS: image.OperatingSystem{
+				TimeSync: &timesync.TimeSync{
+					Servers: []timesync.Server{
+						{Address: "ntp.example.com", Prefer: true},
+						{Address: "pool.ntp.org", Pool: true},
+					},
+					MakeStep:   &timesync.MakeStep{Threshold: 0.5, Limit: -1},
+					MinSources: 2,
+				},
+			},
+		}
+
+		Expect(m.configureTimeSync(conf, output)).To(Succeed())
+
+		data, err := fs.ReadFile(filepath.Join(output.OverlaysDir(), image.ChronyConfigPath()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(`# Generated by elemental from the image definition.
+server ntp.example.com iburst prefer
+pool pool.ntp.org iburst
+makestep 0.5 -1
+minsources 2
+`))
+
+		data, err = fs.ReadFile(filepath.Join(output.OverlaysDir(), image.SystemdPresetPath(), timeSyncPresetName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("enable chronyd.service\n"))
+	})
+
+	It("Writes the hardened profile defaults", func() {
+		conf := &image.Configuration{
+			OS: image.OperatingSystem{
+				TimeSync: &timesync.TimeSync{
+					Servers: []timesync.Server{
+						{Address: "time.cloudflare.com", NTS: true},
+						{Address: "nts.netnod.se", NTS: true},
+					},
+					Hardened: true,
+				},
+			},
+		}
+
+		Expect(m.configureTimeSync(conf, output)).To(Succeed())
+
+		data, err := fs.ReadFile(filepath.Join(output.OverlaysDir(), image.ChronyConfigPath()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(`# Generated by elemental from the image definition.
+server time.cloudflare.com iburst nts
+server nts.netnod.se iburst nts
+makestep 1 3
+minsources 2
+authselectmode require
+ntsdumpdir /var/lib/chrony
+cmdport 0
+`))
+	})
+})
@@ -31,6 +31,7 @@ import (
 	"github.com/suse/elemental/v3/internal/image"
 	"github.com/suse/elemental/v3/internal/image/kubernetes"
 	"github.com/suse/elemental/v3/internal/image/release"
+	"github.com/suse/elemental/v3/pkg/extensions"
 	"github.com/suse/elemental/v3/pkg/manifest/source"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
@@ -49,6 +50,10 @@ func (dir Dir) ClusterFilepath() string {
 	return filepath.Join(dir.kubernetesDir(), "cluster.yaml")
 }
 
+func (dir Dir) OSFilepath() string {
+	return filepath.Join(string(dir), "os.yaml")
+}
+
 func (dir Dir) ButaneFilepath() string {
 	return filepath.Join(string(dir), "butane.yaml")
 }
@@ -77,6 +82,10 @@ func (dir Dir) KubernetesManifestsDir() string {
 	return filepath.Join(dir.kubernetesDir(), "manifests")
 }
 
+func (dir Dir) KubernetesKustomizeDir() string {
+	return filepath.Join(dir.kubernetesDir(), "kustomize")
+}
+
 func (dir Dir) HelmValuesDir() string {
 	return filepath.Join(dir.kubernetesDir(), "helm", "values")
 }
@@ -89,6 +98,14 @@ func (dir Dir) CustomDir() string {
 	return filepath.Join(string(dir), "custom")
 }
 
+func (dir Dir) FirstBootDir() string {
+	return filepath.Join(string(dir), "firstboot")
+}
+
+func (dir Dir) ConfextsDir() string {
+	return filepath.Join(string(dir), "confexts")
+}
+
 func Write(f vfs.FS, configDir Dir, conf *image.Configuration) error {
 	if err := vfs.MkdirAll(f, string(configDir), vfs.DirPerm); err != nil {
 		return fmt.Errorf("creating config directory: %w", err)
@@ -102,6 +119,12 @@ func Write(f vfs.FS, configDir Dir, conf *image.Configuration) error {
 		return err
 	}
 
+	if conf.OS.Firewall != nil || conf.OS.TimeSync != nil || conf.OS.Network != nil {
+		if err := writeYAML(f, configDir.OSFilepath(), &conf.OS); err != nil {
+			return err
+		}
+	}
+
 	if conf.ButaneConfig != nil {
 		if err := writeYAML(f, configDir.ButaneFilepath(), conf.ButaneConfig); err != nil {
 			return err
@@ -117,7 +140,7 @@ func Write(f vfs.FS, configDir Dir, conf *image.Configuration) error {
 	}
 
 	if conf.Kubernetes.Helm != nil || len(conf.Kubernetes.RemoteManifests) > 0 ||
-		len(conf.Kubernetes.Nodes) > 0 || conf.Kubernetes.Network != (kubernetes.Network{}) {
+		len(conf.Kubernetes.Nodes) > 0 || !conf.Kubernetes.Network.IsZero() || conf.Kubernetes.Registries != nil {
 		if err := writeYAML(f, configDir.ClusterFilepath(), &conf.Kubernetes); err != nil {
 			return err
 		}
@@ -171,6 +194,15 @@ func Parse(f vfs.FS, configDir Dir) (conf *image.Configuration, err error) {
 		return nil, fmt.Errorf("updating manifest URI: %w", err)
 	}
 
+	data, err = f.ReadFile(configDir.OSFilepath())
+	if err == nil {
+		if err = ParseAny(data, &conf.OS); err != nil {
+			return nil, fmt.Errorf("parsing config file %q: %w", configDir.OSFilepath(), err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
 	if err = parseKubernetesDir(f, configDir, &conf.Kubernetes, &conf.Release); err != nil {
 		return nil, fmt.Errorf("parsing kubernetes configuration: %w", err)
 	}
@@ -183,6 +215,14 @@ func Parse(f vfs.FS, configDir Dir) (conf *image.Configuration, err error) {
 		return nil, fmt.Errorf("parsing custom directory: %w", err)
 	}
 
+	if err = parseFirstBootDir(f, configDir, &conf.FirstBoot); err != nil {
+		return nil, fmt.Errorf("parsing firstboot directory: %w", err)
+	}
+
+	if conf.Confexts, err = parseConfextsDir(f, configDir); err != nil {
+		return nil, fmt.Errorf("parsing confexts directory: %w", err)
+	}
+
 	data, err = f.ReadFile(configDir.ButaneFilepath())
 	if err == nil {
 		if err = ParseAny(data, &conf.ButaneConfig); err != nil {
@@ -259,6 +299,17 @@ func parseKubernetesDir(f vfs.FS, configDir Dir, k *kubernetes.Kubernetes, r *re
 		k.LocalManifests = append(k.LocalManifests, localManifestPath)
 	}
 
+	entries, err = f.ReadDir(configDir.KubernetesKustomizeDir())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading %s: %w", configDir.KubernetesKustomizeDir(), err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			k.Kustomizations = append(k.Kustomizations, filepath.Join(configDir.KubernetesKustomizeDir(), entry.Name()))
+		}
+	}
+
 	k.Config = kubernetes.Config{}
 
 	serverYamlPath := configDir.KubernetesServerFilepath()
@@ -358,6 +409,73 @@ func parseCustomDir(f vfs.FS, configDir Dir, c *image.Custom) error {
 	return nil
 }
 
+func parseFirstBootDir(f vfs.FS, configDir Dir, fb *image.FirstBoot) error {
+	const (
+		ignitionFile   = "config.ign"
+		combustionPath = "combustion"
+		combustionFile = "script"
+		cloudInitPath  = "cloud-init"
+		cloudInitFile  = "user-data"
+	)
+
+	firstBootDir := configDir.FirstBootDir()
+	entries, err := f.ReadDir(firstBootDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// Not configured.
+			return nil
+		}
+		return fmt.Errorf("reading firstboot directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(firstBootDir, entry.Name())
+		switch entry.Name() {
+		case ignitionFile:
+			fb.IgnitionFile = path
+		case combustionPath:
+			if exists, _ := vfs.Exists(f, filepath.Join(path, combustionFile)); !exists {
+				return fmt.Errorf("no %q found in %q", combustionFile, path)
+			}
+			fb.CombustionDir = path
+		case cloudInitPath:
+			if exists, _ := vfs.Exists(f, filepath.Join(path, cloudInitFile)); !exists {
+				return fmt.Errorf("no %q found in %q", cloudInitFile, path)
+			}
+			fb.CloudInitDir = path
+		default:
+			return fmt.Errorf("unknown firstboot configuration %q, expected %s, %s or %s",
+				path, ignitionFile, combustionPath, cloudInitPath)
+		}
+	}
+
+	return nil
+}
+
+// parseConfextsDir returns the configuration trees of the confexts directory, one per subdirectory
+func parseConfextsDir(f vfs.FS, configDir Dir) ([]string, error) {
+	entries, err := f.ReadDir(configDir.ConfextsDir())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// Not configured.
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", configDir.ConfextsDir(), err)
+	}
+
+	var trees []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if err = extensions.ValidateConfextName(entry.Name()); err != nil {
+			return nil, err
+		}
+		trees = append(trees, filepath.Join(configDir.ConfextsDir(), entry.Name()))
+	}
+	return trees, nil
+}
+
 func ParseAny(data []byte, target any) error {
 	decoder := yaml.NewDecoder(bytes.NewReader(data))
 	decoder.KnownFields(true)
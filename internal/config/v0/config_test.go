@@ -21,12 +21,14 @@ import (
 	"fmt"
 	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/firewall"
 	"github.com/suse/elemental/v3/internal/image/install"
 	"github.com/suse/elemental/v3/internal/image/kubernetes"
 	"github.com/suse/elemental/v3/internal/image/release"
@@ -79,6 +81,26 @@ network:
   apiVIP: 192.168.120.100.sslip.io
 `
 
+var osYAML = `
+firewall:
+  defaultZone: public
+  zones:
+    - name: public
+      interfaces: [eth0]
+      services: [ssh]
+      ports: [80/tcp]
+    - name: cluster
+      sources: [192.168.120.0/24]
+      kubernetes: true
+timeSync:
+  servers:
+    - address: time.example.com
+      nts: true
+    - address: 192.168.120.1
+      nts: true
+  hardened: true
+`
+
 var releaseYAML = `
 manifestURI: oci://registry.foo.bar/release-manifest:0.0.1
 components:
@@ -102,6 +124,7 @@ var _ = Describe("Configuration", Label("configuration"), func() {
 		fs, cleanup, err = sysmock.TestFS(map[string]any{
 			fmt.Sprintf("%s/install.yaml", configDir):                          installYAML,
 			fmt.Sprintf("%s/butane.yaml", configDir):                           butaneYAML,
+			fmt.Sprintf("%s/os.yaml", configDir):                               osYAML,
 			fmt.Sprintf("%s/kubernetes/cluster.yaml", configDir):               kubernetesClusterYAML,
 			fmt.Sprintf("%s/release.yaml", configDir):                          releaseYAML,
 			fmt.Sprintf("%s/foo.yaml", configDir.HelmValuesDir()):              "",
@@ -153,6 +176,21 @@ var _ = Describe("Configuration", Label("configuration"), func() {
 		Expect(conf.Network.ConfigDir).To(Equal(configDir.NetworkDir()))
 		Expect(conf.Network.CustomScript).To(BeEmpty())
 
+		Expect(conf.OS.Firewall).ToNot(BeNil())
+		Expect(conf.OS.Firewall.GetBackend()).To(Equal(firewall.Firewalld))
+		Expect(conf.OS.Firewall.DefaultZone).To(Equal("public"))
+		Expect(conf.OS.Firewall.Zones).To(HaveLen(2))
+		Expect(conf.OS.Firewall.Zones[0].Interfaces).To(Equal([]string{"eth0"}))
+		Expect(conf.OS.Firewall.Zones[0].Ports).To(Equal([]firewall.Port{"80/tcp"}))
+		Expect(conf.OS.Firewall.Zones[1].Sources).To(Equal([]string{"192.168.120.0/24"}))
+		Expect(conf.OS.Firewall.Zones[1].Kubernetes).To(BeTrue())
+
+		Expect(conf.OS.TimeSync).ToNot(BeNil())
+		Expect(conf.OS.TimeSync.Servers).To(HaveLen(2))
+		Expect(conf.OS.TimeSync.Servers[1].Address).To(Equal("192.168.120.1"))
+		Expect(conf.OS.TimeSync.Servers[1].NTS).To(BeTrue())
+		Expect(conf.OS.TimeSync.Hardened).To(BeTrue())
+
 		Expect(conf.Custom.ScriptsDir).To(Equal(filepath.Join(configDir.CustomDir(), "scripts")))
 		Expect(conf.Custom.FilesDir).To(Equal(filepath.Join(configDir.CustomDir(), "files")))
 
@@ -261,6 +299,28 @@ var _ = Describe("Configuration", Label("configuration"), func() {
 		Expect(err).To(MatchError("parsing custom directory: directory \"/tmp/config-dir/custom/files\" is empty"))
 	})
 
+	It("Parses the firstboot directory", func() {
+		firstBootDir := configDir.FirstBootDir()
+		Expect(vfs.MkdirAll(fs, filepath.Join(firstBootDir, "combustion"), vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(firstBootDir, "combustion", "script"), []byte{}, vfs.FilePerm)).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(firstBootDir, "config.ign"), []byte{}, vfs.FilePerm)).To(Succeed())
+
+		conf, err := Parse(fs, configDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conf.FirstBoot.IgnitionFile).To(Equal(filepath.Join(firstBootDir, "config.ign")))
+		Expect(conf.FirstBoot.CombustionDir).To(Equal(filepath.Join(firstBootDir, "combustion")))
+		Expect(conf.FirstBoot.CloudInitDir).To(BeEmpty())
+
+		Expect(vfs.MkdirAll(fs, filepath.Join(firstBootDir, "cloud-init"), vfs.DirPerm)).To(Succeed())
+		_, err = Parse(fs, configDir)
+		Expect(err).To(MatchError(ContainSubstring("parsing firstboot directory: no \"user-data\" found")))
+
+		Expect(fs.RemoveAll(filepath.Join(firstBootDir, "cloud-init"))).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(firstBootDir, "user-data"), []byte{}, vfs.FilePerm)).To(Succeed())
+		_, err = Parse(fs, configDir)
+		Expect(err).To(MatchError(ContainSubstring("unknown firstboot configuration")))
+	})
+
 	It("Parses {server,agent}.yaml without manifests subdir or registries configuration", func() {
 		Expect(fs.RemoveAll(configDir.KubernetesManifestsDir())).To(Succeed())
 		Expect(fs.RemoveAll(configDir.KubernetesRegistriesFilepath())).To(Succeed())
@@ -273,6 +333,25 @@ var _ = Describe("Configuration", Label("configuration"), func() {
 		Expect(cfg.Kubernetes.Config.RegistriesFilePath).To(BeEmpty())
 	})
 
+	It("Parses the kustomize directory", func() {
+		kustomizeDir := configDir.KubernetesKustomizeDir()
+		Expect(vfs.MkdirAll(fs, filepath.Join(kustomizeDir, "apache"), vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(kustomizeDir, "README"), []byte{}, vfs.FilePerm)).To(Succeed())
+
+		cfg, err := Parse(fs, configDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Kubernetes.Kustomizations).To(Equal([]string{"/tmp/config-dir/kubernetes/kustomize/apache"}))
+	})
+
+	It("Parses the confexts directory", func() {
+		Expect(vfs.MkdirAll(fs, filepath.Join(configDir.ConfextsDir(), "motd", "etc"), vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(configDir.ConfextsDir(), "README"), []byte{}, vfs.FilePerm)).To(Succeed())
+
+		cfg, err := Parse(fs, configDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Confexts).To(Equal([]string{"/tmp/config-dir/confexts/motd"}))
+	})
+
 	It("Fails on invalid configuration", func() {
 		installFile := filepath.Join(string(configDir), "install.yaml")
 		invalidInstallYAML := `
@@ -280,6 +359,7 @@ schema: v0
 bootloader: invalid
 raw:
   diskSize: 35X
+  format: vdi
 `
 		Expect(fs.WriteFile(installFile, []byte(invalidInstallYAML), 0644)).To(Succeed())
 
@@ -287,7 +367,155 @@ raw:
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("validating configuration"))
 		Expect(err.Error()).To(ContainSubstring("field \"Configuration.Installation.Bootloader\" must be one of [grub none], but got \"invalid\""))
-		Expect(err.Error()).To(ContainSubstring("field \"Configuration.Installation.RAW.DiskSize\" must be a valid disk size (e.g., 10G, 500M), but got \"35X\""))
+		Expect(err.Error()).To(ContainSubstring("field \"Configuration.Installation.RAW.DiskSize\" must be a valid disk size (e.g., 10G, 500M or auto), but got \"35X\""))
+		Expect(err.Error()).To(ContainSubstring("field \"Configuration.Installation.RAW.Format\" must be one of [raw qcow2 vmdk vhdx], but got \"vdi\""))
+	})
+
+	It("Parses automatic disk size with a custom slack", func() {
+		installFile := filepath.Join(string(configDir), "install.yaml")
+		autoInstallYAML := `
+schema: v0
+bootloader: grub
+raw:
+  diskSize: auto
+  sizeSlack: 50
+`
+		Expect(fs.WriteFile(installFile, []byte(autoInstallYAML), 0644)).To(Succeed())
+
+		cfg, err := Parse(fs, configDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Installation.RAW.DiskSize.IsAuto()).To(BeTrue())
+		Expect(cfg.Installation.RAW.Slack()).To(BeEquivalentTo(50))
+	})
+
+	It("Fails on invalid firewall configuration", func() {
+		invalidOSYAML := `
+firewall:
+  backend: nftables
+  defaultZone: internal
+  zones:
+    - name: public
+      target: BLOCK
+      services: [ssh]
+      ports: [80/icmp]
+`
+		Expect(fs.WriteFile(configDir.OSFilepath(), []byte(invalidOSYAML), 0644)).To(Succeed())
+
+		_, err := Parse(fs, configDir)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("field \"Configuration.OS.Firewall.Zones[0].Target\" must be one of [default ACCEPT DROP REJECT], but got \"BLOCK\""))
+
+		invalidOSYAML = strings.Replace(invalidOSYAML, "BLOCK", "DROP", 1)
+		Expect(fs.WriteFile(configDir.OSFilepath(), []byte(invalidOSYAML), 0644)).To(Succeed())
+
+		_, err = Parse(fs, configDir)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("validating firewall"))
+		Expect(err.Error()).To(ContainSubstring("zone 'public': services are only supported by the firewalld backend"))
+		Expect(err.Error()).To(ContainSubstring("zone 'public': invalid port '80/icmp'"))
+		Expect(err.Error()).To(ContainSubstring("default zone 'internal' is not defined"))
+	})
+
+	It("Fails on invalid time synchronization configuration", func() {
+		invalidOSYAML := `
+timeSync:
+  servers:
+    - address: time.example.com
+  hardened: true
+`
+		Expect(fs.WriteFile(configDir.OSFilepath(), []byte(invalidOSYAML), 0644)).To(Succeed())
+
+		_, err := Parse(fs, configDir)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("validating time synchronization"))
+		Expect(err.Error()).To(ContainSubstring("server 'time.example.com' must use NTS in the hardened profile"))
+		Expect(err.Error()).To(ContainSubstring("2 agreeing sources are required but only 1 can be configured"))
+
+		invalidOSYAML = `
+timeSync:
+  servers:
+    - address: "not a host"
+  makeStep:
+    threshold: 0
+    limit: 0
+`
+		Expect(fs.WriteFile(configDir.OSFilepath(), []byte(invalidOSYAML), 0644)).To(Succeed())
+
+		_, err = Parse(fs, configDir)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Configuration.OS.TimeSync.Servers[0].Address"))
+		Expect(err.Error()).To(ContainSubstring("Configuration.OS.TimeSync.MakeStep.Threshold"))
+		Expect(err.Error()).To(ContainSubstring("Configuration.OS.TimeSync.MakeStep.Limit"))
+	})
+
+	It("Parses network connections and rejects them along with the network directory", func() {
+		networkOSYAML := `
+network:
+  hosts:
+    - hostname: node1
+      dns: [192.168.120.1]
+      interfaces:
+        - name: eth0
+          macAddress: "52:54:00:00:00:01"
+          addresses: [192.168.120.10/24]
+          gateway4: 192.168.120.1
+    - hostname: node2
+      interfaces:
+        - name: eth0
+          macAddress: "52:54:00:00:00:02"
+`
+		Expect(fs.WriteFile(configDir.OSFilepath(), []byte(networkOSYAML), 0644)).To(Succeed())
+
+		_, err := Parse(fs, configDir)
+		Expect(err).To(MatchError(ContainSubstring("can't be combined with the network directory")))
+
+		Expect(fs.RemoveAll(configDir.NetworkDir())).To(Succeed())
+		conf, err := Parse(fs, configDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conf.OS.Network).ToNot(BeNil())
+		Expect(conf.OS.Network.Hosts).To(HaveLen(2))
+		Expect(conf.OS.Network.Hosts[0].Interfaces[0].Gateway4).To(Equal("192.168.120.1"))
+		Expect(conf.OS.Network.Hosts[1].MACAddresses()).To(Equal([]string{"52:54:00:00:00:02"}))
+
+		invalidOSYAML := `
+network:
+  hosts:
+    - interfaces:
+        - name: eth0
+          addresses: [192.168.120.10]
+    - interfaces:
+        - name: eth0
+`
+		Expect(fs.WriteFile(configDir.OSFilepath(), []byte(invalidOSYAML), 0644)).To(Succeed())
+
+		_, err = Parse(fs, configDir)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Configuration.OS.Network.Hosts[0].Interfaces[0].Addresses[0]"))
+	})
+
+	It("Fails on invalid node labels and taints", func() {
+		clusterYAML := strings.Replace(kubernetesClusterYAML, "    type: server\n", `    type: server
+    labels: ["zone a"]
+    taints: ["dedicated=true"]
+`, 1)
+		Expect(fs.WriteFile(fmt.Sprintf("%s/kubernetes/cluster.yaml", configDir), []byte(clusterYAML), 0644)).To(Succeed())
+
+		_, err := Parse(fs, configDir)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`field "Configuration.Kubernetes.Nodes[0].Labels[0]" must be a valid node label in the 'key=value' format, but got "zone a"`))
+		Expect(err.Error()).To(ContainSubstring(`field "Configuration.Kubernetes.Nodes[0].Taints[0]" must be a valid node taint in the 'key[=value]:effect' format, but got "dedicated=true"`))
+	})
+
+	It("Fails on an invalid registry", func() {
+		clusterYAML := kubernetesClusterYAML + `registries:
+  configs:
+    - registry: ../registry.example.com
+`
+		Expect(fs.WriteFile(fmt.Sprintf("%s/kubernetes/cluster.yaml", configDir), []byte(clusterYAML), 0644)).To(Succeed())
+
+		_, err := Parse(fs, configDir)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`field "Configuration.Kubernetes.Registries.Configs[0].Registry" must be a valid registry in the 'host[:port]' format, but got "../registry.example.com"`))
 	})
 
 	It("Fails on missing required release configuration", func() {
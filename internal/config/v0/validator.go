@@ -27,6 +27,7 @@ import (
 
 	"github.com/suse/elemental/v3/internal/image"
 	"github.com/suse/elemental/v3/internal/image/install"
+	"github.com/suse/elemental/v3/internal/image/kubernetes"
 )
 
 var (
@@ -38,6 +39,15 @@ func getValidator() *validator.Validate {
 	once.Do(func() {
 		validate = validator.New(validator.WithRequiredStructEnabled())
 		_ = validate.RegisterValidation("disksize", validateDiskSize)
+		_ = validate.RegisterValidation("k8slabel", func(fl validator.FieldLevel) bool {
+			return kubernetes.IsValidLabel(fl.Field().String())
+		})
+		_ = validate.RegisterValidation("k8staint", func(fl validator.FieldLevel) bool {
+			return kubernetes.IsValidTaint(fl.Field().String())
+		})
+		_ = validate.RegisterValidation("registry", func(fl validator.FieldLevel) bool {
+			return kubernetes.IsValidRegistry(fl.Field().String())
+		})
 	})
 	return validate
 }
@@ -47,13 +57,43 @@ func validateDiskSize(fl validator.FieldLevel) bool {
 	if !ok {
 		return false
 	}
-	if diskSize == "" {
+	if diskSize == "" || diskSize.IsAuto() {
 		return true
 	}
 	return diskSize.IsValid()
 }
 
 func Validate(conf *image.Configuration) error {
+	if err := validateStruct(conf); err != nil {
+		return err
+	}
+
+	if conf.OS.Firewall != nil {
+		if err := conf.OS.Firewall.Validate(); err != nil {
+			return fmt.Errorf("validating firewall: %w", err)
+		}
+	}
+
+	if conf.OS.TimeSync != nil {
+		if err := conf.OS.TimeSync.Validate(); err != nil {
+			return fmt.Errorf("validating time synchronization: %w", err)
+		}
+	}
+
+	if conf.OS.Network != nil {
+		if conf.Network.CustomScript != "" || conf.Network.ConfigDir != "" {
+			return fmt.Errorf("network connections in os.yaml can't be combined with the network directory")
+		}
+
+		if err := conf.OS.Network.Validate(); err != nil {
+			return fmt.Errorf("validating network: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func validateStruct(conf *image.Configuration) error {
 	err := getValidator().Struct(conf)
 	if err == nil {
 		return nil
@@ -69,9 +109,15 @@ func Validate(conf *image.Configuration) error {
 			case "oneof":
 				messages = append(messages, fmt.Sprintf("field %q must be one of [%s], but got %q", vErr.Namespace(), vErr.Param(), vErr.Value()))
 			case "disksize":
-				messages = append(messages, fmt.Sprintf("field %q must be a valid disk size (e.g., 10G, 500M), but got %q", vErr.Namespace(), vErr.Value()))
+				messages = append(messages, fmt.Sprintf("field %q must be a valid disk size (e.g., 10G, 500M or auto), but got %q", vErr.Namespace(), vErr.Value()))
 			case "url":
 				messages = append(messages, fmt.Sprintf("field %q must be a valid URL, but got %q", vErr.Namespace(), vErr.Value()))
+			case "k8slabel":
+				messages = append(messages, fmt.Sprintf("field %q must be a valid node label in the 'key=value' format, but got %q", vErr.Namespace(), vErr.Value()))
+			case "k8staint":
+				messages = append(messages, fmt.Sprintf("field %q must be a valid node taint in the 'key[=value]:effect' format, but got %q", vErr.Namespace(), vErr.Value()))
+			case "registry":
+				messages = append(messages, fmt.Sprintf("field %q must be a valid registry in the 'host[:port]' format, but got %q", vErr.Namespace(), vErr.Value()))
 			case "hostname":
 				messages = append(messages, fmt.Sprintf("field %q must be a valid hostname, but got %q", vErr.Namespace(), vErr.Value()))
 			default:
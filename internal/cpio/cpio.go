@@ -44,7 +44,7 @@ func CreateCPIO(ctx context.Context, s *sys.System, sourceDir, outputPath string
 	}()
 
 	callback := func(stdin io.Writer) error {
-		return vfs.WalkDirFs(s.FS(), sourceDir, func(path string, _ fs.DirEntry, err error) error {
+		return vfs.WalkDirContext(ctx, s.FS(), sourceDir, func(path string, _ fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
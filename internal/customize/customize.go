@@ -22,9 +22,12 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	_ "embed"
 
+	"github.com/docker/go-units"
+
 	"github.com/suse/elemental/v3/internal/config"
 	"github.com/suse/elemental/v3/internal/image"
 	"github.com/suse/elemental/v3/internal/image/install"
@@ -73,6 +76,17 @@ func (r *Runner) Run(ctx context.Context, def *image.Definition, output config.O
 		return err
 	}
 
+	if image.IsNameTemplate(def.Image.OutputImageName) {
+		if err = def.Image.RenderOutputName(rm, time.Now()); err != nil {
+			logger.Error("Rendering output image name failed")
+			return err
+		}
+		if exists, _ := vfs.Exists(r.System.FS(), def.Image.OutputImageName); exists {
+			logger.Error("Output image path %s already exists, will not overwrite", def.Image.OutputImageName)
+			return fmt.Errorf("output image path %s already exists", def.Image.OutputImageName)
+		}
+	}
+
 	containerImage := rm.CorePlatform.Components.OperatingSystem.Image.ISO
 	logger.Info("Extracting ISO from container image %s", containerImage)
 	iso, err := r.FileExtractor.ExtractFrom(containerImage)
@@ -112,18 +126,30 @@ func (r *Runner) Run(ctx context.Context, def *image.Definition, output config.O
 		installer.WithOutputFile(def.Image.OutputImageName),
 	}
 	if mediaType == installer.Disk {
-		diskSizeStr := def.Configuration.Installation.RAW.DiskSize
-		if diskSizeStr == "" {
-			diskSizeStr = "12G"
-		}
-		if !diskSizeStr.IsValid() {
-			return fmt.Errorf("invalid disk size definition '%s'", diskSizeStr)
+		raw := def.Configuration.Installation.RAW
+		var diskMiB deployment.MiB
+		if raw.DiskSize.IsAuto() {
+			diskMiB, err = estimateDiskSize(ctx, r.System.FS(), iso, output, raw.Slack(), installerDeployment, dep)
+			if err != nil {
+				logger.Error("Computing RAW disk size failed")
+				return err
+			}
+			logger.Info("Computed RAW disk size: %dMiB", diskMiB)
+		} else {
+			diskSizeStr := raw.DiskSize
+			if diskSizeStr == "" {
+				diskSizeStr = "12G"
+			}
+			if !diskSizeStr.IsValid() {
+				return fmt.Errorf("invalid disk size definition '%s'", diskSizeStr)
+			}
+			size, err := diskSizeStr.ToMiB()
+			if err != nil {
+				return fmt.Errorf("could not parse disk size '%s': %w", diskSizeStr, err)
+			}
+			diskMiB = deployment.MiB(size)
 		}
-		diskMiB, err := diskSizeStr.ToMiB()
-		if err != nil {
-			return fmt.Errorf("could not parse disk size '%s': %w", diskSizeStr, err)
-		}
-		mediaOpts = append(mediaOpts, installer.WithRawDiskSize(deployment.MiB(diskMiB)))
+		mediaOpts = append(mediaOpts, installer.WithRawDiskSize(diskMiB))
 	}
 
 	// TODO(ipetrov117): Consider refactoring installer.Media, as right now
@@ -148,6 +174,58 @@ func (r *Runner) Run(ctx context.Context, def *image.Definition, output config.O
 	return nil
 }
 
+// ImageExpansion is the factor applied to compressed image sizes to estimate
+// the disk space they require once unpacked
+const ImageExpansion = 3
+
+// AutoDiskSize returns the size of a disk holding all the given sized partitions plus a
+// system partition able to store the given amount of bytes with the given free space
+// percentage. The result is aligned to 128MiB.
+func AutoDiskSize(contentSize int64, slack uint, parts ...*deployment.Partition) deployment.MiB {
+	const (
+		alignment = 128
+		// room for the GPT headers and partitions alignment
+		gptOverhead = 2
+	)
+
+	content := deployment.MiB((contentSize + units.MiB - 1) / units.MiB)
+	size := content + content*deployment.MiB(slack)/100 + gptOverhead
+
+	for _, part := range parts {
+		if part != nil {
+			size += part.Size
+		}
+	}
+
+	return (size + alignment - 1) / alignment * alignment
+}
+
+// estimateDiskSize computes the RAW disk size required to install the OS included in the
+// given ISO and the overlays tree. Partitions are taken from the installer deployment
+// and from the customization deployment, which only adds new partitions.
+func estimateDiskSize(
+	ctx context.Context, fs vfs.FS, iso string, output config.Output, slack uint, installerDep, dep *deployment.Deployment,
+) (deployment.MiB, error) {
+	fInfo, err := fs.Stat(iso)
+	if err != nil {
+		return 0, fmt.Errorf("computing ISO size: %w", err)
+	}
+	content := fInfo.Size() * ImageExpansion
+
+	if exists, _ := vfs.Exists(fs, output.OverlaysDir()); exists {
+		overlaysSize, err := vfs.DirSizeContext(ctx, fs, output.OverlaysDir())
+		if err != nil {
+			return 0, fmt.Errorf("computing overlays size: %w", err)
+		}
+		content += overlaysSize
+	}
+
+	parts := append([]*deployment.Partition{}, installerDep.Disks[0].Partitions...)
+	parts = append(parts, dep.Disks[0].Partitions...)
+
+	return AutoDiskSize(content, slack, parts...), nil
+}
+
 func loadISOInstallDesc(s *sys.System, iso, outputDir string) (dep *deployment.Deployment, err error) {
 	tempDir, err := vfs.TempDir(s.FS(), outputDir, "iso-desc-install")
 	if err != nil {
@@ -215,6 +293,7 @@ func parseDeployment(
 	d.Security = &deployment.SecurityConfig{
 		CryptoPolicy: install.CryptoPolicy,
 	}
+	d.Compression = install.Compression
 
 	if d.IsFipsEnabled() {
 		d.BootConfig.KernelCmdline = fips.AppendCommandLine(d.BootConfig.KernelCmdline)
@@ -228,7 +307,7 @@ func parseDeployment(
 	// Make sure that we define a valid installer config script if such was not defined
 	// during the build process of the ISO that is currently being customized.
 	if installerDep.Installer.CfgScript == "" {
-		autoInst, err := writeAutoInstaller(fs, output.RootPath, mediaType)
+		autoInst, err := WriteAutoInstaller(fs, output.RootPath, mediaType)
 		if err != nil {
 			return nil, fmt.Errorf("writing default '%s' installer script: %w", autoInstallerScriptName, err)
 		}
@@ -282,7 +361,9 @@ func prepareDeploymentPartitions(src, add []*deployment.Partition) []*deployment
 	return append(preparedPartitionSlice, src[len(src)-1])
 }
 
-func writeAutoInstaller(fs vfs.FS, out string, mediaType installer.MediaType) (string, error) {
+// WriteAutoInstaller writes a live installer setup script which runs the installation
+// or reset of the given media type on boot and returns its path
+func WriteAutoInstaller(fs vfs.FS, out string, mediaType installer.MediaType) (string, error) {
 	values := struct {
 		MediaType string
 	}{
@@ -32,10 +32,12 @@ import (
 	"github.com/suse/elemental/v3/internal/image/install"
 	"github.com/suse/elemental/v3/pkg/crypto"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
 	"github.com/suse/elemental/v3/pkg/manifest/api/core"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
@@ -383,6 +385,95 @@ disks:
 		Expect(err.Error()).To(Equal("invalid disk size definition '35Invalid'"))
 
 	})
+
+	It("renders a templated output image name from the release metadata", func() {
+		customizeRunner.ConfigManager = &configManagerMock{
+			configFunc: func(ctx context.Context, conf *image.Configuration, output config.Output) (*resolver.ResolvedManifest, error) {
+				return &resolver.ResolvedManifest{
+					CorePlatform: &core.ReleaseManifest{
+						Metadata: &api.Metadata{Name: "suse-core", Version: "3.4.0"},
+						Components: core.Components{
+							OperatingSystem: &core.OperatingSystem{
+								Image: core.Image{ISO: expectedISO},
+							},
+						},
+					},
+				}, nil
+			},
+		}
+
+		p, err := platform.Parse("linux/arm64")
+		Expect(err).ToNot(HaveOccurred())
+
+		def := &image.Definition{
+			Image: image.Image{
+				ImageType:       "iso",
+				Platform:        p,
+				OutputImageName: "/_out/{{.Name}}-{{.Version}}-{{.Arch}}.{{.Type}}",
+			},
+			Configuration: &image.Configuration{
+				Installation: install.Installation{
+					ISO: install.ISO{Device: "/dev/sda"},
+				},
+			},
+		}
+
+		Expect(customizeRunner.Run(context.Background(), def, output)).To(Succeed())
+		Expect(def.Image.OutputImageName).To(Equal("/_out/suse-core-3.4.0-arm64.iso"))
+	})
+
+	It("fails to render an invalid output image name template", func() {
+		def := &image.Definition{
+			Image: image.Image{
+				ImageType:       "iso",
+				OutputImageName: "/_out/{{.Unknown}}.iso",
+			},
+			Configuration: &image.Configuration{},
+		}
+
+		err := customizeRunner.Run(context.Background(), def, output)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("rendering output image name"))
+	})
+
+	It("fails to compute the RAW disk size without an ISO", func() {
+		customizeRunner.FileExtractor = &fileExtractorMock{
+			extractFunc: func(uri string) (path string, err error) {
+				return "missing.iso", nil
+			},
+		}
+
+		def := &image.Definition{
+			Image: image.Image{
+				ImageType: "raw",
+			},
+			Configuration: &image.Configuration{
+				Installation: install.Installation{
+					RAW: install.RAW{
+						DiskSize: install.DiskSizeAuto,
+					},
+				},
+			},
+		}
+
+		err := customizeRunner.Run(context.Background(), def, output)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("computing ISO size"))
+	})
+})
+
+var _ = Describe("Automatic disk size", Label("customize"), func() {
+	It("sums partitions, content and slack aligned to 128MiB", func() {
+		parts := []*deployment.Partition{
+			{Size: 1024}, nil, {Size: 256}, {Size: deployment.AllAvailableSize},
+		}
+
+		// 1280MiB of partitions + 1000MiB of content + 20% slack + GPT overhead
+		Expect(customize.AutoDiskSize(1000*1024*1024, 20, parts...)).To(BeEquivalentTo(2560))
+		// exact 128MiB multiples are not rounded up further
+		Expect(customize.AutoDiskSize(126*1024*1024, 0)).To(BeEquivalentTo(128))
+		Expect(customize.AutoDiskSize(127*1024*1024, 0)).To(BeEquivalentTo(256))
+	})
 })
 
 type configManagerMock struct {
@@ -25,7 +25,12 @@ cat > /etc/systemd/system/elemental-autoinstall.service << EOF
 [Unit]
 Description=Elemental Autoinstall
 After=multi-user.target
+{{- if eq .MediaType "iso" }}
+ConditionPathExists=|/run/initramfs/live/Install/install.yaml
+ConditionKernelCommandLine=|elemental.install.target
+{{- else }}
 ConditionPathExists=/run/initramfs/live/Install/install.yaml
+{{- end }}
 ConditionFileIsExecutable=/usr/bin/elemental3ctl
 {{- if eq .MediaType "raw" }}
 ConditionKernelCommandLine=elm.recovery
@@ -38,9 +43,9 @@ StartLimitBurst=3
 [Service]
 Type=oneshot
 {{- if eq .MediaType "iso" }}
-ExecStart=/usr/bin/elemental3ctl --debug install
+ExecStart=/usr/bin/elemental3ctl --debug install --auto --yes
 {{- else }}
-ExecStart=/usr/bin/elemental3ctl --debug reset
+ExecStart=/usr/bin/elemental3ctl --debug reset --yes
 {{- end }}
 Restart=on-failure
 RestartSec=5
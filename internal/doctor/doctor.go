@@ -0,0 +1,358 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/suse/elemental/v3/pkg/requirements"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// DefaultMinFreeSpace is the free space, in MiB, required in the work directory by default
+	DefaultMinFreeSpace = 20480
+
+	dialTimeout = 5 * time.Second
+)
+
+// DefaultOperations are the operations whose host requirements are checked by default
+var DefaultOperations = []string{"install", "build-installer"}
+
+// DefaultModules are the kernel modules checked by default, images are built over loop devices
+// holding squashfs and btrfs filesystems
+var DefaultModules = []string{"loop", "squashfs", "btrfs"}
+
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+)
+
+// Check is the result of a single environment check
+type Check struct {
+	Name   string `yaml:"name"`
+	Status Status `yaml:"status"`
+	Detail string `yaml:"detail,omitempty"`
+}
+
+// Report is the list of environment checks run by the Doctor
+type Report struct {
+	Checks []Check `yaml:"checks"`
+}
+
+// Failed returns true if any check of the report failed
+func (r Report) Failed() bool {
+	return slices.ContainsFunc(r.Checks, func(c Check) bool { return c.Status == Fail })
+}
+
+func (r *Report) add(name string, status Status, detail string, args ...any) {
+	r.Checks = append(r.Checks, Check{Name: name, Status: status, Detail: fmt.Sprintf(detail, args...)})
+}
+
+type Opt func(*Doctor)
+
+// Doctor checks the host environment provides what builds and installs require
+type Doctor struct {
+	s            *sys.System
+	operations   []string
+	modules      []string
+	workDir      string
+	minFreeSpace uint64
+	registries   []string
+	lookPath     func(string) (string, error)
+	dial         func(ctx context.Context, network, address string) (net.Conn, error)
+	euid         func() int
+	freeSpace    func(path string) (uint64, error)
+}
+
+// WithOperations sets the operations whose required commands are checked
+func WithOperations(operations ...string) Opt {
+	return func(d *Doctor) {
+		d.operations = operations
+	}
+}
+
+// WithModules sets the kernel modules checked
+func WithModules(modules ...string) Opt {
+	return func(d *Doctor) {
+		d.modules = modules
+	}
+}
+
+// WithWorkDir sets the directory whose free space is checked, defaults to the current directory
+func WithWorkDir(workDir string) Opt {
+	return func(d *Doctor) {
+		d.workDir = workDir
+	}
+}
+
+// WithMinFreeSpace sets the minimum free space in MiB required in the work directory
+func WithMinFreeSpace(size uint64) Opt {
+	return func(d *Doctor) {
+		d.minFreeSpace = size
+	}
+}
+
+// WithRegistries sets the registry hosts checked for network reachability. Hosts without
+// a port are reached on the HTTPS port.
+func WithRegistries(registries ...string) Opt {
+	return func(d *Doctor) {
+		d.registries = registries
+	}
+}
+
+// WithLookPath sets the function used to find commands on the host, defaults to exec.LookPath
+func WithLookPath(lookPath func(string) (string, error)) Opt {
+	return func(d *Doctor) {
+		d.lookPath = lookPath
+	}
+}
+
+// WithDialer sets the function used to reach the registries
+func WithDialer(dial func(ctx context.Context, network, address string) (net.Conn, error)) Opt {
+	return func(d *Doctor) {
+		d.dial = dial
+	}
+}
+
+// WithEUID sets the function returning the effective user ID of the process, defaults to os.Geteuid
+func WithEUID(euid func() int) Opt {
+	return func(d *Doctor) {
+		d.euid = euid
+	}
+}
+
+// WithFreeSpace sets the function returning the free space in MiB of the filesystem of a path
+func WithFreeSpace(freeSpace func(path string) (uint64, error)) Opt {
+	return func(d *Doctor) {
+		d.freeSpace = freeSpace
+	}
+}
+
+func New(s *sys.System, opts ...Opt) *Doctor {
+	d := &Doctor{
+		s:            s.WithComponent("doctor"),
+		operations:   DefaultOperations,
+		modules:      DefaultModules,
+		workDir:      ".",
+		minFreeSpace: DefaultMinFreeSpace,
+		lookPath:     exec.LookPath,
+		dial:         (&net.Dialer{Timeout: dialTimeout}).DialContext,
+		euid:         os.Geteuid,
+	}
+	d.freeSpace = d.statFreeSpace
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+// Run runs all the environment checks, a failed check does not prevent the others from running
+func (d *Doctor) Run(ctx context.Context) (*Report, error) {
+	report := &Report{Checks: []Check{}}
+
+	err := d.checkTools(report)
+	if err != nil {
+		return nil, err
+	}
+	d.checkModules(report)
+	d.checkPrivileges(report)
+	d.checkNamespaces(report)
+	d.checkCgroups(report)
+	d.checkFreeSpace(report)
+	d.checkRegistries(ctx, report)
+
+	return report, nil
+}
+
+// checkTools checks the commands required by each operation. Missing commands of the base feature
+// fail the check, missing commands of optional features only warn.
+func (d *Doctor) checkTools(report *Report) error {
+	prober, err := requirements.NewProber(requirements.WithLookPath(d.lookPath))
+	if err != nil {
+		return err
+	}
+
+	for _, op := range d.operations {
+		name := fmt.Sprintf("tools for %s", op)
+		features := slices.DeleteFunc(prober.Features(op), func(f string) bool { return f == requirements.Base })
+		missing, err := prober.Missing(op, features...)
+		if err != nil {
+			return err
+		}
+		if len(missing) == 0 {
+			report.add(name, Pass, "all required commands found")
+			continue
+		}
+
+		status := Warn
+		details := make([]string, 0, len(missing))
+		for _, m := range missing {
+			if m.Feature == requirements.Base {
+				status = Fail
+			}
+			details = append(details, fmt.Sprintf("%s (%s)", m.Command, m.Feature))
+		}
+		report.add(name, status, "missing commands: %s", strings.Join(details, ", "))
+	}
+	return nil
+}
+
+// checkModules checks each kernel module is loaded, built into the kernel or available to be loaded on demand
+func (d *Doctor) checkModules(report *Report) {
+	fs := d.s.FS()
+	filesystems, _ := fs.ReadFile("/proc/filesystems")
+	release, _ := fs.ReadFile("/proc/sys/kernel/osrelease")
+	modDir := filepath.Join("/lib/modules", strings.TrimSpace(string(release)))
+	builtin, _ := fs.ReadFile(filepath.Join(modDir, "modules.builtin"))
+	deps, _ := fs.ReadFile(filepath.Join(modDir, "modules.dep"))
+
+	for _, mod := range d.modules {
+		name := fmt.Sprintf("kernel module %s", mod)
+		ko := fmt.Sprintf("/%s.ko", mod)
+		switch {
+		case slices.Contains(strings.Fields(string(filesystems)), mod):
+			report.add(name, Pass, "filesystem registered")
+		case exists(fs, filepath.Join("/sys/module", mod)):
+			report.add(name, Pass, "loaded")
+		case strings.Contains(string(builtin), ko):
+			report.add(name, Pass, "built into the kernel")
+		case strings.Contains(string(deps), ko):
+			report.add(name, Pass, "available, loaded on demand")
+		default:
+			report.add(name, Fail, "not available for kernel '%s'", strings.TrimSpace(string(release)))
+		}
+	}
+}
+
+func (d *Doctor) checkPrivileges(report *Report) {
+	if euid := d.euid(); euid != 0 {
+		report.add("privileges", Fail, "running as user %d, root is required to partition, mount and chroot", euid)
+		return
+	}
+	report.add("privileges", Pass, "running as root")
+}
+
+// checkNamespaces checks new mount namespaces can be created, they isolate the mounts of chroot environments
+func (d *Doctor) checkNamespaces(report *Report) {
+	fs := d.s.FS()
+	if !exists(fs, "/proc/self/ns/mnt") {
+		report.add("mount namespaces", Fail, "mount namespaces are not supported by the kernel")
+		return
+	}
+	data, err := fs.ReadFile("/proc/sys/user/max_mnt_namespaces")
+	if err == nil && strings.TrimSpace(string(data)) == "0" {
+		report.add("mount namespaces", Fail, "mount namespaces are disabled, user.max_mnt_namespaces is 0")
+		return
+	}
+	report.add("mount namespaces", Pass, "supported")
+}
+
+// checkCgroups checks the cgroup hierarchy required to run containers, such as the one preloading images
+func (d *Doctor) checkCgroups(report *Report) {
+	fs := d.s.FS()
+	switch {
+	case exists(fs, "/sys/fs/cgroup/cgroup.controllers"):
+		raw, err := fs.RawPath("/sys/fs/cgroup")
+		if err == nil && unix.Access(raw, unix.W_OK) != nil {
+			report.add("cgroups", Warn, "cgroup v2 hierarchy is read-only, containers can't be run")
+			return
+		}
+		report.add("cgroups", Pass, "cgroup v2")
+	case exists(fs, "/sys/fs/cgroup"):
+		report.add("cgroups", Warn, "cgroup v1 hierarchy found, cgroup v2 is recommended")
+	default:
+		report.add("cgroups", Fail, "no cgroup hierarchy mounted at /sys/fs/cgroup")
+	}
+}
+
+// checkFreeSpace checks the free space of the work directory, or of its closest existing parent
+func (d *Doctor) checkFreeSpace(report *Report) {
+	name := "free space"
+	dir, err := filepath.Abs(d.workDir)
+	if err != nil {
+		report.add(name, Fail, "resolving '%s': %v", d.workDir, err)
+		return
+	}
+	for !exists(d.s.FS(), dir) && dir != "/" {
+		dir = filepath.Dir(dir)
+	}
+
+	free, err := d.freeSpace(dir)
+	if err != nil {
+		report.add(name, Fail, "checking free space of '%s': %v", dir, err)
+		return
+	}
+	if free < d.minFreeSpace {
+		report.add(name, Fail, "%dMiB available in '%s', at least %dMiB required", free, dir, d.minFreeSpace)
+		return
+	}
+	report.add(name, Pass, "%dMiB available in '%s'", free, dir)
+}
+
+func (d *Doctor) checkRegistries(ctx context.Context, report *Report) {
+	for _, reg := range d.registries {
+		name := fmt.Sprintf("registry %s", reg)
+		address := reg
+		if _, _, err := net.SplitHostPort(reg); err != nil {
+			address = net.JoinHostPort(reg, "443")
+		}
+
+		dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+		conn, err := d.dial(dialCtx, "tcp", address)
+		cancel()
+		if err != nil {
+			report.add(name, Fail, "not reachable: %v", err)
+			continue
+		}
+		_ = conn.Close()
+		report.add(name, Pass, "reachable at %s", address)
+	}
+}
+
+func (d *Doctor) statFreeSpace(path string) (uint64, error) {
+	raw, err := d.s.FS().RawPath(path)
+	if err != nil {
+		return 0, err
+	}
+	var st unix.Statfs_t
+	err = unix.Statfs(raw, &st)
+	if err != nil {
+		return 0, err
+	}
+	return st.Bavail * uint64(st.Bsize) / (1024 * 1024), nil
+}
+
+func exists(fs vfs.FS, path string) bool {
+	ok, _ := vfs.Exists(fs, path)
+	return ok
+}
@@ -0,0 +1,150 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doctor_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/doctor"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+)
+
+func TestDoctorSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Doctor test suite")
+}
+
+func checkByName(report *doctor.Report, name string) doctor.Check {
+	for _, c := range report.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	return doctor.Check{}
+}
+
+var _ = Describe("Doctor", Label("doctor"), func() {
+	var s *sys.System
+	var cleanup func()
+	var opts []doctor.Opt
+
+	BeforeEach(func() {
+		fs, c, err := sysmock.TestFS(map[string]any{
+			"/proc/filesystems":                          "nodev\tproc\n\tbtrfs\n",
+			"/proc/sys/kernel/osrelease":                 "6.4.0-default\n",
+			"/proc/self/ns/mnt":                          "",
+			"/sys/module/loop/parameters/max_loop":       "0\n",
+			"/lib/modules/6.4.0-default/modules.dep":     "kernel/fs/squashfs/squashfs.ko.zst:\n",
+			"/lib/modules/6.4.0-default/modules.builtin": "",
+			"/sys/fs/cgroup/cgroup.controllers":          "cpu memory\n",
+			"/work/.keep":                                "",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		cleanup = c
+		s, err = sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).ToNot(HaveOccurred())
+
+		opts = []doctor.Opt{
+			doctor.WithOperations("export"),
+			doctor.WithWorkDir("/work/build"),
+			doctor.WithLookPath(func(cmd string) (string, error) { return "/usr/bin/" + cmd, nil }),
+			doctor.WithEUID(func() int { return 0 }),
+			doctor.WithFreeSpace(func(string) (uint64, error) { return 30000, nil }),
+			doctor.WithDialer(func(context.Context, string, string) (net.Conn, error) {
+				client, server := net.Pipe()
+				_ = server.Close()
+				return client, nil
+			}),
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("passes on a suitable environment", func() {
+		report, err := doctor.New(s, append(opts, doctor.WithRegistries("registry.example.com"))...).Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Failed()).To(BeFalse())
+		for _, c := range report.Checks {
+			Expect(c.Status).To(Equal(doctor.Pass), c.Name)
+		}
+		Expect(checkByName(report, "kernel module loop").Detail).To(Equal("loaded"))
+		Expect(checkByName(report, "kernel module squashfs").Detail).To(Equal("available, loaded on demand"))
+		Expect(checkByName(report, "kernel module btrfs").Detail).To(Equal("filesystem registered"))
+		Expect(checkByName(report, "free space").Detail).To(ContainSubstring("'/work'"))
+		Expect(checkByName(report, "registry registry.example.com").Detail).To(Equal("reachable at registry.example.com:443"))
+	})
+
+	It("fails on missing base commands and warns on missing optional ones", func() {
+		report, err := doctor.New(s, append(
+			opts, doctor.WithOperations("build-installer"),
+			doctor.WithLookPath(func(cmd string) (string, error) {
+				if cmd == "mkfs.erofs" {
+					return "", errors.New("not found")
+				}
+				return "/usr/bin/" + cmd, nil
+			}),
+		)...).Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		check := checkByName(report, "tools for build-installer")
+		Expect(check.Status).To(Equal(doctor.Warn))
+		Expect(check.Detail).To(Equal("missing commands: mkfs.erofs (erofs)"))
+		Expect(report.Failed()).To(BeFalse())
+
+		report, err = doctor.New(s, append(
+			opts, doctor.WithLookPath(func(string) (string, error) { return "", errors.New("not found") }),
+		)...).Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		check = checkByName(report, "tools for export")
+		Expect(check.Status).To(Equal(doctor.Fail))
+		Expect(check.Detail).To(Equal("missing commands: tar (base), snapper (snapper), btrfs (snapper)"))
+		Expect(report.Failed()).To(BeTrue())
+	})
+
+	It("fails for unprivileged users, low free space and unreachable registries", func() {
+		report, err := doctor.New(s, append(
+			opts, doctor.WithModules("overlay"),
+			doctor.WithEUID(func() int { return 1000 }),
+			doctor.WithFreeSpace(func(string) (uint64, error) { return 1024, nil }),
+			doctor.WithRegistries("registry.example.com:5000"),
+			doctor.WithDialer(func(context.Context, string, string) (net.Conn, error) {
+				return nil, errors.New("connection refused")
+			}),
+		)...).Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkByName(report, "kernel module overlay").Status).To(Equal(doctor.Fail))
+		Expect(checkByName(report, "privileges").Status).To(Equal(doctor.Fail))
+		Expect(checkByName(report, "free space").Status).To(Equal(doctor.Fail))
+		Expect(checkByName(report, "registry registry.example.com:5000").Detail).To(ContainSubstring("connection refused"))
+		Expect(report.Failed()).To(BeTrue())
+	})
+
+	It("fails for unknown operations", func() {
+		_, err := doctor.New(s, append(opts, doctor.WithOperations("unknown"))...).Run(context.Background())
+		Expect(err).To(HaveOccurred())
+	})
+})
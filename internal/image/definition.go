@@ -19,15 +19,20 @@ limitations under the License.
 package image
 
 import (
+	"github.com/suse/elemental/v3/internal/image/firewall"
 	"github.com/suse/elemental/v3/internal/image/install"
 	"github.com/suse/elemental/v3/internal/image/kubernetes"
+	"github.com/suse/elemental/v3/internal/image/network"
 	"github.com/suse/elemental/v3/internal/image/release"
+	"github.com/suse/elemental/v3/internal/image/timesync"
 
 	"github.com/suse/elemental/v3/pkg/sys/platform"
 )
 
 const (
-	TypeRAW = "raw"
+	TypeRAW      = "raw"
+	TypeISO      = "iso"
+	TypeSquashfs = "squashfs"
 )
 
 type Definition struct {
@@ -39,8 +44,11 @@ type Configuration struct {
 	Installation install.Installation  `validate:"required"`
 	Release      release.Release       `validate:"required"`
 	Kubernetes   kubernetes.Kubernetes `validate:"omitempty"`
+	OS           OperatingSystem       `validate:"omitempty"`
 	Network      Network               `validate:"omitempty"`
 	Custom       Custom                `validate:"omitempty"`
+	FirstBoot    FirstBoot             `validate:"omitempty"`
+	Confexts     []string              `validate:"omitempty"`
 	ButaneConfig map[string]any        `validate:"omitempty"`
 }
 
@@ -50,6 +58,13 @@ type Image struct {
 	OutputImageName string
 }
 
+// OperatingSystem - operating system settings specified under config/os.yaml
+type OperatingSystem struct {
+	Firewall *firewall.Firewall `yaml:"firewall,omitempty" validate:"omitempty"`
+	TimeSync *timesync.TimeSync `yaml:"timeSync,omitempty" validate:"omitempty"`
+	Network  *network.Network   `yaml:"network,omitempty" validate:"omitempty"`
+}
+
 type Network struct {
 	CustomScript string
 	ConfigDir    string
@@ -59,3 +74,10 @@ type Custom struct {
 	ScriptsDir string
 	FilesDir   string
 }
+
+// FirstBoot - provisioning configurations applied on first boot, specified under config/firstboot
+type FirstBoot struct {
+	IgnitionFile  string
+	CombustionDir string
+	CloudInitDir  string
+}
@@ -0,0 +1,204 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firewall
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Backend string
+
+const (
+	Firewalld Backend = "firewalld"
+	NFTables  Backend = "nftables"
+)
+
+type Target string
+
+const (
+	TargetDefault Target = "default"
+	TargetAccept  Target = "ACCEPT"
+	TargetDrop    Target = "DROP"
+	TargetReject  Target = "REJECT"
+)
+
+var (
+	zoneNameRegexp  = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,17}$`)
+	interfaceRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.:@-]{1,15}$`)
+	serviceRegexp   = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+)
+
+// Firewall - firewall configuration specified under config/os.yaml
+type Firewall struct {
+	// Backend renders the zones either as firewalld zones or as a plain nftables ruleset, defaults to firewalld
+	Backend Backend `yaml:"backend,omitempty" validate:"omitempty,oneof=firewalld nftables"`
+	// DefaultZone is the zone applied to traffic not matching the interfaces or sources of any other zone
+	DefaultZone string `yaml:"defaultZone,omitempty"`
+	Zones       []Zone `yaml:"zones,omitempty" validate:"dive"`
+}
+
+type Zone struct {
+	Name string `yaml:"name" validate:"required"`
+	// Target is the verdict for traffic not explicitly allowed by the zone, defaults to REJECT
+	Target     Target   `yaml:"target,omitempty" validate:"omitempty,oneof=default ACCEPT DROP REJECT"`
+	Interfaces []string `yaml:"interfaces,omitempty"`
+	Sources    []string `yaml:"sources,omitempty"`
+	Services   []string `yaml:"services,omitempty"`
+	Ports      []Port   `yaml:"ports,omitempty"`
+	// Kubernetes opens the ports required by the Kubernetes nodes of the cluster
+	Kubernetes bool `yaml:"kubernetes,omitempty"`
+}
+
+// Port is a single port or a port range with its protocol, e.g. '6443/tcp' or '30000-32767/udp'
+type Port string
+
+// Parse returns the first and last port of the range and the protocol
+func (p Port) Parse() (from, to uint16, protocol string, err error) {
+	ports, protocol, ok := strings.Cut(string(p), "/")
+	if !ok || (protocol != "tcp" && protocol != "udp" && protocol != "sctp") {
+		return 0, 0, "", fmt.Errorf("invalid port '%s': expected <port>[-<port>]/<tcp|udp|sctp>", p)
+	}
+
+	first, last, isRange := strings.Cut(ports, "-")
+	if !isRange {
+		last = first
+	}
+
+	f, err := strconv.ParseUint(first, 10, 16)
+	if err != nil || f == 0 {
+		return 0, 0, "", fmt.Errorf("invalid port '%s': '%s' is not a valid port number", p, first)
+	}
+
+	l, err := strconv.ParseUint(last, 10, 16)
+	if err != nil || l < f {
+		return 0, 0, "", fmt.Errorf("invalid port '%s': '%s' is not a valid end of range", p, last)
+	}
+
+	return uint16(f), uint16(l), protocol, nil
+}
+
+// Number returns the port or port range without the protocol, e.g. '30000-32767'
+func (p Port) Number() string {
+	ports, _, _ := strings.Cut(string(p), "/")
+	return ports
+}
+
+// Protocol returns the protocol of the port
+func (p Port) Protocol() string {
+	_, protocol, _ := strings.Cut(string(p), "/")
+	return protocol
+}
+
+// KubernetesPorts returns the inbound ports of a RKE2 node using the default canal CNI.
+// If the cluster exposes its API over a virtual IP the MetalLB memberlist ports are included too.
+func KubernetesPorts(vip bool) []Port {
+	ports := []Port{
+		"6443/tcp", "9345/tcp", "10250/tcp", "2379-2381/tcp",
+		"30000-32767/tcp", "30000-32767/udp", "8472/udp", "9099/tcp",
+	}
+
+	if vip {
+		ports = append(ports, "7946/tcp", "7946/udp")
+	}
+
+	return ports
+}
+
+// GetBackend returns the configured backend or firewalld if none is set
+func (f *Firewall) GetBackend() Backend {
+	if f.Backend == "" {
+		return Firewalld
+	}
+
+	return f.Backend
+}
+
+// GetTarget returns the configured target or REJECT if none is set
+func (z *Zone) GetTarget() Target {
+	if z.Target == "" {
+		return TargetReject
+	}
+
+	return z.Target
+}
+
+// Validate checks the consistency of the zones beyond what the field validations can express
+func (f *Firewall) Validate() error {
+	var errs []error
+
+	var names []string
+	interfaces := map[string]string{}
+
+	for _, z := range f.Zones {
+		if !zoneNameRegexp.MatchString(z.Name) {
+			errs = append(errs, fmt.Errorf("invalid zone name '%s'", z.Name))
+		}
+
+		if slices.Contains(names, z.Name) {
+			errs = append(errs, fmt.Errorf("zone '%s' is defined more than once", z.Name))
+		}
+		names = append(names, z.Name)
+
+		for _, i := range z.Interfaces {
+			if !interfaceRegexp.MatchString(i) {
+				errs = append(errs, fmt.Errorf("zone '%s': invalid interface name '%s'", z.Name, i))
+			}
+
+			if zone, ok := interfaces[i]; ok {
+				errs = append(errs, fmt.Errorf("interface '%s' is assigned to zones '%s' and '%s'", i, zone, z.Name))
+			}
+			interfaces[i] = z.Name
+		}
+
+		for _, s := range z.Sources {
+			if _, err := netip.ParsePrefix(s); err != nil {
+				if _, err = netip.ParseAddr(s); err != nil {
+					errs = append(errs, fmt.Errorf("zone '%s': source '%s' is neither an IP address nor a CIDR", z.Name, s))
+				}
+			}
+		}
+
+		for _, s := range z.Services {
+			if !serviceRegexp.MatchString(s) {
+				errs = append(errs, fmt.Errorf("zone '%s': invalid service name '%s'", z.Name, s))
+			}
+		}
+
+		if len(z.Services) > 0 && f.GetBackend() == NFTables {
+			errs = append(errs, fmt.Errorf("zone '%s': services are only supported by the firewalld backend, use ports instead", z.Name))
+		}
+
+		for _, p := range z.Ports {
+			if _, _, _, err := p.Parse(); err != nil {
+				errs = append(errs, fmt.Errorf("zone '%s': %w", z.Name, err))
+			}
+		}
+	}
+
+	if f.DefaultZone != "" && !slices.Contains(names, f.DefaultZone) {
+		errs = append(errs, fmt.Errorf("default zone '%s' is not defined", f.DefaultZone))
+	}
+
+	return errors.Join(errs...)
+}
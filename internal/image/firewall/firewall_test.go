@@ -0,0 +1,78 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firewall_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/image/firewall"
+)
+
+func TestFirewallSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Firewall test suite")
+}
+
+var _ = Describe("Firewall", func() {
+	It("Parses ports and port ranges", func() {
+		from, to, protocol, err := firewall.Port("6443/tcp").Parse()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(from).To(Equal(uint16(6443)))
+		Expect(to).To(Equal(uint16(6443)))
+		Expect(protocol).To(Equal("tcp"))
+
+		from, to, protocol, err = firewall.Port("30000-32767/udp").Parse()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(from).To(Equal(uint16(30000)))
+		Expect(to).To(Equal(uint16(32767)))
+		Expect(protocol).To(Equal("udp"))
+
+		for _, p := range []firewall.Port{"6443", "0/tcp", "70000/tcp", "200-100/tcp", "ssh/tcp", "80/icmp"} {
+			_, _, _, err = p.Parse()
+			Expect(err).To(HaveOccurred(), string(p))
+		}
+	})
+
+	It("Validates zones", func() {
+		fw := &firewall.Firewall{
+			DefaultZone: "public",
+			Zones: []firewall.Zone{
+				{Name: "public", Interfaces: []string{"eth0"}, Sources: []string{"10.0.0.1", "fd00::/64"}},
+				{Name: "cluster", Interfaces: []string{"eth1"}, Kubernetes: true},
+			},
+		}
+		Expect(fw.Validate()).To(Succeed())
+
+		fw.Zones = append(fw.Zones, firewall.Zone{
+			Name:       "cluster",
+			Interfaces: []string{"eth0", "eth 2"},
+			Sources:    []string{"10.0.0.0/33"},
+			Services:   []string{"ssh;"},
+		})
+		err := fw.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("zone 'cluster' is defined more than once"))
+		Expect(err.Error()).To(ContainSubstring("interface 'eth0' is assigned to zones 'public' and 'cluster'"))
+		Expect(err.Error()).To(ContainSubstring("zone 'cluster': invalid interface name 'eth 2'"))
+		Expect(err.Error()).To(ContainSubstring("zone 'cluster': source '10.0.0.0/33' is neither an IP address nor a CIDR"))
+		Expect(err.Error()).To(ContainSubstring("zone 'cluster': invalid service name 'ssh;'"))
+	})
+})
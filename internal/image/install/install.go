@@ -27,10 +27,24 @@ import (
 	"github.com/docker/go-units"
 
 	"github.com/suse/elemental/v3/pkg/crypto"
+	"github.com/suse/elemental/v3/pkg/deployment"
 )
 
 type DiskSize string
 
+// DiskSizeAuto requests the disk size to be computed from the size of the
+// installed contents instead of using a static value
+const DiskSizeAuto DiskSize = "auto"
+
+// DefaultSizeSlack is the default free space percentage added on top of
+// automatically computed disk sizes
+const DefaultSizeSlack = 20
+
+// IsAuto returns true if the disk size is meant to be computed automatically
+func (d DiskSize) IsAuto() bool {
+	return d == DiskSizeAuto
+}
+
 func (d DiskSize) IsValid() bool {
 	return regexp.MustCompile(`^[1-9]\d*[KMGT]$`).MatchString(string(d))
 }
@@ -58,6 +72,21 @@ func (d DiskSize) ToMiB() (uint64, error) {
 	return value * dimension / units.MiB, nil
 }
 
+// DiskFormat is the format of the disk image artifact, any value is a qemu-img output format
+type DiskFormat string
+
+const (
+	FormatRAW   DiskFormat = "raw"
+	FormatQCOW2 DiskFormat = "qcow2"
+	FormatVMDK  DiskFormat = "vmdk"
+	FormatVHDX  DiskFormat = "vhdx"
+)
+
+// IsRAW returns true for the RAW format or if no format is set
+func (f DiskFormat) IsRAW() bool {
+	return f == "" || f == FormatRAW
+}
+
 type Installation struct {
 	SchemaVersion string        `yaml:"schema"`
 	Bootloader    string        `yaml:"bootloader" validate:"omitempty,oneof=grub none"`
@@ -65,10 +94,28 @@ type Installation struct {
 	RAW           RAW           `yaml:"raw"`
 	ISO           ISO           `yaml:"iso"`
 	CryptoPolicy  crypto.Policy `yaml:"cryptoPolicy" validate:"omitempty,oneof=fips default"`
+	// Compression is validated as part of the deployment it is set in
+	Compression *deployment.CompressionConfig `yaml:"compression,omitempty" validate:"-"`
 }
 
 type RAW struct {
-	DiskSize DiskSize `yaml:"diskSize" validate:"omitempty,disksize"`
+	DiskSize DiskSize   `yaml:"diskSize" validate:"omitempty,disksize"`
+	Format   DiskFormat `yaml:"format" validate:"omitempty,oneof=raw qcow2 vmdk vhdx"`
+	// SizeSlack is the free space percentage added to an automatically computed disk size
+	SizeSlack *uint `yaml:"sizeSlack,omitempty" validate:"omitempty,max=500"`
+	// ExpandPartitions grows the last partition to fill the disk the image is written to on first boot
+	ExpandPartitions bool `yaml:"expandPartitions,omitempty"`
+	// RepartDefinitions ships the disk layout as systemd-repart definitions in the image, delegating the
+	// partitions creation and expansion on boot to systemd-repart
+	RepartDefinitions bool `yaml:"repartDefinitions,omitempty"`
+}
+
+// Slack returns the configured size slack percentage or the default one if unset
+func (r RAW) Slack() uint {
+	if r.SizeSlack == nil {
+		return DefaultSizeSlack
+	}
+	return *r.SizeSlack
 }
 
 type ISO struct {
@@ -0,0 +1,258 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/kubernetes"
+	"github.com/suse/elemental/v3/internal/image/network"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// Inventory - fleet of hosts provisioned from a single image definition
+type Inventory struct {
+	Hosts []Host `yaml:"hosts"`
+}
+
+type Host struct {
+	Hostname string `yaml:"hostname"`
+	// MACAddresses identify the host on first boot, they are assigned in order to the
+	// ethernet interfaces of the network configuration
+	MACAddresses []string `yaml:"macAddresses,omitempty"`
+	// Role is the Kubernetes node type of the host, either server or agent
+	Role string `yaml:"role,omitempty"`
+}
+
+// Parse reads an inventory file, files with the '.csv' extension are parsed as CSV
+// with the 'hostname,macAddresses,role' columns, any other file is parsed as YAML.
+func Parse(f vfs.FS, path string) (*Inventory, error) {
+	data, err := f.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading inventory file: %w", err)
+	}
+
+	inv := &Inventory{}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		inv.Hosts, err = parseCSV(data)
+	} else {
+		err = yaml.Unmarshal(data, inv)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing inventory file '%s': %w", path, err)
+	}
+
+	for i := range inv.Hosts {
+		for j, mac := range inv.Hosts[i].MACAddresses {
+			inv.Hosts[i].MACAddresses[j] = strings.ToLower(mac)
+		}
+	}
+
+	if err = inv.Validate(); err != nil {
+		return nil, fmt.Errorf("validating inventory file '%s': %w", path, err)
+	}
+
+	return inv, nil
+}
+
+// parseCSV reads hosts from CSV records, multiple MAC addresses of a host are separated by
+// spaces or semicolons. A header row and lines starting with '#' are ignored.
+func parseCSV(data []byte) ([]Host, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var hosts []Host
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if len(record) > 3 {
+			line, _ := r.FieldPos(0)
+			return nil, fmt.Errorf("line %d: expected at most 3 columns, got %d", line, len(record))
+		}
+
+		record = append(record, "", "")
+		if len(hosts) == 0 && strings.EqualFold(record[0], "hostname") {
+			continue
+		}
+
+		hosts = append(hosts, Host{
+			Hostname: strings.TrimSpace(record[0]),
+			MACAddresses: strings.FieldsFunc(record[1], func(r rune) bool {
+				return r == ' ' || r == ';'
+			}),
+			Role: strings.TrimSpace(record[2]),
+		})
+	}
+
+	return hosts, nil
+}
+
+// Validate checks that hosts and MAC addresses are unique and roles are valid node types
+func (inv *Inventory) Validate() error {
+	var errs []error
+
+	if len(inv.Hosts) == 0 {
+		return fmt.Errorf("no hosts defined")
+	}
+
+	validate := validator.New()
+	hostnames := map[string]bool{}
+	macs := map[string]string{}
+
+	for _, h := range inv.Hosts {
+		if validate.Var(h.Hostname, "required,hostname_rfc1123") != nil {
+			errs = append(errs, fmt.Errorf("invalid hostname '%s'", h.Hostname))
+		}
+
+		if hostnames[h.Hostname] {
+			errs = append(errs, fmt.Errorf("host '%s' is defined more than once", h.Hostname))
+		}
+		hostnames[h.Hostname] = true
+
+		if len(h.MACAddresses) == 0 {
+			errs = append(errs, fmt.Errorf("host '%s': at least one MAC address is required", h.Hostname))
+		}
+
+		for _, mac := range h.MACAddresses {
+			if _, err := net.ParseMAC(mac); err != nil {
+				errs = append(errs, fmt.Errorf("host '%s': invalid MAC address '%s'", h.Hostname, mac))
+			}
+
+			if other, ok := macs[mac]; ok && other != h.Hostname {
+				errs = append(errs, fmt.Errorf("MAC address '%s' is assigned to hosts '%s' and '%s'", mac, other, h.Hostname))
+			}
+			macs[mac] = h.Hostname
+		}
+
+		if h.Role != "" && h.Role != kubernetes.NodeTypeServer && h.Role != kubernetes.NodeTypeAgent {
+			errs = append(errs, fmt.Errorf("host '%s': invalid role '%s', expected '%s' or '%s'",
+				h.Hostname, h.Role, kubernetes.NodeTypeServer, kubernetes.NodeTypeAgent))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Configure returns a copy of the configuration provisioning the given hosts of the inventory.
+// Every host of the inventory with a role is added as a Kubernetes node, so all images share
+// the same cluster definition, while network connections are only rendered for the given hosts.
+// The network section of the configuration, if any, must define a single host without MAC
+// addresses nor static addresses which is used as template for the connections of each host.
+func (inv *Inventory) Configure(conf *image.Configuration, hosts []Host) (*image.Configuration, error) {
+	c := *conf
+
+	nodes := slices.Clone(conf.Kubernetes.Nodes)
+	for _, h := range inv.Hosts {
+		if h.Role == "" {
+			continue
+		}
+
+		if slices.ContainsFunc(nodes, func(n kubernetes.Node) bool { return n.Hostname == h.Hostname }) {
+			return nil, fmt.Errorf("host '%s' is already defined as a kubernetes node", h.Hostname)
+		}
+
+		nodes = append(nodes, kubernetes.Node{Hostname: h.Hostname, Type: h.Role})
+	}
+	c.Kubernetes.Nodes = nodes
+
+	if conf.Network.CustomScript != "" || conf.Network.ConfigDir != "" {
+		// The network directory configures hosts by their MAC addresses on its own
+		return &c, nil
+	}
+
+	template, err := networkTemplate(conf.OS.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &network.Network{}
+	for _, h := range hosts {
+		netHost, err := configureHost(template, h)
+		if err != nil {
+			return nil, err
+		}
+		n.Hosts = append(n.Hosts, netHost)
+	}
+	c.OS.Network = n
+
+	return &c, nil
+}
+
+func networkTemplate(n *network.Network) (network.Host, error) {
+	if n == nil {
+		return network.Host{}, nil
+	}
+
+	if len(n.Hosts) != 1 || len(n.Hosts[0].MACAddresses()) > 0 || n.Hosts[0].Hostname != "" {
+		return network.Host{}, fmt.Errorf("the network configuration must define a single host without hostname nor MAC addresses to be used as template for the inventory")
+	}
+
+	for _, i := range n.Hosts[0].Interfaces {
+		if len(i.Addresses) > 0 {
+			return network.Host{}, fmt.Errorf("static addresses of interface '%s' can't be shared by the hosts of the inventory", i.Name)
+		}
+	}
+
+	return n.Hosts[0], nil
+}
+
+// configureHost assigns the hostname and MAC addresses of the inventory host to a copy of the
+// template. Without template interfaces each MAC address gets its own DHCP ethernet interface.
+func configureHost(template network.Host, h Host) (network.Host, error) {
+	netHost := network.Host{Hostname: h.Hostname, DNS: template.DNS}
+
+	if len(template.Interfaces) == 0 {
+		for i, mac := range h.MACAddresses {
+			netHost.Interfaces = append(netHost.Interfaces, network.Interface{Name: fmt.Sprintf("eth%d", i), MACAddress: mac})
+		}
+		return netHost, nil
+	}
+
+	macs := slices.Clone(h.MACAddresses)
+	for _, i := range template.Interfaces {
+		if i.GetType() == network.Ethernet && len(macs) > 0 {
+			i.MACAddress, macs = macs[0], macs[1:]
+		}
+		netHost.Interfaces = append(netHost.Interfaces, i)
+	}
+
+	if len(macs) > 0 {
+		return network.Host{}, fmt.Errorf("host '%s' has more MAC addresses than ethernet interfaces in the network configuration", h.Hostname)
+	}
+
+	return netHost, nil
+}
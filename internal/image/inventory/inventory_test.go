@@ -0,0 +1,169 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/inventory"
+	"github.com/suse/elemental/v3/internal/image/kubernetes"
+	"github.com/suse/elemental/v3/internal/image/network"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestInventorySuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Inventory test suite")
+}
+
+const inventoryYAML = `
+hosts:
+  - hostname: node1
+    macAddresses: ["52:54:00:AA:00:01"]
+    role: server
+  - hostname: node2
+    macAddresses: ["52:54:00:aa:00:02", "52:54:00:aa:00:03"]
+    role: agent
+`
+
+const inventoryCSV = `hostname,macAddresses,role
+# edge site 1
+node1,52:54:00:AA:00:01,server
+node2,52:54:00:aa:00:02;52:54:00:aa:00:03,agent
+node3,52:54:00:aa:00:04
+`
+
+var _ = Describe("Inventory", func() {
+	var fs vfs.FS
+	var cleanup func()
+
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/inventory.yaml": inventoryYAML,
+			"/inventory.csv":  inventoryCSV,
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("Parses YAML and CSV inventories", func() {
+		inv, err := inventory.Parse(fs, "/inventory.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inv.Hosts).To(HaveLen(2))
+		Expect(inv.Hosts[0].MACAddresses).To(Equal([]string{"52:54:00:aa:00:01"}))
+
+		csvInv, err := inventory.Parse(fs, "/inventory.csv")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(csvInv.Hosts).To(HaveLen(3))
+		Expect(csvInv.Hosts[:2]).To(Equal(inv.Hosts))
+		Expect(csvInv.Hosts[2]).To(Equal(inventory.Host{Hostname: "node3", MACAddresses: []string{"52:54:00:aa:00:04"}}))
+	})
+
+	It("Fails on invalid inventories", func() {
+		Expect(fs.WriteFile("/invalid.csv", []byte("node_1,not-a-mac,worker\nnode2,52:54:00:aa:00:01\nnode3,52:54:00:aa:00:01\nnode2,\n"), 0644)).To(Succeed())
+
+		_, err := inventory.Parse(fs, "/invalid.csv")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid hostname 'node_1'"))
+		Expect(err.Error()).To(ContainSubstring("host 'node_1': invalid MAC address 'not-a-mac'"))
+		Expect(err.Error()).To(ContainSubstring("host 'node_1': invalid role 'worker'"))
+		Expect(err.Error()).To(ContainSubstring("MAC address '52:54:00:aa:00:01' is assigned to hosts 'node2' and 'node3'"))
+		Expect(err.Error()).To(ContainSubstring("host 'node2' is defined more than once"))
+		Expect(err.Error()).To(ContainSubstring("host 'node2': at least one MAC address is required"))
+
+		Expect(fs.WriteFile("/invalid.csv", []byte("node1,52:54:00:aa:00:01,server,extra\n"), 0644)).To(Succeed())
+		_, err = inventory.Parse(fs, "/invalid.csv")
+		Expect(err).To(MatchError(ContainSubstring("expected at most 3 columns, got 4")))
+	})
+
+	It("Configures Kubernetes nodes and network connections of the hosts", func() {
+		inv, err := inventory.Parse(fs, "/inventory.csv")
+		Expect(err).ToNot(HaveOccurred())
+
+		conf := &image.Configuration{}
+		fleet, err := inv.Configure(conf, inv.Hosts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fleet.Kubernetes.Nodes).To(Equal(kubernetes.Nodes{
+			{Hostname: "node1", Type: kubernetes.NodeTypeServer},
+			{Hostname: "node2", Type: kubernetes.NodeTypeAgent},
+		}))
+		Expect(fleet.OS.Network.Hosts).To(HaveLen(3))
+		Expect(fleet.OS.Network.Hosts[1].Interfaces).To(Equal([]network.Interface{
+			{Name: "eth0", MACAddress: "52:54:00:aa:00:02"},
+			{Name: "eth1", MACAddress: "52:54:00:aa:00:03"},
+		}))
+		Expect(conf.Kubernetes.Nodes).To(BeEmpty())
+		Expect(conf.OS.Network).To(BeNil())
+
+		conf.OS.Network = &network.Network{Hosts: []network.Host{{
+			DNS: []string{"192.168.120.1"},
+			Interfaces: []network.Interface{
+				{Name: "eth0", Controller: "bond0"},
+				{Name: "eth1", Controller: "bond0"},
+				{Name: "bond0", Type: network.Bond, Bond: &network.BondConfig{Mode: "active-backup"}},
+			},
+		}}}
+		host, err := inv.Configure(conf, inv.Hosts[1:2])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(host.Kubernetes.Nodes).To(HaveLen(2))
+		Expect(host.OS.Network.Hosts).To(HaveLen(1))
+		Expect(host.OS.Network.Hosts[0].Hostname).To(Equal("node2"))
+		Expect(host.OS.Network.Hosts[0].MACAddresses()).To(Equal([]string{"52:54:00:aa:00:02", "52:54:00:aa:00:03"}))
+		Expect(host.OS.Network.Hosts[0].Interfaces[2].Bond.Mode).To(Equal("active-backup"))
+		Expect(conf.OS.Network.Hosts[0].MACAddresses()).To(BeEmpty())
+	})
+
+	It("Rejects configurations conflicting with the inventory", func() {
+		inv, err := inventory.Parse(fs, "/inventory.yaml")
+		Expect(err).ToNot(HaveOccurred())
+
+		conf := &image.Configuration{OS: image.OperatingSystem{Network: &network.Network{Hosts: []network.Host{{
+			Interfaces: []network.Interface{{Name: "eth0"}},
+		}}}}}
+		_, err = inv.Configure(conf, inv.Hosts)
+		Expect(err).To(MatchError(ContainSubstring("host 'node2' has more MAC addresses than ethernet interfaces")))
+
+		conf.OS.Network.Hosts[0].Interfaces[0].Addresses = []string{"192.168.120.10/24"}
+		_, err = inv.Configure(conf, inv.Hosts)
+		Expect(err).To(MatchError(ContainSubstring("static addresses of interface 'eth0' can't be shared")))
+
+		conf.OS.Network.Hosts[0].Hostname = "node1"
+		_, err = inv.Configure(conf, inv.Hosts)
+		Expect(err).To(MatchError(ContainSubstring("must define a single host without hostname nor MAC addresses")))
+
+		conf.OS.Network = nil
+		conf.Kubernetes.Nodes = kubernetes.Nodes{{Hostname: "node1", Type: kubernetes.NodeTypeServer}}
+		_, err = inv.Configure(conf, inv.Hosts)
+		Expect(err).To(MatchError("host 'node1' is already defined as a kubernetes node"))
+
+		conf.Kubernetes.Nodes = nil
+		conf.Network.ConfigDir = "/config/network"
+		fleet, err := inv.Configure(conf, inv.Hosts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fleet.OS.Network).To(BeNil())
+	})
+})
@@ -23,6 +23,7 @@ import (
 	"io/fs"
 	"maps"
 	"net/netip"
+	"slices"
 	"strings"
 
 	"github.com/google/uuid"
@@ -34,11 +35,16 @@ import (
 )
 
 const (
-	tokenKey   = "token"
-	cniKey     = "cni"
-	serverKey  = "server"
-	tlsSANKey  = "tls-san"
-	selinuxKey = "selinux"
+	tokenKey       = "token"
+	cniKey         = "cni"
+	serverKey      = "server"
+	tlsSANKey      = "tls-san"
+	selinuxKey     = "selinux"
+	clusterCIDRKey = "cluster-cidr"
+	serviceCIDRKey = "service-cidr"
+	nodeIPKey      = "node-ip"
+	nodeLabelKey   = "node-label"
+	nodeTaintKey   = "node-taint"
 )
 
 type ConfigMap map[string]any
@@ -53,6 +59,11 @@ type Cluster struct {
 	AgentConfig ConfigMap
 	// RegistriesConfig contains the configurations for private or embedded registries
 	RegistriesConfig ConfigMap
+	// RegistryCerts contains the CA certificates of the registries, keyed by their path on the nodes
+	RegistryCerts map[string]string
+	// NodeConfigs contains the complete configuration of each node, keyed by hostname. It includes
+	// the configuration of the node role along with its address, labels and taints.
+	NodeConfigs map[string]ConfigMap
 }
 
 func NewCluster(s *sys.System, kube *Kubernetes) (*Cluster, error) {
@@ -61,17 +72,27 @@ func NewCluster(s *sys.System, kube *Kubernetes) (*Cluster, error) {
 		return nil, fmt.Errorf("parsing registries config: %w", err)
 	}
 
+	registryCerts, err := setRegistriesConfig(s.Logger(), kube, registriesConfig)
+	if err != nil {
+		return nil, fmt.Errorf("setting registries config: %w", err)
+	}
+
 	serverConfig, err := ParseKubernetesConfig(s, kube.Config.ServerFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("parsing server config: %w", err)
 	}
 
+	setNetworkConfig(kube, serverConfig)
+
 	if len(kube.Nodes) < 2 {
 		setSingleNodeConfigDefaults(s.Logger(), kube, serverConfig)
-		return &Cluster{
+		cluster := &Cluster{
 			ServerConfig:     serverConfig,
 			RegistriesConfig: registriesConfig,
-		}, nil
+			RegistryCerts:    registryCerts,
+		}
+		cluster.NodeConfigs = nodeConfigs(s.Logger(), kube, cluster, nil)
+		return cluster, nil
 	}
 
 	var ip4 netip.Addr
@@ -111,12 +132,58 @@ func NewCluster(s *sys.System, kube *Kubernetes) (*Cluster, error) {
 	maps.Copy(initConfig, serverConfig)
 	delete(initConfig, serverKey)
 
-	return &Cluster{
+	initNode, err := FindInitNode(kube.Nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := &Cluster{
 		InitServerConfig: initConfig,
 		ServerConfig:     serverConfig,
 		AgentConfig:      agentConfig,
 		RegistriesConfig: registriesConfig,
-	}, err
+		RegistryCerts:    registryCerts,
+	}
+	cluster.NodeConfigs = nodeConfigs(s.Logger(), kube, cluster, initNode)
+	return cluster, nil
+}
+
+// nodeConfigs renders the configuration of the nodes of the cluster, or only the one of the host
+// the image is built for if any
+func nodeConfigs(logger log.Logger, kube *Kubernetes, c *Cluster, initNode *Node) map[string]ConfigMap {
+	configs := map[string]ConfigMap{}
+	prioritizeIPv6 := IsIPv6Priority(c.ServerConfig)
+
+	for _, node := range kube.Nodes {
+		if kube.Host != "" && node.Hostname != kube.Host {
+			continue
+		}
+
+		base := c.ServerConfig
+		switch {
+		case initNode != nil && node.Hostname == initNode.Hostname:
+			base = c.InitServerConfig
+		case node.Type == NodeTypeAgent && c.AgentConfig != nil:
+			base = c.AgentConfig
+		}
+
+		config := ConfigMap{}
+		maps.Copy(config, base)
+
+		if ip := node.NodeIP(prioritizeIPv6); ip != "" && !IsNodeIPSet(config) {
+			config[nodeIPKey] = ip
+		}
+		for _, label := range node.Labels {
+			appendConfigList(logger, config, nodeLabelKey, label)
+		}
+		for _, taint := range node.Taints {
+			appendConfigList(logger, config, nodeTaintKey, taint)
+		}
+
+		configs[node.Hostname] = config
+	}
+
+	return configs
 }
 
 func ParseKubernetesConfig(s *sys.System, configFile string) (ConfigMap, error) {
@@ -171,7 +238,7 @@ func setMultiNodeConfigDefaults(logger log.Logger, kube *Kubernetes, config Conf
 		return err
 	}
 
-	setClusterToken(logger, config)
+	setClusterToken(logger, config, kube.Token)
 	if kube.Network.APIVIP4 != "" {
 		appendClusterTLSSAN(logger, config, kube.Network.APIVIP4)
 	}
@@ -188,14 +255,36 @@ func setMultiNodeConfigDefaults(logger log.Logger, kube *Kubernetes, config Conf
 	return nil
 }
 
-func setClusterToken(logger log.Logger, config ConfigMap) {
-	if _, ok := config[tokenKey].(string); ok {
+// setNetworkConfig sets the CNI plugin and the cluster and service networks of the definition
+// unless they are already set in the server config
+func setNetworkConfig(kube *Kubernetes, config ConfigMap) {
+	if _, ok := config[cniKey]; !ok && kube.Network.CNI != "" {
+		config[cniKey] = kube.Network.CNI
+	}
+	if _, ok := config[clusterCIDRKey]; !ok && len(kube.Network.ClusterCIDR) > 0 {
+		config[clusterCIDRKey] = strings.Join(kube.Network.ClusterCIDR, ",")
+	}
+	if _, ok := config[serviceCIDRKey]; !ok && len(kube.Network.ServiceCIDR) > 0 {
+		config[serviceCIDRKey] = strings.Join(kube.Network.ServiceCIDR, ",")
+	}
+}
+
+func setClusterToken(logger log.Logger, config ConfigMap, defaultToken string) {
+	if token, ok := config[tokenKey].(string); ok {
+		logger.RegisterSecret(token)
+		return
+	}
+
+	if defaultToken != "" {
+		logger.RegisterSecret(defaultToken)
+		config[tokenKey] = defaultToken
 		return
 	}
 
 	token := uuid.NewString()
+	logger.RegisterSecret(token)
 
-	logger.Info("Generated cluster token: %s", token)
+	logger.Info("Generated cluster token, it is stored in the cluster configuration")
 	config[tokenKey] = token
 }
 
@@ -228,29 +317,33 @@ func appendClusterTLSSAN(logger log.Logger, config ConfigMap, address string) {
 		return
 	}
 
-	tlsSAN, ok := config[tlsSANKey]
+	appendConfigList(logger, config, tlsSANKey, address)
+}
+
+// appendConfigList appends the value to the list of the given key. Comma separated strings are
+// converted into lists and the existing lists are copied, as they may be shared by other configs.
+func appendConfigList(logger log.Logger, config ConfigMap, key, value string) {
+	current, ok := config[key]
 	if !ok {
-		config[tlsSANKey] = []string{address}
+		config[key] = []string{value}
 		return
 	}
 
-	switch v := tlsSAN.(type) {
+	switch v := current.(type) {
 	case string:
-		var tlsSANs []string
-		for san := range strings.SplitSeq(v, ",") {
-			tlsSANs = append(tlsSANs, strings.TrimSpace(san))
+		var values []string
+		for item := range strings.SplitSeq(v, ",") {
+			values = append(values, strings.TrimSpace(item))
 		}
-		tlsSANs = append(tlsSANs, address)
-		config[tlsSANKey] = tlsSANs
+		values = append(values, value)
+		config[key] = values
 	case []string:
-		v = append(v, address)
-		config[tlsSANKey] = v
+		config[key] = append(slices.Clone(v), value)
 	case []any:
-		v = append(v, address)
-		config[tlsSANKey] = v
+		config[key] = append(slices.Clone(v), value)
 	default:
-		logger.Warn("Ignoring invalid 'tls-san' value: %v", v)
-		config[tlsSANKey] = []string{address}
+		logger.Warn("Ignoring invalid '%s' value: %v", key, v)
+		config[key] = []string{value}
 	}
 }
 
@@ -283,17 +376,15 @@ func FilterNodeType(nodes Nodes, nodeType string) Nodes {
 }
 
 func IsIPv6Priority(serverConfig ConfigMap) bool {
-	if clusterCIDR, ok := serverConfig["cluster-cidr"].(string); ok {
-		cidrs := strings.Split(clusterCIDR, ",")
-		if len(cidrs) > 0 {
-			return strings.Contains(cidrs[0], "::")
-		}
+	if clusterCIDR, ok := serverConfig[clusterCIDRKey].(string); ok {
+		cidr, _, _ := strings.Cut(clusterCIDR, ",")
+		return isIPv6Prefix(cidr)
 	}
 
 	return false
 }
 
 func IsNodeIPSet(serverConfig ConfigMap) bool {
-	_, ok := serverConfig["node-ip"].(string)
+	_, ok := serverConfig[nodeIPKey].(string)
 	return ok
 }
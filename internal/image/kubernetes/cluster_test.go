@@ -2,11 +2,13 @@ package kubernetes
 
 import (
 	"net/netip"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/suse/elemental/v3/internal/image/auth"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
@@ -209,9 +211,185 @@ var _ = Describe("Cluster", func() {
 		Expect(cluster.AgentConfig["selinux"]).To(BeTrue())
 		Expect(cluster.AgentConfig["debug"]).To(BeTrue())
 	})
+	It("Sets the cluster networks of an IPv6-only cluster", func() {
+		kubernetes := &Kubernetes{
+			Network: Network{
+				APIHost:     "api.suse.com",
+				APIVIP6:     "fd12:3456:789a::21",
+				ClusterCIDR: []string{"fd00:42::/56"},
+				ServiceCIDR: []string{"fd00:43::/112"},
+			},
+			Nodes: Nodes{
+				{Hostname: "host1.suse.com", Type: NodeTypeServer, IP6: "fd12:3456:789a::1"},
+				{Hostname: "host2.suse.com", Type: NodeTypeAgent, IP6: "fd12:3456:789a::2"},
+			},
+		}
+
+		cluster, err := NewCluster(s, kubernetes)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cluster.ServerConfig["cluster-cidr"]).To(Equal("fd00:42::/56"))
+		Expect(cluster.ServerConfig["service-cidr"]).To(Equal("fd00:43::/112"))
+		Expect(cluster.ServerConfig["server"]).To(Equal("https://[fd12:3456:789a::21]:9345"))
+		Expect(cluster.InitServerConfig["cluster-cidr"]).To(Equal("fd00:42::/56"))
+		Expect(cluster.AgentConfig["server"]).To(Equal("https://[fd12:3456:789a::21]:9345"))
+	})
+	It("Uses the given cluster token if none is set in the server config", func() {
+		kubernetes := &Kubernetes{
+			Network: Network{APIVIP4: "192.168.122.50"},
+			Nodes: Nodes{
+				{Hostname: "host1.suse.com", Type: NodeTypeServer},
+				{Hostname: "host2.suse.com", Type: NodeTypeAgent},
+			},
+			Token: "shared-token",
+		}
+
+		cluster, err := NewCluster(s, kubernetes)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cluster.ServerConfig["token"]).To(Equal("shared-token"))
+		Expect(cluster.AgentConfig["token"]).To(Equal("shared-token"))
+	})
+	It("Renders the configuration of each node", func() {
+		kubernetes := &Kubernetes{
+			Network: Network{APIVIP4: "192.168.122.50", CNI: "cilium"},
+			Nodes: Nodes{
+				{Hostname: "host1", Type: NodeTypeServer, IP4: "192.168.122.11", Labels: []string{"zone=a"}},
+				{Hostname: "host2", Type: NodeTypeServer, Taints: []string{"CriticalAddonsOnly=true:NoExecute"}},
+				{Hostname: "host3", Type: NodeTypeAgent, Labels: []string{"zone=b", "gpu=true"}},
+			},
+		}
+
+		cluster, err := NewCluster(s, kubernetes)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cluster.ServerConfig["cni"]).To(Equal("cilium"))
+		Expect(cluster.AgentConfig["cni"]).To(Equal("cilium"))
+		Expect(cluster.NodeConfigs).To(HaveLen(3))
+
+		init := cluster.NodeConfigs["host1"]
+		Expect(init["server"]).To(BeNil())
+		Expect(init["node-ip"]).To(Equal("192.168.122.11"))
+		Expect(init["node-label"]).To(Equal([]string{"zone=a"}))
+		Expect(init["token"]).To(Equal(cluster.ServerConfig["token"]))
+
+		server := cluster.NodeConfigs["host2"]
+		Expect(server["server"]).To(Equal("https://192.168.122.50:9345"))
+		Expect(server["node-ip"]).To(BeNil())
+		Expect(server["node-taint"]).To(Equal([]string{"CriticalAddonsOnly=true:NoExecute"}))
+		Expect(server["tls-san"]).To(Equal([]string{"192.168.122.50"}))
+
+		agent := cluster.NodeConfigs["host3"]
+		Expect(agent["server"]).To(Equal("https://192.168.122.50:9345"))
+		Expect(agent["node-label"]).To(Equal([]string{"zone=b", "gpu=true"}))
+		Expect(agent["tls-san"]).To(BeNil())
+
+		Expect(cluster.ServerConfig["node-label"]).To(BeNil())
+		Expect(cluster.AgentConfig["node-label"]).To(BeNil())
+	})
+	It("Renders only the configuration of the given host", func() {
+		kubernetes := &Kubernetes{
+			Network: Network{APIVIP4: "192.168.122.50"},
+			Nodes: Nodes{
+				{Hostname: "host1", Type: NodeTypeServer},
+				{Hostname: "host2", Type: NodeTypeAgent},
+			},
+			Host: "host2",
+		}
+
+		cluster, err := NewCluster(s, kubernetes)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cluster.NodeConfigs).To(HaveLen(1))
+		Expect(cluster.NodeConfigs).To(HaveKey("host2"))
+	})
+	It("Renders the registries of the definition", func() {
+		kubernetes := &Kubernetes{
+			Registries: &Registries{
+				Mirrors: []RegistryMirror{
+					{Registry: "docker.io", Endpoints: []string{"https://registry.example.com"}},
+					{Registry: "mirror.example.com", Endpoints: []string{"https://ignored.example.com"}},
+				},
+				Configs: []RegistryConfig{{
+					Registry:              "registry.example.com",
+					Credentials:           &auth.Credentials{Username: "user", Password: "pass"},
+					CA:                    "-----BEGIN CERTIFICATE-----\n",
+					InsecureSkipTLSVerify: true,
+				}},
+			},
+			Config: Config{
+				RegistriesFilePath: "/etc/kubernetes/multi-node/registries.yaml",
+			},
+		}
+
+		cluster, err := NewCluster(s, kubernetes)
+		Expect(err).ToNot(HaveOccurred())
+
+		mirrors := cluster.RegistriesConfig["mirrors"].(ConfigMap)
+		Expect(mirrors).To(HaveLen(2))
+		Expect(mirrors["docker.io"]).To(Equal(ConfigMap{"endpoint": []string{"https://registry.example.com"}}))
+		Expect(mirrors["mirror.example.com"]).To(HaveKeyWithValue("endpoint", []any{"https://mirror.example.com"}))
+
+		configs := cluster.RegistriesConfig["configs"].(ConfigMap)
+		Expect(configs["registry.example.com"]).To(Equal(ConfigMap{
+			"auth": ConfigMap{"username": "user", "password": "pass"},
+			"tls":  ConfigMap{"ca_file": "/etc/rancher/rke2/certs/registry.example.com.crt", "insecure_skip_verify": true},
+		}))
+		Expect(cluster.RegistryCerts).To(Equal(map[string]string{
+			"/etc/rancher/rke2/certs/registry.example.com.crt": "-----BEGIN CERTIFICATE-----\n",
+		}))
+	})
+	It("Fails on an invalid registries config", func() {
+		Expect(fs.WriteFile("/etc/kubernetes/registries.yaml", []byte("mirrors: invalid\n"), 0o644)).To(Succeed())
+		kubernetes := &Kubernetes{
+			Registries: &Registries{
+				Mirrors: []RegistryMirror{{Registry: "docker.io", Endpoints: []string{"https://registry.example.com"}}},
+			},
+			Config: Config{RegistriesFilePath: "/etc/kubernetes/registries.yaml"},
+		}
+
+		_, err := NewCluster(s, kubernetes)
+		Expect(err).To(MatchError("setting registries config: invalid 'mirrors' section of the registries config"))
+	})
+	It("Fails on an invalid registry name", func() {
+		kubernetes := &Kubernetes{
+			Registries: &Registries{
+				Configs: []RegistryConfig{{Registry: "../../../etc/ssl/certs/ca", CA: "-----BEGIN CERTIFICATE-----\n"}},
+			},
+		}
+
+		_, err := NewCluster(s, kubernetes)
+		Expect(err).To(MatchError("setting registries config: invalid registry '../../../etc/ssl/certs/ca', expected a host with an optional port"))
+	})
+	It("Keeps the cluster networks of the server config", func() {
+		kubernetes := &Kubernetes{
+			Network: Network{
+				ClusterCIDR: []string{"10.42.0.0/16", "fd00:42::/56"},
+				ServiceCIDR: []string{"10.43.0.0/16", "fd00:43::/112"},
+			},
+			Config: Config{
+				ServerFilePath: "/etc/kubernetes/single-node/server.yaml",
+			},
+		}
+		Expect(fs.WriteFile(
+			"/etc/kubernetes/single-node/server.yaml", []byte("cluster-cidr: fd00:1::/56,10.1.0.0/16\n"), 0o644,
+		)).To(Succeed())
+
+		cluster, err := NewCluster(s, kubernetes)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cluster.ServerConfig["cluster-cidr"]).To(Equal("fd00:1::/56,10.1.0.0/16"))
+		Expect(cluster.ServerConfig["service-cidr"]).To(Equal("10.43.0.0/16,fd00:43::/112"))
+		Expect(IsIPv6Priority(cluster.ServerConfig)).To(BeTrue())
+	})
 })
 
 var _ = Describe("Cluster Helpers", func() {
+	It("detects the primary IP family of the cluster", func() {
+		Expect(IsIPv6Priority(ConfigMap{})).To(BeFalse())
+		Expect(IsIPv6Priority(ConfigMap{"cluster-cidr": "10.42.0.0/16,fd00:42::/56"})).To(BeFalse())
+		Expect(IsIPv6Priority(ConfigMap{"cluster-cidr": "2001:db8:42:0:0:0:0:0/56,10.42.0.0/16"})).To(BeTrue())
+		Expect(Network{ClusterCIDR: []string{"fd00:42::/56", "10.42.0.0/16"}}.PrioritizeIPv6()).To(BeTrue())
+		Expect(Network{ClusterCIDR: []string{"10.42.0.0/16"}}.PrioritizeIPv6()).To(BeFalse())
+	})
+
 	It("sets cluster API address", func() {
 		config := map[string]any{}
 
@@ -307,6 +485,13 @@ var _ = Describe("Cluster Helpers", func() {
 })
 
 var _ = Describe("Node Helpers", func() {
+	It("Returns the node IP addresses", func() {
+		Expect(Node{}.NodeIP(false)).To(BeEmpty())
+		Expect(Node{IP6: "fd00::1"}.NodeIP(false)).To(Equal("fd00::1"))
+		Expect(Node{IP4: "10.0.0.1", IP6: "fd00::1"}.NodeIP(false)).To(Equal("10.0.0.1,fd00::1"))
+		Expect(Node{IP4: "10.0.0.1", IP6: "fd00::1"}.NodeIP(true)).To(Equal("fd00::1,10.0.0.1"))
+	})
+
 	It("Fails to find suitable init node among 3 unknown types", func() {
 		n1 := Nodes{{}, {}, {}}
 		found, err := FindInitNode(n1)
@@ -333,4 +518,29 @@ var _ = Describe("Node Helpers", func() {
 		Expect(found).ToNot(BeNil())
 		Expect(found.Hostname).To(Equal("server1"))
 	})
+
+	It("Validates the format of node labels and taints", func() {
+		for _, label := range []string{"zone=a", "gpu=", "topology.kubernetes.io/zone=eu-1a", "node.example.com/role=edge_1"} {
+			Expect(IsValidLabel(label)).To(BeTrue(), label)
+		}
+		for _, label := range []string{"zone", "=a", "zone=a b", "Example.com/zone=a", "zone=-a", "a/b/c=d", strings.Repeat("a", 64) + "=b"} {
+			Expect(IsValidLabel(label)).To(BeFalse(), label)
+		}
+
+		for _, taint := range []string{"CriticalAddonsOnly=true:NoExecute", "dedicated:NoSchedule", "example.com/gpu=yes:PreferNoSchedule"} {
+			Expect(IsValidTaint(taint)).To(BeTrue(), taint)
+		}
+		for _, taint := range []string{"dedicated=true", "dedicated=true:Never", "=true:NoSchedule", "dedicated=a:b:NoSchedule"} {
+			Expect(IsValidTaint(taint)).To(BeFalse(), taint)
+		}
+	})
+
+	It("Validates the format of registries", func() {
+		for _, registry := range []string{"docker.io", "registry.example.com:5000", "localhost", "192.168.122.10:5000", "[fd00::10]:5000", "fd00::10"} {
+			Expect(IsValidRegistry(registry)).To(BeTrue(), registry)
+		}
+		for _, registry := range []string{"", "../etc/passwd", "registry.example.com/path", "registry.example.com:port", "registry.example.com:0", "[fd00::10]", "-registry.example.com"} {
+			Expect(IsValidRegistry(registry)).To(BeFalse(), registry)
+		}
+	})
 })
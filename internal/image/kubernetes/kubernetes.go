@@ -19,6 +19,10 @@ package kubernetes
 
 import (
 	"fmt"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/suse/elemental/v3/internal/image/auth"
 	"github.com/suse/elemental/v3/pkg/helm"
@@ -29,6 +33,14 @@ const (
 	NodeTypeAgent  = "agent"
 )
 
+var (
+	// qualifiedName is the name of a label or taint key and the format of their values
+	qualifiedName = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+	// dnsSubdomain is the optional prefix of a label or taint key
+	dnsSubdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	taintEffects = []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}
+)
+
 type Kubernetes struct {
 	// RemoteManifests - manifest URLs specified under config/kubernetes/cluster.yaml
 	RemoteManifests []string `yaml:"manifests,omitempty" validate:"dive,required,url"`
@@ -36,9 +48,19 @@ type Kubernetes struct {
 	Helm *Helm `yaml:"helm,omitempty" validate:"omitempty"`
 	// LocalManifests - local manifest files specified under config/kubernetes/manifests
 	LocalManifests []string
+	// Kustomizations - kustomization directories specified under config/kubernetes/kustomize
+	Kustomizations []string
 	Nodes          Nodes   `yaml:"nodes,omitempty" validate:"dive"`
 	Network        Network `yaml:"network,omitempty"`
-	Config         Config  `yaml:"-"`
+	// Registries - mirrors and access configuration of the container registries used by the cluster
+	Registries *Registries `yaml:"registries,omitempty" validate:"omitempty"`
+	Config     Config      `yaml:"-"`
+	// Token is the cluster token used if none is set in the server configuration, so nodes
+	// of the same cluster built in separate images can join each other
+	Token string `yaml:"-"`
+	// Host is the hostname of the node the image is built for, if set only the configuration
+	// of this node is rendered
+	Host string `yaml:"-"`
 }
 
 type Config struct {
@@ -108,6 +130,28 @@ type Node struct {
 	Hostname string `yaml:"hostname" validate:"required,hostname"`
 	Type     string `yaml:"type" validate:"required,oneof=server agent"`
 	Init     bool   `yaml:"init,omitempty"`
+	// IP4 and IP6 are the node addresses advertised by RKE2, both are set on dual-stack nodes
+	IP4 string `yaml:"ip,omitempty" validate:"omitempty,ipv4"`
+	IP6 string `yaml:"ip6,omitempty" validate:"omitempty,ipv6"`
+	// Labels and Taints are registered with the node when it joins the cluster, in the
+	// 'key=value' and 'key[=value]:effect' formats respectively
+	Labels []string `yaml:"labels,omitempty" validate:"dive,required,k8slabel"`
+	Taints []string `yaml:"taints,omitempty" validate:"dive,required,k8staint"`
+}
+
+// NodeIP returns the RKE2 'node-ip' value of the node, with the IPv6 address first if prioritizeIPv6
+// is set. It returns an empty string if the node has no addresses.
+func (n Node) NodeIP(prioritizeIPv6 bool) string {
+	ips := []string{}
+	for _, ip := range []string{n.IP4, n.IP6} {
+		if ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	if prioritizeIPv6 && len(ips) == 2 {
+		ips[0], ips[1] = ips[1], ips[0]
+	}
+	return strings.Join(ips, ",")
 }
 
 type Nodes []Node
@@ -136,8 +180,65 @@ type Network struct {
 	APIHost string `yaml:"apiHost"`
 	APIVIP4 string `yaml:"apiVIP,omitempty" validate:"omitempty"`
 	APIVIP6 string `yaml:"apiVIP6,omitempty" validate:"omitempty,ipv6"`
+	// CNI is the network plugin of the cluster, unless set in the server configuration
+	CNI string `yaml:"cni,omitempty" validate:"omitempty,oneof=canal calico cilium flannel none"`
+	// ClusterCIDR and ServiceCIDR are the pod and service networks, one per IP family. The family of
+	// the first cluster network is the primary one of the cluster.
+	ClusterCIDR []string `yaml:"clusterCIDR,omitempty" validate:"omitempty,max=2,dive,cidr"`
+	ServiceCIDR []string `yaml:"serviceCIDR,omitempty" validate:"omitempty,max=2,dive,cidr"`
+}
+
+// PrioritizeIPv6 returns true if IPv6 is the primary IP family of the cluster networks
+func (n Network) PrioritizeIPv6() bool {
+	if len(n.ClusterCIDR) == 0 {
+		return false
+	}
+	return isIPv6Prefix(n.ClusterCIDR[0])
+}
+
+func isIPv6Prefix(cidr string) bool {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+	return err == nil && prefix.Addr().Is6()
+}
+
+// IsZero returns true if no network setting is defined
+func (n Network) IsZero() bool {
+	return n.APIHost == "" && n.CNI == "" && !n.IsHA() && len(n.ClusterCIDR) == 0 && len(n.ServiceCIDR) == 0
 }
 
 func (n Network) IsHA() bool {
 	return n.APIVIP4 != "" || n.APIVIP6 != ""
 }
+
+// IsValidLabel returns true if the given node label is in the 'key=value' format, with a valid
+// Kubernetes key and value
+func IsValidLabel(label string) bool {
+	key, value, ok := strings.Cut(label, "=")
+	return ok && isValidKey(key) && isValidValue(value)
+}
+
+// IsValidTaint returns true if the given node taint is in the 'key[=value]:effect' format, with a
+// valid Kubernetes key, value and effect
+func IsValidTaint(taint string) bool {
+	keyValue, effect, ok := strings.Cut(taint, ":")
+	if !ok || !slices.Contains(taintEffects, effect) {
+		return false
+	}
+
+	key, value, _ := strings.Cut(keyValue, "=")
+	return isValidKey(key) && isValidValue(value)
+}
+
+// isValidKey returns true for keys made of a name with an optional DNS subdomain prefix
+func isValidKey(key string) bool {
+	prefix, name, ok := strings.Cut(key, "/")
+	if !ok {
+		return qualifiedName.MatchString(key)
+	}
+	return len(prefix) <= 253 && dnsSubdomain.MatchString(prefix) && qualifiedName.MatchString(name)
+}
+
+// isValidValue returns true for empty values and values in the format of names
+func isValidValue(value string) bool {
+	return value == "" || qualifiedName.MatchString(value)
+}
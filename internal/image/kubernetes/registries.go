@@ -0,0 +1,161 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/netip"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/internal/image/auth"
+	"github.com/suse/elemental/v3/pkg/log"
+)
+
+// RegistryCertsDir is the directory of the nodes holding the CA certificates of the registries
+const RegistryCertsDir = "/etc/rancher/rke2/certs"
+
+const (
+	mirrorsKey = "mirrors"
+	configsKey = "configs"
+)
+
+type Registries struct {
+	Mirrors []RegistryMirror `yaml:"mirrors,omitempty" validate:"dive"`
+	Configs []RegistryConfig `yaml:"configs,omitempty" validate:"dive"`
+}
+
+// RegistryMirror redirects the pulls from the given registry to the endpoints, in order
+type RegistryMirror struct {
+	Registry  string   `yaml:"registry" validate:"required"`
+	Endpoints []string `yaml:"endpoints" validate:"required,dive,url"`
+}
+
+// RegistryConfig defines the credentials and TLS settings used to access a registry or mirror
+type RegistryConfig struct {
+	Registry              string            `yaml:"registry" validate:"required,registry"`
+	Credentials           *auth.Credentials `yaml:"credentials,omitempty"`
+	CA                    string            `yaml:"ca,omitempty"`
+	InsecureSkipTLSVerify bool              `yaml:"insecureSkipTLSVerify,omitempty"`
+}
+
+// setRegistriesConfig adds the mirrors and registry configurations of the definition to the
+// containerd registries config, unless the registry is already defined there. It returns the
+// CA certificates to install on the nodes, keyed by their path.
+func setRegistriesConfig(logger log.Logger, kube *Kubernetes, config ConfigMap) (map[string]string, error) {
+	certs := map[string]string{}
+	if kube.Registries == nil {
+		return certs, nil
+	}
+
+	mirrors, err := configSection(config, mirrorsKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range kube.Registries.Mirrors {
+		if _, ok := mirrors[m.Registry]; ok {
+			logger.Warn("Keeping mirror of registry '%s' from the registries config", m.Registry)
+			continue
+		}
+		mirrors[m.Registry] = ConfigMap{"endpoint": m.Endpoints}
+	}
+
+	configs, err := configSection(config, configsKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range kube.Registries.Configs {
+		if _, ok := configs[c.Registry]; ok {
+			logger.Warn("Keeping configuration of registry '%s' from the registries config", c.Registry)
+			continue
+		}
+
+		if !IsValidRegistry(c.Registry) {
+			return nil, fmt.Errorf("invalid registry '%s', expected a host with an optional port", c.Registry)
+		}
+
+		registry := ConfigMap{}
+		if c.Credentials != nil {
+			logger.RegisterSecret(c.Credentials.Password)
+			registry["auth"] = ConfigMap{"username": c.Credentials.Username, "password": c.Credentials.Password}
+		}
+
+		tls := ConfigMap{}
+		if c.CA != "" {
+			path := filepath.Join(RegistryCertsDir, c.Registry+".crt")
+			certs[path] = c.CA
+			tls["ca_file"] = path
+		}
+		if c.InsecureSkipTLSVerify {
+			tls["insecure_skip_verify"] = true
+		}
+		if len(tls) > 0 {
+			registry["tls"] = tls
+		}
+
+		configs[c.Registry] = registry
+	}
+
+	if len(mirrors) > 0 {
+		config[mirrorsKey] = mirrors
+	}
+	if len(configs) > 0 {
+		config[configsKey] = configs
+	}
+
+	return certs, nil
+}
+
+// IsValidRegistry returns true if the given registry is a hostname or IP address, with an
+// optional port
+func IsValidRegistry(registry string) bool {
+	host := registry
+	if h, port, err := net.SplitHostPort(registry); err == nil {
+		if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+			return false
+		}
+		host = h
+	} else if strings.HasPrefix(registry, "[") {
+		return false
+	}
+
+	if _, err := netip.ParseAddr(host); err == nil {
+		return true
+	}
+	return len(host) <= 253 && dnsSubdomain.MatchString(strings.ToLower(host))
+}
+
+// configSection returns a copy of the given section of the config
+func configSection(config ConfigMap, key string) (ConfigMap, error) {
+	section := ConfigMap{}
+
+	switch v := config[key].(type) {
+	case nil:
+	case ConfigMap:
+		maps.Copy(section, v)
+	case map[string]any:
+		maps.Copy(section, v)
+	default:
+		return nil, fmt.Errorf("invalid '%s' section of the registries config", key)
+	}
+
+	return section, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//revive:disable:var-naming
+package image
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/suse/elemental/v3/internal/template"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+)
+
+// NameData holds the build metadata available to output image name templates
+type NameData struct {
+	// Name of the release, taken from the solution manifest if any or the core platform manifest
+	Name string
+	// Version of the release, taken from the same manifest as the name
+	Version string
+	// Arch is the architecture of the target platform
+	Arch string
+	// Date is the UTC build time formatted as 2006-01-02T15-04-05
+	Date string
+	// Type is the image type, 'raw' or 'iso'
+	Type string
+}
+
+// IsNameTemplate returns true if the given output image name includes template actions
+func IsNameTemplate(name string) bool {
+	return strings.Contains(filepath.Base(name), "{{")
+}
+
+// RenderOutputName renders the base name of the output image if it is a template. The
+// release metadata is read from the given resolved manifest.
+func (i *Image) RenderOutputName(rm *resolver.ResolvedManifest, buildTime time.Time) error {
+	if !IsNameTemplate(i.OutputImageName) {
+		return nil
+	}
+
+	data := NameData{
+		Date: buildTime.UTC().Format("2006-01-02T15-04-05"),
+		Type: i.ImageType,
+	}
+	if i.Platform != nil {
+		data.Arch = i.Platform.Arch
+	}
+
+	if metadata := releaseMetadata(rm); metadata != nil {
+		data.Name = metadata.Name
+		data.Version = metadata.Version
+	}
+
+	name, err := template.Parse("output-name", filepath.Base(i.OutputImageName), data)
+	if err != nil {
+		return fmt.Errorf("rendering output image name: %w", err)
+	}
+
+	if name == "" || strings.ContainsRune(name, filepath.Separator) {
+		return fmt.Errorf("invalid rendered output image name '%s'", name)
+	}
+
+	i.OutputImageName = filepath.Join(filepath.Dir(i.OutputImageName), name)
+	return nil
+}
+
+func releaseMetadata(rm *resolver.ResolvedManifest) *api.Metadata {
+	if rm == nil {
+		return nil
+	}
+
+	if rm.SolutionExtension != nil && rm.SolutionExtension.Metadata != nil {
+		return rm.SolutionExtension.Metadata
+	}
+
+	if rm.CorePlatform != nil {
+		return rm.CorePlatform.Metadata
+	}
+
+	return nil
+}
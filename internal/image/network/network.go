@@ -0,0 +1,280 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type InterfaceType string
+
+const (
+	Ethernet InterfaceType = "ethernet"
+	Bond     InterfaceType = "bond"
+	VLAN     InterfaceType = "vlan"
+)
+
+var interfaceRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.:@-]{1,15}$`)
+
+// Network - NetworkManager connections specified under config/os.yaml
+type Network struct {
+	// Hosts are the network configurations of the nodes built from the image. A node applies
+	// the configuration of the host matching the MAC addresses of its network cards.
+	Hosts []Host `yaml:"hosts" validate:"required,min=1,dive"`
+}
+
+type Host struct {
+	// Hostname is set on the node applying the configuration of the host
+	Hostname   string      `yaml:"hostname,omitempty" validate:"omitempty,hostname_rfc1123"`
+	Interfaces []Interface `yaml:"interfaces" validate:"required,min=1,dive"`
+	// DNS are the name servers of the host, they are assigned to the interfaces with static addresses of
+	// the same family, or to the interfaces using DHCP if no interface has a static address of that family
+	DNS []string `yaml:"dns,omitempty" validate:"omitempty,dive,ip"`
+}
+
+type Interface struct {
+	Name string `yaml:"name" validate:"required"`
+	// Type of the interface, defaults to ethernet
+	Type InterfaceType `yaml:"type,omitempty" validate:"omitempty,oneof=ethernet bond vlan"`
+	// MACAddress binds an ethernet interface to the network card of the host
+	MACAddress string `yaml:"macAddress,omitempty" validate:"omitempty,mac"`
+	// Addresses are static IP addresses in CIDR notation, DHCP is used if none is set
+	Addresses []string `yaml:"addresses,omitempty" validate:"omitempty,dive,cidr"`
+	Gateway4  string   `yaml:"gateway4,omitempty" validate:"omitempty,ipv4"`
+	Gateway6  string   `yaml:"gateway6,omitempty" validate:"omitempty,ipv6"`
+	MTU       int      `yaml:"mtu,omitempty" validate:"omitempty,min=68,max=65535"`
+	// Controller is the bond interface this interface is a port of
+	Controller string      `yaml:"controller,omitempty"`
+	Bond       *BondConfig `yaml:"bond,omitempty" validate:"omitempty"`
+	VLAN       *VLANConfig `yaml:"vlan,omitempty" validate:"omitempty"`
+}
+
+type BondConfig struct {
+	Mode string `yaml:"mode" validate:"required,oneof=balance-rr active-backup balance-xor broadcast 802.3ad balance-tlb balance-alb"`
+	// MIIMon is the link monitoring interval in milliseconds
+	MIIMon int `yaml:"miimon,omitempty" validate:"omitempty,min=0"`
+}
+
+type VLANConfig struct {
+	ID int `yaml:"id" validate:"required,min=1,max=4094"`
+	// Parent is the interface the VLAN is created on
+	Parent string `yaml:"parent" validate:"required"`
+}
+
+// GetType returns the configured type or ethernet if none is set
+func (i *Interface) GetType() InterfaceType {
+	if i.Type == "" {
+		return Ethernet
+	}
+
+	return i.Type
+}
+
+// MACAddresses returns the normalized MAC addresses of the ethernet interfaces of the host
+func (h *Host) MACAddresses() []string {
+	var macs []string
+	for _, i := range h.Interfaces {
+		if i.GetType() == Ethernet && i.MACAddress != "" {
+			macs = append(macs, strings.ToLower(i.MACAddress))
+		}
+	}
+
+	return macs
+}
+
+// Validate checks the consistency of the hosts beyond what the field validations can express
+func (n *Network) Validate() error {
+	var errs []error
+
+	hostnames := map[string]bool{}
+	macs := map[string]int{}
+
+	for idx, h := range n.Hosts {
+		name := h.Hostname
+		if name == "" {
+			name = fmt.Sprintf("#%d", idx)
+		}
+
+		if h.Hostname != "" {
+			if hostnames[h.Hostname] {
+				errs = append(errs, fmt.Errorf("host '%s' is defined more than once", h.Hostname))
+			}
+			hostnames[h.Hostname] = true
+		}
+
+		hostMACs := h.MACAddresses()
+		if len(n.Hosts) > 1 && len(hostMACs) == 0 {
+			errs = append(errs, fmt.Errorf("host '%s': at least one ethernet interface requires a MAC address to identify the host", name))
+		}
+
+		for _, mac := range hostMACs {
+			if other, ok := macs[mac]; ok && other != idx {
+				errs = append(errs, fmt.Errorf("MAC address '%s' is assigned to more than one host", mac))
+			}
+			macs[mac] = idx
+		}
+
+		errs = append(errs, h.validateInterfaces(name)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *Host) validateInterfaces(host string) []error {
+	var errs []error
+
+	types := map[string]InterfaceType{}
+	for _, i := range h.Interfaces {
+		if _, ok := types[i.Name]; ok {
+			errs = append(errs, fmt.Errorf("host '%s': interface '%s' is defined more than once", host, i.Name))
+		}
+		types[i.Name] = i.GetType()
+	}
+
+	for _, i := range h.Interfaces {
+		if !interfaceRegexp.MatchString(i.Name) {
+			errs = append(errs, fmt.Errorf("host '%s': invalid interface name '%s'", host, i.Name))
+		}
+
+		switch i.GetType() {
+		case Ethernet:
+			if i.Bond != nil || i.VLAN != nil {
+				errs = append(errs, fmt.Errorf("host '%s': ethernet interface '%s' can't define bond or vlan settings", host, i.Name))
+			}
+		case Bond:
+			if i.Bond == nil {
+				errs = append(errs, fmt.Errorf("host '%s': bond interface '%s' requires bond settings", host, i.Name))
+			}
+			if i.MACAddress != "" || i.VLAN != nil {
+				errs = append(errs, fmt.Errorf("host '%s': bond interface '%s' can't define a MAC address or vlan settings", host, i.Name))
+			}
+		case VLAN:
+			if i.VLAN == nil {
+				errs = append(errs, fmt.Errorf("host '%s': vlan interface '%s' requires vlan settings", host, i.Name))
+			} else if _, ok := types[i.VLAN.Parent]; !ok {
+				errs = append(errs, fmt.Errorf("host '%s': parent interface '%s' of vlan '%s' is not defined", host, i.VLAN.Parent, i.Name))
+			}
+			if i.MACAddress != "" || i.Bond != nil {
+				errs = append(errs, fmt.Errorf("host '%s': vlan interface '%s' can't define a MAC address or bond settings", host, i.Name))
+			}
+		}
+
+		if i.Controller != "" {
+			if types[i.Controller] != Bond {
+				errs = append(errs, fmt.Errorf("host '%s': controller '%s' of interface '%s' is not a bond interface", host, i.Controller, i.Name))
+			}
+			if len(i.Addresses) > 0 || i.Gateway4 != "" || i.Gateway6 != "" {
+				errs = append(errs, fmt.Errorf("host '%s': port '%s' of bond '%s' can't define IP settings", host, i.Name, i.Controller))
+			}
+		}
+
+		errs = append(errs, i.validateGateways(host)...)
+	}
+
+	if len(h.DNS) > 0 && !h.hasStaticAddresses() {
+		errs = append(errs, fmt.Errorf("host '%s': name servers require an interface with static addresses", host))
+	} else {
+		for _, d := range h.DNS {
+			if !slices.ContainsFunc(h.Interfaces, func(i Interface) bool { return slices.Contains(h.nameServers(i, isIPv4(d)), d) }) {
+				errs = append(errs, fmt.Errorf("host '%s': no interface can reach name server '%s', it requires an interface of the same family", host, d))
+			}
+		}
+	}
+
+	return errs
+}
+
+// NameServers returns the IPv4 and IPv6 name servers of the host assigned to the given interface. Name
+// servers are assigned to the interfaces with static addresses of their family, or to the interfaces
+// using DHCP if no interface has a static address of that family. Bond ports get no name servers.
+func (h *Host) NameServers(i Interface) (v4, v6 []string) {
+	return h.nameServers(i, true), h.nameServers(i, false)
+}
+
+func (h *Host) nameServers(i Interface, ipv4 bool) []string {
+	if i.Controller != "" {
+		return nil
+	}
+
+	static := func(i Interface) bool {
+		v4, v6 := i.AddressFamilies()
+		return (ipv4 && v4) || (!ipv4 && v6)
+	}
+	if slices.ContainsFunc(h.Interfaces, func(i Interface) bool { return i.Controller == "" && static(i) }) {
+		if !static(i) {
+			return nil
+		}
+	} else if len(i.Addresses) > 0 {
+		return nil
+	}
+
+	var servers []string
+	for _, d := range h.DNS {
+		if isIPv4(d) == ipv4 {
+			servers = append(servers, d)
+		}
+	}
+	return servers
+}
+
+func isIPv4(addr string) bool {
+	ip, err := netip.ParseAddr(addr)
+	return err == nil && ip.Is4()
+}
+
+func (h *Host) hasStaticAddresses() bool {
+	return slices.ContainsFunc(h.Interfaces, func(i Interface) bool { return len(i.Addresses) > 0 })
+}
+
+func (i *Interface) validateGateways(host string) []error {
+	var errs []error
+
+	v4, v6 := i.AddressFamilies()
+	if i.Gateway4 != "" && !v4 {
+		errs = append(errs, fmt.Errorf("host '%s': interface '%s' requires a static IPv4 address to set an IPv4 gateway", host, i.Name))
+	}
+
+	if i.Gateway6 != "" && !v6 {
+		errs = append(errs, fmt.Errorf("host '%s': interface '%s' requires a static IPv6 address to set an IPv6 gateway", host, i.Name))
+	}
+
+	return errs
+}
+
+// AddressFamilies reports whether the interface has static IPv4 and IPv6 addresses
+func (i *Interface) AddressFamilies() (v4, v6 bool) {
+	for _, a := range i.Addresses {
+		prefix, err := netip.ParsePrefix(a)
+		if err != nil {
+			continue
+		}
+
+		if prefix.Addr().Is4() {
+			v4 = true
+		} else {
+			v6 = true
+		}
+	}
+
+	return v4, v6
+}
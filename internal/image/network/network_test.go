@@ -0,0 +1,128 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/image/network"
+)
+
+func TestNetworkSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Network test suite")
+}
+
+var _ = Describe("Network", func() {
+	It("Validates a single host without MAC addresses", func() {
+		n := &network.Network{Hosts: []network.Host{{
+			Hostname: "node1",
+			DNS:      []string{"192.168.120.1"},
+			Interfaces: []network.Interface{
+				{Name: "eth0", Controller: "bond0"},
+				{Name: "eth1", Controller: "bond0"},
+				{Name: "bond0", Type: network.Bond, Bond: &network.BondConfig{Mode: "active-backup"}},
+				{
+					Name: "bond0.10", Type: network.VLAN, VLAN: &network.VLANConfig{ID: 10, Parent: "bond0"},
+					Addresses: []string{"192.168.120.10/24", "fd00::10/64"}, Gateway4: "192.168.120.1",
+				},
+			},
+		}}}
+		Expect(n.Validate()).To(Succeed())
+		Expect(n.Hosts[0].Interfaces[0].GetType()).To(Equal(network.Ethernet))
+		Expect(n.Hosts[0].MACAddresses()).To(BeEmpty())
+
+		v4, v6 := n.Hosts[0].Interfaces[3].AddressFamilies()
+		Expect(v4).To(BeTrue())
+		Expect(v6).To(BeTrue())
+	})
+
+	It("Requires MAC addresses to tell multiple hosts apart", func() {
+		n := &network.Network{Hosts: []network.Host{
+			{Hostname: "node1", Interfaces: []network.Interface{{Name: "eth0", MACAddress: "52:54:00:AA:00:01"}}},
+			{Hostname: "node2", Interfaces: []network.Interface{{Name: "eth0", MACAddress: "52:54:00:aa:00:02"}}},
+		}}
+		Expect(n.Validate()).To(Succeed())
+		Expect(n.Hosts[0].MACAddresses()).To(Equal([]string{"52:54:00:aa:00:01"}))
+
+		n.Hosts[1].Interfaces[0].MACAddress = "52:54:00:aa:00:01"
+		n.Hosts = append(n.Hosts, network.Host{Hostname: "node1", Interfaces: []network.Interface{{Name: "eth0"}}})
+		err := n.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("MAC address '52:54:00:aa:00:01' is assigned to more than one host"))
+		Expect(err.Error()).To(ContainSubstring("host 'node1' is defined more than once"))
+		Expect(err.Error()).To(ContainSubstring("host 'node1': at least one ethernet interface requires a MAC address"))
+	})
+
+	It("Validates the consistency of the interfaces", func() {
+		n := &network.Network{Hosts: []network.Host{{
+			Hostname: "node1",
+			DNS:      []string{"192.168.120.1"},
+			Interfaces: []network.Interface{
+				{Name: "eth0", Controller: "eth1", Addresses: []string{"192.168.120.10/24"}},
+				{Name: "eth1", Bond: &network.BondConfig{Mode: "802.3ad"}, Gateway6: "fd00::1"},
+				{Name: "eth1"},
+				{Name: "bond0", Type: network.Bond},
+				{Name: "vlan10", Type: network.VLAN, VLAN: &network.VLANConfig{ID: 10, Parent: "eth5"}},
+				{Name: "interface-name-too-long"},
+			},
+		}}}
+		err := n.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("interface 'eth1' is defined more than once"))
+		Expect(err.Error()).To(ContainSubstring("controller 'eth1' of interface 'eth0' is not a bond interface"))
+		Expect(err.Error()).To(ContainSubstring("port 'eth0' of bond 'eth1' can't define IP settings"))
+		Expect(err.Error()).To(ContainSubstring("ethernet interface 'eth1' can't define bond or vlan settings"))
+		Expect(err.Error()).To(ContainSubstring("interface 'eth1' requires a static IPv6 address to set an IPv6 gateway"))
+		Expect(err.Error()).To(ContainSubstring("bond interface 'bond0' requires bond settings"))
+		Expect(err.Error()).To(ContainSubstring("parent interface 'eth5' of vlan 'vlan10' is not defined"))
+		Expect(err.Error()).To(ContainSubstring("invalid interface name 'interface-name-too-long'"))
+
+		n.Hosts[0].Interfaces = []network.Interface{{Name: "eth0"}}
+		Expect(n.Validate()).To(MatchError(ContainSubstring("name servers require an interface with static addresses")))
+
+		n.Hosts[0].DNS = []string{"fd00::1"}
+		n.Hosts[0].Interfaces = []network.Interface{{Name: "eth0", Addresses: []string{"192.168.120.10/24"}}}
+		Expect(n.Validate()).To(MatchError(ContainSubstring("no interface can reach name server 'fd00::1'")))
+	})
+
+	It("Assigns the name servers of both families", func() {
+		h := network.Host{
+			DNS: []string{"192.168.120.1", "fd00::1"},
+			Interfaces: []network.Interface{
+				{Name: "eth0", Addresses: []string{"192.168.120.10/24"}},
+				{Name: "eth1"},
+				{Name: "eth2", Controller: "bond0"},
+			},
+		}
+		v4, v6 := h.NameServers(h.Interfaces[0])
+		Expect(v4).To(Equal([]string{"192.168.120.1"}))
+		Expect(v6).To(BeEmpty())
+
+		v4, v6 = h.NameServers(h.Interfaces[1])
+		Expect(v4).To(BeEmpty())
+		Expect(v6).To(Equal([]string{"fd00::1"}))
+
+		v4, v6 = h.NameServers(h.Interfaces[2])
+		Expect(v4).To(BeEmpty())
+		Expect(v6).To(BeEmpty())
+	})
+})
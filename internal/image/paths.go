@@ -26,10 +26,18 @@ func ExtensionsPath() string {
 	return filepath.Join("var", "lib", "extensions")
 }
 
+func ConfextsPath() string {
+	return filepath.Join("var", "lib", "confexts")
+}
+
 func IgnitionFilePath() string {
 	return filepath.Join("ignition", "config.ign")
 }
 
+func BuildInfoPath() string {
+	return filepath.Join("etc", "elemental", "build-info.yaml")
+}
+
 func ElementalPath() string {
 	return filepath.Join("var", "lib", "elemental")
 }
@@ -46,6 +54,42 @@ func HelmPath() string {
 	return filepath.Join(KubernetesPath(), "helm")
 }
 
+func KubernetesImagesPath() string {
+	return filepath.Join("var", "lib", "rancher", "rke2", "agent", "images")
+}
+
 func KubernetesInstallPath() string {
 	return filepath.Join("opt", "k8s", "install")
 }
+
+func FirewalldZonesPath() string {
+	return filepath.Join("etc", "firewalld", "zones")
+}
+
+func FirewalldDropInPath() string {
+	return filepath.Join("etc", "systemd", "system", "firewalld.service.d", "50-elemental-default-zone.conf")
+}
+
+func NFTablesConfigPath() string {
+	return filepath.Join("etc", "nftables.conf")
+}
+
+func SystemdPresetPath() string {
+	return filepath.Join("etc", "systemd", "system-preset")
+}
+
+func ChronyConfigPath() string {
+	return filepath.Join("etc", "chrony.d", "elemental.conf")
+}
+
+func NetworkConnectionsPath() string {
+	return filepath.Join("etc", "NetworkManager", "system-connections")
+}
+
+func NetworkHostsPath() string {
+	return filepath.Join("etc", "elemental", "network")
+}
+
+func HostnamePath() string {
+	return filepath.Join("etc", "hostname")
+}
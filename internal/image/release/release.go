@@ -22,6 +22,7 @@ import "github.com/suse/elemental/v3/internal/image/auth"
 type Release struct {
 	ManifestURI string     `yaml:"manifestURI" validate:"required"`
 	Components  Components `yaml:"components,omitempty"`
+	Overrides   *Overrides `yaml:"overrides,omitempty"`
 }
 type Components struct {
 	SystemdExtensions []SystemdExtension `yaml:"systemd,omitempty" validate:"dive"`
@@ -47,3 +48,31 @@ type HelmChart struct {
 	ValuesFile  string            `yaml:"valuesFile,omitempty"`
 	Credentials *auth.Credentials `yaml:"credentials,omitempty"`
 }
+
+// Overrides pins components to versions other than the ones defined in the release manifest
+type Overrides struct {
+	OperatingSystem   *OperatingSystemOverride   `yaml:"operatingSystem,omitempty"`
+	Kubernetes        *KubernetesOverride        `yaml:"kubernetes,omitempty"`
+	HelmCharts        []HelmChartOverride        `yaml:"helm,omitempty" validate:"dive"`
+	SystemdExtensions []SystemdExtensionOverride `yaml:"systemd,omitempty" validate:"dive"`
+}
+
+type OperatingSystemOverride struct {
+	Base string `yaml:"base,omitempty" validate:"required_without=ISO"`
+	ISO  string `yaml:"iso,omitempty" validate:"required_without=Base"`
+}
+
+type KubernetesOverride struct {
+	Version string `yaml:"version" validate:"required"`
+	Image   string `yaml:"image" validate:"required"`
+}
+
+type HelmChartOverride struct {
+	Name    string `yaml:"chart" validate:"required"`
+	Version string `yaml:"version" validate:"required"`
+}
+
+type SystemdExtensionOverride struct {
+	Name  string `yaml:"extension" validate:"required"`
+	Image string `yaml:"image" validate:"required"`
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timesync
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MinHardenedSources is the minimum number of agreeing time sources required by the hardened profile
+const MinHardenedSources = 2
+
+// TimeSync - chrony time synchronization settings specified under config/os.yaml
+type TimeSync struct {
+	Servers []Server `yaml:"servers" validate:"required,min=1,dive"`
+	// MakeStep allows chrony to step the clock instead of slewing it, which is required on
+	// devices booting with a clock far off the actual time
+	MakeStep *MakeStep `yaml:"makeStep,omitempty" validate:"omitempty"`
+	// MinSources is the number of sources that must agree before the clock is updated
+	MinSources int `yaml:"minSources,omitempty" validate:"omitempty,min=1"`
+	// Hardened requires NTS authentication for all servers, at least two agreeing sources,
+	// limits clock steps to the first updates after boot and disables the chronyc command port
+	Hardened bool `yaml:"hardened,omitempty"`
+}
+
+type Server struct {
+	Address string `yaml:"address" validate:"required,hostname_rfc1123|ip"`
+	// Pool resolves the address to multiple servers
+	Pool bool `yaml:"pool,omitempty"`
+	// NTS authenticates the server with Network Time Security
+	NTS    bool `yaml:"nts,omitempty"`
+	Prefer bool `yaml:"prefer,omitempty"`
+}
+
+type MakeStep struct {
+	// Threshold is the offset in seconds above which the clock is stepped
+	Threshold float64 `yaml:"threshold" validate:"gt=0"`
+	// Limit is the number of initial clock updates in which stepping is allowed, -1 for unlimited
+	Limit int `yaml:"limit" validate:"min=-1,ne=0"`
+}
+
+// GetMinSources returns the configured number of sources or the hardened profile minimum
+func (t *TimeSync) GetMinSources() int {
+	if t.Hardened && t.MinSources == 0 {
+		return MinHardenedSources
+	}
+
+	return t.MinSources
+}
+
+// GetMakeStep returns the configured step policy, the hardened profile defaults to stepping
+// only on the first three updates after boot
+func (t *TimeSync) GetMakeStep() *MakeStep {
+	if t.MakeStep == nil && t.Hardened {
+		return &MakeStep{Threshold: 1, Limit: 3}
+	}
+
+	return t.MakeStep
+}
+
+// Validate checks the consistency of the settings beyond what the field validations can express
+func (t *TimeSync) Validate() error {
+	var errs []error
+
+	addresses := map[string]bool{}
+	sources := 0
+	for _, s := range t.Servers {
+		if addresses[s.Address] {
+			errs = append(errs, fmt.Errorf("server '%s' is defined more than once", s.Address))
+		}
+		addresses[s.Address] = true
+
+		if t.Hardened && !s.NTS {
+			errs = append(errs, fmt.Errorf("server '%s' must use NTS in the hardened profile", s.Address))
+		}
+
+		if s.Pool {
+			// chrony uses up to 4 servers of a pool by default
+			sources += 4
+		} else {
+			sources++
+		}
+	}
+
+	if minSources := t.GetMinSources(); minSources > sources {
+		errs = append(errs, fmt.Errorf("%d agreeing sources are required but only %d can be configured", minSources, sources))
+	}
+
+	if t.Hardened {
+		if step := t.GetMakeStep(); step.Limit < 0 {
+			errs = append(errs, fmt.Errorf("unlimited clock steps are not allowed in the hardened profile"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
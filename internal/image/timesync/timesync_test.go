@@ -0,0 +1,66 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timesync_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/image/timesync"
+)
+
+func TestTimeSyncSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TimeSync test suite")
+}
+
+var _ = Describe("TimeSync", func() {
+	It("Applies the hardened profile defaults", func() {
+		ts := &timesync.TimeSync{}
+		Expect(ts.GetMinSources()).To(Equal(0))
+		Expect(ts.GetMakeStep()).To(BeNil())
+
+		ts.Hardened = true
+		Expect(ts.GetMinSources()).To(Equal(timesync.MinHardenedSources))
+		Expect(ts.GetMakeStep()).To(Equal(&timesync.MakeStep{Threshold: 1, Limit: 3}))
+
+		ts.MinSources = 3
+		ts.MakeStep = &timesync.MakeStep{Threshold: 10, Limit: 1}
+		Expect(ts.GetMinSources()).To(Equal(3))
+		Expect(ts.GetMakeStep()).To(Equal(ts.MakeStep))
+	})
+
+	It("Validates servers against the required sources", func() {
+		ts := &timesync.TimeSync{
+			Servers:    []timesync.Server{{Address: "pool.example.com", Pool: true}},
+			MinSources: 3,
+		}
+		Expect(ts.Validate()).To(Succeed())
+
+		ts.Servers = append(ts.Servers, timesync.Server{Address: "pool.example.com"})
+		ts.Hardened = true
+		ts.MakeStep = &timesync.MakeStep{Threshold: 1, Limit: -1}
+		err := ts.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("server 'pool.example.com' is defined more than once"))
+		Expect(err.Error()).To(ContainSubstring("server 'pool.example.com' must use NTS in the hardened profile"))
+		Expect(err.Error()).To(ContainSubstring("unlimited clock steps are not allowed in the hardened profile"))
+	})
+})
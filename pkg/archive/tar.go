@@ -19,6 +19,8 @@ package archive
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
 	"compress/bzip2"
 	"compress/gzip"
 	"context"
@@ -29,6 +31,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
@@ -61,20 +65,68 @@ type link struct {
 	Path string
 }
 
-// ExtractTarball extracts a .tar, .tar.gz or .tar.bz2 taball file to the given target.
-// Compression detection is rudimentary and only based on file name extension.
+// Compression is the compression format of a tarball
+type Compression int
+
+const (
+	Uncompressed Compression = iota
+	Gzip
+	Bzip2
+	Xz
+	Zstd
+)
+
+var compressionMagics = []struct {
+	magic       []byte
+	compression Compression
+}{
+	{[]byte{0x1f, 0x8b}, Gzip},
+	{[]byte("BZh"), Bzip2},
+	{[]byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, Xz},
+	{[]byte{0x28, 0xb5, 0x2f, 0xfd}, Zstd},
+}
+
+// DetectCompression returns the compression format of the given stream based on its magic
+// number and a reader including the consumed header bytes
+func DetectCompression(body io.Reader) (Compression, io.Reader, error) {
+	reader := bufio.NewReader(body)
+	header, err := reader.Peek(6)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Uncompressed, nil, fmt.Errorf("reading tarball header: %w", err)
+	}
+	for _, m := range compressionMagics {
+		if bytes.HasPrefix(header, m.magic) {
+			return m.compression, reader, nil
+		}
+	}
+	return Uncompressed, reader, nil
+}
+
+// ExtractTarball extracts a .tar file, optionally compressed with gzip, bzip2, xz or zstd, to the
+// given target. Compression is detected from the file contents. Xz decompression requires the xz tool.
 func ExtractTarball(ctx context.Context, s *sys.System, tarball string, target string, filters ...Filter) error {
 	sourceFile, err := s.FS().OpenFile(tarball, os.O_RDONLY, vfs.FilePerm)
 	if err != nil {
 		return err
 	}
-	switch {
-	case strings.HasSuffix(tarball, "tar.bz2"):
-		return ExtractTarBz2(ctx, s, sourceFile, target, filters...)
-	case strings.HasSuffix(tarball, "tar.gz"):
-		return ExtractTarGz(ctx, s, sourceFile, target, filters...)
+	defer sourceFile.Close()
+
+	compression, reader, err := DetectCompression(sourceFile)
+	if err != nil {
+		return err
+	}
+
+	switch compression {
+	case Bzip2:
+		return ExtractTarBz2(ctx, s, reader, target, filters...)
+	case Gzip:
+		return ExtractTarGz(ctx, s, reader, target, filters...)
+	case Xz:
+		return ExtractTarXz(ctx, s, reader, target, filters...)
+	case Zstd:
+		return ExtractTarZstd(ctx, s, reader, target, filters...)
 	default:
-		return ExtractTar(ctx, s, sourceFile, target, filters...)
+		return ExtractTar(ctx, s, reader, target, filters...)
 	}
 }
 
@@ -94,6 +146,56 @@ func ExtractTarBz2(ctx context.Context, s *sys.System, body io.Reader, target st
 	return ExtractTar(ctx, s, reader, target, filters...)
 }
 
+// ExtractTarZstd extracts a .tar.zst archived stream of data to the given target
+func ExtractTarZstd(ctx context.Context, s *sys.System, body io.Reader, target string, filters ...Filter) error {
+	reader, err := zstd.NewReader(body)
+	if err != nil {
+		return fmt.Errorf("zstd error: %w", err)
+	}
+	defer reader.Close()
+
+	return ExtractTar(ctx, s, reader, target, filters...)
+}
+
+// ExtractTarXz extracts a .tar.xz archived stream of data to the given target. The stream is
+// decompressed by the xz tool.
+func ExtractTarXz(ctx context.Context, s *sys.System, body io.Reader, target string, filters ...Filter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	stderr := &bytes.Buffer{}
+	done := make(chan error, 1)
+	go func() {
+		err := s.Runner().RunContextWithPipe(ctx, func(stdin io.Writer) error {
+			_, err := io.Copy(stdin, body)
+			return err
+		}, pw, stderr, "", nil, "xz", "-dc")
+		if err != nil {
+			err = fmt.Errorf("xz error: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	err := ExtractTar(ctx, s, pr, target, filters...)
+	if err == nil {
+		// the tar reader stops at the end of archive marker, drain the stream to catch xz errors
+		_, err = io.Copy(io.Discard, pr)
+	} else {
+		// stop the decompression if extraction failed before the end of the stream
+		cancel()
+	}
+	_ = pr.CloseWithError(io.ErrClosedPipe)
+
+	// the body must not be read anymore once returning, wait for the decompression to finish
+	xzErr := <-done
+	if err == nil {
+		err = xzErr
+	}
+	return err
+}
+
 // ExtractTar extracts a .tar archived stream of data to the given target
 func ExtractTar(ctx context.Context, s *sys.System, body io.Reader, target string, filters ...Filter) error {
 	var links []*link
@@ -20,12 +20,16 @@ package archive_test
 import (
 	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -46,22 +50,37 @@ var _ = Describe("Archive", Label("archive"), func() {
 	var tfs vfs.FS
 	var err error
 	var buffer *bytes.Buffer
+	var runner *sysmock.Runner
+	var tarData []byte
 
 	BeforeEach(func() {
-		var gzData []byte
+		var gzData, zstData []byte
 
 		buffer = &bytes.Buffer{}
 		gzData, err = os.ReadFile("../../tests/testdata/test.tar.gz")
 		Expect(err).NotTo(HaveOccurred())
 
+		gzReader, err := gzip.NewReader(bytes.NewReader(gzData))
+		Expect(err).NotTo(HaveOccurred())
+		tarData, err = io.ReadAll(gzReader)
+		Expect(err).NotTo(HaveOccurred())
+		encoder, err := zstd.NewWriter(nil)
+		Expect(err).NotTo(HaveOccurred())
+		zstData = encoder.EncodeAll(tarData, nil)
+		Expect(encoder.Close()).To(Succeed())
+
 		// Include tarballs in test environment
 		tfs, _, err = sysmock.TestFS(map[string]any{
 			"/data/test.tar.gz":  gzData,
+			"/data/test.tar.zst": zstData,
 			"/data/test.tar.bz2": "invalid",
 			"/data/test.tar":     "invalid",
 		})
+		Expect(err).NotTo(HaveOccurred())
+		runner = sysmock.NewRunner()
 		s, err = sys.NewSystem(
 			sys.WithFS(tfs),
+			sys.WithRunner(runner),
 			sys.WithLogger(log.New(log.WithBuffer(buffer))),
 		)
 		Expect(err).NotTo(HaveOccurred())
@@ -120,4 +139,41 @@ var _ = Describe("Archive", Label("archive"), func() {
 		Expect(archive.ExtractTarball(context.Background(), s, "/data/test.tar", "/root")).NotTo(Succeed())
 		Expect(archive.ExtractTarball(context.Background(), s, "/data/test.tar.bz2", "/root")).NotTo(Succeed())
 	})
+
+	It("Extracts a tar.zst file content to the given target", func() {
+		Expect(archive.ExtractTarball(context.Background(), s, "/data/test.tar.zst", "/root")).To(Succeed())
+		ok, _ := vfs.Exists(tfs, "/root/etc/os-release")
+		Expect(ok).To(BeTrue())
+	})
+
+	It("Extracts a tar.xz stream using the xz tool", func() {
+		Expect(archive.ExtractTarXz(context.Background(), s, bytes.NewReader(tarData), "/root")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{"xz", "-dc"}})).To(Succeed())
+		ok, _ := vfs.Exists(tfs, "/root/etc/os-release")
+		Expect(ok).To(BeTrue())
+	})
+
+	It("fails to extract a tar.xz stream if the xz tool fails", func() {
+		runner.ReturnError = fmt.Errorf("xz failed")
+		err := archive.ExtractTarXz(context.Background(), s, bytes.NewReader(tarData), "/root")
+		Expect(err).To(MatchError(ContainSubstring("xz error")))
+	})
+
+	It("detects the compression format from the stream content", func() {
+		for data, compression := range map[string]archive.Compression{
+			"\x1f\x8bdata":         archive.Gzip,
+			"BZh91AY":              archive.Bzip2,
+			"\xfd7zXZ\x00data":     archive.Xz,
+			"\x28\xb5\x2f\xfddata": archive.Zstd,
+			"plain":                archive.Uncompressed,
+			"":                     archive.Uncompressed,
+		} {
+			c, reader, err := archive.DetectCompression(strings.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c).To(Equal(compression))
+			content, err := io.ReadAll(reader)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal(data))
+		}
+	})
 })
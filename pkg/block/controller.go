@@ -0,0 +1,143 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+type ControllerMode string
+
+const (
+	// HardwareRAID is a virtual disk exposed by a hardware RAID controller
+	HardwareRAID ControllerMode = "raid"
+	// HBA is a physical disk passed through by a RAID controller in HBA (JBOD) mode
+	HBA ControllerMode = "hba"
+	// FakeRAID is a physical disk member of a firmware (BIOS) RAID set assembled by the OS
+	FakeRAID ControllerMode = "fakeraid"
+	// Direct is a disk not attached to any RAID capable controller
+	Direct ControllerMode = "direct"
+
+	raidMemberSuffix = "_raid_member"
+	scsiHostDir      = "/sys/class/scsi_host"
+)
+
+// raidDrivers are the SCSI host drivers of the RAID controllers of Dell (PERC), HPE (Smart Array) and
+// Broadcom/LSI (MegaRAID) servers
+var raidDrivers = []string{"megaraid_sas", "mpt3sas", "smartpqi", "hpsa", "aacraid", "mpi3mr"}
+
+// virtualDiskModels matches the model of the virtual disks exposed by hardware RAID controllers
+var virtualDiskModels = regexp.MustCompile(`(?i)^(PERC |LOGICAL VOLUME|MR\d+|MegaRAID|Virtual Disk|RAID\b)`)
+
+// Controller describes the storage controller a disk is attached to and how the disk is exposed by it
+type Controller struct {
+	Disk   string         `yaml:"disk"`
+	Mode   ControllerMode `yaml:"mode"`
+	Driver string         `yaml:"driver,omitempty"`
+	Vendor string         `yaml:"vendor,omitempty"`
+	Model  string         `yaml:"model,omitempty"`
+	// RaidType is the firmware RAID metadata format found on a fake-RAID member disk, e.g. 'isw' or 'ddf'
+	RaidType string `yaml:"raidType,omitempty"`
+	// RaidSets are the assembled RAID devices including a fake-RAID member disk
+	RaidSets []string `yaml:"raidSets,omitempty"`
+}
+
+type jDisk struct {
+	Path     string  `json:"path,omitempty"`
+	Type     string  `json:"type,omitempty"`
+	Vendor   string  `json:"vendor,omitempty"`
+	Model    string  `json:"model,omitempty"`
+	HCTL     string  `json:"hctl,omitempty"`
+	FS       string  `json:"fstype,omitempty"`
+	Children []jDisk `json:"children,omitempty"`
+}
+
+// DetectController returns the controller metadata of the given disk. Hardware RAID virtual disks are
+// identified by their model, HBA mode disks by the driver of their SCSI host and fake-RAID member disks
+// by their RAID metadata signature.
+func DetectController(s *sys.System, disk string) (*Controller, error) {
+	out, err := s.Runner().Run("lsblk", "-p", "-J", "-o", "PATH,TYPE,VENDOR,MODEL,HCTL,FSTYPE", disk)
+	if err != nil {
+		return nil, fmt.Errorf("listing disk '%s': %w", disk, err)
+	}
+
+	devices := struct {
+		BlockDevices []jDisk `json:"blockdevices"`
+	}{}
+	err = json.Unmarshal(out, &devices)
+	if err != nil {
+		return nil, fmt.Errorf("parsing lsblk output: %w", err)
+	}
+	if len(devices.BlockDevices) == 0 {
+		return nil, fmt.Errorf("disk '%s' not reported by lsblk", disk)
+	}
+	jd := devices.BlockDevices[0]
+
+	c := &Controller{
+		Disk:   disk,
+		Mode:   Direct,
+		Vendor: strings.TrimSpace(jd.Vendor),
+		Model:  strings.TrimSpace(jd.Model),
+		Driver: scsiHostDriver(s, jd.HCTL),
+	}
+
+	if raidType, ok := strings.CutSuffix(jd.FS, raidMemberSuffix); ok {
+		c.Mode = FakeRAID
+		c.RaidType = raidType
+		for _, child := range jd.Children {
+			if strings.HasPrefix(child.Type, "raid") && !slices.Contains(c.RaidSets, child.Path) {
+				c.RaidSets = append(c.RaidSets, child.Path)
+			}
+		}
+		return c, nil
+	}
+
+	switch {
+	case virtualDiskModels.MatchString(c.Model):
+		c.Mode = HardwareRAID
+	case slices.Contains(raidDrivers, c.Driver):
+		c.Mode = HBA
+	}
+	return c, nil
+}
+
+// scsiHostDriver returns the driver of the SCSI host of the given HCTL address, empty if unknown
+func scsiHostDriver(s *sys.System, hctl string) string {
+	host, _, ok := strings.Cut(hctl, ":")
+	if !ok {
+		return ""
+	}
+	procName := filepath.Join(scsiHostDir, "host"+host, "proc_name")
+	if ok, _ := vfs.Exists(s.FS(), procName); !ok {
+		return ""
+	}
+	data, err := s.FS().ReadFile(procName)
+	if err != nil {
+		s.Logger().Debug("Could not read SCSI host driver '%s': %v", procName, err)
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package block_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const diskLsblkTmpl = `{
+   "blockdevices": [
+      {
+         "path": "/dev/sda",
+         "type": "disk",
+         "vendor": "%s",
+         "model": "%s",
+         "hctl": "0:2:0:0",
+         "fstype": %s,
+         "children": [%s]
+      }
+   ]
+}
+`
+
+const raidSetLsblk = `{
+   "path": "/dev/md126",
+   "type": "raid1"
+}`
+
+func TestBlockSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Block test suite")
+}
+
+var _ = Describe("Disk controllers", Label("block", "controller"), func() {
+	var runner *sysmock.Runner
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var lsblkOut string
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/sys/class/scsi_host/host0/proc_name": "megaraid_sas\n",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "lsblk" {
+				return []byte(lsblkOut), nil
+			}
+			return []byte{}, nil
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("detects a hardware RAID virtual disk", func() {
+		lsblkOut = fmt.Sprintf(diskLsblkTmpl, "DELL    ", "PERC H740P Mini ", "null", "")
+		c, err := block.DetectController(s, "/dev/sda")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Mode).To(Equal(block.HardwareRAID))
+		Expect(c.Driver).To(Equal("megaraid_sas"))
+		Expect(c.Vendor).To(Equal("DELL"))
+		Expect(c.Model).To(Equal("PERC H740P Mini"))
+	})
+	It("detects a disk passed through by a RAID controller in HBA mode", func() {
+		lsblkOut = fmt.Sprintf(diskLsblkTmpl, "ATA", "SAMSUNG MZ7LH480", "null", "")
+		c, err := block.DetectController(s, "/dev/sda")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Mode).To(Equal(block.HBA))
+	})
+	It("detects a fake-RAID member disk and its assembled RAID set", func() {
+		lsblkOut = fmt.Sprintf(diskLsblkTmpl, "ATA", "SAMSUNG MZ7LH480", `"isw_raid_member"`, raidSetLsblk)
+		c, err := block.DetectController(s, "/dev/sda")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Mode).To(Equal(block.FakeRAID))
+		Expect(c.RaidType).To(Equal("isw"))
+		Expect(c.RaidSets).To(Equal([]string{"/dev/md126"}))
+	})
+	It("detects a disk not attached to a RAID controller", func() {
+		Expect(tfs.Remove("/sys/class/scsi_host/host0/proc_name")).To(Succeed())
+		lsblkOut = fmt.Sprintf(diskLsblkTmpl, "ATA", "SAMSUNG MZ7LH480", "null", "")
+		c, err := block.DetectController(s, "/dev/sda")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Mode).To(Equal(block.Direct))
+		Expect(c.Driver).To(BeEmpty())
+	})
+	It("fails if lsblk does not report the disk", func() {
+		lsblkOut = `{"blockdevices": []}`
+		_, err := block.DetectController(s, "/dev/sda")
+		Expect(err).To(HaveOccurred())
+	})
+})
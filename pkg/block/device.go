@@ -39,6 +39,7 @@ type Partition struct {
 	Size        uint
 	FileSystem  string
 	UUID        string
+	Type        string
 	Flags       []string
 	MountPoints []string
 	Path        string
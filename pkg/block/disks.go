@@ -0,0 +1,158 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+const (
+	lvmMemberFS = "LVM2_member"
+	swapMount   = "[SWAP]"
+)
+
+// Disk is a whole disk of the host, size in bytes
+type Disk struct {
+	Path       string `yaml:"path"`
+	Size       uint64 `yaml:"size"`
+	Vendor     string `yaml:"vendor,omitempty"`
+	Model      string `yaml:"model,omitempty"`
+	Serial     string `yaml:"serial,omitempty"`
+	WWN        string `yaml:"wwn,omitempty"`
+	Transport  string `yaml:"transport,omitempty"`
+	Rotational bool   `yaml:"rotational"`
+	Removable  bool   `yaml:"removable"`
+	ReadOnly   bool   `yaml:"readOnly"`
+	// PartitionTable is the partition table type, 'gpt' or 'dos', empty if the disk is not partitioned
+	PartitionTable string `yaml:"partitionTable,omitempty"`
+	// Usage lists why the disk is in use: mounted filesystems, swap, LVM physical volumes, RAID members
+	// and open encrypted volumes of the disk or its partitions
+	Usage []string `yaml:"usage,omitempty"`
+}
+
+// InUse returns true if the disk or any of its partitions is in use
+func (d Disk) InUse() bool {
+	return len(d.Usage) > 0
+}
+
+// SSD returns true for non rotational disks
+func (d Disk) SSD() bool {
+	return !d.Rotational
+}
+
+type jBlockDevice struct {
+	Path        string         `json:"path"`
+	Type        string         `json:"type"`
+	Size        uint64         `json:"size"`
+	Vendor      string         `json:"vendor"`
+	Model       string         `json:"model"`
+	Serial      string         `json:"serial"`
+	WWN         string         `json:"wwn"`
+	Tran        string         `json:"tran"`
+	RO          bool           `json:"ro"`
+	RM          bool           `json:"rm"`
+	Rota        bool           `json:"rota"`
+	PTType      string         `json:"pttype"`
+	FS          string         `json:"fstype"`
+	MountPoints []string       `json:"mountpoints"`
+	Children    []jBlockDevice `json:"children"`
+}
+
+// DiscoverDisks returns the whole disks of the host, virtual zram devices are left out
+func DiscoverDisks(s *sys.System) ([]Disk, error) {
+	out, err := s.Runner().Run(
+		"lsblk", "-p", "-b", "-J", "-o",
+		"PATH,TYPE,SIZE,VENDOR,MODEL,SERIAL,WWN,TRAN,RO,RM,ROTA,PTTYPE,FSTYPE,MOUNTPOINTS",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing disks: %w", err)
+	}
+
+	devices := struct {
+		BlockDevices []jBlockDevice `json:"blockdevices"`
+	}{}
+	err = json.Unmarshal(out, &devices)
+	if err != nil {
+		return nil, fmt.Errorf("parsing lsblk output: %w", err)
+	}
+
+	disks := []Disk{}
+	for _, jd := range devices.BlockDevices {
+		if jd.Type != "disk" || jd.Size == 0 || strings.HasPrefix(filepath.Base(jd.Path), "zram") {
+			continue
+		}
+		d := Disk{
+			Path:           jd.Path,
+			Size:           jd.Size,
+			Vendor:         strings.TrimSpace(jd.Vendor),
+			Model:          strings.TrimSpace(jd.Model),
+			Serial:         strings.TrimSpace(jd.Serial),
+			WWN:            strings.TrimSpace(jd.WWN),
+			Transport:      jd.Tran,
+			Rotational:     jd.Rota,
+			Removable:      jd.RM,
+			ReadOnly:       jd.RO,
+			PartitionTable: jd.PTType,
+		}
+		d.Usage = deviceUsage(jd, []string{})
+		disks = append(disks, d)
+	}
+	return disks, nil
+}
+
+// ListDisks returns the writable whole disks of the host which are not in use
+func ListDisks(s *sys.System) ([]Disk, error) {
+	disks, err := DiscoverDisks(s)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(disks, func(d Disk) bool { return d.ReadOnly || d.InUse() }), nil
+}
+
+// deviceUsage appends to the given usage list the reasons why the given device or any of its children
+// is in use
+func deviceUsage(jd jBlockDevice, usage []string) []string {
+	for _, mnt := range jd.MountPoints {
+		switch mnt {
+		case "":
+		case swapMount:
+			usage = append(usage, fmt.Sprintf("%s is an active swap device", jd.Path))
+		default:
+			usage = append(usage, fmt.Sprintf("%s is mounted at %s", jd.Path, mnt))
+		}
+	}
+	switch {
+	case jd.FS == lvmMemberFS:
+		usage = append(usage, fmt.Sprintf("%s is an LVM physical volume", jd.Path))
+	case strings.HasSuffix(jd.FS, raidMemberSuffix):
+		usage = append(usage, fmt.Sprintf("%s is a RAID member", jd.Path))
+	}
+	for _, child := range jd.Children {
+		if child.Type == "crypt" {
+			usage = append(usage, fmt.Sprintf("%s is an open encrypted volume", child.Path))
+		}
+		usage = deviceUsage(child, usage)
+	}
+	return usage
+}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package block_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+)
+
+const treeLsblk = `{
+   "blockdevices": [
+      {"path": "/dev/sda", "type": "disk", "size": 480103981056, "vendor": "ATA     ", "model": "SAMSUNG MZ7LH480", "tran": "sata",
+       "ro": false, "rm": false, "rota": false, "pttype": "gpt", "fstype": null, "mountpoints": [null],
+       "children": [
+          {"path": "/dev/sda1", "type": "part", "size": 536870912, "fstype": "vfat", "mountpoints": ["/boot/efi"]},
+          {"path": "/dev/sda2", "type": "part", "size": 8589934592, "fstype": "swap", "mountpoints": ["[SWAP]"]},
+          {"path": "/dev/sda3", "type": "part", "size": 400000000000, "fstype": "crypto_LUKS", "mountpoints": [null],
+           "children": [
+              {"path": "/dev/mapper/data", "type": "crypt", "size": 399983222784, "fstype": "xfs", "mountpoints": [null]}
+           ]}
+       ]},
+      {"path": "/dev/sdb", "type": "disk", "size": 4000787030016, "model": "ST4000NM0035", "tran": "sas",
+       "ro": false, "rm": false, "rota": true, "pttype": null, "fstype": "LVM2_member", "mountpoints": [null],
+       "children": [
+          {"path": "/dev/mapper/vg-lv", "type": "lvm", "size": 4000783007744, "fstype": "ext4", "mountpoints": ["/srv"]}
+       ]},
+      {"path": "/dev/sdc", "type": "disk", "size": 4000787030016, "model": "ST4000NM0035", "tran": "sas",
+       "ro": false, "rm": false, "rota": true, "pttype": "dos", "fstype": null, "mountpoints": [null],
+       "children": [
+          {"path": "/dev/sdc1", "type": "part", "size": 4000786006016, "fstype": "linux_raid_member", "mountpoints": [null]}
+       ]},
+      {"path": "/dev/sdd", "type": "disk", "size": 31914983424, "model": "Ultra USB 3.0", "tran": "usb",
+       "ro": false, "rm": true, "rota": false, "pttype": null, "fstype": null, "mountpoints": [null]},
+      {"path": "/dev/zram0", "type": "disk", "size": 8589934592, "mountpoints": ["[SWAP]"]}
+   ]
+}
+`
+
+var _ = Describe("Disk discovery", Label("block", "disks"), func() {
+	var runner *sysmock.Runner
+	var s *sys.System
+	var cleanup func()
+
+	BeforeEach(func() {
+		runner = sysmock.NewRunner()
+		fs, c, err := sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		cleanup = c
+		s, err = sys.NewSystem(
+			sys.WithFS(fs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		runner.SideEffect = func(cmd string, _ ...string) ([]byte, error) {
+			if cmd == "lsblk" {
+				return []byte(treeLsblk), nil
+			}
+			return []byte{}, nil
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("reports the properties and usage of the disks", func() {
+		disks, err := block.DiscoverDisks(s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(disks).To(HaveLen(4))
+
+		Expect(disks[0].Path).To(Equal("/dev/sda"))
+		Expect(disks[0].SSD()).To(BeTrue())
+		Expect(disks[0].Transport).To(Equal("sata"))
+		Expect(disks[0].PartitionTable).To(Equal("gpt"))
+		Expect(disks[0].Usage).To(Equal([]string{
+			"/dev/sda1 is mounted at /boot/efi",
+			"/dev/sda2 is an active swap device",
+			"/dev/mapper/data is an open encrypted volume",
+		}))
+
+		Expect(disks[1].SSD()).To(BeFalse())
+		Expect(disks[1].PartitionTable).To(BeEmpty())
+		Expect(disks[1].Usage).To(Equal([]string{
+			"/dev/sdb is an LVM physical volume",
+			"/dev/mapper/vg-lv is mounted at /srv",
+		}))
+
+		Expect(disks[2].PartitionTable).To(Equal("dos"))
+		Expect(disks[2].Usage).To(Equal([]string{"/dev/sdc1 is a RAID member"}))
+
+		Expect(disks[3].Removable).To(BeTrue())
+		Expect(disks[3].InUse()).To(BeFalse())
+	})
+	It("lists only the disks available for selection", func() {
+		disks, err := block.ListDisks(s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(disks).To(HaveLen(1))
+		Expect(disks[0].Path).To(Equal("/dev/sdd"))
+	})
+})
@@ -40,6 +40,7 @@ type jPart struct {
 	Label       string   `json:"label,omitempty"`
 	Name        string   `json:"partlabel,omitempty"`
 	UUID        string   `json:"partuuid,omitempty"`
+	PartType    string   `json:"parttype,omitempty"`
 	Size        uint64   `json:"size,omitempty"`
 	FS          string   `json:"fstype,omitempty"`
 	MountPoints []string `json:"mountpoints,omitempty"`
@@ -57,6 +58,7 @@ func (p jPart) Partition() *block.Partition {
 		Size:        uint(p.Size / (1024 * 1024)),
 		FileSystem:  p.FS,
 		UUID:        p.UUID,
+		Type:        p.PartType,
 		Flags:       []string{},
 		MountPoints: p.MountPoints,
 		Path:        p.Path,
@@ -131,7 +133,7 @@ func unmarshalSectorSize(lsblkOut []byte) (uint, error) {
 // GetAllPartitions gets a slice of all partition devices found in the host
 // mapped into a v1.PartitionList object.
 func (l lsDevice) GetAllPartitions() (block.PartitionList, error) {
-	out, err := l.runner.Run("lsblk", "-p", "-b", "-n", "-J", "--output", "LABEL,PARTLABEL,PARTUUID,SIZE,FSTYPE,MOUNTPOINTS,PATH,PKNAME,TYPE")
+	out, err := l.runner.Run("lsblk", "-p", "-b", "-n", "-J", "--output", "LABEL,PARTLABEL,PARTUUID,PARTTYPE,SIZE,FSTYPE,MOUNTPOINTS,PATH,PKNAME,TYPE")
 	if err != nil {
 		return nil, err
 	}
@@ -143,7 +145,7 @@ func (l lsDevice) GetAllPartitions() (block.PartitionList, error) {
 // into a v1.PartitionList object. If the device is a disk it will list all disk
 // partitions, if the device is already a partition it will simply list a single partition.
 func (l lsDevice) GetDevicePartitions(device string) (block.PartitionList, error) {
-	out, err := l.runner.Run("lsblk", "-p", "-b", "-n", "-J", "--output", "LABEL,PARTLABEL,PARTUUID,SIZE,FSTYPE,MOUNTPOINTS,PATH,PKNAME,TYPE", device)
+	out, err := l.runner.Run("lsblk", "-p", "-b", "-n", "-J", "--output", "LABEL,PARTLABEL,PARTUUID,PARTTYPE,SIZE,FSTYPE,MOUNTPOINTS,PATH,PKNAME,TYPE", device)
 	if err != nil {
 		return nil, err
 	}
@@ -84,6 +84,7 @@ const partsPortionLslbkOut = `,{
          "label": "STATE",
          "partlabel": "state",
          "uuid": "34a8abb8-ddb3-48a2-8ecc-2443e92c7510",
+         "parttype": "0fc63daf-8483-4772-8e79-3d69d8477de4",
          "size": 351333777408,
          "fstype": "btrfs",
          "mountpoints": [
@@ -166,6 +167,7 @@ var _ = Describe("BlockDevice", Label("lsblk"), func() {
 			part := pl.GetByLabel("STATE")
 			Expect(part).NotTo(BeNil())
 			Expect(part.Path).To(Equal("/dev/sda2"))
+			Expect(part.Type).To(Equal("0fc63daf-8483-4772-8e79-3d69d8477de4"))
 			part = pl.GetByName("persistent")
 			Expect(part).NotTo(BeNil())
 			Expect(part.FileSystem).To(Equal("xfs"))
@@ -0,0 +1,190 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package block
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+const (
+	SelectLargest  = "largest"
+	SelectSmallest = "smallest"
+
+	selectModel   = "model"
+	selectVendor  = "vendor"
+	selectSerial  = "serial"
+	selectWWN     = "wwn"
+	selectMinSize = "minsize"
+	selectMaxSize = "maxsize"
+)
+
+// DiskSelector selects a disk of the host by its properties instead of its device path. It is a comma
+// separated list of terms, all of them must match:
+//
+//   - 'model:<glob>', 'vendor:<glob>', 'serial:<glob>' and 'wwn:<glob>' match disk properties, case insensitive
+//   - 'minsize:<size>' and 'maxsize:<size>' bound the disk size, in bytes or with a K, M, G or T binary suffix
+//   - 'largest' and 'smallest' pick the largest or smallest of the matching disks
+//
+// Without 'largest' or 'smallest' the first matching disk in path order is picked.
+type DiskSelector struct {
+	filters []func(Disk) bool
+	order   string
+}
+
+// IsDiskSelector returns true if the given device is a disk selector rather than a device path
+func IsDiskSelector(device string) bool {
+	return device != "" && !filepath.IsAbs(device)
+}
+
+// ParseDiskSelector parses the given disk selector
+func ParseDiskSelector(selector string) (*DiskSelector, error) {
+	ds := &DiskSelector{}
+	for term := range strings.SplitSeq(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == SelectLargest || term == SelectSmallest {
+			if ds.order != "" {
+				return nil, fmt.Errorf("invalid disk selector '%s': '%s' and '%s' are exclusive", selector, SelectLargest, SelectSmallest)
+			}
+			ds.order = term
+			continue
+		}
+
+		key, value, ok := strings.Cut(term, ":")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid disk selector term '%s'", term)
+		}
+		switch key {
+		case selectModel, selectVendor, selectSerial, selectWWN:
+			pattern := strings.ToLower(value)
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern in disk selector term '%s': %w", term, err)
+			}
+			ds.filters = append(ds.filters, func(d Disk) bool {
+				ok, _ := filepath.Match(pattern, strings.ToLower(diskProperty(d, key)))
+				return ok
+			})
+		case selectMinSize, selectMaxSize:
+			size, err := parseSize(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid size in disk selector term '%s': %w", term, err)
+			}
+			ds.filters = append(ds.filters, func(d Disk) bool {
+				if key == selectMinSize {
+					return d.Size >= size
+				}
+				return d.Size <= size
+			})
+		default:
+			return nil, fmt.Errorf("unknown disk selector term '%s'", term)
+		}
+	}
+	return ds, nil
+}
+
+// Select returns the disk matching the selector out of the given disks, excluding the disks with
+// any of the given paths
+func (ds DiskSelector) Select(disks []Disk, exclude ...string) (*Disk, error) {
+	var matches []Disk
+	for _, d := range disks {
+		if slices.Contains(exclude, d.Path) {
+			continue
+		}
+		if !slices.ContainsFunc(ds.filters, func(f func(Disk) bool) bool { return !f(d) }) {
+			matches = append(matches, d)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no disk matches the selector")
+	}
+
+	slices.SortStableFunc(matches, func(a, b Disk) int { return strings.Compare(a.Path, b.Path) })
+	switch ds.order {
+	case SelectLargest:
+		slices.SortStableFunc(matches, func(a, b Disk) int { return compareSize(b, a) })
+	case SelectSmallest:
+		slices.SortStableFunc(matches, compareSize)
+	}
+	return &matches[0], nil
+}
+
+// SelectDisk resolves the given disk selector against the disks of the host and returns the path of
+// the selected disk. Disks with any of the given paths are excluded, so multiple selectors of a
+// deployment can resolve to different disks.
+func SelectDisk(s *sys.System, selector string, exclude ...string) (string, error) {
+	ds, err := ParseDiskSelector(selector)
+	if err != nil {
+		return "", err
+	}
+	disks, err := ListDisks(s)
+	if err != nil {
+		return "", err
+	}
+	disk, err := ds.Select(disks, exclude...)
+	if err != nil {
+		return "", fmt.Errorf("selecting disk '%s': %w", selector, err)
+	}
+	s.Logger().Info("Disk selector '%s' resolved to '%s' (%s %s, %d bytes)", selector, disk.Path, disk.Vendor, disk.Model, disk.Size)
+	return disk.Path, nil
+}
+
+func diskProperty(d Disk, key string) string {
+	switch key {
+	case selectModel:
+		return d.Model
+	case selectVendor:
+		return d.Vendor
+	case selectSerial:
+		return d.Serial
+	default:
+		return d.WWN
+	}
+}
+
+func compareSize(a, b Disk) int {
+	switch {
+	case a.Size < b.Size:
+		return -1
+	case a.Size > b.Size:
+		return 1
+	}
+	return 0
+}
+
+// parseSize parses a size in bytes with an optional K, M, G or T binary suffix
+func parseSize(value string) (uint64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	shift := 0
+	for i, suffix := range []string{"K", "M", "G", "T"} {
+		if v, ok := strings.CutSuffix(value, suffix); ok {
+			value = v
+			shift = 10 * (i + 1)
+			break
+		}
+	}
+	size, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return size << shift, nil
+}
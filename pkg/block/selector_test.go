@@ -0,0 +1,111 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package block_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+)
+
+const disksLsblk = `{
+   "blockdevices": [
+      {"path": "/dev/sdb", "type": "disk", "size": 4000787030016, "vendor": "ATA     ", "model": "ST4000NM0035    ", "serial": "ZC1A2B3C", "wwn": "0x5000c500a1b2c3d4", "ro": false},
+      {"path": "/dev/sda", "type": "disk", "size": 480103981056, "vendor": "ATA     ", "model": "SAMSUNG MZ7LH480", "serial": "S45PNA0M", "wwn": "0x5002538e4a1b2c3d", "ro": false},
+      {"path": "/dev/nvme0n1", "type": "disk", "size": 1000204886016, "vendor": null, "model": "Samsung SSD 980 PRO 1TB", "serial": "S5GXNF0R", "wwn": "eui.002538b111b2c3d4", "ro": false},
+      {"path": "/dev/sr0", "type": "rom", "size": 1073741312, "vendor": "QEMU", "model": "QEMU DVD-ROM", "serial": null, "wwn": null, "ro": true},
+      {"path": "/dev/zram0", "type": "disk", "size": 8589934592, "vendor": null, "model": null, "serial": null, "wwn": null, "ro": false}
+   ]
+}
+`
+
+var _ = Describe("Disk selectors", Label("block", "selector"), func() {
+	var runner *sysmock.Runner
+	var s *sys.System
+	var cleanup func()
+
+	BeforeEach(func() {
+		runner = sysmock.NewRunner()
+		fs, c, err := sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		cleanup = c
+		s, err = sys.NewSystem(
+			sys.WithFS(fs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		runner.SideEffect = func(cmd string, _ ...string) ([]byte, error) {
+			if cmd == "lsblk" {
+				return []byte(disksLsblk), nil
+			}
+			return []byte{}, nil
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("tells selectors from device paths", func() {
+		Expect(block.IsDiskSelector("largest")).To(BeTrue())
+		Expect(block.IsDiskSelector("model:Samsung*")).To(BeTrue())
+		Expect(block.IsDiskSelector("/dev/sda")).To(BeFalse())
+		Expect(block.IsDiskSelector("")).To(BeFalse())
+	})
+	It("lists the writable whole disks", func() {
+		disks, err := block.ListDisks(s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(disks).To(HaveLen(3))
+		Expect(disks[0].Vendor).To(Equal("ATA"))
+		Expect(disks[0].Model).To(Equal("ST4000NM0035"))
+	})
+	DescribeTable("selects disks by their properties",
+		func(selector, device string) {
+			Expect(block.SelectDisk(s, selector)).To(Equal(device))
+		},
+		Entry("largest", "largest", "/dev/sdb"),
+		Entry("smallest", "smallest", "/dev/sda"),
+		Entry("first in path order", "vendor:*", "/dev/nvme0n1"),
+		Entry("model glob", "model:samsung*,largest", "/dev/nvme0n1"),
+		Entry("serial", "serial:S45PNA0M", "/dev/sda"),
+		Entry("wwn", "wwn:0x5000c500*", "/dev/sdb"),
+		Entry("size bounds", "minsize:500G,maxsize:2T", "/dev/nvme0n1"),
+	)
+	It("excludes the given disks", func() {
+		Expect(block.SelectDisk(s, "largest", "/dev/sdb")).To(Equal("/dev/nvme0n1"))
+	})
+	It("fails if no disk matches", func() {
+		_, err := block.SelectDisk(s, "model:INTEL*")
+		Expect(err).To(MatchError("selecting disk 'model:INTEL*': no disk matches the selector"))
+	})
+	It("fails on invalid selectors", func() {
+		for selector, msg := range map[string]string{
+			"biggest":          "invalid disk selector term 'biggest'",
+			"label:DATA":       "unknown disk selector term 'label:DATA'",
+			"minsize:lots":     "invalid size in disk selector term 'minsize:lots'",
+			"model:[":          "invalid pattern in disk selector term 'model:['",
+			"largest,smallest": "'largest' and 'smallest' are exclusive",
+		} {
+			_, err := block.ParseDiskSelector(selector)
+			Expect(err).To(MatchError(ContainSubstring(msg)), selector)
+		}
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+})
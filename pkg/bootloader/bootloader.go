@@ -18,6 +18,7 @@ limitations under the License.
 package bootloader
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -28,6 +29,19 @@ type Bootloader interface {
 	Install(i InstallCtx) error
 	InstallLive(i InstallCtx) error
 	Prune(rootPath, espDir string, keepEntryIDs []int) error
+	GetBootEntry(espDir, entryID string) (BootEntry, error)
+}
+
+// BootEntry describes the artifacts and kernel command line booted by a bootloader entry
+type BootEntry struct {
+	// Kernel is the full path of the kernel image booted by the entry.
+	Kernel string
+
+	// Initrd is the full path of the initrd booted by the entry.
+	Initrd string
+
+	// KernelCmdline is the kernel command line set for the entry.
+	KernelCmdline string
 }
 
 // InstallCtx defines the parameters requierd by the bootloader to perform an installation
@@ -56,11 +70,18 @@ type InstallCtx struct {
 	// InitrdExtensions is the list of CPIO files to stack into the stock initrd. These CPIO files are mostly
 	// used to inject additional setup into the stock initrd.
 	InitrdExtensions []string
+	// BootTries is the number of attempts to boot the installed entry before the bootloader falls
+	// back to the previous default entry, zero disables the trial boot. A completed boot flags the
+	// entry as successful and clears the trial variables.
+	BootTries int
 }
 
 const (
 	BootNone = "none"
 	BootGrub = "grub"
+
+	// MaxBootTries is the maximum number of attempts to boot a trial entry
+	MaxBootTries = 9
 )
 
 type None struct {
@@ -86,12 +107,16 @@ func (n *None) Prune(_, _ string, _ []int) error {
 	return nil
 }
 
-func New(name string, s *sys.System) (Bootloader, error) {
+func (n *None) GetBootEntry(_, entryID string) (BootEntry, error) {
+	return BootEntry{}, fmt.Errorf("boot entry '%s': %w", entryID, errors.ErrUnsupported)
+}
+
+func New(ctx context.Context, name string, s *sys.System) (Bootloader, error) {
 	switch name {
 	case BootNone:
 		return NewNone(s), nil
 	case BootGrub:
-		return NewGrub(s), nil
+		return NewGrub(s, WithContext(ctx)), nil
 	}
 
 	return nil, fmt.Errorf("new bootloader '%s': %w", name, errors.ErrUnsupported)
@@ -18,6 +18,7 @@ limitations under the License.
 package bootloader_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -46,13 +47,13 @@ var _ = Describe("Bootloader tests", Label("bootloader", "grub", "none"), func()
 	})
 	It("Successfully creates a new bootloader", func() {
 		for _, name := range []string{"none", "grub"} {
-			b, err := bootloader.New(name, s)
+			b, err := bootloader.New(context.Background(), name, s)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(b).NotTo(BeNil())
 		}
 	})
 	It("New() returns unsupported error for unknown bootloader", func() {
-		b, err := bootloader.New("bogus", s)
+		b, err := bootloader.New(context.Background(), "bogus", s)
 		Expect(b).To(BeNil())
 		Expect(err).To(HaveOccurred())
 		Expect(errors.Is(err, errors.ErrUnsupported)).To(BeTrue(), err.Error())
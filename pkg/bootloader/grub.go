@@ -18,6 +18,8 @@ limitations under the License.
 package bootloader
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	_ "embed"
 	"encoding/hex"
@@ -38,10 +40,25 @@ import (
 
 const grubImg = "grub.efi"
 
+const (
+	// BIOSLiveImage is the El Torito BIOS boot image of live media, relative to the media root
+	BIOSLiveImage = "boot/grub2/" + grubBIOSPlatform + "/eltorito.img"
+	// BIOSHybridMBR is the MBR boot code of hybrid live media, relative to the media root
+	BIOSHybridMBR = "boot/grub2/" + grubBIOSPlatform + "/boot_hybrid.img"
+
+	grubBIOSPlatform = "i386-pc"
+)
+
+// grubBIOSModules are the modules embedded in the BIOS boot image to find and load the live media configuration
+var grubBIOSModules = []string{
+	"biosdisk", "iso9660", "part_msdos", "part_gpt", "search", "configfile", "normal", "linux", "echo", "test",
+}
+
 var _ Bootloader = (*Grub)(nil)
 
 type Grub struct {
-	s *sys.System
+	s   *sys.System
+	ctx context.Context
 }
 
 type grubBootEntry struct {
@@ -54,8 +71,15 @@ type grubBootEntry struct {
 
 type Option func(*Grub)
 
+// WithContext sets the context used to abort copying the kernel and initrd files
+func WithContext(ctx context.Context) Option {
+	return func(g *Grub) {
+		g.ctx = ctx
+	}
+}
+
 func NewGrub(s *sys.System, opts ...Option) *Grub {
-	g := &Grub{s}
+	g := &Grub{s: s, ctx: context.Background()}
 
 	for _, opt := range opts {
 		opt(g)
@@ -69,9 +93,17 @@ const (
 	Initrd         = "initrd"
 	DefaultBootID  = "active"
 	RecoveryBootID = "recovery"
+	// BootCompleteUnit is the systemd unit marking the trial boot of a new boot entry as completed
+	BootCompleteUnit = "elemental-boot-complete.service"
 
 	liveBootPath = "/boot"
 	grubEnvFile  = "grubenv"
+
+	defaultEntryVar  = "default_entry"
+	trialEntryVar    = "trial_entry"
+	bootCounterVar   = "boot_counter"
+	bootSuccessVar   = "boot_success"
+	fallbackEntryVar = "fallback_entry"
 )
 
 //go:embed grubtemplates/grub.cfg
@@ -83,6 +115,9 @@ var grubLiveEFICfg []byte
 //go:embed grubtemplates/grub_live.cfg
 var grubLiveCfg []byte
 
+//go:embed grubtemplates/elemental-boot-complete.service
+var bootCompleteUnit []byte
+
 // InstallLive installs the live bootloader to the specified target.
 func (g *Grub) InstallLive(i InstallCtx) error {
 	g.s.Logger().Info("Preparing GRUB bootloader for live media")
@@ -122,11 +157,46 @@ func (g *Grub) InstallLive(i InstallCtx) error {
 		return fmt.Errorf("installing elemental EFI apps: %w", err)
 	}
 
+	err = g.installBIOSLive(i.Target, data)
+	if err != nil {
+		return fmt.Errorf("installing BIOS boot image: %w", err)
+	}
+
+	return nil
+}
+
+// installBIOSLive creates the El Torito BIOS boot image of live media. It is only created if the
+// OS provides the GRUB BIOS platform, otherwise the media is UEFI only.
+func (g *Grub) installBIOSLive(target string, data map[string]string) error {
+	platformDir := filepath.Join(target, liveBootPath, "grub2", grubBIOSPlatform)
+	if ok, _ := vfs.Exists(g.s.FS(), filepath.Join(platformDir, "kernel.img")); !ok {
+		g.s.Logger().Info("GRUB %s platform not found, skipping BIOS boot setup", grubBIOSPlatform)
+		return nil
+	}
+
+	// The embedded configuration finds the media root the same way the EFI configuration does
+	err := g.writeGrubConfig(platformDir, grubLiveEFICfg, data)
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"-O", grubBIOSPlatform + "-eltorito", "-d", platformDir, "-p", filepath.Join(liveBootPath, "grub2"),
+		"-c", filepath.Join(platformDir, "grub.cfg"), "-o", filepath.Join(target, BIOSLiveImage),
+	}
+	_, err = g.s.Runner().Run("grub2-mkimage", append(args, grubBIOSModules...)...)
+	if err != nil {
+		return fmt.Errorf("creating El Torito image: %w", err)
+	}
 	return nil
 }
 
 // Install installs the bootloader to the specified root.
 func (g *Grub) Install(i InstallCtx) error {
+	if i.BootTries > MaxBootTries {
+		return fmt.Errorf("boot tries %d exceed the maximum of %d", i.BootTries, MaxBootTries)
+	}
+
 	err := g.installElementalEFI(i.RootDir, i.Target, i.ESPLabel)
 	if err != nil {
 		return fmt.Errorf("installing elemental EFI apps: %w", err)
@@ -169,14 +239,143 @@ func (g *Grub) Install(i InstallCtx) error {
 		entries = append(entries, &recoveryEntry)
 	}
 
+	fallback := ""
+	if i.BootTries > 0 {
+		fallback, err = g.fallbackEntry(i.Target)
+		if err != nil {
+			return fmt.Errorf("finding fallback boot entry: %w", err)
+		}
+	}
+
 	err = g.updateBootEntries(i.Target, entries...)
 	if err != nil {
 		return fmt.Errorf("updating boot entries: %w", err)
 	}
 
+	if fallback != "" {
+		err = g.installBootCompleteUnit(i.RootDir, i.Target)
+		if err != nil {
+			return fmt.Errorf("installing boot complete unit: %w", err)
+		}
+		err = g.setTrialBoot(i.Target, i.EntryID, fallback, i.BootTries)
+		if err != nil {
+			return fmt.Errorf("setting trial boot: %w", err)
+		}
+	} else if i.BootTries > 0 {
+		g.s.Logger().Warn("No previous boot entry to fall back to, skipping trial boot")
+	}
+
 	return nil
 }
 
+// fallbackEntry returns the entry booted by default before installing a new one. That is the
+// pinned default entry, if any, or the most recent snapshot entry.
+func (g *Grub) fallbackEntry(espDir string) (string, error) {
+	grubEnvPath := filepath.Join(espDir, grubEnvFile)
+	if ok, _ := vfs.Exists(g.s.FS(), grubEnvPath); !ok {
+		return "", nil
+	}
+
+	grubEnv, err := g.readGrubEnv(grubEnvPath)
+	if err != nil {
+		return "", err
+	}
+
+	if grubEnv[defaultEntryVar] != "" {
+		return grubEnv[defaultEntryVar], nil
+	}
+
+	for _, entry := range strings.Fields(grubEnv["entries"]) {
+		if entry != DefaultBootID && entry != RecoveryBootID {
+			return entry, nil
+		}
+	}
+	return "", nil
+}
+
+// installBootCompleteUnit installs and enables in rootPath the systemd unit clearing the trial boot
+// variables once the boot is completed. The unit disables itself after running.
+func (g *Grub) installBootCompleteUnit(rootPath, espDir string) error {
+	unitDir := filepath.Join(rootPath, "etc", "systemd", "system")
+	wantsDir := filepath.Join(unitDir, "multi-user.target.wants")
+	err := vfs.MkdirAll(g.s.FS(), wantsDir, vfs.DirPerm)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	unit := template.Must(template.New("unit").Parse(string(bootCompleteUnit)))
+	err = unit.Execute(&buf, map[string]string{
+		"GrubEnv": filepath.Join("/", strings.TrimPrefix(espDir, rootPath), grubEnvFile),
+	})
+	if err != nil {
+		return err
+	}
+
+	unitFile := filepath.Join(unitDir, BootCompleteUnit)
+	err = g.s.FS().WriteFile(unitFile, buf.Bytes(), vfs.FilePerm)
+	if err != nil {
+		return err
+	}
+
+	link := filepath.Join(wantsDir, BootCompleteUnit)
+	_ = g.s.FS().Remove(link)
+	return g.s.FS().Symlink(filepath.Join("/etc/systemd/system", BootCompleteUnit), link)
+}
+
+// setTrialBoot sets the given entry to be booted up to the given number of tries before falling
+// back to the fallback entry
+func (g *Grub) setTrialBoot(espDir, entryID, fallback string, tries int) error {
+	g.s.Logger().Info("Setting %d trial boots of entry '%s' falling back to '%s'", tries, entryID, fallback)
+
+	grubEnvPath := filepath.Join(espDir, grubEnvFile)
+	_, err := g.s.Runner().Run("grub2-editenv", grubEnvPath, "unset", defaultEntryVar)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.s.Runner().Run(
+		"grub2-editenv", grubEnvPath, "set",
+		fmt.Sprintf("%s=%s", trialEntryVar, entryID), fmt.Sprintf("%s=%s", fallbackEntryVar, fallback),
+		fmt.Sprintf("%s=%d", bootCounterVar, tries), fmt.Sprintf("%s=0", bootSuccessVar),
+	)
+	return err
+}
+
+// TrialState is the state of the trial boot of a grub boot entry
+type TrialState struct {
+	// Entry is the boot entry on trial, empty if no trial boot is pending
+	Entry string
+	// Fallback is the boot entry grub falls back to when the trial boot fails
+	Fallback string
+	// Completed is true if the last trial boot completed
+	Completed bool
+	// Failed is true if the tries of the last trial boot were exhausted and grub fell back
+	Failed bool
+}
+
+// ReadTrialState reads the trial boot state from the grub environment of the given EFI directory. A
+// missing grub environment reports no trial boot.
+func ReadTrialState(s *sys.System, espDir string) (TrialState, error) {
+	grubEnvPath := filepath.Join(espDir, grubEnvFile)
+	if ok, _ := vfs.Exists(s.FS(), grubEnvPath); !ok {
+		return TrialState{}, nil
+	}
+	grubEnv, err := NewGrub(s).readGrubEnv(grubEnvPath)
+	if err != nil {
+		return TrialState{}, err
+	}
+
+	state := TrialState{
+		Entry:     grubEnv[trialEntryVar],
+		Fallback:  grubEnv[fallbackEntryVar],
+		Completed: grubEnv[bootSuccessVar] == "1",
+	}
+	// grub clears the trial entry but keeps the fallback entry once the tries are exhausted
+	state.Failed = !state.Completed && state.Entry == "" && state.Fallback != ""
+	return state, nil
+}
+
 // Prune prunes old boot entries and artifacts not in the passed in keepSnapshotIDs.
 func (g Grub) Prune(rootPath, espDir string, keepSnapshotIDs []int) (err error) {
 	g.s.Logger().Info("Pruning old boot artifacts in %s", espDir)
@@ -320,22 +519,18 @@ func (g Grub) writeGrubConfig(targetDir string, cfgTemplate []byte, data any) er
 	}
 
 	gCfg := filepath.Join(targetDir, "grub.cfg")
-	f, err := g.s.FS().Create(gCfg)
-	if err != nil {
-		return fmt.Errorf("failed creating bootloader config file %s: %w", gCfg, err)
-	}
 
+	var buf bytes.Buffer
 	gcfg := template.New("grub")
 	gcfg = template.Must(gcfg.Parse(string(cfgTemplate)))
-	err = gcfg.Execute(f, data)
+	err = gcfg.Execute(&buf, data)
 	if err != nil {
-		_ = f.Close()
 		return fmt.Errorf("failed rendering bootloader config file: %w", err)
 	}
 
-	err = f.Close()
+	err = vfs.WriteFileAtomic(g.s.FS(), gCfg, buf.Bytes(), vfs.FilePerm)
 	if err != nil {
-		return fmt.Errorf("failed closing bootloader config file %s: %w", gCfg, err)
+		return fmt.Errorf("failed writing bootloader config file %s: %w", gCfg, err)
 	}
 	return nil
 }
@@ -368,14 +563,14 @@ func (g *Grub) installEFIEntry(rootPath, targetDir string, grubTmpl []byte, data
 	for _, name := range bootFiles(g.s.Platform().Arch) {
 		src := filepath.Join(srcDir, name)
 		target := filepath.Join(targetDir, name)
-		err = vfs.CopyFile(g.s.FS(), src, target)
+		err = vfs.CopyFileContext(g.ctx, g.s.FS(), src, target)
 		if err != nil {
 			return fmt.Errorf("copying file '%s': %w", src, err)
 		}
 	}
 
 	src, target := defaultEfiBootFileName(g.s.Platform())
-	err = vfs.CopyFile(g.s.FS(), filepath.Join(srcDir, src), filepath.Join(targetDir, target))
+	err = vfs.CopyFileContext(g.ctx, g.s.FS(), filepath.Join(srcDir, src), filepath.Join(targetDir, target))
 	if err != nil {
 		return fmt.Errorf("copying file '%s': %w", src, err)
 	}
@@ -502,7 +697,7 @@ func (g *Grub) installKernelInitrd(rootPath, espDir, subfolder string, extension
 		return entry, fmt.Errorf("creating kernel dir '%s': %w", targetDir, err)
 	}
 
-	err = vfs.CopyFile(g.s.FS(), kernel, targetDir)
+	err = vfs.CopyFileContext(g.ctx, g.s.FS(), kernel, targetDir)
 	if err != nil {
 		return entry, fmt.Errorf("copying kernel '%s': %w", kernel, err)
 	}
@@ -513,7 +708,7 @@ func (g *Grub) installKernelInitrd(rootPath, espDir, subfolder string, extension
 		return entry, fmt.Errorf("finding kernel hmac '%s': %w", kernel, err)
 	}
 
-	err = vfs.CopyFile(g.s.FS(), kernelHmac, targetDir)
+	err = vfs.CopyFileContext(g.ctx, g.s.FS(), kernelHmac, targetDir)
 	if err != nil {
 		return entry, fmt.Errorf("copying kernel hmac '%s': %w", kernelHmac, err)
 	}
@@ -524,7 +719,7 @@ func (g *Grub) installKernelInitrd(rootPath, espDir, subfolder string, extension
 	}
 
 	g.s.Logger().Debug("Concatenating extensions %v and initrd %q", extensions, initrdPath)
-	err = vfs.ConcatFiles(g.s.FS(), append(extensions, initrdPath), filepath.Join(targetDir, Initrd))
+	err = vfs.ConcatFilesContext(g.ctx, g.s.FS(), append(extensions, initrdPath), filepath.Join(targetDir, Initrd))
 	if err != nil {
 		return entry, fmt.Errorf("copying initrd '%s': %w", initrdPath, err)
 	}
@@ -535,6 +730,29 @@ func (g *Grub) installKernelInitrd(rootPath, espDir, subfolder string, extension
 	return entry, nil
 }
 
+// GetBootEntry returns the kernel, initrd and kernel command line of the given boot entry installed in espDir.
+func (g *Grub) GetBootEntry(espDir, entryID string) (BootEntry, error) {
+	entryPath := filepath.Join(espDir, "loader", "entries", entryID)
+	if ok, _ := vfs.Exists(g.s.FS(), entryPath); !ok {
+		return BootEntry{}, fmt.Errorf("boot entry '%s' not found", entryID)
+	}
+
+	vars, err := g.readGrubEnv(entryPath)
+	if err != nil {
+		return BootEntry{}, fmt.Errorf("reading boot entry '%s': %w", entryID, err)
+	}
+
+	if vars["linux"] == "" || vars["initrd"] == "" {
+		return BootEntry{}, fmt.Errorf("boot entry '%s' has no kernel or initrd defined", entryID)
+	}
+
+	return BootEntry{
+		Kernel:        filepath.Join(espDir, vars["linux"]),
+		Initrd:        filepath.Join(espDir, vars["initrd"]),
+		KernelCmdline: vars["cmdline"],
+	}, nil
+}
+
 func (g *Grub) readGrubEnv(path string) (map[string]string, error) {
 	stdOut, err := g.s.Runner().Run("grub2-editenv", path, "list")
 	if err != nil {
@@ -80,7 +80,7 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 					return tfs.ReadFile(path)
 				}
 				return nil, nil
-			case "rsync":
+			case "rsync", "grub2-mkimage":
 				return nil, nil
 			}
 
@@ -171,6 +171,21 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 		Expect(vfs.Exists(tfs, "/iso/dir/EFI/BOOT/grub.cfg")).To(BeTrue())
 		Expect(vfs.Exists(tfs, "/iso/dir/boot/grub2/grub.cfg")).To(BeTrue())
 	})
+	It("Installs grub BIOS boot image for LiveOS image", func() {
+		i.Target = "/iso/dir"
+		Expect(vfs.MkdirAll(tfs, "/iso/dir/boot/grub2/i386-pc", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile("/iso/dir/boot/grub2/i386-pc/kernel.img", []byte("kernel.img"), vfs.FilePerm)).To(Succeed())
+
+		Expect(grub.InstallLive(i)).To(Succeed())
+
+		Expect(runner.MatchMilestones([][]string{{
+			"grub2-mkimage", "-O", "i386-pc-eltorito", "-d", "/iso/dir/boot/grub2/i386-pc", "-p", "/boot/grub2",
+			"-c", "/iso/dir/boot/grub2/i386-pc/grub.cfg", "-o", "/iso/dir/boot/grub2/i386-pc/eltorito.img",
+		}})).To(Succeed())
+		cfg, err := tfs.ReadFile("/iso/dir/boot/grub2/i386-pc/grub.cfg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(cfg)).To(ContainSubstring("search --file --set=root /boot/"))
+	})
 	It("Fails with an error if initrd is not found", func() {
 		// Remove initrd
 		err := tfs.Remove("/target/dir/usr/lib/modules/6.14.4-1-default/initrd")
@@ -217,6 +232,49 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(string(entries)).To(Equal("entries=active 2 1 recovery"))
 	})
+	It("Sets a trial boot of the new entry falling back to the previous one", func() {
+		i.EntryID = "1"
+		i.BootTries = 3
+		Expect(grub.Install(i)).To(Succeed())
+		// Nothing to fall back to on first install
+		Expect(vfs.Exists(tfs, "/target/dir/etc/systemd/system/elemental-boot-complete.service")).To(BeFalse())
+
+		i.EntryID = "2"
+		runner.ClearCmds()
+		Expect(grub.Install(i)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"grub2-editenv", "/target/dir/boot/grubenv", "unset", "default_entry"},
+			{
+				"grub2-editenv", "/target/dir/boot/grubenv", "set", "trial_entry=2", "fallback_entry=1",
+				"boot_counter=3", "boot_success=0",
+			},
+		})).To(Succeed())
+
+		unit, err := tfs.ReadFile("/target/dir/etc/systemd/system/elemental-boot-complete.service")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(unit)).To(ContainSubstring("grub2-editenv /boot/grubenv set boot_success=1"))
+		Expect(string(unit)).To(ContainSubstring("grub2-editenv /boot/grubenv unset trial_entry boot_counter fallback_entry"))
+		link, err := tfs.Readlink("/target/dir/etc/systemd/system/multi-user.target.wants/elemental-boot-complete.service")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(link).To(HaveSuffix("/etc/systemd/system/elemental-boot-complete.service"))
+	})
+	It("Gets the artifacts of an installed boot entry", func() {
+		i.EntryID = "3"
+		i.KernelCmdline = "root=LABEL=SYSTEM rw"
+		Expect(grub.Install(i)).To(Succeed())
+
+		entry, err := grub.GetBootEntry("/target/dir/boot", "3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entry.Kernel).To(Equal("/target/dir/boot/opensuse-tumbleweed/6.14.4-1-default/vmlinuz"))
+		Expect(entry.Initrd).To(Equal("/target/dir/boot/opensuse-tumbleweed/6.14.4-1-default/initrd"))
+		Expect(entry.KernelCmdline).To(Equal("root=LABEL=SYSTEM rw"))
+		Expect(vfs.Exists(tfs, entry.Kernel)).To(BeTrue())
+		Expect(vfs.Exists(tfs, entry.Initrd)).To(BeTrue())
+	})
+	It("Fails to get a missing boot entry", func() {
+		_, err := grub.GetBootEntry("/target/dir/boot", "4")
+		Expect(err).To(MatchError("boot entry '4' not found"))
+	})
 	It("Prunes old snapshots", func() {
 		// "Install" older (6.6.99) kernel
 		Expect(vfs.MkdirAll(tfs, "/target/dir/boot/opensuse-tumbleweed/6.6.99-1-default", vfs.DirPerm)).To(Succeed())
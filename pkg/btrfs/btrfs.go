@@ -18,8 +18,12 @@ limitations under the License.
 package btrfs
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -134,3 +138,61 @@ func SetDefaultSubvolume(s *sys.System, path string) error {
 	}
 	return nil
 }
+
+// Usage returns the used and total bytes of the btrfs filesystem including the given path
+func Usage(s *sys.System, path string) (used, size uint64, err error) {
+	cmdOut, err := s.Runner().Run("btrfs", "filesystem", "usage", "--raw", path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("getting btrfs usage of '%s': %s: %w", path, string(cmdOut), err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(cmdOut))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Device size":
+			size, err = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		case "Used":
+			used, err = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("parsing btrfs usage '%s': %w", key, err)
+		}
+		if key == "Free (estimated)" {
+			// Overall section ends, per profile details follow
+			break
+		}
+	}
+	if size == 0 {
+		return 0, 0, fmt.Errorf("no device size found in btrfs usage of '%s'", path)
+	}
+	return used, size, nil
+}
+
+// CreateSwapFile creates a swap file of the given size in MiB at the given path. The file is
+// created without copy on write and fully allocated, as required to swap on btrfs.
+func CreateSwapFile(s *sys.System, path string, sizeMiB uint64) error {
+	s.Logger().Debug("Creating swap file %s of %dMiB", path, sizeMiB)
+	cmdOut, err := s.Runner().Run("btrfs", "filesystem", "mkswapfile", "--size", fmt.Sprintf("%dm", sizeMiB), path)
+	if err != nil {
+		return fmt.Errorf("creating swap file '%s': %s: %w", path, string(cmdOut), err)
+	}
+	return nil
+}
+
+// SwapFileOffset returns the physical offset of the given swap file, as expected by the resume_offset
+// kernel parameter
+func SwapFileOffset(s *sys.System, path string) (uint64, error) {
+	cmdOut, err := s.Runner().Run("btrfs", "inspect-internal", "map-swapfile", "-r", path)
+	if err != nil {
+		return 0, fmt.Errorf("mapping swap file '%s': %s: %w", path, string(cmdOut), err)
+	}
+	offset, err := strconv.ParseUint(strings.TrimSpace(string(cmdOut)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing offset of swap file '%s': %w", path, err)
+	}
+	return offset, nil
+}
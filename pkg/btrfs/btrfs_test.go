@@ -94,6 +94,25 @@ var _ = Describe("DirectoryUnpacker", Label("directory"), func() {
 			{"btrfs", "subvolume", "delete", "-c", "-R", "/path/to/subvolume"},
 		})).To(Succeed())
 	})
+	It("gets the filesystem usage", func() {
+		runner.SideEffect = func(_ string, _ ...string) ([]byte, error) {
+			return []byte(btrfsUsage), nil
+		}
+		used, size, err := btrfs.Usage(s, "/path/to/mountpoint")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(used).To(Equal(uint64(4294967296)))
+		Expect(size).To(Equal(uint64(21474836480)))
+		Expect(runner.CmdsMatch([][]string{
+			{"btrfs", "filesystem", "usage", "--raw", "/path/to/mountpoint"},
+		})).To(Succeed())
+	})
+	It("fails to get the filesystem usage of an unknown output", func() {
+		runner.SideEffect = func(_ string, _ ...string) ([]byte, error) {
+			return []byte("unexpected"), nil
+		}
+		_, _, err := btrfs.Usage(s, "/path/to/mountpoint")
+		Expect(err).To(MatchError(ContainSubstring("no device size found")))
+	})
 	It("sets a btrfs partition", func() {
 		Expect(btrfs.SetBtrfsPartition(s, "/path/to/mountpoint")).To(Succeed())
 		Expect(runner.IncludesCmds([][]string{
@@ -104,3 +123,21 @@ var _ = Describe("DirectoryUnpacker", Label("directory"), func() {
 		})).To(Succeed())
 	})
 })
+
+const btrfsUsage = `Overall:
+    Device size:                 21474836480
+    Device allocated:             6450839552
+    Device unallocated:          15023996928
+    Device missing:                        0
+    Device slack:                          0
+    Used:                         4294967296
+    Free (estimated):            16647020544      (min: 9135022080)
+    Free (statfs, df):           16646496256
+    Data ratio:                         1.00
+    Metadata ratio:                     2.00
+    Global reserve:                  5767168      (used: 0)
+    Multiple profiles:                    no
+
+Data,single: Size:5905580032, Used:4282236928
+   /dev/vda3     5905580032
+`
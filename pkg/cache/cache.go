@@ -0,0 +1,451 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/registry"
+	"github.com/suse/elemental/v3/pkg/rsync"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	imagesDir = "images"
+	layersDir = "layers"
+	filesDir  = "files"
+	chartsDir = "charts"
+	indexFile = "index.yaml"
+
+	// DefaultMaxAge is the age after which cache entries are pruned, unpacked images
+	// are refreshed each time a build uses them
+	DefaultMaxAge = 30 * 24 * time.Hour
+)
+
+// DigestResolver returns the digest an image reference currently points to
+type DigestResolver func(ctx context.Context, imageRef string) (string, error)
+
+// UnpackFunc unpacks an image to the given destination and returns its digest
+type UnpackFunc func(ctx context.Context, dest string) (digest string, err error)
+
+// DownloadFunc downloads the given url to the given path
+type DownloadFunc func(ctx context.Context, fs vfs.FS, url, path string) error
+
+// PullFunc pulls an artifact, such as a helm chart or container images archive, to the given path
+type PullFunc func(ctx context.Context, path string) error
+
+// Cache is a content addressed directory shared across builds. Unpacked images are stored
+// by the digest their reference resolves to and the platform they are unpacked for, so
+// a reference pointing to a new digest is never served from a stale entry. The digest of
+// each stored reference is recorded in an index, so an offline cache serves the stored
+// images without any network access.
+type Cache struct {
+	system     *sys.System
+	root       string
+	maxAge     time.Duration
+	offline    bool
+	storeFiles bool
+	registry   *registry.Config
+	resolve    DigestResolver
+
+	mu    sync.Mutex
+	index map[string]string
+}
+
+type Opts func(c *Cache)
+
+// WithRegistryConfig sets the credentials, mirrors and insecure registries used to resolve image digests
+func WithRegistryConfig(reg *registry.Config) Opts {
+	return func(c *Cache) {
+		c.registry = reg
+	}
+}
+
+// WithDigestResolver sets the function resolving image references to digests
+func WithDigestResolver(r DigestResolver) Opts {
+	return func(c *Cache) {
+		c.resolve = r
+	}
+}
+
+// WithMaxAge sets the time after which unused entries are pruned, zero disables pruning
+func WithMaxAge(age time.Duration) Opts {
+	return func(c *Cache) {
+		c.maxAge = age
+	}
+}
+
+// WithFileStore stores the downloaded files, so an offline cache of the same directory serves them.
+// Otherwise files are downloaded on each build, so they are always up to date, and not stored.
+func WithFileStore() Opts {
+	return func(c *Cache) {
+		c.storeFiles = true
+	}
+}
+
+// WithOffline serves the stored images and files only, references are resolved from the index
+// and missing entries are errors. Entries are never pruned.
+func WithOffline() Opts {
+	return func(c *Cache) {
+		c.offline = true
+	}
+}
+
+// New opens the cache at the given directory, creating it if needed unless offline, and prunes
+// the entries which have not been used for longer than the configured maximum age.
+func New(s *sys.System, root string, opts ...Opts) (*Cache, error) {
+	c := &Cache{
+		system: s,
+		root:   root,
+		maxAge: DefaultMaxAge,
+		index:  map[string]string{},
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	if c.offline {
+		if ok, _ := vfs.Exists(s.FS(), root); !ok {
+			return nil, fmt.Errorf("directory '%s' does not exist", root)
+		}
+		c.resolve = c.indexDigest
+		c.maxAge = 0
+	} else {
+		if c.resolve == nil {
+			c.resolve = registryDigestResolver(c.registry)
+		}
+		for _, dir := range c.dirs() {
+			if err := vfs.MkdirAll(s.FS(), dir, vfs.DirPerm); err != nil {
+				return nil, fmt.Errorf("creating cache directory '%s': %w", dir, err)
+			}
+		}
+	}
+
+	data, err := s.FS().ReadFile(filepath.Join(root, indexFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading cache index: %w", err)
+	}
+	if err = yaml.Unmarshal(data, &c.index); err != nil {
+		return nil, fmt.Errorf("parsing cache index: %w", err)
+	}
+
+	if err = c.prune(); err != nil {
+		return nil, fmt.Errorf("pruning cache: %w", err)
+	}
+
+	return c, nil
+}
+
+// Offline returns true if the cache only serves the stored entries
+func (c *Cache) Offline() bool {
+	return c.offline
+}
+
+// ImagesDir is the directory of the unpacked images, one sub directory per digest
+func (c *Cache) ImagesDir() string {
+	return filepath.Join(c.root, imagesDir)
+}
+
+// LayersDir is the directory of the image layers, stored by digest
+func (c *Cache) LayersDir() string {
+	return filepath.Join(c.root, layersDir)
+}
+
+// FilesDir is the directory of the downloaded files, stored by the hash of their URL
+func (c *Cache) FilesDir() string {
+	return filepath.Join(c.root, filesDir)
+}
+
+// ChartsDir is the directory of the helm chart archives, stored by the hash of their repository, name and version
+func (c *Cache) ChartsDir() string {
+	return filepath.Join(c.root, chartsDir)
+}
+
+func (c *Cache) dirs() []string {
+	return []string{c.ImagesDir(), c.LayersDir(), c.FilesDir(), c.ChartsDir()}
+}
+
+// Unpack syncs the image tree cached for the digest of the given reference, unpacked for the platform
+// of the system, into dest and returns the digest. On a cache miss the image is unpacked with the given function and stored for the
+// next builds. If the digest can't be resolved the image is unpacked straight into dest, unless
+// the cache is offline.
+func (c *Cache) Unpack(ctx context.Context, imageRef, dest string, unpack UnpackFunc) (string, error) {
+	entry, digest, err := c.Store(ctx, imageRef, "", unpack)
+	if errors.Is(err, errUnresolved) && !c.offline {
+		c.system.Logger().Warn("Not caching image '%s': %v", imageRef, err)
+		return unpack(ctx, dest)
+	} else if err != nil {
+		return "", err
+	}
+
+	sync := rsync.NewRsync(c.system, rsync.WithContext(ctx))
+	if err = sync.SyncData(entry, dest); err != nil {
+		return "", fmt.Errorf("syncing cached image '%s': %w", imageRef, err)
+	}
+
+	return digest, nil
+}
+
+// Store makes sure the image of the given reference, unpacked for the given platform or the platform of
+// the system if empty, is cached and returns the path of the entry and the image digest. Offline caches
+// fail for images not stored yet.
+func (c *Cache) Store(ctx context.Context, imageRef, platform string, unpack UnpackFunc) (entry, digest string, err error) {
+	if platform == "" {
+		platform = c.system.Platform().String()
+	}
+
+	digest, err = c.resolve(ctx, imageRef)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %w", errUnresolved, err)
+	}
+
+	entry, err = c.entryPath(digest, platform)
+	if err != nil {
+		return "", "", err
+	}
+
+	if ok, _ := vfs.Exists(c.system.FS(), entry); ok {
+		c.system.Logger().Info("Using cached image '%s' (%s)", imageRef, digest)
+		c.touch(entry)
+	} else if c.offline {
+		return "", "", fmt.Errorf("image '%s' (%s) is not stored in '%s'", imageRef, digest, c.root)
+	} else if err = c.store(ctx, imageRef, entry, unpack); err != nil {
+		return "", "", err
+	}
+
+	if !c.offline {
+		if err = c.record(imageRef, digest); err != nil {
+			return "", "", err
+		}
+	}
+
+	return entry, digest, nil
+}
+
+// Download copies the file stored for the given url to path. Online caches download the url
+// with the given function, and store the file only if the cache has a file store.
+func (c *Cache) Download(ctx context.Context, url, path string, download DownloadFunc) error {
+	return c.file(ctx, fmt.Sprintf("file '%s'", url), hashKey(url), path, func(ctx context.Context, path string) error {
+		return download(ctx, c.system.FS(), url, path)
+	})
+}
+
+// ImageArchive copies the archive stored for the given container images and platform to path. Online
+// caches pull the archive with the given function, and store it only if the cache has a file store.
+func (c *Cache) ImageArchive(ctx context.Context, images []string, platform, path string, pull PullFunc) error {
+	name := fmt.Sprintf("container images archive for '%s'", platform)
+	return c.file(ctx, name, hashKey(append([]string{platform}, images...)...), path, pull)
+}
+
+func (c *Cache) file(ctx context.Context, name, key, path string, pull PullFunc) error {
+	fs := c.system.FS()
+	entry := filepath.Join(c.FilesDir(), key)
+
+	if c.offline {
+		if ok, _ := vfs.Exists(fs, entry); !ok {
+			return fmt.Errorf("%s is not stored in '%s'", name, c.root)
+		}
+		c.system.Logger().Info("Using stored %s", name)
+		return vfs.CopyFileContext(ctx, fs, entry, path)
+	}
+
+	if err := pull(ctx, path); err != nil {
+		return err
+	}
+
+	if !c.storeFiles {
+		return nil
+	}
+
+	if err := vfs.CopyFileContext(ctx, fs, path, entry); err != nil {
+		return fmt.Errorf("storing %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Chart copies the archive stored for the given helm chart to path, pulling it with the given function
+// on a cache miss. Online caches pull the charts without a version each time, so they are up to date.
+// Offline caches fail for charts not stored yet.
+func (c *Cache) Chart(ctx context.Context, repo, chart, version, path string, pull PullFunc) error {
+	fs := c.system.FS()
+	entry := filepath.Join(c.ChartsDir(), hashKey(repo, chart, version))
+	name := strings.TrimSuffix(fmt.Sprintf("%s %s", chart, version), " ")
+
+	if ok, _ := vfs.Exists(fs, entry); ok && (c.offline || version != "") {
+		c.system.Logger().Info("Using cached helm chart '%s'", name)
+		c.touch(entry)
+		return vfs.CopyFileContext(ctx, fs, entry, path)
+	} else if c.offline {
+		return fmt.Errorf("helm chart '%s' is not stored in '%s'", name, c.root)
+	}
+
+	if err := pull(ctx, path); err != nil {
+		return err
+	}
+
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading helm chart archive: %w", err)
+	}
+	if err = vfs.WriteFileAtomic(fs, entry, data, 0o644); err != nil {
+		return fmt.Errorf("storing helm chart '%s': %w", name, err)
+	}
+
+	return nil
+}
+
+// hashKey returns the hex encoded hash of the given values
+func hashKey(values ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(values, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+var errUnresolved = errors.New("unresolved image digest")
+
+// store unpacks the image into a temporary directory next to the entry and renames it
+// once complete, so interrupted or concurrent builds never leave partial entries behind
+func (c *Cache) store(ctx context.Context, imageRef, entry string, unpack UnpackFunc) error {
+	fs := c.system.FS()
+
+	tmp, err := vfs.TempDir(fs, c.ImagesDir(), ".tmp-")
+	if err != nil {
+		return fmt.Errorf("creating cache entry: %w", err)
+	}
+	defer func() {
+		_ = vfs.ForceRemoveAll(fs, tmp)
+	}()
+
+	c.system.Logger().Info("Caching image '%s'", imageRef)
+	if _, err = unpack(ctx, tmp); err != nil {
+		return err
+	}
+
+	if err = fs.Rename(tmp, entry); err != nil {
+		if ok, _ := vfs.Exists(fs, entry); ok {
+			// stored by a concurrent build meanwhile
+			return nil
+		}
+		return fmt.Errorf("storing cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// record adds the digest of the given reference to the index
+func (c *Cache) record(imageRef, digest string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.index[imageRef] == digest {
+		return nil
+	}
+	c.index[imageRef] = digest
+
+	data, err := yaml.Marshal(c.index)
+	if err != nil {
+		return fmt.Errorf("marshalling cache index: %w", err)
+	}
+
+	if err = vfs.WriteFileAtomic(c.system.FS(), filepath.Join(c.root, indexFile), data, 0o644); err != nil {
+		return fmt.Errorf("writing cache index: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) indexDigest(_ context.Context, imageRef string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	digest, ok := c.index[imageRef]
+	if !ok {
+		return "", fmt.Errorf("image '%s' is not stored in '%s'", imageRef, c.root)
+	}
+	return digest, nil
+}
+
+func (c *Cache) entryPath(digest, platform string) (string, error) {
+	algorithm, hash, ok := strings.Cut(digest, ":")
+	if !ok || algorithm == "" || hash == "" || strings.ContainsAny(hash, "/.") {
+		return "", fmt.Errorf("invalid digest format '%s', expected '<algorithm>:<hash>'", digest)
+	}
+
+	if platform != "" {
+		hash = fmt.Sprintf("%s-%s", hash, strings.ReplaceAll(platform, "/", "-"))
+	}
+
+	return filepath.Join(c.ImagesDir(), hash), nil
+}
+
+// touch marks the entry as recently used, failures only make it a pruning candidate earlier
+func (c *Cache) touch(entry string) {
+	path, err := c.system.FS().RawPath(entry)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+}
+
+func (c *Cache) prune() error {
+	if c.maxAge <= 0 {
+		return nil
+	}
+
+	fs := c.system.FS()
+	for _, dir := range c.dirs() {
+		entries, err := fs.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("reading cache directory '%s': %w", dir, err)
+		}
+
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || time.Since(info.ModTime()) < c.maxAge {
+				continue
+			}
+
+			c.system.Logger().Debug("Pruning unused cache entry '%s'", entry.Name())
+			if err = vfs.ForceRemoveAll(fs, filepath.Join(dir, entry.Name())); err != nil {
+				return fmt.Errorf("removing cache entry '%s': %w", entry.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// registryDigestResolver resolves references with a HEAD request to the configured mirrors
+// first, references pinned to a digest are returned as is
+func registryDigestResolver(reg *registry.Config) DigestResolver {
+	return reg.Digest
+}
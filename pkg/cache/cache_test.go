@@ -0,0 +1,300 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/cache"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	imageRef = "registry.example.com/extensions/rke2:1.35"
+	hash     = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+)
+
+func TestCacheSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Build cache test suite")
+}
+
+var _ = Describe("Cache", Label("cache"), func() {
+	var runner *sysmock.Runner
+	var s *sys.System
+	var fs vfs.FS
+	var cleanup func()
+	var unpacked []string
+	var unpack cache.UnpackFunc
+	var resolver cache.DigestResolver
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/dest/.keep": "",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		runner = sysmock.NewRunner()
+		s, err = sys.NewSystem(sys.WithRunner(runner), sys.WithFS(fs), sys.WithPlatform("linux/amd64"),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		unpacked = nil
+		unpack = func(_ context.Context, dest string) (string, error) {
+			unpacked = append(unpacked, dest)
+			return "sha256:" + hash, fs.WriteFile(filepath.Join(dest, "extension.raw"), []byte("raw"), vfs.FilePerm)
+		}
+		resolver = func(_ context.Context, ref string) (string, error) {
+			Expect(ref).To(Equal(imageRef))
+			return "sha256:" + hash, nil
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("stores the unpacked image on a cache miss", func() {
+		c, err := cache.New(s, "/cache", cache.WithDigestResolver(resolver))
+		Expect(err).NotTo(HaveOccurred())
+
+		digest, err := c.Unpack(GinkgoT().Context(), imageRef, "/dest", unpack)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal("sha256:" + hash))
+		Expect(unpacked).To(HaveLen(1))
+		Expect(unpacked[0]).To(HavePrefix("/cache/images/.tmp-"))
+
+		data, err := fs.ReadFile(filepath.Join("/cache/images", hash+"-linux-amd64", "extension.raw"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("raw"))
+		Expect(vfs.Exists(fs, unpacked[0])).To(BeFalse())
+
+		entry, err := fs.RawPath(filepath.Join("/cache/images", hash+"-linux-amd64"))
+		Expect(err).NotTo(HaveOccurred())
+		dest, err := fs.RawPath("/dest")
+		Expect(err).NotTo(HaveOccurred())
+		cmds := runner.GetCmds()
+		Expect(cmds).To(HaveLen(1))
+		Expect(cmds[0][0]).To(Equal("rsync"))
+		Expect(cmds[0][len(cmds[0])-2:]).To(Equal([]string{entry + "/", dest + "/"}))
+	})
+	It("reuses the entry stored for the digest", func() {
+		Expect(vfs.MkdirAll(fs, filepath.Join("/cache/images", hash+"-linux-amd64"), vfs.DirPerm)).To(Succeed())
+		c, err := cache.New(s, "/cache", cache.WithDigestResolver(resolver))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = c.Unpack(GinkgoT().Context(), imageRef, "/dest", unpack)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unpacked).To(BeEmpty())
+		Expect(runner.IncludesCmds([][]string{{"rsync"}})).To(Succeed())
+	})
+	It("stores the image of each platform apart", func() {
+		c, err := cache.New(s, "/cache", cache.WithDigestResolver(resolver))
+		Expect(err).NotTo(HaveOccurred())
+
+		amd64, _, err := c.Store(GinkgoT().Context(), imageRef, "", unpack)
+		Expect(err).NotTo(HaveOccurred())
+		arm64, _, err := c.Store(GinkgoT().Context(), imageRef, "linux/arm64", unpack)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(amd64).To(Equal(filepath.Join("/cache/images", hash+"-linux-amd64")))
+		Expect(arm64).To(Equal(filepath.Join("/cache/images", hash+"-linux-arm64")))
+		Expect(unpacked).To(HaveLen(2))
+	})
+	It("downloads the files each time without a file store", func() {
+		c, err := cache.New(s, "/cache")
+		Expect(err).NotTo(HaveOccurred())
+
+		downloads := 0
+		download := func(_ context.Context, fs vfs.FS, _, path string) error {
+			downloads++
+			return fs.WriteFile(path, []byte("manifest"), vfs.FilePerm)
+		}
+		for range 2 {
+			Expect(c.Download(GinkgoT().Context(), "https://example.com/manifest.yaml", "/dest/manifest.yaml", download)).To(Succeed())
+		}
+		Expect(downloads).To(Equal(2))
+
+		entries, err := fs.ReadDir("/cache/files")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+	It("reuses the stored charts of a given version only", func() {
+		c, err := cache.New(s, "/cache")
+		Expect(err).NotTo(HaveOccurred())
+
+		pulls := 0
+		pull := func(_ context.Context, path string) error {
+			pulls++
+			return fs.WriteFile(path, []byte("chart"), vfs.FilePerm)
+		}
+		for range 2 {
+			Expect(c.Chart(GinkgoT().Context(), "https://charts.example.com", "metallb", "0.15.2", "/dest/metallb.tgz", pull)).To(Succeed())
+			Expect(c.Chart(GinkgoT().Context(), "https://charts.example.com", "longhorn", "", "/dest/longhorn.tgz", pull)).To(Succeed())
+		}
+		Expect(pulls).To(Equal(3))
+	})
+	It("does not resolve references pinned to a digest", func() {
+		Expect(vfs.MkdirAll(fs, filepath.Join("/cache/images", hash+"-linux-amd64"), vfs.DirPerm)).To(Succeed())
+		c, err := cache.New(s, "/cache")
+		Expect(err).NotTo(HaveOccurred())
+
+		digest, err := c.Unpack(GinkgoT().Context(), "registry.example.com/extensions/rke2@sha256:"+hash, "/dest", unpack)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal("sha256:" + hash))
+		Expect(unpacked).To(BeEmpty())
+	})
+	It("unpacks to the destination if the digest can't be resolved", func() {
+		c, err := cache.New(s, "/cache", cache.WithDigestResolver(func(context.Context, string) (string, error) {
+			return "", fmt.Errorf("unreachable registry")
+		}))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = c.Unpack(GinkgoT().Context(), imageRef, "/dest", unpack)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unpacked).To(Equal([]string{"/dest"}))
+		Expect(runner.GetCmds()).To(BeEmpty())
+
+		entries, err := fs.ReadDir("/cache/images")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+	It("does not store failed unpacks", func() {
+		c, err := cache.New(s, "/cache", cache.WithDigestResolver(resolver))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = c.Unpack(GinkgoT().Context(), imageRef, "/dest", func(context.Context, string) (string, error) {
+			return "", fmt.Errorf("pull failed")
+		})
+		Expect(err).To(MatchError(ContainSubstring("pull failed")))
+
+		entries, err := fs.ReadDir("/cache/images")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+	It("fails on invalid digests", func() {
+		c, err := cache.New(s, "/cache", cache.WithDigestResolver(func(context.Context, string) (string, error) {
+			return "../escape", nil
+		}))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = c.Unpack(GinkgoT().Context(), imageRef, "/dest", unpack)
+		Expect(err).To(MatchError(ContainSubstring("invalid digest format")))
+	})
+	It("prunes entries older than the maximum age", func() {
+		old := filepath.Join("/cache/images", strings.Repeat("a", 64))
+		recent := filepath.Join("/cache/images", hash+"-linux-amd64")
+		layer := "/cache/layers/sha256:" + strings.Repeat("b", 64)
+		Expect(vfs.MkdirAll(fs, old, vfs.DirPerm)).To(Succeed())
+		Expect(vfs.MkdirAll(fs, recent, vfs.DirPerm)).To(Succeed())
+		Expect(vfs.MkdirAll(fs, "/cache/layers", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile(layer, []byte("layer"), vfs.FilePerm)).To(Succeed())
+
+		past := time.Now().Add(-48 * time.Hour)
+		for _, path := range []string{old, layer} {
+			raw, err := fs.RawPath(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Chtimes(raw, past, past)).To(Succeed())
+		}
+
+		_, err := cache.New(s, "/cache", cache.WithMaxAge(24*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vfs.Exists(fs, old)).To(BeFalse())
+		Expect(vfs.Exists(fs, layer)).To(BeFalse())
+		Expect(vfs.Exists(fs, recent)).To(BeTrue())
+	})
+	It("serves the recorded images, files and charts offline", func() {
+		c, err := cache.New(s, "/cache", cache.WithDigestResolver(resolver), cache.WithFileStore())
+		Expect(err).NotTo(HaveOccurred())
+		_, err = c.Unpack(GinkgoT().Context(), imageRef, "/dest", unpack)
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = c.Store(GinkgoT().Context(), imageRef, "linux/arm64", unpack)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Download(GinkgoT().Context(), "https://example.com/manifest.yaml", "/dest/manifest.yaml",
+			func(_ context.Context, fs vfs.FS, _, path string) error {
+				return fs.WriteFile(path, []byte("manifest"), vfs.FilePerm)
+			})).To(Succeed())
+		Expect(c.Chart(GinkgoT().Context(), "https://charts.example.com", "metallb", "0.15.2", "/dest/metallb.tgz",
+			func(_ context.Context, path string) error {
+				return fs.WriteFile(path, []byte("chart"), vfs.FilePerm)
+			})).To(Succeed())
+		Expect(c.ImageArchive(GinkgoT().Context(), []string{"registry.example.com/httpd:2.4"}, "linux/amd64", "/dest/images.tar",
+			func(_ context.Context, path string) error {
+				return fs.WriteFile(path, []byte("images"), vfs.FilePerm)
+			})).To(Succeed())
+		Expect(unpacked).To(HaveLen(2))
+
+		offline, err := cache.New(s, "/cache", cache.WithOffline())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(offline.Offline()).To(BeTrue())
+
+		entry, digest, err := offline.Store(GinkgoT().Context(), imageRef, "linux/arm64", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry).To(Equal(filepath.Join("/cache/images", hash+"-linux-arm64")))
+		Expect(digest).To(Equal("sha256:" + hash))
+
+		_, err = offline.Unpack(GinkgoT().Context(), imageRef, "/dest", unpack)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unpacked).To(HaveLen(2))
+
+		Expect(offline.Download(GinkgoT().Context(), "https://example.com/manifest.yaml", "/dest/copy.yaml", nil)).To(Succeed())
+		data, err := fs.ReadFile("/dest/copy.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("manifest"))
+
+		Expect(offline.Chart(GinkgoT().Context(), "https://charts.example.com", "metallb", "0.15.2", "/dest/copy.tgz", nil)).To(Succeed())
+		data, err = fs.ReadFile("/dest/copy.tgz")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("chart"))
+
+		Expect(offline.ImageArchive(GinkgoT().Context(), []string{"registry.example.com/httpd:2.4"}, "linux/amd64", "/dest/copy.tar", nil)).To(Succeed())
+		data, err = fs.ReadFile("/dest/copy.tar")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("images"))
+	})
+	It("fails offline for artifacts not stored", func() {
+		offline, err := cache.New(s, "/dest", cache.WithOffline())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vfs.Exists(fs, "/dest/images")).To(BeFalse())
+
+		_, err = offline.Unpack(GinkgoT().Context(), imageRef, "/dest", unpack)
+		Expect(err).To(MatchError(ContainSubstring("image '" + imageRef + "' is not stored in '/dest'")))
+		Expect(unpacked).To(BeEmpty())
+
+		err = offline.Download(GinkgoT().Context(), "https://example.com/manifest.yaml", "/dest/manifest.yaml", nil)
+		Expect(err).To(MatchError(ContainSubstring("file 'https://example.com/manifest.yaml' is not stored")))
+
+		err = offline.Chart(GinkgoT().Context(), "https://charts.example.com", "metallb", "0.15.2", "/dest/metallb.tgz", nil)
+		Expect(err).To(MatchError(ContainSubstring("helm chart 'metallb 0.15.2' is not stored")))
+
+		err = offline.ImageArchive(GinkgoT().Context(), []string{"registry.example.com/httpd:2.4"}, "linux/amd64", "/dest/images.tar", nil)
+		Expect(err).To(MatchError(ContainSubstring("container images archive for 'linux/amd64' is not stored")))
+
+		_, err = cache.New(s, "/missing", cache.WithOffline())
+		Expect(err).To(MatchError(ContainSubstring("does not exist")))
+	})
+})
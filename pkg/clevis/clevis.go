@@ -0,0 +1,224 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clevis
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/chroot"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// DracutConfig is the dracut configuration adding the modules required to unlock LUKS volumes over the network
+	DracutConfig = "/etc/dracut.conf.d/50-elemental-network-unlock.conf"
+
+	dracutModules = "network clevis clevis-pin-tang clevis-pin-sss"
+	defaultIP     = "dhcp"
+)
+
+// UnlockPolicy defines which pins are required to unlock the LUKS volumes
+type UnlockPolicy string
+
+const (
+	// PolicyTang unlocks the volumes with the keys provided by the tang servers only
+	PolicyTang UnlockPolicy = "tang"
+	// PolicyTangAndTPM unlocks the volumes only if the TPM unseals its share of the key
+	// and the threshold of tang servers is reachable
+	PolicyTangAndTPM UnlockPolicy = "tang+tpm2"
+)
+
+// IsValid returns true if the policy is known, an empty policy defaults to PolicyTang
+func (p UnlockPolicy) IsValid() bool {
+	return p == "" || p == PolicyTang || p == PolicyTangAndTPM
+}
+
+// RequiresTPM returns true if the policy includes the tpm2 pin
+func (p UnlockPolicy) RequiresTPM() bool {
+	return p == PolicyTangAndTPM
+}
+
+// Config configures unlocking LUKS volumes at boot with the keys provided by tang servers
+type Config struct {
+	// Servers are the tang servers the keys are bound to
+	Servers []TangServer `yaml:"servers" validate:"required,min=1,dive"`
+	// Threshold is the number of servers that must be reachable to unlock the volumes, defaults to 1
+	Threshold int `yaml:"threshold,omitempty" validate:"gte=0"`
+	// IP is the value of the 'ip' kernel parameter configuring the early network, defaults to 'dhcp'
+	IP string `yaml:"ip,omitempty"`
+	// Policy is the unlock policy, defaults to 'tang'
+	Policy UnlockPolicy `yaml:"policy,omitempty"`
+	// PCRs are the PCR indexes the TPM share of the key is sealed against with the 'tang+tpm2' policy
+	PCRs []int `yaml:"pcrs,omitempty" validate:"dive,gte=0,lte=23"`
+}
+
+// Validate checks the unlock policy is consistent with the configured tang servers
+func (c Config) Validate() error {
+	if !c.Policy.IsValid() {
+		return fmt.Errorf("invalid unlock policy '%s'", c.Policy)
+	}
+	if c.Threshold > len(c.Servers) {
+		return fmt.Errorf("threshold %d is higher than the number of tang servers", c.Threshold)
+	}
+	if len(c.PCRs) > 0 && !c.Policy.RequiresTPM() {
+		return fmt.Errorf("PCRs are only supported with the '%s' unlock policy", PolicyTangAndTPM)
+	}
+	return nil
+}
+
+type TangServer struct {
+	URL string `yaml:"url" validate:"required,url"`
+	// Thumbprint of the tang server signing key. Without a thumbprint the key advertised by the
+	// server at installation time is trusted.
+	Thumbprint string `yaml:"thumbprint,omitempty"`
+}
+
+type tangPin struct {
+	URL        string `json:"url"`
+	Thumbprint string `json:"thp,omitempty"`
+}
+
+type tpm2Pin struct {
+	PCRBank string `json:"pcr_bank,omitempty"`
+	PCRIDs  string `json:"pcr_ids,omitempty"`
+}
+
+// Pin returns the clevis pin and its JSON configuration binding the keys to the configured servers.
+// A single server is bound with the tang pin, multiple servers are combined with the sss pin. The
+// 'tang+tpm2' policy nests the tang pins in an sss pin requiring both the TPM and the tang servers.
+func (c Config) Pin() (string, string, error) {
+	if err := c.Validate(); err != nil {
+		return "", "", err
+	}
+
+	pins := make([]tangPin, 0, len(c.Servers))
+	for _, s := range c.Servers {
+		pins = append(pins, tangPin{URL: s.URL, Thumbprint: s.Thumbprint})
+	}
+
+	var pin string
+	var cfg any
+	if len(pins) == 1 {
+		pin, cfg = "tang", pins[0]
+	} else {
+		pin, cfg = "sss", map[string]any{"t": max(c.Threshold, 1), "pins": map[string]any{"tang": pins}}
+	}
+
+	if c.Policy.RequiresTPM() {
+		tpm := tpm2Pin{}
+		if len(c.PCRs) > 0 {
+			ids := make([]string, 0, len(c.PCRs))
+			for _, pcr := range c.PCRs {
+				ids = append(ids, strconv.Itoa(pcr))
+			}
+			tpm = tpm2Pin{PCRBank: "sha256", PCRIDs: strings.Join(ids, ",")}
+		}
+		cfg = map[string]any{"t": 2, "pins": map[string]any{pin: cfg, "tpm2": tpm}}
+		pin = "sss"
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", "", fmt.Errorf("serializing clevis %s pin: %w", pin, err)
+	}
+	return pin, string(data), nil
+}
+
+// AppendCommandLine adds the kernel parameters bringing up the network in the initrd, unless already present
+func AppendCommandLine(cmdline string, cfg *Config) string {
+	params := strings.Fields(cmdline)
+
+	if !slices.Contains(params, "rd.neednet=1") {
+		params = append(params, "rd.neednet=1")
+	}
+	if !slices.ContainsFunc(params, func(p string) bool { return strings.HasPrefix(p, "ip=") }) {
+		ip := cfg.IP
+		if ip == "" {
+			ip = defaultIP
+		}
+		params = append(params, "ip="+ip)
+	}
+
+	return strings.Join(params, " ")
+}
+
+// ConfigureInitrd adds the network and clevis dracut modules required by the unlock policy to the
+// initrd of the given root and regenerates it
+func ConfigureInitrd(ctx context.Context, s *sys.System, rootDir string, cfg *Config) error {
+	kernel, version, err := vfs.FindKernel(s.FS(), rootDir)
+	if err != nil {
+		return fmt.Errorf("finding kernel: %w", err)
+	}
+
+	confFile := filepath.Join(rootDir, DracutConfig)
+	err = vfs.MkdirAll(s.FS(), filepath.Dir(confFile), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating dracut configuration directory: %w", err)
+	}
+	modules := dracutModules
+	if cfg.Policy.RequiresTPM() {
+		modules += " clevis-pin-tpm2 tpm2-tss"
+	}
+	err = s.FS().WriteFile(confFile, []byte(fmt.Sprintf("add_dracutmodules+=\" %s \"\n", modules)), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing dracut configuration: %w", err)
+	}
+
+	initrd := filepath.Join(strings.TrimPrefix(filepath.Dir(kernel), rootDir), "initrd")
+	callback := func() error {
+		s.Logger().Info("Regenerating initrd of kernel %s with network unlock support", version)
+		stdOut, err := s.Runner().RunContext(ctx, "dracut", "--force", "--kver", version, initrd)
+		s.Logger().Debug("dracut: %s", string(stdOut))
+		return err
+	}
+	return chroot.ChrootedCallback(s, rootDir, nil, callback)
+}
+
+// Bind binds the keys of the given LUKS volumes to the tang servers. The volumes are unlocked with the
+// passphrase stored in the given key file.
+func Bind(ctx context.Context, s *sys.System, cfg *Config, keyFile string, volumes []string) error {
+	pin, pinCfg, err := cfg.Pin()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"luks", "bind", "-k", keyFile}
+	if slices.ContainsFunc(cfg.Servers, func(t TangServer) bool { return t.Thumbprint == "" }) {
+		s.Logger().Warn("Trusting the keys advertised by tang servers without a thumbprint")
+		args = append(args, "-y")
+	}
+
+	for _, volume := range volumes {
+		s.Logger().Info("Binding the key of LUKS volume '%s' to %d tang server(s) with the '%s' unlock policy", volume, len(cfg.Servers), cmp.Or(cfg.Policy, PolicyTang))
+
+		stdOut, err := s.Runner().RunContext(ctx, "clevis", append(args, "-d", volume, pin, pinCfg)...)
+		if err != nil {
+			s.Logger().Error("failed binding LUKS volume (%s): %s", err.Error(), string(stdOut))
+			return fmt.Errorf("binding LUKS volume '%s': %w", volume, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clevis_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/clevis"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestClevisSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Clevis test suite")
+}
+
+var _ = Describe("Clevis", Label("clevis"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var cfg *clevis.Config
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/some/root/usr/lib/modules/6.4.0-1-default/vmlinuz": "",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithMounter(sysmock.NewMounter()), sys.WithRunner(runner),
+			sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithSyscall(&sysmock.Syscall{}),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		cfg = &clevis.Config{
+			Servers: []clevis.TangServer{{URL: "http://tang1.example.com", Thumbprint: "abc"}},
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("returns the tang pin for a single server", func() {
+		pin, pinCfg, err := cfg.Pin()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pin).To(Equal("tang"))
+		Expect(pinCfg).To(Equal(`{"url":"http://tang1.example.com","thp":"abc"}`))
+	})
+	It("returns the sss pin for multiple servers", func() {
+		cfg.Servers = append(cfg.Servers, clevis.TangServer{URL: "http://tang2.example.com"})
+		cfg.Threshold = 2
+		pin, pinCfg, err := cfg.Pin()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pin).To(Equal("sss"))
+		Expect(pinCfg).To(Equal(`{"pins":{"tang":[{"url":"http://tang1.example.com","thp":"abc"},{"url":"http://tang2.example.com"}]},"t":2}`))
+
+		cfg.Threshold = 3
+		_, _, err = cfg.Pin()
+		Expect(err).To(MatchError(ContainSubstring("threshold 3 is higher than the number of tang servers")))
+	})
+	It("requires the TPM and the tang servers with the tang+tpm2 policy", func() {
+		cfg.Policy = clevis.PolicyTangAndTPM
+		pin, pinCfg, err := cfg.Pin()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pin).To(Equal("sss"))
+		Expect(pinCfg).To(Equal(`{"pins":{"tang":{"url":"http://tang1.example.com","thp":"abc"},"tpm2":{}},"t":2}`))
+
+		cfg.Servers = append(cfg.Servers, clevis.TangServer{URL: "http://tang2.example.com"})
+		cfg.PCRs = []int{7, 11}
+		pin, pinCfg, err = cfg.Pin()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pin).To(Equal("sss"))
+		Expect(pinCfg).To(Equal(
+			`{"pins":{"sss":{"pins":{"tang":[{"url":"http://tang1.example.com","thp":"abc"},{"url":"http://tang2.example.com"}]},"t":1},` +
+				`"tpm2":{"pcr_bank":"sha256","pcr_ids":"7,11"}},"t":2}`,
+		))
+	})
+	It("validates the unlock policy", func() {
+		Expect(cfg.Validate()).To(Succeed())
+
+		cfg.PCRs = []int{7}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("only supported with the 'tang+tpm2' unlock policy")))
+
+		cfg.Policy = "tpm2"
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("invalid unlock policy 'tpm2'")))
+	})
+	It("appends the early network kernel parameters", func() {
+		Expect(clevis.AppendCommandLine("console=ttyS0", cfg)).To(Equal("console=ttyS0 rd.neednet=1 ip=dhcp"))
+		Expect(clevis.AppendCommandLine("ip=eth0:dhcp6 rd.neednet=1", cfg)).To(Equal("ip=eth0:dhcp6 rd.neednet=1"))
+
+		cfg.IP = "10.0.0.5::10.0.0.1:255.255.255.0::eth0:none"
+		Expect(clevis.AppendCommandLine("", cfg)).To(Equal("rd.neednet=1 ip=10.0.0.5::10.0.0.1:255.255.255.0::eth0:none"))
+	})
+	It("regenerates the initrd with the network unlock modules", func() {
+		root := "/some/root"
+		for _, path := range []string{"/dev", "/dev/pts", "/proc", "/sys"} {
+			Expect(vfs.MkdirAll(fs, path, vfs.DirPerm)).To(Succeed())
+		}
+		Expect(clevis.ConfigureInitrd(context.Background(), s, root, cfg)).To(Succeed())
+
+		data, err := fs.ReadFile(filepath.Join(root, clevis.DracutConfig))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("add_dracutmodules+=\" network clevis clevis-pin-tang clevis-pin-sss \"\n"))
+
+		cfg.Policy = clevis.PolicyTangAndTPM
+		Expect(clevis.ConfigureInitrd(context.Background(), s, root, cfg)).To(Succeed())
+		data, err = fs.ReadFile(filepath.Join(root, clevis.DracutConfig))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("clevis-pin-sss clevis-pin-tpm2 tpm2-tss"))
+
+		Expect(runner.IncludesCmds([][]string{
+			{"dracut", "--force", "--kver", "6.4.0-1-default", "/usr/lib/modules/6.4.0-1-default/initrd"},
+		})).To(Succeed())
+	})
+	It("binds the volumes to the tang servers unlocking them with the key file", func() {
+		volumes := []string{"/dev/disk/by-partuuid/part3", "/dev/disk/by-partuuid/part4"}
+		Expect(clevis.Bind(context.Background(), s, cfg, "/etc/luks.key", volumes)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{
+				"clevis", "luks", "bind", "-k", "/etc/luks.key", "-d", "/dev/disk/by-partuuid/part3", "tang",
+				`{"url":"http://tang1.example.com","thp":"abc"}`,
+			},
+			{"clevis", "luks", "bind", "-k", "/etc/luks.key", "-d", "/dev/disk/by-partuuid/part4", "tang"},
+		})).To(Succeed())
+	})
+	It("trusts the advertised keys of servers without thumbprint", func() {
+		cfg.Servers[0].Thumbprint = ""
+		Expect(clevis.Bind(context.Background(), s, cfg, "/etc/luks.key", []string{"/dev/disk/by-partuuid/part3"})).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"clevis", "luks", "bind", "-k", "/etc/luks.key", "-y", "-d", "/dev/disk/by-partuuid/part3", "tang"},
+		})).To(Succeed())
+	})
+})
@@ -0,0 +1,99 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypttab
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const File = "/etc/crypttab"
+
+// Line is a crypttab entry without key file, the volume is unlocked with the enrolled tokens or
+// interactively
+type Line struct {
+	Name    string
+	Device  string
+	Options []string
+}
+
+// Lines returns the crypttab lines unlocking the LUKS volumes of the encrypted partitions of the
+// given deployment. Volumes bound to tang servers are attached in the initrd once the network is up.
+func Lines(d *deployment.Deployment) []Line {
+	lines := []Line{}
+	for _, part := range d.GetEncryptedPartitions() {
+		opts := []string{"luks"}
+		if d.Firmware != nil && d.Firmware.TPM != nil {
+			opts = append(opts, "tpm2-device=auto")
+		}
+		if d.IsNetworkUnlockEnabled() {
+			opts = append(opts, "_netdev", "x-initrd.attach")
+		}
+		lines = append(lines, Line{
+			Name:    part.MapperName(),
+			Device:  fmt.Sprintf("PARTUUID=%s", part.UUID),
+			Options: opts,
+		})
+	}
+	return lines
+}
+
+// Configure writes the crypttab lines of the encrypted partitions of the given deployment to the
+// crypttab file of the given root. Entries of other volumes already present in the file are kept.
+func Configure(s *sys.System, root string, d *deployment.Deployment) error {
+	lines := Lines(d)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	file := filepath.Join(root, File)
+	var buf bytes.Buffer
+	data, err := s.FS().ReadFile(file)
+	if err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) > 0 && slices.ContainsFunc(lines, func(l Line) bool { return l.Name == fields[0] }) {
+				continue
+			}
+			buf.WriteString(scanner.Text() + "\n")
+		}
+	}
+
+	for _, line := range lines {
+		fmt.Fprintf(&buf, "%s %s none %s\n", line.Name, line.Device, strings.Join(line.Options, ","))
+	}
+
+	err = vfs.MkdirAll(s.FS(), filepath.Dir(file), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating crypttab directory: %w", err)
+	}
+	err = vfs.WriteFileAtomic(s.FS(), file, buf.Bytes(), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing crypttab: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypttab_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/clevis"
+	"github.com/suse/elemental/v3/pkg/crypttab"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestCrypttabSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Crypttab test suite")
+}
+
+var _ = Describe("Crypttab", Label("crypttab"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var d *deployment.Deployment
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/root/etc/crypttab": "swap /dev/sdb2 /dev/urandom swap\nluks-data PARTUUID=data none luks\n",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+
+		d = deployment.DefaultDeployment()
+		d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+			Role: deployment.Generic, MountPoint: "/data", UUID: "data", Encrypted: true,
+		})
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("adds the encrypted partitions keeping other volumes", func() {
+		Expect(crypttab.Configure(s, "/root", d)).To(Succeed())
+		data, err := tfs.ReadFile("/root/etc/crypttab")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("swap /dev/sdb2 /dev/urandom swap\nluks-data PARTUUID=data none luks\n"))
+	})
+	It("unlocks the volumes with the TPM2 and the tang servers", func() {
+		d.Firmware.TPM = &firmware.TPMConfig{}
+		d.Security.NetworkUnlock = &clevis.Config{Servers: []clevis.TangServer{{URL: "http://tang.example.com"}}}
+		Expect(crypttab.Lines(d)).To(Equal([]crypttab.Line{{
+			Name: "luks-data", Device: "PARTUUID=data",
+			Options: []string{"luks", "tpm2-device=auto", "_netdev", "x-initrd.attach"},
+		}}))
+	})
+	It("does not write the crypttab without encrypted partitions", func() {
+		d = deployment.DefaultDeployment()
+		Expect(crypttab.Configure(s, "/other", d)).To(Succeed())
+		ok, _ := vfs.Exists(tfs, "/other/etc/crypttab")
+		Expect(ok).To(BeFalse())
+	})
+})
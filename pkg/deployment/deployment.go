@@ -31,6 +31,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"go.yaml.in/yaml/v3"
 
+	"github.com/suse/elemental/v3/pkg/clevis"
 	"github.com/suse/elemental/v3/pkg/crypto"
 	"github.com/suse/elemental/v3/pkg/firmware"
 	"github.com/suse/elemental/v3/pkg/sys"
@@ -63,9 +64,18 @@ const (
 	SystemMnt            = "/"
 	AllAvailableSize MiB = 0
 
+	// LegacyBIOSBootableAttr is the GPT attribute bit flagging a partition as bootable by legacy BIOS
+	LegacyBIOSBootableAttr = 2
+
 	ConfigLabel = "ignition"
 	ConfigMnt   = "/run/elemental/firstboot"
 
+	VarMnt = "/var"
+
+	// SwapVolume is the rw volume of the system partition holding the swap file
+	SwapVolume = "/swap"
+	SwapFile   = "/swap/swapfile"
+
 	deploymentFile = "/etc/elemental/deployment.yaml"
 
 	Unknown = "unknown"
@@ -79,6 +89,7 @@ const (
 	Recovery
 	Generic
 	Config
+	Swap
 )
 
 type FileSystem int
@@ -158,6 +169,8 @@ func ParseRole(function string) (PartRole, error) {
 		return Generic, nil
 	case "config":
 		return Config, nil
+	case "swap":
+		return Swap, nil
 	default:
 		return PartRole(0), fmt.Errorf("unknown partition function: %s", function)
 	}
@@ -175,6 +188,8 @@ func (p PartRole) String() string {
 		return "generic"
 	case Config:
 		return "config"
+	case Swap:
+		return "swap"
 	default:
 		return Unknown
 	}
@@ -218,13 +233,92 @@ type Partition struct {
 	RWVolumes  RWVolumes  `yaml:"rwVolumes,omitempty" validate:"excluded_unless=FileSystem 1,dive"` // FileSystem 1 = btrfs
 	UUID       string     `yaml:"uuid,omitempty"`
 	Hidden     bool       `yaml:"hidden,omitempty"`
+	// TypeUUID overrides the GPT partition type GUID derived from the role
+	TypeUUID string `yaml:"typeUUID,omitempty" validate:"omitempty,uuid"`
+	// LegacyBIOSBootable sets the legacy BIOS bootable GPT attribute
+	LegacyBIOSBootable bool `yaml:"legacyBIOSBootable,omitempty"`
+	// Attributes are additional GPT attribute bits to set, e.g. 59 to grow the filesystem
+	// or 60 to flag the partition as read-only as defined by the Discoverable Partitions Specification
+	Attributes []uint `yaml:"attributes,omitempty" validate:"dive,max=63"`
+	// Reuse keeps the existing partition matching the label or UUID of this one on install, including
+	// its data. The partition is created as any other if no match is found.
+	Reuse bool `yaml:"reuse,omitempty"`
+	// Encrypted creates the filesystem within a LUKS2 volume, unlocked at boot by the TPM2 or the tang
+	// servers if configured. Only supported for generic partitions without RW volumes.
+	Encrypted bool `yaml:"encrypted,omitempty"`
+}
+
+// LUKSDevice returns the persistent path of the LUKS volume of an encrypted partition
+func (p Partition) LUKSDevice() string {
+	return filepath.Join("/dev/disk/by-partuuid", p.UUID)
+}
+
+// MapperName returns the device mapper name of the unlocked LUKS volume of an encrypted partition
+func (p Partition) MapperName() string {
+	return "luks-" + p.UUID
+}
+
+// FstabDevice returns the device of the partition filesystem as referenced in fstab
+func (p Partition) FstabDevice() string {
+	if p.Encrypted {
+		return filepath.Join("/dev/mapper", p.MapperName())
+	}
+	return fmt.Sprintf("PARTUUID=%s", p.UUID)
+}
+
+// GPTAttributes returns the GPT attributes field of the partition, zero if no attribute is set
+func (p Partition) GPTAttributes() uint64 {
+	var attrs uint64
+	if p.LegacyBIOSBootable {
+		attrs |= 1 << LegacyBIOSBootableAttr
+	}
+	for _, bit := range p.Attributes {
+		attrs |= 1 << bit
+	}
+	return attrs
 }
 
 type Partitions []*Partition
 
 type Disk struct {
-	Device     string     `yaml:"target,omitempty" validate:"disk_device_required,disk_device_exists"`
+	Device string `yaml:"target,omitempty" validate:"disk_device_required,disk_device_exists"`
+	// Size is the size of the raw disk image file created at Device path when
+	// installing to a regular file instead of a block device
+	Size       MiB        `yaml:"size,omitempty"`
 	Partitions Partitions `yaml:"partitions" validate:"required,min=1,dive"`
+	// ExpandPartitions grows the last partition and its filesystem to fill the disk on install and
+	// on first boot, so the same raw disk image fits disks of different sizes
+	ExpandPartitions bool `yaml:"expandPartitions,omitempty"`
+	// RepartDefinitions ships the partitions of the disk as systemd-repart definitions in the OS, so
+	// systemd-repart creates any missing partition on boot. Only honored for the system disk.
+	RepartDefinitions bool `yaml:"repartDefinitions,omitempty"`
+	// Wipe defines how the current contents of the disk are cleared on install, defaults to only
+	// replacing the partition table
+	Wipe WipePolicy `yaml:"wipe,omitempty" validate:"wipe_policy"`
+}
+
+type WipePolicy string
+
+const (
+	// WipeTable replaces the partition table, leaving the previous data on disk
+	WipeTable WipePolicy = "table"
+	// WipeZapAll erases all filesystem, RAID and partition table signatures of the disk and its partitions
+	WipeZapAll WipePolicy = "zap-all"
+	// WipeDiscard discards all sectors of the disk
+	WipeDiscard WipePolicy = "discard"
+	// WipeSecureDiscard securely discards all sectors of the disk, not supported by all devices
+	WipeSecureDiscard WipePolicy = "secure-discard"
+	// WipeKeepData keeps the existing partitions of the disk and only adds the missing ones
+	WipeKeepData WipePolicy = "keep-data"
+)
+
+// IsValid returns true if the wipe policy is known, an empty policy is valid and equals WipeTable
+func (w WipePolicy) IsValid() bool {
+	switch w {
+	case "", WipeTable, WipeZapAll, WipeDiscard, WipeSecureDiscard, WipeKeepData:
+		return true
+	}
+	return false
 }
 
 type BootConfig struct {
@@ -234,18 +328,130 @@ type BootConfig struct {
 	// InitrdExtensions represents a list of CPIO files which are added in the
 	// bootloader initrd call in addition to the stock initrd included within the OS
 	InitrdExtensions []string `yaml:"initrdExtensions,omitempty"`
+
+	// BootTries is the number of attempts to boot a new snapshot before the bootloader falls
+	// back to the previous one, zero disables the boot assessment
+	BootTries int `yaml:"bootTries,omitempty" validate:"min=0,max=9"`
 }
 
 type FirmwareConfig struct {
 	BootEntries []*firmware.EfiBootEntry `yaml:"entries"`
+	// TPM enables recording the expected TPM2 measurements of the boot chain and
+	// sealing the keys of LUKS volumes against them
+	TPM *firmware.TPMConfig `yaml:"tpm,omitempty"`
 }
 
 type SecurityConfig struct {
 	CryptoPolicy crypto.Policy `yaml:"cryptoPolicy" validate:"crypto_policy"`
+	// LUKSKeyFile is the file holding the passphrase of the LUKS volumes of the encrypted partitions. It is
+	// only read on installation to create the volumes and to enroll the TPM2 and tang keys.
+	LUKSKeyFile string `yaml:"luksKeyFile,omitempty" validate:"omitempty,abspath"`
+	// NetworkUnlock enables unlocking LUKS volumes at boot with keys provided by tang servers
+	NetworkUnlock *clevis.Config `yaml:"networkUnlock,omitempty" validate:"omitempty,network_unlock"`
+}
+
+type CompressionAlgorithm string
+
+const (
+	Zstd CompressionAlgorithm = "zstd"
+	Xz   CompressionAlgorithm = "xz"
+
+	// MaxZstdLevel is the highest zstd compression level
+	MaxZstdLevel = 22
+)
+
+// Compression is the compression of a generated image, higher levels trade build time for size
+type Compression struct {
+	Algorithm CompressionAlgorithm `yaml:"algorithm"`
+	// Level is the zstd compression level, zero uses the default level of the tool
+	Level int `yaml:"level,omitempty"`
+}
+
+// Validate checks the algorithm is supported and the level is only set for zstd within its range
+func (c Compression) Validate() error {
+	switch c.Algorithm {
+	case Zstd:
+		if c.Level < 0 || c.Level > MaxZstdLevel {
+			return fmt.Errorf("zstd compression level must be between 1 and %d, got %d", MaxZstdLevel, c.Level)
+		}
+	case Xz:
+		if c.Level != 0 {
+			return fmt.Errorf("compression level is only supported for zstd")
+		}
+	default:
+		return fmt.Errorf("unsupported compression algorithm '%s', supported algorithms: %s, %s", c.Algorithm, Zstd, Xz)
+	}
+	return nil
+}
+
+// CompressionConfig sets the compression of the images (re)generated during a transaction. Unset
+// images keep the default compression of the tool generating them.
+type CompressionConfig struct {
+	// Initrd is the compression of the initrd, it is regenerated with it by every transaction
+	Initrd *Compression `yaml:"initrd,omitempty" validate:"omitempty,compression"`
+	// Images is the compression of the squashfs and EROFS images generated for the deployment, such as
+	// the live root of installer media, the OS images of the overlay snapshotter or confext images
+	Images *Compression `yaml:"images,omitempty" validate:"omitempty,compression"`
+}
+
+type SwapType string
+
+const (
+	SwapPartition SwapType = "partition"
+	SwapFileType  SwapType = "file"
+	SwapZram      SwapType = "zram"
+)
+
+// SwapConfig defines the swap space of the system, either the partition with the swap role, a swap
+// file in a dedicated rw volume of the btrfs system partition or a compressed RAM device
+type SwapConfig struct {
+	Type SwapType `yaml:"type"`
+	// Size is the size of the swap file or the zram device, swap partitions are sized in the partition
+	// definition. Zero sets the default size of zram-generator for zram devices.
+	Size MiB `yaml:"size,omitempty"`
+	// Hibernation sets the kernel parameters to resume from the swap space after hibernation
+	Hibernation bool `yaml:"hibernation,omitempty"`
 }
 
 type SnapshotterConfig struct {
 	Name string `yaml:"name"`
+	// Delta applies only the new layers of the OS image on top of the previous snapshot on upgrades,
+	// provided the new image is built on top of the previous one. Local changes of the previous snapshot
+	// outside the new layers are kept. Only supported by snapper.
+	Delta bool `yaml:"delta,omitempty"`
+	// CleanupThreshold is the usage of the system partition, as a percentage, above which old snapshots
+	// are deleted before starting a new transaction and after committing it, even if the retention
+	// policy would keep them. Zero disables it.
+	CleanupThreshold int `yaml:"cleanupThreshold,omitempty" validate:"usage_threshold"`
+	// Retention defines the snapshots kept by the cleanup following each transaction
+	Retention *RetentionPolicy `yaml:"retention,omitempty"`
+	// Checksums stores a SHA256 manifest of the read-only contents in each new snapshot, so
+	// the system integrity can be verified later on
+	Checksums bool `yaml:"checksums,omitempty"`
+}
+
+// RetentionPolicy defines which snapshots are kept when cleaning up after a transaction. The active
+// and default snapshots are always kept.
+type RetentionPolicy struct {
+	// KeepLast is the number of most recent snapshots kept, not counting the ones kept because of
+	// their userdata. Defaults to 8.
+	KeepLast int `yaml:"keepLast,omitempty" validate:"gte=0"`
+	// KeepImportant keeps the snapshots flagged with the 'important=yes' userdata
+	KeepImportant bool `yaml:"keepImportant,omitempty"`
+	// KeepTagged keeps the snapshots with any of the given userdata keys set to 'yes', as manually
+	// tagged with 'snapper modify --userdata <key>=yes <number>'
+	KeepTagged []string `yaml:"keepTagged,omitempty"`
+}
+
+// FirstBootConfig defines configurations applied by provisioning tools on the first boot of the installed
+// system. Values are paths of the host the installation runs on.
+type FirstBootConfig struct {
+	// Ignition is an Ignition configuration file, written into the config partition
+	Ignition string `yaml:"ignition,omitempty" validate:"omitempty,abspath"`
+	// Combustion is a combustion script, or a directory including the script, written into the config partition
+	Combustion string `yaml:"combustion,omitempty" validate:"omitempty,abspath"`
+	// CloudInit is a cloud-init user data file, or a NoCloud seed directory, written into the installed system
+	CloudInit string `yaml:"cloudInit,omitempty" validate:"omitempty,abspath"`
 }
 
 type LiveInstaller struct {
@@ -255,14 +461,17 @@ type LiveInstaller struct {
 }
 
 type Deployment struct {
-	SourceOS    *ImageSource       `yaml:"sourceOS" validate:"required,not_empty_source"`
-	Disks       []*Disk            `yaml:"disks" validate:"required,min=1,dive,system_partition,multiple_system_partitions,efi_partition,multiple_efi_partitions,recovery_partition,last_partition_size,rw_volumes"`
+	SourceOS    *ImageSource       `yaml:"sourceOS" validate:"required,not_empty_source,signature_verification"`
+	Disks       []*Disk            `yaml:"disks" validate:"required,min=1,system_partition,multiple_system_partitions,efi_partition,multiple_efi_partitions,recovery_partition,swap_partition,var_partition,last_partition_size,rw_volumes,unique_mountpoints,reused_partitions,unique_devices,dive"`
 	Firmware    *FirmwareConfig    `yaml:"firmware"`
 	BootConfig  *BootConfig        `yaml:"bootloader"`
-	Security    *SecurityConfig    `yaml:"security" validate:"required"`
+	Security    *SecurityConfig    `yaml:"security" validate:"required,encryption"`
 	Snapshotter *SnapshotterConfig `yaml:"snapshotter"`
+	Compression *CompressionConfig `yaml:"compression,omitempty"`
+	Swap        *SwapConfig        `yaml:"swap,omitempty" validate:"omitempty,swap"`
 	OverlayTree *ImageSource       `yaml:"overlayTree,omitempty"`
 	CfgScript   string             `yaml:"configScript,omitempty"`
+	FirstBoot   *FirstBootConfig   `yaml:"firstBoot,omitempty" validate:"omitempty,first_boot"`
 	Installer   LiveInstaller      `yaml:"installer,omitempty"`
 }
 
@@ -270,18 +479,31 @@ var validate = validator.New()
 
 func init() {
 	_ = validate.RegisterValidation("not_empty_source", validateNotEmptySource)
+	_ = validate.RegisterValidation("signature_verification", validateSignatureVerification)
+	_ = validate.RegisterValidation("usage_threshold", validateUsageThreshold)
 	_ = validate.RegisterValidation("system_partition", validateSystemPartition)
 	_ = validate.RegisterValidation("multiple_system_partitions", validateMultipleSystemPartitions)
 	_ = validate.RegisterValidation("efi_partition", validateEFIPartition)
 	_ = validate.RegisterValidation("multiple_efi_partitions", validateMultipleEFIPartitions)
 	_ = validate.RegisterValidation("recovery_partition", validateRecoveryPartition)
+	_ = validate.RegisterValidation("swap_partition", validateSwapPartition)
+	_ = validate.RegisterValidation("var_partition", validateVarPartition)
 	_ = validate.RegisterValidation("last_partition_size", validateLastPartitionSize)
 	_ = validate.RegisterValidation("rw_volumes", validateRWVolumes)
+	_ = validate.RegisterValidation("unique_mountpoints", validateUniqueMountPoints)
+	_ = validate.RegisterValidation("reused_partitions", validateReusedPartitions)
 	_ = validate.RegisterValidation("crypto_policy", validateCryptoPolicy)
+	_ = validate.RegisterValidation("network_unlock", validateNetworkUnlock)
+	_ = validate.RegisterValidation("encryption", validateEncryption)
+	_ = validate.RegisterValidation("compression", validateCompression)
+	_ = validate.RegisterValidation("swap", validateSwap)
+	_ = validate.RegisterValidation("first_boot", validateFirstBoot)
 	_ = validate.RegisterValidation("abspath", validateAbsPath)
+	_ = validate.RegisterValidation("wipe_policy", validateWipePolicy)
 	_ = validate.RegisterValidationCtx("disk_device_exists", validateDiskDeviceExists)
 	_ = validate.RegisterValidationCtx("disk_device_required", validateDiskDeviceRequired)
 	_ = validate.RegisterValidationCtx("recovery_mountpoint", validateRecoveryMountPoint)
+	_ = validate.RegisterValidationCtx("unique_devices", validateUniqueDevices)
 }
 
 func validateNotEmptySource(fl validator.FieldLevel) bool {
@@ -299,6 +521,26 @@ func validateNotEmptySource(fl validator.FieldLevel) bool {
 	return !src.IsEmpty()
 }
 
+func validateSignatureVerification(fl validator.FieldLevel) bool {
+	src, ok := fl.Field().Interface().(*ImageSource)
+	if !ok {
+		srcVal, ok := fl.Field().Interface().(ImageSource)
+		if !ok {
+			return false
+		}
+		src = &srcVal
+	}
+	if src == nil || src.VerifySignature == nil {
+		return true
+	}
+	return src.IsOCI() && src.VerifySignature.Validate() == nil
+}
+
+func validateUsageThreshold(fl validator.FieldLevel) bool {
+	threshold := fl.Field().Int()
+	return threshold >= 0 && threshold < 100
+}
+
 func validateSystemPartition(fl validator.FieldLevel) bool {
 	disks, ok := fl.Field().Interface().([]*Disk)
 	if !ok {
@@ -414,6 +656,56 @@ func validateRecoveryPartition(fl validator.FieldLevel) bool {
 	return count <= 1
 }
 
+func validateVarPartition(fl validator.FieldLevel) bool {
+	disks, ok := fl.Field().Interface().([]*Disk)
+	if !ok {
+		disk, ok := fl.Field().Interface().(Disk)
+		if !ok {
+			return false
+		}
+		disks = []*Disk{&disk}
+	}
+	var count int
+	for _, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part == nil || part.MountPoint != VarMnt {
+				continue
+			}
+			if part.Role != Generic || !slices.Contains([]FileSystem{Btrfs, Ext4, XFS}, part.FileSystem) {
+				return false
+			}
+			count++
+		}
+	}
+	return count <= 1
+}
+
+func validateSwapPartition(fl validator.FieldLevel) bool {
+	disks, ok := fl.Field().Interface().([]*Disk)
+	if !ok {
+		disk, ok := fl.Field().Interface().(Disk)
+		if !ok {
+			return false
+		}
+		disks = []*Disk{&disk}
+	}
+	var count int
+	for _, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.Role == Swap {
+				count++
+			}
+		}
+	}
+	return count <= 1
+}
+
 func validateLastPartitionSize(fl validator.FieldLevel) bool {
 	disks, ok := fl.Field().Interface().([]*Disk)
 	if !ok {
@@ -485,6 +777,39 @@ func validateRWVolumes(fl validator.FieldLevel) bool {
 	return true
 }
 
+func validateUniqueMountPoints(fl validator.FieldLevel) bool {
+	disks, ok := fl.Field().Interface().([]*Disk)
+	if !ok {
+		disk, ok := fl.Field().Interface().(Disk)
+		if !ok {
+			return false
+		}
+		disks = []*Disk{&disk}
+	}
+	return checkMountPoints(disks) == nil
+}
+
+func validateUniqueDevices(ctx context.Context, fl validator.FieldLevel) bool {
+	disks, ok := fl.Field().Interface().([]*Disk)
+	if !ok {
+		return false
+	}
+	s, _ := ctx.Value(contextKeySystem).(*sys.System)
+	return checkUniqueDevices(s, disks) == nil
+}
+
+func validateReusedPartitions(fl validator.FieldLevel) bool {
+	disks, ok := fl.Field().Interface().([]*Disk)
+	if !ok {
+		disk, ok := fl.Field().Interface().(Disk)
+		if !ok {
+			return false
+		}
+		disks = []*Disk{&disk}
+	}
+	return checkReusedPartitions(disks) == nil
+}
+
 func validateCryptoPolicy(fl validator.FieldLevel) bool {
 	policy, ok := fl.Field().Interface().(crypto.Policy)
 	if !ok {
@@ -493,10 +818,66 @@ func validateCryptoPolicy(fl validator.FieldLevel) bool {
 	return policy.IsValid()
 }
 
+func validateNetworkUnlock(fl validator.FieldLevel) bool {
+	cfg, ok := fl.Field().Interface().(clevis.Config)
+	if !ok {
+		return false
+	}
+	return cfg.Validate() == nil
+}
+
+func validateEncryption(fl validator.FieldLevel) bool {
+	d, ok := fl.Parent().Interface().(Deployment)
+	if !ok {
+		dPtr, ok := fl.Parent().Interface().(*Deployment)
+		if !ok {
+			return false
+		}
+		d = *dPtr
+	}
+	return d.checkEncryption() == nil
+}
+
+func validateCompression(fl validator.FieldLevel) bool {
+	c, ok := fl.Field().Interface().(Compression)
+	if !ok {
+		return false
+	}
+	return c.Validate() == nil
+}
+
+func validateSwap(fl validator.FieldLevel) bool {
+	d, ok := fl.Parent().Interface().(Deployment)
+	if !ok {
+		dPtr, ok := fl.Parent().Interface().(*Deployment)
+		if !ok {
+			return false
+		}
+		d = *dPtr
+	}
+	return d.checkSwap() == nil
+}
+
+func validateFirstBoot(fl validator.FieldLevel) bool {
+	d, ok := fl.Parent().Interface().(Deployment)
+	if !ok {
+		dPtr, ok := fl.Parent().Interface().(*Deployment)
+		if !ok {
+			return false
+		}
+		d = *dPtr
+	}
+	return d.checkFirstBoot() == nil
+}
+
 func validateAbsPath(fl validator.FieldLevel) bool {
 	return filepath.IsAbs(fl.Field().String())
 }
 
+func validateWipePolicy(fl validator.FieldLevel) bool {
+	return WipePolicy(fl.Field().String()).IsValid()
+}
+
 func validateDiskDeviceExists(ctx context.Context, fl validator.FieldLevel) bool {
 	if skip, ok := ctx.Value(contextKeySkipDiskDeviceExists).(bool); ok && skip {
 		return true
@@ -509,6 +890,17 @@ func validateDiskDeviceExists(ctx context.Context, fl validator.FieldLevel) bool
 	if device == "" {
 		return true
 	}
+	// raw disk image files are created at install time
+	switch disk := fl.Parent().Interface().(type) {
+	case Disk:
+		if disk.Size > 0 {
+			return true
+		}
+	case *Disk:
+		if disk.Size > 0 {
+			return true
+		}
+	}
 	exists, _ := vfs.Exists(s.FS(), device)
 	return exists
 }
@@ -585,6 +977,16 @@ func (d Disk) GetSystemPartition() *Partition {
 	return nil
 }
 
+// HasReusedPartitions returns true if any partition of the disk is flagged for reuse
+func (d Disk) HasReusedPartitions() bool {
+	for _, part := range d.Partitions {
+		if part != nil && part.Reuse {
+			return true
+		}
+	}
+	return false
+}
+
 // GetSystemPartition returns the system partition from the disk.
 // returns nil if not found.
 func (d Deployment) GetSystemPartition() *Partition {
@@ -659,7 +1061,66 @@ func (d Deployment) GetRecoveryPartition() *Partition {
 	return nil
 }
 
-// GetSystemDisk gets the disk data including the system partition.
+// GetConfigPartition gets the data of the config partition.
+// returns nil if not found
+func (d Deployment) GetConfigPartition() *Partition {
+	for _, disk := range d.Disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.Role == Config {
+				return part
+			}
+		}
+	}
+	return nil
+}
+
+// GetVarPartition gets the data of the dedicated partition mounted at /var.
+// returns nil if /var is not a partition on its own
+func (d Deployment) GetVarPartition() *Partition {
+	for _, disk := range d.Disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.Role == Generic && part.MountPoint == VarMnt {
+				return part
+			}
+		}
+	}
+	return nil
+}
+
+// GetSwapPartition gets the data of the swap partition.
+// returns nil if not found
+func (d Deployment) GetSwapPartition() *Partition {
+	for _, disk := range d.Disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.Role == Swap {
+				return part
+			}
+		}
+	}
+	return nil
+}
+
+// GetEncryptedPartitions gets the partitions created within a LUKS volume
+func (d Deployment) GetEncryptedPartitions() Partitions {
+	var parts Partitions
+	for _, part := range d.GetAllPartitions() {
+		if part.Encrypted {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// GetEfiDisk gets the disk data including the EFI partition.
 // returns nil if not found
 func (d Deployment) GetEfiDisk() *Disk {
 	for _, disk := range d.Disks {
@@ -691,6 +1152,24 @@ func (d Deployment) RecoveryKernelCmdline() string {
 	return fmt.Sprintf("%s %s", LiveKernelCmdline(label), RecoveryMark)
 }
 
+// GetAllPartitions returns all partitions in all disks, in the order they are defined
+func (d Deployment) GetAllPartitions() Partitions {
+	var parts Partitions
+
+	for _, disk := range d.Disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil {
+				parts = append(parts, part)
+			}
+		}
+	}
+
+	return parts
+}
+
 // GetSELinuxSupportedPartitions returns all partitions in all disks that
 // support SELinux security labels
 func (d Deployment) GetSELinuxSupportedPartitions() Partitions {
@@ -701,7 +1180,7 @@ func (d Deployment) GetSELinuxSupportedPartitions() Partitions {
 			continue
 		}
 		for _, part := range disk.Partitions {
-			if part.FileSystem != VFat {
+			if part.FileSystem != VFat && part.Role != Swap {
 				parts = append(parts, part)
 			}
 		}
@@ -719,6 +1198,14 @@ func (d *Deployment) setDefaults(s *sys.System) {
 			if part == nil {
 				continue
 			}
+			if part.Role == Swap {
+				// Swap partitions are formatted as swap space and never mounted
+				part.FileSystem = FileSystem(0)
+				part.MountPoint = ""
+				part.MountOpts = nil
+				part.RWVolumes = nil
+				continue
+			}
 			if part.Role == System {
 				if part.MountPoint != SystemMnt {
 					s.Logger().Warn("custom mountpoints for the system partition are not supported")
@@ -767,11 +1254,58 @@ func (d *Deployment) setDefaults(s *sys.System) {
 					part.Label = RecoveryLabel
 				}
 			}
+			if part.Role == Generic && part.MountPoint == VarMnt && part.FileSystem.String() == Unknown {
+				part.FileSystem = XFS
+			}
 			if part.FileSystem.String() == Unknown {
 				part.FileSystem = Btrfs
 			}
 		}
 	}
+	d.setVarPartitionDefaults(s)
+	d.setSwapDefaults(s)
+}
+
+// setSwapDefaults adds the rw volume holding the swap file to the system partition, copy on write
+// is disabled as btrfs does not support swap files otherwise
+func (d *Deployment) setSwapDefaults(s *sys.System) {
+	sysPart := d.GetSystemPartition()
+	if d.Swap == nil || d.Swap.Type != SwapFileType || sysPart == nil || sysPart.FileSystem != Btrfs {
+		return
+	}
+
+	idx := slices.IndexFunc(sysPart.RWVolumes, func(v RWVolume) bool { return v.Path == SwapVolume })
+	if idx < 0 {
+		s.Logger().Info("added '%s' rw volume to the system partition to hold the swap file", SwapVolume)
+		sysPart.RWVolumes = append(sysPart.RWVolumes, RWVolume{Path: SwapVolume, NoCopyOnWrite: true})
+		return
+	}
+	sysPart.RWVolumes[idx].Snapshotted = false
+	sysPart.RWVolumes[idx].NoCopyOnWrite = true
+}
+
+// setVarPartitionDefaults replaces the /var rw volume of the system partition by the dedicated /var
+// partition, if any. The partition is mounted from the initrd as the rw volume is.
+func (d *Deployment) setVarPartitionDefaults(s *sys.System) {
+	varPart := d.GetVarPartition()
+	sysPart := d.GetSystemPartition()
+	if varPart == nil || sysPart == nil {
+		return
+	}
+
+	sysPart.RWVolumes = slices.DeleteFunc(sysPart.RWVolumes, func(v RWVolume) bool {
+		if v.Path == VarMnt {
+			s.Logger().Info("dropped '%s' rw volume of the system partition in favor of a dedicated partition", VarMnt)
+			return true
+		}
+		return false
+	})
+	if len(varPart.MountOpts) == 0 {
+		varPart.MountOpts = []string{"defaults"}
+	}
+	if !slices.Contains(varPart.MountOpts, initrdMnt) {
+		varPart.MountOpts = append(varPart.MountOpts, initrdMnt)
+	}
 }
 
 // Sanitize checks the consistency of the current Disk structure. ExcludeChecks parameter
@@ -813,16 +1347,51 @@ func (d *Deployment) formatValidationErrors(s *sys.System, errs validator.Valida
 			return fmt.Errorf("multiple 'efi' partitions defined, there must be only one")
 		case "recovery_partition":
 			return fmt.Errorf("multiple 'recovery' partitions defined, there can be only one")
+		case "swap_partition":
+			return fmt.Errorf("multiple 'swap' partitions defined, there can be only one")
+		case "var_partition":
+			return fmt.Errorf("'%s' can only be mounted from a single generic partition formatted with xfs, ext4 or btrfs", VarMnt)
 		case "recovery_mountpoint":
 			return fmt.Errorf("custom mountpoints for the recovery partition are not supported")
 		case "last_partition_size":
 			return fmt.Errorf("only last partition can be defined to be as big as available size in disk")
 		case "rw_volumes":
 			return d.checkRWVolumes()
+		case "unique_mountpoints":
+			return checkMountPoints(d.Disks)
+		case "reused_partitions":
+			return checkReusedPartitions(d.Disks)
+		case "unique_devices":
+			return checkUniqueDevices(s, d.Disks)
 		case "crypto_policy":
 			return fmt.Errorf("invalid crypto policy: %s", d.Security.CryptoPolicy)
+		case "network_unlock":
+			return fmt.Errorf("invalid network unlock configuration: %w", d.Security.NetworkUnlock.Validate())
+		case "encryption":
+			return fmt.Errorf("invalid encryption configuration: %w", d.checkEncryption())
+		case "compression":
+			if d.Compression.Initrd != nil && d.Compression.Initrd.Validate() != nil {
+				return fmt.Errorf("invalid initrd compression: %w", d.Compression.Initrd.Validate())
+			}
+			return fmt.Errorf("invalid images compression: %w", d.Compression.Images.Validate())
+		case "swap":
+			return fmt.Errorf("invalid swap configuration: %w", d.checkSwap())
+		case "first_boot":
+			return fmt.Errorf("invalid first boot configuration: %w", d.checkFirstBoot())
+		case "wipe_policy":
+			return fmt.Errorf(
+				"invalid wipe policy '%s', supported policies: %s, %s, %s, %s, %s", e.Value(),
+				WipeTable, WipeZapAll, WipeDiscard, WipeSecureDiscard, WipeKeepData,
+			)
 		case "not_empty_source":
 			return fmt.Errorf("no OS image defined in deployment")
+		case "signature_verification":
+			if !d.SourceOS.IsOCI() {
+				return fmt.Errorf("signature verification is only supported for OCI images")
+			}
+			return fmt.Errorf("invalid OS image signature verification: %w", d.SourceOS.VerifySignature.Validate())
+		case "usage_threshold":
+			return fmt.Errorf("snapshot cleanup threshold must be a percentage below 100, got %d", d.Snapshotter.CleanupThreshold)
 		case "disk_device_required":
 			for i, disk := range d.Disks {
 				if disk.Device == "" {
@@ -831,7 +1400,7 @@ func (d *Deployment) formatValidationErrors(s *sys.System, errs validator.Valida
 			}
 		case "disk_device_exists":
 			for i, disk := range d.Disks {
-				if ok, _ := vfs.Exists(s.FS(), disk.Device); !ok {
+				if ok, _ := vfs.Exists(s.FS(), disk.Device); !ok && disk.Size == 0 {
 					return fmt.Errorf("device '%s' for disk %d not found", disk.Device, i)
 				}
 			}
@@ -840,6 +1409,17 @@ func (d *Deployment) formatValidationErrors(s *sys.System, errs validator.Valida
 	return errs[0] // Fallback to the first error if no specific tag is matched
 }
 
+// checkFirstBoot checks the config partition is defined for first boot configurations stored in it
+func (d *Deployment) checkFirstBoot() error {
+	if d.FirstBoot == nil {
+		return nil
+	}
+	if (d.FirstBoot.Ignition != "" || d.FirstBoot.Combustion != "") && d.GetConfigPartition() == nil {
+		return fmt.Errorf("ignition and combustion configurations require a '%s' partition", Config)
+	}
+	return nil
+}
+
 // checkRWVolumes is kept as a helper for specific error messages when validator fails
 func (d *Deployment) checkRWVolumes() error {
 	pathMap := map[string]bool{}
@@ -870,6 +1450,111 @@ func (d *Deployment) checkRWVolumes() error {
 	return nil
 }
 
+// checkMountPoints checks no mount point is shared by multiple partitions, regardless of their disks
+// checkReusedPartitions checks the partitions to reuse can be identified and survive the wipe policy of
+// their disk. The system and recovery partitions are always recreated.
+func checkReusedPartitions(disks []*Disk) error {
+	for _, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part == nil || !part.Reuse {
+				continue
+			}
+			switch {
+			case part.Role == System || part.Role == Recovery:
+				return fmt.Errorf("'%s' partitions can't be reused", part.Role)
+			case part.Label == "" && part.UUID == "":
+				return fmt.Errorf("reused '%s' partitions require a label or a UUID", part.Role)
+			case disk.Wipe != "" && disk.Wipe != WipeTable && disk.Wipe != WipeKeepData:
+				return fmt.Errorf("partitions can't be reused on disks with the '%s' wipe policy", disk.Wipe)
+			}
+		}
+	}
+	return nil
+}
+
+func checkMountPoints(disks []*Disk) error {
+	mountPoints := map[string]bool{}
+	for _, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part == nil || part.MountPoint == "" {
+				continue
+			}
+			mountPoint := filepath.Clean(part.MountPoint)
+			if mountPoints[mountPoint] {
+				return fmt.Errorf("mount point '%s' is defined for multiple partitions", mountPoint)
+			}
+			mountPoints[mountPoint] = true
+		}
+	}
+	return nil
+}
+
+// checkUniqueDevices checks no device is the target of multiple disks. Symlinks, such as the
+// /dev/disk/by-id paths, are resolved if a system is given.
+func checkUniqueDevices(s *sys.System, disks []*Disk) error {
+	devices := map[string]int{}
+	for i, disk := range disks {
+		if disk == nil || disk.Device == "" {
+			continue
+		}
+		device := filepath.Clean(disk.Device)
+		if s != nil {
+			if resolved, err := vfs.ResolveLink(s.FS(), device, "/", vfs.MaxLinkDepth); err == nil {
+				device = resolved
+			}
+		}
+		if j, ok := devices[device]; ok {
+			return fmt.Errorf("device '%s' is the target of disks %d and %d", disk.Device, j, i)
+		}
+		devices[device] = i
+	}
+	return nil
+}
+
+// checkSwap checks the swap configuration is consistent with the partitions and the snapshotter
+func (d *Deployment) checkSwap() error {
+	if d.Swap == nil {
+		return nil
+	}
+	swapPart := d.GetSwapPartition()
+
+	switch d.Swap.Type {
+	case SwapPartition:
+		if swapPart == nil {
+			return fmt.Errorf("no 'swap' partition defined")
+		}
+		if d.Swap.Size != 0 {
+			return fmt.Errorf("the size of the swap space is defined by the 'swap' partition")
+		}
+		return nil
+	case SwapFileType:
+		sysPart := d.GetSystemPartition()
+		if sysPart == nil || sysPart.FileSystem != Btrfs || (d.Snapshotter != nil && d.Snapshotter.Name != "snapper") {
+			return fmt.Errorf("swap files require a btrfs system partition handled by the snapper snapshotter")
+		}
+		if d.Swap.Size == 0 {
+			return fmt.Errorf("the size of the swap file is required")
+		}
+	case SwapZram:
+		if d.Swap.Hibernation {
+			return fmt.Errorf("hibernation is not supported on zram devices")
+		}
+	default:
+		return fmt.Errorf("unsupported swap type '%s', supported types: %s, %s, %s", d.Swap.Type, SwapPartition, SwapFileType, SwapZram)
+	}
+
+	if swapPart != nil {
+		return fmt.Errorf("'swap' partition defined for '%s' swap type", d.Swap.Type)
+	}
+	return nil
+}
+
 // Dummy function to keep compatibility with existing code using these variables
 var (
 	CheckDiskDevice SanitizeDeployment = func(*sys.System, *Deployment) error { return nil }
@@ -880,6 +1565,44 @@ func (d *Deployment) IsFipsEnabled() bool {
 	return d.Security.CryptoPolicy == crypto.FIPSPolicy
 }
 
+// checkEncryption verifies encrypted partitions are supported and their volumes can be unlocked
+func (d *Deployment) checkEncryption() error {
+	encrypted := d.GetEncryptedPartitions()
+	for _, part := range encrypted {
+		if part.Role != Generic || len(part.RWVolumes) > 0 {
+			return fmt.Errorf("partition '%s' can not be encrypted, only generic partitions without RW volumes are supported", part.Label)
+		}
+	}
+	if len(encrypted) > 0 && (d.Security == nil || d.Security.LUKSKeyFile == "") {
+		return fmt.Errorf("encrypted partitions require a LUKS key file")
+	}
+	if d.IsNetworkUnlockEnabled() && len(encrypted) == 0 {
+		return fmt.Errorf("network unlock requires at least one encrypted partition")
+	}
+	return nil
+}
+
+// IsNetworkUnlockEnabled returns true if LUKS volumes are unlocked over the network, otherwise false.
+func (d *Deployment) IsNetworkUnlockEnabled() bool {
+	return d.Security != nil && d.Security.NetworkUnlock != nil
+}
+
+// InitrdCompression returns the compression of the initrd, nil if not set
+func (d *Deployment) InitrdCompression() *Compression {
+	if d.Compression == nil {
+		return nil
+	}
+	return d.Compression.Initrd
+}
+
+// ImagesCompression returns the compression of the filesystem images, nil if not set
+func (d *Deployment) ImagesCompression() *Compression {
+	if d.Compression == nil {
+		return nil
+	}
+	return d.Compression.Images
+}
+
 // DeepCopy returns deep copy of the current Deployment object. Note the deep copy
 // is based on yaml.Marshal and yaml.Unmarshal, hence it is subject to the defined
 // marshalling behavior with custom marshallers and type decorators.
@@ -930,6 +1653,8 @@ func (d *Deployment) WriteDeploymentFile(s *sys.System, root string) error {
 	dep.OverlayTree = nil
 	dep.CfgScript = ""
 	dep.Installer = LiveInstaller{}
+	// first boot configurations are only applied on installation
+	dep.FirstBoot = nil
 
 	// omit initrd extensions as this is a runtime information which might not be consistent on reboots
 	if dep.BootConfig != nil {
@@ -944,7 +1669,7 @@ func (d *Deployment) WriteDeploymentFile(s *sys.System, root string) error {
 	dataStr := string(data)
 	dataStr = "# self-generated content, do not edit\n\n" + dataStr
 
-	err = s.FS().WriteFile(path, []byte(dataStr), 0444)
+	err = vfs.WriteFileAtomic(s.FS(), path, []byte(dataStr), 0444)
 	if err != nil {
 		return fmt.Errorf("writing deployment file '%s': %w", path, err)
 	}
@@ -26,6 +26,7 @@ import (
 
 	"go.yaml.in/yaml/v3"
 
+	"github.com/suse/elemental/v3/pkg/clevis"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/sys"
@@ -49,7 +50,8 @@ var _ = Describe("Deployment", Label("deployment"), func() {
 		BeforeEach(func() {
 			buffer = &bytes.Buffer{}
 			tfs, cleanup, err = sysmock.TestFS(map[string]string{
-				"/dev/device": "device",
+				"/dev/device":  "device",
+				"/dev/device2": "device",
 			})
 			Expect(err).NotTo(HaveOccurred())
 			s, err = sys.NewSystem(
@@ -80,6 +82,13 @@ var _ = Describe("Deployment", Label("deployment"), func() {
 			d.Disks[0].Device = "/dev/nonexisting"
 			Expect(d.Sanitize(s)).NotTo(Succeed())
 		})
+		It("accepts a non existing raw disk image file with a size", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/build/disk.raw"
+			d.Disks[0].Size = 4096
+			Expect(d.Sanitize(s)).To(Succeed())
+		})
 		It("creates a default deployment with a configuration partition and without a device assigned", func() {
 			d := deployment.New(deployment.WithConfigPartition(127))
 			d.SourceOS = deployment.NewDirSrc("/some/dir")
@@ -138,6 +147,273 @@ var _ = Describe("Deployment", Label("deployment"), func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("no 'system'"))
 		})
+		It("fails if the OS image signature can't be verified", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.SourceOS.VerifySignature = &deployment.SignatureVerification{Key: "/etc/cosign.pub"}
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("only supported for OCI images")))
+
+			d.SourceOS = deployment.NewOCISrc("registry.org/my/os:latest")
+			d.SourceOS.VerifySignature = &deployment.SignatureVerification{Issuer: "https://accounts.example.com"}
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("requires a key or a certificate identity")))
+		})
+		It("fails if the snapshot cleanup threshold is not a valid percentage", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Snapshotter.CleanupThreshold = 80
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.Snapshotter.CleanupThreshold = 120
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("percentage below 100, got 120")))
+		})
+		It("validates the snapshot retention policy", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Snapshotter.Retention = &deployment.RetentionPolicy{KeepLast: 4, KeepImportant: true}
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.Snapshotter.Retention.KeepLast = -1
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("KeepLast")))
+		})
+		It("validates the number of boot tries", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.BootConfig.BootTries = 3
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.BootConfig.BootTries = 10
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("BootTries")))
+		})
+		It("validates the network unlock policy", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Security.NetworkUnlock = &clevis.Config{
+				Servers: []clevis.TangServer{{URL: "http://tang.example.com"}},
+				Policy:  clevis.PolicyTangAndTPM,
+			}
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("network unlock requires at least one encrypted partition")))
+
+			d.GetSystemPartition().Size = 4096
+			d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+				Role: deployment.Generic, MountPoint: "/data", FileSystem: deployment.Ext4, Encrypted: true,
+			})
+			d.Security.LUKSKeyFile = "/etc/luks.key"
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.Security.NetworkUnlock.Threshold = 2
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("higher than the number of tang servers")))
+
+			d.Security.NetworkUnlock.Threshold = 1
+			d.Security.NetworkUnlock.Policy = "tpm2"
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("invalid unlock policy 'tpm2'")))
+		})
+		It("validates the encrypted partitions", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			data := &deployment.Partition{
+				Role: deployment.Generic, MountPoint: "/data", FileSystem: deployment.Ext4, Encrypted: true, UUID: "uuid",
+			}
+			d.GetSystemPartition().Size = 4096
+			d.Disks[0].Partitions = append(d.Disks[0].Partitions, data)
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("encrypted partitions require a LUKS key file")))
+
+			d.Security.LUKSKeyFile = "/etc/luks.key"
+			Expect(d.Sanitize(s)).To(Succeed())
+			Expect(d.GetEncryptedPartitions()).To(Equal(deployment.Partitions{data}))
+			Expect(data.LUKSDevice()).To(Equal("/dev/disk/by-partuuid/uuid"))
+			Expect(data.FstabDevice()).To(Equal("/dev/mapper/luks-uuid"))
+
+			d.GetSystemPartition().Encrypted = true
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("only generic partitions without RW volumes are supported")))
+		})
+		It("validates the compression of generated images", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Compression = &deployment.CompressionConfig{
+				Initrd: &deployment.Compression{Algorithm: deployment.Zstd, Level: 19},
+				Images: &deployment.Compression{Algorithm: deployment.Xz},
+			}
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.Compression.Initrd.Level = 23
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("invalid initrd compression")))
+
+			d.Compression.Initrd.Level = 0
+			d.Compression.Images.Level = 9
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("invalid images compression")))
+
+			d.Compression.Images = &deployment.Compression{Algorithm: "lz4"}
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("unsupported compression algorithm 'lz4'")))
+		})
+		It("validates the swap configuration", func() {
+			d := deployment.New(deployment.WithPartitions(1, &deployment.Partition{
+				Role: deployment.Swap, Size: 4096, MountPoint: "/swap",
+			}))
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Swap = &deployment.SwapConfig{Type: deployment.SwapPartition, Hibernation: true}
+			Expect(d.Sanitize(s)).To(Succeed())
+			Expect(d.GetSwapPartition().MountPoint).To(BeEmpty())
+			Expect(d.GetSwapPartition().FileSystem).To(Equal(deployment.FileSystem(0)))
+
+			d.Swap = &deployment.SwapConfig{Type: deployment.SwapZram}
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("'swap' partition defined for 'zram' swap type")))
+
+			d = deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Swap = &deployment.SwapConfig{Type: deployment.SwapPartition}
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("no 'swap' partition defined")))
+
+			d.Swap = &deployment.SwapConfig{Type: deployment.SwapZram, Hibernation: true}
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("hibernation is not supported on zram")))
+
+			d.Swap = &deployment.SwapConfig{Type: "disk"}
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("unsupported swap type 'disk'")))
+
+			d.Swap = &deployment.SwapConfig{Type: deployment.SwapFileType}
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("size of the swap file is required")))
+
+			d.Swap.Size = 2048
+			Expect(d.Sanitize(s)).To(Succeed())
+			Expect(d.GetSystemPartition().RWVolumes).To(ContainElement(
+				deployment.RWVolume{Path: deployment.SwapVolume, NoCopyOnWrite: true},
+			))
+
+			d.Snapshotter.Name = "overlay"
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("swap files require a btrfs system partition")))
+		})
+		It("validates the GPT type and attributes of partitions", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			sysPart := d.GetSystemPartition()
+			sysPart.TypeUUID = "4f68bce3-e8cd-4db1-96e7-fbcaf984b709"
+			sysPart.Attributes = []uint{59}
+			Expect(d.Sanitize(s)).To(Succeed())
+			Expect(sysPart.GPTAttributes()).To(Equal(uint64(1 << 59)))
+
+			sysPart.Attributes = []uint{64}
+			Expect(d.Sanitize(s)).NotTo(Succeed())
+
+			sysPart.Attributes = nil
+			sysPart.TypeUUID = "root"
+			Expect(d.Sanitize(s)).NotTo(Succeed())
+		})
+		It("replaces the /var rw volume by a dedicated partition", func() {
+			d := deployment.New(deployment.WithPartitions(1, &deployment.Partition{
+				Role: deployment.Generic, MountPoint: deployment.VarMnt, Size: 4096,
+			}))
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			varPart := d.GetVarPartition()
+			Expect(varPart).NotTo(BeNil())
+			Expect(varPart.FileSystem).To(Equal(deployment.XFS))
+			Expect(varPart.MountOpts).To(Equal([]string{"defaults", "x-initrd.mount"}))
+			for _, rwVol := range d.GetSystemPartition().RWVolumes {
+				Expect(rwVol.Path).NotTo(Equal(deployment.VarMnt))
+			}
+
+			varPart.FileSystem = deployment.VFat
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("'/var' can only be mounted from a single generic partition")))
+		})
+		It("places partitions of key roles on different disks", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks = []*deployment.Disk{
+				{Device: "/dev/device", Partitions: []*deployment.Partition{
+					{Role: deployment.EFI, Size: 1024},
+				}},
+				{Device: "/dev/device2", Partitions: []*deployment.Partition{
+					{Role: deployment.System, Size: 8192},
+					{Role: deployment.Generic, MountPoint: "/data"},
+				}},
+			}
+			Expect(d.Sanitize(s)).To(Succeed())
+			Expect(d.GetEfiDisk()).To(Equal(d.Disks[0]))
+			Expect(d.GetSystemDisk()).To(Equal(d.Disks[1]))
+			Expect(d.GetAllPartitions()).To(HaveLen(3))
+
+			d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{Role: deployment.System})
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("multiple 'system'")))
+		})
+		It("fails if a mount point is shared by partitions of different disks", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Disks = append(d.Disks, &deployment.Disk{Device: "/dev/device2", Partitions: []*deployment.Partition{
+				{Role: deployment.Generic, MountPoint: "/boot/"},
+			}})
+			Expect(d.Sanitize(s)).To(MatchError("mount point '/boot' is defined for multiple partitions"))
+		})
+		It("fails if a device is the target of multiple disks", func() {
+			Expect(vfs.MkdirAll(tfs, "/dev/disk/by-id", vfs.DirPerm)).To(Succeed())
+			Expect(tfs.Symlink("/dev/device", "/dev/disk/by-id/disk-1")).To(Succeed())
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Disks = append(d.Disks, &deployment.Disk{Device: "/dev/device/", Partitions: []*deployment.Partition{
+				{Role: deployment.Generic, MountPoint: "/data"},
+			}})
+			Expect(d.Sanitize(s)).To(MatchError("device '/dev/device/' is the target of disks 0 and 1"))
+
+			d.Disks[1].Device = "/dev/disk/by-id/disk-1"
+			Expect(d.Sanitize(s)).To(MatchError("device '/dev/disk/by-id/disk-1' is the target of disks 0 and 1"))
+
+			d.Disks[1].Device = "/dev/device2"
+			Expect(d.Sanitize(s)).To(Succeed())
+		})
+		It("validates the wipe policy of disks", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Disks[0].Wipe = deployment.WipeZapAll
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.Disks[0].Wipe = "shred"
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("invalid wipe policy 'shred'")))
+		})
+		It("validates the partitions to reuse", func() {
+			d := deployment.New(deployment.WithPartitions(1, &deployment.Partition{
+				Role: deployment.Generic, Label: "HOME", MountPoint: "/home", Size: 4096, Reuse: true,
+			}))
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			Expect(d.Sanitize(s)).To(Succeed())
+			Expect(d.Disks[0].HasReusedPartitions()).To(BeTrue())
+
+			d.Disks[0].Wipe = deployment.WipeDiscard
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("can't be reused on disks with the 'discard' wipe policy")))
+
+			d.Disks[0].Wipe = ""
+			d.GetSystemPartition().Reuse = true
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("'system' partitions can't be reused")))
+		})
+		It("validates the first boot configuration", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.FirstBoot = &deployment.FirstBootConfig{CloudInit: "/some/user-data"}
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.FirstBoot.Ignition = "/some/config.ign"
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("require a 'config' partition")))
+
+			d = deployment.New(deployment.WithConfigPartition(0))
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.FirstBoot = &deployment.FirstBootConfig{Ignition: "/some/config.ign", Combustion: "/some/script"}
+			Expect(d.Sanitize(s)).To(Succeed())
+		})
 		It("fails if no efi partition is defined", func() {
 			d := &deployment.Deployment{
 				Disks: []*deployment.Disk{
@@ -176,9 +452,11 @@ var _ = Describe("Deployment", Label("deployment"), func() {
 				KernelCmdline:    "kernel parameters",
 				InitrdExtensions: []string{"/path/to/initrd/extension"},
 			}
+			d.FirstBoot = &deployment.FirstBootConfig{CloudInit: "/some/user-data"}
 			Expect(d.WriteDeploymentFile(s, "/some/dir")).To(Succeed())
 			rD, err := deployment.Parse(s, "/some/dir")
 			Expect(err).NotTo(HaveOccurred())
+			Expect(rD.FirstBoot).To(BeNil())
 			Expect(len(rD.Disks)).To(Equal(1))
 			Expect(rD.Disks[0].Device).To(BeEmpty())
 			Expect(rD.BootConfig).NotTo(BeNil())
@@ -250,7 +528,7 @@ var _ = Describe("Deployment", Label("deployment"), func() {
 			Expect(err).To(HaveOccurred())
 		})
 		It("Un/marshals PartRole", func() {
-			roles := []string{"efi", "system", "recovery", "config", "generic"}
+			roles := []string{"efi", "system", "recovery", "config", "generic", "swap"}
 			var r deployment.PartRole
 
 			for _, role := range roles {
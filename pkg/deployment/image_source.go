@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/url"
 	"path/filepath"
+	"strings"
 
 	"github.com/distribution/reference"
 	"go.yaml.in/yaml/v3"
@@ -33,6 +34,9 @@ const (
 	OCI
 	Raw
 	Tar
+	ContainersStorage
+	Containerd
+	Squash
 )
 
 func ParseSrcImageType(i string) (ImageSrcType, error) {
@@ -45,6 +49,12 @@ func ParseSrcImageType(i string) (ImageSrcType, error) {
 		return Raw, nil
 	case "tar":
 		return Tar, nil
+	case "containers-storage":
+		return ContainersStorage, nil
+	case "containerd":
+		return Containerd, nil
+	case "squash":
+		return Squash, nil
 	default:
 		return ImageSrcType(0), fmt.Errorf("image source type not supported: %s", i)
 	}
@@ -60,15 +70,59 @@ func (i ImageSrcType) String() string {
 		return "raw"
 	case Tar:
 		return "tar"
+	case ContainersStorage:
+		return "containers-storage"
+	case Containerd:
+		return "containerd"
+	case Squash:
+		return "squash"
 	default:
 		return Unknown
 	}
 }
 
+const (
+	CosignTool   = "cosign"
+	NotationTool = "notation"
+)
+
+// SignatureVerification defines how the signature of an OCI image is verified before it is used
+type SignatureVerification struct {
+	// Tool is the tool used to verify the signature, either cosign or notation. Defaults to cosign.
+	Tool string `yaml:"tool,omitempty"`
+	// Key is the path or KMS URI of the cosign public key.
+	Key string `yaml:"key,omitempty"`
+	// Identity is the certificate identity of cosign keyless signatures.
+	Identity string `yaml:"identity,omitempty"`
+	// Issuer is the OIDC issuer of the certificate of cosign keyless signatures.
+	Issuer string `yaml:"issuer,omitempty"`
+}
+
+// Validate checks the verification settings are consistent with the configured tool
+func (v SignatureVerification) Validate() error {
+	switch v.Tool {
+	case "", CosignTool:
+		if v.Key == "" && (v.Identity == "" || v.Issuer == "") {
+			return fmt.Errorf("cosign verification requires a key or a certificate identity and issuer")
+		}
+	case NotationTool:
+		if v.Key != "" || v.Identity != "" || v.Issuer != "" {
+			return fmt.Errorf("notation verification is configured by its trust policy, key and identity are not supported")
+		}
+	default:
+		return fmt.Errorf("unsupported signature verification tool '%s'", v.Tool)
+	}
+	return nil
+}
+
 type ImageSource struct {
 	uri     string
 	digest  string
 	srcType ImageSrcType
+
+	// VerifySignature, if set, requires the image signature to be verified before using the image.
+	// Only OCI images can be verified.
+	VerifySignature *SignatureVerification
 }
 
 var (
@@ -104,6 +158,35 @@ func (i ImageSource) IsTar() bool {
 	return i.srcType == Tar
 }
 
+// IsSquash returns true for squashfs image files
+func (i ImageSource) IsSquash() bool {
+	return i.srcType == Squash
+}
+
+// IsContainersStorage returns true for images read from the local podman containers storage
+func (i ImageSource) IsContainersStorage() bool {
+	return i.srcType == ContainersStorage
+}
+
+// IsContainerd returns true for images read from the local containerd image store
+func (i ImageSource) IsContainerd() bool {
+	return i.srcType == Containerd
+}
+
+// IsLocalStore returns true for images already present in a container runtime store of the host
+func (i ImageSource) IsLocalStore() bool {
+	return i.IsContainersStorage() || i.IsContainerd()
+}
+
+// IsRemote returns true for tarball sources referenced by an HTTP(S) URL
+func (i ImageSource) IsRemote() bool {
+	return i.srcType == Tar && isRemoteURL(i.uri)
+}
+
+func isRemoteURL(uri string) bool {
+	return strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://")
+}
+
 func (i ImageSource) IsEmpty() bool {
 	if i.srcType == 0 {
 		return true
@@ -147,16 +230,32 @@ func NewTarSrc(src string) *ImageSource {
 	return &ImageSource{uri: src, srcType: Tar}
 }
 
+func NewSquashSrc(src string) *ImageSource {
+	return &ImageSource{uri: src, srcType: Squash}
+}
+
+func NewContainersStorageSrc(src string) *ImageSource {
+	return &ImageSource{uri: src, srcType: ContainersStorage}
+}
+
+func NewContainerdSrc(src string) *ImageSource {
+	return &ImageSource{uri: src, srcType: Containerd}
+}
+
+// imageSource is the serialized form of an ImageSource
+type imageSource struct {
+	Digest          string                 `yaml:"digest,omitempty"`
+	URI             string                 `yaml:"uri"`
+	VerifySignature *SignatureVerification `yaml:"verifySignature,omitempty"`
+}
+
 func (i ImageSource) MarshalYAML() (any, error) {
-	type imageSource struct {
-		Digest string `yaml:"digest,omitempty"`
-		URI    string `yaml:"uri"`
-	}
 	imgSrc := imageSource{}
 	if i.digest != "" {
 		imgSrc.Digest = i.digest
 	}
 	imgSrc.URI = i.String()
+	imgSrc.VerifySignature = i.VerifySignature
 
 	n := &yaml.Node{}
 	err := n.Encode(imgSrc)
@@ -165,23 +264,31 @@ func (i ImageSource) MarshalYAML() (any, error) {
 }
 
 func (i *ImageSource) UnmarshalYAML(data *yaml.Node) (err error) {
-	imgSrc := map[string]string{}
+	imgSrc := imageSource{}
 	if err = data.Decode(&imgSrc); err != nil {
 		return err
 	}
-	if imgSrc["uri"] == "" {
+	if imgSrc.URI == "" {
 		return fmt.Errorf("no 'uri' provided for the image source: %s", string(data.Value))
 	}
 
-	err = i.updateFromURI(imgSrc["uri"])
+	err = i.updateFromURI(imgSrc.URI)
 	if err != nil {
 		return err
 	}
-	i.digest = imgSrc["digest"]
+	i.digest = imgSrc.Digest
+	i.VerifySignature = imgSrc.VerifySignature
 	return err
 }
 
 func (i *ImageSource) updateFromURI(uri string) error {
+	// remote tarballs keep their full URL, including the checksum fragment if any
+	if rest, ok := strings.CutPrefix(uri, Tar.String()+"://"); ok && isRemoteURL(rest) {
+		i.srcType = Tar
+		i.uri = rest
+		return nil
+	}
+
 	u, err := url.Parse(uri)
 	if err != nil {
 		return err
@@ -205,7 +312,7 @@ func (i *ImageSource) updateFromURI(uri string) error {
 		i.uri = uri
 		return nil
 	}
-	if srcType == OCI {
+	if srcType == OCI || srcType == ContainersStorage || srcType == Containerd {
 		uri, err = parseImageReference(value)
 		if err != nil {
 			return err
@@ -55,6 +55,45 @@ var _ = Describe("Image Source", Label("imagesource"), func() {
 		Expect(imgsrc.IsRaw()).To(BeFalse())
 		Expect(imgsrc.URI()).To(Equal("some/path/to/directory"))
 	})
+	It("initiates a remote Tar image source from URI", func() {
+		imgsrc, err := deployment.NewSrcFromURI("tar://https://example.com/os.tar.zst#sha256=abcd")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imgsrc.String()).To(Equal("tar://https://example.com/os.tar.zst#sha256=abcd"))
+		Expect(imgsrc.IsTar()).To(BeTrue())
+		Expect(imgsrc.IsRemote()).To(BeTrue())
+		Expect(imgsrc.URI()).To(Equal("https://example.com/os.tar.zst#sha256=abcd"))
+	})
+	It("initiates a local Tar image source from URI", func() {
+		imgsrc, err := deployment.NewSrcFromURI("tar:///some/path/os.tar.xz")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imgsrc.IsTar()).To(BeTrue())
+		Expect(imgsrc.IsRemote()).To(BeFalse())
+		Expect(imgsrc.URI()).To(Equal("/some/path/os.tar.xz"))
+	})
+	It("initiates a squashfs image source from URI", func() {
+		imgsrc, err := deployment.NewSrcFromURI("squash:///some/path/os.squashfs")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imgsrc.IsSquash()).To(BeTrue())
+		Expect(imgsrc.IsRaw()).To(BeFalse())
+		Expect(imgsrc.URI()).To(Equal("/some/path/os.squashfs"))
+		Expect(imgsrc.String()).To(Equal("squash:///some/path/os.squashfs"))
+	})
+	It("initiates a containers-storage image source from URI", func() {
+		imgsrc, err := deployment.NewSrcFromURI("containers-storage://registry.suse.com/my/image")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imgsrc.IsContainersStorage()).To(BeTrue())
+		Expect(imgsrc.IsLocalStore()).To(BeTrue())
+		Expect(imgsrc.IsOCI()).To(BeFalse())
+		Expect(imgsrc.URI()).To(Equal("registry.suse.com/my/image:latest"))
+		Expect(imgsrc.String()).To(Equal("containers-storage://registry.suse.com/my/image:latest"))
+	})
+	It("initiates a containerd image source from URI", func() {
+		imgsrc, err := deployment.NewSrcFromURI("containerd://registry.suse.com/my/image:v1.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imgsrc.IsContainerd()).To(BeTrue())
+		Expect(imgsrc.IsLocalStore()).To(BeTrue())
+		Expect(imgsrc.URI()).To(Equal("registry.suse.com/my/image:v1.0"))
+	})
 	It("fails with unknown schema in URI", func() {
 		imgsrc, err := deployment.NewSrcFromURI("https://example.com/my/image")
 		Expect(err).To(HaveOccurred())
@@ -99,6 +138,28 @@ uri: oci://registry.org/my/image:latest
 		Expect(imgsrc.String()).To(Equal("raw:///path/to/image/file.raw"))
 		Expect(imgsrc.GetDigest()).To(Equal("adfasdfadsfaf"))
 	})
+	It("un/marshals the signature verification of an image source", func() {
+		imgsrc := deployment.NewEmptySrc()
+		data := `uri: oci://registry.org/my/image:latest
+verifySignature:
+    identity: builder@example.com
+    issuer: https://accounts.example.com
+`
+		Expect(yaml.Unmarshal([]byte(data), imgsrc)).To(Succeed())
+		Expect(imgsrc.VerifySignature).To(Equal(&deployment.SignatureVerification{
+			Identity: "builder@example.com", Issuer: "https://accounts.example.com",
+		}))
+		out, err := yaml.Marshal(imgsrc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(Equal(data))
+	})
+	It("validates the signature verification settings", func() {
+		Expect(deployment.SignatureVerification{Key: "/etc/cosign.pub"}.Validate()).To(Succeed())
+		Expect(deployment.SignatureVerification{Tool: deployment.NotationTool}.Validate()).To(Succeed())
+		Expect(deployment.SignatureVerification{Identity: "builder@example.com"}.Validate()).NotTo(Succeed())
+		Expect(deployment.SignatureVerification{Tool: deployment.NotationTool, Key: "key"}.Validate()).NotTo(Succeed())
+		Expect(deployment.SignatureVerification{Tool: "gpg"}.Validate()).To(MatchError(ContainSubstring("'gpg'")))
+	})
 	It("fails to deserialize an image without uri", func() {
 		imgsrc := deployment.NewEmptySrc()
 		Expect(yaml.Unmarshal([]byte("digest: adfadsfa"), imgsrc)).NotTo(Succeed())
@@ -35,6 +35,8 @@ func (t *transformer) Transformer(typ reflect.Type) func(dest, src reflect.Value
 		return t.mergeDisks()
 	case typ == reflect.TypeOf(Partitions{}):
 		return t.mergePartitions()
+	case typ == reflect.TypeOf(&ImageSource{}):
+		return t.mergeImageSource()
 	}
 	return nil
 }
@@ -58,6 +60,25 @@ func (t *transformer) mergePartitions() func(dest, src reflect.Value) error {
 	return mergePtrSlice[Partitions](t)
 }
 
+// mergeImageSource replaces the destination image source with any non empty source, as the image
+// source fields are not meaningful on their own
+func (t *transformer) mergeImageSource() func(dest, src reflect.Value) error {
+	return func(dest, src reflect.Value) error {
+		if !dest.CanSet() {
+			return fmt.Errorf("dest cannot be set")
+		}
+
+		srcImg, ok := src.Interface().(*ImageSource)
+		if !ok {
+			return fmt.Errorf("transformer expected src to be an image source, got %T", src.Interface())
+		}
+		if srcImg != nil && !srcImg.IsEmpty() {
+			dest.Set(src)
+		}
+		return nil
+	}
+}
+
 func mergePtrSlice[T ~[]*E, E any](t *transformer) func(dest, src reflect.Value) error {
 	return func(dest, src reflect.Value) error {
 		if !dest.CanSet() {
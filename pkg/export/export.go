@@ -0,0 +1,212 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	imgspec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+type Format string
+
+const (
+	// OCI exports the snapshot as a single layer image in an OCI image layout archive, loadable
+	// with 'podman load' or as an 'oci-archive:' source of skopeo
+	OCI Format = "oci"
+	// Tar exports the snapshot as a plain tarball of its root tree
+	Tar Format = "tar"
+
+	DefaultTag = "localhost/elemental-snapshot:%d"
+)
+
+type Option func(*Exporter)
+
+type Exporter struct {
+	ctx context.Context
+	s   *sys.System
+	t   transaction.Interface
+	tag string
+}
+
+func WithTransaction(t transaction.Interface) Option {
+	return func(e *Exporter) {
+		e.t = t
+	}
+}
+
+// WithTag sets the image reference of exported OCI images. Defaults to DefaultTag
+// formatted with the snapshot ID.
+func WithTag(tag string) Option {
+	return func(e *Exporter) {
+		e.tag = tag
+	}
+}
+
+func New(ctx context.Context, s *sys.System, opts ...Option) *Exporter {
+	e := &Exporter{
+		s:   s,
+		ctx: ctx,
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	if e.t == nil {
+		e.t = transaction.NewSnapper(ctx, s)
+	}
+	return e
+}
+
+// Export writes the root tree of the snapshot with the given ID to the given output file
+// in the given format
+func (e Exporter) Export(d *deployment.Deployment, snapshotID int, format Format, output string) (err error) {
+	if format != OCI && format != Tar {
+		return fmt.Errorf("unsupported export format '%s'", format)
+	}
+
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	_, err = e.t.Init(*d)
+	if err != nil {
+		return fmt.Errorf("initializing transaction: %w", err)
+	}
+
+	snapRoot, err := e.t.MountSnapshot(snapshotID, cleanup)
+	if err != nil {
+		return fmt.Errorf("mounting snapshot: %w", err)
+	}
+
+	err = vfs.MkdirAll(e.s.FS(), filepath.Dir(output), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	if format == Tar {
+		e.s.Logger().Info("Exporting snapshot %d as tarball '%s'", snapshotID, output)
+		return e.archive(snapRoot, output)
+	}
+
+	e.s.Logger().Info("Exporting snapshot %d as OCI image archive '%s'", snapshotID, output)
+	layerFile := output + ".layer.tar"
+	cleanup.Push(func() error { return e.s.FS().RemoveAll(layerFile) })
+	err = e.archive(snapRoot, layerFile)
+	if err != nil {
+		return err
+	}
+
+	layoutDir := output + ".layout"
+	cleanup.Push(func() error { return e.s.FS().RemoveAll(layoutDir) })
+	err = e.writeLayout(snapshotID, layerFile, layoutDir)
+	if err != nil {
+		return err
+	}
+
+	out, err := e.s.Runner().RunContext(e.ctx, "tar", "--create", "--file", output, "--directory", layoutDir, ".")
+	if err != nil {
+		return fmt.Errorf("archiving OCI image layout: %s: %w", string(out), err)
+	}
+	return nil
+}
+
+// archive creates a tarball of the given root tree preserving ownership, ACLs, extended attributes
+// and SELinux labels
+func (e Exporter) archive(root, output string) error {
+	out, err := e.s.Runner().RunContext(
+		e.ctx, "tar", "--create", "--numeric-owner", "--xattrs", "--xattrs-include=*", "--acls", "--selinux",
+		"--sparse", "--file", output, "--directory", root, ".",
+	)
+	if err != nil {
+		return fmt.Errorf("archiving '%s': %s: %w", root, string(out), err)
+	}
+	return nil
+}
+
+// writeLayout writes an OCI image layout to the given directory including a single image with the given
+// tarball as its only layer
+func (e Exporter) writeLayout(snapshotID int, layerFile, layoutDir string) error {
+	tag := e.tag
+	if tag == "" {
+		tag = fmt.Sprintf(DefaultTag, snapshotID)
+	}
+	ref, err := name.NewTag(tag)
+	if err != nil {
+		return fmt.Errorf("parsing image tag '%s': %w", tag, err)
+	}
+
+	layer, err := tarball.LayerFromOpener(
+		func() (io.ReadCloser, error) { return e.s.FS().Open(layerFile) }, tarball.WithMediaType(types.OCILayer),
+	)
+	if err != nil {
+		return fmt.Errorf("creating image layer: %w", err)
+	}
+
+	base := mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON)
+	img, err := mutate.AppendLayers(base, layer)
+	if err != nil {
+		return fmt.Errorf("appending image layer: %w", err)
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return fmt.Errorf("reading image config: %w", err)
+	}
+	cfg = cfg.DeepCopy()
+	cfg.OS = e.s.Platform().OS
+	cfg.Architecture = e.s.Platform().GolangArch
+	cfg.Config = v1.Config{Labels: map[string]string{"com.suse.elemental.snapshot": fmt.Sprint(snapshotID)}}
+	img, err = mutate.ConfigFile(img, cfg)
+	if err != nil {
+		return fmt.Errorf("setting image config: %w", err)
+	}
+
+	err = vfs.MkdirAll(e.s.FS(), layoutDir, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating OCI image layout directory: %w", err)
+	}
+	rawDir, err := e.s.FS().RawPath(layoutDir)
+	if err != nil {
+		return fmt.Errorf("resolving OCI image layout directory: %w", err)
+	}
+
+	p, err := layout.Write(rawDir, empty.Index)
+	if err != nil {
+		return fmt.Errorf("writing OCI image layout: %w", err)
+	}
+	err = p.AppendImage(img, layout.WithAnnotations(map[string]string{imgspec.AnnotationRefName: ref.Name()}))
+	if err != nil {
+		return fmt.Errorf("writing image '%s' to OCI image layout: %w", ref.Name(), err)
+	}
+	return nil
+}
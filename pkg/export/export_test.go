@@ -0,0 +1,153 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export_test
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/suse/elemental/v3/pkg/archive"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/export"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	transmock "github.com/suse/elemental/v3/pkg/transaction/mock"
+)
+
+func TestExportSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Export test suite")
+}
+
+var _ = Describe("Export", Label("export"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var d *deployment.Deployment
+	var e *export.Exporter
+	var t *transmock.Transactioner
+
+	BeforeEach(func() {
+		var err error
+		t = &transmock.Transactioner{SnapshotPath: "/snapshot"}
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/snapshot/etc/hosts": "127.0.0.1 localhost",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithMounter(sysmock.NewMounter()), sys.WithRunner(runner),
+			sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Fake tar archiving the given --directory to the given --file
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd != "tar" {
+				return []byte{}, nil
+			}
+			dir, err := fs.RawPath(args[slices.Index(args, "--directory")+1])
+			if err != nil {
+				return []byte{}, err
+			}
+			f, err := fs.Create(args[slices.Index(args, "--file")+1])
+			if err != nil {
+				return []byte{}, err
+			}
+			defer f.Close()
+			tw := tar.NewWriter(f)
+			if err = tw.AddFS(os.DirFS(dir)); err != nil {
+				return []byte{}, err
+			}
+			return []byte{}, tw.Close()
+		}
+
+		d = deployment.DefaultDeployment()
+		e = export.New(context.Background(), s, export.WithTransaction(t))
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("exports a snapshot as a tarball", func() {
+		Expect(e.Export(d, 3, export.Tar, "/out/snapshot.tar")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{
+			"tar", "--create", "--numeric-owner", "--xattrs", "--xattrs-include=*", "--acls", "--selinux",
+			"--sparse", "--file", "/out/snapshot.tar", "--directory", "/snapshot", ".",
+		}})).To(Succeed())
+		ok, _ := vfs.Exists(fs, "/out/snapshot.tar")
+		Expect(ok).To(BeTrue())
+	})
+	It("exports a snapshot as an OCI image layout archive", func() {
+		Expect(e.Export(d, 3, export.OCI, "/out/snapshot.oci")).To(Succeed())
+		ok, _ := vfs.Exists(fs, "/out/snapshot.oci.layer.tar")
+		Expect(ok).To(BeFalse())
+		ok, _ = vfs.Exists(fs, "/out/snapshot.oci.layout")
+		Expect(ok).To(BeFalse())
+
+		Expect(archive.ExtractTarball(context.Background(), s, "/out/snapshot.oci", "/layout")).To(Succeed())
+		raw, err := fs.RawPath("/layout")
+		Expect(err).NotTo(HaveOccurred())
+		index, err := layout.ImageIndexFromPath(raw)
+		Expect(err).NotTo(HaveOccurred())
+		manifest, err := index.IndexManifest()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Manifests).To(HaveLen(1))
+		Expect(manifest.Manifests[0].MediaType).To(Equal(types.OCIManifestSchema1))
+		Expect(manifest.Manifests[0].Annotations).To(HaveKeyWithValue(
+			"org.opencontainers.image.ref.name", fmt.Sprintf(export.DefaultTag, 3),
+		))
+		img, err := index.Image(manifest.Manifests[0].Digest)
+		Expect(err).NotTo(HaveOccurred())
+
+		layers, err := img.Layers()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layers).To(HaveLen(1))
+		cfg, err := img.ConfigFile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Config.Labels).To(HaveKeyWithValue("com.suse.elemental.snapshot", "3"))
+		Expect(cfg.Architecture).To(Equal(s.Platform().GolangArch))
+	})
+	It("fails with an unsupported format", func() {
+		Expect(e.Export(d, 3, export.Format("qcow2"), "/out/snapshot")).To(MatchError("unsupported export format 'qcow2'"))
+	})
+	It("fails to mount the snapshot", func() {
+		t.MountSnapshotErr = fmt.Errorf("not found")
+		Expect(e.Export(d, 3, export.Tar, "/out/snapshot.tar")).To(MatchError("mounting snapshot: not found"))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("fails to archive the snapshot", func() {
+		runner.SideEffect = func(_ string, _ ...string) ([]byte, error) {
+			return []byte("no space left"), fmt.Errorf("tar failed")
+		}
+		err := e.Export(d, 3, export.OCI, "/out/snapshot.oci")
+		Expect(err).To(MatchError("archiving '/snapshot': no space left: tar failed"))
+	})
+})
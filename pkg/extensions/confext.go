@@ -0,0 +1,242 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extensions
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// ConfextsDir is the directory systemd-confext activates the configuration extensions from.
+	// It is shared across snapshots, so configuration is upgraded separately from the OS.
+	ConfextsDir = "/var/lib/confexts"
+
+	confextSuffix = ".raw"
+	backupSuffix  = ".prev"
+	newSuffix     = ".new"
+)
+
+// confextNameRegexp matches the valid confext names, which are used as file names within ConfextsDir
+var confextNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ValidateConfextName checks the given confext name can't resolve outside of ConfextsDir
+func ValidateConfextName(name string) error {
+	if name == "." || name == ".." || !confextNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid confext name '%s': only letters, digits, '_', '.' and '-' are allowed", name)
+	}
+	return nil
+}
+
+// Signing holds the private key and certificate signing the verity hash of confext images
+type Signing struct {
+	PrivateKey  string
+	Certificate string
+}
+
+// ConfextReleaseFile returns the path of the extension release file of the given confext, relative to its root
+func ConfextReleaseFile(name string) string {
+	return filepath.Join("etc", "extension-release.d", "extension-release."+name)
+}
+
+// BuildConfext packages the /etc hierarchy of the given configuration tree into the confext image
+// '<name>.raw' of the given directory and returns its path. An extension release file matching any
+// OS is added if the tree does not include one. Images are packaged as signed verity images with
+// systemd-repart if signing is set, or as plain EROFS images otherwise. The EROFS filesystem is
+// compressed with the given compression, if any.
+func BuildConfext(
+	ctx context.Context, s *sys.System, name, tree, destDir string, signing *Signing, compression *deployment.Compression,
+) (string, error) {
+	fs := s.FS()
+
+	if err := ValidateConfextName(name); err != nil {
+		return "", err
+	}
+
+	etcDir := filepath.Join(tree, "etc")
+	if ok, _ := vfs.IsDir(fs, etcDir); !ok {
+		return "", fmt.Errorf("invalid confext '%s': a /etc directory is required", name)
+	}
+
+	tempDir, err := vfs.TempDir(fs, "", fmt.Sprintf("confext-%s-", name))
+	if err != nil {
+		return "", fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer func() {
+		_ = fs.RemoveAll(tempDir)
+	}()
+
+	if err = vfs.MkdirAll(fs, filepath.Join(tempDir, "etc"), vfs.DirPerm); err != nil {
+		return "", fmt.Errorf("creating configuration tree: %w", err)
+	}
+	if err = vfs.CopyDirContext(ctx, fs, etcDir, filepath.Join(tempDir, "etc"), true, nil); err != nil {
+		return "", fmt.Errorf("copying configuration tree: %w", err)
+	}
+
+	releaseFile := filepath.Join(tempDir, ConfextReleaseFile(name))
+	if ok, _ := vfs.Exists(fs, releaseFile); !ok {
+		if err = vfs.MkdirAll(fs, filepath.Dir(releaseFile), vfs.DirPerm); err != nil {
+			return "", fmt.Errorf("creating extension release directory: %w", err)
+		}
+		if err = fs.WriteFile(releaseFile, []byte("ID=_any\n"), vfs.FilePerm); err != nil {
+			return "", fmt.Errorf("writing extension release file: %w", err)
+		}
+	}
+
+	if err = vfs.MkdirAll(fs, destDir, vfs.DirPerm); err != nil {
+		return "", fmt.Errorf("creating confexts directory: %w", err)
+	}
+
+	image := filepath.Join(destDir, name+confextSuffix)
+
+	var mkfsOpts []string
+	if compression != nil {
+		mkfsOpts = filesystem.EROFSCompressionOptions(compression)
+	}
+
+	var out []byte
+	if signing != nil {
+		var env []string
+		if len(mkfsOpts) > 0 {
+			env = append(env, "SYSTEMD_REPART_MKFS_OPTIONS_EROFS="+strings.Join(mkfsOpts, " "))
+		}
+		out, err = s.Runner().RunContextEnv(
+			ctx, "systemd-repart", env, "--make-ddi=confext", "--copy-source="+tempDir,
+			"--private-key="+signing.PrivateKey, "--certificate="+signing.Certificate, image,
+		)
+	} else {
+		out, err = s.Runner().RunContext(ctx, "mkfs.erofs", append(mkfsOpts, image, tempDir)...)
+	}
+	if err != nil {
+		return "", fmt.Errorf("packaging confext '%s': %s: %w", name, strings.TrimSpace(string(out)), err)
+	}
+
+	return image, nil
+}
+
+// InstallConfext activates the given confext image as '<name>.raw', replacing the previous image of the
+// same name. The previous image is restored if the configuration extensions fail to be refreshed.
+func InstallConfext(ctx context.Context, s *sys.System, name, image string) (err error) {
+	fs := s.FS()
+
+	if err = ValidateConfextName(name); err != nil {
+		return err
+	}
+
+	target := filepath.Join(ConfextsDir, name+confextSuffix)
+	backup := target + backupSuffix
+
+	if err = vfs.MkdirAll(fs, ConfextsDir, vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating confexts directory: %w", err)
+	}
+
+	// Copy next to the target first, so the image is replaced atomically
+	if err = vfs.CopyFileContext(ctx, fs, image, target+newSuffix); err != nil {
+		return fmt.Errorf("copying confext image '%s': %w", image, err)
+	}
+
+	exists, _ := vfs.Exists(fs, target)
+	if exists {
+		if err = fs.Rename(target, backup); err != nil {
+			_ = fs.Remove(target + newSuffix)
+			return fmt.Errorf("backing up confext '%s': %w", name, err)
+		}
+	}
+
+	if err = fs.Rename(target+newSuffix, target); err != nil {
+		_ = fs.Remove(target + newSuffix)
+		if exists {
+			if rErr := fs.Rename(backup, target); rErr != nil {
+				s.Logger().Error("Restoring the previous '%s' confext failed: %v", name, rErr)
+			}
+		}
+		return fmt.Errorf("replacing confext '%s': %w", name, err)
+	}
+
+	if err = refreshConfexts(s); err != nil {
+		s.Logger().Warn("Refreshing confexts failed, restoring the previous '%s' confext", name)
+		_ = fs.Remove(target)
+		if exists {
+			_ = fs.Rename(backup, target)
+		}
+		if rErr := refreshConfexts(s); rErr != nil {
+			s.Logger().Error("Refreshing confexts after restoring '%s' failed: %v", name, rErr)
+		}
+		return err
+	}
+
+	if exists {
+		_ = fs.Remove(backup)
+	}
+	return nil
+}
+
+// RemoveConfext deactivates and removes the confext of the given name
+func RemoveConfext(s *sys.System, name string) error {
+	if err := ValidateConfextName(name); err != nil {
+		return err
+	}
+
+	target := filepath.Join(ConfextsDir, name+confextSuffix)
+	if ok, _ := vfs.Exists(s.FS(), target); !ok {
+		return fmt.Errorf("confext '%s' not found", name)
+	}
+
+	if err := s.FS().Remove(target); err != nil {
+		return fmt.Errorf("removing confext '%s': %w", name, err)
+	}
+
+	return refreshConfexts(s)
+}
+
+// ListConfexts returns the names of the confexts installed in ConfextsDir
+func ListConfexts(s *sys.System) ([]string, error) {
+	entries, err := s.FS().ReadDir(ConfextsDir)
+	if err != nil {
+		if ok, _ := vfs.Exists(s.FS(), ConfextsDir); !ok {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading confexts directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), confextSuffix); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func refreshConfexts(s *sys.System) error {
+	out, err := s.Runner().Run("systemd-confext", "refresh")
+	if err != nil {
+		return fmt.Errorf("refreshing confexts: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
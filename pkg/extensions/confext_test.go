@@ -0,0 +1,164 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extensions_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/extensions"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Confexts", Label("confext"), func() {
+	var s *sys.System
+	var fs vfs.FS
+	var runner *sysmock.Runner
+	var cleanup func()
+	var packaged map[string]string
+
+	BeforeEach(func() {
+		var err error
+		packaged = map[string]string{}
+		fs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/config/confexts/motd/etc/motd":          "welcome\n",
+			"/config/confexts/motd/etc/issue.d/a.txt": "a\n",
+			"/config/confexts/motd/usr/bin/ignored":   "",
+			"/images/motd.raw":                        "new image",
+			"/var/lib/confexts/motd.raw":              "old image",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		runner = sysmock.NewRunner()
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "mkfs.erofs" {
+				// record the packaged tree before it is removed
+				tree := args[len(args)-1]
+				release, err := fs.ReadFile(filepath.Join(tree, extensions.ConfextReleaseFile("motd")))
+				Expect(err).NotTo(HaveOccurred())
+				packaged["release"] = string(release)
+				_, err = fs.Stat(filepath.Join(tree, "etc", "issue.d", "a.txt"))
+				Expect(err).NotTo(HaveOccurred())
+				_, err = fs.Stat(filepath.Join(tree, "usr"))
+				Expect(err).To(HaveOccurred())
+			}
+			return nil, nil
+		}
+		s, err = sys.NewSystem(
+			sys.WithFS(fs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("packages the /etc hierarchy of a configuration tree as an EROFS image", func() {
+		image, err := extensions.BuildConfext(context.Background(), s, "motd", "/config/confexts/motd", "/out", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image).To(Equal("/out/motd.raw"))
+		Expect(packaged["release"]).To(Equal("ID=_any\n"))
+		Expect(runner.GetCmds()).To(HaveLen(1))
+		Expect(runner.GetCmds()[0][:2]).To(Equal([]string{"mkfs.erofs", "/out/motd.raw"}))
+	})
+
+	It("packages signed images with systemd-repart", func() {
+		signing := &extensions.Signing{PrivateKey: "/keys/confext.key", Certificate: "/keys/confext.crt"}
+		_, err := extensions.BuildConfext(context.Background(), s, "motd", "/config/confexts/motd", "/out", signing, nil)
+		Expect(err).NotTo(HaveOccurred())
+		cmd := runner.GetCmds()[0]
+		Expect(cmd[0]).To(Equal("systemd-repart"))
+		Expect(cmd).To(ContainElements(
+			"--make-ddi=confext", "--private-key=/keys/confext.key", "--certificate=/keys/confext.crt", "/out/motd.raw",
+		))
+	})
+
+	It("compresses the EROFS filesystem with the given compression", func() {
+		compression := &deployment.Compression{Algorithm: deployment.Zstd, Level: 19}
+		_, err := extensions.BuildConfext(context.Background(), s, "motd", "/config/confexts/motd", "/out", nil, compression)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runner.GetCmds()[0][:3]).To(Equal([]string{"mkfs.erofs", "-zzstd,level=19", "/out/motd.raw"}))
+
+		signing := &extensions.Signing{PrivateKey: "/keys/confext.key", Certificate: "/keys/confext.crt"}
+		_, err = extensions.BuildConfext(context.Background(), s, "motd", "/config/confexts/motd", "/out", signing, compression)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runner.EnvsMatch([][]string{
+			{"mkfs.erofs"}, {"systemd-repart", "SYSTEMD_REPART_MKFS_OPTIONS_EROFS=-zzstd,level=19"},
+		})).To(Succeed())
+	})
+
+	It("fails to package trees without /etc", func() {
+		_, err := extensions.BuildConfext(context.Background(), s, "empty", "/config/confexts/empty", "/out", nil, nil)
+		Expect(err).To(MatchError("invalid confext 'empty': a /etc directory is required"))
+	})
+
+	It("replaces an installed confext and refreshes the merged configuration", func() {
+		Expect(extensions.InstallConfext(context.Background(), s, "motd", "/images/motd.raw")).To(Succeed())
+		data, err := fs.ReadFile("/var/lib/confexts/motd.raw")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("new image"))
+		Expect(vfs.Exists(fs, "/var/lib/confexts/motd.raw.prev")).To(BeFalse())
+		Expect(runner.CmdsMatch([][]string{{"systemd-confext", "refresh"}})).To(Succeed())
+	})
+
+	It("restores the previous confext if refreshing fails", func() {
+		calls := 0
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			calls++
+			if calls == 1 {
+				return []byte("merge failed"), fmt.Errorf("exit status 1")
+			}
+			return nil, nil
+		}
+		Expect(extensions.InstallConfext(context.Background(), s, "motd", "/images/motd.raw")).To(MatchError(ContainSubstring("merge failed")))
+		data, err := fs.ReadFile("/var/lib/confexts/motd.raw")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("old image"))
+		Expect(runner.GetCmds()).To(HaveLen(2))
+	})
+
+	It("lists and removes installed confexts", func() {
+		Expect(fs.WriteFile("/var/lib/confexts/motd.raw.prev", []byte{}, vfs.FilePerm)).To(Succeed())
+		names, err := extensions.ListConfexts(s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(Equal([]string{"motd"}))
+
+		Expect(extensions.RemoveConfext(s, "motd")).To(Succeed())
+		Expect(vfs.Exists(fs, "/var/lib/confexts/motd.raw")).To(BeFalse())
+		Expect(extensions.RemoveConfext(s, "motd")).To(MatchError("confext 'motd' not found"))
+	})
+	It("rejects names resolving outside of the confexts directory", func() {
+		for _, name := range []string{"..", ".", "../motd", "a/b", "", "my conf"} {
+			Expect(extensions.ValidateConfextName(name)).NotTo(Succeed(), name)
+		}
+		Expect(extensions.ValidateConfextName("my-conf_1.2")).To(Succeed())
+
+		Expect(extensions.InstallConfext(context.Background(), s, "../motd", "/images/motd.raw")).To(MatchError(ContainSubstring("invalid confext name")))
+		Expect(extensions.RemoveConfext(s, "..")).To(MatchError(ContainSubstring("invalid confext name")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+})
@@ -23,6 +23,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/suse/elemental/v3/pkg/cache"
+	"github.com/suse/elemental/v3/pkg/registry"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
@@ -35,11 +37,12 @@ type OCIUnpacker interface {
 }
 
 type ociUnpacker struct {
-	system *sys.System
+	system   *sys.System
+	registry *registry.Config
 }
 
 func (o *ociUnpacker) Unpack(ctx context.Context, uri, dest string, local bool) (digest string, err error) {
-	unpacker := unpack.NewOCIUnpacker(o.system, uri, unpack.WithLocalOCI(local))
+	unpacker := unpack.NewOCIUnpacker(o.system, uri, unpack.WithLocalOCI(local), unpack.WithRegistryConfigOCI(o.registry))
 	return unpacker.Unpack(ctx, dest)
 }
 
@@ -59,6 +62,8 @@ type OCIFileExtractor struct {
 	fs       vfs.FS
 	ctx      context.Context
 	local    bool
+	registry *registry.Config
+	cache    *cache.Cache
 }
 
 type OCIFileExtractorOpts func(o *OCIFileExtractor)
@@ -93,6 +98,20 @@ func WithLocal(local bool) OCIFileExtractorOpts {
 	}
 }
 
+// WithRegistryConfig sets the credentials, mirrors and insecure registries used by the default OCI unpacker
+func WithRegistryConfig(c *registry.Config) OCIFileExtractorOpts {
+	return func(r *OCIFileExtractor) {
+		r.registry = c
+	}
+}
+
+// WithCache unpacks the OCI images through the given build cache
+func WithCache(c *cache.Cache) OCIFileExtractorOpts {
+	return func(r *OCIFileExtractor) {
+		r.cache = c
+	}
+}
+
 func New(searchPaths []string, opts ...OCIFileExtractorOpts) (*OCIFileExtractor, error) {
 	extr := &OCIFileExtractor{
 		searchPaths: searchPaths,
@@ -124,7 +143,8 @@ func New(searchPaths []string, opts ...OCIFileExtractorOpts) (*OCIFileExtractor,
 		}
 
 		extr.unpacker = &ociUnpacker{
-			system: s,
+			system:   s,
+			registry: extr.registry,
 		}
 	}
 
@@ -144,7 +164,16 @@ func (o *OCIFileExtractor) ExtractFrom(uri string) (path string, err error) {
 		_ = o.fs.RemoveAll(unpackDir)
 	}()
 
-	digest, err := o.unpacker.Unpack(o.ctx, uri, unpackDir, o.local)
+	unpackImage := func(ctx context.Context, dest string) (string, error) {
+		return o.unpacker.Unpack(ctx, uri, dest, o.local)
+	}
+
+	var digest string
+	if o.cache != nil && !o.local {
+		digest, err = o.cache.Unpack(o.ctx, uri, unpackDir, unpackImage)
+	} else {
+		digest, err = unpackImage(o.ctx, unpackDir)
+	}
 	if err != nil {
 		return "", fmt.Errorf("unpacking oci image: %w", err)
 	}
@@ -164,7 +193,7 @@ func (o *OCIFileExtractor) ExtractFrom(uri string) (path string, err error) {
 	}
 
 	fileInStore := filepath.Join(fileStorePath, filepath.Base(fileInOCI))
-	if err := vfs.CopyFile(o.fs, fileInOCI, fileInStore); err != nil {
+	if err := vfs.CopyFileContext(o.ctx, o.fs, fileInOCI, fileInStore); err != nil {
 		return "", fmt.Errorf("copying file to store: %w", err)
 	}
 
@@ -30,7 +30,10 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/suse/elemental/v3/pkg/cache"
 	"github.com/suse/elemental/v3/pkg/extractor"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
@@ -111,6 +114,39 @@ var _ = Describe("OCIFileExtractor", Label("file-extractor"), func() {
 		validateExtractedFileContent(tfs, extractedFile)
 	})
 
+	It("extracts file from the build cache", func() {
+		digestEnc := randomDigestEnc(64)
+		unpacker.fail = true
+
+		runner := sysmock.NewRunner()
+		runner.SideEffect = func(command string, args ...string) ([]byte, error) {
+			// rsync gets the raw host paths of the test filesystem
+			return nil, os.CopyFS(args[len(args)-1], os.DirFS(args[len(args)-2]))
+		}
+		s, err := sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner), sys.WithPlatform("linux/amd64"),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		c, err := cache.New(s, "/cache", cache.WithDigestResolver(func(context.Context, string) (string, error) {
+			return "sha256:" + digestEnc, nil
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		entry := filepath.Join("/cache/images", digestEnc+"-linux-amd64")
+		Expect(vfs.MkdirAll(tfs, entry, 0755)).To(Succeed())
+		Expect(tfs.WriteFile(filepath.Join(entry, fileName), []byte(dummyContent), 0644)).To(Succeed())
+
+		extrOpts = append(extrOpts, extractor.WithCache(c))
+		cachedExtr, err := extractor.New(defaultSearchPaths, extrOpts...)
+		Expect(err).ToNot(HaveOccurred())
+
+		extractedFile, err := cachedExtr.ExtractFrom(dummyOCI)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filepath.Base(filepath.Dir(extractedFile))).To(Equal(digestEnc))
+		validateExtractedFileContent(tfs, extractedFile)
+	})
+
 	It("extracts first found file", func() {
 		digestEnc := randomDigestEnc(64)
 		unpacker.digest = "sha512:" + digestEnc
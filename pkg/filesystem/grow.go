@@ -0,0 +1,86 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"fmt"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// Grow grows the filesystem of the given device to fill its partition. Btrfs and XFS filesystems are
+// grown online through the given mount point, ext filesystems are grown through the device.
+func Grow(s *sys.System, device, mountPoint string, fs deployment.FileSystem) error {
+	var tool string
+	var args []string
+
+	switch fs {
+	case deployment.Btrfs:
+		tool, args = "btrfs", []string{"filesystem", "resize", "max", mountPoint}
+	case deployment.XFS:
+		tool, args = "xfs_growfs", []string{mountPoint}
+	case deployment.Ext2, deployment.Ext4:
+		tool, args = "resize2fs", []string{device}
+	default:
+		return fmt.Errorf("growing filesystem '%s' is not supported", fs)
+	}
+
+	out, err := s.Runner().Run(tool, args...)
+	if err != nil {
+		s.Logger().Error("%s failed with: %s", tool, string(out))
+		return fmt.Errorf("growing filesystem of '%s': %w", device, err)
+	}
+	return nil
+}
+
+// Shrink shrinks the filesystem of the given device to the given size. Btrfs filesystems are shrunk
+// online through their mount point, ext filesystems are checked and shrunk offline, so they must not be mounted.
+func Shrink(s *sys.System, device, mountPoint string, fs deployment.FileSystem, size deployment.MiB) error {
+	if size == 0 {
+		return fmt.Errorf("shrinking filesystem of '%s' requires a size", device)
+	}
+
+	switch fs {
+	case deployment.Btrfs:
+		out, err := s.Runner().Run("btrfs", "filesystem", "resize", fmt.Sprintf("%dM", size), mountPoint)
+		if err != nil {
+			s.Logger().Error("btrfs failed with: %s", string(out))
+			return fmt.Errorf("shrinking filesystem of '%s': %w", device, err)
+		}
+	case deployment.Ext2, deployment.Ext4:
+		out, err := s.Runner().Run("e2fsck", "-f", "-p", device)
+		if err != nil {
+			s.Logger().Error("e2fsck failed with: %s", string(out))
+			return fmt.Errorf("checking filesystem of '%s': %w", device, err)
+		}
+		out, err = s.Runner().Run("resize2fs", device, fmt.Sprintf("%dM", size))
+		if err != nil {
+			s.Logger().Error("resize2fs failed with: %s", string(out))
+			return fmt.Errorf("shrinking filesystem of '%s': %w", device, err)
+		}
+	default:
+		return fmt.Errorf("shrinking filesystem '%s' is not supported", fs)
+	}
+	return nil
+}
+
+// CanShrink returns true if the given filesystem can be shrunk with Shrink
+func CanShrink(fs deployment.FileSystem) bool {
+	return fs == deployment.Btrfs || fs == deployment.Ext2 || fs == deployment.Ext4
+}
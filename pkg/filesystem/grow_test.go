@@ -0,0 +1,69 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+)
+
+var _ = Describe("Grow", Label("grow"), func() {
+	var runner *sysmock.Runner
+	var s *sys.System
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		s, err = sys.NewSystem(sys.WithRunner(runner), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("Grows btrfs and xfs filesystems through their mount point", func() {
+		Expect(filesystem.Grow(s, "/dev/sda3", "/mnt", deployment.Btrfs)).To(Succeed())
+		Expect(filesystem.Grow(s, "/dev/sda4", "/var", deployment.XFS)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"btrfs", "filesystem", "resize", "max", "/mnt"},
+			{"xfs_growfs", "/var"},
+		})).To(Succeed())
+	})
+	It("Grows ext4 filesystems through their device", func() {
+		Expect(filesystem.Grow(s, "/dev/sda3", "/mnt", deployment.Ext4)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{"resize2fs", "/dev/sda3"}})).To(Succeed())
+	})
+	It("Fails for unsupported filesystems", func() {
+		Expect(filesystem.Grow(s, "/dev/sda1", "/boot", deployment.VFat)).NotTo(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("Shrinks btrfs filesystems online and ext4 filesystems offline", func() {
+		Expect(filesystem.Shrink(s, "/dev/sda3", "/mnt", deployment.Btrfs, 4096)).To(Succeed())
+		Expect(filesystem.Shrink(s, "/dev/sda4", "", deployment.Ext4, 2048)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"btrfs", "filesystem", "resize", "4096M", "/mnt"},
+			{"e2fsck", "-f", "-p", "/dev/sda4"},
+			{"resize2fs", "/dev/sda4", "2048M"},
+		})).To(Succeed())
+	})
+	It("Fails to shrink xfs filesystems", func() {
+		Expect(filesystem.CanShrink(deployment.XFS)).To(BeFalse())
+		Expect(filesystem.Shrink(s, "/dev/sda4", "/var", deployment.XFS, 2048)).NotTo(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+})
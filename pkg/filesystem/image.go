@@ -18,6 +18,7 @@ limitations under the License.
 package filesystem
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -65,8 +66,10 @@ func CreateEmptyFile(fs vfs.FS, filename string, size int64, noSparse bool) erro
 // CreatePreloadedFileSystemImage creates a new raw image with the given filesystem. The size of the image
 // is computed form the provided root tree size plus the given overhead. The resulting image size is aligned
 // with the given overhead and has a minimum of a full overhead of free space.
-func CreatePreloadedFileSystemImage(s *sys.System, root, filename, label string, overheadM int64, fs deployment.FileSystem) error {
-	size, err := vfs.DirSize(s.FS(), root)
+func CreatePreloadedFileSystemImage(
+	ctx context.Context, s *sys.System, root, filename, label string, overheadM int64, fs deployment.FileSystem,
+) error {
+	size, err := vfs.DirSizeContext(ctx, s.FS(), root)
 	if err != nil {
 		return fmt.Errorf("could not compute required image size: %w", err)
 	}
@@ -92,7 +95,7 @@ func CreatePreloadedFileSystemImage(s *sys.System, root, filename, label string,
 	}
 
 	mkfsCall := NewMkfsCall(s, filename, fs.String(), label, "", flags...)
-	err = mkfsCall.Apply()
+	err = mkfsCall.ApplyContext(ctx)
 	if err != nil {
 		return fmt.Errorf("failed formatting preloaded filesystem image %s: %w", filename, err)
 	}
@@ -104,7 +107,7 @@ func CreatePreloadedFileSystemImage(s *sys.System, root, filename, label string,
 		}
 
 		for _, f := range files {
-			_, err = s.Runner().Run("mcopy", "-s", "-i", filename, filepath.Join(root, f.Name()), "::")
+			_, err = s.Runner().RunContext(ctx, "mcopy", "-s", "-i", filename, filepath.Join(root, f.Name()), "::")
 			if err != nil {
 				return fmt.Errorf("failed copying file %s to the vfat image %s: %w", f.Name(), filename, err)
 			}
@@ -18,6 +18,8 @@ limitations under the License.
 package filesystem_test
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -71,25 +73,25 @@ var _ = Describe("Image", Label("image"), func() {
 		Expect(filesystem.CreateEmptyFile(roFS, "/test/raw.img", 10, false)).NotTo(Succeed())
 	})
 	It("Creates a ext4 image with preloaded content", func() {
-		Expect(filesystem.CreatePreloadedFileSystemImage(s, "/some/root", "/test/raw.img", "ROOT", 64, deployment.Ext4)).To(Succeed())
+		Expect(filesystem.CreatePreloadedFileSystemImage(context.Background(), s, "/some/root", "/test/raw.img", "ROOT", 64, deployment.Ext4)).To(Succeed())
 		Expect(runner.CmdsMatch([][]string{{"mkfs.ext4", "-L", "ROOT", "-F", "-d", "/some/root", "/test/raw.img"}})).To(Succeed())
 		size, _ := vfs.DirSizeMB(fs, "/test")
 		Expect(size).To(Equal(uint(129)))
 	})
 	It("Creates a ext2 image with preloaded content", func() {
-		Expect(filesystem.CreatePreloadedFileSystemImage(s, "/some/root", "/test/raw.img", "ROOT", 32, deployment.Ext2)).To(Succeed())
+		Expect(filesystem.CreatePreloadedFileSystemImage(context.Background(), s, "/some/root", "/test/raw.img", "ROOT", 32, deployment.Ext2)).To(Succeed())
 		Expect(runner.CmdsMatch([][]string{{"mkfs.ext2", "-L", "ROOT", "-F", "-d", "/some/root", "/test/raw.img"}})).To(Succeed())
 		size, _ := vfs.DirSizeMB(fs, "/test")
 		Expect(size).To(Equal(uint(65)))
 	})
 	It("Creates a btrfs image with preloaded content", func() {
-		Expect(filesystem.CreatePreloadedFileSystemImage(s, "/some/root", "/test/raw.img", "ROOT", 32, deployment.Btrfs)).To(Succeed())
+		Expect(filesystem.CreatePreloadedFileSystemImage(context.Background(), s, "/some/root", "/test/raw.img", "ROOT", 32, deployment.Btrfs)).To(Succeed())
 		Expect(runner.CmdsMatch([][]string{{"mkfs.btrfs", "-L", "ROOT", "-f", "--root-dir", "/some/root", "/test/raw.img"}})).To(Succeed())
 		size, _ := vfs.DirSizeMB(fs, "/test")
 		Expect(size).To(Equal(uint(65)))
 	})
 	It("Creates a vfat image with preloaded content", func() {
-		Expect(filesystem.CreatePreloadedFileSystemImage(s, "/some/root", "/test/raw.img", "ROOT", 16, deployment.VFat)).To(Succeed())
+		Expect(filesystem.CreatePreloadedFileSystemImage(context.Background(), s, "/some/root", "/test/raw.img", "ROOT", 16, deployment.VFat)).To(Succeed())
 		Expect(runner.CmdsMatch([][]string{
 			{"mkfs.vfat", "-n", "ROOT", "/test/raw.img"},
 			{"mcopy", "-s", "-i", "/test/raw.img", "/some/root/file", "::"},
@@ -98,6 +100,6 @@ var _ = Describe("Image", Label("image"), func() {
 		Expect(size).To(Equal(uint(33)))
 	})
 	It("Fails to create a preloaded image with a not supported filesystem", func() {
-		Expect(filesystem.CreatePreloadedFileSystemImage(s, "/some/root", "/test/raw.img", "ROOT", 16, deployment.XFS)).NotTo(Succeed())
+		Expect(filesystem.CreatePreloadedFileSystemImage(context.Background(), s, "/some/root", "/test/raw.img", "ROOT", 16, deployment.XFS)).NotTo(Succeed())
 	})
 })
@@ -0,0 +1,66 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/runner"
+)
+
+// CreateEROFS creates an EROFS image at destination from a source, with options
+func CreateEROFS(ctx context.Context, s *sys.System, source string, destination string, options []string) error {
+	args := append([]string{}, options...)
+	args = append(args, destination, source)
+
+	tail := runner.NewLineTail(outputTailLines)
+	stdoutH := runner.Handlers(tail.Handler, func(line string) {
+		s.Logger().Debug("mkfs.erofs: %s", line)
+	})
+	err := s.Runner().RunContextParseOutput(ctx, stdoutH, tail.Handler, "mkfs.erofs", args...)
+	if err != nil {
+		s.Logger().Error("Error running mkfs.erofs, last stdout and stderr output lines:\n%s", tail)
+		return fmt.Errorf("error creating erofs from %s to %s: %w", source, destination, err)
+	}
+	return nil
+}
+
+func DefaultEROFSCompressionOptions() []string {
+	return []string{"-zlz4hc"}
+}
+
+// EROFSCompressionOptions returns the mkfs.erofs options compressing with the given compression,
+// it returns the default compression options if no compression is given
+func EROFSCompressionOptions(c *deployment.Compression) []string {
+	if c == nil {
+		return DefaultEROFSCompressionOptions()
+	}
+
+	switch {
+	case c.Algorithm == deployment.Zstd && c.Level > 0:
+		return []string{fmt.Sprintf("-zzstd,level=%d", c.Level)}
+	case c.Algorithm == deployment.Xz:
+		// EROFS implements xz as its lzma compressor
+		return []string{"-zlzma"}
+	default:
+		return []string{fmt.Sprintf("-z%s", c.Algorithm)}
+	}
+}
@@ -18,11 +18,13 @@ limitations under the License.
 package filesystem
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/progress"
 	"github.com/suse/elemental/v3/pkg/sys"
 )
 
@@ -34,12 +36,13 @@ type MkfsCall struct {
 	dev        string
 	runner     sys.Runner
 	logger     log.Logger
+	progress   progress.Reporter
 }
 
 func NewMkfsCall(s *sys.System, dev, fileSystem, label, uuid string, customOpts ...string) *MkfsCall {
 	return &MkfsCall{
 		dev: dev, fileSystem: fileSystem, label: label, uuid: uuid,
-		runner: s.Runner(), customOpts: customOpts, logger: s.Logger(),
+		runner: s.Runner(), customOpts: customOpts, logger: s.Logger(), progress: s.Progress(),
 	}
 }
 
@@ -101,15 +104,23 @@ func (mkfs MkfsCall) buildOptions() ([]string, error) {
 }
 
 func (mkfs MkfsCall) Apply() error {
+	return mkfs.ApplyContext(context.Background())
+}
+
+// ApplyContext formats the device, the mkfs call is killed if the given context is cancelled
+func (mkfs MkfsCall) ApplyContext(ctx context.Context) error {
 	opts, err := mkfs.buildOptions()
 	if err != nil {
 		mkfs.logger.Error("failed preparing mkfs arguments: %v", err)
 		return err
 	}
 	tool := fmt.Sprintf("mkfs.%s", mkfs.fileSystem)
-	out, err := mkfs.runner.Run(tool, opts...)
+	progress.Start(mkfs.progress, progress.StepFormat, mkfs.dev)
+	out, err := mkfs.runner.RunContext(ctx, tool, opts...)
 	if err != nil {
 		mkfs.logger.Error("mkfs failed with: %s", string(out))
+		return err
 	}
-	return err
+	progress.Done(mkfs.progress, progress.StepFormat, mkfs.dev)
+	return nil
 }
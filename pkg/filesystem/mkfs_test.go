@@ -25,6 +25,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/suse/elemental/v3/pkg/filesystem"
 	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/progress"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
 )
@@ -61,6 +62,20 @@ var _ = Describe("mkfs", Label("mkfs"), func() {
 		cmds := [][]string{{"mkfs.vfat", "-n", "EFI", "-i", vfatUUID, "/dev/device"}}
 		Expect(runner.CmdsMatch(cmds)).To(BeNil())
 	})
+	It("Reports the progress of the format", func() {
+		events := []progress.Event{}
+		s, err := sys.NewSystem(
+			sys.WithRunner(runner), sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithProgress(progress.Func(func(e progress.Event) { events = append(events, e) })),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		mkfs := filesystem.NewMkfsCall(s, "/dev/device", "xfs", "OEM", validUUID)
+		Expect(mkfs.Apply()).To(Succeed())
+		Expect(events).To(Equal([]progress.Event{
+			{Step: progress.StepFormat, Target: "/dev/device"},
+			{Step: progress.StepFormat, Target: "/dev/device", Percent: 100, Done: true},
+		}))
+	})
 	It("Fails for unsupported filesystem", func() {
 		mkfs := filesystem.NewMkfsCall(s, "/dev/device", "zfs", "OEM", validUUID)
 		Expect(mkfs.Apply()).ToNot(Succeed())
@@ -23,6 +23,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/filesystem"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/sys"
@@ -80,4 +81,17 @@ var _ = Describe("Mksquashfs", Label("mksquashfs"), func() {
 			{"mksquashfs", "/some/root", "/some/rootfs.squashfs", "-b", "1024k"},
 		})).To(Succeed())
 	})
+	It("Creates a squashfs image with the given compression", func() {
+		Expect(filesystem.SquashfsCompressionOptions(nil)).To(Equal(filesystem.DefaultSquashfsCompressionOptions()))
+		Expect(filesystem.SquashfsCompressionOptions(&deployment.Compression{Algorithm: deployment.Xz})).To(
+			Equal([]string{"-b", "1024k", "-comp", "xz"}),
+		)
+		Expect(filesystem.CreateSquashFS(
+			context.Background(), s, "/some/root", "/some/rootfs.squashfs",
+			filesystem.SquashfsCompressionOptions(&deployment.Compression{Algorithm: deployment.Zstd, Level: 19}),
+		)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"mksquashfs", "/some/root", "/some/rootfs.squashfs", "-b", "1024k", "-comp", "zstd", "-Xcompression-level", "19"},
+		})).To(Succeed())
+	})
 })
@@ -20,18 +20,28 @@ package filesystem
 import (
 	"context"
 	"fmt"
+	"strconv"
 
+	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/runner"
 )
 
+// outputTailLines is the number of output lines of verbose commands kept for error reporting
+const outputTailLines = 50
+
 // CreateSquashFS creates a squash file at destination from a source, with options
 func CreateSquashFS(ctx context.Context, s *sys.System, source string, destination string, options []string) error {
 	args := []string{source, destination}
 
 	args = append(args, options...)
-	out, err := s.Runner().RunContext(ctx, "mksquashfs", args...)
+	tail := runner.NewLineTail(outputTailLines)
+	stdoutH := runner.Handlers(tail.Handler, func(line string) {
+		s.Logger().Debug("mksquashfs: %s", line)
+	})
+	err := s.Runner().RunContextParseOutput(ctx, stdoutH, tail.Handler, "mksquashfs", args...)
 	if err != nil {
-		s.Logger().Error("Error running mksquashfs, stdout and stderr output: %s", out)
+		s.Logger().Error("Error running mksquashfs, last stdout and stderr output lines:\n%s", tail)
 		return fmt.Errorf("error creating squashfs from %s to %s: %w", source, destination, err)
 	}
 	return nil
@@ -45,6 +55,21 @@ func DefaultSquashfsCompressionOptions() []string {
 	return []string{"-b", "1024k"}
 }
 
+// SquashfsCompressionOptions returns the mksquashfs options compressing with the given compression,
+// it returns the default compression options if no compression is given
+func SquashfsCompressionOptions(c *deployment.Compression) []string {
+	opts := DefaultSquashfsCompressionOptions()
+	if c == nil {
+		return opts
+	}
+
+	opts = append(opts, "-comp", string(c.Algorithm))
+	if c.Algorithm == deployment.Zstd && c.Level > 0 {
+		opts = append(opts, "-Xcompression-level", strconv.Itoa(c.Level))
+	}
+	return opts
+}
+
 func SquashfsExcludeOptions(excludes ...string) []string {
 	opts := []string{}
 	if len(excludes) == 0 {
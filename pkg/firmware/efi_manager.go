@@ -18,8 +18,16 @@ limitations under the License.
 package firmware
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/platform"
 )
@@ -36,7 +44,25 @@ const (
 
 // EfiBootManager contains logic to update the EFI variables and boot-entries for a system.
 type EfiBootManager struct {
-	s *sys.System
+	s          *sys.System
+	quirksFile string
+	quirks     *Quirk
+}
+
+type EfiBootManagerOpt func(*EfiBootManager)
+
+// WithQuirksFile sets the firmware quirks database extending the built-in one, defaults to QuirksFile
+func WithQuirksFile(file string) EfiBootManagerOpt {
+	return func(b *EfiBootManager) {
+		b.quirksFile = file
+	}
+}
+
+// WithQuirk sets the firmware workarounds to apply, skipping the detection of the host quirks
+func WithQuirk(quirk Quirk) EfiBootManagerOpt {
+	return func(b *EfiBootManager) {
+		b.quirks = &quirk
+	}
 }
 
 // EfiBootEntry contains information about a EFI boot entry.
@@ -44,19 +70,78 @@ type EfiBootEntry struct {
 	Label  string
 	Loader string
 	Disk   string
+	// Part is the number of the EFI partition within the disk, efibootmgr defaults to the first one if unset
+	Part int `yaml:"part,omitempty"`
+}
+
+// EfiBootConfig is the boot configuration of the firmware as reported by efibootmgr.
+type EfiBootConfig struct {
+	Current string          `yaml:"current,omitempty"`
+	Next    string          `yaml:"next,omitempty"`
+	Order   []string        `yaml:"order,omitempty"`
+	Entries []EfiBootRecord `yaml:"entries,omitempty"`
+}
+
+// EfiBootRecord is an existing EFI boot entry.
+type EfiBootRecord struct {
+	Number     string `yaml:"number"`
+	Label      string `yaml:"label"`
+	Active     bool   `yaml:"active"`
+	DevicePath string `yaml:"devicePath,omitempty"`
 }
 
+var (
+	bootRecordRegexp = regexp.MustCompile(`^Boot([0-9A-Fa-f]{4})(\*?)\s+(.*)$`)
+	bootNumberRegexp = regexp.MustCompile(`^[0-9A-Fa-f]{4}$`)
+	hdPathRegexp     = regexp.MustCompile(`HD\(\d+,GPT,([0-9A-Fa-f-]{36}),`)
+)
+
 // NewEfiBootManager creates a new EfiBootManager.
-func NewEfiBootManager(s *sys.System) *EfiBootManager {
-	return &EfiBootManager{s}
+func NewEfiBootManager(s *sys.System, opts ...EfiBootManagerOpt) *EfiBootManager {
+	b := &EfiBootManager{s: s, quirksFile: QuirksFile}
+	for _, o := range opts {
+		o(b)
+	}
+	return b
+}
+
+// Quirk returns the firmware workarounds applying to the host, they are detected on first use
+func (b *EfiBootManager) Quirk() (Quirk, error) {
+	if b.quirks == nil {
+		quirks, err := LoadQuirks(b.s, b.quirksFile)
+		if err != nil {
+			return Quirk{}, err
+		}
+		detected := DetectQuirks(b.s, quirks)
+		b.quirks = &detected
+	}
+	return *b.quirks, nil
 }
 
-// CreateBootEntries creates the EFI boot entries using efibootmgr.
+// CreateBootEntries creates the EFI boot entries using efibootmgr. Firmware quirks of the host are
+// applied to the created entries.
 func (b *EfiBootManager) CreateBootEntries(entries []*EfiBootEntry) error {
+	quirk, err := b.Quirk()
+	if err != nil {
+		return err
+	}
+	if quirk.SkipBootEntries {
+		b.s.Logger().Info("Skipping creation of boot entries, firmware only boots from %s", EfiFallbackPath)
+		return nil
+	}
+
 	b.s.Logger().Info("Creating %d boot entries...", len(entries))
 
 	for _, entry := range entries {
-		cmdOut, err := b.s.Runner().Run("efibootmgr", "--create", "--disk", entry.Disk, "--label", entry.Label, "--loader", entry.Loader)
+		loader := entry.Loader
+		if quirk.RemovableLoader {
+			loader = filepath.Join(EfiFallbackPath, filepath.Base(loader))
+		}
+		args := []string{"--create", "--disk", entry.Disk, "--label", entry.Label, "--loader", loader}
+		if entry.Part > 0 {
+			args = append(args, "--part", strconv.Itoa(entry.Part))
+		}
+		cmdOut, err := b.s.Runner().Run("efibootmgr", append(args, quirk.EfibootmgrArgs...)...)
 		if err != nil {
 			b.s.Logger().Error("failed creating boot entry (%s): %s", err.Error(), string(cmdOut))
 			return err
@@ -84,3 +169,185 @@ func DefaultBootEntry(p *platform.Platform, disk string) *EfiBootEntry {
 		Disk:   disk,
 	}
 }
+
+// BootConfig returns the current firmware boot configuration including all the boot entries.
+func (b *EfiBootManager) BootConfig() (*EfiBootConfig, error) {
+	out, err := b.s.Runner().Run("efibootmgr")
+	if err != nil {
+		return nil, fmt.Errorf("listing boot entries: %w", err)
+	}
+	return parseBootConfig(out), nil
+}
+
+// DeleteBootEntry deletes the boot entry with the given number.
+func (b *EfiBootManager) DeleteBootEntry(number string) error {
+	if !bootNumberRegexp.MatchString(number) {
+		return fmt.Errorf("invalid boot entry number '%s'", number)
+	}
+	b.s.Logger().Info("Deleting boot entry %s", number)
+	cmdOut, err := b.s.Runner().Run("efibootmgr", "--bootnum", number, "--delete-bootnum")
+	if err != nil {
+		b.s.Logger().Error("failed deleting boot entry (%s): %s", err.Error(), string(cmdOut))
+		return err
+	}
+	return nil
+}
+
+// PruneBootEntries deletes the stale boot entries with the given label left behind by previous
+// installations. Entries are matched to partitions by the GPT partition UUID of their device path,
+// entries pointing to partitions which no longer exist are stale. Out of the entries pointing to the
+// same partition the one used for the current boot or the first one of the boot order is kept.
+// It returns the deleted boot entries.
+func (b *EfiBootManager) PruneBootEntries(label string) ([]EfiBootRecord, error) {
+	cfg, err := b.BootConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := lsblk.NewLsDevice(b.s).GetAllPartitions()
+	if err != nil {
+		return nil, fmt.Errorf("listing partitions: %w", err)
+	}
+	uuids := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.UUID != "" {
+			uuids = append(uuids, part.UUID)
+		}
+	}
+
+	stale := cfg.StaleEntries(label, uuids)
+	if len(stale) == 0 {
+		b.s.Logger().Info("No stale '%s' boot entries found", label)
+		return nil, nil
+	}
+	for _, entry := range stale {
+		if err = b.DeleteBootEntry(entry.Number); err != nil {
+			return nil, fmt.Errorf("deleting boot entry %s: %w", entry.Number, err)
+		}
+	}
+	return stale, nil
+}
+
+// SetBootOrder sets the firmware boot order to the given boot entry numbers.
+func (b *EfiBootManager) SetBootOrder(order []string) error {
+	if len(order) == 0 {
+		return fmt.Errorf("empty boot order")
+	}
+	for _, number := range order {
+		if !bootNumberRegexp.MatchString(number) {
+			return fmt.Errorf("invalid boot entry number '%s'", number)
+		}
+	}
+	b.s.Logger().Info("Setting boot order to %s", strings.Join(order, ","))
+	cmdOut, err := b.s.Runner().Run("efibootmgr", "--bootorder", strings.Join(order, ","))
+	if err != nil {
+		b.s.Logger().Error("failed setting boot order (%s): %s", err.Error(), string(cmdOut))
+		return err
+	}
+	return nil
+}
+
+// SetBootNext sets the boot entry used for the next boot only.
+func (b *EfiBootManager) SetBootNext(number string) error {
+	if !bootNumberRegexp.MatchString(number) {
+		return fmt.Errorf("invalid boot entry number '%s'", number)
+	}
+	b.s.Logger().Info("Setting next boot to %s", number)
+	cmdOut, err := b.s.Runner().Run("efibootmgr", "--bootnext", number)
+	if err != nil {
+		b.s.Logger().Error("failed setting next boot (%s): %s", err.Error(), string(cmdOut))
+		return err
+	}
+	return nil
+}
+
+// PartUUID returns the GPT partition UUID of the device path of the entry, it is empty for entries not
+// booting from a GPT partition or if the device path is unknown.
+func (r EfiBootRecord) PartUUID() string {
+	match := hdPathRegexp.FindStringSubmatch(r.DevicePath)
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(match[1])
+}
+
+// StaleEntries returns the entries with the given label which are not pointing to any of the given
+// partition UUIDs and, for each partition, the duplicated entries other than the one used for the
+// current boot or the first one of the boot order. Entries without a known partition UUID are kept.
+func (c EfiBootConfig) StaleEntries(label string, partUUIDs []string) []EfiBootRecord {
+	existing := map[string]bool{}
+	for _, uuid := range partUUIDs {
+		existing[strings.ToLower(uuid)] = true
+	}
+
+	// Entries to keep by partition UUID, the current boot entry has precedence over the boot order
+	keep := map[string]string{}
+	labeled := func(number string) (EfiBootRecord, bool) {
+		i := slices.IndexFunc(c.Entries, func(e EfiBootRecord) bool { return e.Number == number && e.Label == label })
+		if i < 0 {
+			return EfiBootRecord{}, false
+		}
+		return c.Entries[i], true
+	}
+	for _, number := range append([]string{c.Current}, c.Order...) {
+		if entry, ok := labeled(number); ok {
+			uuid := entry.PartUUID()
+			if _, found := keep[uuid]; !found && uuid != "" {
+				keep[uuid] = number
+			}
+		}
+	}
+
+	var stale []EfiBootRecord
+	for _, entry := range c.Entries {
+		uuid := entry.PartUUID()
+		if entry.Label != label || uuid == "" {
+			continue
+		}
+		if !existing[uuid] {
+			stale = append(stale, entry)
+			continue
+		}
+		if number, found := keep[uuid]; !found {
+			keep[uuid] = entry.Number
+		} else if number != entry.Number {
+			stale = append(stale, entry)
+		}
+	}
+	return stale
+}
+
+// parseBootConfig parses the efibootmgr output. Labels and device paths are separated by a tab,
+// older efibootmgr versions only print device paths in verbose mode.
+func parseBootConfig(out []byte) *EfiBootConfig {
+	cfg := &EfiBootConfig{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " ")
+		key, value, _ := strings.Cut(line, ":")
+		switch key {
+		case "BootCurrent":
+			cfg.Current = strings.TrimSpace(value)
+			continue
+		case "BootNext":
+			cfg.Next = strings.TrimSpace(value)
+			continue
+		case "BootOrder":
+			cfg.Order = strings.Split(strings.TrimSpace(value), ",")
+			continue
+		}
+
+		match := bootRecordRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		label, path, _ := strings.Cut(match[3], "\t")
+		cfg.Entries = append(cfg.Entries, EfiBootRecord{
+			Number:     strings.ToUpper(match[1]),
+			Label:      strings.TrimSpace(label),
+			Active:     match[2] == "*",
+			DevicePath: strings.TrimSpace(path),
+		})
+	}
+	return cfg
+}
@@ -0,0 +1,130 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmware_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+)
+
+const efibootmgrOutput = `BootCurrent: 0003
+Timeout: 0 seconds
+BootOrder: 0003,0001,0000,0002
+Boot0000* UiApp	FvVol(7cb8bdc9-f8eb-4f34-aaea-3ee4af6516a1)/FvFile(462caa21-7614-4503-836e-8ab6f4662331)
+Boot0001* elemental-shim	HD(1,GPT,9c3d7a26-6c5a-4a4b-8a2f-6e1f2b7c3a11,0x800,0x100000)/File(\EFI\ELEMENTAL\bootx64.efi)
+Boot0002  elemental-shim	HD(1,GPT,1b2c3d4e-0000-4a4b-8a2f-6e1f2b7c3a11,0x800,0x100000)/File(\EFI\ELEMENTAL\bootx64.efi)
+Boot0003* elemental-shim	HD(1,GPT,5e6f7a8b-1111-4a4b-8a2f-6e1f2b7c3a11,0x800,0x100000)/File(\EFI\ELEMENTAL\bootx64.efi)
+`
+
+const lsblkOutput = `{"blockdevices": [
+	{"partuuid": "5e6f7a8b-1111-4a4b-8a2f-6e1f2b7c3a11", "path": "/dev/sda1", "pkname": "/dev/sda", "type": "part"},
+	{"partuuid": "9C3D7A26-6C5A-4A4B-8A2F-6E1F2B7C3A11", "path": "/dev/sdb1", "pkname": "/dev/sdb", "type": "part"}
+]}`
+
+func TestFirmwareSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Firmware test suite")
+}
+
+var _ = Describe("EfiBootManager", Label("firmware"), func() {
+	var runner *sysmock.Runner
+	var manager *firmware.EfiBootManager
+	BeforeEach(func() {
+		runner = sysmock.NewRunner()
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "efibootmgr" && len(args) == 0 {
+				return []byte(efibootmgrOutput), nil
+			}
+			if cmd == "lsblk" {
+				return []byte(lsblkOutput), nil
+			}
+			return []byte{}, nil
+		}
+		s, err := sys.NewSystem(sys.WithRunner(runner), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+		manager = firmware.NewEfiBootManager(s)
+	})
+	It("lists the boot configuration", func() {
+		cfg, err := manager.BootConfig()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Current).To(Equal("0003"))
+		Expect(cfg.Next).To(BeEmpty())
+		Expect(cfg.Order).To(Equal([]string{"0003", "0001", "0000", "0002"}))
+		Expect(cfg.Entries).To(HaveLen(4))
+		Expect(cfg.Entries[0]).To(Equal(firmware.EfiBootRecord{
+			Number: "0000", Label: "UiApp", Active: true,
+			DevicePath: "FvVol(7cb8bdc9-f8eb-4f34-aaea-3ee4af6516a1)/FvFile(462caa21-7614-4503-836e-8ab6f4662331)",
+		}))
+		Expect(cfg.Entries[2].Label).To(Equal(firmware.EfiBootEntryName))
+		Expect(cfg.Entries[2].Active).To(BeFalse())
+	})
+	It("prunes the elemental entries pointing to partitions which no longer exist", func() {
+		deleted, err := manager.PruneBootEntries(firmware.EfiBootEntryName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(HaveLen(1))
+		Expect(deleted[0].Number).To(Equal("0002"))
+		Expect(runner.CmdsMatch([][]string{
+			{"efibootmgr"},
+			{"lsblk", "-p", "-b", "-n", "-J", "--output", "LABEL,PARTLABEL,PARTUUID,PARTTYPE,SIZE,FSTYPE,MOUNTPOINTS,PATH,PKNAME,TYPE"},
+			{"efibootmgr", "--bootnum", "0002", "--delete-bootnum"},
+		})).To(Succeed())
+	})
+	It("keeps one entry per partition preferring the current boot and then the boot order", func() {
+		const uuid = "9c3d7a26-6c5a-4a4b-8a2f-6e1f2b7c3a11"
+		path := "HD(1,GPT," + uuid + ",0x800,0x100000)/File(\\EFI\\ELEMENTAL\\bootx64.efi)"
+		cfg := firmware.EfiBootConfig{
+			Current: "0000",
+			Order:   []string{"0000", "0002", "0001"},
+			Entries: []firmware.EfiBootRecord{
+				{Number: "0000", Label: "UiApp"},
+				{Number: "0001", Label: firmware.EfiBootEntryName, DevicePath: path},
+				{Number: "0002", Label: firmware.EfiBootEntryName, DevicePath: path},
+				{Number: "0003", Label: firmware.EfiBootEntryName},
+			},
+		}
+		Expect(cfg.StaleEntries(firmware.EfiBootEntryName, []string{uuid})).To(Equal([]firmware.EfiBootRecord{
+			{Number: "0001", Label: firmware.EfiBootEntryName, DevicePath: path},
+		}))
+
+		cfg.Current = "0001"
+		Expect(cfg.StaleEntries(firmware.EfiBootEntryName, []string{uuid})).To(Equal([]firmware.EfiBootRecord{
+			{Number: "0002", Label: firmware.EfiBootEntryName, DevicePath: path},
+		}))
+	})
+	It("sets the boot order and the next boot", func() {
+		Expect(manager.SetBootOrder([]string{"0003", "0000"})).To(Succeed())
+		Expect(manager.SetBootNext("0000")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"efibootmgr", "--bootorder", "0003,0000"},
+			{"efibootmgr", "--bootnext", "0000"},
+		})).To(Succeed())
+	})
+	It("rejects invalid boot entry numbers", func() {
+		Expect(manager.SetBootOrder([]string{"0003", "boot1"})).To(MatchError("invalid boot entry number 'boot1'"))
+		Expect(manager.SetBootNext("")).To(MatchError("invalid boot entry number ''"))
+		Expect(manager.DeleteBootEntry("12345")).To(MatchError("invalid boot entry number '12345'"))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,141 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmware
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// QuirksFile is the firmware quirks database extending the built-in one, its quirks take
+	// precedence over the built-in ones.
+	QuirksFile = "/etc/elemental/firmware-quirks.yaml"
+
+	dmiVendorFile  = "/sys/class/dmi/id/sys_vendor"
+	dmiProductFile = "/sys/class/dmi/id/product_name"
+)
+
+//go:embed quirks.yaml
+var builtinQuirks []byte
+
+// Quirk defines the workarounds applied to the firmware of the machines matching the DMI vendor and product.
+// Vendor and product are shell patterns, an empty product matches any product of the vendor.
+type Quirk struct {
+	Vendor      string `yaml:"vendor"`
+	Product     string `yaml:"product,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	// SkipBootEntries does not create EFI boot entries, the firmware only boots from the removable media path.
+	SkipBootEntries bool `yaml:"skipBootEntries,omitempty"`
+	// RemovableLoader points the EFI boot entries to the loader in the removable media path.
+	RemovableLoader bool `yaml:"removableLoader,omitempty"`
+	// EfibootmgrArgs are appended to the efibootmgr arguments creating the EFI boot entries, e.g. ['--part', '2'].
+	EfibootmgrArgs []string `yaml:"efibootmgrArgs,omitempty"`
+}
+
+// Matches returns true if the quirk applies to the given DMI vendor and product
+func (q Quirk) Matches(vendor, product string) bool {
+	if q.Vendor == "" {
+		return false
+	}
+	if ok, _ := filepath.Match(q.Vendor, vendor); !ok {
+		return false
+	}
+	if q.Product == "" {
+		return true
+	}
+	ok, _ := filepath.Match(q.Product, product)
+	return ok
+}
+
+// merge adds the workarounds of the given quirk to this one
+func (q *Quirk) merge(quirk Quirk) {
+	q.SkipBootEntries = q.SkipBootEntries || quirk.SkipBootEntries
+	q.RemovableLoader = q.RemovableLoader || quirk.RemovableLoader
+	q.EfibootmgrArgs = append(q.EfibootmgrArgs, quirk.EfibootmgrArgs...)
+	if quirk.Description != "" {
+		q.Description = strings.TrimPrefix(q.Description+"; "+quirk.Description, "; ")
+	}
+}
+
+// quirksDB is the serialized form of the firmware quirks database
+type quirksDB struct {
+	Quirks []Quirk `yaml:"quirks"`
+}
+
+// LoadQuirks returns the built-in firmware quirks database followed by the quirks defined in the given file, if it exists
+func LoadQuirks(s *sys.System, file string) ([]Quirk, error) {
+	db := quirksDB{}
+	if err := yaml.Unmarshal(builtinQuirks, &db); err != nil {
+		return nil, fmt.Errorf("parsing built-in firmware quirks: %w", err)
+	}
+	quirks := db.Quirks
+
+	data, err := s.FS().ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return quirks, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading firmware quirks file '%s': %w", file, err)
+	}
+
+	db = quirksDB{}
+	if err = yaml.Unmarshal(data, &db); err != nil {
+		return nil, fmt.Errorf("parsing firmware quirks file '%s': %w", file, err)
+	}
+	return append(quirks, db.Quirks...), nil
+}
+
+// DetectQuirks returns the workarounds of all the quirks matching the DMI vendor and product of the host
+func DetectQuirks(s *sys.System, quirks []Quirk) Quirk {
+	vendor := readDMI(s, dmiVendorFile)
+	product := readDMI(s, dmiProductFile)
+
+	detected := Quirk{Vendor: vendor, Product: product}
+	if vendor == "" {
+		return detected
+	}
+	for _, quirk := range quirks {
+		if quirk.Matches(vendor, product) {
+			s.Logger().Info("Applying firmware quirk for '%s %s': %s", quirk.Vendor, quirk.Product, quirk.Description)
+			detected.merge(quirk)
+		}
+	}
+	return detected
+}
+
+// readDMI returns the trimmed contents of the given DMI file, empty if it can't be read
+func readDMI(s *sys.System, file string) string {
+	if ok, _ := vfs.Exists(s.FS(), file); !ok {
+		return ""
+	}
+	data, err := s.FS().ReadFile(file)
+	if err != nil {
+		s.Logger().Debug("Could not read DMI file '%s': %v", file, err)
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
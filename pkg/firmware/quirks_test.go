@@ -0,0 +1,122 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmware_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const customQuirks = `quirks:
+  - vendor: "ACME*"
+    product: "Server 1?"
+    description: needs the ESP partition number
+    efibootmgrArgs: ["--part", "2"]
+  - vendor: "ACME Corp."
+    description: ignores the elemental loader path
+    removableLoader: true
+`
+
+var _ = Describe("Firmware quirks", Label("firmware", "quirks"), func() {
+	var runner *sysmock.Runner
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var entries []*firmware.EfiBootEntry
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/sys/class/dmi/id/sys_vendor":   "ACME Corp.\n",
+			"/sys/class/dmi/id/product_name": "Server 12\n",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		entries = []*firmware.EfiBootEntry{{Label: "elemental-shim", Loader: "/EFI/ELEMENTAL/bootx64.efi", Disk: "/dev/sda"}}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("matches quirks by vendor and product patterns", func() {
+		q := firmware.Quirk{Vendor: "ACME*", Product: "Server 1?"}
+		Expect(q.Matches("ACME Corp.", "Server 12")).To(BeTrue())
+		Expect(q.Matches("ACME Corp.", "Server 2")).To(BeFalse())
+		Expect(firmware.Quirk{Vendor: "ACME Corp."}.Matches("ACME Corp.", "anything")).To(BeTrue())
+		Expect(firmware.Quirk{}.Matches("ACME Corp.", "Server 12")).To(BeFalse())
+	})
+	It("creates boot entries as usual if no quirk matches", func() {
+		manager := firmware.NewEfiBootManager(s)
+		Expect(manager.CreateBootEntries(entries)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{
+			"efibootmgr", "--create", "--disk", "/dev/sda", "--label", "elemental-shim", "--loader", "/EFI/ELEMENTAL/bootx64.efi",
+		}})).To(Succeed())
+	})
+	It("creates boot entries for EFI partitions other than the first one", func() {
+		entries[0].Part = 2
+		manager := firmware.NewEfiBootManager(s)
+		Expect(manager.CreateBootEntries(entries)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{
+			"efibootmgr", "--create", "--disk", "/dev/sda", "--label", "elemental-shim", "--loader", "/EFI/ELEMENTAL/bootx64.efi",
+			"--part", "2",
+		}})).To(Succeed())
+	})
+	It("applies all the matching quirks of the quirks file", func() {
+		Expect(vfs.MkdirAll(tfs, "/etc/elemental", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile(firmware.QuirksFile, []byte(customQuirks), vfs.FilePerm)).To(Succeed())
+
+		manager := firmware.NewEfiBootManager(s)
+		quirk, err := manager.Quirk()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(quirk.RemovableLoader).To(BeTrue())
+		Expect(quirk.SkipBootEntries).To(BeFalse())
+
+		Expect(manager.CreateBootEntries(entries)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{
+			"efibootmgr", "--create", "--disk", "/dev/sda", "--label", "elemental-shim",
+			"--loader", "/EFI/BOOT/bootx64.efi", "--part", "2",
+		}})).To(Succeed())
+	})
+	It("skips boot entries for firmware ignoring them", func() {
+		Expect(tfs.WriteFile("/sys/class/dmi/id/sys_vendor", []byte("Apple Inc.\n"), vfs.FilePerm)).To(Succeed())
+		manager := firmware.NewEfiBootManager(s)
+		Expect(manager.CreateBootEntries(entries)).To(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("uses the given quirk without detection", func() {
+		manager := firmware.NewEfiBootManager(s, firmware.WithQuirk(firmware.Quirk{SkipBootEntries: true}))
+		Expect(manager.CreateBootEntries(entries)).To(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("fails on an invalid quirks file", func() {
+		Expect(vfs.MkdirAll(tfs, "/etc", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile("/etc/quirks.yaml", []byte("quirks: {invalid"), vfs.FilePerm)).To(Succeed())
+		manager := firmware.NewEfiBootManager(s, firmware.WithQuirksFile("/etc/quirks.yaml"))
+		Expect(manager.CreateBootEntries(entries)).To(MatchError(ContainSubstring("parsing firmware quirks file")))
+	})
+})
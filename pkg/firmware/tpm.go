@@ -0,0 +1,221 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmware
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	PCRLockCmd    = "/usr/lib/systemd/systemd-pcrlock"
+	PCRLockDir    = "/var/lib/pcrlock.d"
+	PCRLockPolicy = "/var/lib/systemd/pcrlock.json"
+
+	kernelComponent  = "650-elemental-kernel.pcrlock.d"
+	cmdlineComponent = "710-elemental-cmdline.pcrlock.d"
+	initrdComponent  = "720-elemental-initrd.pcrlock.d"
+)
+
+// DefaultSealPCRs are the PCRs the LUKS keys are sealed against when no pcrlock policy is used,
+// PCR 7 holds the Secure Boot state which does not change across kernel upgrades.
+var DefaultSealPCRs = []int{7}
+
+// firmwareComponents are the boot components measured before the bootloader, they are locked
+// from the TPM event log of the current boot. Components depending on the booted medium are not
+// locked when predicting the boot of another disk.
+var firmwareComponents = []struct {
+	verb       string
+	file       string
+	bootMedium bool
+}{
+	{"lock-firmware-code", "250-firmware-code.pcrlock", false},
+	{"lock-firmware-config", "250-firmware-config.pcrlock", false},
+	{"lock-secureboot-policy", "240-secureboot-policy.pcrlock", false},
+	{"lock-secureboot-authority", "620-secureboot-authority.pcrlock", true},
+	{"lock-gpt", "600-gpt.pcrlock", true},
+}
+
+// TPMConfig configures sealing the keys of LUKS volumes to the TPM2.
+type TPMConfig struct {
+	// PCRs are the PCR indexes the keys are sealed against. Without a pcrlock policy
+	// it defaults to DefaultSealPCRs, with a pcrlock policy it defaults to the PCRs
+	// systemd-pcrlock locks.
+	PCRs []int `yaml:"pcrs,omitempty" validate:"dive,gte=0,lte=23"`
+	// PCRLock seals the keys against a systemd-pcrlock policy which is updated with the
+	// predicted measurements of the kernel on each upgrade.
+	PCRLock bool `yaml:"pcrLock,omitempty"`
+}
+
+// MeasuredEntry is a boot entry whose measurements are predicted.
+type MeasuredEntry struct {
+	ID            string
+	Kernel        string
+	Initrd        string
+	KernelCmdline string
+}
+
+// TPMManager contains logic to predict the TPM2 measurements of the boot chain and to seal LUKS keys.
+type TPMManager struct {
+	s *sys.System
+}
+
+// NewTPMManager creates a new TPMManager.
+func NewTPMManager(s *sys.System) *TPMManager {
+	return &TPMManager{s}
+}
+
+// PredictMeasurements records the expected measurements of booting the given entries as pcrlock
+// files within the pcrlock directory of the given root. Predictions of entries not included in
+// the list are removed, so the policy only allows booting the given entries. The disk is the
+// disk to boot if it is not the one of the current boot, as on installation. Its partition table
+// is locked instead of the booted one and the Secure Boot authorities are not locked, as the
+// event log only records the ones verifying the loaders of the current boot. They are locked
+// on the first upgrade booted from the disk.
+func (t *TPMManager) PredictMeasurements(root, disk string, entries []MeasuredEntry) error {
+	lockDir := filepath.Join(root, PCRLockDir)
+
+	t.s.Logger().Info("Recording expected measurements of %d boot entries", len(entries))
+	for _, component := range []string{kernelComponent, cmdlineComponent, initrdComponent} {
+		dir := filepath.Join(lockDir, component)
+		err := vfs.ForceRemoveAll(t.s.FS(), dir)
+		if err != nil {
+			return fmt.Errorf("removing previous predictions '%s': %w", dir, err)
+		}
+		err = vfs.MkdirAll(t.s.FS(), dir, vfs.DirPerm)
+		if err != nil {
+			return fmt.Errorf("creating predictions directory '%s': %w", dir, err)
+		}
+	}
+
+	for _, c := range firmwareComponents {
+		pcrlock := filepath.Join(lockDir, c.file)
+		var args []string
+		if disk != "" && c.bootMedium {
+			if c.verb != "lock-gpt" {
+				t.s.Logger().Warn("Not locking '%s' until booting from disk '%s'", c.file, disk)
+				err := vfs.ForceRemoveAll(t.s.FS(), pcrlock)
+				if err != nil {
+					return fmt.Errorf("removing previous prediction '%s': %w", pcrlock, err)
+				}
+				continue
+			}
+			args = append(args, disk)
+		}
+		err := t.lock(pcrlock, c.verb, args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		err := t.predictEntry(lockDir, entry)
+		if err != nil {
+			return fmt.Errorf("predicting measurements of boot entry '%s': %w", entry.ID, err)
+		}
+	}
+	return nil
+}
+
+// MakePolicy computes the pcrlock policy from the predictions within the given root and
+// stores it in the TPM2 NV index, so sealed keys unlock with any of the predicted boot entries.
+func (t *TPMManager) MakePolicy(root string, cfg *TPMConfig) error {
+	args := []string{
+		"make-policy",
+		"--components=" + filepath.Join(root, PCRLockDir),
+		"--policy=" + filepath.Join(root, PCRLockPolicy),
+	}
+	for _, pcr := range cfg.PCRs {
+		args = append(args, "--pcr="+strconv.Itoa(pcr))
+	}
+
+	t.s.Logger().Info("Updating the TPM2 pcrlock policy")
+	cmdOut, err := t.s.Runner().Run(PCRLockCmd, args...)
+	if err != nil {
+		t.s.Logger().Error("failed making pcrlock policy (%s): %s", err.Error(), string(cmdOut))
+		return fmt.Errorf("making pcrlock policy: %w", err)
+	}
+	return nil
+}
+
+// SealVolumes enrolls a TPM2 key in each of the given LUKS volumes, replacing any previous TPM2 key.
+// The key is bound to the pcrlock policy within the given root or to the configured PCRs. The
+// volumes are unlocked with the passphrase stored in the given key file.
+func (t *TPMManager) SealVolumes(root string, cfg *TPMConfig, keyFile string, volumes []string) error {
+	binding := ""
+	if cfg.PCRLock {
+		binding = "--tpm2-pcrlock=" + filepath.Join(root, PCRLockPolicy)
+	} else {
+		pcrs := cfg.PCRs
+		if len(pcrs) == 0 {
+			pcrs = DefaultSealPCRs
+		}
+		indexes := make([]string, 0, len(pcrs))
+		for _, pcr := range pcrs {
+			indexes = append(indexes, strconv.Itoa(pcr))
+		}
+		binding = "--tpm2-pcrs=" + strings.Join(indexes, "+")
+	}
+
+	for _, volume := range volumes {
+		t.s.Logger().Info("Sealing the key of LUKS volume '%s' to the TPM2", volume)
+		cmdOut, err := t.s.Runner().Run(
+			"systemd-cryptenroll", "--unlock-key-file="+keyFile, "--wipe-slot=tpm2", "--tpm2-device=auto", binding, volume,
+		)
+		if err != nil {
+			t.s.Logger().Error("failed enrolling TPM2 key (%s): %s", err.Error(), string(cmdOut))
+			return fmt.Errorf("sealing LUKS volume '%s': %w", volume, err)
+		}
+	}
+	return nil
+}
+
+// predictEntry locks the kernel image, the kernel command line and the initrd of the given entry.
+func (t *TPMManager) predictEntry(lockDir string, entry MeasuredEntry) error {
+	err := t.lock(filepath.Join(lockDir, kernelComponent, entry.ID+".pcrlock"), "lock-pe", entry.Kernel)
+	if err != nil {
+		return err
+	}
+
+	cmdline := filepath.Join(lockDir, cmdlineComponent, entry.ID+".cmdline")
+	err = t.s.FS().WriteFile(cmdline, []byte(entry.KernelCmdline), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing kernel command line '%s': %w", cmdline, err)
+	}
+	err = t.lock(filepath.Join(lockDir, cmdlineComponent, entry.ID+".pcrlock"), "lock-kernel-cmdline", cmdline)
+	if err != nil {
+		return err
+	}
+
+	return t.lock(filepath.Join(lockDir, initrdComponent, entry.ID+".pcrlock"), "lock-kernel-initrd", entry.Initrd)
+}
+
+// lock runs the given systemd-pcrlock verb writing the resulting pcrlock file to the given path.
+func (t *TPMManager) lock(pcrlock, verb string, args ...string) error {
+	cmdOut, err := t.s.Runner().Run(PCRLockCmd, append([]string{verb, "--pcrlock=" + pcrlock}, args...)...)
+	if err != nil {
+		t.s.Logger().Error("failed running %s (%s): %s", verb, err.Error(), string(cmdOut))
+		return fmt.Errorf("recording '%s': %w", filepath.Base(pcrlock), err)
+	}
+	return nil
+}
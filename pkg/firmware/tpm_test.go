@@ -0,0 +1,150 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmware_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("TPMManager", Label("firmware", "tpm"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var manager *firmware.TPMManager
+	var entries []firmware.MeasuredEntry
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/root/var/lib/pcrlock.d/650-elemental-kernel.pcrlock.d/1.pcrlock": "{}",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err := sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		manager = firmware.NewTPMManager(s)
+		entries = []firmware.MeasuredEntry{{
+			ID: "2", Kernel: "/efi/os/vmlinuz", Initrd: "/efi/os/initrd", KernelCmdline: "root=LABEL=SYSTEM",
+		}}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("records the expected measurements of the boot entries", func() {
+		Expect(manager.PredictMeasurements("/root", "", entries)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{firmware.PCRLockCmd, "lock-firmware-code", "--pcrlock=/root/var/lib/pcrlock.d/250-firmware-code.pcrlock"},
+			{firmware.PCRLockCmd, "lock-secureboot-policy", "--pcrlock=/root/var/lib/pcrlock.d/240-secureboot-policy.pcrlock"},
+			{
+				firmware.PCRLockCmd, "lock-pe", "--pcrlock=/root/var/lib/pcrlock.d/650-elemental-kernel.pcrlock.d/2.pcrlock",
+				"/efi/os/vmlinuz",
+			},
+			{
+				firmware.PCRLockCmd, "lock-kernel-cmdline", "--pcrlock=/root/var/lib/pcrlock.d/710-elemental-cmdline.pcrlock.d/2.pcrlock",
+				"/root/var/lib/pcrlock.d/710-elemental-cmdline.pcrlock.d/2.cmdline",
+			},
+			{
+				firmware.PCRLockCmd, "lock-kernel-initrd", "--pcrlock=/root/var/lib/pcrlock.d/720-elemental-initrd.pcrlock.d/2.pcrlock",
+				"/efi/os/initrd",
+			},
+		})).To(Succeed())
+
+		cmdline, err := fs.ReadFile("/root/var/lib/pcrlock.d/710-elemental-cmdline.pcrlock.d/2.cmdline")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(cmdline)).To(Equal("root=LABEL=SYSTEM"))
+
+		// Predictions of entries which are no longer booted are removed
+		ok, _ := vfs.Exists(fs, "/root/var/lib/pcrlock.d/650-elemental-kernel.pcrlock.d/1.pcrlock")
+		Expect(ok).To(BeFalse())
+	})
+	It("locks the partition table of the target disk on installation", func() {
+		Expect(fs.WriteFile("/root/var/lib/pcrlock.d/620-secureboot-authority.pcrlock", []byte("{}"), vfs.FilePerm)).To(Succeed())
+		Expect(manager.PredictMeasurements("/root", "/dev/vda", entries)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{firmware.PCRLockCmd, "lock-gpt", "--pcrlock=/root/var/lib/pcrlock.d/600-gpt.pcrlock", "/dev/vda"},
+		})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{firmware.PCRLockCmd, "lock-secureboot-authority", "--pcrlock=/root/var/lib/pcrlock.d/620-secureboot-authority.pcrlock"},
+		})).NotTo(Succeed())
+		ok, _ := vfs.Exists(fs, "/root/var/lib/pcrlock.d/620-secureboot-authority.pcrlock")
+		Expect(ok).To(BeFalse())
+	})
+	It("fails to record the kernel measurements", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == firmware.PCRLockCmd && args[0] == "lock-pe" {
+				return []byte{}, fmt.Errorf("invalid PE binary")
+			}
+			return []byte{}, nil
+		}
+		Expect(manager.PredictMeasurements("/root", "", entries)).To(MatchError(
+			"predicting measurements of boot entry '2': recording '2.pcrlock': invalid PE binary",
+		))
+	})
+	It("makes the pcrlock policy for the configured PCRs", func() {
+		Expect(manager.MakePolicy("/root", &firmware.TPMConfig{PCRs: []int{4, 7}, PCRLock: true})).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{
+			firmware.PCRLockCmd, "make-policy", "--components=/root/var/lib/pcrlock.d",
+			"--policy=/root/var/lib/systemd/pcrlock.json", "--pcr=4", "--pcr=7",
+		}})).To(Succeed())
+	})
+	It("seals the volumes against the default PCRs", func() {
+		volumes := []string{"/dev/disk/by-partuuid/part3", "/dev/disk/by-partuuid/part4"}
+		Expect(manager.SealVolumes("/root", &firmware.TPMConfig{}, "/etc/luks.key", volumes)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{
+				"systemd-cryptenroll", "--unlock-key-file=/etc/luks.key", "--wipe-slot=tpm2", "--tpm2-device=auto",
+				"--tpm2-pcrs=7", "/dev/disk/by-partuuid/part3",
+			},
+			{
+				"systemd-cryptenroll", "--unlock-key-file=/etc/luks.key", "--wipe-slot=tpm2", "--tpm2-device=auto",
+				"--tpm2-pcrs=7", "/dev/disk/by-partuuid/part4",
+			},
+		})).To(Succeed())
+	})
+	It("seals the volumes against the pcrlock policy", func() {
+		cfg := &firmware.TPMConfig{PCRLock: true}
+		Expect(manager.SealVolumes("/root", cfg, "/etc/luks.key", []string{"/dev/disk/by-partuuid/part3"})).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{
+			"systemd-cryptenroll", "--unlock-key-file=/etc/luks.key", "--wipe-slot=tpm2", "--tpm2-device=auto",
+			"--tpm2-pcrlock=/root/var/lib/systemd/pcrlock.json", "/dev/disk/by-partuuid/part3",
+		}})).To(Succeed())
+	})
+	It("fails to seal a volume", func() {
+		runner.SideEffect = func(_ string, _ ...string) ([]byte, error) {
+			return []byte{}, fmt.Errorf("no TPM2 device")
+		}
+		cfg := &firmware.TPMConfig{PCRs: []int{0, 7}}
+		Expect(manager.SealVolumes("/root", cfg, "/etc/luks.key", []string{"/dev/disk/by-partuuid/part3"})).To(
+			MatchError("sealing LUKS volume '/dev/disk/by-partuuid/part3': no TPM2 device"),
+		)
+		Expect(runner.CmdsMatch([][]string{{
+			"systemd-cryptenroll", "--unlock-key-file=/etc/luks.key", "--wipe-slot=tpm2", "--tpm2-device=auto",
+			"--tpm2-pcrs=0+7", "/dev/disk/by-partuuid/part3",
+		}})).To(Succeed())
+	})
+})
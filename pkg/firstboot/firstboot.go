@@ -0,0 +1,169 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firstboot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// IgnitionConfig is the path of the Ignition configuration, relative to the config partition
+	IgnitionConfig = "ignition/config.ign"
+	// CombustionDir is the combustion directory, relative to the config partition
+	CombustionDir = "combustion"
+	// CombustionScript is the name of the combustion script within CombustionDir
+	CombustionScript = "script"
+	// CloudInitSeedDir is the cloud-init NoCloud seed directory, relative to the root tree
+	CloudInitSeedDir = "/var/lib/cloud/seed/nocloud"
+
+	cloudInitUserData = "user-data"
+	cloudInitMetaData = "meta-data"
+	// cloudInitInstanceID is the NoCloud instance ID of installed systems. It is only required to
+	// be set, cloud-init runs once per instance ID on each installation.
+	cloudInitInstanceID = "instance-id: elemental\n"
+)
+
+// Configure writes the first boot configurations of the given deployment into the given root tree. Ignition
+// and combustion configurations are written into the config partition, expected to be mounted in the root tree,
+// and cloud-init user data is written as a NoCloud seed.
+func Configure(ctx context.Context, s *sys.System, root string, d *deployment.Deployment) error {
+	fb := d.FirstBoot
+	if fb == nil {
+		return nil
+	}
+
+	var configDir string
+	if fb.Ignition != "" || fb.Combustion != "" {
+		part := d.GetConfigPartition()
+		if part == nil {
+			return fmt.Errorf("ignition and combustion configurations require a '%s' partition", deployment.Config)
+		}
+		configDir = filepath.Join(root, part.MountPoint)
+	}
+
+	if fb.Ignition != "" {
+		s.Logger().Info("Writing Ignition configuration")
+		err := WriteIgnition(ctx, s, fb.Ignition, configDir)
+		if err != nil {
+			return err
+		}
+	}
+	if fb.Combustion != "" {
+		s.Logger().Info("Writing combustion configuration")
+		err := WriteCombustion(ctx, s, fb.Combustion, configDir)
+		if err != nil {
+			return err
+		}
+	}
+	if fb.CloudInit != "" {
+		s.Logger().Info("Writing cloud-init NoCloud seed")
+		err := WriteCloudInit(ctx, s, fb.CloudInit, root)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteIgnition copies the given Ignition configuration file into the given config partition root
+func WriteIgnition(ctx context.Context, s *sys.System, src, configDir string) error {
+	target := filepath.Join(configDir, IgnitionConfig)
+	err := vfs.MkdirAll(s.FS(), filepath.Dir(target), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating ignition directory: %w", err)
+	}
+	err = vfs.CopyFileContext(ctx, s.FS(), src, target)
+	if err != nil {
+		return fmt.Errorf("copying ignition configuration '%s': %w", src, err)
+	}
+	return nil
+}
+
+// WriteCombustion copies the given combustion configuration into the given config partition root. The
+// configuration is either a directory including the combustion script and any file it uses, or just the
+// script.
+func WriteCombustion(ctx context.Context, s *sys.System, src, configDir string) error {
+	target := filepath.Join(configDir, CombustionDir)
+	err := vfs.MkdirAll(s.FS(), target, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating combustion directory: %w", err)
+	}
+
+	if dir, _ := vfs.IsDir(s.FS(), src); dir {
+		if ok, _ := vfs.Exists(s.FS(), filepath.Join(src, CombustionScript)); !ok {
+			return fmt.Errorf("no combustion '%s' found in '%s'", CombustionScript, src)
+		}
+		err = vfs.CopyDirContext(ctx, s.FS(), src, target, true, nil)
+		if err != nil {
+			return fmt.Errorf("copying combustion directory '%s': %w", src, err)
+		}
+		return nil
+	}
+
+	script := filepath.Join(target, CombustionScript)
+	err = vfs.CopyFileContext(ctx, s.FS(), src, script)
+	if err != nil {
+		return fmt.Errorf("copying combustion script '%s': %w", src, err)
+	}
+	err = s.FS().Chmod(script, 0700)
+	if err != nil {
+		return fmt.Errorf("setting combustion script permissions: %w", err)
+	}
+	return nil
+}
+
+// WriteCloudInit writes the given cloud-init configuration as a NoCloud seed in the given root tree. The
+// configuration is either a NoCloud seed directory, including user-data and optionally meta-data and
+// network-config files, or just the user data file. A meta-data file is written if missing.
+func WriteCloudInit(ctx context.Context, s *sys.System, src, root string) error {
+	target := filepath.Join(root, CloudInitSeedDir)
+	err := vfs.MkdirAll(s.FS(), target, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating cloud-init seed directory: %w", err)
+	}
+
+	if dir, _ := vfs.IsDir(s.FS(), src); dir {
+		if ok, _ := vfs.Exists(s.FS(), filepath.Join(src, cloudInitUserData)); !ok {
+			return fmt.Errorf("no cloud-init '%s' found in '%s'", cloudInitUserData, src)
+		}
+		err = vfs.CopyDirContext(ctx, s.FS(), src, target, false, nil)
+		if err != nil {
+			return fmt.Errorf("copying cloud-init seed directory '%s': %w", src, err)
+		}
+	} else {
+		err = vfs.CopyFileContext(ctx, s.FS(), src, filepath.Join(target, cloudInitUserData))
+		if err != nil {
+			return fmt.Errorf("copying cloud-init user data '%s': %w", src, err)
+		}
+	}
+
+	metaData := filepath.Join(target, cloudInitMetaData)
+	if ok, _ := vfs.Exists(s.FS(), metaData); !ok {
+		err = s.FS().WriteFile(metaData, []byte(cloudInitInstanceID), vfs.FilePerm)
+		if err != nil {
+			return fmt.Errorf("writing cloud-init meta data: %w", err)
+		}
+	}
+	return nil
+}
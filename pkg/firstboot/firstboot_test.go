@@ -0,0 +1,122 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firstboot_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/firstboot"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestFirstbootSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "First boot test suite")
+}
+
+var _ = Describe("First boot", Label("firstboot"), func() {
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var d *deployment.Deployment
+	var configDir string
+
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/host/config.ign":            `{"ignition":{"version":"3.5.0"}}`,
+			"/host/script":                "#!/bin/bash",
+			"/host/combustion/script":     "#!/bin/bash",
+			"/host/combustion/files/data": "data",
+			"/host/user-data":             "#cloud-config",
+			"/host/seed/user-data":        "#cloud-config",
+			"/host/seed/meta-data":        "instance-id: host",
+			"/root/.keep":                 "",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+		d = deployment.New(deployment.WithConfigPartition(0))
+		configDir = filepath.Join("/root", deployment.ConfigMnt)
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("does nothing without first boot configuration", func() {
+		Expect(firstboot.Configure(context.Background(), s, "/root", d)).To(Succeed())
+		Expect(vfs.Exists(fs, configDir)).To(BeFalse())
+	})
+
+	It("writes the ignition and combustion configuration into the config partition", func() {
+		d.FirstBoot = &deployment.FirstBootConfig{Ignition: "/host/config.ign", Combustion: "/host/script"}
+		Expect(firstboot.Configure(context.Background(), s, "/root", d)).To(Succeed())
+
+		data, err := fs.ReadFile(filepath.Join(configDir, firstboot.IgnitionConfig))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("3.5.0"))
+
+		info, err := fs.Stat(filepath.Join(configDir, firstboot.CombustionDir, firstboot.CombustionScript))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+	})
+
+	It("copies a combustion directory", func() {
+		d.FirstBoot = &deployment.FirstBootConfig{Combustion: "/host/combustion"}
+		Expect(firstboot.Configure(context.Background(), s, "/root", d)).To(Succeed())
+		Expect(vfs.Exists(fs, filepath.Join(configDir, firstboot.CombustionDir, "files", "data"))).To(BeTrue())
+
+		Expect(fs.Remove("/host/combustion/script")).To(Succeed())
+		Expect(firstboot.Configure(context.Background(), s, "/root", d)).To(MatchError(ContainSubstring("no combustion 'script' found")))
+	})
+
+	It("writes the cloud-init NoCloud seed into the root tree", func() {
+		d.FirstBoot = &deployment.FirstBootConfig{CloudInit: "/host/user-data"}
+		Expect(firstboot.Configure(context.Background(), s, "/root", d)).To(Succeed())
+
+		seedDir := filepath.Join("/root", firstboot.CloudInitSeedDir)
+		Expect(vfs.Exists(fs, filepath.Join(seedDir, "user-data"))).To(BeTrue())
+		data, err := fs.ReadFile(filepath.Join(seedDir, "meta-data"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("instance-id"))
+		Expect(vfs.Exists(fs, configDir)).To(BeFalse())
+
+		d.FirstBoot.CloudInit = "/host/seed"
+		Expect(firstboot.Configure(context.Background(), s, "/root", d)).To(Succeed())
+		data, err = fs.ReadFile(filepath.Join(seedDir, "meta-data"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("instance-id: host"))
+	})
+
+	It("fails to write ignition without a config partition", func() {
+		d = deployment.DefaultDeployment()
+		d.FirstBoot = &deployment.FirstBootConfig{Ignition: "/host/config.ign"}
+		Expect(firstboot.Configure(context.Background(), s, "/root", d)).To(MatchError(ContainSubstring("require a 'config' partition")))
+	})
+})
@@ -19,9 +19,9 @@ package fstab
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
-	"os"
 	"slices"
 	"strconv"
 	"text/tabwriter"
@@ -44,44 +44,56 @@ type Line struct {
 }
 
 // Write writes an fstab file at the given location including the given fstab lines
-func Write(s *sys.System, fstabFile string, fstabLines []Line) (err error) {
-	fstab, err := s.FS().Create(fstabFile)
+func Write(s *sys.System, fstabFile string, fstabLines []Line) error {
+	var buf bytes.Buffer
+	err := writeFstabLines(&buf, fstabLines)
 	if err != nil {
-		return fmt.Errorf("creating file: %w", err)
+		return fmt.Errorf("writing content: %w", err)
 	}
-	defer func() {
-		e := fstab.Close()
-		if err == nil && e != nil {
-			err = fmt.Errorf("closing file: %w", e)
-		}
-	}()
 
-	err = writeFstabLines(fstab, fstabLines)
+	err = vfs.WriteFileAtomic(s.FS(), fstabFile, buf.Bytes(), vfs.FilePerm)
 	if err != nil {
-		return fmt.Errorf("writing content: %w", err)
+		return fmt.Errorf("writing file: %w", err)
 	}
 
 	return nil
 }
 
+// Read parses the given fstab file, empty lines and comments are skipped
+func Read(s *sys.System, fstabFile string) ([]Line, error) {
+	data, err := s.FS().ReadFile(fstabFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+
+	var fstabLines []Line
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fstabLine, err := fstabLineFromFields(strings.Fields(line))
+		if err != nil {
+			return nil, fmt.Errorf("invalid fstab line '%s': %w", line, err)
+		}
+		fstabLines = append(fstabLines, fstabLine)
+	}
+	return fstabLines, nil
+}
+
 // Update updates the given fstab file by replacing each oldLine with its newLine.
-func Update(s *sys.System, fstabFile string, oldLines, newLines []Line) (err error) {
+func Update(s *sys.System, fstabFile string, oldLines, newLines []Line) error {
 	if len(oldLines) != len(newLines) {
 		return fmt.Errorf("length of new and old lines must match")
 	}
 
-	fstab, err := s.FS().OpenFile(fstabFile, os.O_RDWR, vfs.FilePerm)
+	data, err := s.FS().ReadFile(fstabFile)
 	if err != nil {
-		return fmt.Errorf("opening file: %w", err)
+		return fmt.Errorf("reading file: %w", err)
 	}
-	defer func() {
-		e := fstab.Close()
-		if err == nil && e != nil {
-			err = fmt.Errorf("closing file: %w", e)
-		}
-	}()
 
-	scanner := bufio.NewScanner(fstab)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 
 	var fstabLines []Line
 	for scanner.Scan() {
@@ -95,22 +107,7 @@ func Update(s *sys.System, fstabFile string, oldLines, newLines []Line) (err err
 
 	fstabLines = updateFstabLines(fstabLines, oldLines, newLines)
 
-	_, err = fstab.Seek(0, 0)
-	if err != nil {
-		return fmt.Errorf("resetting file cursor: %w", err)
-	}
-
-	err = fstab.Truncate(0)
-	if err != nil {
-		return fmt.Errorf("truncating file: %w", err)
-	}
-
-	err = writeFstabLines(fstab, fstabLines)
-	if err != nil {
-		return fmt.Errorf("writing content: %w", err)
-	}
-
-	return nil
+	return Write(s, fstabFile, fstabLines)
 }
 
 func updateFstabLines(lines []Line, oldLines, newLines []Line) []Line {
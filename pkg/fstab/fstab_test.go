@@ -95,7 +95,15 @@ var _ = Describe("Fstab", Label("fstab"), func() {
 
 		err = fstab.Write(s, fstab.File, lines)
 		Expect(err).To(HaveOccurred())
-		Expect(err).To(MatchError("creating file: Create /etc/fstab: operation not permitted"))
+		Expect(err).To(MatchError(MatchRegexp(`writing file: OpenFile /etc/.fstab.\d+: operation not permitted`)))
+	})
+	It("reads an fstab file skipping comments", func() {
+		Expect(tfs.WriteFile(fstab.File, []byte("# static file system information\n\n"+fstabFile), vfs.FilePerm)).To(Succeed())
+		Expect(fstab.Read(s, fstab.File)).To(Equal(lines))
+
+		Expect(tfs.WriteFile(fstab.File, []byte("/dev/device / ext2\n"), vfs.FilePerm)).To(Succeed())
+		_, err := fstab.Read(s, fstab.File)
+		Expect(err).To(MatchError(ContainSubstring("invalid fstab line '/dev/device / ext2'")))
 	})
 	It("updates the fstab file with a new line", func() {
 		Expect(fstab.Write(s, fstab.File, lines)).To(Succeed())
@@ -130,6 +138,6 @@ var _ = Describe("Fstab", Label("fstab"), func() {
 
 		err = fstab.Update(s, fstab.File, lines, lines)
 		Expect(err).To(HaveOccurred())
-		Expect(err).To(MatchError("opening file: OpenFile /etc/fstab: operation not permitted"))
+		Expect(err).To(MatchError(MatchRegexp(`writing file: OpenFile /etc/.fstab.\d+: operation not permitted`)))
 	})
 })
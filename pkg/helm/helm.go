@@ -52,6 +52,8 @@ type Spec struct {
 	RegistryAuthSecret    *AuthSecret `yaml:"dockerRegistrySecret,omitempty"`
 	RepositoryAuthSecret  *AuthSecret `yaml:"authSecret,omitempty"`
 	InsecureSkipTLSVerify bool        `yaml:"insecureSkipTLSVerify,omitempty"`
+	// ChartContent is the base64 encoded chart archive, installed instead of the chart from the repository
+	ChartContent string `yaml:"chartContent,omitempty"`
 }
 
 type AuthSecret struct {
@@ -0,0 +1,146 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// IsHelmChart returns true if the given resource kind and API version identify a HelmChart resource
+func IsHelmChart(apiVersion, kind string) bool {
+	return apiVersion == helmChartAPIVersion && kind == helmChartKind
+}
+
+// Template renders the manifests of the chart deployed by the given HelmChart resource with the helm CLI
+func Template(ctx context.Context, s *sys.System, crd *CRD) ([]byte, error) {
+	dir, err := vfs.TempDir(s.FS(), "", "elemental-helm-values")
+	if err != nil {
+		return nil, fmt.Errorf("creating values directory: %w", err)
+	}
+	defer func() { _ = s.FS().RemoveAll(dir) }()
+
+	args := []string{"template", crd.Metadata.Name}
+	if crd.Spec.ChartContent != "" {
+		data, err := base64.StdEncoding.DecodeString(crd.Spec.ChartContent)
+		if err != nil {
+			return nil, fmt.Errorf("decoding content of helm chart '%s': %w", crd.Spec.Chart, err)
+		}
+
+		chart := filepath.Join(dir, "chart.tgz")
+		if err = s.FS().WriteFile(chart, data, vfs.FilePerm); err != nil {
+			return nil, fmt.Errorf("writing chart archive: %w", err)
+		}
+		args = append(args, chart)
+	} else {
+		args = append(args, crd.Spec.Chart)
+		if crd.Spec.Version != "" {
+			args = append(args, "--version", crd.Spec.Version)
+		}
+		if crd.Spec.Repo != "" {
+			args = append(args, "--repo", crd.Spec.Repo)
+		}
+	}
+	if crd.Spec.TargetNamespace != "" {
+		args = append(args, "--namespace", crd.Spec.TargetNamespace)
+	}
+	if crd.Spec.InsecureSkipTLSVerify {
+		args = append(args, "--insecure-skip-tls-verify")
+	}
+
+	if crd.Spec.ValuesContent != "" {
+		values := filepath.Join(dir, "values.yaml")
+		err = s.FS().WriteFile(values, []byte(crd.Spec.ValuesContent), vfs.FilePerm)
+		if err != nil {
+			return nil, fmt.Errorf("writing values file: %w", err)
+		}
+		args = append(args, "--values", values)
+	}
+
+	out, err := s.Runner().RunContext(ctx, "helm", args...)
+	if err != nil {
+		return nil, fmt.Errorf("rendering helm chart '%s': %w", crd.Spec.Chart, err)
+	}
+	return out, nil
+}
+
+// Pull downloads the archive of the chart deployed by the given HelmChart resource with the helm CLI
+// to the given path. The username and password are only used for authenticated repositories or registries.
+func Pull(ctx context.Context, s *sys.System, crd *CRD, username, password, archive string) error {
+	dir, err := vfs.TempDir(s.FS(), "", "elemental-helm-chart")
+	if err != nil {
+		return fmt.Errorf("creating chart directory: %w", err)
+	}
+	defer func() { _ = s.FS().RemoveAll(dir) }()
+
+	args := []string{"pull", crd.Spec.Chart, "--destination", dir}
+	if crd.Spec.Version != "" {
+		args = append(args, "--version", crd.Spec.Version)
+	}
+	if crd.Spec.Repo != "" {
+		args = append(args, "--repo", crd.Spec.Repo)
+	}
+	if crd.Spec.InsecureSkipTLSVerify {
+		args = append(args, "--insecure-skip-tls-verify")
+	}
+	if username != "" {
+		args = append(args, "--username", username, "--password", password)
+	}
+
+	if _, err = s.Runner().RunContext(ctx, "helm", args...); err != nil {
+		return fmt.Errorf("pulling helm chart '%s': %w", crd.Spec.Chart, err)
+	}
+
+	entries, err := s.FS().ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading chart directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tgz") {
+			continue
+		}
+
+		if err = vfs.CopyFile(s.FS(), filepath.Join(dir, entry.Name()), archive); err != nil {
+			return fmt.Errorf("copying chart archive: %w", err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("pulling helm chart '%s': no chart archive found", crd.Spec.Chart)
+}
+
+// Embed embeds the given chart archive into the HelmChart resource, so the chart is installed
+// without accessing its repository
+func Embed(s *sys.System, crd *CRD, archive string) error {
+	data, err := s.FS().ReadFile(archive)
+	if err != nil {
+		return fmt.Errorf("reading chart archive: %w", err)
+	}
+
+	crd.Spec.ChartContent = base64.StdEncoding.EncodeToString(data)
+	crd.Spec.Repo = ""
+	crd.Spec.RepositoryAuthSecret = nil
+	crd.Spec.RegistryAuthSecret = nil
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Chart templating", func() {
+	var runner *sysmock.Runner
+	var s *sys.System
+	var cleanup func()
+	BeforeEach(func() {
+		var fs vfs.FS
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithRunner(runner), sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("identifies HelmChart resources", func() {
+		Expect(IsHelmChart("helm.cattle.io/v1", "HelmChart")).To(BeTrue())
+		Expect(IsHelmChart("v1", "ConfigMap")).To(BeFalse())
+	})
+	It("renders a chart from a repository with its values", func() {
+		var values string
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			data, err := s.FS().ReadFile(args[len(args)-1])
+			Expect(err).NotTo(HaveOccurred())
+			values = string(data)
+			return []byte("kind: Deployment\n"), nil
+		}
+		crd := NewCRD("metallb-system", "metallb", "0.14.9", "speaker:\n  enabled: false\n", "https://charts.example.com", false, true)
+		out, err := Template(GinkgoT().Context(), s, crd)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(Equal("kind: Deployment\n"))
+		Expect(values).To(Equal("speaker:\n  enabled: false\n"))
+		Expect(runner.CmdsMatch([][]string{{
+			"helm", "template", "metallb", "metallb", "--version", "0.14.9", "--repo", "https://charts.example.com",
+			"--namespace", "metallb-system", "--insecure-skip-tls-verify", "--values",
+		}})).To(Succeed())
+	})
+	It("renders a chart from an OCI registry", func() {
+		crd := NewCRD("", "cert-manager", "1.17.0", "", "oci://registry.example.com/charts", false, false)
+		_, err := Template(GinkgoT().Context(), s, crd)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runner.CmdsMatch([][]string{{
+			"helm", "template", "cert-manager", "oci://registry.example.com/charts/cert-manager", "--version", "1.17.0",
+		}})).To(Succeed())
+	})
+	It("fails to render a chart", func() {
+		runner.ReturnError = fmt.Errorf("chart not found")
+		crd := NewCRD("", "missing", "1.0.0", "", "https://charts.example.com", false, false)
+		_, err := Template(GinkgoT().Context(), s, crd)
+		Expect(err).To(MatchError("rendering helm chart 'missing': chart not found"))
+	})
+	It("renders a vendored chart from its archive", func() {
+		var chart string
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			data, err := s.FS().ReadFile(args[2])
+			Expect(err).NotTo(HaveOccurred())
+			chart = string(data)
+			return nil, nil
+		}
+		crd := NewCRD("", "metallb", "0.14.9", "", "https://charts.example.com", false, false)
+		crd.Spec.ChartContent = base64.StdEncoding.EncodeToString([]byte("chart"))
+		_, err := Template(GinkgoT().Context(), s, crd)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(chart).To(Equal("chart"))
+		Expect(runner.GetCmds()[0]).NotTo(ContainElement("--repo"))
+	})
+	It("pulls a chart and embeds it into its resource", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			dest := args[slices.Index(args, "--destination")+1]
+			return nil, s.FS().WriteFile(filepath.Join(dest, "metallb-0.14.9.tgz"), []byte("chart"), vfs.FilePerm)
+		}
+		crd := NewCRD("metallb-system", "metallb", "0.14.9", "", "https://charts.example.com", true, true)
+		Expect(Pull(GinkgoT().Context(), s, crd, "user", "pass", "/metallb.tgz")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{
+			"helm", "pull", "metallb", "--destination",
+		}})).To(Succeed())
+		Expect(runner.GetCmds()[0]).To(ContainElements(
+			"--version", "0.14.9", "--repo", "https://charts.example.com", "--insecure-skip-tls-verify", "--username", "user",
+		))
+		data, err := s.FS().ReadFile("/metallb.tgz")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("chart"))
+
+		Expect(Embed(s, crd, "/metallb.tgz")).To(Succeed())
+		Expect(crd.Spec.ChartContent).To(Equal(base64.StdEncoding.EncodeToString([]byte("chart"))))
+		Expect(crd.Spec.Repo).To(BeEmpty())
+		Expect(crd.Spec.RepositoryAuthSecret).To(BeNil())
+	})
+	It("fails to pull a chart", func() {
+		crd := NewCRD("", "metallb", "0.14.9", "", "https://charts.example.com", false, false)
+		Expect(Pull(GinkgoT().Context(), s, crd, "", "", "/metallb.tgz")).To(MatchError("pulling helm chart 'metallb': no chart archive found"))
+
+		runner.ReturnError = fmt.Errorf("chart not found")
+		Expect(Pull(GinkgoT().Context(), s, crd, "", "", "/metallb.tgz")).To(MatchError("pulling helm chart 'metallb': chart not found"))
+	})
+})
@@ -20,24 +20,142 @@ package http
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	backoff "github.com/cenkalti/backoff/v4"
+
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
-func DownloadFile(ctx context.Context, fs vfs.FS, url, path string) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+const (
+	// DefaultAttempts is the number of attempts of Fetch
+	DefaultAttempts = 3
+	// DefaultDelay is the delay before the first retry of Fetch
+	DefaultDelay = 5 * time.Second
+
+	// maxDelay caps the exponential backoff between attempts
+	maxDelay = time.Minute
+	// stallTimeout aborts requests not receiving any data for this long
+	stallTimeout = 90 * time.Second
+	// partialSuffix is appended to the path of the incomplete downloads
+	partialSuffix = ".part"
+)
+
+type DownloadOpt func(*downloader)
+
+type downloader struct {
+	attempts  int
+	delay     time.Duration
+	checksum  string
+	validator string
+}
+
+// WithRetries sets the number of download attempts and the delay before the first retry,
+// the delay doubles after each failed attempt
+func WithRetries(attempts int, delay time.Duration) DownloadOpt {
+	return func(d *downloader) {
+		d.attempts = attempts
+		d.delay = delay
+	}
+}
+
+// WithChecksum verifies the downloaded file matches the given hex encoded SHA256 checksum
+func WithChecksum(checksum string) DownloadOpt {
+	return func(d *downloader) {
+		d.checksum = strings.ToLower(checksum)
+	}
+}
+
+// SplitChecksum returns the given URL without its '#sha256=<checksum>' fragment and the checksum,
+// which is empty if the URL has no fragment
+func SplitChecksum(uri string) (string, string, error) {
+	u, err := url.Parse(uri)
 	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
+		return "", "", fmt.Errorf("parsing URL '%s': %w", uri, err)
+	}
+
+	if u.Fragment == "" {
+		return uri, "", nil
 	}
 
-	httpClient := &http.Client{Timeout: 90 * time.Second}
-	resp, err := httpClient.Do(req) // #nosec G704 -- url is assumed to be trusted.
+	checksum, ok := strings.CutPrefix(u.Fragment, "sha256=")
+	if !ok {
+		return "", "", fmt.Errorf("unsupported checksum '%s' for '%s', only sha256 is supported", u.Fragment, uri)
+	}
+	u.Fragment = ""
+
+	return u.String(), checksum, nil
+}
+
+// Fetch downloads the given url to the given path with the default retries. A '#sha256=<checksum>'
+// fragment of the url is verified against the downloaded file.
+func Fetch(ctx context.Context, fs vfs.FS, url, path string) error {
+	url, checksum, err := SplitChecksum(url)
 	if err != nil {
-		return fmt.Errorf("executing request: %w", err)
+		return err
+	}
+
+	opts := []DownloadOpt{WithRetries(DefaultAttempts, DefaultDelay)}
+	if checksum != "" {
+		opts = append(opts, WithChecksum(checksum))
+	}
+
+	return Download(ctx, fs, url, path, opts...)
+}
+
+// Download downloads the given url to the given path. The content is written to '<path>.part'
+// first and renamed once complete, retried attempts resume a partial download with a range request
+// conditioned on the validator of the first response, if the server supports it. A partial file left
+// by an earlier download is only resumed if a checksum is given. Failed attempts, including checksum
+// mismatches, are retried if configured.
+func Download(ctx context.Context, fs vfs.FS, url, path string, opts ...DownloadOpt) error {
+	d := &downloader{attempts: 1}
+	for _, o := range opts {
+		o(d)
+	}
+
+	client := d.client()
+	partial := path + partialSuffix
+
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = d.delay
+	bo.MaxInterval = maxDelay
+	bo.MaxElapsedTime = 0
+
+	attempts := 0
+	err := backoff.Retry(func() error {
+		attempts++
+		return d.download(ctx, fs, client, url, partial)
+	}, backoff.WithContext(backoff.WithMaxRetries(bo, uint64(max(d.attempts-1, 0))), ctx))
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("downloading '%s': %w", url, ctxErr)
+		}
+		return fmt.Errorf("downloading '%s' after %d attempts: %w", url, attempts, err)
+	}
+
+	if err = fs.Rename(partial, path); err != nil {
+		return fmt.Errorf("renaming downloaded file: %w", err)
+	}
+	return nil
+}
+
+// DownloadFile downloads the given url to the given path in a single attempt
+func DownloadFile(ctx context.Context, fs vfs.FS, url, path string) error {
+	d := &downloader{}
+	resp, err := d.get(ctx, d.client(), url, 0)
+	if err != nil {
+		return err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -50,15 +168,186 @@ func DownloadFile(ctx context.Context, fs vfs.FS, url, path string) error {
 		return fmt.Errorf("creating file: %w", err)
 	}
 
-	_, err = io.Copy(file, resp.Body)
+	return copyBody(file, resp.Body)
+}
+
+func (d *downloader) client() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = stallTimeout
+
+	return &http.Client{Transport: transport}
+}
+
+// download runs a single attempt, resuming the partial file if any, and verifies the checksum
+// of the complete file. Errors which can't be fixed by retrying are permanent.
+func (d *downloader) download(ctx context.Context, fs vfs.FS, client *http.Client, url, partial string) error {
+	var offset int64
+	if info, err := fs.Stat(partial); err == nil {
+		if d.validator == "" && d.checksum == "" {
+			// nothing tells the partial file belongs to the current content, start over
+			_ = fs.Remove(partial)
+		} else {
+			offset = info.Size()
+		}
+	}
+
+	resp, err := d.get(ctx, client, url, offset)
 	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var file *os.File
+	switch resp.StatusCode {
+	case http.StatusOK:
+		d.validator = rangeValidator(resp.Header)
+		file, err = fs.Create(partial)
+	case http.StatusPartialContent:
+		if start := rangeStart(resp.Header.Get("Content-Range")); start != offset {
+			_ = fs.Remove(partial)
+			return fmt.Errorf("unexpected content range '%s' resuming at byte %d", resp.Header.Get("Content-Range"), offset)
+		}
+		file, err = fs.OpenFile(partial, os.O_WRONLY|os.O_APPEND, vfs.FilePerm)
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file is stale, start over on the next attempt
+		_ = fs.Remove(partial)
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	default:
+		err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		if !retryableStatus(resp.StatusCode) {
+			err = backoff.Permanent(err)
+		}
+		return err
+	}
+	if err != nil {
+		return backoff.Permanent(fmt.Errorf("creating file: %w", err))
+	}
+
+	if err = copyBody(file, resp.Body); err != nil {
+		return err
+	}
+
+	return d.verify(fs, partial)
+}
+
+// get sends the request for the given url from the given offset, the range is only served if the
+// content still matches the validator, if any. The request is cancelled if no data is received for
+// a while, so stalled downloads are retried.
+func (d *downloader) get(ctx context.Context, client *http.Client, url string, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	req = req.WithContext(ctx)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if d.validator != "" {
+			req.Header.Set("If-Range", d.validator)
+		}
+	}
+
+	resp, err := client.Do(req) // #nosec G704 -- url is assumed to be trusted.
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+
+	resp.Body = &stallReader{
+		ReadCloser: resp.Body,
+		timer:      time.AfterFunc(stallTimeout, cancel),
+		cancel:     cancel,
+	}
+	return resp, nil
+}
+
+func (d *downloader) verify(fs vfs.FS, path string) error {
+	if d.checksum == "" {
+		return nil
+	}
+
+	file, err := fs.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening downloaded file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return fmt.Errorf("computing checksum: %w", err)
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if sum != d.checksum {
+		_ = fs.Remove(path)
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", d.checksum, sum)
+	}
+	return nil
+}
+
+func copyBody(file *os.File, body io.Reader) error {
+	if _, err := io.Copy(file, body); err != nil {
 		_ = file.Close()
 		return fmt.Errorf("copying file contents: %w", err)
 	}
 
-	if err = file.Close(); err != nil {
+	if err := file.Close(); err != nil {
 		return fmt.Errorf("closing file: %w", err)
 	}
 
 	return nil
 }
+
+// rangeValidator returns the strong entity tag or the modification date of the response, which
+// can condition a range request on the content being unchanged, or an empty string
+func rangeValidator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// rangeStart returns the first byte of a 'bytes <start>-<end>/<size>' content range, or -1
+func rangeStart(contentRange string) int64 {
+	r, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return -1
+	}
+
+	start, _, _ := strings.Cut(r, "-")
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// retryableStatus returns true for the server errors and the client errors which may succeed later
+func retryableStatus(code int) bool {
+	return code >= http.StatusInternalServerError || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+}
+
+// stallReader cancels the request once no data has been read for stallTimeout
+type stallReader struct {
+	io.ReadCloser
+	timer  *time.Timer
+	cancel context.CancelFunc
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if n > 0 {
+		s.timer.Reset(stallTimeout)
+	}
+	if errors.Is(err, context.Canceled) {
+		err = fmt.Errorf("no data received for %s: %w", stallTimeout, err)
+	}
+	return n, err
+}
+
+func (s *stallReader) Close() error {
+	s.timer.Stop()
+	s.cancel()
+	return s.ReadCloser.Close()
+}
@@ -19,12 +19,20 @@ package http
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
 func TestDownloadSuite(t *testing.T) {
@@ -63,3 +71,163 @@ var _ = Describe("Invalid download attempts", func() {
 		Expect(err).To(MatchError("creating file: Create downloads/abc: operation not permitted"))
 	})
 })
+
+var _ = Describe("Downloads with retries and checksum", func() {
+	const content = "some content"
+	var fs vfs.FS
+	var server *httptest.Server
+	var requests int
+	var checksum string
+
+	BeforeEach(func() {
+		var cleanup func()
+		var err error
+		fs, cleanup, err = mock.TestFS(map[string]any{"/downloads": map[string]any{}})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(cleanup)
+
+		requests = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+			if requests == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(content))
+		}))
+		DeferCleanup(server.Close)
+
+		sum := sha256.Sum256([]byte(content))
+		checksum = hex.EncodeToString(sum[:])
+	})
+
+	It("retries failed downloads and verifies the checksum", func() {
+		err := Download(
+			context.Background(), fs, server.URL, "/downloads/file",
+			WithRetries(3, time.Millisecond), WithChecksum(checksum),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests).To(Equal(2))
+		data, err := fs.ReadFile("/downloads/file")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(content))
+	})
+
+	It("fails without retries", func() {
+		err := Download(context.Background(), fs, server.URL, "/downloads/file")
+		Expect(err).To(MatchError(ContainSubstring("after 1 attempts: unexpected status code: 503")))
+	})
+
+	It("fails and removes the file if the checksum does not match", func() {
+		err := Download(
+			context.Background(), fs, server.URL, "/downloads/file",
+			WithRetries(2, time.Millisecond), WithChecksum("0123"),
+		)
+		Expect(err).To(MatchError(ContainSubstring("checksum mismatch: expected 0123, got " + checksum)))
+		ok, _ := vfs.Exists(fs, "/downloads/file")
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("Resumable downloads", func() {
+	const content = "some content to resume"
+	var fs vfs.FS
+	var server *httptest.Server
+	var handler http.HandlerFunc
+	var ranges []string
+
+	BeforeEach(func() {
+		var cleanup func()
+		var err error
+		fs, cleanup, err = mock.TestFS(map[string]any{
+			"/downloads/file.part": content[:5],
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(cleanup)
+
+		ranges = nil
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(content))
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ranges = append(ranges, r.Header.Get("Range"))
+			handler(w, r)
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("resumes a partial download with a range request", func() {
+		sum := sha256.Sum256([]byte(content))
+		Expect(Fetch(context.Background(), fs, server.URL+"/file#sha256="+hex.EncodeToString(sum[:]), "/downloads/file")).To(Succeed())
+		Expect(ranges).To(Equal([]string{"bytes=5-"}))
+
+		data, err := fs.ReadFile("/downloads/file")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(content))
+		ok, _ := vfs.Exists(fs, "/downloads/file.part")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("starts over if the server does not support range requests", func() {
+		handler = func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(content))
+		}
+		Expect(Download(context.Background(), fs, server.URL, "/downloads/file")).To(Succeed())
+
+		data, err := fs.ReadFile("/downloads/file")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(content))
+	})
+
+	It("does not retry client errors", func() {
+		handler = func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		err := Download(context.Background(), fs, server.URL, "/downloads/file", WithRetries(3, time.Millisecond))
+		Expect(err).To(MatchError(ContainSubstring("after 1 attempts: unexpected status code: 404")))
+		Expect(ranges).To(HaveLen(1))
+	})
+
+	It("discards a partial file left by an earlier download without checksum", func() {
+		Expect(fs.WriteFile("/downloads/file.part", []byte("stale"), vfs.FilePerm)).To(Succeed())
+		Expect(Download(context.Background(), fs, server.URL, "/downloads/file")).To(Succeed())
+		Expect(ranges).To(Equal([]string{""}))
+
+		data, err := fs.ReadFile("/downloads/file")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(content))
+	})
+
+	It("resumes an interrupted attempt only if the content is unchanged", func() {
+		var ifRanges []string
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			if len(ranges) == 1 {
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				_, _ = w.Write([]byte(content[:5]))
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			}
+			ifRanges = append(ifRanges, r.Header.Get("If-Range"))
+			http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(content))
+		}
+		Expect(fs.Remove("/downloads/file.part")).To(Succeed())
+		Expect(Download(context.Background(), fs, server.URL, "/downloads/file", WithRetries(2, time.Millisecond))).To(Succeed())
+		Expect(ranges).To(Equal([]string{"", "bytes=5-"}))
+		Expect(ifRanges).To(Equal([]string{`"v1"`}))
+
+		data, err := fs.ReadFile("/downloads/file")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(content))
+	})
+
+	It("splits the checksum fragment of URLs", func() {
+		url, checksum, err := SplitChecksum("https://example.com/ext.raw#sha256=abc")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(url).To(Equal("https://example.com/ext.raw"))
+		Expect(checksum).To(Equal("abc"))
+
+		_, _, err = SplitChecksum("https://example.com/ext.raw#md5=abc")
+		Expect(err).To(MatchError(ContainSubstring("only sha256 is supported")))
+	})
+})
@@ -0,0 +1,161 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/btrfs"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/rsync"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// CloneDeployment returns a copy of the given deployment targeting the given device, with partition UUIDs
+// cleared so new ones are generated, and the given snapshot root as the OS image source.
+// Only single disk deployments can be cloned.
+func CloneDeployment(d *deployment.Deployment, device, snapshotRoot string) (*deployment.Deployment, error) {
+	if len(d.Disks) != 1 {
+		return nil, fmt.Errorf("cloning is only supported for single disk deployments, found %d disks", len(d.Disks))
+	}
+
+	clone, err := d.DeepCopy()
+	if err != nil {
+		return nil, fmt.Errorf("copying deployment: %w", err)
+	}
+
+	clone.Disks[0].Device = device
+	for _, part := range clone.Disks[0].Partitions {
+		part.UUID = ""
+	}
+	clone.SourceOS = deployment.NewDirSrc(snapshotRoot)
+	clone.OverlayTree = nil
+	clone.CfgScript = ""
+	if clone.Firmware != nil {
+		clone.Firmware.BootEntries = nil
+	}
+	return clone, nil
+}
+
+// Clone partitions the disk of the clone deployment with the layout of the source deployment, copies the
+// content of the recovery, config and generic partitions from the source disk and installs the OS image
+// of the clone deployment, typically the active snapshot of the source, as the first snapshot of the new disk.
+// The RW volumes of the system partition not included in the snapshot are copied from the source disk too.
+// The EFI partition is not copied, the bootloader is installed from the cloned snapshot.
+func (i Installer) Clone(src, clone *deployment.Deployment) (err error) {
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	if len(src.Disks) != 1 || len(clone.Disks) != 1 || len(src.Disks[0].Partitions) != len(clone.Disks[0].Partitions) {
+		return fmt.Errorf("source and clone deployments do not share the same disk layout")
+	}
+
+	err = i.checkTargetDisks(clone)
+	if err != nil {
+		return err
+	}
+
+	disk := clone.Disks[0]
+	err = repart.PartitionAndFormatDevice(i.s, disk)
+	if err != nil {
+		return fmt.Errorf("partitioning disk '%s': %w", disk.Device, err)
+	}
+	for _, part := range disk.Partitions {
+		err = createPartitionVolumes(i.s, cleanup, part)
+		if err != nil {
+			return fmt.Errorf("creating partition volumes: %w", err)
+		}
+	}
+
+	for j, part := range disk.Partitions {
+		switch part.Role {
+		case deployment.Recovery, deployment.Config, deployment.Generic:
+			err = i.copyPartition(src.Disks[0].Partitions[j], part)
+			if err != nil {
+				return fmt.Errorf("copying '%s' partition: %w", part.Role.String(), err)
+			}
+		}
+	}
+
+	err = i.u.Upgrade(clone)
+	if err != nil {
+		return fmt.Errorf("executing transaction: %w", err)
+	}
+
+	for j, part := range disk.Partitions {
+		if part.Role != deployment.System || part.FileSystem != deployment.Btrfs {
+			continue
+		}
+		for _, rwVol := range part.RWVolumes {
+			if rwVol.Snapshotted {
+				continue
+			}
+			i.s.Logger().Info("Copying '%s' volume", rwVol.Path)
+			subvol := fmt.Sprintf("subvol=%s", filepath.Join(btrfs.TopSubVol, rwVol.Path))
+			err = i.copyPartition(src.Disks[0].Partitions[j], part, subvol)
+			if err != nil {
+				return fmt.Errorf("copying '%s' volume: %w", rwVol.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// copyPartition syncs the whole content of the source partition into the target partition, both partitions
+// are mounted with the given additional mount options
+func (i Installer) copyPartition(src, target *deployment.Partition, opts ...string) (err error) {
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	srcMnt, err := i.mountPartition(cleanup, src, append([]string{"ro"}, opts...)...)
+	if err != nil {
+		return err
+	}
+	targetMnt, err := i.mountPartition(cleanup, target, append([]string{"rw"}, opts...)...)
+	if err != nil {
+		return err
+	}
+
+	i.s.Logger().Info("Copying '%s' partition content", src.Role.String())
+	return rsync.NewRsync(i.s, rsync.WithContext(i.ctx)).SyncData(srcMnt, targetMnt)
+}
+
+// mountPartition mounts the given partition to a temporary directory and returns its path
+func (i Installer) mountPartition(cleanup *cleanstack.CleanStack, part *deployment.Partition, opts ...string) (string, error) {
+	mountPoint, err := vfs.TempDir(i.s.FS(), "", "elemental_"+part.Role.String())
+	if err != nil {
+		return "", fmt.Errorf("creating temporary mount point: %w", err)
+	}
+	cleanup.PushSuccessOnly(func() error { return i.s.FS().RemoveAll(mountPoint) })
+
+	bPart, err := block.GetPartitionByUUID(i.s, lsblk.NewLsDevice(i.s), part.UUID, 4)
+	if err != nil {
+		return "", fmt.Errorf("finding partition '%s': %w", part.UUID, err)
+	}
+	err = i.s.Mounter().Mount(bPart.Path, mountPoint, "", opts)
+	if err != nil {
+		return "", fmt.Errorf("mounting partition '%s': %w", bPart.Path, err)
+	}
+	cleanup.Push(func() error { return i.s.Mounter().Unmount(mountPoint) })
+	return mountPoint, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/btrfs"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// OpenDiskImage attaches the given RAW disk image to a loop device and mounts the default snapshot of its
+// system partition together with the snapshots volume, as a booted system does, so new transactions can
+// be opened on top of the installed OS. It returns the deployment stored in the default snapshot targeting
+// the loop device. Unmounting the disk image and detaching the loop device are pushed to the given clean stack.
+func OpenDiskImage(s *sys.System, image string, cleanup *cleanstack.CleanStack) (*deployment.Deployment, error) {
+	if info, err := s.FS().Stat(image); err != nil {
+		return nil, fmt.Errorf("inspecting disk image '%s': %w", image, err)
+	} else if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("'%s' is not a raw disk image file", image)
+	}
+
+	out, err := s.Runner().Run("losetup", "-f", "--show", "-P", image)
+	if err != nil {
+		return nil, fmt.Errorf("attaching loop device to '%s': %w", image, err)
+	}
+	device := strings.TrimSpace(string(out))
+	cleanup.Push(func() error {
+		_, err := s.Runner().Run("losetup", "-d", device)
+		return err
+	})
+
+	parts, err := lsblk.NewLsDevice(s).GetDevicePartitions(device)
+	if err != nil {
+		return nil, fmt.Errorf("listing partitions of '%s': %w", device, err)
+	}
+	sysPart := parts.GetByUUIDNameOrLabel("", deployment.System.String(), deployment.SystemLabel)
+	if sysPart == nil {
+		return nil, fmt.Errorf("system partition not found in disk image '%s'", image)
+	}
+
+	root, err := vfs.TempDir(s.FS(), "", "elemental_disk_image")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary mount point: %w", err)
+	}
+	cleanup.PushSuccessOnly(func() error { return s.FS().RemoveAll(root) })
+
+	err = s.Mounter().Mount(sysPart.Path, root, "", []string{"rw"})
+	if err != nil {
+		return nil, fmt.Errorf("mounting default snapshot of '%s': %w", sysPart.Path, err)
+	}
+	cleanup.Push(func() error { return s.Mounter().Unmount(root) })
+
+	snapshots := filepath.Join(root, snapper.SnapshotsPath)
+	subvol := fmt.Sprintf("subvol=%s", filepath.Join(btrfs.TopSubVol, snapper.SnapshotsPath))
+	err = s.Mounter().Mount(sysPart.Path, snapshots, "", []string{"rw", subvol})
+	if err != nil {
+		return nil, fmt.Errorf("mounting snapshots volume of '%s': %w", sysPart.Path, err)
+	}
+	cleanup.Push(func() error { return s.Mounter().Unmount(snapshots) })
+
+	d, err := deployment.Parse(s, root)
+	if err != nil {
+		return nil, fmt.Errorf("parsing deployment: %w", err)
+	} else if d == nil {
+		return nil, fmt.Errorf("deployment not found in disk image '%s'", image)
+	}
+	if len(d.Disks) != 1 {
+		return nil, fmt.Errorf("only single disk deployments are supported, found %d disks", len(d.Disks))
+	}
+	d.Disks[0].Device = device
+
+	return d, nil
+}
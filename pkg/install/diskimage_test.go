@@ -0,0 +1,145 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install_test
+
+import (
+	"fmt"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/install"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// diskImageMounter writes the given deployment into the mounted default snapshot
+type diskImageMounter struct {
+	*sysmock.Mounter
+	s *sys.System
+	d *deployment.Deployment
+}
+
+func (m diskImageMounter) Mount(source string, target string, fstype string, options []string) error {
+	if m.d != nil && slices.Equal(options, []string{"rw"}) {
+		if err := m.d.WriteDeploymentFile(m.s, target); err != nil {
+			return err
+		}
+	}
+	return m.Mounter.Mount(source, target, fstype, options)
+}
+
+var _ = Describe("OpenDiskImage", Label("diskimage"), func() {
+	var runner *sysmock.Runner
+	var mounter *diskImageMounter
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var stack *cleanstack.CleanStack
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		mounter = &diskImageMounter{Mounter: sysmock.NewMounter()}
+
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/build/disk.raw": []byte{},
+			"/dev/device":     []byte{},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithMounter(mounter), sys.WithRunner(runner),
+			sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		mounter.s = s
+		mounter.d = deployment.DefaultDeployment()
+		stack = cleanstack.NewCleanStack()
+
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			switch cmd {
+			case "losetup":
+				if slices.Contains(args, "--show") {
+					return []byte("/dev/device\n"), nil
+				}
+			case "lsblk":
+				return []byte(lsblkJson), nil
+			}
+			return []byte{}, nil
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("mounts the default snapshot of the disk image and parses its deployment", func() {
+		d, err := install.OpenDiskImage(s, "/build/disk.raw", stack)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.Disks[0].Device).To(Equal("/dev/device"))
+		Expect(d.GetSystemPartition()).NotTo(BeNil())
+
+		mounts, err := mounter.GetMountPoints("/dev/device3")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mounts).To(HaveLen(2))
+		Expect(mounts[1].Path).To(Equal(mounts[0].Path + "/.snapshots"))
+		Expect(mounts[1].Opts).To(ContainElement("subvol=@/.snapshots"))
+
+		Expect(stack.Cleanup(nil)).To(Succeed())
+		mounts, err = mounter.GetMountPoints("/dev/device3")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mounts).To(BeEmpty())
+		Expect(runner.MatchMilestones([][]string{
+			{"losetup", "-f", "--show", "-P", "/build/disk.raw"},
+			{"lsblk"},
+			{"losetup", "-d", "/dev/device"},
+		})).To(Succeed())
+	})
+	It("fails if the image is not a regular file", func() {
+		_, err := install.OpenDiskImage(s, "/build", stack)
+		Expect(err).To(MatchError(ContainSubstring("'/build' is not a raw disk image file")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("fails if the disk image has no system partition", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "lsblk" {
+				return []byte(`{"blockdevices": []}`), nil
+			}
+			return []byte("/dev/device\n"), nil
+		}
+		_, err := install.OpenDiskImage(s, "/build/disk.raw", stack)
+		Expect(err).To(MatchError("system partition not found in disk image '/build/disk.raw'"))
+		Expect(stack.Cleanup(err)).NotTo(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"losetup", "-d", "/dev/device"}})).To(Succeed())
+	})
+	It("fails if the disk image has no deployment", func() {
+		mounter.d = nil
+		_, err := install.OpenDiskImage(s, "/build/disk.raw", stack)
+		Expect(err).To(MatchError("deployment not found in disk image '/build/disk.raw'"))
+	})
+	It("fails if the loop device can't be attached", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			return []byte{}, fmt.Errorf("no free loop device")
+		}
+		_, err := install.OpenDiskImage(s, "/build/disk.raw", stack)
+		Expect(err).To(MatchError("attaching loop device to '/build/disk.raw': no free loop device"))
+	})
+})
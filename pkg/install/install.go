@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/suse/elemental/v3/pkg/block"
@@ -45,6 +46,7 @@ type Installer struct {
 	u          upgrade.Interface
 	unpackOpts []unpack.Opt
 	b          bootloader.Bootloader
+	force      bool
 }
 
 func WithUnpackOpts(opts ...unpack.Opt) Option {
@@ -65,7 +67,15 @@ func WithBootloader(b bootloader.Bootloader) Option {
 	}
 }
 
+// WithForce allows installing to disks with mounted filesystems
+func WithForce(force bool) Option {
+	return func(i *Installer) {
+		i.force = force
+	}
+}
+
 func New(ctx context.Context, s *sys.System, opts ...Option) *Installer {
+	s = s.WithComponent("install")
 	installer := &Installer{
 		s:   s,
 		ctx: ctx,
@@ -110,17 +120,41 @@ func (i Installer) Install(d *deployment.Deployment) (err error) {
 	cleanup := cleanstack.NewCleanStack()
 	defer func() { err = cleanup.Cleanup(err) }()
 
+	err = i.attachDiskImages(cleanup, d)
+	if err != nil {
+		return err
+	}
+
 	err = i.checkTargetDisks(d)
 	if err != nil {
 		return err
 	}
 
+	backupDir, err := i.backupPartitionTables(cleanup, d)
+	if err != nil {
+		return err
+	}
+
+	opts := repartOptions(d)
 	for _, disk := range d.Disks {
-		err = repart.PartitionAndFormatDevice(i.s, disk)
+		err = i.wipeDisk(disk)
+		if err != nil {
+			return fmt.Errorf("wiping disk '%s': %w", disk.Device, err)
+		}
+		var reused []*deployment.Partition
+		if disk.Wipe == deployment.WipeKeepData {
+			err = repart.ReconcileDevicePartitions(i.s, disk, opts...)
+		} else {
+			reused, err = repart.PartitionDeviceReusing(i.s, lsblk.NewLsDevice(i.s), disk, opts...)
+		}
 		if err != nil {
 			return fmt.Errorf("partitioning disk '%s': %w", disk.Device, err)
 		}
 		for _, part := range disk.Partitions {
+			if slices.Contains(reused, part) {
+				i.s.Logger().Debug("keeping the volumes of reused partition '%s'", part.Label)
+				continue
+			}
 			i.s.Logger().Debug("creating partition volumes: %+v", part.RWVolumes)
 			err = createPartitionVolumes(i.s, cleanup, part)
 			if err != nil {
@@ -129,6 +163,8 @@ func (i Installer) Install(d *deployment.Deployment) (err error) {
 		}
 	}
 
+	i.storePartitionBackup(backupDir, d)
+
 	err = i.installRecoveryPartition(cleanup, d)
 	if err != nil {
 		return fmt.Errorf("installing recovery system: %w", err)
@@ -146,8 +182,13 @@ func (i Installer) Reset(d *deployment.Deployment) (err error) {
 	cleanup := cleanstack.NewCleanStack()
 	defer func() { err = cleanup.Cleanup(err) }()
 
+	backupDir, err := i.backupPartitionTables(cleanup, d)
+	if err != nil {
+		return err
+	}
+
 	for _, disk := range d.Disks {
-		err = repart.ReconcileDevicePartitions(i.s, disk)
+		err = repart.ReconcileDevicePartitions(i.s, disk, repartOptions(d)...)
 		if err != nil {
 			return fmt.Errorf("partitioning disk '%s': %w", disk.Device, err)
 		}
@@ -160,6 +201,8 @@ func (i Installer) Reset(d *deployment.Deployment) (err error) {
 		}
 	}
 
+	i.storePartitionBackup(backupDir, d)
+
 	err = i.u.Upgrade(d)
 	if err != nil {
 		return fmt.Errorf("executing transaction: %w", err)
@@ -168,6 +211,51 @@ func (i Installer) Reset(d *deployment.Deployment) (err error) {
 	return nil
 }
 
+// repartOptions returns the partitioning options of the deployment, the LUKS volumes of encrypted
+// partitions are created with the configured key file
+func repartOptions(d *deployment.Deployment) []repart.Option {
+	if d.Security == nil || d.Security.LUKSKeyFile == "" {
+		return nil
+	}
+	return []repart.Option{repart.WithKeyFile(d.Security.LUKSKeyFile)}
+}
+
+// attachDiskImages creates the raw disk image files of the disks with a size set and attaches them
+// to loop devices. The disk devices point to the loop devices until the installation is done.
+func (i Installer) attachDiskImages(cleanup *cleanstack.CleanStack, d *deployment.Deployment) error {
+	for _, disk := range d.Disks {
+		if disk.Size == 0 {
+			continue
+		}
+
+		image := disk.Device
+		if info, err := i.s.FS().Stat(image); err == nil && !info.Mode().IsRegular() {
+			return fmt.Errorf("disk size is only supported for raw disk image files, '%s' is not a regular file", image)
+		}
+
+		i.s.Logger().Info("Creating raw disk image '%s'", image)
+		_, err := i.s.Runner().Run("truncate", "-s", fmt.Sprintf("%dM", disk.Size), image)
+		if err != nil {
+			return fmt.Errorf("creating raw disk image '%s': %w", image, err)
+		}
+
+		out, err := i.s.Runner().Run("losetup", "-f", "--show", image)
+		if err != nil {
+			return fmt.Errorf("attaching loop device to '%s': %w", image, err)
+		}
+		device := strings.TrimSpace(string(out))
+		disk.Device = device
+		cleanup.Push(func() error {
+			disk.Device = image
+			_, err := i.s.Runner().Run("losetup", "-d", device)
+			return err
+		})
+	}
+	return nil
+}
+
+// checkTargetDisks refuses to install to disks with mounted filesystems, unless the installer
+// is forced to, in which case it only warns about them.
 func (i Installer) checkTargetDisks(d *deployment.Deployment) error {
 	bDev := lsblk.NewLsDevice(i.s)
 	for _, disk := range d.Disks {
@@ -176,14 +264,112 @@ func (i Installer) checkTargetDisks(d *deployment.Deployment) error {
 			return fmt.Errorf("failed to list target device partitions: %w", err)
 		}
 		for _, part := range parts {
-			if part != nil && len(part.MountPoints) > 0 {
-				return fmt.Errorf("cannot install, target device (%s) has active mountpoints: %v", disk.Device, part.MountPoints)
+			if part == nil || len(part.MountPoints) == 0 {
+				continue
+			}
+			if !i.force {
+				return fmt.Errorf(
+					"cannot install, target device (%s) has active mountpoints: %v, use --force to install anyway",
+					disk.Device, part.MountPoints,
+				)
+			}
+			i.s.Logger().Warn("Forcing installation to '%s', '%s' is mounted at %v", disk.Device, part.Path, part.MountPoints)
+		}
+	}
+	return nil
+}
+
+// wipeDisk clears the current contents of the given disk according to its wipe policy. The
+// partition table is replaced later on regardless of the policy, except for WipeKeepData.
+// Freshly created raw disk images have nothing to wipe.
+func (i Installer) wipeDisk(disk *deployment.Disk) error {
+	if disk.Size > 0 {
+		return nil
+	}
+
+	switch disk.Wipe {
+	case deployment.WipeZapAll:
+		i.s.Logger().Info("Erasing all signatures of disk '%s'", disk.Device)
+		parts, err := lsblk.NewLsDevice(i.s).GetDevicePartitions(disk.Device)
+		if err != nil {
+			return fmt.Errorf("listing partitions: %w", err)
+		}
+		for _, part := range parts {
+			if part == nil || part.Path == "" {
+				continue
+			}
+			_, err = i.s.Runner().Run("wipefs", "--all", "--force", part.Path)
+			if err != nil {
+				return fmt.Errorf("erasing signatures of partition '%s': %w", part.Path, err)
 			}
 		}
+		_, err = i.s.Runner().Run("wipefs", "--all", "--force", disk.Device)
+		if err != nil {
+			return fmt.Errorf("erasing signatures: %w", err)
+		}
+	case deployment.WipeDiscard:
+		i.s.Logger().Info("Discarding all sectors of disk '%s'", disk.Device)
+		_, err := i.s.Runner().Run("blkdiscard", "-f", disk.Device)
+		if err != nil {
+			return fmt.Errorf("discarding sectors: %w", err)
+		}
+	case deployment.WipeSecureDiscard:
+		i.s.Logger().Info("Securely discarding all sectors of disk '%s'", disk.Device)
+		_, err := i.s.Runner().Run("blkdiscard", "-f", "--secure", disk.Device)
+		if err != nil {
+			return fmt.Errorf("securely discarding sectors: %w", err)
+		}
 	}
 	return nil
 }
 
+// backupPartitionTables dumps the current partition tables and LUKS headers of all target disks into
+// a temporary directory. It returns an empty path if none of the disks had a partition table to back up.
+func (i Installer) backupPartitionTables(cleanup *cleanstack.CleanStack, d *deployment.Deployment) (string, error) {
+	backupDir, err := vfs.TempDir(i.s.FS(), "", "elemental_partbackup")
+	if err != nil {
+		return "", fmt.Errorf("creating temporary directory for partition table backups: %w", err)
+	}
+	cleanup.Push(func() error { return i.s.FS().RemoveAll(backupDir) })
+
+	var found bool
+	bDev := lsblk.NewLsDevice(i.s)
+	for _, disk := range d.Disks {
+		ok, err := repart.BackupDevice(i.s, bDev, disk.Device, backupDir)
+		if err != nil {
+			return "", fmt.Errorf("backing up disk '%s': %w", disk.Device, err)
+		}
+		found = found || ok
+	}
+	if !found {
+		return "", nil
+	}
+	return backupDir, nil
+}
+
+// storePartitionBackup copies the partition table backups from the given directory into the recovery partition,
+// or the config partition if there is no recovery partition. This is a best effort call, failures are only logged
+// as the disks have already been repartitioned at this point.
+func (i Installer) storePartitionBackup(backupDir string, d *deployment.Deployment) {
+	if backupDir == "" {
+		return
+	}
+
+	part := d.GetRecoveryPartition()
+	if part == nil {
+		part = d.GetConfigPartition()
+	}
+	if part == nil {
+		i.s.Logger().Warn("No recovery or config partition defined, partition table backups are not kept")
+		return
+	}
+
+	err := repart.StoreBackup(i.ctx, i.s, backupDir, part)
+	if err != nil {
+		i.s.Logger().Warn("Could not store partition table backups in '%s' partition: %v", part.Role.String(), err)
+	}
+}
+
 func (i Installer) installRecoveryPartition(cleanup *cleanstack.CleanStack, d *deployment.Deployment) (err error) {
 	recPart := d.GetRecoveryPartition()
 	if recPart == nil {
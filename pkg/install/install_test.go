@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -168,6 +169,32 @@ var _ = Describe("Install", Label("install"), func() {
 			{"mksquashfs"},
 		}))
 	})
+	It("installs the given deployment to a raw disk image file", func() {
+		deployment.WithRecoveryPartition(0)(d)
+		d.Disks[0].Device = "/build/disk.raw"
+		d.Disks[0].Size = 4096
+		sideEffects["losetup"] = func(args ...string) ([]byte, error) {
+			if slices.Contains(args, "--show") {
+				return []byte("/dev/device\n"), nil
+			}
+			return []byte{}, nil
+		}
+		Expect(i.Install(d)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"truncate", "-s", "4096M", "/build/disk.raw"},
+			{"losetup", "-f", "--show", "/build/disk.raw"},
+			{"systemd-repart"},
+			{"losetup", "-d", "/dev/device"},
+		})).To(Succeed())
+		Expect(d.Disks[0].Device).To(Equal("/build/disk.raw"))
+	})
+	It("fails to install to a block device with a disk size", func() {
+		Expect(fs.Mkdir("/dev/block", vfs.DirPerm)).To(Succeed())
+		d.Disks[0].Device = "/dev/block"
+		d.Disks[0].Size = 4096
+		Expect(i.Install(d)).To(MatchError(ContainSubstring("'/dev/block' is not a regular file")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
 	It("fails if lsblk can't get target device data", func() {
 		sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
 			return nil, fmt.Errorf("lsblk failed")
@@ -180,6 +207,101 @@ var _ = Describe("Install", Label("install"), func() {
 		}
 		Expect(i.Install(d)).To(MatchError(ContainSubstring("has active mountpoints")))
 	})
+	It("installs to a target device with mountpoints if forced to", func() {
+		sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
+			if slices.Contains(args, "NAME,PHY-SEC") {
+				return []byte(sectorSizeJson), nil
+			}
+			return []byte(lsblkJson), nil
+		}
+		deployment.WithRecoveryPartition(0)(d)
+		i = install.New(context.Background(), s, install.WithUpgrader(upgrader), install.WithForce(true))
+		Expect(i.Install(d)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).To(Succeed())
+	})
+	It("erases all signatures of the target disk and its partitions before partitioning", func() {
+		deployment.WithRecoveryPartition(0)(d)
+		d.Disks[0].Wipe = deployment.WipeZapAll
+		sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
+			if slices.Contains(args, "NAME,PHY-SEC") {
+				return []byte(sectorSizeJson), nil
+			}
+			if slices.Contains(args, "/dev/device") {
+				return []byte(`{"blockdevices": [{"path": "/dev/device1", "pkname": "/dev/device", "type": "part"}]}`), nil
+			}
+			return []byte(lsblkJson), nil
+		}
+		Expect(i.Install(d)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"wipefs", "--all", "--force", "/dev/device1"},
+			{"wipefs", "--all", "--force", "/dev/device"},
+			{"systemd-repart"},
+		})).To(Succeed())
+	})
+	It("discards all sectors of the target disk before partitioning", func() {
+		deployment.WithRecoveryPartition(0)(d)
+		d.Disks[0].Wipe = deployment.WipeSecureDiscard
+		Expect(i.Install(d)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"blkdiscard", "-f", "--secure", "/dev/device"},
+			{"systemd-repart"},
+		})).To(Succeed())
+	})
+	It("keeps the existing partitions of the target disk", func() {
+		deployment.WithRecoveryPartition(0)(d)
+		d.Disks[0].Wipe = deployment.WipeKeepData
+		Expect(i.Install(d)).To(Succeed())
+		for _, cmd := range runner.GetCmds() {
+			if cmd[0] == "systemd-repart" {
+				Expect(cmd).To(ContainElement("--empty=allow"))
+			}
+		}
+		Expect(runner.IncludesCmds([][]string{{"wipefs"}})).NotTo(Succeed())
+	})
+	It("reuses an existing partition of the target disk", func() {
+		deployment.WithRecoveryPartition(0)(d)
+		d.GetSystemPartition().Size = 8192
+		d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+			Role: deployment.Generic, Label: "HOME", MountPoint: "/home", Reuse: true,
+		})
+		Expect(vfs.MkdirAll(fs, "/sys/class/block/device1", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/sys/class/block/device1/partition", []byte("1\n"), vfs.FilePerm)).To(Succeed())
+		sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
+			if slices.Contains(args, "NAME,PHY-SEC") {
+				return []byte(sectorSizeJson), nil
+			}
+			if slices.Contains(args, "/dev/device") {
+				return []byte(`{"blockdevices": [{
+					"partlabel": "HOME", "partuuid": "8ecc2443-e92c-4751-a8ab-b8ddb348a2ab",
+					"path": "/dev/device1", "pkname": "/dev/device", "type": "part"
+				}]}`), nil
+			}
+			return []byte(lsblkJson), nil
+		}
+		sideEffects["systemd-repart"] = func(args ...string) ([]byte, error) {
+			return []byte(`[
+				{"uuid" : "c60d1845-7b04-4fc4-8639-8c49eb7277d5", "file" : "/tmp/elemental-repart.d/0-efi.conf"},
+				{"uuid" : "ddb334a8-48a2-c4de-ddb3-849eb2443e92", "file" : "/tmp/elemental-repart.d/1-recovery.conf"},
+				{"uuid" : "34a8abb8-ddb3-48a2-8ecc-2443e92c7510", "file" : "/tmp/elemental-repart.d/2-system.conf"},
+				{"uuid" : "8ecc2443-e92c-4751-a8ab-b8ddb348a2ab", "file" : "/tmp/elemental-repart.d/3-generic.conf"}
+			]`), nil
+		}
+		Expect(i.Install(d)).To(Succeed())
+		Expect(d.Disks[0].Partitions[3].UUID).To(Equal("8ecc2443-e92c-4751-a8ab-b8ddb348a2ab"))
+		for _, cmd := range runner.GetCmds() {
+			if cmd[0] == "systemd-repart" {
+				Expect(cmd).To(ContainElement("--empty=allow"))
+			}
+		}
+	})
+	It("fails if the target disk can't be wiped", func() {
+		d.Disks[0].Wipe = deployment.WipeDiscard
+		sideEffects["blkdiscard"] = func(args ...string) ([]byte, error) {
+			return nil, fmt.Errorf("discard not supported")
+		}
+		Expect(i.Install(d)).To(MatchError(ContainSubstring("discard not supported")))
+		Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).NotTo(Succeed())
+	})
 	It("fails if systemd-repart partitions do not match deployment", func() {
 		// systemd-repart reports a recovery partition that is not part of the deployment
 		Expect(i.Install(d)).To(MatchError(ContainSubstring("matching partitions and systemd-repart JSON output")))
@@ -213,6 +335,29 @@ var _ = Describe("Install", Label("install"), func() {
 			{"mksquashfs"},
 		}))
 	})
+	It("backs up the current partition table before resetting", func() {
+		sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
+			return []byte(lsblkJson), runner.ReturnError
+		}
+		deployment.WithRecoveryPartition(0)(d)
+		Expect(i.Reset(d)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"sgdisk", "--backup="},
+			{"systemd-repart"},
+		})).To(Succeed())
+		Expect(mounter.List()).To(BeEmpty())
+	})
+	It("does not reset if the partition table backup fails", func() {
+		sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
+			return []byte(lsblkJson), runner.ReturnError
+		}
+		sideEffects["sgdisk"] = func(args ...string) ([]byte, error) {
+			return nil, fmt.Errorf("sgdisk failed")
+		}
+		deployment.WithRecoveryPartition(0)(d)
+		Expect(i.Reset(d)).To(MatchError(ContainSubstring("sgdisk failed")))
+		Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).NotTo(Succeed())
+	})
 	It("resets the given deployment", func() {
 		deployment.WithRecoveryPartition(0)(d)
 		Expect(i.Reset(d)).To(Succeed())
@@ -221,4 +366,64 @@ var _ = Describe("Install", Label("install"), func() {
 			{"btrfs", "subvolume", "create"},
 		}))
 	})
+	It("clones a deployment into another disk", func() {
+		deployment.WithRecoveryPartition(0)(d)
+		srcUUIDs := []string{
+			"0b1a7e4c-52f7-4f1c-9a3d-1c2b3d4e5f60", "1c2b3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d", "2d3c4e5f-6071-4b8c-9dae-1f2a3b4c5d6e",
+		}
+		for j, uuid := range srcUUIDs {
+			d.Disks[0].Partitions[j].UUID = uuid
+		}
+		sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
+			if slices.Contains(args, "NAME,PHY-SEC") {
+				return []byte(sectorSizeJson), nil
+			}
+			if slices.Contains(args, "/dev/device") {
+				return []byte(`{"blockdevices": []}`), nil
+			}
+			srcParts := ""
+			for j, uuid := range srcUUIDs {
+				srcParts += fmt.Sprintf(`{"partuuid": "%s", "path": "/dev/source%d", "pkname": "/dev/source", "type": "part"},`, uuid, j+1)
+			}
+			return []byte(strings.Replace(lsblkJson, `"blockdevices": [`, `"blockdevices": [`+srcParts, 1)), nil
+		}
+
+		clone, err := install.CloneDeployment(d, "/dev/device", "/snapshot")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(clone.SourceOS.URI()).To(Equal("/snapshot"))
+		for _, part := range clone.Disks[0].Partitions {
+			Expect(part.UUID).To(BeEmpty())
+		}
+
+		Expect(i.Clone(d, clone)).To(Succeed())
+		for j, part := range clone.Disks[0].Partitions {
+			Expect(part.UUID).NotTo(BeEmpty())
+			Expect(part.UUID).NotTo(Equal(d.Disks[0].Partitions[j].UUID))
+		}
+		Expect(clone.Disks[0].Partitions[1].UUID).To(Equal("ddb334a8-48a2-c4de-ddb3-849eb2443e92"))
+
+		// The recovery partition and every non snapshotted RW volume of the system partition are copied
+		var rsyncs int
+		for _, cmd := range runner.GetCmds() {
+			if cmd[0] == "rsync" {
+				rsyncs++
+			}
+		}
+		Expect(rsyncs).To(Equal(7))
+		Expect(runner.MatchMilestones([][]string{
+			{"systemd-repart"},
+			{"rsync"},
+		})).To(Succeed())
+	})
+	It("fails to clone a multi disk deployment", func() {
+		d.Disks = append(d.Disks, &deployment.Disk{Device: "/dev/device2"})
+		_, err := install.CloneDeployment(d, "/dev/device", "/snapshot")
+		Expect(err).To(MatchError(ContainSubstring("only supported for single disk deployments")))
+	})
+	It("fails to clone if the layouts do not match", func() {
+		clone, err := install.CloneDeployment(d, "/dev/device", "/snapshot")
+		Expect(err).NotTo(HaveOccurred())
+		deployment.WithRecoveryPartition(0)(clone)
+		Expect(i.Clone(d, clone)).To(MatchError(ContainSubstring("do not share the same disk layout")))
+	})
 })
@@ -23,6 +23,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
+	"path"
 	"path/filepath"
 
 	"go.yaml.in/yaml/v3"
@@ -31,10 +33,13 @@ import (
 	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/http"
+	"github.com/suse/elemental/v3/pkg/iso9660"
 	"github.com/suse/elemental/v3/pkg/repart"
 	"github.com/suse/elemental/v3/pkg/rsync"
 	"github.com/suse/elemental/v3/pkg/selinux"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/runner"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
@@ -48,6 +53,10 @@ const (
 	isoBootCatalog = "boot.catalog"
 	cfgScript      = "setup.sh"
 	xorriso        = "xorriso"
+	isoVolumeID    = "LIVE"
+
+	// outputTailLines is the number of xorriso output lines kept for error reporting
+	outputTailLines = 50
 
 	LiveMountPoint  = "/run/initramfs/live"
 	SquashfsRelPath = liveDir + "/" + squashfsImg
@@ -61,6 +70,7 @@ type MediaType int
 const (
 	ISO MediaType = iota + 1
 	Disk
+	Netboot
 )
 
 func (m MediaType) String() string {
@@ -69,6 +79,8 @@ func (m MediaType) String() string {
 		return "iso"
 	case Disk:
 		return "raw"
+	case Netboot:
+		return "netboot"
 	default:
 		return "unknown"
 	}
@@ -80,11 +92,45 @@ func StringToMediaType(mType string) (MediaType, error) {
 		return Disk, nil
 	case "iso":
 		return ISO, nil
+	case "netboot":
+		return Netboot, nil
 	default:
 		return 0, fmt.Errorf("unsupported media type %s: %w", mType, errors.ErrUnsupported)
 	}
 }
 
+// ISOBackend is the tool used to write ISO images
+type ISOBackend int
+
+const (
+	// AutoISOBackend uses xorriso if it is installed and the native writer otherwise
+	AutoISOBackend ISOBackend = iota
+	XorrisoISOBackend
+	NativeISOBackend
+)
+
+// RootfsFormat is the filesystem of the live root image. Regardless of the format the image is
+// stored at SquashfsRelPath, dracut and the installer detect its filesystem when mounting it.
+type RootfsFormat string
+
+const (
+	SquashfsRootfs RootfsFormat = "squashfs"
+	EROFSRootfs    RootfsFormat = "erofs"
+	Ext4Rootfs     RootfsFormat = "ext4"
+
+	// ext4RootfsOverhead is the free space, in MiB, reserved for the ext4 metadata
+	ext4RootfsOverhead = 256
+)
+
+func ParseRootfsFormat(format string) (RootfsFormat, error) {
+	switch f := RootfsFormat(format); f {
+	case SquashfsRootfs, EROFSRootfs, Ext4Rootfs:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported rootfs format %s: %w", format, errors.ErrUnsupported)
+	}
+}
+
 type Option func(*Media)
 
 type Media struct {
@@ -100,6 +146,9 @@ type Media struct {
 	bl          bootloader.Bootloader
 	outputFile  string
 	rawDiskSize deployment.MiB
+	isoBackend  ISOBackend
+	netbootURL  string
+	rootfs      RootfsFormat
 }
 
 // WithBootloader allows to create an ISO object with the given bootloader interface instance
@@ -122,6 +171,27 @@ func WithRawDiskSize(size deployment.MiB) Option {
 	}
 }
 
+// WithISOBackend sets the tool used to write ISO images, defaults to AutoISOBackend
+func WithISOBackend(backend ISOBackend) Option {
+	return func(i *Media) {
+		i.isoBackend = backend
+	}
+}
+
+// WithRootfsFormat sets the filesystem of the live root image, defaults to SquashfsRootfs
+func WithRootfsFormat(format RootfsFormat) Option {
+	return func(i *Media) {
+		i.rootfs = format
+	}
+}
+
+// WithNetbootURL sets the base HTTP(S) URL netboot media is served from
+func WithNetbootURL(netbootURL string) Option {
+	return func(i *Media) {
+		i.netbootURL = netbootURL
+	}
+}
+
 func WithOutputFile(outputFile string) Option {
 	return func(i *Media) {
 		i.outputFile = outputFile
@@ -136,12 +206,13 @@ func NewMedia(ctx context.Context, s *sys.System, mType MediaType, opts ...Optio
 		ctx:        ctx,
 		unpackOpts: []unpack.Opt{},
 		mType:      mType,
+		rootfs:     SquashfsRootfs,
 	}
 	for _, o := range opts {
 		o(media)
 	}
 	if media.bl == nil {
-		media.bl, _ = bootloader.New(bootloader.BootGrub, media.s)
+		media.bl, _ = bootloader.New(ctx, bootloader.BootGrub, media.s)
 	}
 	if media.mType == ISO {
 		media.Label = "LIVE"
@@ -149,13 +220,12 @@ func NewMedia(ctx context.Context, s *sys.System, mType MediaType, opts ...Optio
 	return media
 }
 
-// extractISO extracts the given source path (relative to iso root) to the destination path
-func extractISO(s *sys.System, iso, srcPath, destPath string) error {
+// ExtractISO extracts the given source path (relative to iso root) to the destination path
+func ExtractISO(s *sys.System, iso, srcPath, destPath string) error {
 	args := []string{
 		"-osirrox", "on:auto_chmod_on", "-overwrite", "nondir", "-indev", iso, "-extract", srcPath, destPath,
 	}
-	out, err := s.Runner().Run("xorriso", args...)
-	s.Logger().Debug("xorriso output: %s", string(out))
+	err := runXorriso(context.Background(), s, args...)
 	if err != nil {
 		return fmt.Errorf("failed extracting '%s' to '%s' from iso '%s': %w", srcPath, destPath, iso, err)
 	}
@@ -167,7 +237,7 @@ func LoadISOInstallDesc(s *sys.System, tempDir, iso string) (*deployment.Deploym
 	installDst := filepath.Join(tempDir, installCfg)
 	installSrc := filepath.Join(installDir, installCfg)
 
-	err := extractISO(s, iso, installSrc, installDst)
+	err := ExtractISO(s, iso, installSrc, installDst)
 	if err != nil {
 		return nil, fmt.Errorf("failed extracting install description: %w", err)
 	}
@@ -225,6 +295,9 @@ func (i Media) Build(d *deployment.Deployment) (err error) {
 		err = i.buildISO(tempDir, liveRoot, osRoot, cmdline)
 	case Disk:
 		err = i.buildDisk(tempDir, liveRoot, osRoot, d)
+	case Netboot:
+		// Netboot media is a directory tree including its own checksums file
+		return i.buildNetboot(liveRoot, osRoot, d)
 	default:
 		return fmt.Errorf("unknown media type: %w", errors.ErrUnsupported)
 	}
@@ -250,7 +323,7 @@ func (i *Media) PrepareInstallerFS(rootDir, workDir string, d *deployment.Deploy
 	case d.SourceOS.IsRaw():
 		// We assume this is coming from a ready to be used installer media
 		// no need to unpack and repack
-		err = vfs.CopyFile(i.s.FS(), d.SourceOS.URI(), squashImg)
+		err = vfs.CopyFileContext(i.ctx, i.s.FS(), d.SourceOS.URI(), squashImg)
 		if err != nil {
 			return fmt.Errorf("failed copying OS image to installer root tree: %w", err)
 		}
@@ -259,14 +332,14 @@ func (i *Media) PrepareInstallerFS(rootDir, workDir string, d *deployment.Deploy
 		if err != nil {
 			return fmt.Errorf("preparing unpack: %w", err)
 		}
-		err = filesystem.CreateSquashFS(i.ctx, i.s, workDir, squashImg, filesystem.DefaultSquashfsCompressionOptions())
+		err = i.createRootfsImage(workDir, squashImg, d.ImagesCompression())
 		if err != nil {
 			return fmt.Errorf("failed creating image (%s) for live ISO: %w", squashImg, err)
 		}
 	}
 
 	if d.Installer.CfgScript != "" {
-		err = vfs.CopyFile(i.s.FS(), d.Installer.CfgScript, filepath.Join(imgDir, cfgScript))
+		err = vfs.CopyFileContext(i.ctx, i.s.FS(), d.Installer.CfgScript, filepath.Join(imgDir, cfgScript))
 		if err != nil {
 			return fmt.Errorf("failed copying %s to image directory: %w", d.Installer.CfgScript, err)
 		}
@@ -310,8 +383,30 @@ func (i *Media) PrepareInstallerFS(rootDir, workDir string, d *deployment.Deploy
 	return i.writeInstallDescription(filepath.Join(rootDir, installDir), d)
 }
 
+// RootfsFormat returns the filesystem of the live root image
+func (i Media) RootfsFormat() RootfsFormat {
+	return i.rootfs
+}
+
+// createRootfsImage creates the live root image of the configured format from the given root tree,
+// compressed images use the given compression
+func (i Media) createRootfsImage(root, image string, compression *deployment.Compression) error {
+	switch i.rootfs {
+	case EROFSRootfs:
+		return filesystem.CreateEROFS(i.ctx, i.s, root, image, filesystem.EROFSCompressionOptions(compression))
+	case Ext4Rootfs:
+		return filesystem.CreatePreloadedFileSystemImage(i.ctx, i.s, root, image, "", ext4RootfsOverhead, deployment.Ext4)
+	default:
+		return filesystem.CreateSquashFS(i.ctx, i.s, root, image, filesystem.SquashfsCompressionOptions(compression))
+	}
+}
+
 // Customize repacks an existing installer with more artifacts.
 func (i *Media) Customize(d *deployment.Deployment) (err error) {
+	if i.mType == Netboot {
+		return fmt.Errorf("customizing netboot media: %w", errors.ErrUnsupported)
+	}
+
 	err = i.sanitize()
 	if err != nil {
 		return fmt.Errorf("cannot proceed with customize due to inconsistent setup: %w", err)
@@ -411,6 +506,14 @@ func (i *Media) Customize(d *deployment.Deployment) (err error) {
 	return i.writeChecksum()
 }
 
+// OutputFile returns the path of the media image file produced by Build or Customize
+func (i Media) OutputFile() string {
+	if i.outputFile != "" {
+		return i.outputFile
+	}
+	return filepath.Join(i.OutputDir, fmt.Sprintf("%s.%s", i.Name, i.mType.String()))
+}
+
 // writeChecksum computes the checksum for the current media output file and writes
 // the checksum file to the same output file path, but with the *.sha256 suffix
 func (i Media) writeChecksum() error {
@@ -495,6 +598,13 @@ func (i *Media) sanitize() error {
 		return fmt.Errorf("undefined name of the installer media")
 	}
 
+	if i.mType == Netboot {
+		u, err := url.Parse(i.netbootURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("netboot media requires an HTTP(S) URL, got '%s'", i.netbootURL)
+		}
+	}
+
 	if i.InputFile != "" {
 		if ok, _ := vfs.Exists(i.s.FS(), i.InputFile); !ok {
 			return fmt.Errorf("target input file %s does not exist", i.InputFile)
@@ -502,7 +612,7 @@ func (i *Media) sanitize() error {
 	}
 
 	if i.outputFile == "" {
-		i.outputFile = filepath.Join(i.OutputDir, fmt.Sprintf("%s.%s", i.Name, i.mType.String()))
+		i.outputFile = i.OutputFile()
 		if ok, _ := vfs.Exists(i.s.FS(), i.outputFile); ok {
 			return fmt.Errorf("target output file %s is an already existing file", i.outputFile)
 		}
@@ -518,7 +628,7 @@ func (i Media) customizeISO(inputFile, outputFile string, fileMap map[string]str
 		args = append(args, "-map", f, m)
 	}
 
-	_, err := i.s.Runner().RunContext(i.ctx, xorriso, args...)
+	err := runXorriso(i.ctx, i.s, args...)
 	if err != nil {
 		return fmt.Errorf("failed creating the installer ISO image: %w", err)
 	}
@@ -602,7 +712,7 @@ func (i Media) addInstallationAssets(root string, d *deployment.Deployment) erro
 	}
 
 	if d.CfgScript != "" {
-		err = vfs.CopyFile(i.s.FS(), d.CfgScript, filepath.Join(installPath, cfgScript))
+		err = vfs.CopyFileContext(i.ctx, i.s.FS(), d.CfgScript, filepath.Join(installPath, cfgScript))
 		if err != nil {
 			return fmt.Errorf("failed copying %s to install directory: %w", d.CfgScript, err)
 		}
@@ -624,12 +734,16 @@ func (i Media) addInstallationAssets(root string, d *deployment.Deployment) erro
 			}
 			d.OverlayTree = deployment.NewDirSrc(filepath.Join(LiveMountPoint, installDir, overlayDir))
 		case d.OverlayTree.IsRaw() || d.OverlayTree.IsTar():
-			overlayFile := filepath.Join(overlayPath, filepath.Base(d.OverlayTree.URI()))
-			err = vfs.CopyFile(i.s.FS(), d.OverlayTree.URI(), overlayFile)
+			overlayFile := filepath.Join(overlayPath, overlayFileName(d.OverlayTree))
+			if d.OverlayTree.IsRemote() {
+				err = http.Fetch(i.ctx, i.s.FS(), d.OverlayTree.URI(), overlayFile)
+			} else {
+				err = vfs.CopyFileContext(i.ctx, i.s.FS(), d.OverlayTree.URI(), overlayFile)
+			}
 			if err != nil {
 				return fmt.Errorf("failed adding overlay image to ISO directory tree: %w", err)
 			}
-			path := filepath.Join(LiveMountPoint, installDir, overlayDir, filepath.Base(d.OverlayTree.URI()))
+			path := filepath.Join(LiveMountPoint, installDir, overlayDir, overlayFileName(d.OverlayTree))
 			if d.OverlayTree.IsTar() {
 				d.OverlayTree = deployment.NewTarSrc(path)
 			} else {
@@ -642,7 +756,7 @@ func (i Media) addInstallationAssets(root string, d *deployment.Deployment) erro
 		extensions := []string{}
 		for j, extension := range d.BootConfig.InitrdExtensions {
 			extFile := fmt.Sprintf("%d-%s", j, filepath.Base(extension))
-			err = vfs.CopyFile(i.s.FS(), extension, filepath.Join(installPath, extFile))
+			err = vfs.CopyFileContext(i.ctx, i.s.FS(), extension, filepath.Join(installPath, extFile))
 			if err != nil {
 				return fmt.Errorf("copying initrd extension %q: %w", extension, err)
 			}
@@ -654,6 +768,15 @@ func (i Media) addInstallationAssets(root string, d *deployment.Deployment) erro
 	return nil
 }
 
+// overlayFileName returns the file name of the given overlay image or tarball within the media.
+// Remote tarballs are named after their URL path, without the query and checksum fragment.
+func overlayFileName(src *deployment.ImageSource) string {
+	if u, err := url.Parse(src.URI()); err == nil && src.IsRemote() {
+		return path.Base(u.Path)
+	}
+	return filepath.Base(src.URI())
+}
+
 // writeInstallDescription writes the installation yaml file embedded in installer media
 // with the installer assets related to the live system mount point.
 func (i Media) writeInstallDescription(installPath string, d *deployment.Deployment) error {
@@ -669,7 +792,7 @@ func (i Media) writeInstallDescription(installPath string, d *deployment.Deploym
 		case d.OverlayTree.IsDir():
 			d.OverlayTree = deployment.NewDirSrc(filepath.Join(LiveMountPoint, installDir, overlayDir))
 		case d.OverlayTree.IsRaw() || d.OverlayTree.IsTar():
-			path := filepath.Join(LiveMountPoint, installDir, overlayDir, filepath.Base(d.OverlayTree.URI()))
+			path := filepath.Join(LiveMountPoint, installDir, overlayDir, overlayFileName(d.OverlayTree))
 			if d.OverlayTree.IsTar() {
 				d.OverlayTree = deployment.NewTarSrc(path)
 			} else {
@@ -687,6 +810,10 @@ func (i Media) writeInstallDescription(installPath string, d *deployment.Deploym
 	}
 
 	d.SourceOS = deployment.NewRawSrc(SquashfsPath)
+	if i.mType == Netboot {
+		// The squashfs image is fetched over the network and not available within the live mount point
+		d.SourceOS = deployment.NewDirSrc(NetbootRootfs)
+	}
 	d.Installer.OverlayTree = deployment.NewDirSrc(LiveMountPoint)
 
 	if i.mType == Disk {
@@ -723,7 +850,7 @@ func (i Media) customizeDisk(tempDir string, d *deployment.Deployment, mappedFil
 		return fmt.Errorf("undefined essential recovery or esp partitions")
 	}
 
-	err = extractISO(i.s, i.InputFile, "/", isoDir)
+	err = ExtractISO(i.s, i.InputFile, "/", isoDir)
 	if err != nil {
 		return err
 	}
@@ -742,7 +869,7 @@ func (i Media) customizeDisk(tempDir string, d *deployment.Deployment, mappedFil
 		if info.IsDir() {
 			err = sync.SyncData(k, target)
 		} else {
-			err = vfs.CopyFile(i.s.FS(), k, target)
+			err = vfs.CopyFileContext(i.ctx, i.s.FS(), k, target)
 		}
 		if err != nil {
 			return err
@@ -820,18 +947,44 @@ func (i Media) buildISO(tempDir, isoDir, osRoot, kernelCmdline string) error {
 	}
 
 	efiImg := filepath.Join(tempDir, filepath.Base(espDir)+".img")
-	err = filesystem.CreatePreloadedFileSystemImage(i.s, espDir, efiImg, deployment.EfiLabel, 1, deployment.VFat)
+	err = filesystem.CreatePreloadedFileSystemImage(i.ctx, i.s, espDir, efiImg, deployment.EfiLabel, 1, deployment.VFat)
 	if err != nil {
 		return fmt.Errorf("failed creating EFI image for the installer image: %w", err)
 	}
 
+	biosBoot, _ := vfs.Exists(i.s.FS(), filepath.Join(isoDir, bootloader.BIOSLiveImage))
+	if !biosBoot {
+		i.s.Logger().Warn("No BIOS boot image found, the installer ISO image is only bootable on UEFI")
+	}
+
+	if !i.useXorriso() {
+		i.s.Logger().Info("Writing the installer ISO image with the native writer")
+		opts := []iso9660.Option{
+			iso9660.WithVolumeID(isoVolumeID), iso9660.WithEFIBootImage(efiImg), iso9660.WithPermissions(0755),
+		}
+		if biosBoot {
+			opts = append(opts,
+				iso9660.WithBIOSBootImage(bootloader.BIOSLiveImage),
+				iso9660.WithHybridMBR(filepath.Join(isoDir, bootloader.BIOSHybridMBR)),
+			)
+		}
+		err = iso9660.NewWriter(i.s, opts...).Write(i.ctx, isoDir, i.outputFile)
+		if err != nil {
+			return fmt.Errorf("failed writing the installer ISO image: %w", err)
+		}
+		return nil
+	}
+
 	args := []string{
-		"-volid", "LIVE", "-padding", "0",
+		"-volid", isoVolumeID, "-padding", "0",
 		"-outdev", i.outputFile, "-map", isoDir, "/", "-chmod", "0755", "--",
 	}
+	if biosBoot {
+		args = append(args, xorrisoBIOSArgs(filepath.Join(isoDir, bootloader.BIOSHybridMBR))...)
+	}
 	args = append(args, xorrisoBootloaderArgs(efiImg)...)
 
-	_, err = i.s.Runner().RunContext(i.ctx, xorriso, args...)
+	err = runXorriso(i.ctx, i.s, args...)
 	if err != nil {
 		return fmt.Errorf("failed creating the installer ISO image: %w", err)
 	}
@@ -839,6 +992,32 @@ func (i Media) buildISO(tempDir, isoDir, osRoot, kernelCmdline string) error {
 	return nil
 }
 
+// useXorriso reports whether ISO images are written with xorriso or with the native writer
+func (i Media) useXorriso() bool {
+	switch i.isoBackend {
+	case XorrisoISOBackend:
+		return true
+	case NativeISOBackend:
+		return false
+	default:
+		return sys.CommandExists(xorriso)
+	}
+}
+
+// runXorriso runs xorriso streaming its output to the debug log, only the last output lines are
+// kept to be logged on error
+func runXorriso(ctx context.Context, s *sys.System, args ...string) error {
+	tail := runner.NewLineTail(outputTailLines)
+	handler := runner.Handlers(tail.Handler, func(line string) {
+		s.Logger().Debug("xorriso: %s", line)
+	})
+	err := s.Runner().RunContextParseOutput(ctx, handler, handler, xorriso, args...)
+	if err != nil {
+		s.Logger().Error("Error running xorriso, last output lines:\n%s", tail)
+	}
+	return err
+}
+
 // xorrisoBootloaderArgs returns a slice of flags for xorriso to defined a common bootloader parameters
 //
 //nolint:goconst
@@ -855,6 +1034,24 @@ func xorrisoBootloaderArgs(efiImg string) []string {
 	return args
 }
 
+// xorrisoBIOSArgs returns a slice of flags for xorriso to add the GRUB BIOS El Torito image and
+// the hybrid MBR, BIOS args must precede the EFI args as they define the default boot entry
+//
+//nolint:goconst
+func xorrisoBIOSArgs(hybridMBR string) []string {
+	return []string{
+		"-boot_image", "grub", fmt.Sprintf("bin_path=/%s", bootloader.BIOSLiveImage),
+		"-boot_image", "grub", fmt.Sprintf("grub2_mbr=%s", hybridMBR),
+		"-boot_image", "grub", "grub2_boot_info=on",
+		"-boot_image", "any", "boot_info_table=on",
+		"-boot_image", "any", "load_size=2048",
+		"-boot_image", "any", "platform_id=0x00",
+		"-boot_image", "any", "emul_type=no_emulation",
+		"-boot_image", "any", "mbr_force_bootable=on",
+		"-boot_image", "any", "next",
+	}
+}
+
 // calcFileChecksum opens the given file and returns the sha256 checksum of it.
 func calcFileChecksum(fs vfs.FS, fileName string) (string, error) {
 	f, err := fs.Open(fileName)
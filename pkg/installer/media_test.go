@@ -20,6 +20,9 @@ package installer_test
 import (
 	"context"
 	"fmt"
+	gohttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"slices"
 	"strings"
 
@@ -93,7 +96,10 @@ var _ = Describe("InstallerMedia", Label("installermedia"), func() {
 			InitrdExtensions: []string{"/some/dir/initrdExt"},
 		}
 
-		iso := installer.NewMedia(context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)))
+		iso := installer.NewMedia(
+			context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)),
+			installer.WithISOBackend(installer.XorrisoISOBackend),
+		)
 
 		iso.OutputDir = "/some/dir/build"
 		d.CfgScript = "/some/dir/config.sh"
@@ -113,9 +119,164 @@ var _ = Describe("InstallerMedia", Label("installermedia"), func() {
 			{"xorriso", "-volid", "LIVE", "-padding", "0", "-outdev", "/some/dir/build/installer.iso"},
 		}))
 	})
+	It("creates a hybrid BIOS and UEFI installation ISO", func() {
+		var xorrisoArgs []string
+		sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+			xorrisoArgs = args
+			Expect(fs.WriteFile("/some/dir/build/installer.iso", []byte("data"), vfs.FilePerm)).To(Succeed())
+			return []byte{}, nil
+		}
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		iso := installer.NewMedia(
+			context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)),
+			installer.WithISOBackend(installer.XorrisoISOBackend),
+		)
+		iso.OutputDir = "/some/dir/build"
+		isoDir := "/some/dir/build/elemental-installer/liveroot"
+		Expect(vfs.MkdirAll(fs, filepath.Join(isoDir, "boot/grub2/i386-pc"), vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(isoDir, bootloader.BIOSLiveImage), []byte("eltorito"), vfs.FilePerm)).To(Succeed())
+
+		Expect(iso.Build(d)).To(Succeed())
+		Expect(strings.Join(xorrisoArgs, " ")).To(ContainSubstring(
+			"-boot_image grub bin_path=/boot/grub2/i386-pc/eltorito.img -boot_image grub grub2_mbr=",
+		))
+		Expect(xorrisoArgs).To(ContainElement("grub2_boot_info=on"))
+		Expect(xorrisoArgs).To(ContainElement("efi_path=--interval:appended_partition_2:all::"))
+	})
+	It("creates an installation ISO with the native writer", func() {
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		iso := installer.NewMedia(
+			context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)),
+			installer.WithISOBackend(installer.NativeISOBackend),
+		)
+		iso.OutputDir = "/some/dir/build"
+
+		Expect(iso.Build(d)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{"xorriso"}})).NotTo(Succeed())
+
+		data, err := fs.ReadFile("/some/dir/build/installer.iso")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data[16*2048+1 : 16*2048+6])).To(Equal("CD001"))
+		Expect(string(data[16*2048+40 : 16*2048+44])).To(Equal("LIVE"))
+	})
+	It("creates netboot media", func() {
+		sideEffects["rsync"] = func(args ...string) ([]byte, error) {
+			// rsync gets the raw host path of the test filesystem
+			target := args[len(args)-1]
+			modules := filepath.Join(target[strings.Index(target, "/some/"):], "usr/lib/modules/6.14.4-1-default")
+			if strings.Contains(modules, "osroot") {
+				Expect(vfs.MkdirAll(fs, modules, vfs.DirPerm)).To(Succeed())
+				Expect(fs.WriteFile(filepath.Join(modules, "vmlinuz"), []byte("kernel"), vfs.FilePerm)).To(Succeed())
+				Expect(fs.WriteFile(filepath.Join(modules, "initrd"), []byte("initrd"), vfs.FilePerm)).To(Succeed())
+			}
+			return []byte{}, nil
+		}
+		sideEffects["mksquashfs"] = func(args ...string) ([]byte, error) {
+			return []byte{}, fs.WriteFile(args[1], []byte("squashfs"), vfs.FilePerm)
+		}
+
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		d.CfgScript = "/some/dir/config.sh"
+		d.Installer.KernelCmdline = "elemental.install.target=/dev/sda"
+		Expect(fs.WriteFile("/some/dir/config.sh", []byte("install config script"), vfs.FilePerm)).To(Succeed())
+
+		media := installer.NewMedia(
+			context.Background(), s, installer.Netboot, installer.WithNetbootURL("http://10.0.0.1:8080/elemental/"),
+		)
+		media.OutputDir = "/some/dir/build"
+		Expect(media.Build(d)).To(Succeed())
+
+		out := "/some/dir/build/installer.netboot"
+		Expect(media.OutputFile()).To(Equal(out))
+		for _, f := range []string{"boot/vmlinuz", "boot/initrd", "LiveOS/squashfs.img", "Install/install.yaml", "Install/setup.sh", "SHA256SUMS"} {
+			Expect(vfs.Exists(fs, filepath.Join(out, f))).To(BeTrue(), f)
+		}
+
+		ipxe, err := fs.ReadFile(filepath.Join(out, "boot.ipxe"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(ipxe)).To(ContainSubstring("kernel http://10.0.0.1:8080/elemental/boot/vmlinuz initrd=initrd " +
+			"root=live:http://10.0.0.1:8080/elemental/LiveOS/squashfs.img"))
+		Expect(string(ipxe)).To(ContainSubstring("elemental.install.description=http://10.0.0.1:8080/elemental/Install/install.yaml"))
+		Expect(string(ipxe)).To(ContainSubstring("elemental.install.config=http://10.0.0.1:8080/elemental/Install/setup.sh"))
+		Expect(string(ipxe)).To(ContainSubstring("elemental.install.target=/dev/sda"))
+
+		grub, err := fs.ReadFile(filepath.Join(out, "grub.cfg"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(grub)).To(ContainSubstring("linux (http,10.0.0.1,8080)/elemental/boot/vmlinuz"))
+		Expect(string(grub)).To(ContainSubstring("initrd (http,10.0.0.1,8080)/elemental/boot/initrd"))
+
+		installDesc, err := fs.ReadFile(filepath.Join(out, "Install/install.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(installDesc)).To(ContainSubstring("dir:///run/rootfsbase"))
+	})
+	It("downloads a remote overlay tarball into netboot media", func() {
+		server := httptest.NewServer(gohttp.HandlerFunc(func(w gohttp.ResponseWriter, _ *gohttp.Request) {
+			_, _ = w.Write([]byte("overlay"))
+		}))
+		defer server.Close()
+		sideEffects["rsync"] = func(args ...string) ([]byte, error) {
+			target := args[len(args)-1]
+			modules := filepath.Join(target[strings.Index(target, "/some/"):], "usr/lib/modules/6.14.4-1-default")
+			if strings.Contains(modules, "osroot") {
+				Expect(vfs.MkdirAll(fs, modules, vfs.DirPerm)).To(Succeed())
+				Expect(fs.WriteFile(filepath.Join(modules, "vmlinuz"), []byte("kernel"), vfs.FilePerm)).To(Succeed())
+				Expect(fs.WriteFile(filepath.Join(modules, "initrd"), []byte("initrd"), vfs.FilePerm)).To(Succeed())
+			}
+			return []byte{}, nil
+		}
+		sideEffects["mksquashfs"] = func(args ...string) ([]byte, error) {
+			return []byte{}, fs.WriteFile(args[1], []byte("squashfs"), vfs.FilePerm)
+		}
+
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		src, err := deployment.NewSrcFromURI("tar://" + server.URL + "/files/overlay.tar.gz")
+		Expect(err).NotTo(HaveOccurred())
+		d.OverlayTree = src
+
+		media := installer.NewMedia(
+			context.Background(), s, installer.Netboot, installer.WithNetbootURL("http://10.0.0.1:8080/elemental/"),
+		)
+		media.OutputDir = "/some/dir/build"
+		Expect(media.Build(d)).To(Succeed())
+
+		data, err := fs.ReadFile("/some/dir/build/installer.netboot/Install/Overlay/overlay.tar.gz")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("overlay"))
+		ipxe, err := fs.ReadFile("/some/dir/build/installer.netboot/boot.ipxe")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(ipxe)).To(ContainSubstring("elemental.install.overlay=http://10.0.0.1:8080/elemental/Install/Overlay/overlay.tar.gz"))
+	})
+	It("creates an installation ISO with an erofs root image", func() {
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		iso := installer.NewMedia(
+			context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)),
+			installer.WithISOBackend(installer.NativeISOBackend), installer.WithRootfsFormat(installer.EROFSRootfs),
+		)
+		iso.OutputDir = "/some/dir/build"
+
+		Expect(iso.Build(d)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"mkfs.erofs", "-zlz4hc", "/some/dir/build/elemental-installer/liveroot/LiveOS/squashfs.img", "/some/dir/build/elemental-installer/osroot"},
+		})).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{"mksquashfs"}})).NotTo(Succeed())
+	})
+	It("fails to parse an unknown rootfs format", func() {
+		_, err := installer.ParseRootfsFormat("btrfs")
+		Expect(err).To(MatchError(ContainSubstring("unsupported rootfs format btrfs")))
+		Expect(installer.ParseRootfsFormat("erofs")).To(Equal(installer.EROFSRootfs))
+	})
+	It("fails to create netboot media without an HTTP URL", func() {
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		media := installer.NewMedia(context.Background(), s, installer.Netboot, installer.WithNetbootURL("tftp://10.0.0.1"))
+		media.OutputDir = "/some/dir/build"
+		Expect(media.Build(d)).To(MatchError(ContainSubstring("netboot media requires an HTTP(S) URL, got 'tftp://10.0.0.1'")))
+	})
 	It("fails to create an ISO without an output directory defined", func() {
 		d.SourceOS = deployment.NewDirSrc("/some/root")
-		iso := installer.NewMedia(context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)))
+		iso := installer.NewMedia(
+			context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)),
+			installer.WithISOBackend(installer.XorrisoISOBackend),
+		)
 
 		err := iso.Build(d)
 		Expect(err).To(HaveOccurred())
@@ -157,7 +318,10 @@ var _ = Describe("InstallerMedia", Label("installermedia"), func() {
 		}
 
 		d.SourceOS = deployment.NewDirSrc("/some/root")
-		iso := installer.NewMedia(context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)))
+		iso := installer.NewMedia(
+			context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)),
+			installer.WithISOBackend(installer.XorrisoISOBackend),
+		)
 		iso.OutputDir = "/some/dir/build"
 
 		err := iso.Build(d)
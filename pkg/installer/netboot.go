@@ -0,0 +1,185 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	netbootDir    = "boot"
+	netbootKernel = "vmlinuz"
+	netbootIPXE   = "boot.ipxe"
+	netbootGrub   = "grub.cfg"
+	netbootSums   = "SHA256SUMS"
+
+	// NetbootRootfs is the path where dracut mounts the squashfs image fetched over the network
+	NetbootRootfs = "/run/rootfsbase"
+)
+
+//go:embed templates/netboot.ipxe
+var netbootIPXETpl string
+
+//go:embed templates/netboot_grub.cfg
+var netbootGrubTpl string
+
+type netbootConfig struct {
+	Name     string
+	URL      string
+	GrubRoot string
+	Kernel   string
+	Initrd   string
+	Cmdline  string
+}
+
+// buildNetboot creates the output directory tree to serve the installer from a PXE and HTTP server.
+// It includes the kernel, the initrd, the live tree including the squashfs image and the iPXE and
+// GRUB configurations to boot it from the netboot URL.
+func (i Media) buildNetboot(liveRoot, osRoot string, d *deployment.Deployment) error {
+	bootDir := filepath.Join(liveRoot, netbootDir)
+	err := vfs.MkdirAll(i.s.FS(), bootDir, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed creating netboot boot directory: %w", err)
+	}
+
+	kernel, _, err := vfs.FindKernel(i.s.FS(), osRoot)
+	if err != nil {
+		return fmt.Errorf("failed finding kernel: %w", err)
+	}
+	err = vfs.CopyFileContext(i.ctx, i.s.FS(), kernel, filepath.Join(bootDir, netbootKernel))
+	if err != nil {
+		return fmt.Errorf("failed copying kernel: %w", err)
+	}
+	err = vfs.CopyFileContext(i.ctx, i.s.FS(), filepath.Join(filepath.Dir(kernel), bootloader.Initrd), filepath.Join(bootDir, bootloader.Initrd))
+	if err != nil {
+		return fmt.Errorf("failed copying initrd: %w", err)
+	}
+
+	cmdline, err := i.netbootCmdline(d)
+	if err != nil {
+		return err
+	}
+
+	cfg := netbootConfig{
+		Name:    "Elemental Installer",
+		URL:     strings.TrimSuffix(i.netbootURL, "/"),
+		Kernel:  filepath.Join(netbootDir, netbootKernel),
+		Initrd:  filepath.Join(netbootDir, bootloader.Initrd),
+		Cmdline: cmdline,
+	}
+	err = i.writeNetbootConfig(filepath.Join(liveRoot, netbootIPXE), netbootIPXETpl, cfg)
+	if err != nil {
+		return err
+	}
+
+	u, _ := url.Parse(cfg.URL)
+	if u.Scheme == "http" {
+		cfg.GrubRoot = fmt.Sprintf("(http,%s)%s", strings.Replace(u.Host, ":", ",", 1), u.Path)
+		err = i.writeNetbootConfig(filepath.Join(liveRoot, netbootGrub), netbootGrubTpl, cfg)
+		if err != nil {
+			return err
+		}
+	} else {
+		i.s.Logger().Warn("GRUB can't boot from '%s' URLs, skipping GRUB netboot configuration", u.Scheme)
+	}
+
+	err = i.s.FS().Rename(liveRoot, i.outputFile)
+	if err != nil {
+		return fmt.Errorf("failed moving netboot tree to '%s': %w", i.outputFile, err)
+	}
+	return i.writeNetbootChecksums()
+}
+
+// netbootCmdline returns the kernel command line fetching the live root and the installation
+// assets from the netboot URL
+func (i Media) netbootCmdline(d *deployment.Deployment) (string, error) {
+	baseURL := strings.TrimSuffix(i.netbootURL, "/")
+	args := []string{
+		fmt.Sprintf("root=live:%s/%s", baseURL, SquashfsRelPath),
+		"rd.live.overlay.overlayfs=1", "rd.neednet=1", "ip=dhcp",
+		fmt.Sprintf("elemental.install.description=%s/%s/%s", baseURL, installDir, installCfg),
+	}
+
+	if d.CfgScript != "" {
+		args = append(args, fmt.Sprintf("elemental.install.config=%s/%s/%s", baseURL, installDir, cfgScript))
+	}
+
+	if d.OverlayTree != nil && !d.OverlayTree.IsEmpty() {
+		if !d.OverlayTree.IsTar() {
+			return "", fmt.Errorf("netboot media only supports tarball installation overlays")
+		}
+		args = append(args, fmt.Sprintf(
+			"elemental.install.overlay=%s/%s/%s/%s", baseURL, installDir, overlayDir, overlayFileName(d.OverlayTree),
+		))
+	}
+
+	if d.Installer.KernelCmdline != "" {
+		args = append(args, d.Installer.KernelCmdline)
+	}
+	return strings.Join(args, " "), nil
+}
+
+func (i Media) writeNetbootConfig(path, tpl string, cfg netbootConfig) error {
+	t, err := template.New(filepath.Base(path)).Parse(tpl)
+	if err != nil {
+		return fmt.Errorf("failed parsing netboot template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = t.Execute(&buf, cfg)
+	if err != nil {
+		return fmt.Errorf("failed rendering netboot template: %w", err)
+	}
+
+	err = i.s.FS().WriteFile(path, buf.Bytes(), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("failed writing netboot configuration '%s': %w", path, err)
+	}
+	return nil
+}
+
+// writeNetbootChecksums writes the checksums file of the netboot boot artifacts
+func (i Media) writeNetbootChecksums() error {
+	var sums strings.Builder
+	for _, f := range []string{
+		filepath.Join(netbootDir, netbootKernel), filepath.Join(netbootDir, bootloader.Initrd), SquashfsRelPath,
+	} {
+		checksum, err := calcFileChecksum(i.s.FS(), filepath.Join(i.outputFile, f))
+		if err != nil {
+			return fmt.Errorf("could not compute checksum of '%s': %w", f, err)
+		}
+		fmt.Fprintf(&sums, "%s %s\n", checksum, f)
+	}
+
+	sumsFile := filepath.Join(i.outputFile, netbootSums)
+	err := i.s.FS().WriteFile(sumsFile, []byte(sums.String()), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("failed writing checksums file %s: %w", sumsFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integrity
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// ManifestFile is the path, relative to the root tree, of the SHA256 manifest of the snapshot contents.
+// It follows the sha256sum format, so it can also be checked with 'sha256sum -c'.
+const ManifestFile = "/usr/lib/elemental/sha256sums"
+
+// Status is the result of verifying a file against the manifest
+type Status string
+
+const (
+	Modified Status = "modified"
+	Missing  Status = "missing"
+	Added    Status = "added"
+)
+
+// Mismatch is a file which does not match the manifest
+type Mismatch struct {
+	Path   string `yaml:"path"`
+	Status Status `yaml:"status"`
+}
+
+// runtimePaths are never part of the snapshot contents
+var runtimePaths = []string{"/dev", "/proc", "/sys", "/run", "/tmp", "/" + snapper.SnapshotsPath}
+
+// ExcludedPaths returns the paths of the given deployment which are not part of the read-only snapshot
+// contents, that is the mount points of other partitions and all the RW volumes
+func ExcludedPaths(d *deployment.Deployment) []string {
+	excludes := slices.Clone(runtimePaths)
+	for _, disk := range d.Disks {
+		for _, part := range disk.Partitions {
+			if part.MountPoint != "" && part.MountPoint != deployment.SystemMnt {
+				excludes = append(excludes, part.MountPoint)
+			}
+			for _, rwVol := range part.RWVolumes {
+				excludes = append(excludes, rwVol.Path)
+			}
+		}
+	}
+	return excludes
+}
+
+// Generate writes the SHA256 manifest of the regular files of the given root tree, skipping the
+// given excluded paths. Excluded paths are relative to the root tree.
+func Generate(ctx context.Context, s *sys.System, root string, excludes ...string) error {
+	sums, err := checksums(ctx, s.FS(), root, excludes)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, path := range slices.Sorted(maps.Keys(sums)) {
+		fmt.Fprintf(&buf, "%s  %s\n", sums[path], path)
+	}
+
+	manifest := filepath.Join(root, ManifestFile)
+	if err = vfs.MkdirAll(s.FS(), filepath.Dir(manifest), vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating manifest directory: %w", err)
+	}
+	if err = s.FS().WriteFile(manifest, buf.Bytes(), vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing checksums manifest: %w", err)
+	}
+
+	s.Logger().Info("Stored checksums of %d files at '%s'", len(sums), ManifestFile)
+	return nil
+}
+
+// Verify checks the regular files of the given root tree against its SHA256 manifest, skipping
+// the given excluded paths. It returns the files not matching the manifest sorted by path.
+func Verify(ctx context.Context, s *sys.System, root string, excludes ...string) ([]Mismatch, error) {
+	expected, err := readManifest(s.FS(), filepath.Join(root, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := checksums(ctx, s.FS(), root, excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := []Mismatch{}
+	for path, sum := range expected {
+		if excluded(path, excludes) {
+			continue
+		}
+		curSum, ok := current[path]
+		switch {
+		case !ok:
+			mismatches = append(mismatches, Mismatch{Path: path, Status: Missing})
+		case curSum != sum:
+			mismatches = append(mismatches, Mismatch{Path: path, Status: Modified})
+		}
+	}
+	for path := range current {
+		if _, ok := expected[path]; !ok {
+			mismatches = append(mismatches, Mismatch{Path: path, Status: Added})
+		}
+	}
+
+	slices.SortFunc(mismatches, func(a, b Mismatch) int { return strings.Compare(a.Path, b.Path) })
+	return mismatches, nil
+}
+
+// checksums returns the SHA256 checksums of the regular files of the given root tree indexed by
+// their absolute path within the tree. The manifest itself is never included.
+func checksums(ctx context.Context, fsys vfs.FS, root string, excludes []string) (map[string]string, error) {
+	sums := map[string]string{}
+	err := vfs.WalkDirContext(ctx, fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.Join("/", rel)
+		if excluded(rel, excludes) || rel == ManifestFile {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		sum, err := fileChecksum(fsys, path)
+		if err != nil {
+			return fmt.Errorf("computing checksum of '%s': %w", rel, err)
+		}
+		sums[rel] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("computing checksums of '%s': %w", root, err)
+	}
+	return sums, nil
+}
+
+func readManifest(fsys vfs.FS, manifest string) (map[string]string, error) {
+	f, err := fsys.Open(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("opening checksums manifest: %w", err)
+	}
+	defer f.Close()
+
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		sum, path, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("malformed checksums manifest line: '%s'", scanner.Text())
+		}
+		sums[path] = sum
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading checksums manifest: %w", err)
+	}
+	return sums, nil
+}
+
+func fileChecksum(fsys vfs.FS, path string) (string, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// excluded returns true if the given path is any of the given excludes or is nested in them
+func excluded(path string, excludes []string) bool {
+	return slices.ContainsFunc(excludes, func(exclude string) bool {
+		exclude = filepath.Clean(filepath.Join("/", exclude))
+		return path == exclude || strings.HasPrefix(path, exclude+"/")
+	})
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integrity_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/integrity"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestIntegritySuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Integrity test suite")
+}
+
+var _ = Describe("Integrity", Label("integrity"), func() {
+	const root = "/snapshot"
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var excludes []string
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/snapshot/usr/bin/tool":          "tool",
+			"/snapshot/usr/lib/os-release":    "NAME=SL Micro",
+			"/snapshot/etc/hostname":          "host",
+			"/snapshot/var/log/messages":      "log",
+			"/snapshot/.snapshots/1/info.xml": "info",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tfs.Symlink("tool", filepath.Join(root, "usr/bin/link"))).To(Succeed())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+		excludes = integrity.ExcludedPaths(deployment.DefaultDeployment())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("lists the paths out of the read-only snapshot contents", func() {
+		Expect(excludes).To(ContainElements("/.snapshots", "/proc", "/boot", "/var", "/etc", "/usr/local"))
+		Expect(excludes).NotTo(ContainElement("/"))
+	})
+	It("generates a sha256sum manifest of the regular files", func() {
+		Expect(integrity.Generate(context.Background(), s, root, excludes...)).To(Succeed())
+
+		data, err := tfs.ReadFile(filepath.Join(root, integrity.ManifestFile))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(
+			"7c9bbe5ec9b3fb774e8fa0f54247e93c34ddf8e5d16fe3073420de0ae81a262d  /usr/bin/tool\n" +
+				"c16d55dc360ff298e37fa13a9b10d05044fb00ed33fa9575e74e4d84e463c3d0  /usr/lib/os-release\n",
+		))
+	})
+	It("verifies an unmodified tree", func() {
+		Expect(integrity.Generate(context.Background(), s, root, excludes...)).To(Succeed())
+		Expect(tfs.WriteFile(filepath.Join(root, "etc/hostname"), []byte("other"), vfs.FilePerm)).To(Succeed())
+
+		mismatches, err := integrity.Verify(context.Background(), s, root, excludes...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mismatches).To(BeEmpty())
+	})
+	It("reports modified, missing and added files", func() {
+		Expect(integrity.Generate(context.Background(), s, root, excludes...)).To(Succeed())
+		Expect(tfs.WriteFile(filepath.Join(root, "usr/bin/tool"), []byte("tampered"), vfs.FilePerm)).To(Succeed())
+		Expect(tfs.Remove(filepath.Join(root, "usr/lib/os-release"))).To(Succeed())
+		Expect(tfs.WriteFile(filepath.Join(root, "usr/bin/backdoor"), []byte("evil"), vfs.FilePerm)).To(Succeed())
+
+		mismatches, err := integrity.Verify(context.Background(), s, root, excludes...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mismatches).To(Equal([]integrity.Mismatch{
+			{Path: "/usr/bin/backdoor", Status: integrity.Added},
+			{Path: "/usr/bin/tool", Status: integrity.Modified},
+			{Path: "/usr/lib/os-release", Status: integrity.Missing},
+		}))
+	})
+	It("fails to verify without a manifest", func() {
+		_, err := integrity.Verify(context.Background(), s, root, excludes...)
+		Expect(err).To(MatchError(ContainSubstring("opening checksums manifest")))
+	})
+	It("fails to verify a malformed manifest", func() {
+		manifest := filepath.Join(root, integrity.ManifestFile)
+		Expect(vfs.MkdirAll(tfs, filepath.Dir(manifest), vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile(manifest, []byte("abc /usr/bin/tool\n"), vfs.FilePerm)).To(Succeed())
+
+		_, err := integrity.Verify(context.Background(), s, root, excludes...)
+		Expect(err).To(MatchError(ContainSubstring("malformed checksums manifest line")))
+	})
+})
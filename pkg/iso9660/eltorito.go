@@ -0,0 +1,164 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iso9660
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// bootInfoTableOffset is the location of the boot info table within a BIOS boot image
+	bootInfoTableOffset = 8
+	// grubBootInfoOffset is the location within GRUB El Torito images of the 512 bytes block
+	// address of the GRUB core image
+	grubBootInfoOffset = 2548
+	// mbrBootCodeSize is the size of the hybrid MBR boot code kept from the GRUB image
+	mbrBootCodeSize = 432
+	// biosLoadSectors is the number of virtual sectors of the BIOS image loaded by the firmware
+	biosLoadSectors = 4
+)
+
+// bootable reports whether the image includes El Torito records
+func (w Writer) bootable() bool {
+	return w.efiImage != "" || w.biosImage != ""
+}
+
+// bootRecord returns the El Torito boot record volume descriptor
+func bootRecord(catalog uint32) []byte {
+	br := make([]byte, sectorSize)
+	copy(br[1:], "CD001")
+	br[6] = 1
+	copy(br[7:], elToritoID)
+	binary.LittleEndian.PutUint32(br[71:], catalog)
+	return br
+}
+
+// bootCatalog returns the El Torito boot catalog. The BIOS image, if any, is the default entry
+// and the EFI image is added in its own section, otherwise the EFI image is the default entry.
+func (w Writer) bootCatalog(l layout) []byte {
+	cat := make([]byte, sectorSize)
+	cat[0] = 1
+	cat[1] = efiPlatformID
+	if l.biosImage != nil {
+		cat[1] = biosPlatformID
+	}
+	cat[30], cat[31] = 0x55, 0xaa
+	var sum uint16
+	for i := 0; i < 32; i += 2 {
+		sum += binary.LittleEndian.Uint16(cat[i:])
+	}
+	binary.LittleEndian.PutUint16(cat[28:], -sum)
+
+	if l.biosImage == nil {
+		bootEntry(cat[32:64], l.efiImage, l.efiImageSize)
+		return cat
+	}
+	entry := cat[32:64]
+	entry[0] = 0x88
+	binary.LittleEndian.PutUint16(entry[6:], biosLoadSectors)
+	binary.LittleEndian.PutUint32(entry[8:], l.biosImage.extent)
+	if w.efiImage != "" {
+		header := cat[64:96]
+		header[0] = 0x91
+		header[1] = efiPlatformID
+		binary.LittleEndian.PutUint16(header[2:], 1)
+		bootEntry(cat[96:128], l.efiImage, l.efiImageSize)
+	}
+	return cat
+}
+
+// bootEntry fills a bootable no emulation entry loading the whole image
+func bootEntry(entry []byte, image uint32, size int64) {
+	entry[0] = 0x88
+	// Sector count in virtual 512 bytes sectors, capped for images larger than the field allows
+	binary.LittleEndian.PutUint16(entry[6:], uint16(min((size+511)/512, 0xffff)))
+	binary.LittleEndian.PutUint32(entry[8:], image)
+}
+
+// writeBIOSImage writes the BIOS boot image patched with the boot info table and the location
+// of the GRUB core image
+func (w Writer) writeBIOSImage(iw *imageWriter, n *node) error {
+	data, err := w.s.FS().ReadFile(n.path)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != n.size {
+		return fmt.Errorf("file size changed from %d to %d bytes while writing the image", n.size, len(data))
+	}
+
+	table := data[bootInfoTableOffset : bootInfoTableOffset+56]
+	clear(table)
+	binary.LittleEndian.PutUint32(table[0:], systemAreaSectors)
+	binary.LittleEndian.PutUint32(table[4:], n.extent)
+	binary.LittleEndian.PutUint32(table[8:], uint32(n.size))
+	var sum uint32
+	for i := 64; i+4 <= len(data); i += 4 {
+		sum += binary.LittleEndian.Uint32(data[i:])
+	}
+	// A trailing partial word is summed as if it was padded with zeros
+	if rest := len(data) % 4; rest != 0 && len(data) > 64 {
+		var word [4]byte
+		copy(word[:], data[len(data)-rest:])
+		sum += binary.LittleEndian.Uint32(word[:])
+	}
+	binary.LittleEndian.PutUint32(table[12:], sum)
+
+	// GRUB El Torito images embed the core image right after the first 2048 bytes
+	binary.LittleEndian.PutUint64(data[grubBootInfoOffset:], uint64(n.extent)*4+5)
+
+	return iw.write(n.extent, data)
+}
+
+// mbr returns the hybrid MBR of the system area. It includes the GRUB boot code pointing to the
+// GRUB core image and a partition table with a bootable partition covering the whole image and,
+// if any, an EFI System Partition covering the EFI boot image.
+func (w Writer) mbr(l layout) ([]byte, error) {
+	f, err := w.s.FS().Open(w.hybridMBR)
+	if err != nil {
+		return nil, fmt.Errorf("opening hybrid MBR: %w", err)
+	}
+	defer f.Close()
+
+	mbr := make([]byte, 512)
+	_, err = io.ReadFull(f, mbr[:mbrBootCodeSize])
+	if err != nil {
+		return nil, fmt.Errorf("reading hybrid MBR: %w", err)
+	}
+	// Location of the GRUB core image in 512 bytes blocks, right after the first 2048 bytes of the
+	// El Torito image
+	binary.LittleEndian.PutUint64(mbr[mbrBootCodeSize:], uint64(l.biosImage.extent)*4+4)
+
+	mbrPartition(mbr[446:462], 0x80, 0xcd, 0, uint64(l.total)*4)
+	if w.efiImage != "" {
+		mbrPartition(mbr[462:478], 0, 0xef, uint64(l.efiImage)*4, uint64(l.efiImageSize+511)/512)
+	}
+	mbr[510], mbr[511] = 0x55, 0xaa
+	return mbr, nil
+}
+
+// mbrPartition fills an MBR partition entry in LBA mode, CHS addresses are set to their maximum
+func mbrPartition(entry []byte, status, partType byte, start, size uint64) {
+	entry[0] = status
+	copy(entry[1:4], []byte{0xfe, 0xff, 0xff})
+	entry[4] = partType
+	copy(entry[5:8], []byte{0xfe, 0xff, 0xff})
+	binary.LittleEndian.PutUint32(entry[8:], uint32(min(start, 0xffffffff)))
+	binary.LittleEndian.PutUint32(entry[12:], uint32(min(size, 0xffffffff)))
+}
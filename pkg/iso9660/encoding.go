@@ -0,0 +1,293 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iso9660
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+const (
+	// maxRecordLen is the maximum even length of a directory record
+	maxRecordLen = 254
+	// ceLen is the length of a SUSP continuation area entry
+	ceLen = 28
+	// maxSUEntryPayload is the maximum payload of a single SUSP entry
+	maxSUEntryPayload = 250
+
+	flagHidden     = 0x01
+	flagDir        = 0x02
+	flagMultiExtnt = 0x80
+
+	rripID     = "RRIP_1991A"
+	rripDesc   = "THE ROCK RIDGE INTERCHANGE PROTOCOL PROVIDES SUPPORT FOR POSIX FILE SYSTEM SEMANTICS"
+	rripSource = "PLEASE CONTACT DISC PUBLISHER FOR SPECIFICATION SOURCE.  SEE PUBLISHER IDENTIFIER IN " +
+		"PRIMARY VOLUME DESCRIPTOR FOR CONTACT INFORMATION."
+)
+
+func putBoth16(b []byte, v uint16) {
+	binary.LittleEndian.PutUint16(b, v)
+	binary.BigEndian.PutUint16(b[2:], v)
+}
+
+func putBoth32(b []byte, v uint32) {
+	binary.LittleEndian.PutUint32(b, v)
+	binary.BigEndian.PutUint32(b[4:], v)
+}
+
+// putRecordTime encodes the given time in the 7 bytes format of directory records
+func putRecordTime(b []byte, t time.Time) {
+	t = t.UTC()
+	year := min(max(t.Year(), 1900), 2155)
+	b[0] = byte(year - 1900)
+	b[1] = byte(t.Month())
+	b[2] = byte(t.Day())
+	b[3] = byte(t.Hour())
+	b[4] = byte(t.Minute())
+	b[5] = byte(t.Second())
+	b[6] = 0
+}
+
+// putVolumeTime encodes the given time in the 17 bytes format of volume descriptors, a zero time
+// is encoded as not specified
+func putVolumeTime(b []byte, t time.Time) {
+	if t.IsZero() {
+		copy(b, strings.Repeat("0", 16))
+	} else {
+		t = t.UTC()
+		copy(b, fmt.Sprintf("%04d%02d%02d%02d%02d%02d%02d",
+			t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond()/10000000))
+	}
+	b[16] = 0
+}
+
+// putPadded copies the given string into b padding the remaining space with spaces
+func putPadded(b []byte, s string) {
+	for i := range b {
+		b[i] = ' '
+	}
+	copy(b, s)
+}
+
+// putPaddedUCS2 copies the given string as UCS-2 into b padding the remaining space with UCS-2 spaces
+func putPaddedUCS2(b []byte, s string) {
+	for i := 0; i+1 < len(b); i += 2 {
+		b[i], b[i+1] = 0, ' '
+	}
+	encoded := ucs2(s)
+	copy(b, encoded[:min(len(encoded), len(b)&^1)])
+}
+
+// dirRecord returns a directory record with the given system use entries, the record is padded
+// to an even length
+func dirRecord(id []byte, extent, size uint32, flags byte, modTime time.Time, su []byte) []byte {
+	length := 33 + len(id)
+	if len(id)%2 == 0 {
+		length++
+	}
+	rec := make([]byte, length, length+len(su)+1)
+	rec = append(rec, su...)
+	if len(rec)%2 != 0 {
+		rec = append(rec, 0)
+	}
+
+	rec[0] = byte(len(rec))
+	putBoth32(rec[2:], extent)
+	putBoth32(rec[10:], size)
+	putRecordTime(rec[18:], modTime)
+	rec[25] = flags
+	putBoth16(rec[28:], 1)
+	rec[32] = byte(len(id))
+	copy(rec[33:], id)
+	return rec
+}
+
+// recordLen returns the length of a directory record for the given identifier without system use entries
+func recordLen(id []byte) int {
+	if len(id)%2 == 0 {
+		return 34 + len(id)
+	}
+	return 33 + len(id)
+}
+
+// contArea collects the SUSP entries not fitting in directory records
+type contArea struct {
+	start uint32
+	data  []byte
+}
+
+// add stores the given entries in the continuation area and returns the CE entry pointing to them.
+// Entries never span sector boundaries.
+func (c *contArea) add(entries []byte) ([]byte, error) {
+	if len(entries) > sectorSize {
+		return nil, fmt.Errorf("system use entries of %d bytes exceed the sector size", len(entries))
+	}
+	if used := len(c.data) % sectorSize; used+len(entries) > sectorSize {
+		c.data = append(c.data, make([]byte, sectorSize-used)...)
+	}
+
+	offset := len(c.data)
+	c.data = append(c.data, entries...)
+
+	ce := suEntry("CE", make([]byte, ceLen-4))
+	putBoth32(ce[4:], c.start+uint32(offset/sectorSize))
+	putBoth32(ce[12:], uint32(offset%sectorSize))
+	putBoth32(ce[20:], uint32(len(entries)))
+	return ce, nil
+}
+
+// fit returns the system use area of a directory record with the given space available. Entries not
+// fitting in the record are moved to the continuation area.
+func (c *contArea) fit(entries [][]byte, available int) ([]byte, error) {
+	var total int
+	for _, e := range entries {
+		total += len(e)
+	}
+
+	su := make([]byte, 0, total)
+	if total <= available {
+		for _, e := range entries {
+			su = append(su, e...)
+		}
+		return su, nil
+	}
+
+	i := 0
+	for ; i < len(entries) && len(su)+len(entries[i])+ceLen <= available; i++ {
+		su = append(su, entries[i]...)
+	}
+	var rest []byte
+	for _, e := range entries[i:] {
+		rest = append(rest, e...)
+	}
+	ce, err := c.add(rest)
+	if err != nil {
+		return nil, err
+	}
+	return append(su, ce...), nil
+}
+
+func suEntry(signature string, payload []byte) []byte {
+	e := make([]byte, 4, 4+len(payload))
+	copy(e, signature)
+	e[2] = byte(4 + len(payload))
+	e[3] = 1
+	return append(e, payload...)
+}
+
+// spEntry returns the SUSP entry marking the use of the system use sharing protocol
+func spEntry() []byte {
+	return suEntry("SP", []byte{0xbe, 0xef, 0})
+}
+
+// erEntry returns the SUSP entry identifying the Rock Ridge extensions
+func erEntry() []byte {
+	payload := []byte{byte(len(rripID)), byte(len(rripDesc)), byte(len(rripSource)), 1}
+	payload = append(payload, rripID+rripDesc+rripSource...)
+	return suEntry("ER", payload)
+}
+
+// pxEntry returns the Rock Ridge POSIX attributes entry of the given node
+func pxEntry(n *node, perm fs.FileMode) []byte {
+	mode := uint32(perm.Perm())
+	if perm == 0 {
+		mode = uint32(n.mode.Perm())
+	}
+	if n.mode&fs.ModeSetuid != 0 {
+		mode |= 0o4000
+	}
+	if n.mode&fs.ModeSetgid != 0 {
+		mode |= 0o2000
+	}
+	if n.mode&fs.ModeSticky != 0 {
+		mode |= 0o1000
+	}
+
+	nlink := uint32(1)
+	switch {
+	case n.isDir():
+		mode |= 0o040000
+		nlink = uint32(2 + n.subdirs())
+	case n.isLink():
+		mode |= 0o120000
+		mode |= 0o777
+	default:
+		mode |= 0o100000
+	}
+
+	payload := make([]byte, 32)
+	putBoth32(payload, mode)
+	putBoth32(payload[8:], nlink)
+	return suEntry("PX", payload)
+}
+
+// tfEntry returns the Rock Ridge entry including the modification time
+func tfEntry(t time.Time) []byte {
+	payload := make([]byte, 8)
+	payload[0] = 0x02
+	putRecordTime(payload[1:], t)
+	return suEntry("TF", payload)
+}
+
+// nmEntries returns the Rock Ridge entries including the given alternate name
+func nmEntries(name string) [][]byte {
+	var entries [][]byte
+	for len(name) > maxSUEntryPayload-1 {
+		entries = append(entries, suEntry("NM", append([]byte{0x01}, name[:maxSUEntryPayload-1]...)))
+		name = name[maxSUEntryPayload-1:]
+	}
+	return append(entries, suEntry("NM", append([]byte{0}, name...)))
+}
+
+// slEntries returns the Rock Ridge entries describing a symbolic link to the given target
+func slEntries(target string) [][]byte {
+	var components [][]byte
+	if strings.HasPrefix(target, "/") {
+		components = append(components, []byte{0x08, 0})
+	}
+	for _, part := range strings.Split(target, "/") {
+		switch part {
+		case "":
+		case ".":
+			components = append(components, []byte{0x02, 0})
+		case "..":
+			components = append(components, []byte{0x04, 0})
+		default:
+			for len(part) > maxSUEntryPayload-3 {
+				components = append(components, append([]byte{0x01, maxSUEntryPayload - 3}, part[:maxSUEntryPayload-3]...))
+				part = part[maxSUEntryPayload-3:]
+			}
+			components = append(components, append([]byte{0, byte(len(part))}, part...))
+		}
+	}
+
+	var entries [][]byte
+	payload := []byte{0}
+	for _, c := range components {
+		if len(payload)+len(c) > maxSUEntryPayload {
+			payload[0] = 0x01
+			entries = append(entries, suEntry("SL", payload))
+			payload = []byte{0}
+		}
+		payload = append(payload, c...)
+	}
+	return append(entries, suEntry("SL", payload))
+}
@@ -0,0 +1,606 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iso9660
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+const (
+	sectorSize        = 2048
+	systemAreaSectors = 16
+	// maxExtentSize is the largest sector aligned size of a single extent, larger files are
+	// recorded in multiple extents
+	maxExtentSize = 0xFFFFF800
+
+	defaultVolumeID = "ISOIMAGE"
+	applicationID   = "ELEMENTAL"
+	elToritoID      = "EL TORITO SPECIFICATION"
+	efiPlatformID   = 0xef
+	biosPlatformID  = 0x00
+)
+
+type Option func(*Writer)
+
+// Writer writes ISO 9660 images including Rock Ridge and Joliet extensions and, optionally,
+// El Torito EFI and BIOS boot images.
+type Writer struct {
+	s         *sys.System
+	volumeID  string
+	efiImage  string
+	biosImage string
+	hybridMBR string
+	perm      fs.FileMode
+	now       func() time.Time
+}
+
+// WithVolumeID sets the volume identifier of the image
+func WithVolumeID(volumeID string) Option {
+	return func(w *Writer) {
+		w.volumeID = volumeID
+	}
+}
+
+// WithEFIBootImage sets the EFI System Partition image to boot from. The image is stored
+// as a hidden file not visible in the directory tree.
+func WithEFIBootImage(image string) Option {
+	return func(w *Writer) {
+		w.efiImage = image
+	}
+}
+
+// WithBIOSBootImage sets the no emulation BIOS boot image, given as a path relative to the root
+// directory of the image. The image is patched with a boot info table and, as done by xorriso
+// for GRUB El Torito images, with the location of the GRUB core image.
+func WithBIOSBootImage(image string) Option {
+	return func(w *Writer) {
+		w.biosImage = image
+	}
+}
+
+// WithHybridMBR sets the GRUB hybrid MBR boot code written to the system area, so the image
+// also boots from BIOS when it is dumped to a disk. It requires a BIOS boot image.
+func WithHybridMBR(mbr string) Option {
+	return func(w *Writer) {
+		w.hybridMBR = mbr
+	}
+}
+
+// WithPermissions sets the permissions of all files and directories in the image
+// instead of keeping the permissions of the source tree
+func WithPermissions(perm fs.FileMode) Option {
+	return func(w *Writer) {
+		w.perm = perm
+	}
+}
+
+func NewWriter(s *sys.System, opts ...Option) *Writer {
+	w := &Writer{
+		s:        s,
+		volumeID: defaultVolumeID,
+		now:      time.Now,
+	}
+	for _, o := range opts {
+		o(w)
+	}
+	return w
+}
+
+// layout holds the location in sectors of all the image areas
+type layout struct {
+	bootRecord   uint32
+	joliet       uint32
+	terminator   uint32
+	catalog      uint32
+	lPath        uint32
+	mPath        uint32
+	jLPath       uint32
+	jMPath       uint32
+	pathSize     uint32
+	jPathSize    uint32
+	contArea     uint32
+	efiImage     uint32
+	efiImageSize int64
+	biosImage    *node
+	total        uint32
+}
+
+// Write creates the output image including all the files of the given root directory
+func (w Writer) Write(ctx context.Context, rootDir, output string) (err error) {
+	root, err := buildTree(w.s.FS(), rootDir)
+	if err != nil {
+		return fmt.Errorf("collecting files of '%s': %w", rootDir, err)
+	}
+
+	dirs := directories(root, false)
+	jDirs := directories(root, true)
+	if len(dirs) > 0xffff {
+		return fmt.Errorf("too many directories: %d", len(dirs))
+	}
+
+	l, files, err := w.layout(root, dirs, jDirs)
+	if err != nil {
+		return err
+	}
+
+	f, err := w.s.FS().Create(output)
+	if err != nil {
+		return fmt.Errorf("creating image file: %w", err)
+	}
+	defer func() {
+		if cErr := f.Close(); cErr != nil && err == nil {
+			err = fmt.Errorf("closing image file: %w", cErr)
+		}
+	}()
+
+	iw := &imageWriter{w: bufio.NewWriterSize(f, 1024*1024)}
+	if w.hybridMBR != "" {
+		mbr, err := w.mbr(l)
+		if err != nil {
+			return err
+		}
+		if err = iw.write(0, mbr); err != nil {
+			return err
+		}
+	}
+	err = w.writeMetadata(iw, root, dirs, jDirs, l)
+	if err != nil {
+		return err
+	}
+
+	if w.efiImage != "" {
+		err = iw.copyFile(w.s, w.efiImage, l.efiImage, l.efiImageSize)
+		if err != nil {
+			return fmt.Errorf("writing EFI boot image: %w", err)
+		}
+	}
+
+	for _, n := range files {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if n == l.biosImage {
+			err = w.writeBIOSImage(iw, n)
+			if err != nil {
+				return fmt.Errorf("writing BIOS boot image: %w", err)
+			}
+			continue
+		}
+		err = iw.copyFile(w.s, n.path, n.extent, n.size)
+		if err != nil {
+			return fmt.Errorf("writing file '%s': %w", n.path, err)
+		}
+	}
+
+	err = iw.seek(l.total)
+	if err != nil {
+		return err
+	}
+	err = iw.w.Flush()
+	if err != nil {
+		return fmt.Errorf("flushing image file: %w", err)
+	}
+	w.s.Logger().Debug("Written ISO image '%s' of %d sectors", output, l.total)
+	return nil
+}
+
+// layout computes the location of every area, directory and file of the image. It returns the
+// layout and the files with data in the order they must be written.
+func (w Writer) layout(root *node, dirs, jDirs []*node) (layout, []*node, error) {
+	var l layout
+	next := uint32(systemAreaSectors + 1)
+
+	if w.biosImage != "" {
+		l.biosImage = root.lookup(w.biosImage)
+		if l.biosImage == nil || !l.biosImage.mode.IsRegular() {
+			return l, nil, fmt.Errorf("BIOS boot image '%s' not found in the root directory", w.biosImage)
+		}
+		if l.biosImage.size < grubBootInfoOffset+8 {
+			return l, nil, fmt.Errorf("BIOS boot image '%s' is too small", w.biosImage)
+		}
+	} else if w.hybridMBR != "" {
+		return l, nil, fmt.Errorf("hybrid MBR requires a BIOS boot image")
+	}
+	if w.efiImage != "" {
+		info, err := w.s.FS().Stat(w.efiImage)
+		if err != nil {
+			return l, nil, fmt.Errorf("inspecting EFI boot image: %w", err)
+		}
+		l.efiImageSize = info.Size()
+	}
+	if w.bootable() {
+		l.bootRecord = next
+		next++
+	}
+	l.joliet = next
+	l.terminator = next + 1
+	next += 2
+	if w.bootable() {
+		l.catalog = next
+		next++
+	}
+
+	l.pathSize = uint32(len(pathTable(dirs, false, false)))
+	l.jPathSize = uint32(len(pathTable(jDirs, true, false)))
+	l.lPath = next
+	l.mPath = l.lPath + sectors(int64(l.pathSize))
+	l.jLPath = l.mPath + sectors(int64(l.pathSize))
+	l.jMPath = l.jLPath + sectors(int64(l.jPathSize))
+	next = l.jMPath + sectors(int64(l.jPathSize))
+
+	// Directory sizes do not depend on locations, measure them with a throwaway continuation area
+	ca := &contArea{}
+	for _, d := range dirs {
+		data, err := w.directory(d, false, ca)
+		if err != nil {
+			return l, nil, err
+		}
+		d.dirSize = uint32(len(data))
+		d.extent = next
+		next += sectors(int64(d.dirSize))
+	}
+	// Streaming readers expect continuation areas right after the directories referring to them
+	l.contArea = next
+	next += sectors(int64(len(ca.data)))
+	for _, d := range jDirs {
+		data, err := w.directory(d, true, ca)
+		if err != nil {
+			return l, nil, err
+		}
+		d.jDirSize = uint32(len(data))
+		d.jExtent = next
+		next += sectors(int64(d.jDirSize))
+	}
+
+	if w.efiImage != "" {
+		l.efiImage = next
+		next += sectors(l.efiImageSize)
+	}
+
+	var files []*node
+	for _, d := range dirs {
+		for _, c := range d.children {
+			if c.isDir() || c.isLink() || c.size == 0 {
+				continue
+			}
+			if next+sectors(c.size) < next {
+				return l, nil, fmt.Errorf("image exceeds the maximum ISO 9660 size")
+			}
+			c.extent = next
+			next += sectors(c.size)
+			files = append(files, c)
+		}
+	}
+	l.total = next
+	return l, files, nil
+}
+
+// writeMetadata writes all the areas of the image before the file contents
+func (w Writer) writeMetadata(iw *imageWriter, root *node, dirs, jDirs []*node, l layout) error {
+	now := w.now()
+	if err := iw.write(systemAreaSectors, w.volumeDescriptor(root, false, l, now)); err != nil {
+		return err
+	}
+	if w.bootable() {
+		if err := iw.write(l.bootRecord, bootRecord(l.catalog)); err != nil {
+			return err
+		}
+	}
+	if err := iw.write(l.joliet, w.volumeDescriptor(root, true, l, now)); err != nil {
+		return err
+	}
+	if err := iw.write(l.terminator, terminator()); err != nil {
+		return err
+	}
+	if w.bootable() {
+		if err := iw.write(l.catalog, w.bootCatalog(l)); err != nil {
+			return err
+		}
+	}
+
+	tables := []struct {
+		sector uint32
+		data   []byte
+	}{
+		{l.lPath, pathTable(dirs, false, false)},
+		{l.mPath, pathTable(dirs, false, true)},
+		{l.jLPath, pathTable(jDirs, true, false)},
+		{l.jMPath, pathTable(jDirs, true, true)},
+	}
+	for _, t := range tables {
+		if err := iw.write(t.sector, t.data); err != nil {
+			return err
+		}
+	}
+
+	ca := &contArea{start: l.contArea}
+	for _, d := range dirs {
+		data, err := w.directory(d, false, ca)
+		if err != nil {
+			return err
+		}
+		if err = iw.write(d.extent, data); err != nil {
+			return err
+		}
+	}
+	if err := iw.write(l.contArea, ca.data); err != nil {
+		return err
+	}
+	for _, d := range jDirs {
+		data, err := w.directory(d, true, ca)
+		if err != nil {
+			return err
+		}
+		if err = iw.write(d.jExtent, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// directory returns the sector aligned records of the given directory
+func (w Writer) directory(d *node, joliet bool, ca *contArea) ([]byte, error) {
+	var data []byte
+	add := func(rec []byte) {
+		if used := len(data) % sectorSize; used+len(rec) > sectorSize {
+			data = append(data, make([]byte, sectorSize-used)...)
+		}
+		data = append(data, rec...)
+	}
+
+	if joliet {
+		add(dirRecord([]byte{0}, d.jExtent, d.jDirSize, flagDir, d.modTime, nil))
+		add(dirRecord([]byte{1}, d.parent.jExtent, d.parent.jDirSize, flagDir, d.parent.modTime, nil))
+		for _, c := range d.jolietChildren() {
+			if c.isDir() {
+				add(dirRecord(c.jolietID, c.jExtent, c.jDirSize, flagDir, c.modTime, nil))
+				continue
+			}
+			for _, rec := range fileRecords(c, c.jolietID, nil) {
+				add(rec)
+			}
+		}
+		return padSector(data), nil
+	}
+
+	self := [][]byte{pxEntry(d, w.perm), tfEntry(d.modTime)}
+	if d.parent == d {
+		self = append([][]byte{spEntry()}, append(self, erEntry())...)
+	}
+	su, err := ca.fit(self, maxRecordLen-recordLen([]byte{0}))
+	if err != nil {
+		return nil, err
+	}
+	add(dirRecord([]byte{0}, d.extent, d.dirSize, flagDir, d.modTime, su))
+
+	su, err = ca.fit([][]byte{pxEntry(d.parent, w.perm), tfEntry(d.parent.modTime)}, maxRecordLen-recordLen([]byte{1}))
+	if err != nil {
+		return nil, err
+	}
+	add(dirRecord([]byte{1}, d.parent.extent, d.parent.dirSize, flagDir, d.parent.modTime, su))
+
+	for _, c := range d.children {
+		entries := [][]byte{pxEntry(c, w.perm), tfEntry(c.modTime)}
+		entries = append(entries, nmEntries(c.name)...)
+		if c.isLink() {
+			entries = append(entries, slEntries(c.target)...)
+		}
+		su, err = ca.fit(entries, maxRecordLen-recordLen(c.isoID))
+		if err != nil {
+			return nil, fmt.Errorf("recording '%s': %w", c.path, err)
+		}
+
+		if c.isDir() {
+			add(dirRecord(c.isoID, c.extent, c.dirSize, flagDir, c.modTime, su))
+			continue
+		}
+		for _, rec := range fileRecords(c, c.isoID, su) {
+			add(rec)
+		}
+	}
+	return padSector(data), nil
+}
+
+// fileRecords returns the records of a file, files larger than the maximum extent size are
+// recorded as multiple consecutive extents
+func fileRecords(n *node, id, su []byte) [][]byte {
+	var records [][]byte
+	extent, size := n.extent, n.size
+	for size > maxExtentSize {
+		records = append(records, dirRecord(id, extent, maxExtentSize, flagMultiExtnt, n.modTime, su))
+		extent += maxExtentSize / sectorSize
+		size -= maxExtentSize
+	}
+	return append(records, dirRecord(id, extent, uint32(size), 0, n.modTime, su))
+}
+
+// directories returns all the directories of the tree in path table order, numbering them
+func directories(root *node, joliet bool) []*node {
+	dirs := []*node{root}
+	for i := 0; i < len(dirs); i++ {
+		d := dirs[i]
+		children := d.children
+		if joliet {
+			children = d.jolietChildren()
+			d.jDirNum = uint16(i + 1)
+		} else {
+			d.dirNum = uint16(i + 1)
+		}
+		for _, c := range children {
+			if c.isDir() {
+				dirs = append(dirs, c)
+			}
+		}
+	}
+	return dirs
+}
+
+// pathTable returns the path table of the given directories, either in little or big endian
+func pathTable(dirs []*node, joliet, bigEndian bool) []byte {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+
+	var table []byte
+	for _, d := range dirs {
+		id, extent, parent := d.isoID, d.extent, d.parent.dirNum
+		if joliet {
+			id, extent, parent = d.jolietID, d.jExtent, d.parent.jDirNum
+		}
+		entry := make([]byte, 8+len(id)+len(id)%2)
+		entry[0] = byte(len(id))
+		order.PutUint32(entry[2:], extent)
+		order.PutUint16(entry[6:], parent)
+		copy(entry[8:], id)
+		table = append(table, entry...)
+	}
+	return table
+}
+
+// volumeDescriptor returns the primary volume descriptor or the Joliet supplementary volume descriptor
+func (w Writer) volumeDescriptor(root *node, joliet bool, l layout, now time.Time) []byte {
+	vd := make([]byte, sectorSize)
+	vd[0] = 1
+	copy(vd[1:], "CD001")
+	vd[6] = 1
+
+	put := putPadded
+	extent, size, pathSize, lPath, mPath := root.extent, root.dirSize, l.pathSize, l.lPath, l.mPath
+	if joliet {
+		vd[0] = 2
+		put = putPaddedUCS2
+		// UCS-2 level 3 escape sequence
+		copy(vd[88:], "%/E")
+		extent, size, pathSize, lPath, mPath = root.jExtent, root.jDirSize, l.jPathSize, l.jLPath, l.jMPath
+	}
+
+	put(vd[8:40], "LINUX")
+	put(vd[40:72], w.volumeID)
+	putBoth32(vd[80:], l.total)
+	putBoth16(vd[120:], 1)
+	putBoth16(vd[124:], 1)
+	putBoth16(vd[128:], sectorSize)
+	putBoth32(vd[132:], pathSize)
+	binary.LittleEndian.PutUint32(vd[140:], lPath)
+	binary.BigEndian.PutUint32(vd[148:], mPath)
+	copy(vd[156:190], dirRecord([]byte{0}, extent, size, flagDir, root.modTime, nil))
+	put(vd[190:318], w.volumeID)
+	put(vd[318:446], "")
+	put(vd[446:574], "")
+	put(vd[574:702], applicationID)
+	put(vd[702:739], "")
+	put(vd[739:776], "")
+	put(vd[776:813], "")
+	putVolumeTime(vd[813:], now)
+	putVolumeTime(vd[830:], now)
+	putVolumeTime(vd[847:], time.Time{})
+	putVolumeTime(vd[864:], time.Time{})
+	vd[881] = 1
+	return vd
+}
+
+func terminator() []byte {
+	vd := make([]byte, sectorSize)
+	vd[0] = 255
+	copy(vd[1:], "CD001")
+	vd[6] = 1
+	return vd
+}
+
+func sectors(size int64) uint32 {
+	return uint32((size + sectorSize - 1) / sectorSize)
+}
+
+func padSector(data []byte) []byte {
+	if len(data)%sectorSize == 0 {
+		return data
+	}
+	return append(data, make([]byte, sectorSize-len(data)%sectorSize)...)
+}
+
+// imageWriter writes the image sequentially keeping track of the current sector
+type imageWriter struct {
+	w      *bufio.Writer
+	offset int64
+}
+
+// seek pads the image with zeros up to the given sector, the image can't be rewound
+func (iw *imageWriter) seek(sector uint32) error {
+	target := int64(sector) * sectorSize
+	if target < iw.offset {
+		return fmt.Errorf("invalid image layout: sector %d already written", sector)
+	}
+	n, err := io.CopyN(iw.w, zeroReader{}, target-iw.offset)
+	iw.offset += n
+	if err != nil {
+		return fmt.Errorf("padding image: %w", err)
+	}
+	return nil
+}
+
+func (iw *imageWriter) write(sector uint32, data []byte) error {
+	err := iw.seek(sector)
+	if err != nil {
+		return err
+	}
+	n, err := iw.w.Write(data)
+	iw.offset += int64(n)
+	if err != nil {
+		return fmt.Errorf("writing image: %w", err)
+	}
+	return nil
+}
+
+// copyFile copies the given file at the given sector, the file size must match the size of the layout
+func (iw *imageWriter) copyFile(s *sys.System, path string, sector uint32, size int64) error {
+	err := iw.seek(sector)
+	if err != nil {
+		return err
+	}
+	f, err := s.FS().Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := io.Copy(iw.w, f)
+	iw.offset += n
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("file size changed from %d to %d bytes while writing the image", size, n)
+	}
+	return nil
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,266 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iso9660_test
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/iso9660"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const sectorSize = 2048
+
+func TestISO9660Suite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ISO9660 test suite")
+}
+
+// record is a parsed directory record
+type record struct {
+	id     string
+	extent uint32
+	size   uint32
+	flags  byte
+	su     []byte
+}
+
+// readDir parses all the records of the directory at the given extent
+func readDir(image []byte, extent, size uint32) []record {
+	var records []record
+	data := image[extent*sectorSize : extent*sectorSize+size]
+	for i := 0; i < len(data); {
+		length := int(data[i])
+		if length == 0 {
+			i = (i/sectorSize + 1) * sectorSize
+			continue
+		}
+		rec := data[i : i+length]
+		idLen := int(rec[32])
+		suStart := 33 + idLen + (idLen+1)%2
+		records = append(records, record{
+			id:     string(rec[33 : 33+idLen]),
+			extent: binary.LittleEndian.Uint32(rec[2:]),
+			size:   binary.LittleEndian.Uint32(rec[10:]),
+			flags:  rec[25],
+			su:     rec[suStart:],
+		})
+		i += length
+	}
+	return records
+}
+
+// rockRidgeName returns the alternate name of the record following continuation areas
+func rockRidgeName(image []byte, su []byte) string {
+	var name string
+	for len(su) >= 4 && su[2] >= 4 {
+		entry := su[:su[2]]
+		switch string(entry[:2]) {
+		case "NM":
+			name += string(entry[5:])
+		case "CE":
+			block := binary.LittleEndian.Uint32(entry[4:])
+			offset := binary.LittleEndian.Uint32(entry[12:])
+			length := binary.LittleEndian.Uint32(entry[20:])
+			start := block*sectorSize + offset
+			name += rockRidgeName(image, image[start:start+length])
+		}
+		su = su[len(entry):]
+	}
+	return name
+}
+
+func find(records []record, name string, image []byte) *record {
+	for _, r := range records {
+		if rockRidgeName(image, r.su) == name {
+			return &r
+		}
+	}
+	return nil
+}
+
+var _ = Describe("ISO9660", Label("iso9660"), func() {
+	var fs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	longName := strings.Repeat("long-name", 25)
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/root/boot/grub2/grub.cfg": "set timeout=5\n",
+			"/root/LiveOS/squashfs.img": strings.Repeat("squashfs", 1000),
+			"/root/" + longName:         "long",
+			"/root/same_name.txt":       "one",
+			"/root/SAME-NAME.TXT":       "two",
+			"/root/empty":               "",
+			"/efi.img":                  strings.Repeat("e", 3000),
+			"/root/boot/eltorito.img":   strings.Repeat("b", 3000),
+			"/mbr.img":                  strings.Repeat("m", 512),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fs.Symlink("../boot/grub2/grub.cfg", "/root/LiveOS/link")).To(Succeed())
+		s, err = sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("writes a bootable image including all files", func() {
+		w := iso9660.NewWriter(s, iso9660.WithVolumeID("LIVE"), iso9660.WithEFIBootImage("/efi.img"))
+		Expect(w.Write(context.Background(), "/root", "/out.iso")).To(Succeed())
+
+		image, err := fs.ReadFile("/out.iso")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(image) % sectorSize).To(Equal(0))
+
+		pvd := image[16*sectorSize:]
+		Expect(pvd[0]).To(Equal(byte(1)))
+		Expect(string(pvd[1:6])).To(Equal("CD001"))
+		Expect(string(pvd[40:72])).To(Equal("LIVE" + strings.Repeat(" ", 28)))
+		Expect(binary.LittleEndian.Uint32(pvd[80:])).To(Equal(uint32(len(image) / sectorSize)))
+
+		// El Torito boot record and catalog
+		br := image[17*sectorSize:]
+		Expect(br[0]).To(Equal(byte(0)))
+		Expect(string(br[7:30])).To(Equal("EL TORITO SPECIFICATION"))
+		catalog := image[binary.LittleEndian.Uint32(br[71:])*sectorSize:]
+		var sum uint16
+		for i := 0; i < 32; i += 2 {
+			sum += binary.LittleEndian.Uint16(catalog[i:])
+		}
+		Expect(sum).To(BeZero())
+		Expect(catalog[1]).To(Equal(byte(0xef)))
+		Expect(catalog[32]).To(Equal(byte(0x88)))
+		Expect(binary.LittleEndian.Uint16(catalog[38:])).To(Equal(uint16(6)))
+		efiExtent := binary.LittleEndian.Uint32(catalog[40:])
+		Expect(string(image[efiExtent*sectorSize : efiExtent*sectorSize+3000])).To(Equal(strings.Repeat("e", 3000)))
+
+		// Joliet supplementary volume descriptor
+		svd := image[18*sectorSize:]
+		Expect(svd[0]).To(Equal(byte(2)))
+		Expect(string(svd[88:91])).To(Equal("%/E"))
+
+		// Primary tree with Rock Ridge names
+		rootRec := pvd[156:190]
+		root := readDir(image, binary.LittleEndian.Uint32(rootRec[2:]), binary.LittleEndian.Uint32(rootRec[10:]))
+		Expect(string(root[0].su[:2])).To(Equal("SP"))
+
+		liveOS := find(root, "LiveOS", image)
+		Expect(liveOS).NotTo(BeNil())
+		Expect(liveOS.id).To(Equal("LIVEOS"))
+		Expect(liveOS.flags).To(Equal(byte(0x02)))
+
+		live := readDir(image, liveOS.extent, liveOS.size)
+		squash := find(live, "squashfs.img", image)
+		Expect(squash).NotTo(BeNil())
+		Expect(squash.id).To(Equal("SQUASHFS.IMG;1"))
+		Expect(string(image[squash.extent*sectorSize : squash.extent*sectorSize+squash.size])).To(
+			Equal(strings.Repeat("squashfs", 1000)),
+		)
+		link := find(live, "link", image)
+		Expect(link).NotTo(BeNil())
+		Expect(string(link.su)).To(ContainSubstring("SL"))
+
+		Expect(find(root, longName, image)).NotTo(BeNil())
+		one, two := find(root, "same_name.txt", image), find(root, "SAME-NAME.TXT", image)
+		Expect(one).NotTo(BeNil())
+		Expect(two).NotTo(BeNil())
+		Expect(one.id).NotTo(Equal(two.id))
+		Expect(find(root, "empty", image).size).To(BeZero())
+	})
+	It("writes a hybrid BIOS and EFI bootable image", func() {
+		w := iso9660.NewWriter(
+			s, iso9660.WithEFIBootImage("/efi.img"), iso9660.WithBIOSBootImage("boot/eltorito.img"),
+			iso9660.WithHybridMBR("/mbr.img"),
+		)
+		Expect(w.Write(context.Background(), "/root", "/out.iso")).To(Succeed())
+
+		image, err := fs.ReadFile("/out.iso")
+		Expect(err).NotTo(HaveOccurred())
+
+		// BIOS default entry and EFI section
+		catalog := image[binary.LittleEndian.Uint32(image[17*sectorSize+71:])*sectorSize:]
+		Expect(catalog[1]).To(Equal(byte(0)))
+		Expect(catalog[32]).To(Equal(byte(0x88)))
+		Expect(binary.LittleEndian.Uint16(catalog[38:])).To(Equal(uint16(4)))
+		biosExtent := binary.LittleEndian.Uint32(catalog[40:])
+		Expect(catalog[64]).To(Equal(byte(0x91)))
+		Expect(catalog[65]).To(Equal(byte(0xef)))
+		Expect(catalog[96]).To(Equal(byte(0x88)))
+		efiExtent := binary.LittleEndian.Uint32(catalog[104:])
+		Expect(image[efiExtent*sectorSize]).To(Equal(byte('e')))
+
+		// Patched BIOS image
+		bios := image[biosExtent*sectorSize : biosExtent*sectorSize+3000]
+		Expect(binary.LittleEndian.Uint32(bios[8:])).To(Equal(uint32(16)))
+		Expect(binary.LittleEndian.Uint32(bios[12:])).To(Equal(biosExtent))
+		Expect(binary.LittleEndian.Uint32(bios[16:])).To(Equal(uint32(3000)))
+		// The checksum covers the original image, before locating the GRUB core image
+		Expect(binary.LittleEndian.Uint32(bios[20:])).To(Equal(uint32(0x62626262 * (3000 - 64) / 4 % (1 << 32))))
+		Expect(binary.LittleEndian.Uint64(bios[2548:])).To(Equal(uint64(biosExtent)*4 + 5))
+		Expect(string(bios[2556:])).To(Equal(strings.Repeat("b", 444)))
+
+		// Hybrid MBR
+		Expect(string(image[:432])).To(Equal(strings.Repeat("m", 432)))
+		Expect(binary.LittleEndian.Uint64(image[432:])).To(Equal(uint64(biosExtent)*4 + 4))
+		Expect(image[446]).To(Equal(byte(0x80)))
+		Expect(binary.LittleEndian.Uint32(image[458:])).To(Equal(uint32(len(image) / 512)))
+		Expect(image[466]).To(Equal(byte(0xef)))
+		Expect(binary.LittleEndian.Uint32(image[470:])).To(Equal(efiExtent * 4))
+		Expect(image[510:512]).To(Equal([]byte{0x55, 0xaa}))
+	})
+	It("fails to write a hybrid MBR without BIOS boot image", func() {
+		w := iso9660.NewWriter(s, iso9660.WithHybridMBR("/mbr.img"))
+		Expect(w.Write(context.Background(), "/root", "/out.iso")).To(MatchError(ContainSubstring("requires a BIOS boot image")))
+	})
+	It("fails if the BIOS boot image is not in the root directory", func() {
+		w := iso9660.NewWriter(s, iso9660.WithBIOSBootImage("boot/missing.img"))
+		Expect(w.Write(context.Background(), "/root", "/out.iso")).To(MatchError(ContainSubstring("not found in the root directory")))
+	})
+	It("writes a non bootable image without El Torito records", func() {
+		Expect(iso9660.NewWriter(s).Write(context.Background(), "/root", "/out.iso")).To(Succeed())
+
+		image, err := fs.ReadFile("/out.iso")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(image[16*sectorSize+40 : 16*sectorSize+48])).To(Equal("ISOIMAGE"))
+		Expect(image[17*sectorSize]).To(Equal(byte(2)))
+		Expect(image[18*sectorSize]).To(Equal(byte(255)))
+	})
+	It("fails if the root is not a directory", func() {
+		err := iso9660.NewWriter(s).Write(context.Background(), "/efi.img", "/out.iso")
+		Expect(err).To(MatchError(ContainSubstring("'/efi.img' is not a directory")))
+	})
+	It("fails if the EFI boot image does not exist", func() {
+		w := iso9660.NewWriter(s, iso9660.WithEFIBootImage("/missing.img"))
+		Expect(w.Write(context.Background(), "/root", "/out.iso")).To(MatchError(ContainSubstring("inspecting EFI boot image")))
+	})
+	It("stops writing if the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(iso9660.NewWriter(s).Write(ctx, "/root", "/out.iso")).To(MatchError(context.Canceled))
+	})
+})
@@ -0,0 +1,286 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iso9660
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// maxISOFileName is the maximum length of a file identifier, without version, at interchange level 2
+	maxISOFileName = 30
+	// maxISODirName is the maximum length of a directory identifier at interchange level 2
+	maxISODirName = 31
+	// maxJolietName is the maximum number of UCS-2 characters of a Joliet identifier
+	maxJolietName = 64
+	// fileVersion is the version suffix appended to all file identifiers
+	fileVersion = ";1"
+)
+
+// node is a file, directory or symbolic link of the tree being written
+type node struct {
+	name     string
+	path     string
+	mode     fs.FileMode
+	modTime  time.Time
+	size     int64
+	target   string
+	parent   *node
+	children []*node
+
+	isoID    []byte
+	jolietID []byte
+
+	// extent is the data location of files and the location of directories in the primary tree
+	extent  uint32
+	dirSize uint32
+	dirNum  uint16
+	// jExtent, jDirSize and jDirNum locate directories in the Joliet tree
+	jExtent  uint32
+	jDirSize uint32
+	jDirNum  uint16
+}
+
+func (n *node) isDir() bool {
+	return n.mode.IsDir()
+}
+
+func (n *node) isLink() bool {
+	return n.mode&fs.ModeSymlink != 0
+}
+
+// subdirs returns the number of child directories
+func (n *node) subdirs() int {
+	var count int
+	for _, c := range n.children {
+		if c.isDir() {
+			count++
+		}
+	}
+	return count
+}
+
+// jolietChildren returns the children included in the Joliet tree, symbolic links can't be represented
+// and are omitted
+func (n *node) jolietChildren() []*node {
+	children := slices.DeleteFunc(slices.Clone(n.children), func(c *node) bool { return c.isLink() })
+	slices.SortFunc(children, func(a, b *node) int { return compareBytes(a.jolietID, b.jolietID) })
+	return children
+}
+
+// buildTree walks the given root directory and returns the tree of nodes with the identifiers of the
+// primary and Joliet trees already set. Only regular files, directories and symbolic links are included.
+func buildTree(fileSystem vfs.FS, root string) (*node, error) {
+	info, err := fileSystem.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting root directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", root)
+	}
+
+	rootNode := &node{path: root, mode: info.Mode(), modTime: info.ModTime(), isoID: []byte{0}, jolietID: []byte{0}}
+	rootNode.parent = rootNode
+	err = addChildren(fileSystem, rootNode)
+	if err != nil {
+		return nil, err
+	}
+	return rootNode, nil
+}
+
+func addChildren(fileSystem vfs.FS, dir *node) error {
+	entries, err := fileSystem.ReadDir(dir.path)
+	if err != nil {
+		return fmt.Errorf("reading directory '%s': %w", dir.path, err)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir.path, entry.Name())
+		info, err := fileSystem.Lstat(path)
+		if err != nil {
+			return fmt.Errorf("inspecting '%s': %w", path, err)
+		}
+
+		child := &node{name: entry.Name(), path: path, mode: info.Mode(), modTime: info.ModTime(), parent: dir}
+		switch {
+		case info.IsDir():
+			err = addChildren(fileSystem, child)
+			if err != nil {
+				return err
+			}
+		case info.Mode()&fs.ModeSymlink != 0:
+			child.target, err = fileSystem.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("reading link '%s': %w", path, err)
+			}
+		case info.Mode().IsRegular():
+			child.size = info.Size()
+		default:
+			continue
+		}
+		dir.children = append(dir.children, child)
+	}
+
+	setIdentifiers(dir.children)
+	slices.SortFunc(dir.children, func(a, b *node) int { return compareBytes(a.isoID, b.isoID) })
+	return nil
+}
+
+// setIdentifiers sets unique primary and Joliet identifiers for all the given sibling nodes
+func setIdentifiers(siblings []*node) {
+	isoIDs := map[string]bool{}
+	jolietIDs := map[string]bool{}
+	for _, n := range siblings {
+		maxLen := maxISOFileName
+		if n.isDir() {
+			maxLen = maxISODirName
+		}
+		base, ext := isoName(n.name, n.isDir())
+		id := uniqueName(isoIDs, base, ext, maxLen, func(s string) int { return len(s) })
+		if !n.isDir() {
+			id += fileVersion
+		}
+		n.isoID = []byte(id)
+
+		base, ext = jolietName(n.name, n.isDir())
+		maxLen = maxJolietName
+		if !n.isDir() {
+			maxLen -= len(fileVersion)
+		}
+		id = uniqueName(jolietIDs, base, ext, maxLen, func(s string) int { return len(utf16.Encode([]rune(s))) })
+		if !n.isDir() {
+			id += fileVersion
+		}
+		n.jolietID = ucs2(id)
+	}
+}
+
+// uniqueName returns the base and extension joined and truncated to the allowed length. Names colliding
+// with previous ones get a numeric suffix.
+func uniqueName(used map[string]bool, base, ext string, maxLen int, length func(string) int) string {
+	for i := 0; ; i++ {
+		suffix := ""
+		if i > 0 {
+			suffix = "_" + strconv.Itoa(i)
+		}
+		name := truncate(base, maxLen-length(ext)-length(suffix), length) + suffix + ext
+		if !used[name] {
+			used[name] = true
+			return name
+		}
+	}
+}
+
+func truncate(s string, maxLen int, length func(string) int) string {
+	runes := []rune(s)
+	for len(runes) > 0 && length(string(runes)) > maxLen {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
+
+// isoName returns the base name and extension of the given name using only d-characters. The
+// extension includes the separator, which is mandatory for file identifiers.
+func isoName(name string, dir bool) (string, string) {
+	mapping := func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		default:
+			return '_'
+		}
+	}
+
+	if dir {
+		return strings.Map(mapping, name), ""
+	}
+
+	base, ext := name, ""
+	if i := strings.LastIndex(name, "."); i > 0 {
+		base, ext = name[:i], name[i+1:]
+	}
+	ext = strings.Map(mapping, ext)
+	if len(ext) > 8 {
+		ext = ext[:8]
+	}
+	return strings.Map(mapping, base), "." + ext
+}
+
+// jolietName returns the base name and extension of the given name without the characters
+// forbidden by the Joliet specification
+func jolietName(name string, dir bool) (string, string) {
+	mapping := func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune("*/:;?\\", r) {
+			return '_'
+		}
+		return r
+	}
+
+	name = strings.Map(mapping, name)
+	if dir {
+		return name, ""
+	}
+	if i := strings.LastIndex(name, "."); i > 0 && len(name)-i <= 16 {
+		return name[:i], name[i:]
+	}
+	return name, ""
+}
+
+// ucs2 encodes the given string as big endian UCS-2
+func ucs2(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		b = append(b, byte(u>>8), byte(u))
+	}
+	return b
+}
+
+func compareBytes(a, b []byte) int {
+	return strings.Compare(string(a), string(b))
+}
+
+// lookup returns the node of the given path relative to this directory or nil if not found
+func (n *node) lookup(path string) *node {
+	current := n
+	for _, name := range strings.Split(filepath.Clean("/"+path), "/")[1:] {
+		var found *node
+		for _, c := range current.children {
+			if c.name == name {
+				found = c
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		current = found
+	}
+	return current
+}
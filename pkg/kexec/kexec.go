@@ -0,0 +1,57 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kexec
+
+import (
+	"fmt"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// Load stages the given kernel, initrd and kernel command line to be booted on the next kexec reboot.
+// Once loaded, the given kernel and initrd files are no longer required.
+func Load(s *sys.System, kernel, initrd, cmdline string) error {
+	s.Logger().Info("Loading kernel '%s' for kexec", kernel)
+	_, err := s.Runner().Run(
+		"kexec", "--load", kernel, fmt.Sprintf("--initrd=%s", initrd), fmt.Sprintf("--command-line=%s", cmdline),
+	)
+	if err != nil {
+		return fmt.Errorf("loading kernel '%s': %w", kernel, err)
+	}
+	return nil
+}
+
+// Unload drops any kernel previously staged with Load
+func Unload(s *sys.System) error {
+	_, err := s.Runner().Run("kexec", "--unload")
+	if err != nil {
+		return fmt.Errorf("unloading kexec kernel: %w", err)
+	}
+	return nil
+}
+
+// Reboot boots into the kernel staged with Load. It relies on systemd to gracefully stop
+// all services and unmount filesystems before executing the loaded kernel.
+func Reboot(s *sys.System) error {
+	s.Logger().Info("Rebooting into the loaded kernel")
+	_, err := s.Runner().Run("systemctl", "kexec")
+	if err != nil {
+		return fmt.Errorf("rebooting with kexec: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kexec_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/kexec"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+)
+
+func TestKexecSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Kexec test suite")
+}
+
+var _ = Describe("Kexec", Label("kexec"), func() {
+	var runner *sysmock.Runner
+	var s *sys.System
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		s, err = sys.NewSystem(sys.WithRunner(runner), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	It("loads the given kernel and reboots into it", func() {
+		Expect(kexec.Load(s, "/boot/vmlinuz", "/boot/initrd", "root=LABEL=SYSTEM quiet")).To(Succeed())
+		Expect(kexec.Reboot(s)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"kexec", "--load", "/boot/vmlinuz", "--initrd=/boot/initrd", "--command-line=root=LABEL=SYSTEM quiet"},
+			{"systemctl", "kexec"},
+		})).To(Succeed())
+	})
+	It("unloads a staged kernel", func() {
+		Expect(kexec.Unload(s)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{"kexec", "--unload"}})).To(Succeed())
+	})
+	It("fails to load a kernel", func() {
+		runner.ReturnError = fmt.Errorf("kexec failed")
+		Expect(kexec.Load(s, "/boot/vmlinuz", "/boot/initrd", "")).To(MatchError(ContainSubstring("kexec failed")))
+	})
+})
@@ -0,0 +1,377 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lifecycle exposes the upgrade transaction stages over a local Unix socket, so node-local
+// agents, such as a Kubernetes operator coordinating node drains, can follow an upgrade and veto or
+// delay it before it is committed.
+//
+// The protocol is newline delimited JSON. Agents connect to the socket and send a Subscription. The
+// server then sends an Event at each stage the agent subscribed to and, unless the event has no Wait
+// flag, it expects a Response with the same ID before proceeding. A subscriber disconnecting or not
+// responding in time vetoes the transaction.
+//
+// The socket can be owned by a systemd socket unit, so it exists for the lifetime of the unit and agents
+// can connect to it before the upgrade starts. The server then takes over the listener passed by socket
+// activation and keeps the socket on Close.
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+const (
+	// DefaultSocket is the conventional path of the lifecycle socket
+	DefaultSocket = "/run/elemental/lifecycle.sock"
+	// DefaultTimeout is the time the server waits for each subscriber to respond to an event
+	DefaultTimeout = 2 * time.Minute
+
+	subscribeTimeout = 10 * time.Second
+	listenFdsStart   = 3
+	socketPerm       = 0o660
+	socketDirPerm    = 0o750
+)
+
+// Verdict is the answer of a subscriber to an event
+type Verdict string
+
+const (
+	// Ack lets the transaction proceed
+	Ack Verdict = "ack"
+	// Veto aborts the transaction
+	Veto Verdict = "veto"
+	// Delay makes the transaction wait and send the event again
+	Delay Verdict = "delay"
+)
+
+// Subscription is the first message sent by agents once connected
+type Subscription struct {
+	// Name identifies the agent in logs and errors
+	Name string `json:"name"`
+	// Stages are the stages the agent is notified about, all of them if empty
+	Stages []upgrade.Stage `json:"stages,omitempty"`
+}
+
+// Event notifies subscribers about a transaction stage
+type Event struct {
+	ID    uint64        `json:"id"`
+	Stage upgrade.Stage `json:"stage"`
+	Root  string        `json:"root"`
+	// Wait is set if the server waits for a Response, events of the after-commit stage are
+	// only notifications as the transaction can't be vetoed anymore
+	Wait bool `json:"wait"`
+}
+
+// Response is the answer of a subscriber to an Event
+type Response struct {
+	ID      uint64  `json:"id"`
+	Verdict Verdict `json:"verdict"`
+	Reason  string  `json:"reason,omitempty"`
+	// DelaySeconds is the time to wait before sending the event again on a Delay verdict
+	DelaySeconds int `json:"delaySeconds,omitempty"`
+}
+
+type subscriber struct {
+	Subscription
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+}
+
+func (sub *subscriber) wants(stage upgrade.Stage) bool {
+	return len(sub.Stages) == 0 || slices.Contains(sub.Stages, stage)
+}
+
+// Server is an upgrade.Hook forwarding the transaction stages to the subscribers of a Unix socket
+type Server struct {
+	s        *sys.System
+	path     string
+	timeout  time.Duration
+	listener net.Listener
+	external bool
+	mu       sync.Mutex
+	subs     []*subscriber
+	closed   bool
+	nextID   uint64
+	wg       sync.WaitGroup
+}
+
+type Opt func(*Server)
+
+// WithTimeout sets the time the server waits for each subscriber to respond to an event
+func WithTimeout(timeout time.Duration) Opt {
+	return func(srv *Server) {
+		srv.timeout = timeout
+	}
+}
+
+// WithListener sets the listener of the socket, as passed by systemd socket activation. The socket
+// is owned by the caller and it is not removed on Close.
+func WithListener(l net.Listener) Opt {
+	return func(srv *Server) {
+		srv.listener = l
+		srv.external = l != nil
+	}
+}
+
+// ActivatedListener returns the listener of the given socket path passed by systemd socket
+// activation, or nil if the socket was not passed to this process.
+func ActivatedListener(s *sys.System, path string) (net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds <= 0 {
+		return nil, nil
+	}
+	rawPath, err := s.FS().RawPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for fd := listenFdsStart; fd < listenFdsStart+fds; fd++ {
+		l, err := net.FileListener(os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd)))
+		if err != nil {
+			continue
+		}
+		if addr, ok := l.Addr().(*net.UnixAddr); ok && addr.Name == rawPath {
+			return l, nil
+		}
+		_ = l.Close()
+	}
+	return nil, nil
+}
+
+func NewServer(s *sys.System, path string, opts ...Opt) *Server {
+	srv := &Server{s: s, path: path, timeout: DefaultTimeout}
+	for _, o := range opts {
+		o(srv)
+	}
+	return srv
+}
+
+// Start listens on the socket, only reachable by the owner and group of the socket file, and
+// accepts subscribers in the background until Close is called
+func (srv *Server) Start() error {
+	if srv.external {
+		srv.s.Logger().Info("Using lifecycle socket '%s' passed by socket activation", srv.path)
+		srv.wg.Add(1)
+		go srv.accept()
+		return nil
+	}
+
+	err := vfs.MkdirAll(srv.s.FS(), filepath.Dir(srv.path), socketDirPerm)
+	if err != nil {
+		return fmt.Errorf("creating socket directory: %w", err)
+	}
+	// Remove any stale socket left behind by an interrupted upgrade
+	err = srv.s.FS().RemoveAll(srv.path)
+	if err != nil {
+		return fmt.Errorf("removing stale socket: %w", err)
+	}
+
+	path, err := srv.s.FS().RawPath(srv.path)
+	if err != nil {
+		return err
+	}
+	srv.listener, err = net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("listening on '%s': %w", srv.path, err)
+	}
+	err = srv.s.FS().Chmod(srv.path, socketPerm)
+	if err != nil {
+		_ = srv.listener.Close()
+		return fmt.Errorf("setting socket permissions: %w", err)
+	}
+
+	srv.wg.Add(1)
+	go srv.accept()
+	return nil
+}
+
+// Close stops accepting subscribers, disconnects the current ones and removes the socket, unless it is
+// owned by the caller
+func (srv *Server) Close() error {
+	if srv.listener == nil {
+		return nil
+	}
+	err := srv.listener.Close()
+	srv.wg.Wait()
+
+	srv.mu.Lock()
+	srv.closed = true
+	for _, sub := range srv.subs {
+		_ = sub.conn.Close()
+	}
+	srv.subs = nil
+	srv.mu.Unlock()
+
+	if srv.external {
+		return err
+	}
+	return errors.Join(err, srv.s.FS().RemoveAll(srv.path))
+}
+
+func (srv *Server) accept() {
+	defer srv.wg.Done()
+	for {
+		conn, err := srv.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				srv.s.Logger().Warn("Accepting lifecycle subscriber failed: %v", err)
+			}
+			return
+		}
+
+		go srv.subscribe(conn)
+	}
+}
+
+// subscribe reads the subscription of the given connection and registers its subscriber
+func (srv *Server) subscribe(conn net.Conn) {
+	sub := &subscriber{conn: conn, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn)}
+	_ = conn.SetReadDeadline(time.Now().Add(subscribeTimeout))
+	if err := sub.dec.Decode(&sub.Subscription); err != nil || sub.Name == "" {
+		srv.s.Logger().Warn("Rejecting lifecycle subscriber: invalid subscription")
+		_ = conn.Close()
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.closed {
+		_ = conn.Close()
+		return
+	}
+	srv.s.Logger().Info("Lifecycle subscriber '%s' connected", sub.Name)
+	srv.subs = append(srv.subs, sub)
+}
+
+// Subscribers returns the names of the connected subscribers
+func (srv *Server) Subscribers() []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	names := make([]string, len(srv.subs))
+	for i, sub := range srv.subs {
+		names[i] = sub.Name
+	}
+	return names
+}
+
+func (srv *Server) Name() string {
+	return "lifecycle socket"
+}
+
+// Run sends the stage event to all its subscribers and waits for their responses. Any veto aborts
+// the transaction and any delay delays it by the longest requested delay. Subscribers disconnecting
+// or not responding in time veto the transaction, disconnected subscribers are dropped.
+func (srv *Server) Run(ctx context.Context, stage upgrade.Stage, root string) error {
+	srv.mu.Lock()
+	srv.nextID++
+	event := Event{ID: srv.nextID, Stage: stage, Root: root, Wait: stage != upgrade.StageAfterCommit}
+	subs := slices.Clone(srv.subs)
+	srv.mu.Unlock()
+
+	var delay *upgrade.DelayError
+	for _, sub := range subs {
+		if !sub.wants(stage) {
+			continue
+		}
+		resp, err := srv.notify(ctx, sub, event)
+		if err != nil {
+			return err
+		} else if resp == nil {
+			continue
+		}
+
+		switch resp.Verdict {
+		case Ack:
+		case Veto:
+			return fmt.Errorf("vetoed by '%s': %s", sub.Name, resp.Reason)
+		case Delay:
+			d := time.Duration(resp.DelaySeconds) * time.Second
+			if delay == nil || d > delay.Delay {
+				delay = &upgrade.DelayError{Delay: d, Reason: fmt.Sprintf("requested by '%s': %s", sub.Name, resp.Reason)}
+			}
+		default:
+			return fmt.Errorf("invalid verdict '%s' from '%s'", resp.Verdict, sub.Name)
+		}
+	}
+	if delay != nil {
+		return delay
+	}
+	return nil
+}
+
+// notify sends the event to the given subscriber and returns its response, if the event waits for
+// one. It fails if the subscriber is gone or does not respond in time, unless the event does not wait
+// for a response.
+func (srv *Server) notify(ctx context.Context, sub *subscriber, event Event) (*Response, error) {
+	deadline := time.Now().Add(srv.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = sub.conn.SetDeadline(deadline)
+	defer func() { _ = sub.conn.SetDeadline(time.Time{}) }()
+
+	err := sub.enc.Encode(event)
+	if err == nil && event.Wait {
+		resp := &Response{}
+		for err == nil && resp.ID != event.ID {
+			err = sub.dec.Decode(resp)
+		}
+		if err == nil {
+			return resp, nil
+		}
+	}
+
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return nil, nil
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return nil, fmt.Errorf("subscriber '%s' did not respond to %s event", sub.Name, event.Stage)
+	default:
+		srv.s.Logger().Warn("Lifecycle subscriber '%s' disconnected: %v", sub.Name, err)
+		srv.drop(sub)
+		if !event.Wait {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("subscriber '%s' disconnected before responding to %s event", sub.Name, event.Stage)
+	}
+}
+
+func (srv *Server) drop(sub *subscriber) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	_ = sub.conn.Close()
+	srv.subs = slices.DeleteFunc(srv.subs, func(s *subscriber) bool { return s == sub })
+}
@@ -0,0 +1,197 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lifecycle_test
+
+import (
+	"context"
+	"encoding/json"
+	iofs "io/fs"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/lifecycle"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+func TestLifecycleSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Lifecycle test suite")
+}
+
+// agent subscribes to the server socket and answers each waiting event with the given response
+func agent(fs vfs.FS, sub lifecycle.Subscription, resp lifecycle.Response, events chan<- lifecycle.Event) net.Conn {
+	path, err := fs.RawPath(lifecycle.DefaultSocket)
+	Expect(err).NotTo(HaveOccurred())
+	conn, err := net.Dial("unix", path)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(json.NewEncoder(conn).Encode(sub)).To(Succeed())
+
+	go func() {
+		defer GinkgoRecover()
+		dec, enc := json.NewDecoder(conn), json.NewEncoder(conn)
+		for {
+			event := lifecycle.Event{}
+			if dec.Decode(&event) != nil {
+				return
+			}
+			events <- event
+			if event.Wait {
+				resp.ID = event.ID
+				_ = enc.Encode(resp)
+			}
+		}
+	}()
+	return conn
+}
+
+var _ = Describe("Lifecycle server", Label("lifecycle"), func() {
+	var fs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var srv *lifecycle.Server
+	var events chan lifecycle.Event
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+		events = make(chan lifecycle.Event, 10)
+
+		srv = lifecycle.NewServer(s, lifecycle.DefaultSocket, lifecycle.WithTimeout(time.Second))
+		Expect(srv.Start()).To(Succeed())
+	})
+	AfterEach(func() {
+		Expect(srv.Close()).To(Succeed())
+		Expect(vfs.Exists(fs, lifecycle.DefaultSocket)).To(BeFalse())
+		cleanup()
+	})
+	It("creates the socket only accessible by owner and group", func() {
+		info, err := fs.Stat(lifecycle.DefaultSocket)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(iofs.FileMode(0o660)))
+	})
+	It("proceeds if there are no subscribers", func() {
+		Expect(srv.Run(context.Background(), upgrade.StageBeforeCommit, "/root")).To(Succeed())
+	})
+	It("proceeds once all subscribers acknowledge", func() {
+		agent(fs, lifecycle.Subscription{Name: "drainer"}, lifecycle.Response{Verdict: lifecycle.Ack}, events)
+		agent(fs, lifecycle.Subscription{Name: "other", Stages: []upgrade.Stage{upgrade.StageBeforeSync}},
+			lifecycle.Response{Verdict: lifecycle.Veto}, events)
+		Eventually(srv.Subscribers).Should(ConsistOf("drainer", "other"))
+
+		Expect(srv.Run(context.Background(), upgrade.StageBeforeCommit, "/root")).To(Succeed())
+		Expect(events).To(Receive(Equal(lifecycle.Event{ID: 1, Stage: upgrade.StageBeforeCommit, Root: "/root", Wait: true})))
+		Expect(events).NotTo(Receive())
+	})
+	It("aborts the transaction on a veto", func() {
+		agent(fs, lifecycle.Subscription{Name: "drainer"}, lifecycle.Response{Verdict: lifecycle.Veto, Reason: "drain failed"}, events)
+		Eventually(srv.Subscribers).Should(HaveLen(1))
+
+		err := srv.Run(context.Background(), upgrade.StageBeforeCommit, "/root")
+		Expect(err).To(MatchError("vetoed by 'drainer': drain failed"))
+	})
+	It("delays the transaction", func() {
+		agent(fs, lifecycle.Subscription{Name: "drainer"}, lifecycle.Response{
+			Verdict: lifecycle.Delay, Reason: "draining", DelaySeconds: 30,
+		}, events)
+		Eventually(srv.Subscribers).Should(HaveLen(1))
+
+		err := srv.Run(context.Background(), upgrade.StageAfterMerge, "/root")
+		delay := &upgrade.DelayError{}
+		Expect(err).To(BeAssignableToTypeOf(delay))
+		Expect(err.(*upgrade.DelayError).Delay).To(Equal(30 * time.Second))
+	})
+	It("only notifies after commit", func() {
+		agent(fs, lifecycle.Subscription{Name: "drainer"}, lifecycle.Response{Verdict: lifecycle.Veto}, events)
+		Eventually(srv.Subscribers).Should(HaveLen(1))
+
+		Expect(srv.Run(context.Background(), upgrade.StageAfterCommit, "/root")).To(Succeed())
+		Eventually(events).Should(Receive(HaveField("Wait", false)))
+	})
+	It("fails if a subscriber does not respond", func() {
+		path, err := fs.RawPath(lifecycle.DefaultSocket)
+		Expect(err).NotTo(HaveOccurred())
+		conn, err := net.Dial("unix", path)
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		Expect(json.NewEncoder(conn).Encode(lifecycle.Subscription{Name: "silent"})).To(Succeed())
+		Eventually(srv.Subscribers).Should(HaveLen(1))
+
+		err = srv.Run(context.Background(), upgrade.StageBeforeSync, "/root")
+		Expect(err).To(MatchError("subscriber 'silent' did not respond to before-sync event"))
+	})
+	It("aborts the transaction if a subscriber disconnects", func() {
+		conn := agent(fs, lifecycle.Subscription{Name: "gone"}, lifecycle.Response{Verdict: lifecycle.Ack}, events)
+		Eventually(srv.Subscribers).Should(HaveLen(1))
+		Expect(conn.Close()).To(Succeed())
+
+		err := srv.Run(context.Background(), upgrade.StageBeforeSync, "/root")
+		Expect(err).To(MatchError("subscriber 'gone' disconnected before responding to before-sync event"))
+		Expect(srv.Subscribers()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Lifecycle server with an activated socket", Label("lifecycle"), func() {
+	var fs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("accepts subscribers on the given listener and keeps the socket", func() {
+		Expect(vfs.MkdirAll(fs, "/run/elemental", vfs.DirPerm)).To(Succeed())
+		path, err := fs.RawPath(lifecycle.DefaultSocket)
+		Expect(err).NotTo(HaveOccurred())
+		l, err := net.Listen("unix", path)
+		Expect(err).NotTo(HaveOccurred())
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+
+		// Agents can connect before the server starts
+		events := make(chan lifecycle.Event, 10)
+		agent(fs, lifecycle.Subscription{Name: "drainer"}, lifecycle.Response{Verdict: lifecycle.Ack}, events)
+
+		srv := lifecycle.NewServer(s, lifecycle.DefaultSocket, lifecycle.WithListener(l), lifecycle.WithTimeout(time.Second))
+		Expect(srv.Start()).To(Succeed())
+		Eventually(srv.Subscribers).Should(ConsistOf("drainer"))
+		Expect(srv.Run(context.Background(), upgrade.StageBeforeCommit, "/root")).To(Succeed())
+
+		Expect(srv.Close()).To(Succeed())
+		Expect(vfs.Exists(fs, lifecycle.DefaultSocket)).To(BeTrue())
+	})
+	It("finds no activated socket if none was passed", func() {
+		l, err := lifecycle.ActivatedListener(s, lifecycle.DefaultSocket)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l).To(BeNil())
+	})
+})
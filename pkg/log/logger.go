@@ -20,11 +20,27 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// Format is the format of the log records
+type Format int
+
+const (
+	// Text formats the records as human readable lines
+	Text Format = iota
+	// JSON formats the records as JSON objects, one per line, with the timestamp, level, component,
+	// message and fields keys
+	JSON
+)
+
+// ComponentKey is the field identifying the component emitting a record
+const ComponentKey = "component"
+
 // Logger is the interface we want for our logger, so we can plug different ones easily
 type Logger interface {
 	Info(string, ...any)
@@ -38,6 +54,21 @@ type Logger interface {
 	SetLevel(level uint32)
 	GetLevel() uint32
 	SetOutput(writer io.Writer)
+	SetFormat(format Format)
+
+	// WithComponent returns a logger tagging its records with the given component name, sharing
+	// the level, output and format with this logger
+	WithComponent(name string) Logger
+	// WithFields returns a logger adding the given fields to its records, sharing the level, output
+	// and format with this logger
+	WithFields(fields map[string]any) Logger
+
+	// RegisterSecret registers literal values to be redacted from the records of this logger and
+	// of the loggers sharing its output, typically credentials read from configuration files which
+	// do not follow any recognizable pattern. Only whole occurrences of the values are redacted.
+	RegisterSecret(values ...string)
+	// Redact masks the registered secrets and the well known secret patterns in the given string
+	Redact(s string) string
 }
 
 var _ Logger = (*logrusWrapper)(nil)
@@ -55,10 +86,12 @@ type LoggerOptions func(l *log.Logger)
 
 func New(opts ...LoggerOptions) Logger {
 	logger := log.New()
+	secrets := &secretStore{}
+	logger.AddHook(redactHook{secrets: secrets})
 	for _, o := range opts {
 		o(logger)
 	}
-	return newLogrusWrapper(logger)
+	return &logrusWrapper{Logger: logger, entry: log.NewEntry(logger), secrets: secrets}
 }
 
 // WithDiscardAll will set a logger that discards all logs, used mainly for testing
@@ -75,12 +108,84 @@ func WithBuffer(b *bytes.Buffer) LoggerOptions {
 	}
 }
 
+// WithFormat sets the format of the log records
+func WithFormat(format Format) LoggerOptions {
+	return func(l *log.Logger) {
+		setFormat(l, format)
+	}
+}
+
+func setFormat(l *log.Logger, format Format) {
+	switch format {
+	case JSON:
+		l.SetFormatter(jsonFormatter{})
+	default:
+		l.SetFormatter(&log.TextFormatter{})
+	}
+}
+
+type jsonRecord struct {
+	Timestamp string         `json:"timestamp"`
+	Level     string         `json:"level"`
+	Component string         `json:"component,omitempty"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// jsonFormatter formats logrus entries as jsonRecord objects
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(e *log.Entry) ([]byte, error) {
+	record := jsonRecord{
+		Timestamp: e.Time.Format(time.RFC3339Nano),
+		Level:     e.Level.String(),
+		Message:   e.Message,
+	}
+	for key, value := range e.Data {
+		if key == ComponentKey {
+			record.Component, _ = value.(string)
+			continue
+		}
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		if record.Fields == nil {
+			record.Fields = map[string]any{}
+		}
+		record.Fields[key] = value
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
 type logrusWrapper struct {
 	*log.Logger
+	entry   *log.Entry
+	secrets *secretStore
+}
+
+func (w *logrusWrapper) SetFormat(format Format) {
+	setFormat(w.Logger, format)
+}
+
+func (w *logrusWrapper) WithComponent(name string) Logger {
+	return &logrusWrapper{Logger: w.Logger, entry: w.entry.WithField(ComponentKey, name), secrets: w.secrets}
+}
+
+func (w *logrusWrapper) WithFields(fields map[string]any) Logger {
+	return &logrusWrapper{Logger: w.Logger, entry: w.entry.WithFields(fields), secrets: w.secrets}
+}
+
+func (w *logrusWrapper) RegisterSecret(values ...string) {
+	w.secrets.register(values...)
 }
 
-func newLogrusWrapper(l *log.Logger) Logger {
-	return &logrusWrapper{Logger: l}
+func (w *logrusWrapper) Redact(s string) string {
+	return w.secrets.redact(s)
 }
 
 func (w logrusWrapper) GetLevel() uint32 {
@@ -92,29 +197,29 @@ func (w *logrusWrapper) SetLevel(level uint32) {
 }
 
 func (w *logrusWrapper) Debug(msg string, args ...any) {
-	w.Debugf(msg, args...)
+	w.entry.Debugf(msg, args...)
 }
 
 func (w *logrusWrapper) Info(msg string, args ...any) {
-	w.Infof(msg, args...)
+	w.entry.Infof(msg, args...)
 }
 
 func (w *logrusWrapper) Warn(msg string, args ...any) {
-	w.Warnf(msg, args...)
+	w.entry.Warnf(msg, args...)
 }
 
 func (w *logrusWrapper) Error(msg string, args ...any) {
-	w.Errorf(msg, args...)
+	w.entry.Errorf(msg, args...)
 }
 
 func (w *logrusWrapper) Fatal(msg string, args ...any) {
-	w.Fatalf(msg, args...)
+	w.entry.Fatalf(msg, args...)
 }
 
 func (w *logrusWrapper) Panic(msg string, args ...any) {
-	w.Panicf(msg, args...)
+	w.entry.Panicf(msg, args...)
 }
 
 func (w *logrusWrapper) Trace(msg string, args ...any) {
-	w.Tracef(msg, args...)
+	w.entry.Tracef(msg, args...)
 }
@@ -0,0 +1,157 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//revive:disable:var-naming
+package log
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Redacted replaces secrets in redacted strings
+const Redacted = "******"
+
+// minSecretLen is the minimum length of registered secrets, shorter values are ignored to
+// avoid masking unrelated text
+const minSecretLen = 4
+
+const secretKey = `[\w.-]*(?:password|passwd|token|secret|api[_-]?key|credentials?)[\w.-]*`
+
+var (
+	// key=value, key: value and "key": "value" pairs
+	secretPairRegexp = regexp.MustCompile(`(?i)(` + secretKey + `"?\s*[=:]\s*"?)([^\s"',}]+)`)
+	// --key value command line flags
+	secretFlagRegexp = regexp.MustCompile(`(?i)(\s--?` + secretKey + `\s+)([^\s-]\S*)`)
+	// base64 encoded credentials of docker auth files
+	dockerAuthRegexp = regexp.MustCompile(`(?i)("auth"\s*:\s*")([^"]+)`)
+	// user:password@host URL credentials
+	urlUserRegexp = regexp.MustCompile(`([a-zA-Z][\w+.-]*://[^/\s:@]+):[^/\s@]+@`)
+	// crypt(3) password hashes, as used in /etc/shadow
+	cryptHashRegexp = regexp.MustCompile(`\$(?:1|2[abxy]|5|6|y|gy|7)\$[./\w$=,]+`)
+	// command line flags expecting a secret value as the following argument
+	secretArgRegexp = regexp.MustCompile(`(?i)^--?` + secretKey + `$`)
+)
+
+// secretStore holds the literal secrets registered to a logger and the loggers derived from it
+type secretStore struct {
+	sync.RWMutex
+	values []string
+}
+
+// register adds the given values to the store, values shorter than minSecretLen are ignored
+func (st *secretStore) register(values ...string) {
+	st.Lock()
+	defer st.Unlock()
+
+	for _, v := range values {
+		if len(v) >= minSecretLen && !slices.Contains(st.values, v) {
+			st.values = append(st.values, v)
+		}
+	}
+	// replace longer secrets first, so secrets including others are fully masked
+	slices.SortFunc(st.values, func(a, b string) int { return len(b) - len(a) })
+}
+
+// redact masks the registered secrets and the well known secret patterns in the given string
+func (st *secretStore) redact(s string) string {
+	st.RLock()
+	for _, v := range st.values {
+		s = replaceWhole(s, v, Redacted)
+	}
+	st.RUnlock()
+	return Redact(s)
+}
+
+// replaceWhole replaces the occurrences of value in s which are not part of a longer word,
+// so a secret which happens to be a substring of unrelated text is not masked
+func replaceWhole(s, value, replacement string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, value)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end := i + len(value)
+		whole := (i == 0 || !isWordByte(s[i-1])) && (end == len(s) || !isWordByte(s[end]))
+		b.WriteString(s[:i])
+		if whole {
+			b.WriteString(replacement)
+		} else {
+			b.WriteString(value)
+		}
+		s = s[end:]
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// Redact masks values of password, token or secret keys and flags, URL credentials and password
+// hashes in the given string. Secrets registered to a logger are only masked by Logger.Redact.
+func Redact(s string) string {
+	s = urlUserRegexp.ReplaceAllString(s, "$1:"+Redacted+"@")
+	s = cryptHashRegexp.ReplaceAllString(s, Redacted)
+	s = dockerAuthRegexp.ReplaceAllString(s, "${1}"+Redacted)
+	s = secretPairRegexp.ReplaceAllString(s, "${1}"+Redacted)
+	return secretFlagRegexp.ReplaceAllString(s, "${1}"+Redacted)
+}
+
+// RedactArgs returns a redacted copy of the given command arguments. Arguments following
+// a secret flag, such as '--password', are masked.
+func RedactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if i > 0 && secretArgRegexp.MatchString(args[i-1]) {
+			redacted[i] = Redacted
+			continue
+		}
+		redacted[i] = Redact(arg)
+	}
+	return redacted
+}
+
+// redactHook redacts the message and the string and error fields of all log records
+type redactHook struct {
+	secrets *secretStore
+}
+
+func (redactHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h redactHook) Fire(e *log.Entry) error {
+	// entries are duplicated before firing hooks, so they can be modified in place
+	e.Message = h.secrets.redact(e.Message)
+	for key, value := range e.Data {
+		switch v := value.(type) {
+		case string:
+			e.Data[key] = h.secrets.redact(v)
+		case error:
+			if msg := v.Error(); h.secrets.redact(msg) != msg {
+				e.Data[key] = h.secrets.redact(msg)
+			}
+		}
+	}
+	return nil
+}
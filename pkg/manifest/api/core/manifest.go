@@ -46,8 +46,9 @@ type OperatingSystem struct {
 }
 
 type Kubernetes struct {
-	Version string `yaml:"version" validate:"required"`
-	Image   string `yaml:"image" validate:"required"`
+	Version string   `yaml:"version" validate:"required"`
+	Image   string   `yaml:"image" validate:"required"`
+	Images  []string `yaml:"images,omitempty"`
 }
 
 type Image struct {
@@ -66,9 +66,17 @@ const (
 )
 
 type Metadata struct {
-	Name         string `yaml:"name" validate:"required"`
-	Version      string `yaml:"version" validate:"required"`
-	CreationDate string `yaml:"creationDate,omitempty"`
+	Name         string   `yaml:"name" validate:"required"`
+	Version      string   `yaml:"version" validate:"required"`
+	CreationDate string   `yaml:"creationDate,omitempty"`
+	Upgrade      *Upgrade `yaml:"upgrade,omitempty"`
+}
+
+// Upgrade describes the installed releases supported to be upgraded to the release
+type Upgrade struct {
+	// MinVersion is the oldest installed release version that can be upgraded
+	// directly to this release. Older releases need to go through intermediate releases.
+	MinVersion string `yaml:"minVersion,omitempty"`
 }
 
 type Helm struct {
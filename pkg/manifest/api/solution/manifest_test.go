@@ -78,6 +78,8 @@ var _ = Describe("ReleaseManifest", Label("release-manifest"), func() {
 		Expect(rm.Metadata.Name).To(Equal("suse-edge"))
 		Expect(rm.Metadata.Version).To(Equal("3.2.0"))
 		Expect(rm.Metadata.CreationDate).To(Equal("2025-01-20"))
+		Expect(rm.Metadata.Upgrade).NotTo(BeNil())
+		Expect(rm.Metadata.Upgrade.MinVersion).To(Equal("3.1.0"))
 
 		Expect(rm.CorePlatform).ToNot(BeNil())
 		Expect(rm.CorePlatform.Image).To(Equal("foo.example.com/bar/release-manifest:1.0"))
@@ -0,0 +1,368 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/api/solution"
+	"github.com/suse/elemental/v3/pkg/registry"
+)
+
+const (
+	KindCore     = "core"
+	KindSolution = "solution"
+)
+
+// ArtifactChecker verifies that the artifacts referenced by a release manifest exist
+type ArtifactChecker interface {
+	// CheckImage verifies the given OCI image reference can be pulled
+	CheckImage(ctx context.Context, ref string) error
+	// CheckURL verifies the given HTTP URL can be downloaded
+	CheckURL(ctx context.Context, url string) error
+}
+
+// Report is the result of linting a release manifest. Errors make the manifest unusable, warnings
+// point to references that can only be verified once the manifest is resolved.
+type Report struct {
+	Kind     string   `yaml:"kind,omitempty"`
+	Errors   []string `yaml:"errors,omitempty"`
+	Warnings []string `yaml:"warnings,omitempty"`
+}
+
+func (r *Report) OK() bool {
+	return len(r.Errors) == 0
+}
+
+func (r *Report) errorf(format string, args ...any) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+func (r *Report) warnf(format string, args ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// CoreReader reads the core platform release manifest stored in the given OCI image
+type CoreReader func(ctx context.Context, image string) ([]byte, error)
+
+type LinterOpt func(*Linter)
+
+// Linter validates release manifests against the manifest API types and checks the consistency
+// of the references between their components
+type Linter struct {
+	checker    ArtifactChecker
+	coreReader CoreReader
+}
+
+// WithArtifactChecker sets the checker used to resolve the referenced artifacts. Artifacts are
+// not resolved if no checker is set.
+func WithArtifactChecker(c ArtifactChecker) LinterOpt {
+	return func(l *Linter) {
+		l.checker = c
+	}
+}
+
+// WithCoreReader sets the reader of the core platform release manifests referenced by solution
+// manifests. The core platform manifest is then linted too and the references of the solution
+// manifest are resolved against it. Otherwise these references are only reported as warnings.
+func WithCoreReader(r CoreReader) LinterOpt {
+	return func(l *Linter) {
+		l.coreReader = r
+	}
+}
+
+func NewLinter(opts ...LinterOpt) *Linter {
+	l := &Linter{}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// Lint validates the given release manifest. Manifests defining a core platform reference are
+// validated as solution manifests, any other manifest is validated as a core manifest.
+func (l Linter) Lint(ctx context.Context, data []byte) *Report {
+	report := &Report{}
+	if _, err := api.LoadSchemaVersion(data); err != nil {
+		report.errorf("%v", err)
+		return report
+	}
+
+	if isSolution(data) {
+		report.Kind = KindSolution
+		rm, err := solution.Parse(data)
+		if err != nil {
+			report.errorf("%v", err)
+			return report
+		}
+		l.lintSolution(ctx, rm, report)
+		return report
+	}
+
+	report.Kind = KindCore
+	rm, err := core.Parse(data)
+	if err != nil {
+		report.errorf("%v", err)
+		return report
+	}
+	l.lintCore(ctx, rm, report)
+	return report
+}
+
+func (l Linter) lintCore(ctx context.Context, rm *core.ReleaseManifest, report *Report) {
+	c := rm.Components
+	lintReferences(c.Systemd, c.Helm, nil, false, report)
+
+	l.checkImage(ctx, "operating system base image", c.OperatingSystem.Image.Base, report)
+	l.checkImage(ctx, "operating system ISO image", c.OperatingSystem.Image.ISO, report)
+	if c.Kubernetes != nil {
+		l.checkImage(ctx, "kubernetes image", c.Kubernetes.Image, report)
+	}
+	l.checkArtifacts(ctx, c.Systemd, c.Helm, report)
+}
+
+func (l Linter) lintSolution(ctx context.Context, rm *solution.ReleaseManifest, report *Report) {
+	c := rm.Components
+	base := l.lintCorePlatform(ctx, rm.CorePlatform.Image, report)
+	lintReferences(c.Systemd, c.Helm, base, base == nil, report)
+
+	l.checkImage(ctx, "core platform image", rm.CorePlatform.Image, report)
+	l.checkArtifacts(ctx, c.Systemd, c.Helm, report)
+}
+
+// lintCorePlatform reads and lints the core platform release manifest of a solution manifest, its
+// findings are added to the report. It returns nil if no core reader is set or the manifest is invalid.
+func (l Linter) lintCorePlatform(ctx context.Context, image string, report *Report) *core.Components {
+	if l.coreReader == nil {
+		return nil
+	}
+
+	data, err := l.coreReader(ctx, image)
+	if err != nil {
+		report.errorf("reading core platform release manifest '%s': %v", image, err)
+		return nil
+	}
+
+	coreReport := &Report{}
+	rm, err := core.Parse(data)
+	if err == nil {
+		l.lintCore(ctx, rm, coreReport)
+	} else {
+		coreReport.errorf("%v", err)
+	}
+	for _, e := range coreReport.Errors {
+		report.errorf("core platform: %s", e)
+	}
+	for _, w := range coreReport.Warnings {
+		report.warnf("core platform: %s", w)
+	}
+
+	if err != nil {
+		return nil
+	}
+	return &rm.Components
+}
+
+// lintReferences checks for duplicated components and for references to undefined repositories
+// and dependencies. References can be resolved against the components of the given core platform.
+// Solution manifests can refer to components of an unknown core platform, hence these references
+// are only reported as warnings if solution is set.
+func lintReferences(systemd api.Systemd, helm *api.Helm, base *core.Components, solution bool, report *Report) {
+	unresolved := report.errorf
+	if solution {
+		unresolved = report.warnf
+	}
+
+	extensions := map[string]bool{}
+	for _, e := range systemd.Extensions {
+		if extensions[e.Name] {
+			report.errorf("systemd extension '%s' is defined more than once", e.Name)
+		}
+		extensions[e.Name] = true
+	}
+
+	if helm == nil {
+		return
+	}
+
+	repositories := map[string]bool{}
+	for _, r := range helm.Repositories {
+		if repositories[r.Name] {
+			report.errorf("helm repository '%s' is defined more than once", r.Name)
+		}
+		repositories[r.Name] = true
+	}
+
+	charts := map[string]bool{}
+	for _, c := range helm.Charts {
+		if charts[c.Chart] {
+			report.errorf("helm chart '%s' is defined more than once", c.Chart)
+		}
+		charts[c.Chart] = true
+	}
+
+	if base != nil {
+		for _, e := range base.Systemd.Extensions {
+			extensions[e.Name] = true
+		}
+		if base.Helm != nil {
+			for _, r := range base.Helm.Repositories {
+				repositories[r.Name] = true
+			}
+			for _, c := range base.Helm.Charts {
+				charts[c.Chart] = true
+			}
+		}
+	}
+
+	for _, c := range helm.Charts {
+		if c.Repository != "" && !repositories[c.Repository] {
+			unresolved("helm chart '%s' refers to undefined repository '%s'", c.Chart, c.Repository)
+		}
+		for _, d := range c.DependsOn {
+			switch {
+			case d.Type == api.DependencyTypeHelm && !charts[d.Name]:
+				unresolved("helm chart '%s' depends on undefined helm chart '%s'", c.Chart, d.Name)
+			case d.Type == api.DependencyTypeExtension && !extensions[d.Name]:
+				unresolved("helm chart '%s' depends on undefined systemd extension '%s'", c.Chart, d.Name)
+			}
+		}
+		for _, i := range c.Images {
+			if _, err := name.ParseReference(i.Image); err != nil {
+				report.errorf("helm chart '%s' image '%s' is not a valid image reference: %v", c.Chart, i.Image, err)
+			}
+		}
+	}
+}
+
+// checkArtifacts resolves the systemd extensions, helm chart images and helm repositories
+func (l Linter) checkArtifacts(ctx context.Context, systemd api.Systemd, helm *api.Helm, report *Report) {
+	for _, e := range systemd.Extensions {
+		if isHTTP(e.Image) {
+			l.checkURL(ctx, fmt.Sprintf("systemd extension '%s'", e.Name), e.Image, report)
+			continue
+		}
+		l.checkImage(ctx, fmt.Sprintf("systemd extension '%s'", e.Name), e.Image, report)
+	}
+
+	if helm == nil {
+		return
+	}
+	for _, c := range helm.Charts {
+		for _, i := range c.Images {
+			l.checkImage(ctx, fmt.Sprintf("helm chart '%s' image '%s'", c.Chart, i.Name), i.Image, report)
+		}
+	}
+	for _, r := range helm.Repositories {
+		if isHTTP(r.URL) {
+			index := strings.TrimSuffix(r.URL, "/") + "/index.yaml"
+			l.checkURL(ctx, fmt.Sprintf("helm repository '%s'", r.Name), index, report)
+		}
+	}
+}
+
+func (l Linter) checkImage(ctx context.Context, what, ref string, report *Report) {
+	if l.checker == nil || ref == "" {
+		return
+	}
+	if err := l.checker.CheckImage(ctx, ref); err != nil {
+		report.errorf("%s '%s' can't be resolved: %v", what, ref, err)
+	}
+}
+
+func (l Linter) checkURL(ctx context.Context, what, u string, report *Report) {
+	if l.checker == nil {
+		return
+	}
+	if err := l.checker.CheckURL(ctx, u); err != nil {
+		report.errorf("%s '%s' can't be resolved: %v", what, u, err)
+	}
+}
+
+// isSolution reports whether the given manifest references a core platform
+func isSolution(data []byte) bool {
+	var header struct {
+		CorePlatform any `yaml:"corePlatform"`
+	}
+	return yaml.Unmarshal(data, &header) == nil && header.CorePlatform != nil
+}
+
+func isHTTP(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// RegistryChecker resolves OCI images against their registries and HTTP URLs with HEAD requests
+type RegistryChecker struct {
+	registry *registry.Config
+	client   *http.Client
+}
+
+func NewRegistryChecker(reg *registry.Config) *RegistryChecker {
+	return &RegistryChecker{registry: reg, client: http.DefaultClient}
+}
+
+func (c RegistryChecker) CheckImage(ctx context.Context, ref string) error {
+	refs, err := c.registry.References(ref)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, r := range refs {
+		_, err = remote.Head(r,
+			remote.WithTransport(c.registry.Transport(r.Context().RegistryStr())),
+			remote.WithAuthFromKeychain(c.registry.Keychain()),
+			remote.WithContext(ctx),
+		)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (c RegistryChecker) CheckURL(ctx context.Context, u string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
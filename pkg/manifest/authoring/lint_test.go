@@ -0,0 +1,164 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authoring_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/manifest/authoring"
+)
+
+type checkerMock struct {
+	missing []string
+	checked []string
+}
+
+func (c *checkerMock) CheckImage(_ context.Context, ref string) error {
+	return c.check(ref)
+}
+
+func (c *checkerMock) CheckURL(_ context.Context, url string) error {
+	return c.check(url)
+}
+
+func (c *checkerMock) check(artifact string) error {
+	c.checked = append(c.checked, artifact)
+	if slices.Contains(c.missing, artifact) {
+		return fmt.Errorf("not found")
+	}
+	return nil
+}
+
+const validCore = `schema: v0
+metadata:
+  name: suse-core
+  version: "1.0"
+components:
+  operatingSystem:
+    image:
+      base: registry.com/os-base:6.2
+      iso: registry.com/installer-iso:6.2
+  systemd:
+    extensions:
+      - name: ext
+        image: https://example.com/ext.raw
+  helm:
+    charts:
+      - chart: foo
+        version: 0.0.0
+        repository: foo-charts
+        dependsOn:
+          - name: ext
+            type: sysext
+    repositories:
+      - name: foo-charts
+        url: https://foo.github.io/charts
+`
+
+var _ = Describe("Linter", func() {
+	var checker *checkerMock
+	BeforeEach(func() {
+		checker = &checkerMock{}
+	})
+	It("lints a valid core manifest resolving its artifacts", func() {
+		report := authoring.NewLinter(authoring.WithArtifactChecker(checker)).Lint(context.Background(), []byte(validCore))
+		Expect(report.OK()).To(BeTrue(), "%v", report.Errors)
+		Expect(report.Kind).To(Equal(authoring.KindCore))
+		Expect(report.Warnings).To(BeEmpty())
+		Expect(checker.checked).To(ConsistOf(
+			"registry.com/os-base:6.2", "registry.com/installer-iso:6.2", "https://example.com/ext.raw",
+			"https://foo.github.io/charts/index.yaml",
+		))
+	})
+	It("reports missing artifacts", func() {
+		checker.missing = []string{"registry.com/installer-iso:6.2"}
+		report := authoring.NewLinter(authoring.WithArtifactChecker(checker)).Lint(context.Background(), []byte(validCore))
+		Expect(report.OK()).To(BeFalse())
+		Expect(report.Errors).To(ConsistOf(
+			"operating system ISO image 'registry.com/installer-iso:6.2' can't be resolved: not found",
+		))
+	})
+	It("reports undefined references of a core manifest as errors", func() {
+		data, err := os.ReadFile(filepath.Join("..", "testdata", "full_core_release_manifest.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		report := authoring.NewLinter().Lint(context.Background(), data)
+		Expect(report.Kind).To(Equal(authoring.KindCore))
+		Expect(report.Errors).To(ConsistOf("helm chart 'foo' depends on undefined helm chart 'baz'"))
+	})
+	It("reports undefined references of a solution manifest as warnings", func() {
+		data, err := os.ReadFile(filepath.Join("..", "testdata", "full_solution_release_manifest.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		report := authoring.NewLinter(authoring.WithArtifactChecker(checker)).Lint(context.Background(), data)
+		Expect(report.OK()).To(BeTrue(), "%v", report.Errors)
+		Expect(report.Kind).To(Equal(authoring.KindSolution))
+		Expect(report.Warnings).To(ConsistOf(
+			"helm chart 'bar' depends on undefined helm chart 'foo'",
+			"helm chart 'bar' depends on undefined systemd extension 'bar'",
+		))
+		Expect(checker.checked).To(ContainElements(
+			"foo.example.com/bar/release-manifest:1.0", "registry.com/bar/bar:0.0.0",
+		))
+	})
+	It("resolves the references of a solution manifest against its core platform", func() {
+		data, err := os.ReadFile(filepath.Join("..", "testdata", "full_solution_release_manifest.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		coreData, err := os.ReadFile(filepath.Join("..", "testdata", "full_core_release_manifest.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		var read string
+		reader := func(_ context.Context, image string) ([]byte, error) {
+			read = image
+			return coreData, nil
+		}
+
+		report := authoring.NewLinter(authoring.WithCoreReader(reader)).Lint(context.Background(), data)
+		Expect(read).To(Equal("foo.example.com/bar/release-manifest:1.0"))
+		Expect(report.Kind).To(Equal(authoring.KindSolution))
+		Expect(report.Warnings).To(BeEmpty())
+		Expect(report.Errors).To(ConsistOf(
+			"core platform: helm chart 'foo' depends on undefined helm chart 'baz'",
+			"helm chart 'bar' depends on undefined systemd extension 'bar'",
+		))
+
+		reader = func(_ context.Context, image string) ([]byte, error) {
+			return nil, fmt.Errorf("not found")
+		}
+		report = authoring.NewLinter(authoring.WithCoreReader(reader)).Lint(context.Background(), data)
+		Expect(report.Errors).To(ContainElement(
+			"reading core platform release manifest 'foo.example.com/bar/release-manifest:1.0': not found",
+		))
+	})
+	It("reports schema violations and duplicates", func() {
+		report := authoring.NewLinter().Lint(context.Background(), []byte("schema: v0\ncomponents: {}\n"))
+		Expect(report.OK()).To(BeFalse())
+		Expect(report.Errors[0]).To(ContainSubstring("validating 'core' release manifest"))
+
+		duplicated := validCore + "      - name: foo-charts\n        url: https://foo.example.com/charts\n"
+		report = authoring.NewLinter().Lint(context.Background(), []byte(duplicated))
+		Expect(report.Errors).To(ConsistOf("helm repository 'foo-charts' is defined more than once"))
+	})
+})
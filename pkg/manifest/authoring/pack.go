@@ -0,0 +1,158 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authoring
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/registry"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// ManifestFile is the release manifest file name within OCI images, it matches the file
+// patterns searched when resolving release manifests from OCI images
+const ManifestFile = "release_manifest.yaml"
+
+// Image returns a single layer OCI image holding the given release manifest at the root of
+// its file system. The image is labeled with the manifest metadata.
+func Image(data []byte) (v1.Image, error) {
+	var header struct {
+		Metadata *api.Metadata `yaml:"metadata"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("reading release manifest metadata: %w", err)
+	}
+
+	created := time.Now().UTC()
+	layerData, err := manifestLayer(data, created)
+	if err != nil {
+		return nil, fmt.Errorf("archiving release manifest: %w", err)
+	}
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(layerData)), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating image layer: %w", err)
+	}
+
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		return nil, fmt.Errorf("appending image layer: %w", err)
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("reading image config: %w", err)
+	}
+	cfg = cfg.DeepCopy()
+	cfg.Created = v1.Time{Time: created}
+	cfg.Config.Labels = map[string]string{}
+	if header.Metadata != nil {
+		cfg.Config.Labels["org.opencontainers.image.title"] = header.Metadata.Name
+		cfg.Config.Labels["org.opencontainers.image.version"] = header.Metadata.Version
+	}
+	img, err = mutate.ConfigFile(img, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("setting image config: %w", err)
+	}
+	return img, nil
+}
+
+// WriteImage writes the given image as an OCI image tarball loadable with 'podman load'
+func WriteImage(fs vfs.FS, img v1.Image, tag, output string) error {
+	ref, err := name.NewTag(tag)
+	if err != nil {
+		return fmt.Errorf("parsing image tag '%s': %w", tag, err)
+	}
+
+	f, err := fs.Create(output)
+	if err != nil {
+		return fmt.Errorf("creating image file '%s': %w", output, err)
+	}
+	defer f.Close()
+
+	err = tarball.Write(ref, img, f)
+	if err != nil {
+		return fmt.Errorf("writing image '%s': %w", output, err)
+	}
+	return nil
+}
+
+// PushImage pushes the given image to the given reference and returns the pushed image digest
+func PushImage(ctx context.Context, img v1.Image, imageRef string, reg *registry.Config) (string, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err == nil && reg.IsInsecure(ref.Context().RegistryStr()) {
+		ref, err = name.ParseReference(imageRef, name.Insecure)
+	}
+	if err != nil {
+		return "", fmt.Errorf("parsing image reference '%s': %w", imageRef, err)
+	}
+
+	err = remote.Write(ref, img,
+		remote.WithTransport(reg.Transport(ref.Context().RegistryStr())),
+		remote.WithAuthFromKeychain(reg.Keychain()),
+		remote.WithContext(ctx),
+	)
+	if err != nil {
+		return "", fmt.Errorf("pushing image '%s': %w", ref.Name(), err)
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return "", fmt.Errorf("computing image digest: %w", err)
+	}
+	return digest.String(), nil
+}
+
+// manifestLayer returns a tarball including the given release manifest
+func manifestLayer(data []byte, modTime time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     ManifestFile,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tw.Write(data); err != nil {
+		return nil, err
+	}
+	if err = tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
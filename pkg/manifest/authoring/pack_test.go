@@ -0,0 +1,99 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authoring_test
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	containerregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+
+	"github.com/suse/elemental/v3/pkg/manifest/authoring"
+	"github.com/suse/elemental/v3/pkg/registry"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+)
+
+// manifestFromImage returns the release manifest file stored in the given image
+func manifestFromImage(img v1.Image) string {
+	layers, err := img.Layers()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(layers).To(HaveLen(1))
+	rc, err := layers[0].Uncompressed()
+	Expect(err).NotTo(HaveOccurred())
+	defer rc.Close()
+
+	tr := tar.NewReader(rc)
+	hdr, err := tr.Next()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(hdr.Name).To(Equal(authoring.ManifestFile))
+	data, err := io.ReadAll(tr)
+	Expect(err).NotTo(HaveOccurred())
+	return string(data)
+}
+
+var _ = Describe("Pack", func() {
+	It("writes the release manifest as an OCI image tarball", func() {
+		fs, cleanup, err := sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer cleanup()
+
+		img, err := authoring.Image([]byte(validCore))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(authoring.WriteImage(fs, img, "localhost/release-manifest:1.0", "/manifest.tar")).To(Succeed())
+
+		loaded, err := tarball.Image(func() (io.ReadCloser, error) { return fs.Open("/manifest.tar") }, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifestFromImage(loaded)).To(Equal(validCore))
+		cfg, err := loaded.ConfigFile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Config.Labels).To(HaveKeyWithValue("org.opencontainers.image.title", "suse-core"))
+		Expect(cfg.Config.Labels).To(HaveKeyWithValue("org.opencontainers.image.version", "1.0"))
+	})
+	It("pushes the release manifest image to a registry", func() {
+		server := httptest.NewServer(containerregistry.New(containerregistry.Logger(log.New(io.Discard, "", 0))))
+		defer server.Close()
+		host := strings.TrimPrefix(server.URL, "http://")
+		reg := &registry.Config{Insecure: []string{host}}
+
+		img, err := authoring.Image([]byte(validCore))
+		Expect(err).NotTo(HaveOccurred())
+		digest, err := authoring.PushImage(context.Background(), img, host+"/release-manifest:1.0", reg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(HavePrefix("sha256:"))
+
+		ref, err := name.ParseReference(host+"/release-manifest:1.0", name.Insecure)
+		Expect(err).NotTo(HaveOccurred())
+		pulled, err := remote.Image(ref)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifestFromImage(pulled)).To(Equal(validCore))
+
+		Expect(authoring.NewRegistryChecker(reg).CheckImage(context.Background(), host+"/release-manifest:1.0")).To(Succeed())
+		Expect(authoring.NewRegistryChecker(reg).CheckImage(context.Background(), host+"/missing:1.0")).NotTo(Succeed())
+	})
+})
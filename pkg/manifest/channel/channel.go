@@ -0,0 +1,261 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package channel
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-playground/validator/v10"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+)
+
+const (
+	// Latest resolves to the highest release version listed in the index
+	Latest = "latest"
+	// Stable resolves to the release pinned by the 'stable' channel of the index,
+	// or to the highest release version that is not a pre-release
+	Stable = "stable"
+)
+
+// Index lists the published releases of a product and the channels pointing to them
+type Index struct {
+	Schema   api.SchemaVersion `yaml:"schema,omitempty"`
+	Channels map[string]string `yaml:"channels,omitempty"`
+	Releases []Release         `yaml:"releases" validate:"required,dive"`
+}
+
+// Release describes a single release listed in the index
+type Release struct {
+	// Version of the release
+	Version string `yaml:"version" validate:"required"`
+	// Manifest is the source URI of the release manifest (e.g. oci://registry.example.com/release-manifest:1.0)
+	Manifest string `yaml:"manifest" validate:"required"`
+	// MinUpgradeVersion is the oldest installed release version that can be upgraded
+	// directly to this release. It mirrors the 'metadata.upgrade.minVersion' of the release manifest.
+	MinUpgradeVersion string `yaml:"minUpgradeVersion,omitempty"`
+
+	version    *semver.Version
+	minUpgrade *semver.Version
+}
+
+func Parse(data []byte) (*Index, error) {
+	if _, err := api.LoadSchemaVersion(data); err != nil {
+		return nil, fmt.Errorf("parsing release channel index: %w", err)
+	}
+
+	index := &Index{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(index); err != nil {
+		return nil, fmt.Errorf("unmarshaling release channel index: %w", err)
+	}
+
+	if err := api.NewValidator(api.WithYAMLFieldNames()).Struct(index); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			err = api.FormatErrors(validationErrors)
+		}
+
+		return nil, fmt.Errorf("validating release channel index: %w", err)
+	}
+
+	if err := index.parseVersions(); err != nil {
+		return nil, fmt.Errorf("validating release channel index: %w", err)
+	}
+
+	return index, nil
+}
+
+func (i *Index) parseVersions() (err error) {
+	for n := range i.Releases {
+		r := &i.Releases[n]
+
+		r.version, err = semver.NewVersion(r.Version)
+		if err != nil {
+			return fmt.Errorf("invalid release version '%s': %w", r.Version, err)
+		}
+
+		if r.MinUpgradeVersion != "" {
+			r.minUpgrade, err = semver.NewVersion(r.MinUpgradeVersion)
+			if err != nil {
+				return fmt.Errorf("invalid minimum upgrade version '%s' of release '%s': %w", r.MinUpgradeVersion, r.Version, err)
+			}
+		}
+	}
+
+	slices.SortFunc(i.Releases, func(a, b Release) int {
+		return a.version.Compare(b.version)
+	})
+
+	for n := 1; n < len(i.Releases); n++ {
+		if i.Releases[n].version.Equal(i.Releases[n-1].version) {
+			return fmt.Errorf("release '%s' is listed more than once", i.Releases[n].Version)
+		}
+	}
+
+	for channel, version := range i.Channels {
+		if _, err := i.release(version); err != nil {
+			return fmt.Errorf("channel '%s': %w", channel, err)
+		}
+	}
+
+	return nil
+}
+
+// Resolve returns the release referenced by the given channel name or pinned version.
+// 'latest' and 'stable' are always available; any other channel must be defined in the index.
+func (i *Index) Resolve(ref string) (*Release, error) {
+	if version, ok := i.Channels[ref]; ok {
+		return i.release(version)
+	}
+
+	switch ref {
+	case Latest:
+		return i.latest(false)
+	case Stable:
+		return i.latest(true)
+	}
+
+	release, err := i.release(ref)
+	if err != nil {
+		return nil, fmt.Errorf("resolving release '%s': not a known channel nor a listed version", ref)
+	}
+
+	return release, nil
+}
+
+// UpgradePath computes the releases to go through, in order, to upgrade from the installed
+// version to the target one. Each step jumps to the most recent release that supports being
+// upgraded from the current one. Pre-releases are only gone through if the target is itself a
+// pre-release. An error is returned if the target can't be reached without skipping releases
+// marked as unsupported.
+func (i *Index) UpgradePath(installed, target string) ([]Release, error) {
+	from, err := semver.NewVersion(installed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid installed version '%s': %w", installed, err)
+	}
+
+	to, err := i.release(target)
+	if err != nil {
+		return nil, err
+	}
+
+	switch from.Compare(to.version) {
+	case 0:
+		return nil, nil
+	case 1:
+		return nil, fmt.Errorf("downgrading from '%s' to '%s' is not supported", installed, to.Version)
+	}
+
+	var path []Release
+
+	preRelease := to.version.Prerelease() != ""
+	current := from
+	for !current.Equal(to.version) {
+		next := -1
+		for n, r := range i.Releases {
+			if !r.version.GreaterThan(current) || r.version.GreaterThan(to.version) {
+				continue
+			}
+			if !preRelease && r.version.Prerelease() != "" {
+				continue
+			}
+			if r.minUpgrade == nil || !r.minUpgrade.GreaterThan(current) {
+				next = n
+			}
+		}
+
+		if next < 0 {
+			return nil, fmt.Errorf("upgrading from '%s' to '%s' is not supported: no release supports being upgraded from '%s'", installed, to.Version, current.Original())
+		}
+
+		path = append(path, i.Releases[next])
+		current = i.Releases[next].version
+	}
+
+	return path, nil
+}
+
+// CheckUpgrade verifies that the release described by the given metadata supports being
+// upgraded to directly from the installed version.
+func CheckUpgrade(installed string, metadata *api.Metadata) error {
+	if metadata == nil {
+		return nil
+	}
+
+	from, err := semver.NewVersion(installed)
+	if err != nil {
+		return fmt.Errorf("invalid installed version '%s': %w", installed, err)
+	}
+
+	to, err := semver.NewVersion(metadata.Version)
+	if err != nil {
+		return fmt.Errorf("invalid release version '%s': %w", metadata.Version, err)
+	}
+
+	if from.GreaterThan(to) {
+		return fmt.Errorf("downgrading from '%s' to '%s' is not supported", installed, metadata.Version)
+	}
+
+	if metadata.Upgrade == nil || metadata.Upgrade.MinVersion == "" {
+		return nil
+	}
+
+	minVersion, err := semver.NewVersion(metadata.Upgrade.MinVersion)
+	if err != nil {
+		return fmt.Errorf("invalid minimum upgrade version '%s': %w", metadata.Upgrade.MinVersion, err)
+	}
+
+	if from.LessThan(minVersion) {
+		return fmt.Errorf("upgrading from '%s' to '%s' is not supported: release requires at least '%s' to be installed", installed, metadata.Version, metadata.Upgrade.MinVersion)
+	}
+
+	return nil
+}
+
+func (i *Index) release(version string) (*Release, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release version '%s': %w", version, err)
+	}
+
+	for n := range i.Releases {
+		if i.Releases[n].version.Equal(v) {
+			return &i.Releases[n], nil
+		}
+	}
+
+	return nil, fmt.Errorf("release '%s' is not listed", version)
+}
+
+func (i *Index) latest(stable bool) (*Release, error) {
+	for n := len(i.Releases) - 1; n >= 0; n-- {
+		if !stable || i.Releases[n].version.Prerelease() == "" {
+			return &i.Releases[n], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no release available")
+}
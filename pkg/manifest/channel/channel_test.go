@@ -0,0 +1,210 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package channel_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/channel"
+)
+
+const index = `
+schema: v0
+channels:
+  stable: "1.2.0"
+releases:
+- version: "1.3.0-rc1"
+  manifest: "oci://registry.example.com/release-manifest:1.3.0-rc1"
+  minUpgradeVersion: "1.2.0"
+- version: "1.0.0"
+  manifest: "oci://registry.example.com/release-manifest:1.0.0"
+- version: "1.1.0"
+  manifest: "oci://registry.example.com/release-manifest:1.1.0"
+- version: "1.1.1"
+  manifest: "oci://registry.example.com/release-manifest:1.1.1"
+- version: "1.2.0"
+  manifest: "oci://registry.example.com/release-manifest:1.2.0"
+  minUpgradeVersion: "1.1.0"
+`
+
+var _ = Describe("Release channels", Label("release-manifest"), func() {
+	var idx *channel.Index
+
+	BeforeEach(func() {
+		var err error
+		idx, err = channel.Parse([]byte(index))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("Parse", func() {
+		It("sorts releases by version", func() {
+			var versions []string
+			for _, r := range idx.Releases {
+				versions = append(versions, r.Version)
+			}
+			Expect(versions).To(Equal([]string{"1.0.0", "1.1.0", "1.1.1", "1.2.0", "1.3.0-rc1"}))
+		})
+
+		It("fails on unknown fields", func() {
+			_, err := channel.Parse([]byte("releases: []\nfoo: bar\n"))
+			Expect(err).To(MatchError(ContainSubstring("unmarshaling release channel index")))
+		})
+
+		It("fails on missing releases", func() {
+			_, err := channel.Parse([]byte("channels:\n  stable: 1.0.0\n"))
+			Expect(err).To(MatchError(ContainSubstring(`field "Index.releases" is required`)))
+		})
+
+		It("fails on invalid versions", func() {
+			_, err := channel.Parse([]byte("releases:\n- version: foo\n  manifest: file:///foo.yaml\n"))
+			Expect(err).To(MatchError(ContainSubstring("invalid release version 'foo'")))
+		})
+
+		It("fails on duplicated releases", func() {
+			_, err := channel.Parse([]byte("releases:\n- version: 1.0.0\n  manifest: file:///a.yaml\n- version: v1.0.0\n  manifest: file:///b.yaml\n"))
+			Expect(err).To(MatchError(ContainSubstring("release 'v1.0.0' is listed more than once")))
+		})
+
+		It("fails on channels pointing to unlisted releases", func() {
+			_, err := channel.Parse([]byte("channels:\n  stable: 2.0.0\nreleases:\n- version: 1.0.0\n  manifest: file:///a.yaml\n"))
+			Expect(err).To(MatchError(ContainSubstring("channel 'stable': release '2.0.0' is not listed")))
+		})
+	})
+
+	Describe("Resolve", func() {
+		It("resolves the latest release", func() {
+			r, err := idx.Resolve(channel.Latest)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.Version).To(Equal("1.3.0-rc1"))
+		})
+
+		It("resolves the stable channel of the index", func() {
+			r, err := idx.Resolve(channel.Stable)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.Version).To(Equal("1.2.0"))
+			Expect(r.Manifest).To(Equal("oci://registry.example.com/release-manifest:1.2.0"))
+		})
+
+		It("resolves stable to the most recent non pre-release if not pinned", func() {
+			delete(idx.Channels, channel.Stable)
+			idx.Releases = idx.Releases[:len(idx.Releases)-1]
+			r, err := idx.Resolve(channel.Stable)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.Version).To(Equal("1.2.0"))
+		})
+
+		It("resolves pinned versions", func() {
+			r, err := idx.Resolve("v1.1.1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.Version).To(Equal("1.1.1"))
+		})
+
+		It("fails on unknown references", func() {
+			_, err := idx.Resolve("edge")
+			Expect(err).To(MatchError("resolving release 'edge': not a known channel nor a listed version"))
+			_, err = idx.Resolve("2.0.0")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("UpgradePath", func() {
+		It("jumps directly to the target when supported", func() {
+			path, err := idx.UpgradePath("1.1.0", "1.2.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(HaveLen(1))
+			Expect(path[0].Version).To(Equal("1.2.0"))
+		})
+
+		It("goes through intermediate releases on unsupported skip-level upgrades", func() {
+			path, err := idx.UpgradePath("1.0.0", "1.3.0-rc1")
+			Expect(err).NotTo(HaveOccurred())
+			var versions []string
+			for _, r := range path {
+				versions = append(versions, r.Version)
+			}
+			Expect(versions).To(Equal([]string{"1.1.1", "1.2.0", "1.3.0-rc1"}))
+		})
+
+		It("skips pre-releases when upgrading to a stable release", func() {
+			var err error
+			idx, err = channel.Parse([]byte(index + `- version: "1.2.0-rc1"
+  manifest: "oci://registry.example.com/release-manifest:1.2.0-rc1"
+`))
+			Expect(err).NotTo(HaveOccurred())
+
+			path, err := idx.UpgradePath("1.0.0", "1.2.0")
+			Expect(err).NotTo(HaveOccurred())
+			var versions []string
+			for _, r := range path {
+				versions = append(versions, r.Version)
+			}
+			Expect(versions).To(Equal([]string{"1.1.1", "1.2.0"}))
+
+			path, err = idx.UpgradePath("1.0.0", "1.2.0-rc1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(HaveLen(1))
+			Expect(path[0].Version).To(Equal("1.2.0-rc1"))
+		})
+
+		It("returns an empty path if the target is already installed", func() {
+			path, err := idx.UpgradePath("1.2.0", "1.2.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(BeEmpty())
+		})
+
+		It("refuses downgrades", func() {
+			_, err := idx.UpgradePath("1.2.0", "1.1.0")
+			Expect(err).To(MatchError("downgrading from '1.2.0' to '1.1.0' is not supported"))
+		})
+
+		It("refuses upgrades that can't be reached through listed releases", func() {
+			idx.Releases = idx.Releases[3:]
+			_, err := idx.UpgradePath("1.0.0", "1.2.0")
+			Expect(err).To(MatchError("upgrading from '1.0.0' to '1.2.0' is not supported: no release supports being upgraded from '1.0.0'"))
+		})
+	})
+
+	Describe("CheckUpgrade", func() {
+		var metadata *api.Metadata
+
+		BeforeEach(func() {
+			metadata = &api.Metadata{
+				Name:    "suse-edge",
+				Version: "1.2.0",
+				Upgrade: &api.Upgrade{MinVersion: "1.1.0"},
+			}
+		})
+
+		It("accepts supported upgrades", func() {
+			Expect(channel.CheckUpgrade("1.1.1", metadata)).To(Succeed())
+			Expect(channel.CheckUpgrade("1.0.0", &api.Metadata{Version: "1.2.0"})).To(Succeed())
+		})
+
+		It("refuses unsupported skip-level upgrades", func() {
+			Expect(channel.CheckUpgrade("1.0.0", metadata)).To(MatchError(
+				"upgrading from '1.0.0' to '1.2.0' is not supported: release requires at least '1.1.0' to be installed",
+			))
+		})
+
+		It("refuses downgrades", func() {
+			Expect(channel.CheckUpgrade("1.3.0", metadata)).To(MatchError(ContainSubstring("downgrading")))
+		})
+	})
+})
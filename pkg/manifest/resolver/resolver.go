@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/suse/elemental/v3/pkg/manifest/api"
 	"github.com/suse/elemental/v3/pkg/manifest/api/core"
 	"github.com/suse/elemental/v3/pkg/manifest/api/solution"
 	"github.com/suse/elemental/v3/pkg/manifest/source"
@@ -33,6 +34,17 @@ type ResolvedManifest struct {
 	SolutionExtension *solution.ReleaseManifest
 }
 
+// Metadata returns the release metadata of the solution, or of the core platform if there is no solution
+func (r *ResolvedManifest) Metadata() *api.Metadata {
+	if r.SolutionExtension != nil {
+		return r.SolutionExtension.Metadata
+	}
+	if r.CorePlatform != nil {
+		return r.CorePlatform.Metadata
+	}
+	return nil
+}
+
 type SourceReader interface {
 	// Read reads a release manifest from the given source and returns the file contents
 	Read(m *source.ReleaseManifestSource) ([]byte, error)
@@ -149,6 +149,11 @@ func validateResolvedManifest(rm *resolver.ResolvedManifest, coreOnly bool) {
 	Expect(rm.CorePlatform.Metadata.Name).To(Equal("suse-core"))
 	Expect(rm.CorePlatform.Metadata.Version).To(Equal("1.0"))
 	Expect(rm.CorePlatform.Metadata.CreationDate).To(Equal("2000-01-01"))
+	if coreOnly {
+		Expect(rm.Metadata().Version).To(Equal("1.0"))
+	} else {
+		Expect(rm.Metadata().Version).To(Equal("3.2.0"))
+	}
 
 	Expect(rm.CorePlatform.Components).ToNot(BeNil())
 	Expect(rm.CorePlatform.Components.OperatingSystem).ToNot(BeNil())
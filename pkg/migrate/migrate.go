@@ -0,0 +1,252 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/relayout"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/rsync"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+const initrdMnt = "x-initrd.mount"
+
+// Volume describes the dedicated partition a shared RW volume of the system partition is moved to, or
+// the new size of the dedicated partition the volume is already mounted from
+type Volume struct {
+	Path       string
+	Label      string
+	Size       deployment.MiB
+	FileSystem deployment.FileSystem
+}
+
+// Migrator moves the data of a shared RW volume of the system partition onto a new dedicated partition
+// of the system disk. The new partition is only mounted by the snapshot created by the upgrade transaction
+// the migration is part of, previous snapshots keep mounting the RW volume, which is left untouched.
+// Volumes already mounted from a dedicated partition are resized instead, as long as it is the last
+// partition of its disk.
+type Migrator struct {
+	ctx       context.Context
+	s         *sys.System
+	volume    Volume
+	partition *deployment.Partition
+	created   *block.Partition
+	resize    *relayout.Relayouter
+}
+
+func New(ctx context.Context, s *sys.System, volume Volume) *Migrator {
+	volume.Path = filepath.Clean(volume.Path)
+	if volume.Label == "" {
+		volume.Label = strings.ToUpper(filepath.Base(volume.Path))
+	}
+	if volume.FileSystem == deployment.FileSystem(0) {
+		volume.FileSystem = deployment.XFS
+	}
+	return &Migrator{ctx: ctx, s: s.WithComponent("migrate"), volume: volume}
+}
+
+// Plan updates the given deployment to mount the volume from the new partition. The RW volume is
+// dropped from the system partition and the new partition is appended to the system disk. If the
+// volume is already mounted from a dedicated partition the deployment is updated to its new size.
+func (m *Migrator) Plan(d *deployment.Deployment) error {
+	for _, disk := range d.Disks {
+		for _, part := range disk.Partitions {
+			if part.MountPoint == m.volume.Path {
+				return m.planResize(d, part)
+			}
+		}
+	}
+
+	sysPart := d.GetSystemPartition()
+	disk := d.GetSystemDisk()
+	if sysPart == nil || disk == nil {
+		return fmt.Errorf("no system partition found in deployment")
+	}
+
+	idx := slices.IndexFunc(sysPart.RWVolumes, func(v deployment.RWVolume) bool { return v.Path == m.volume.Path })
+	if idx < 0 {
+		return fmt.Errorf("no '%s' rw volume found in the system partition", m.volume.Path)
+	}
+	rwVol := sysPart.RWVolumes[idx]
+	if rwVol.Snapshotted {
+		return fmt.Errorf("snapshotted rw volume '%s' can't be moved out of the system partition", m.volume.Path)
+	}
+	if !slices.Contains([]deployment.FileSystem{deployment.Btrfs, deployment.Ext4, deployment.XFS}, m.volume.FileSystem) {
+		return fmt.Errorf("filesystem '%s' is not supported for data partitions", m.volume.FileSystem)
+	}
+
+	for _, part := range disk.Partitions {
+		if part.Label == m.volume.Label {
+			return fmt.Errorf("a partition labeled '%s' already exists", m.volume.Label)
+		}
+	}
+	if last := disk.Partitions[len(disk.Partitions)-1]; last.Size == 0 {
+		return fmt.Errorf("no free space left on disk '%s', partition '%s' takes all the remaining space", disk.Device, last.Label)
+	}
+
+	m.partition = &deployment.Partition{
+		Label:      m.volume.Label,
+		Role:       deployment.Generic,
+		MountPoint: m.volume.Path,
+		FileSystem: m.volume.FileSystem,
+		Size:       m.volume.Size,
+		MountOpts:  []string{"defaults"},
+	}
+	if slices.Contains(rwVol.MountOpts, initrdMnt) {
+		m.partition.MountOpts = append(m.partition.MountOpts, initrdMnt)
+	}
+
+	sysPart.RWVolumes = slices.Delete(sysPart.RWVolumes, idx, idx+1)
+	disk.Partitions = append(disk.Partitions, m.partition)
+	return nil
+}
+
+// planResize updates the given deployment to resize the given partition the volume is mounted from
+func (m *Migrator) planResize(d *deployment.Deployment, part *deployment.Partition) error {
+	if part.Size == m.volume.Size {
+		return fmt.Errorf("partition '%s' mounted at '%s' already has the requested size", part.Label, m.volume.Path)
+	}
+
+	target, err := d.DeepCopy()
+	if err != nil {
+		return fmt.Errorf("copying deployment: %w", err)
+	}
+	for _, disk := range target.Disks {
+		for _, tPart := range disk.Partitions {
+			if tPart.MountPoint == m.volume.Path {
+				tPart.Size = m.volume.Size
+			}
+		}
+	}
+
+	r := relayout.New(m.ctx, m.s)
+	err = r.Plan(d, target.Disks)
+	if err != nil {
+		return fmt.Errorf("planning resize of partition '%s': %w", part.Label, err)
+	}
+	m.resize = r
+	return nil
+}
+
+// Populate creates the new partition on the system disk and copies the current data of the volume into it.
+// Data written to the volume after this point is not migrated. The new partition is removed again if
+// populating it fails. Planned resizes are applied to the partition and its filesystem instead.
+func (m *Migrator) Populate(d *deployment.Deployment) (err error) {
+	if m.resize != nil {
+		m.s.Logger().Info("Resizing partition of '%s'", m.volume.Path)
+		return m.resize.Apply()
+	}
+	if m.partition == nil {
+		return fmt.Errorf("migration of '%s' is not planned", m.volume.Path)
+	}
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	disk := d.GetSystemDisk()
+	m.s.Logger().Info("Creating partition for '%s' on disk '%s'", m.volume.Path, disk.Device)
+	err = repart.ReconcileDevicePartitions(m.s, disk)
+	if err != nil {
+		return fmt.Errorf("partitioning disk '%s': %w", disk.Device, err)
+	}
+
+	bPart, err := block.GetPartitionByUUID(m.s, lsblk.NewLsDevice(m.s), m.partition.UUID, 4)
+	if err != nil {
+		return fmt.Errorf("finding partition '%s': %w", m.partition.UUID, err)
+	}
+	m.created = bPart
+	cleanup.PushErrorOnly(m.Revert)
+
+	mountPoint, err := vfs.TempDir(m.s.FS(), "", "elemental_migrate")
+	if err != nil {
+		return fmt.Errorf("creating temporary directory to mount partition: %w", err)
+	}
+	cleanup.Push(func() error { return m.s.FS().RemoveAll(mountPoint) })
+
+	err = m.s.Mounter().Mount(bPart.Path, mountPoint, "", []string{"rw"})
+	if err != nil {
+		return fmt.Errorf("mounting partition '%s': %w", bPart.Path, err)
+	}
+	cleanup.Push(func() error { return m.s.Mounter().Unmount(mountPoint) })
+
+	m.s.Logger().Info("Copying '%s' data to partition '%s'", m.volume.Path, bPart.Path)
+	r := rsync.NewRsync(m.s, rsync.WithContext(m.ctx), rsync.WithFlags(append(rsync.DefaultFlags(), "--one-file-system")...))
+	err = r.SyncData(m.volume.Path, mountPoint)
+	if err != nil {
+		return fmt.Errorf("copying '%s' data: %w", m.volume.Path, err)
+	}
+	return nil
+}
+
+// Revert removes the partition created by Populate, if any. It is meant to be called if the upgrade
+// transaction the migration is part of fails, as the partition is not mounted by any snapshot then.
+// Applied resizes are not reverted.
+func (m *Migrator) Revert() error {
+	if m.created == nil {
+		return nil
+	}
+	m.s.Logger().Info("Removing partition '%s' created for '%s'", m.created.Path, m.volume.Path)
+	err := repart.DeleteDevicePartition(m.s, m.created)
+	if err != nil {
+		return err
+	}
+	m.created = nil
+	return nil
+}
+
+// Hook returns the upgrade hook mounting the new partition instead of the RW volume in the fstab of the new snapshot.
+// It runs at the upgrade.StageAfterMerge stage.
+func (m *Migrator) Hook() upgrade.Hook {
+	if m.resize != nil {
+		return m.resize.Hook()
+	}
+	return upgrade.NewHookFunc("migrate-data", func(_ context.Context, stage upgrade.Stage, root string) error {
+		if stage != upgrade.StageAfterMerge {
+			return nil
+		}
+		if m.partition == nil || m.partition.UUID == "" {
+			return fmt.Errorf("partition for '%s' not created", m.volume.Path)
+		}
+
+		m.s.Logger().Info("Mounting '%s' from partition '%s' in the new snapshot", m.volume.Path, m.partition.UUID)
+		line := fstab.Line{
+			Device:     fmt.Sprintf("PARTUUID=%s", m.partition.UUID),
+			MountPoint: m.volume.Path,
+			FileSystem: m.partition.FileSystem.String(),
+			Options:    m.partition.MountOpts,
+			FsckOrder:  2,
+		}
+		err := fstab.Update(m.s, filepath.Join(root, fstab.File), []fstab.Line{{MountPoint: m.volume.Path}}, []fstab.Line{line})
+		if err != nil {
+			return fmt.Errorf("updating fstab: %w", err)
+		}
+		return nil
+	})
+}
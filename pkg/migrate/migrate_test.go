@@ -0,0 +1,160 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/migrate"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+const snapshotFstab = `PARTUUID=sys-uuid /var btrfs x-initrd.mount,subvol=@/var 0 0
+PARTUUID=sys-uuid /root btrfs x-initrd.mount,subvol=@/root 0 0
+`
+
+func TestMigrateSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Migrate test suite")
+}
+
+var _ = Describe("Data migration", Label("migrate"), func() {
+	var runner *sysmock.Runner
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var d *deployment.Deployment
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/snapshot/etc/fstab": snapshotFstab,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner), sys.WithMounter(sysmock.NewMounter()),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		d = deployment.DefaultDeployment()
+		d.Disks[0].Device = "/dev/sda"
+		d.GetSystemPartition().Size = 32768
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("moves a shared rw volume to a new partition of the system disk", func() {
+		m := migrate.New(context.Background(), s, migrate.Volume{Path: "/var/", Size: 16384})
+		Expect(m.Plan(d)).To(Succeed())
+
+		sysPart := d.GetSystemPartition()
+		for _, rwVol := range sysPart.RWVolumes {
+			Expect(rwVol.Path).NotTo(Equal("/var"))
+		}
+		parts := d.GetSystemDisk().Partitions
+		part := parts[len(parts)-1]
+		Expect(part.Label).To(Equal("VAR"))
+		Expect(part.Role).To(Equal(deployment.Generic))
+		Expect(part.FileSystem).To(Equal(deployment.XFS))
+		Expect(part.MountPoint).To(Equal("/var"))
+		Expect(part.MountOpts).To(Equal([]string{"defaults", "x-initrd.mount"}))
+	})
+	It("fails to move snapshotted or unknown rw volumes", func() {
+		Expect(migrate.New(context.Background(), s, migrate.Volume{Path: "/etc"}).Plan(d)).
+			To(MatchError(ContainSubstring("snapshotted")))
+		Expect(migrate.New(context.Background(), s, migrate.Volume{Path: "/data"}).Plan(d)).
+			To(MatchError(ContainSubstring("no '/data' rw volume")))
+	})
+	It("fails if the last partition takes all the remaining disk space", func() {
+		d.GetSystemPartition().Size = 0
+		m := migrate.New(context.Background(), s, migrate.Volume{Path: "/var"})
+		Expect(m.Plan(d)).To(MatchError(ContainSubstring("no free space")))
+	})
+	It("mounts the new partition in the fstab of the new snapshot", func() {
+		m := migrate.New(context.Background(), s, migrate.Volume{Path: "/var"})
+		Expect(m.Plan(d)).To(Succeed())
+		parts := d.GetSystemDisk().Partitions
+		parts[len(parts)-1].UUID = "var-uuid"
+
+		hook := m.Hook()
+		Expect(hook.Run(context.Background(), upgrade.StageBeforeSync, "/snapshot")).To(Succeed())
+		data, err := tfs.ReadFile("/snapshot/etc/fstab")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(snapshotFstab))
+
+		Expect(hook.Run(context.Background(), upgrade.StageAfterMerge, "/snapshot")).To(Succeed())
+		data, err = tfs.ReadFile("/snapshot/etc/fstab")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("PARTUUID=var-uuid /var"))
+		Expect(string(data)).To(ContainSubstring("xfs"))
+		Expect(string(data)).NotTo(ContainSubstring("subvol=@/var"))
+		Expect(string(data)).To(ContainSubstring("subvol=@/root"))
+	})
+	It("resizes the dedicated partition the volume is already mounted from", func() {
+		d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+			Label: "DATA", Role: deployment.Generic, FileSystem: deployment.XFS, Size: 16384, MountPoint: "/data",
+		})
+		m := migrate.New(context.Background(), s, migrate.Volume{Path: "/data", Size: 20480})
+		Expect(m.Plan(d)).To(Succeed())
+		parts := d.GetSystemDisk().Partitions
+		Expect(parts).To(HaveLen(3))
+		Expect(parts[2].Size).To(Equal(deployment.MiB(20480)))
+
+		m = migrate.New(context.Background(), s, migrate.Volume{Path: "/data", Size: 20480})
+		Expect(m.Plan(d)).To(MatchError(ContainSubstring("already has the requested size")))
+	})
+	It("removes the new partition if copying the volume data fails", func() {
+		Expect(vfs.MkdirAll(tfs, "/sys/class/block/sda3", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile("/sys/class/block/sda3/partition", []byte("3\n"), vfs.FilePerm)).To(Succeed())
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			switch cmd {
+			case "lsblk":
+				return []byte(`{"blockdevices": [{"partuuid": "var-uuid", "path": "/dev/sda3", "pkname": "/dev/sda", "type": "part"}]}`), nil
+			case "systemd-repart":
+				return []byte("[]"), nil
+			case "rsync":
+				return nil, fmt.Errorf("rsync failed")
+			}
+			return []byte{}, nil
+		}
+
+		m := migrate.New(context.Background(), s, migrate.Volume{Path: "/var", Size: 16384})
+		Expect(m.Plan(d)).To(Succeed())
+		parts := d.GetSystemDisk().Partitions
+		parts[len(parts)-1].UUID = "var-uuid"
+
+		Expect(m.Populate(d)).To(MatchError(ContainSubstring("rsync failed")))
+		Expect(runner.IncludesCmds([][]string{{"sgdisk", "--delete=3", "/dev/sda"}})).To(Succeed())
+	})
+	It("fails to update the fstab if the partition was not created", func() {
+		m := migrate.New(context.Background(), s, migrate.Volume{Path: "/var"})
+		Expect(m.Plan(d)).To(Succeed())
+		Expect(m.Hook().Run(context.Background(), upgrade.StageAfterMerge, "/snapshot")).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,128 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+const (
+	// StepSync is the step of rsync based synchronizations, the target is the destination path
+	StepSync = "sync"
+	// StepUnpack is the step of image layer extractions, the target is the image reference
+	StepUnpack = "unpack"
+	// StepPartition is the step of disk partitioning, the target is the disk device
+	StepPartition = "partition"
+	// StepFormat is the step of filesystem creations, the target is the partition device
+	StepFormat = "format"
+
+	barWidth = 40
+)
+
+// Event is a progress update of a long running step
+type Event struct {
+	// Step identifies the kind of operation reporting progress
+	Step string `json:"step"`
+	// Target is the item the step operates on, e.g. a destination path or a device
+	Target string `json:"target,omitempty"`
+	// Percent is the completion percentage of the step, from 0 to 100
+	Percent int `json:"percent"`
+	// Done is set on the last event of the step
+	Done bool `json:"done,omitempty"`
+}
+
+// Reporter receives the progress events of long running operations
+type Reporter interface {
+	Report(Event)
+}
+
+// Func is a Reporter calling the given function for each event
+type Func func(Event)
+
+func (f Func) Report(e Event) {
+	f(e)
+}
+
+// Discard is a Reporter ignoring all events
+var Discard Reporter = Func(func(Event) {})
+
+// Start reports the given step started on the given target
+func Start(r Reporter, step, target string) {
+	r.Report(Event{Step: step, Target: target})
+}
+
+// Done reports the given step completed on the given target
+func Done(r Reporter, step, target string) {
+	r.Report(Event{Step: step, Target: target, Percent: 100, Done: true})
+}
+
+type jsonReporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSON returns a Reporter writing each event as a JSON object in its own line, meant
+// for machine consumers
+func NewJSON(w io.Writer) Reporter {
+	return &jsonReporter{enc: json.NewEncoder(w)}
+}
+
+func (j *jsonReporter) Report(e Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_ = j.enc.Encode(e)
+}
+
+type barReporter struct {
+	mu   sync.Mutex
+	out  io.Writer
+	bars map[string]*progressbar.ProgressBar
+}
+
+// NewBar returns a Reporter drawing a progress bar per step and target in the given terminal writer
+func NewBar(w io.Writer) Reporter {
+	return &barReporter{out: w, bars: map[string]*progressbar.ProgressBar{}}
+}
+
+func (b *barReporter) Report(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := e.Step + ":" + e.Target
+	bar, ok := b.bars[key]
+	if !ok {
+		bar = progressbar.NewOptions(
+			100, progressbar.OptionSetWriter(b.out), progressbar.OptionSetWidth(barWidth),
+			progressbar.OptionSetDescription(fmt.Sprintf("%s %s", e.Step, e.Target)),
+			progressbar.OptionOnCompletion(func() {
+				_, _ = fmt.Fprintln(b.out)
+			}),
+		)
+		b.bars[key] = bar
+	}
+	_ = bar.Set(min(max(e.Percent, 0), 100))
+	if e.Done {
+		_ = bar.Finish()
+		delete(b.bars, key)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package progress_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/progress"
+)
+
+func TestProgressSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Progress test suite")
+}
+
+var _ = Describe("Progress", Label("progress"), func() {
+	var out *bytes.Buffer
+	BeforeEach(func() {
+		out = &bytes.Buffer{}
+	})
+	It("reports events as JSON lines", func() {
+		r := progress.NewJSON(out)
+		progress.Start(r, progress.StepSync, "/target")
+		r.Report(progress.Event{Step: progress.StepSync, Target: "/target", Percent: 42})
+		progress.Done(r, progress.StepSync, "/target")
+		Expect(strings.Split(strings.TrimSpace(out.String()), "\n")).To(Equal([]string{
+			`{"step":"sync","target":"/target","percent":0}`,
+			`{"step":"sync","target":"/target","percent":42}`,
+			`{"step":"sync","target":"/target","percent":100,"done":true}`,
+		}))
+	})
+	It("draws progress bars", func() {
+		r := progress.NewBar(out)
+		progress.Start(r, progress.StepUnpack, "registry.example.com/os:latest")
+		r.Report(progress.Event{Step: progress.StepUnpack, Target: "registry.example.com/os:latest", Percent: 50})
+		progress.Done(r, progress.StepUnpack, "registry.example.com/os:latest")
+		Expect(out.String()).To(ContainSubstring("unpack registry.example.com/os:latest"))
+		Expect(out.String()).To(ContainSubstring("100%"))
+		Expect(out.String()).To(HaveSuffix("\n"))
+	})
+	It("discards events", func() {
+		Expect(func() { progress.Done(progress.Discard, progress.StepFormat, "/dev/sda1") }).NotTo(Panic())
+	})
+})
@@ -0,0 +1,189 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+const (
+	// CmdlineProxy is the kernel parameter setting the proxy URL for HTTP and HTTPS connections
+	CmdlineProxy = "proxy"
+	// CmdlineNoProxy is the kernel parameter setting the comma separated list of hosts reached directly
+	CmdlineNoProxy = "noproxy"
+
+	// wpadOption is the name NetworkManager gives to the DHCP option 252 carrying the WPAD URL
+	wpadOption = "wpad"
+	pacTimeout = 10 * time.Second
+)
+
+var (
+	envVars     = []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"}
+	noProxyVars = []string{"NO_PROXY", "no_proxy"}
+
+	// pacProxy matches the first proxy returned by a proxy auto-config script
+	pacProxy = regexp.MustCompile(`(?i)"\s*PROXY\s+([^\s;"]+)`)
+)
+
+// Config is the proxy configuration of the HTTP and HTTPS connections
+type Config struct {
+	// Proxy is the URL of the proxy server
+	Proxy string
+	// NoProxy is the comma separated list of hosts, domains and networks reached directly
+	NoProxy string
+}
+
+// Detect returns the proxy configuration set by the kernel command line or, if not set, by the proxy
+// auto-config script found at the WPAD URL provided by DHCP. It returns nil if the proxy environment
+// variables are already set or if no proxy is found.
+func Detect(ctx context.Context, s *sys.System) (*Config, error) {
+	for _, env := range envVars {
+		if os.Getenv(env) != "" {
+			s.Logger().Debug("Proxy already set by the %s environment variable", env)
+			return nil, nil
+		}
+	}
+
+	cfg, err := FromCmdline(s)
+	if err != nil || cfg != nil {
+		return cfg, err
+	}
+
+	url, err := WPADURL(s)
+	if err != nil {
+		s.Logger().Debug("Could not get WPAD URL from DHCP: %v", err)
+		return nil, nil
+	} else if url == "" {
+		return nil, nil
+	}
+	return FromPAC(ctx, url)
+}
+
+// FromCmdline returns the proxy configuration set by the 'proxy' and 'noproxy' kernel parameters,
+// nil if no proxy is set
+func FromCmdline(s *sys.System) (*Config, error) {
+	data, err := s.FS().ReadFile("/proc/cmdline")
+	if err != nil {
+		return nil, fmt.Errorf("reading kernel command line: %w", err)
+	}
+
+	cfg := &Config{}
+	for _, param := range strings.Fields(string(data)) {
+		key, value, _ := strings.Cut(param, "=")
+		switch key {
+		case CmdlineProxy:
+			cfg.Proxy = value
+		case CmdlineNoProxy:
+			cfg.NoProxy = value
+		}
+	}
+	if cfg.Proxy == "" {
+		return nil, nil
+	}
+	s.Logger().Info("Using proxy '%s' from the kernel command line", cfg.Proxy)
+	return cfg, nil
+}
+
+// WPADURL returns the proxy auto-config URL provided by the DHCP option 252 of any device managed by
+// NetworkManager, an empty string if none is provided
+func WPADURL(s *sys.System) (string, error) {
+	out, err := s.Runner().Run("nmcli", "-t", "-f", "DHCP4", "device", "show")
+	if err != nil {
+		return "", fmt.Errorf("listing DHCP options: %w", err)
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		_, option, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value, ok := strings.Cut(option, "=")
+		if ok && strings.TrimSpace(key) == wpadOption {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", nil
+}
+
+// FromPAC downloads the proxy auto-config script at the given URL and returns the first proxy it returns.
+// The script is not evaluated, so proxies selected by host or network conditions are not honored.
+func FromPAC(ctx context.Context, url string) (*Config, error) {
+	ctx, cancel := context.WithTimeout(ctx, pacTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	client := &http.Client{Transport: &http.Transport{Proxy: nil}}
+	resp, err := client.Do(req) // #nosec G704 -- url is provided by the DHCP server of the network
+	if err != nil {
+		return nil, fmt.Errorf("downloading proxy auto-config '%s': %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading proxy auto-config '%s': unexpected status: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading proxy auto-config '%s': %w", url, err)
+	}
+
+	match := pacProxy.FindSubmatch(data)
+	if match == nil {
+		return nil, nil
+	}
+	return &Config{Proxy: "http://" + string(match[1])}, nil
+}
+
+// Env returns the environment variables setting this proxy configuration
+func (c Config) Env() []string {
+	env := []string{}
+	for _, key := range envVars {
+		env = append(env, fmt.Sprintf("%s=%s", key, c.Proxy))
+	}
+	if c.NoProxy != "" {
+		for _, key := range noProxyVars {
+			env = append(env, fmt.Sprintf("%s=%s", key, c.NoProxy))
+		}
+	}
+	return env
+}
+
+// Apply sets the proxy environment variables of the current process, which are honored by the HTTP
+// connections and inherited by the executed commands. It must be called before any HTTP connection
+// is made, as the proxy environment is only read once.
+func (c Config) Apply() error {
+	for _, env := range c.Env() {
+		key, value, _ := strings.Cut(env, "=")
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("setting %s: %w", key, err)
+		}
+	}
+	return nil
+}
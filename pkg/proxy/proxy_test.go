@@ -0,0 +1,155 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/proxy"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestProxySuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Proxy test suite")
+}
+
+const pacScript = `function FindProxyForURL(url, host) {
+	if (isPlainHostName(host)) {
+		return "DIRECT";
+	}
+	return "PROXY proxy.example.com:3128; DIRECT";
+}`
+
+var _ = Describe("Proxy", Label("proxy"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var server *httptest.Server
+	var nmcliOut string
+	BeforeEach(func() {
+		var err error
+		for _, env := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+			if value, ok := os.LookupEnv(env); ok {
+				Expect(os.Unsetenv(env)).To(Succeed())
+				DeferCleanup(os.Setenv, env, value)
+			}
+		}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/wpad.dat" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(pacScript))
+		}))
+		nmcliOut = fmt.Sprintf("DHCP4.OPTION[1]:broadcast_address = 192.168.1.255\n"+
+			"DHCP4.OPTION[2]:wpad = %s/wpad.dat\nDHCP4.OPTION[3]:routers = 192.168.1.1\n", server.URL)
+
+		runner = sysmock.NewRunner()
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "nmcli" {
+				return []byte(nmcliOut), nil
+			}
+			return []byte{}, nil
+		}
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/proc/cmdline": "root=LABEL=SYSTEM quiet",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		server.Close()
+		cleanup()
+	})
+	It("detects the proxy from the kernel command line", func() {
+		Expect(fs.WriteFile("/proc/cmdline", []byte("quiet proxy=http://10.0.0.1:8080 noproxy=localhost,.local"), vfs.FilePerm)).To(Succeed())
+		cfg, err := proxy.Detect(context.Background(), s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(Equal(&proxy.Config{Proxy: "http://10.0.0.1:8080", NoProxy: "localhost,.local"}))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("detects the proxy from the DHCP WPAD URL", func() {
+		cfg, err := proxy.Detect(context.Background(), s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(Equal(&proxy.Config{Proxy: "http://proxy.example.com:3128"}))
+		Expect(runner.CmdsMatch([][]string{{"nmcli", "-t", "-f", "DHCP4", "device", "show"}})).To(Succeed())
+	})
+	It("does not detect any proxy if the environment already sets it", func() {
+		Expect(os.Setenv("https_proxy", "http://10.0.0.2:3128")).To(Succeed())
+		DeferCleanup(os.Unsetenv, "https_proxy")
+		Expect(fs.WriteFile("/proc/cmdline", []byte("proxy=http://10.0.0.1:8080"), vfs.FilePerm)).To(Succeed())
+		cfg, err := proxy.Detect(context.Background(), s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(BeNil())
+	})
+	It("does not detect any proxy if no WPAD URL is provided", func() {
+		nmcliOut = "DHCP4.OPTION[1]:broadcast_address = 192.168.1.255\n"
+		cfg, err := proxy.Detect(context.Background(), s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(BeNil())
+	})
+	It("ignores NetworkManager failures", func() {
+		runner.SideEffect = func(_ string, _ ...string) ([]byte, error) {
+			return []byte{}, fmt.Errorf("nmcli not found")
+		}
+		cfg, err := proxy.Detect(context.Background(), s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(BeNil())
+	})
+	It("fails if the proxy auto-config can't be downloaded", func() {
+		nmcliOut = fmt.Sprintf("DHCP4.OPTION[1]:wpad = %s/missing.dat\n", server.URL)
+		_, err := proxy.Detect(context.Background(), s)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unexpected status"))
+	})
+	It("returns nil for proxy auto-configs without proxies", func() {
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`function FindProxyForURL(url, host) { return "DIRECT"; }`))
+		})
+		cfg, err := proxy.FromPAC(context.Background(), server.URL+"/wpad.dat")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(BeNil())
+	})
+	It("returns the proxy environment variables", func() {
+		Expect(proxy.Config{Proxy: "http://proxy:3128"}.Env()).To(Equal([]string{
+			"HTTP_PROXY=http://proxy:3128", "HTTPS_PROXY=http://proxy:3128",
+			"http_proxy=http://proxy:3128", "https_proxy=http://proxy:3128",
+		}))
+		Expect(proxy.Config{Proxy: "http://proxy:3128", NoProxy: "localhost"}.Env()).To(ContainElements(
+			"NO_PROXY=localhost", "no_proxy=localhost",
+		))
+	})
+})
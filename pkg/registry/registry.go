@@ -0,0 +1,318 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// Config holds the authentication, mirror and insecure settings used to reach OCI registries.
+// A nil Config is valid and resolves references to their origin registry using the default keychain.
+type Config struct {
+	// Mirrors maps a registry host to the list of mirrors tried, in order, before the registry itself.
+	// Mirrors can include a repository prefix, e.g. 'mirror.example.com:5000/docker.io'.
+	Mirrors map[string][]string `yaml:"mirrors,omitempty"`
+
+	// Insecure lists the registry hosts reached over plain HTTP or HTTPS without certificate verification.
+	Insecure []string `yaml:"insecure,omitempty"`
+
+	auths map[string]authn.AuthConfig
+}
+
+// dockerConfig is the subset of the docker config.json format holding registry credentials
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Auth          string `json:"auth,omitempty"`
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	RegistryToken string `json:"registrytoken,omitempty"`
+}
+
+// LoadConfig reads the mirror and insecure registry settings from the given YAML file
+func LoadConfig(fs vfs.FS, path string) (*Config, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading registry config '%s': %w", path, err)
+	}
+
+	c := &Config{}
+	err = yaml.Unmarshal(data, c)
+	if err != nil {
+		return nil, fmt.Errorf("parsing registry config '%s': %w", path, err)
+	}
+
+	mirrors := map[string][]string{}
+	for reg, regMirrors := range c.Mirrors {
+		mirrors[normalizeHost(reg)] = regMirrors
+	}
+	c.Mirrors = mirrors
+
+	for i, reg := range c.Insecure {
+		c.Insecure[i] = normalizeHost(reg)
+	}
+	return c, nil
+}
+
+// LoadAuthFile adds the registry credentials of the given docker-style config.json file
+func (c *Config) LoadAuthFile(fs vfs.FS, path string) error {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading registry auth file '%s': %w", path, err)
+	}
+
+	dc := dockerConfig{}
+	err = json.Unmarshal(data, &dc)
+	if err != nil {
+		return fmt.Errorf("parsing registry auth file '%s': %w", path, err)
+	}
+
+	for reg, auth := range dc.Auths {
+		cfg := authn.AuthConfig{
+			Username:      auth.Username,
+			Password:      auth.Password,
+			IdentityToken: auth.IdentityToken,
+		}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return fmt.Errorf("decoding credentials of registry '%s': %w", reg, err)
+			}
+			user, pass, ok := strings.Cut(string(decoded), ":")
+			if !ok {
+				return fmt.Errorf("invalid credentials format for registry '%s'", reg)
+			}
+			cfg.Username, cfg.Password = user, pass
+		}
+		c.addAuth(reg, cfg)
+	}
+	return nil
+}
+
+// AddAuth adds the credentials given in the 'registry=username:password' format
+func (c *Config) AddAuth(auth string) error {
+	reg, creds, ok := strings.Cut(auth, "=")
+	if !ok || reg == "" {
+		return fmt.Errorf("invalid registry auth, expected 'registry=username:password'")
+	}
+
+	user, pass, ok := strings.Cut(creds, ":")
+	if !ok || user == "" {
+		return fmt.Errorf("invalid credentials for registry '%s', expected 'username:password'", reg)
+	}
+
+	c.addAuth(reg, authn.AuthConfig{Username: user, Password: pass})
+	return nil
+}
+
+func (c *Config) addAuth(reg string, cfg authn.AuthConfig) {
+	if c.auths == nil {
+		c.auths = map[string]authn.AuthConfig{}
+	}
+	c.auths[normalizeHost(reg)] = cfg
+}
+
+// Secrets returns the passwords and identity tokens of the configured credentials, so they can
+// be redacted from the logs
+func (c *Config) Secrets() []string {
+	var secrets []string
+	for _, cfg := range c.auths {
+		secrets = append(secrets, cfg.Password, cfg.IdentityToken)
+	}
+	return secrets
+}
+
+// WriteAuthFile writes a docker-style config.json file with the credentials resolved for the registries
+// of the given references, so tools not using this configuration, such as cosign or notation, can reach them
+func (c *Config) WriteAuthFile(ctx context.Context, fs vfs.FS, path string, refs ...name.Reference) error {
+	dc := dockerConfig{Auths: map[string]dockerAuth{}}
+	for _, ref := range refs {
+		reg := ref.Context().Registry
+		auth, err := authn.Resolve(ctx, c.Keychain(), reg)
+		if err != nil {
+			return fmt.Errorf("resolving credentials of registry '%s': %w", reg.RegistryStr(), err)
+		}
+		cfg, err := authn.Authorization(ctx, auth)
+		if err != nil {
+			return fmt.Errorf("resolving credentials of registry '%s': %w", reg.RegistryStr(), err)
+		}
+		if *cfg == (authn.AuthConfig{}) {
+			continue
+		}
+		dc.Auths[reg.RegistryStr()] = dockerAuth{
+			Auth:          cfg.Auth,
+			Username:      cfg.Username,
+			Password:      cfg.Password,
+			IdentityToken: cfg.IdentityToken,
+			RegistryToken: cfg.RegistryToken,
+		}
+	}
+
+	data, err := json.Marshal(dc)
+	if err != nil {
+		return fmt.Errorf("marshalling registry auth file: %w", err)
+	}
+	err = fs.WriteFile(path, data, 0600)
+	if err != nil {
+		return fmt.Errorf("writing registry auth file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// Keychain returns a keychain resolving the configured credentials first and falling back
+// to the default docker and podman credential files
+func (c *Config) Keychain() authn.Keychain {
+	if c == nil || len(c.auths) == 0 {
+		return authn.DefaultKeychain
+	}
+	return authn.NewMultiKeychain(c, authn.DefaultKeychain)
+}
+
+// Resolve implements authn.Keychain for the credentials added to this configuration
+func (c *Config) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	if c != nil {
+		if cfg, ok := c.auths[normalizeHost(target.RegistryStr())]; ok {
+			return authn.FromConfig(cfg), nil
+		}
+	}
+	return authn.Anonymous, nil
+}
+
+// IsInsecure returns true if the given registry host is configured as insecure
+func (c *Config) IsInsecure(reg string) bool {
+	return c != nil && slices.Contains(c.Insecure, normalizeHost(reg))
+}
+
+// Transport returns the HTTP transport to reach the given registry host
+func (c *Config) Transport(reg string) http.RoundTripper {
+	if !c.IsInsecure(reg) {
+		return http.DefaultTransport
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	return t
+}
+
+// References parses the given image reference and returns the references of all its configured mirrors
+// followed by the reference itself. Insecure registries are parsed allowing plain HTTP connections.
+func (c *Config) References(imageRef string, opts ...name.Option) ([]name.Reference, error) {
+	ref, err := c.parse(imageRef, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if c == nil {
+		return []name.Reference{ref}, nil
+	}
+
+	separator := ":"
+	if _, ok := ref.(name.Digest); ok {
+		separator = "@"
+	}
+
+	refs := []name.Reference{}
+	for _, mirror := range c.Mirrors[normalizeHost(ref.Context().RegistryStr())] {
+		mirrorRef, err := c.parse(
+			fmt.Sprintf("%s/%s%s%s", strings.TrimSuffix(trimScheme(mirror), "/"), ref.Context().RepositoryStr(), separator, ref.Identifier()),
+			opts...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("parsing mirror '%s' reference: %w", mirror, err)
+		}
+		refs = append(refs, mirrorRef)
+	}
+	return append(refs, ref), nil
+}
+
+// Digest returns the digest the given image reference currently points to. It is resolved with a HEAD
+// request to the configured mirrors first, references pinned to a digest are returned as is.
+func (c *Config) Digest(ctx context.Context, imageRef string) (string, error) {
+	refs, err := c.References(imageRef)
+	if err != nil {
+		return "", fmt.Errorf("parsing image reference '%s': %w", imageRef, err)
+	}
+
+	if d, ok := refs[len(refs)-1].(name.Digest); ok {
+		return d.DigestStr(), nil
+	}
+
+	var errs []error
+	for _, r := range refs {
+		desc, err := remote.Head(r,
+			remote.WithTransport(c.Transport(r.Context().RegistryStr())),
+			remote.WithAuthFromKeychain(c.Keychain()),
+			remote.WithContext(ctx),
+		)
+		if err == nil {
+			return desc.Digest.String(), nil
+		}
+		errs = append(errs, err)
+	}
+
+	return "", fmt.Errorf("resolving digest of '%s': %w", imageRef, errors.Join(errs...))
+}
+
+func (c *Config) parse(imageRef string, opts ...name.Option) (name.Reference, error) {
+	ref, err := name.ParseReference(imageRef, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsInsecure(ref.Context().RegistryStr()) {
+		return name.ParseReference(imageRef, append(slices.Clip(opts), name.Insecure)...)
+	}
+	return ref, nil
+}
+
+// normalizeHost strips any scheme and path from the given registry address and maps the
+// docker hub aliases to its canonical registry name
+func normalizeHost(reg string) string {
+	reg, _, _ = strings.Cut(trimScheme(reg), "/")
+	switch reg {
+	case "docker.io", "registry-1.docker.io":
+		return name.DefaultRegistry
+	}
+	// Bare IPv6 addresses are bracketed as in the registry part of image references
+	if addr, err := netip.ParseAddr(reg); err == nil && addr.Is6() {
+		return "[" + reg + "]"
+	}
+	return reg
+}
+
+func trimScheme(reg string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(reg, "https://"), "http://")
+}
@@ -0,0 +1,166 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/suse/elemental/v3/pkg/registry"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const registryConfig = `mirrors:
+  docker.io:
+  - mirror.example.com:5000/dockerhub
+  - https://mirror2.example.com
+insecure:
+- mirror.example.com:5000
+`
+
+// auth field encodes 'bob:secret'
+const authFile = `{
+  "auths": {
+    "https://index.docker.io/v1/": {"auth": "Ym9iOnNlY3JldA=="},
+    "registry.example.com": {"username": "alice", "password": "pass"}
+  }
+}`
+
+func TestRegistrySuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Registry test suite")
+}
+
+var _ = Describe("Registry", Label("registry"), func() {
+	var tfs vfs.FS
+	var cleanup func()
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/etc/elemental/registries.yaml": registryConfig,
+			"/root/.docker/config.json":      authFile,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("resolves mirrors ahead of the origin registry", func() {
+		c, err := registry.LoadConfig(tfs, "/etc/elemental/registries.yaml")
+		Expect(err).NotTo(HaveOccurred())
+
+		refs, err := c.References("alpine:3.21")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(refs).To(HaveLen(3))
+		Expect(refs[0].Name()).To(Equal("mirror.example.com:5000/dockerhub/library/alpine:3.21"))
+		Expect(refs[0].Context().Scheme()).To(Equal("http"))
+		Expect(refs[1].Name()).To(Equal("mirror2.example.com/library/alpine:3.21"))
+		Expect(refs[1].Context().Scheme()).To(Equal("https"))
+		Expect(refs[2].Name()).To(Equal("index.docker.io/library/alpine:3.21"))
+
+		refs, err = c.References("registry.example.com/os@sha256:" + sha)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(refs).To(HaveLen(1))
+		Expect(refs[0].Name()).To(Equal("registry.example.com/os@sha256:" + sha))
+	})
+	It("uses an insecure transport only for insecure registries", func() {
+		c, err := registry.LoadConfig(tfs, "/etc/elemental/registries.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.IsInsecure("mirror.example.com:5000")).To(BeTrue())
+		Expect(c.Transport("registry.example.com")).To(BeIdenticalTo(http.DefaultTransport))
+
+		t, ok := c.Transport("mirror.example.com:5000").(*http.Transport)
+		Expect(ok).To(BeTrue())
+		Expect(t.TLSClientConfig.InsecureSkipVerify).To(BeTrue())
+	})
+	It("matches bare IPv6 insecure registries", func() {
+		c := &registry.Config{Insecure: []string{"[fd00::1]:5000"}}
+		Expect(c.IsInsecure("[fd00::1]:5000")).To(BeTrue())
+		Expect(c.IsInsecure("fd00::1")).To(BeFalse())
+
+		c = &registry.Config{Insecure: []string{"[fd00::1]"}}
+		Expect(c.IsInsecure("fd00::1")).To(BeTrue())
+
+		refs, err := c.References("[fd00::1]/os:latest")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(refs[0].Context().Scheme()).To(Equal("http"))
+	})
+	It("resolves credentials from auth files and flags", func() {
+		c := &registry.Config{}
+		Expect(c.LoadAuthFile(tfs, "/root/.docker/config.json")).To(Succeed())
+		Expect(c.AddAuth("private.example.com=carol:pa:ss")).To(Succeed())
+
+		for reg, expected := range map[string]authn.AuthConfig{
+			"index.docker.io":      {Username: "bob", Password: "secret"},
+			"registry.example.com": {Username: "alice", Password: "pass"},
+			"private.example.com":  {Username: "carol", Password: "pa:ss"},
+		} {
+			r, err := name.NewRegistry(reg)
+			Expect(err).NotTo(HaveOccurred())
+			auth, err := authn.Resolve(GinkgoT().Context(), c.Keychain(), r)
+			Expect(err).NotTo(HaveOccurred())
+			cfg, err := auth.Authorization()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Username).To(Equal(expected.Username))
+			Expect(cfg.Password).To(Equal(expected.Password))
+		}
+	})
+	It("writes the credentials of the given references to a docker config file", func() {
+		c := &registry.Config{}
+		Expect(c.AddAuth("registry.example.com=alice:pass")).To(Succeed())
+		refs, err := c.References("registry.example.com/os:latest")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(c.WriteAuthFile(GinkgoT().Context(), tfs, "/root/.docker/elemental.json", refs...)).To(Succeed())
+		data, err := tfs.ReadFile("/root/.docker/elemental.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(`{"auths":{"registry.example.com":{"username":"alice","password":"pass"}}}`))
+	})
+	It("fails to add malformed credentials", func() {
+		c := &registry.Config{}
+		Expect(c.AddAuth("carol:pass")).NotTo(Succeed())
+		Expect(c.AddAuth("private.example.com=carol")).To(MatchError(ContainSubstring("'private.example.com'")))
+	})
+	It("resolves the origin reference with a nil config", func() {
+		var c *registry.Config
+		refs, err := c.References("registry.example.com/os:latest")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(refs).To(HaveLen(1))
+		Expect(refs[0].Name()).To(Equal("registry.example.com/os:latest"))
+		Expect(c.Keychain()).To(Equal(authn.DefaultKeychain))
+	})
+	It("returns the digest of pinned references without querying the registry", func() {
+		var c *registry.Config
+		digest, err := c.Digest(context.Background(), "registry.example.com/os@sha256:"+sha)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal("sha256:" + sha))
+
+		_, err = c.Digest(context.Background(), "registry.example.com/OS")
+		Expect(err).To(MatchError(ContainSubstring("parsing image reference 'registry.example.com/OS'")))
+	})
+})
+
+const sha = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
@@ -0,0 +1,384 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package relayout
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+// BackupDir is the persistent directory the partition tables are backed up to before applying layout changes
+const BackupDir = "/var/lib/elemental/partition-backup"
+
+// ChangeType is the kind of a partition change
+type ChangeType string
+
+const (
+	// Grow grows the last partition of a disk and its filesystem
+	Grow ChangeType = "grow"
+	// Shrink shrinks the last partition of a disk and its filesystem
+	Shrink ChangeType = "shrink"
+	// Add creates a new data partition in the free space at the end of a disk
+	Add ChangeType = "add"
+)
+
+// Change is a single partition change of a layout migration
+type Change struct {
+	Type      ChangeType
+	Disk      *deployment.Disk
+	Partition *deployment.Partition
+	// Size is the size of the partition once the change is applied, zero takes all the remaining disk space
+	Size deployment.MiB
+}
+
+// Relayouter applies limited layout changes to the disks of a running system: the last partition of a disk
+// can be grown or shrunk if it is a data partition and new data partitions can be added in the free space at
+// the end of a disk. Any other partition must remain unchanged.
+type Relayouter struct {
+	ctx        context.Context
+	s          *sys.System
+	changes    []Change
+	backupPart *deployment.Partition
+}
+
+func New(ctx context.Context, s *sys.System) *Relayouter {
+	return &Relayouter{ctx: ctx, s: s.WithComponent("relayout")}
+}
+
+// Changes returns the planned layout changes
+func (r *Relayouter) Changes() []Change {
+	return r.changes
+}
+
+// Plan compares the disks of the given deployment with the given target disks and updates the deployment
+// to the target layout. It fails if the target layout requires any change other than growing or shrinking the
+// last data partition of a disk or appending new data partitions to it.
+func (r *Relayouter) Plan(d *deployment.Deployment, target []*deployment.Disk) error {
+	if len(target) != len(d.Disks) {
+		return fmt.Errorf("adding or removing disks is not supported, expected %d disks", len(d.Disks))
+	}
+
+	var changes []Change
+	for i, disk := range d.Disks {
+		diskChanges, err := planDisk(d, disk, target[i])
+		if err != nil {
+			return fmt.Errorf("disk %d: %w", i, err)
+		}
+		changes = append(changes, diskChanges...)
+	}
+
+	for _, change := range changes {
+		switch change.Type {
+		case Grow, Shrink:
+			change.Partition.Size = change.Size
+		case Add:
+			change.Disk.Partitions = append(change.Disk.Partitions, change.Partition)
+		}
+	}
+	r.changes = changes
+	r.backupPart = d.GetRecoveryPartition()
+	if r.backupPart == nil {
+		r.backupPart = d.GetConfigPartition()
+	}
+	return nil
+}
+
+// planDisk lists the changes required to apply the target layout to the given disk
+func planDisk(d *deployment.Deployment, disk, target *deployment.Disk) ([]Change, error) {
+	current := disk.Partitions
+	if len(target.Partitions) < len(current) {
+		return nil, fmt.Errorf("removing partitions is not supported")
+	}
+
+	var changes []Change
+	for j, part := range current {
+		tPart := target.Partitions[j]
+		if tPart.Label != part.Label || tPart.Role != part.Role || tPart.FileSystem != part.FileSystem {
+			return nil, fmt.Errorf("partition %d does not match the current partition '%s'", j, part.Label)
+		}
+		if tPart.Size == part.Size {
+			continue
+		}
+		if j < len(current)-1 {
+			return nil, fmt.Errorf("partition '%s' can't be resized, only the last partition of a disk can", part.Label)
+		}
+		if part.Role != deployment.Generic {
+			return nil, fmt.Errorf("partition '%s' can't be resized, only data partitions can", part.Label)
+		}
+
+		change := Change{Type: Grow, Disk: disk, Partition: part, Size: tPart.Size}
+		if part.Size == 0 || (tPart.Size != 0 && tPart.Size < part.Size) {
+			change.Type = Shrink
+			if !filesystem.CanShrink(part.FileSystem) {
+				return nil, fmt.Errorf("partition '%s' can't be shrunk, its filesystem '%s' does not support it", part.Label, part.FileSystem)
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	newParts := target.Partitions[len(current):]
+	if len(newParts) == 0 {
+		return changes, nil
+	}
+	last := current[len(current)-1]
+	lastSize := last.Size
+	if len(changes) > 0 {
+		lastSize = changes[len(changes)-1].Size
+	}
+	if lastSize == 0 {
+		return nil, fmt.Errorf("no free space left, partition '%s' takes all the remaining space", last.Label)
+	}
+
+	for _, tPart := range newParts {
+		if tPart.Role != deployment.Generic {
+			return nil, fmt.Errorf("new partition '%s' must be a data partition", tPart.Label)
+		}
+		if tPart.MountPoint == "" {
+			return nil, fmt.Errorf("new partition '%s' has no mount point", tPart.Label)
+		}
+		mountPoint := filepath.Clean(tPart.MountPoint)
+		if !slices.Contains([]deployment.FileSystem{deployment.Btrfs, deployment.Ext4, deployment.XFS}, tPart.FileSystem) {
+			return nil, fmt.Errorf("filesystem '%s' is not supported for data partitions", tPart.FileSystem)
+		}
+		if inUse(d, mountPoint) || slices.ContainsFunc(changes, func(c Change) bool {
+			return c.Type == Add && c.Partition.MountPoint == mountPoint
+		}) {
+			return nil, fmt.Errorf("'%s' of new partition '%s' is already mounted from a partition or RW volume", mountPoint, tPart.Label)
+		}
+		part := &deployment.Partition{
+			Label:      tPart.Label,
+			Role:       deployment.Generic,
+			FileSystem: tPart.FileSystem,
+			Size:       tPart.Size,
+			MountPoint: mountPoint,
+			MountOpts:  tPart.MountOpts,
+		}
+		if len(part.MountOpts) == 0 {
+			part.MountOpts = []string{"defaults"}
+		}
+		changes = append(changes, Change{Type: Add, Disk: disk, Partition: part, Size: part.Size})
+	}
+	return changes, nil
+}
+
+// inUse checks if the given path is already mounted from any partition or RW volume of the deployment
+func inUse(d *deployment.Deployment, path string) bool {
+	for _, disk := range d.Disks {
+		for _, part := range disk.Partitions {
+			if part.MountPoint == path {
+				return true
+			}
+			for _, rwVol := range part.RWVolumes {
+				if rwVol.Path == path {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Apply runs the preflight checks and applies the planned changes to the disks. The partition tables of
+// the changed disks are backed up before any change and restored if any change fails. Backups are kept
+// in BackupDir and copied to the recovery or config partition, if any, so they are still available to
+// restore-partitions after a reboot.
+func (r *Relayouter) Apply() (err error) {
+	if len(r.changes) == 0 {
+		return nil
+	}
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	bDev := lsblk.NewLsDevice(r.s)
+	bParts, err := r.preflight(bDev)
+	if err != nil {
+		return fmt.Errorf("preflight checks failed: %w", err)
+	}
+
+	err = r.s.FS().RemoveAll(BackupDir)
+	if err != nil {
+		return fmt.Errorf("removing previous partition table backups: %w", err)
+	}
+
+	var disks []*deployment.Disk
+	for _, change := range r.changes {
+		if !slices.Contains(disks, change.Disk) {
+			disks = append(disks, change.Disk)
+		}
+	}
+	for _, disk := range disks {
+		_, err = repart.BackupDevice(r.s, bDev, disk.Device, BackupDir)
+		if err != nil {
+			return fmt.Errorf("backing up disk '%s': %w", disk.Device, err)
+		}
+		cleanup.PushErrorOnly(func() error {
+			rErr := repart.RestoreDevice(r.s, bDev, disk.Device, BackupDir)
+			if rErr != nil {
+				r.s.Logger().Error("Restoring partition table of '%s' failed, backup kept at '%s'", disk.Device, BackupDir)
+			}
+			return rErr
+		})
+	}
+	if r.backupPart != nil {
+		err = repart.StoreBackup(r.ctx, r.s, BackupDir, r.backupPart)
+		if err != nil {
+			r.s.Logger().Warn("Could not store partition table backups in '%s' partition: %v", r.backupPart.Role.String(), err)
+		}
+	}
+
+	for _, change := range r.changes {
+		if change.Type != Shrink {
+			continue
+		}
+		err = r.shrink(change, bParts[change.Partition])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, disk := range disks {
+		r.s.Logger().Info("Updating partitions of disk '%s'", disk.Device)
+		err = repart.ReconcileDevicePartitions(r.s, disk)
+		if err != nil {
+			return fmt.Errorf("partitioning disk '%s': %w", disk.Device, err)
+		}
+	}
+
+	for _, change := range r.changes {
+		if change.Type != Grow {
+			continue
+		}
+		err = repart.GrowFileSystem(r.s, change.Partition)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preflight checks the partitions to resize exist and can be resized in their current state
+func (r *Relayouter) preflight(bDev block.Device) (map[*deployment.Partition]*block.Partition, error) {
+	bParts := map[*deployment.Partition]*block.Partition{}
+	for _, change := range r.changes {
+		if change.Type == Add {
+			continue
+		}
+		if change.Disk.Device == "" {
+			return nil, fmt.Errorf("no device set for the disk of partition '%s'", change.Partition.Label)
+		}
+		bPart, err := block.GetPartitionByUUID(r.s, bDev, change.Partition.UUID, 1)
+		if err != nil {
+			return nil, fmt.Errorf("finding partition '%s': %w", change.Partition.Label, err)
+		}
+		if change.Type == Shrink && change.Partition.FileSystem != deployment.Btrfs && len(bPart.MountPoints) > 0 {
+			return nil, fmt.Errorf("partition '%s' must be unmounted to shrink its '%s' filesystem", change.Partition.Label, change.Partition.FileSystem)
+		}
+		bParts[change.Partition] = bPart
+	}
+	return bParts, nil
+}
+
+// shrink shrinks the filesystem of the given partition and then the partition itself
+func (r *Relayouter) shrink(change Change, bPart *block.Partition) (err error) {
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	var mountPoint string
+	if change.Partition.FileSystem == deployment.Btrfs {
+		if len(bPart.MountPoints) > 0 {
+			mountPoint = bPart.MountPoints[0]
+		} else {
+			mountPoint, err = vfs.TempDir(r.s.FS(), "", "elemental_relayout")
+			if err != nil {
+				return fmt.Errorf("creating temporary directory to mount partition: %w", err)
+			}
+			cleanup.Push(func() error { return r.s.FS().RemoveAll(mountPoint) })
+
+			err = r.s.Mounter().Mount(bPart.Path, mountPoint, "", []string{"rw"})
+			if err != nil {
+				return fmt.Errorf("mounting partition '%s': %w", bPart.Path, err)
+			}
+			cleanup.Push(func() error { return r.s.Mounter().Unmount(mountPoint) })
+		}
+	}
+
+	r.s.Logger().Info("Shrinking filesystem of partition '%s'", bPart.Path)
+	err = filesystem.Shrink(r.s, bPart.Path, mountPoint, change.Partition.FileSystem, change.Size)
+	if err != nil {
+		return err
+	}
+	return repart.ShrinkDevicePartition(r.s, bPart, change.Size)
+}
+
+// Hook returns the upgrade hook mounting the added partitions in the fstab of the new snapshot.
+// It runs at the upgrade.StageAfterMerge stage.
+func (r *Relayouter) Hook() upgrade.Hook {
+	return upgrade.NewHookFunc("relayout", func(_ context.Context, stage upgrade.Stage, root string) error {
+		if stage != upgrade.StageAfterMerge {
+			return nil
+		}
+
+		var newLines []fstab.Line
+		for _, change := range r.changes {
+			if change.Type != Add {
+				continue
+			}
+			if change.Partition.UUID == "" {
+				return fmt.Errorf("partition '%s' not created", change.Partition.Label)
+			}
+			newLines = append(newLines, fstab.Line{
+				Device:     fmt.Sprintf("PARTUUID=%s", change.Partition.UUID),
+				MountPoint: change.Partition.MountPoint,
+				FileSystem: change.Partition.FileSystem.String(),
+				Options:    change.Partition.MountOpts,
+				FsckOrder:  2,
+			})
+		}
+		if len(newLines) == 0 {
+			return nil
+		}
+
+		r.s.Logger().Info("Mounting the new partitions in the new snapshot")
+		fstabFile := filepath.Join(root, fstab.File)
+		lines, err := fstab.Read(r.s, fstabFile)
+		if err != nil {
+			return fmt.Errorf("reading fstab: %w", err)
+		}
+		lines = slices.DeleteFunc(lines, func(l fstab.Line) bool {
+			return slices.ContainsFunc(newLines, func(n fstab.Line) bool { return n.MountPoint == l.MountPoint })
+		})
+		err = fstab.Write(r.s, fstabFile, append(lines, newLines...))
+		if err != nil {
+			return fmt.Errorf("writing fstab: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,226 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package relayout_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/relayout"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+const lsblkData = `{
+	"blockdevices": [
+		{"partuuid": "efi-uuid", "path": "/dev/sda1", "pkname": "/dev/sda", "type": "part", "fstype": "vfat"},
+		{"partuuid": "sys-uuid", "path": "/dev/sda2", "pkname": "/dev/sda", "type": "part", "fstype": "btrfs",
+		 "mountpoints": ["/", "/var"]},
+		{"partuuid": "data-uuid", "path": "/dev/sda3", "pkname": "/dev/sda", "type": "part", "fstype": "btrfs",
+		 "mountpoints": ["/data"]}
+	]
+}`
+
+const sgdiskInfo = `Partition GUID code: 0FC63DAF-8483-4772-8E79-3D69D8477DE4 (Linux filesystem)
+Partition unique GUID: DATA-UUID
+First sector: 69208064 (at 33.0 GiB)
+Attribute flags: 0000000000000000
+Partition name: 'DATA'
+`
+
+func TestRelayoutSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Relayout test suite")
+}
+
+var _ = Describe("Layout changes", Label("relayout"), func() {
+	var runner *sysmock.Runner
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var d, target *deployment.Deployment
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/snapshot/etc/fstab":             "PARTUUID=data-uuid /data btrfs defaults 0 2\n",
+			"/sys/class/block/sda3/partition": "3\n",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			switch cmd {
+			case "lsblk":
+				return []byte(lsblkData), nil
+			case "systemd-repart":
+				return []byte("[]"), nil
+			case "sgdisk":
+				if args[0] == "--info=3" {
+					return []byte(sgdiskInfo), nil
+				}
+				if backup, ok := strings.CutPrefix(args[0], "--backup="); ok {
+					return nil, tfs.WriteFile(backup, []byte("gpt"), vfs.FilePerm)
+				}
+			}
+			return []byte{}, nil
+		}
+
+		d = deployment.DefaultDeployment()
+		d.Disks[0].Device = "/dev/sda"
+		d.GetSystemPartition().Size = 32768
+		d.GetSystemPartition().UUID = "sys-uuid"
+		d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+			Label: "DATA", Role: deployment.Generic, FileSystem: deployment.Btrfs, Size: 16384,
+			MountPoint: "/data", UUID: "data-uuid",
+		})
+		target, err = d.DeepCopy()
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("grows the last data partition and adds new partitions", func() {
+		target.Disks[0].Partitions[2].Size = 20480
+		target.Disks[0].Partitions = append(target.Disks[0].Partitions, &deployment.Partition{
+			Label: "LOGS", Role: deployment.Generic, FileSystem: deployment.XFS, MountPoint: "/var/log/app/",
+		})
+		r := relayout.New(context.Background(), s)
+		Expect(r.Plan(d, target.Disks)).To(Succeed())
+
+		Expect(r.Changes()).To(HaveLen(2))
+		Expect(r.Changes()[0].Type).To(Equal(relayout.Grow))
+		Expect(r.Changes()[1].Type).To(Equal(relayout.Add))
+		parts := d.Disks[0].Partitions
+		Expect(parts).To(HaveLen(4))
+		Expect(parts[2].Size).To(Equal(deployment.MiB(20480)))
+		Expect(parts[3].MountPoint).To(Equal("/var/log/app"))
+		Expect(parts[3].MountOpts).To(Equal([]string{"defaults"}))
+
+		Expect(r.Apply()).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"sgdisk", "--backup"},
+			{"systemd-repart", "--json=pretty"},
+			{"btrfs", "filesystem", "resize", "max", "/data"},
+		})).To(Succeed())
+		Expect(vfs.Exists(tfs, filepath.Join(relayout.BackupDir, "sda.gpt"))).To(BeTrue())
+	})
+	It("shrinks the filesystem before the partition", func() {
+		target.Disks[0].Partitions[2].Size = 8192
+		r := relayout.New(context.Background(), s)
+		Expect(r.Plan(d, target.Disks)).To(Succeed())
+		Expect(r.Changes()[0].Type).To(Equal(relayout.Shrink))
+
+		Expect(r.Apply()).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"sgdisk", "--backup"},
+			{"btrfs", "filesystem", "resize", "8192M", "/data"},
+			{"sgdisk", "--delete=3", "--new=3:69208064:+8192M"},
+			{"systemd-repart", "--json=pretty"},
+		})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"sgdisk", "--load-backup"}})).NotTo(Succeed())
+	})
+	It("restores the partition table if a change fails", func() {
+		target.Disks[0].Partitions[2].Size = 0
+		r := relayout.New(context.Background(), s)
+		Expect(r.Plan(d, target.Disks)).To(Succeed())
+
+		sideEffect := runner.SideEffect
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "systemd-repart" {
+				return nil, fmt.Errorf("not enough space")
+			}
+			return sideEffect(cmd, args...)
+		}
+		Expect(r.Apply()).To(MatchError(ContainSubstring("not enough space")))
+		Expect(runner.MatchMilestones([][]string{
+			{"sgdisk", "--backup"},
+			{"systemd-repart", "--json=pretty"},
+			{"sgdisk", "--load-backup"},
+		})).To(Succeed())
+	})
+	It("fails the preflight checks if an ext4 partition to shrink is mounted", func() {
+		d.Disks[0].Partitions[2].FileSystem = deployment.Ext4
+		target.Disks[0].Partitions[2].FileSystem = deployment.Ext4
+		target.Disks[0].Partitions[2].Size = 8192
+		r := relayout.New(context.Background(), s)
+		Expect(r.Plan(d, target.Disks)).To(Succeed())
+		Expect(r.Apply()).To(MatchError(ContainSubstring("must be unmounted")))
+		Expect(runner.IncludesCmds([][]string{{"sgdisk"}})).NotTo(Succeed())
+	})
+	It("rejects unsupported layout changes", func() {
+		r := relayout.New(context.Background(), s)
+
+		target.Disks[0].Partitions[1].Size = 40960
+		Expect(r.Plan(d, target.Disks)).To(MatchError(ContainSubstring("only the last partition")))
+
+		target.Disks[0].Partitions[1].Size = 32768
+		target.Disks[0].Partitions = target.Disks[0].Partitions[:2]
+		Expect(r.Plan(d, target.Disks)).To(MatchError(ContainSubstring("removing partitions")))
+
+		d.Disks[0].Partitions[2].FileSystem = deployment.XFS
+		target, _ = d.DeepCopy()
+		target.Disks[0].Partitions[2].Size = 8192
+		Expect(r.Plan(d, target.Disks)).To(MatchError(ContainSubstring("can't be shrunk")))
+
+		target.Disks[0].Partitions[2].Size = 0
+		target.Disks[0].Partitions = append(target.Disks[0].Partitions, &deployment.Partition{
+			Label: "LOGS", Role: deployment.Generic, FileSystem: deployment.XFS, MountPoint: "/var/log",
+		})
+		Expect(r.Plan(d, target.Disks)).To(MatchError(ContainSubstring("no free space left")))
+
+		target.Disks[0].Partitions[2].Size = 16384
+		target.Disks[0].Partitions[3].MountPoint = "/var"
+		Expect(r.Plan(d, target.Disks)).To(MatchError(ContainSubstring("already mounted")))
+		Expect(d.Disks[0].Partitions).To(HaveLen(3))
+	})
+	It("mounts the new partitions in the fstab of the new snapshot", func() {
+		target.Disks[0].Partitions = append(target.Disks[0].Partitions, &deployment.Partition{
+			Label: "LOGS", Role: deployment.Generic, FileSystem: deployment.XFS, MountPoint: "/var/log/app",
+		})
+		r := relayout.New(context.Background(), s)
+		Expect(r.Plan(d, target.Disks)).To(Succeed())
+
+		hook := r.Hook()
+		Expect(hook.Run(context.Background(), upgrade.StageAfterMerge, "/snapshot")).
+			To(MatchError(ContainSubstring("not created")))
+
+		d.Disks[0].Partitions[3].UUID = "logs-uuid"
+		Expect(hook.Run(context.Background(), upgrade.StageAfterMerge, "/snapshot")).To(Succeed())
+		data, err := tfs.ReadFile("/snapshot/etc/fstab")
+		Expect(err).NotTo(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		Expect(lines).To(HaveLen(2))
+		Expect(strings.Fields(lines[0])).To(Equal([]string{"PARTUUID=data-uuid", "/data", "btrfs", "defaults", "0", "2"}))
+		Expect(strings.Fields(lines[1])).To(Equal([]string{"PARTUUID=logs-uuid", "/var/log/app", "xfs", "defaults", "0", "2"}))
+	})
+})
@@ -0,0 +1,146 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// BackupDir is the directory, relative to the partition root, where partition table backups are stored
+	BackupDir = "partition-backup"
+
+	luksFS     = "crypto_LUKS"
+	gptExt     = ".gpt"
+	luksHdrExt = ".luks"
+)
+
+// BackupDevice dumps the partition table of the given device and the LUKS headers of any encrypted
+// partition it contains into the given directory. Backup files are named after the device and partition
+// base names, the partition table is dumped with the partitioner returned by NewPartitioner. It returns
+// false without creating any file if the device has no partitions.
+func BackupDevice(s *sys.System, b block.Device, device, dir string) (bool, error) {
+	parts, err := b.GetDevicePartitions(device)
+	if err != nil {
+		return false, fmt.Errorf("listing partitions of device '%s': %w", device, err)
+	}
+	if len(parts) == 0 {
+		s.Logger().Debug("No partitions found on device '%s', nothing to back up", device)
+		return false, nil
+	}
+
+	err = vfs.MkdirAll(s.FS(), dir, vfs.DirPerm)
+	if err != nil {
+		return false, fmt.Errorf("creating backup directory '%s': %w", dir, err)
+	}
+
+	p := NewPartitioner(s)
+	tableFile := p.BackupFile(device, dir)
+	s.Logger().Info("Backing up partition table of '%s' to '%s' with %s", device, tableFile, p.Name())
+	err = p.Backup(device, tableFile)
+	if err != nil {
+		return false, fmt.Errorf("backing up partition table of '%s': %w", device, err)
+	}
+
+	for _, part := range parts {
+		if part.FileSystem != luksFS {
+			continue
+		}
+		hdrFile := filepath.Join(dir, filepath.Base(part.Path)+luksHdrExt)
+		_ = s.FS().Remove(hdrFile)
+		s.Logger().Info("Backing up LUKS header of '%s' to '%s'", part.Path, hdrFile)
+		_, err = s.Runner().Run("cryptsetup", "luksHeaderBackup", part.Path, "--header-backup-file", hdrFile)
+		if err != nil {
+			return false, fmt.Errorf("backing up LUKS header of '%s': %w", part.Path, err)
+		}
+	}
+	return true, nil
+}
+
+// StoreBackup copies the partition table backups of the given directory into the BackupDir of the given
+// partition, typically the recovery or config partition, where restore-partitions looks for them
+func StoreBackup(ctx context.Context, s *sys.System, backupDir string, part *deployment.Partition) (err error) {
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	mountPoint, err := vfs.TempDir(s.FS(), "", "elemental_"+part.Role.String())
+	if err != nil {
+		return fmt.Errorf("creating temporary mount point: %w", err)
+	}
+	cleanup.PushSuccessOnly(func() error { return s.FS().RemoveAll(mountPoint) })
+
+	bPart, err := block.GetPartitionByUUID(s, lsblk.NewLsDevice(s), part.UUID, 4)
+	if err != nil {
+		return fmt.Errorf("finding partition '%s': %w", part.UUID, err)
+	}
+	err = s.Mounter().Mount(bPart.Path, mountPoint, "", []string{"rw"})
+	if err != nil {
+		return fmt.Errorf("mounting partition '%s': %w", bPart.Path, err)
+	}
+	cleanup.Push(func() error { return s.Mounter().Unmount(mountPoint) })
+
+	target := filepath.Join(mountPoint, BackupDir)
+	err = vfs.MkdirAll(s.FS(), target, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating backup directory: %w", err)
+	}
+	return vfs.CopyDirContext(ctx, s.FS(), backupDir, target, false, nil)
+}
+
+// RestoreDevice restores the partition table of the given device from the backup stored in the given
+// directory by BackupDevice, using the partitioner the backup was created with. Once the partition table
+// is restored, any LUKS header backup matching one of the restored partitions is also written back.
+func RestoreDevice(s *sys.System, b block.Device, device, dir string) error {
+	p, tableFile, ok := backupPartitioner(s, device, dir)
+	if !ok {
+		return fmt.Errorf("no partition table backup found for '%s' in '%s'", device, dir)
+	}
+
+	s.Logger().Info("Restoring partition table of '%s' from '%s' with %s", device, tableFile, p.Name())
+	err := p.Restore(device, tableFile)
+	if err != nil {
+		return fmt.Errorf("restoring partition table of '%s': %w", device, err)
+	}
+	notifyKernel(s, device)
+
+	parts, err := b.GetDevicePartitions(device)
+	if err != nil {
+		return fmt.Errorf("listing partitions of device '%s': %w", device, err)
+	}
+	for _, part := range parts {
+		hdrFile := filepath.Join(dir, filepath.Base(part.Path)+luksHdrExt)
+		if ok, _ := vfs.Exists(s.FS(), hdrFile); !ok {
+			continue
+		}
+		s.Logger().Info("Restoring LUKS header of '%s' from '%s'", part.Path, hdrFile)
+		_, err = s.Runner().Run("cryptsetup", "-q", "luksHeaderRestore", part.Path, "--header-backup-file", hdrFile)
+		if err != nil {
+			return fmt.Errorf("restoring LUKS header of '%s': %w", part.Path, err)
+		}
+	}
+	return nil
+}
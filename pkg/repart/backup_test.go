@@ -0,0 +1,106 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	blockmock "github.com/suse/elemental/v3/pkg/block/mock"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Partition table backup", Label("backup"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var bDev *blockmock.Device
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		bDev = blockmock.NewBlockDevice(
+			&block.Partition{Path: "/dev/sda1", Disk: "/dev/sda", FileSystem: "vfat"},
+			&block.Partition{Path: "/dev/sda2", Disk: "/dev/sda", FileSystem: "crypto_LUKS"},
+		)
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("backs up the partition table and LUKS headers", func() {
+		ok, err := repart.BackupDevice(s, bDev, "/dev/sda", "/backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(runner.CmdsMatch([][]string{
+			{"sgdisk", "--version"},
+			{"sgdisk", "--backup=/backup/sda.gpt", "/dev/sda"},
+			{"cryptsetup", "luksHeaderBackup", "/dev/sda2", "--header-backup-file", "/backup/sda2.luks"},
+		})).To(Succeed())
+	})
+
+	It("skips devices without partitions", func() {
+		ok, err := repart.BackupDevice(s, bDev, "/dev/sdb", "/backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+
+	It("fails if sgdisk fails", func() {
+		runner.ReturnError = fmt.Errorf("sgdisk failed")
+		_, err := repart.BackupDevice(s, bDev, "/dev/sda", "/backup")
+		Expect(err).To(MatchError(ContainSubstring("sgdisk failed")))
+	})
+
+	It("restores the partition table and LUKS headers", func() {
+		Expect(vfs.MkdirAll(fs, "/backup", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/backup/sda.gpt", []byte{}, vfs.FilePerm)).To(Succeed())
+		Expect(fs.WriteFile("/backup/sda2.luks", []byte{}, vfs.FilePerm)).To(Succeed())
+
+		Expect(repart.RestoreDevice(s, bDev, "/dev/sda", "/backup")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"sgdisk", "--load-backup=/backup/sda.gpt", "/dev/sda"},
+			{"partx", "-u", "/dev/sda"},
+			{"udevadm", "settle"},
+			{"cryptsetup", "-q", "luksHeaderRestore", "/dev/sda2", "--header-backup-file", "/backup/sda2.luks"},
+		})).To(Succeed())
+	})
+
+	It("fails to restore if there is no backup for the device", func() {
+		Expect(repart.RestoreDevice(s, bDev, "/dev/sda", "/backup")).To(
+			MatchError("no partition table backup found for '/dev/sda' in '/backup'"),
+		)
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+})
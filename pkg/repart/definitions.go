@@ -0,0 +1,107 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// DefinitionsDir is the systemd-repart drop-in directory definitions are written to within a root tree
+	DefinitionsDir = "/etc/repart.d"
+
+	definitionsGlob = "*-elemental-*.conf"
+
+	// growFSOption is the mount option systemd-fstab-generator turns into a systemd-growfs unit
+	growFSOption = "x-systemd.growfs"
+)
+
+// WriteDefinitions writes the partitions of the given disk as systemd-repart definitions into the
+// DefinitionsDir of the given root tree, so systemd-repart.service can create any missing partition and
+// grow the last one on boot. The filesystem of a grown partition is grown on mount too, by flagging it in
+// its fstab entry. Previously written definitions are replaced. As systemd-repart only operates on the
+// disk backing the root filesystem, this only makes sense for the system disk.
+func WriteDefinitions(s *sys.System, root string, d *deployment.Disk) error {
+	dir := filepath.Join(root, DefinitionsDir)
+	err := vfs.MkdirAll(s.FS(), dir, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating systemd-repart definitions directory: %w", err)
+	}
+
+	entries, err := s.FS().ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("listing previous systemd-repart definitions: %w", err)
+	}
+	for _, entry := range entries {
+		if ok, _ := filepath.Match(definitionsGlob, entry.Name()); !ok {
+			continue
+		}
+		err = s.FS().Remove(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("removing previous systemd-repart definition '%s': %w", entry.Name(), err)
+		}
+	}
+
+	for i, part := range d.Partitions {
+		p := Partition{Partition: part, Grow: d.ExpandPartitions && i == len(d.Partitions)-1}
+		file := filepath.Join(dir, fmt.Sprintf("%02d-elemental-%s.conf", (i+1)*10, part.Role.String()))
+		err = CreatePartitionConfFile(s, file, p)
+		if err != nil {
+			return err
+		}
+		if p.Grow {
+			err = growFileSystemOnMount(s, root, part)
+			if err != nil {
+				return fmt.Errorf("enabling filesystem growth of partition '%s': %w", part.Label, err)
+			}
+		}
+	}
+	return nil
+}
+
+// growFileSystemOnMount adds the growfs mount option to the first fstab entry of the given partition within
+// the given root tree, so systemd-growfs grows the filesystem once systemd-repart grew the partition.
+func growFileSystemOnMount(s *sys.System, root string, part *deployment.Partition) error {
+	fstabFile := filepath.Join(root, fstab.File)
+	if ok, _ := vfs.Exists(s.FS(), fstabFile); !ok {
+		s.Logger().Warn("No fstab found in '%s', the filesystem of partition '%s' will not be grown", root, part.Label)
+		return nil
+	}
+	lines, err := fstab.Read(s, fstabFile)
+	if err != nil {
+		return fmt.Errorf("reading fstab: %w", err)
+	}
+
+	i := slices.IndexFunc(lines, func(l fstab.Line) bool { return l.Device == part.FstabDevice() })
+	if i < 0 {
+		s.Logger().Warn("Partition '%s' is not mounted in fstab, its filesystem will not be grown", part.Label)
+		return nil
+	}
+	if slices.Contains(lines[i].Options, growFSOption) {
+		return nil
+	}
+	lines[i].Options = append(slices.Clone(lines[i].Options), growFSOption)
+	return fstab.Write(s, fstabFile, lines)
+}
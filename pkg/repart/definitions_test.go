@@ -0,0 +1,109 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("systemd-repart definitions", Label("definitions"), func() {
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var disk *deployment.Disk
+
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/root/etc/fstab": "PARTUUID=efi-uuid /boot/efi vfat defaults 0 2\n" +
+				"PARTUUID=sys-uuid / btrfs ro 0 1\n" +
+				"PARTUUID=sys-uuid /var btrfs subvol=@/var 0 0\n",
+			"/root/etc/repart.d/30-elemental-generic.conf": "[Partition]\n",
+			"/root/etc/repart.d/50-custom.conf":            "[Partition]\n",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(sysmock.NewRunner()), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		disk = &deployment.Disk{
+			Partitions: deployment.Partitions{
+				{Label: "EFI", Role: deployment.EFI, UUID: "efi-uuid", FileSystem: deployment.VFat, Size: 1024},
+				{Label: "SYSTEM", Role: deployment.System, UUID: "sys-uuid", FileSystem: deployment.Btrfs, Size: 8192},
+			},
+			ExpandPartitions: true,
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("writes a definition per partition replacing the previous ones", func() {
+		Expect(repart.WriteDefinitions(s, "/root", disk)).To(Succeed())
+
+		dir := filepath.Join("/root", repart.DefinitionsDir)
+		entries, err := fs.ReadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		Expect(names).To(ConsistOf("10-elemental-efi.conf", "20-elemental-system.conf", "50-custom.conf"))
+
+		data, err := fs.ReadFile(filepath.Join(dir, "10-elemental-efi.conf"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("Label=EFI"))
+		Expect(string(data)).To(ContainSubstring("UUID=efi-uuid"))
+		Expect(string(data)).To(ContainSubstring("SizeMinBytes=1024M"))
+
+		data, err = fs.ReadFile(filepath.Join(dir, "20-elemental-system.conf"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("Label=SYSTEM"))
+		Expect(string(data)).NotTo(ContainSubstring("SizeMaxBytes"))
+		Expect(string(data)).To(ContainSubstring("GrowFileSystem=yes"))
+	})
+	It("grows the filesystem of the last partition on mount", func() {
+		Expect(repart.WriteDefinitions(s, "/root", disk)).To(Succeed())
+
+		lines, err := fstab.Read(s, "/root/etc/fstab")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines).To(HaveLen(3))
+		Expect(lines[0].Options).To(Equal([]string{"defaults"}))
+		Expect(lines[1].Options).To(Equal([]string{"ro", "x-systemd.growfs"}))
+		Expect(lines[2].Options).To(Equal([]string{"subvol=@/var"}))
+
+		// Writing the definitions again does not duplicate the option
+		Expect(repart.WriteDefinitions(s, "/root", disk)).To(Succeed())
+		lines, err = fstab.Read(s, "/root/etc/fstab")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines[1].Options).To(Equal([]string{"ro", "x-systemd.growfs"}))
+	})
+})
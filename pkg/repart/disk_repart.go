@@ -30,6 +30,7 @@ import (
 	"text/template"
 
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/progress"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"golang.org/x/sys/unix"
@@ -40,6 +41,7 @@ const (
 	rootArchType = "root-%s"
 	genericType  = "linux-generic"
 	espType      = "esp"
+	swapType     = "swap"
 
 	// Custom types defined by Elemental as none of the predefined types is a clear match to those partition roles
 	// Do not change these values as this could break backward compatibility on already installed systems (e.g. reseting a system)
@@ -58,13 +60,29 @@ type Partition struct {
 	// Excludes is a list of paths to exclude from the host to be copied into the partition, uses
 	// ExcludeFiles syntax as defined in repart.d(5) man pages
 	Excludes []string
+	// Grow ignores the partition size and lets the partition take all the remaining disk space
+	Grow bool
+}
+
+// Option configures how systemd-repart creates the partitions of a disk
+type Option func(*options)
+
+type options struct {
+	keyFile string
+}
+
+// WithKeyFile sets the file holding the passphrase of the LUKS volumes of encrypted partitions
+func WithKeyFile(keyFile string) Option {
+	return func(o *options) {
+		o.keyFile = keyFile
+	}
 }
 
 // PartitionAndFormatDevice creates a new empty partition table on target disk
 // and applies the configured disk layout by creating and formatting all
 // required partitions.
-func PartitionAndFormatDevice(s *sys.System, d *deployment.Disk) error {
-	err := repartDisk(s, d, "force")
+func PartitionAndFormatDevice(s *sys.System, d *deployment.Disk, opts ...Option) error {
+	err := repartDisk(s, d, "force", opts...)
 	if err != nil {
 		return fmt.Errorf("failed creating the new partition table: %w", err)
 	}
@@ -76,8 +94,8 @@ func PartitionAndFormatDevice(s *sys.System, d *deployment.Disk) error {
 // ReconcileDevicePartitions attempts to match the given disk layout with the current device.
 // It attempts to extend an existing partition table or create a new one if none exists. It does not
 // remove any pre-existing partition.
-func ReconcileDevicePartitions(s *sys.System, d *deployment.Disk) error {
-	err := repartDisk(s, d, "allow")
+func ReconcileDevicePartitions(s *sys.System, d *deployment.Disk, opts ...Option) error {
+	err := repartDisk(s, d, "allow", opts...)
 	if err != nil {
 		return fmt.Errorf("failed updating the current partition table: %w", err)
 	}
@@ -123,6 +141,9 @@ func CreatePartitionConf(s *sys.System, wr io.Writer, p Partition) error {
 	if pType == deployment.Unknown {
 		return fmt.Errorf("invalid partition role: %s", p.Partition.Role.String())
 	}
+	if p.Partition.TypeUUID != "" {
+		pType = p.Partition.TypeUUID
+	}
 
 	for _, copy := range p.CopyFiles {
 		path := strings.Split(copy, ":")[0]
@@ -131,6 +152,11 @@ func CreatePartitionConf(s *sys.System, wr io.Writer, p Partition) error {
 		}
 	}
 
+	size := p.Partition.Size
+	if p.Grow {
+		size = 0
+	}
+
 	values := struct {
 		Type      string
 		Format    string
@@ -140,15 +166,21 @@ func CreatePartitionConf(s *sys.System, wr io.Writer, p Partition) error {
 		CopyFiles []string
 		Excludes  []string
 		ReadOnly  string
+		Flags     uint64
+		Encrypt   bool
+		GrowFS    bool
 	}{
 		Type:      pType,
-		Format:    fileSystemToFormat(p.Partition.FileSystem),
-		Size:      p.Partition.Size,
+		Format:    partitionFormat(p.Partition),
+		Size:      size,
 		Label:     p.Partition.Label,
 		UUID:      p.Partition.UUID,
 		CopyFiles: p.CopyFiles,
 		Excludes:  p.Excludes,
 		ReadOnly:  readOnlyPart(p.Partition),
+		Flags:     p.Partition.GPTAttributes(),
+		Encrypt:   p.Partition.Encrypted,
+		GrowFS:    p.Grow && p.Partition.Role != deployment.Swap && partitionFormat(p.Partition) != "",
 	}
 
 	partCfg := template.New("partition")
@@ -170,13 +202,30 @@ func notifyKernel(s *sys.System, device string) {
 
 // repartDisk generates the systemd-repart configuration according to the given disk and runs systemd-repart with the given
 // empty flag.
-func repartDisk(s *sys.System, d *deployment.Disk, empty string) (err error) {
+func repartDisk(s *sys.System, d *deployment.Disk, empty string, opts ...Option) (err error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	parts := make([]Partition, len(d.Partitions))
+	encrypted := false
 	for i, part := range d.Partitions {
 		parts[i] = Partition{Partition: part}
+		encrypted = encrypted || (part != nil && part.Encrypted)
+	}
+	if d.ExpandPartitions && len(parts) > 0 {
+		parts[len(parts)-1].Grow = true
 	}
 
-	return runSystemdRepart(s, d.Device, parts, fmt.Sprintf("--empty=%s", empty))
+	flags := []string{fmt.Sprintf("--empty=%s", empty)}
+	if encrypted {
+		if o.keyFile == "" {
+			return fmt.Errorf("a key file is required to create encrypted partitions")
+		}
+		flags = append(flags, fmt.Sprintf("--key-file=%s", o.keyFile))
+	}
+	return runSystemdRepart(s, d.Device, parts, flags...)
 }
 
 // runSystemdRepart runs systemd-repart for the given partitions and target device. It appends to the generated command the
@@ -220,10 +269,12 @@ func runSystemdRepart(s *sys.System, target string, parts []Partition, flags ...
 	}
 	args = append(args, target)
 
+	progress.Start(s.Progress(), progress.StepPartition, target)
 	out, err := s.Runner().RunEnv("systemd-repart", []string{"PATH=/sbin:/usr/sbin:/usr/bin:/bin"}, args...)
 	if err != nil {
 		return fmt.Errorf("failed partitioning disk '%s' with systemd-repart: %w", target, err)
 	}
+	progress.Done(s.Progress(), progress.StepPartition, target)
 	uuids := []struct {
 		UUID string `json:"uuid,omitempty"`
 		File string `json:"file,omitempty"`
@@ -262,11 +313,21 @@ func roleToType(s *sys.System, role deployment.PartRole) string {
 		return recoveryType
 	case deployment.Config:
 		return configType
+	case deployment.Swap:
+		return swapType
 	default:
 		return deployment.Unknown
 	}
 }
 
+// partitionFormat returns the format of the given partition, swap partitions are formatted as swap space
+func partitionFormat(part *deployment.Partition) string {
+	if part.Role == deployment.Swap {
+		return "swap"
+	}
+	return fileSystemToFormat(part.FileSystem)
+}
+
 func fileSystemToFormat(f deployment.FileSystem) string {
 	switch {
 	case f.String() == deployment.Unknown:
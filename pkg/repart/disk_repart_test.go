@@ -118,6 +118,35 @@ var _ = Describe("Systemd-repart tests", Label("systemd-repart"), func() {
 		Expect(buffer.String()).ToNot(ContainSubstring("UUID"))
 	})
 
+	It("creates a swap partition configuration", func() {
+		var buffer bytes.Buffer
+		part := &deployment.Partition{
+			Label: "SWAP",
+			Role:  deployment.Swap,
+			Size:  2048,
+		}
+
+		Expect(repart.CreatePartitionConf(s, &buffer, repart.Partition{Partition: part})).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("Type=swap"))
+		Expect(buffer.String()).To(ContainSubstring("Format=swap"))
+		Expect(buffer.String()).To(ContainSubstring("SizeMinBytes=2048M"))
+	})
+
+	It("creates a partition configuration with a custom type and GPT attributes", func() {
+		var buffer bytes.Buffer
+		part := &deployment.Partition{
+			Label:              "DATA",
+			Role:               deployment.Generic,
+			TypeUUID:           "0fc63daf-8483-4772-8e79-3d69d8477de4",
+			LegacyBIOSBootable: true,
+			Attributes:         []uint{59, 60},
+		}
+
+		Expect(repart.CreatePartitionConf(s, &buffer, repart.Partition{Partition: part})).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("Type=0fc63daf-8483-4772-8e79-3d69d8477de4"))
+		Expect(buffer.String()).To(ContainSubstring("Flags=0x1800000000000004"))
+	})
+
 	It("creates a partition configuration file", func() {
 		part := &deployment.Partition{
 			Label: "SYSTEM",
@@ -197,6 +226,24 @@ var _ = Describe("Systemd-repart tests", Label("systemd-repart"), func() {
 		}}))
 	})
 
+	It("creates the LUKS volumes of encrypted partitions with the key file", func() {
+		d := deployment.DefaultDeployment()
+		d.Disks[0].Device = "/dev/device"
+		d.Disks[0].Partitions[1].Encrypted = true
+		Expect(repart.PartitionAndFormatDevice(s, d.Disks[0])).To(
+			MatchError(ContainSubstring("a key file is required to create encrypted partitions")),
+		)
+		Expect(repart.PartitionAndFormatDevice(s, d.Disks[0], repart.WithKeyFile("/etc/luks.key"))).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{{
+			"systemd-repart", "--json=pretty", "--definitions=/tmp/elemental-repart.d",
+			"--dry-run=no", "--empty=force", "--key-file=/etc/luks.key", "/dev/device",
+		}})).To(Succeed())
+
+		var buffer bytes.Buffer
+		Expect(repart.CreatePartitionConf(s, &buffer, repart.Partition{Partition: d.Disks[0].Partitions[1]})).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("Encrypt=key-file"))
+	})
+
 	It("fails if systemd-repart does not return a valid json", func() {
 		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
 			return []byte{}, runner.ReturnError
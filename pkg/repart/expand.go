@@ -0,0 +1,124 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// ExpandUnitName is the systemd unit expanding the partitions of the disks on first boot
+const ExpandUnitName = "elemental-expand-partitions.service"
+
+//go:embed templates/elemental-expand-partitions.service
+var expandUnit []byte
+
+// ExpandDevicePartitions grows the last partition of the given disk to take all the remaining disk space and
+// grows its filesystem accordingly. The partition table must already exist and match the disk layout.
+func ExpandDevicePartitions(s *sys.System, d *deployment.Disk) error {
+	if len(d.Partitions) == 0 {
+		return fmt.Errorf("no partitions defined for disk '%s'", d.Device)
+	}
+
+	parts := make([]Partition, len(d.Partitions))
+	for i, part := range d.Partitions {
+		parts[i] = Partition{Partition: part}
+	}
+	last := parts[len(parts)-1]
+	last.Grow = true
+	parts[len(parts)-1] = last
+
+	s.Logger().Info("Expanding partition '%s' of disk '%s'", last.Partition.Label, d.Device)
+	err := runSystemdRepart(s, d.Device, parts, "--empty=refuse")
+	if err != nil {
+		return fmt.Errorf("failed expanding the partitions of disk '%s': %w", d.Device, err)
+	}
+	notifyKernel(s, d.Device)
+
+	return GrowFileSystem(s, last.Partition)
+}
+
+// EnableExpandOnBoot installs and enables in the given root tree the systemd unit expanding the
+// partitions of the disks on first boot, so a disk image flashed to a larger disk fills it.
+func EnableExpandOnBoot(s *sys.System, root string) error {
+	unitDir := filepath.Join(root, "etc", "systemd", "system")
+	wantsDir := filepath.Join(unitDir, "multi-user.target.wants")
+	err := vfs.MkdirAll(s.FS(), wantsDir, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating systemd unit directory: %w", err)
+	}
+
+	unitFile := filepath.Join(unitDir, ExpandUnitName)
+	err = s.FS().WriteFile(unitFile, expandUnit, vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing unit '%s': %w", unitFile, err)
+	}
+
+	link := filepath.Join(wantsDir, ExpandUnitName)
+	_ = s.FS().Remove(link)
+	err = s.FS().Symlink(filepath.Join("/etc/systemd/system", ExpandUnitName), link)
+	if err != nil {
+		return fmt.Errorf("enabling unit '%s': %w", ExpandUnitName, err)
+	}
+	return nil
+}
+
+// GrowFileSystem grows the filesystem of the given partition. Filesystems not mounted in the
+// running system are temporarily mounted to be grown online.
+func GrowFileSystem(s *sys.System, part *deployment.Partition) (err error) {
+	bPart, err := block.GetPartitionByUUID(s, lsblk.NewLsDevice(s), part.UUID, 4)
+	if err != nil {
+		return fmt.Errorf("finding partition '%s': %w", part.UUID, err)
+	}
+
+	var mountPoint string
+	for _, mnt := range bPart.MountPoints {
+		// The root is a read-only snapshot, prefer any other mount point
+		if mountPoint == "" || mountPoint == "/" {
+			mountPoint = mnt
+		}
+	}
+	if mountPoint == "" {
+		cleanup := cleanstack.NewCleanStack()
+		defer func() { err = cleanup.Cleanup(err) }()
+
+		mountPoint, err = vfs.TempDir(s.FS(), "", "elemental_expand")
+		if err != nil {
+			return fmt.Errorf("creating temporary directory to mount partition: %w", err)
+		}
+		cleanup.Push(func() error { return s.FS().RemoveAll(mountPoint) })
+
+		err = s.Mounter().Mount(bPart.Path, mountPoint, "", []string{"rw"})
+		if err != nil {
+			return fmt.Errorf("mounting partition '%s': %w", bPart.Path, err)
+		}
+		cleanup.Push(func() error { return s.Mounter().Unmount(mountPoint) })
+	}
+
+	s.Logger().Info("Growing filesystem of partition '%s'", bPart.Path)
+	return filesystem.Grow(s, bPart.Path, mountPoint, part.FileSystem)
+}
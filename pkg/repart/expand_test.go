@@ -0,0 +1,119 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart_test
+
+import (
+	"bytes"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const lsblkExpand = `{
+	"blockdevices": [
+		{"partuuid": "efi-uuid", "path": "/dev/sda1", "pkname": "/dev/sda", "type": "part", "fstype": "vfat"},
+		{"partuuid": "sys-uuid", "path": "/dev/sda2", "pkname": "/dev/sda", "type": "part", "fstype": "btrfs",
+		 "mountpoints": ["/", "/var"]}
+	]
+}`
+
+var _ = Describe("Partitions expansion", Label("expand"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var disk *deployment.Disk
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			switch cmd {
+			case "systemd-repart":
+				return []byte("[]"), nil
+			case "lsblk":
+				return []byte(lsblkExpand), nil
+			}
+			return []byte{}, nil
+		}
+		disk = &deployment.Disk{
+			Device: "/dev/sda",
+			Partitions: deployment.Partitions{
+				{Label: "EFI", Role: deployment.EFI, UUID: "efi-uuid", FileSystem: deployment.VFat, Size: 1024},
+				{Label: "SYSTEM", Role: deployment.System, UUID: "sys-uuid", FileSystem: deployment.Btrfs, Size: 8192},
+			},
+			ExpandPartitions: true,
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("ignores the size of the last partition when expanding partitions", func() {
+		var buffer bytes.Buffer
+		part := &deployment.Partition{Label: "SYSTEM", Role: deployment.System, Size: 8192}
+		Expect(repart.CreatePartitionConf(s, &buffer, repart.Partition{Partition: part, Grow: true})).To(Succeed())
+		Expect(buffer.String()).ToNot(ContainSubstring("SizeMinBytes"))
+		Expect(buffer.String()).ToNot(ContainSubstring("SizeMaxBytes"))
+	})
+
+	It("grows the last partition and its filesystem", func() {
+		Expect(repart.ExpandDevicePartitions(s, disk)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"systemd-repart", "--json=pretty"},
+			{"btrfs", "filesystem", "resize", "max", "/var"},
+		})).To(Succeed())
+		Expect(disk.Partitions[1].Size).To(Equal(deployment.MiB(8192)))
+	})
+
+	It("fails if the last partition is not found", func() {
+		disk.Partitions[1].UUID = "unknown-uuid"
+		Expect(repart.ExpandDevicePartitions(s, disk)).To(MatchError(ContainSubstring("finding partition")))
+	})
+
+	It("installs and enables the unit expanding partitions on boot", func() {
+		Expect(repart.EnableExpandOnBoot(s, "/root")).To(Succeed())
+
+		unitDir := "/root/etc/systemd/system"
+		data, err := fs.ReadFile(filepath.Join(unitDir, repart.ExpandUnitName))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("ExecStart=/usr/bin/elemental3ctl expand-partitions"))
+
+		target, err := fs.Readlink(filepath.Join(unitDir, "multi-user.target.wants", repart.ExpandUnitName))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(target).To(HaveSuffix(filepath.Join("/etc/systemd/system", repart.ExpandUnitName)))
+
+		Expect(repart.EnableExpandOnBoot(s, "/root")).To(Succeed())
+	})
+})
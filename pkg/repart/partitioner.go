@@ -0,0 +1,220 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	sgdiskCmd = "sgdisk"
+	sfdiskCmd = "sfdisk"
+
+	sfdiskExt = ".sfdisk"
+)
+
+var (
+	firstSectorReg = regexp.MustCompile(`(?m)^First sector: (\d+)`)
+	typeCodeReg    = regexp.MustCompile(`(?m)^Partition GUID code: ([0-9A-Fa-f-]+)`)
+	uniqueGUIDReg  = regexp.MustCompile(`(?m)^Partition unique GUID: ([0-9A-Fa-f-]+)`)
+	attributesReg  = regexp.MustCompile(`(?m)^Attribute flags: ([0-9A-Fa-f]+)`)
+	partNameReg    = regexp.MustCompile(`(?m)^Partition name: '(.*)'`)
+)
+
+// Partitioner edits partition tables for the operations systemd-repart does not cover: backing up and
+// restoring a whole partition table and shrinking a partition entry.
+type Partitioner interface {
+	// Name returns the name of the partitioning tool
+	Name() string
+	// BackupFile returns the path of the partition table backup of the given device in the given directory
+	BackupFile(device, dir string) string
+	// Backup dumps the partition table of the given device into the given file
+	Backup(device, file string) error
+	// Restore writes back the partition table of the given device from the given file
+	Restore(device, file string) error
+	// Shrink resizes the partition with the given number of the given disk to the given size, keeping
+	// its start sector, type, unique GUID, attributes and name
+	Shrink(disk, num string, size deployment.MiB) error
+	// Delete removes the partition with the given number from the partition table of the given disk
+	Delete(disk, num string) error
+}
+
+// NewPartitioner returns the partitioner backed by sgdisk, unless sgdisk is not installed and sfdisk is.
+// sgdisk is also returned if none of them is installed, so failures report the preferred tool.
+func NewPartitioner(s *sys.System) Partitioner {
+	if !commandNotFound(s, sgdiskCmd) {
+		return NewSgdiskPartitioner(s)
+	}
+	if !commandNotFound(s, sfdiskCmd) {
+		s.Logger().Debug("%s not found, falling back to %s", sgdiskCmd, sfdiskCmd)
+		return NewSfdiskPartitioner(s)
+	}
+	return NewSgdiskPartitioner(s)
+}
+
+// backupPartitioner returns the partitioner which created the partition table backup of the given
+// device in the given directory, if any
+func backupPartitioner(s *sys.System, device, dir string) (Partitioner, string, bool) {
+	for _, p := range []Partitioner{NewSgdiskPartitioner(s), NewSfdiskPartitioner(s)} {
+		file := p.BackupFile(device, dir)
+		if ok, _ := vfs.Exists(s.FS(), file); ok {
+			return p, file, true
+		}
+	}
+	return nil, "", false
+}
+
+func commandNotFound(s *sys.System, command string) bool {
+	_, err := s.Runner().Run(command, "--version")
+	return errors.Is(err, exec.ErrNotFound)
+}
+
+type sgdiskPartitioner struct {
+	s *sys.System
+}
+
+// NewSgdiskPartitioner returns a partitioner backed by sgdisk, which only handles GPT partition tables
+func NewSgdiskPartitioner(s *sys.System) Partitioner {
+	return sgdiskPartitioner{s: s}
+}
+
+func (p sgdiskPartitioner) Name() string {
+	return sgdiskCmd
+}
+
+func (p sgdiskPartitioner) BackupFile(device, dir string) string {
+	return filepath.Join(dir, filepath.Base(device)+gptExt)
+}
+
+func (p sgdiskPartitioner) Backup(device, file string) error {
+	_, err := p.s.Runner().Run(sgdiskCmd, fmt.Sprintf("--backup=%s", file), device)
+	return err
+}
+
+func (p sgdiskPartitioner) Restore(device, file string) error {
+	_, err := p.s.Runner().Run(sgdiskCmd, fmt.Sprintf("--load-backup=%s", file), device)
+	return err
+}
+
+func (p sgdiskPartitioner) Shrink(disk, num string, size deployment.MiB) error {
+	out, err := p.s.Runner().Run(sgdiskCmd, fmt.Sprintf("--info=%s", num), disk)
+	if err != nil {
+		return fmt.Errorf("reading partition '%s' of '%s': %w", num, disk, err)
+	}
+	info := map[string]string{}
+	for _, field := range []struct {
+		key string
+		reg *regexp.Regexp
+	}{
+		{"start", firstSectorReg}, {"type", typeCodeReg}, {"guid", uniqueGUIDReg}, {"attrs", attributesReg}, {"name", partNameReg},
+	} {
+		match := field.reg.FindSubmatch(out)
+		if match == nil {
+			return fmt.Errorf("parsing partition '%s' of '%s': no %s found", num, disk, field.key)
+		}
+		info[field.key] = string(match[1])
+	}
+
+	_, err = p.s.Runner().Run(
+		sgdiskCmd, fmt.Sprintf("--delete=%s", num),
+		fmt.Sprintf("--new=%s:%s:+%dM", num, info["start"], size),
+		fmt.Sprintf("--typecode=%s:%s", num, info["type"]),
+		fmt.Sprintf("--partition-guid=%s:%s", num, info["guid"]),
+		fmt.Sprintf("--attributes=%s:=:%s", num, info["attrs"]),
+		fmt.Sprintf("--change-name=%s:%s", num, info["name"]),
+		disk,
+	)
+	return err
+}
+
+func (p sgdiskPartitioner) Delete(disk, num string) error {
+	_, err := p.s.Runner().Run(sgdiskCmd, fmt.Sprintf("--delete=%s", num), disk)
+	return err
+}
+
+type sfdiskPartitioner struct {
+	s *sys.System
+}
+
+// NewSfdiskPartitioner returns a partitioner backed by sfdisk. Backups are sfdisk dumps, which can't be
+// restored with sgdisk and the other way around.
+func NewSfdiskPartitioner(s *sys.System) Partitioner {
+	return sfdiskPartitioner{s: s}
+}
+
+func (p sfdiskPartitioner) Name() string {
+	return sfdiskCmd
+}
+
+func (p sfdiskPartitioner) BackupFile(device, dir string) string {
+	return filepath.Join(dir, filepath.Base(device)+sfdiskExt)
+}
+
+func (p sfdiskPartitioner) Backup(device, file string) error {
+	out, err := p.s.Runner().Run(sfdiskCmd, "--dump", device)
+	if err != nil {
+		return err
+	}
+	return p.s.FS().WriteFile(file, out, vfs.FilePerm)
+}
+
+func (p sfdiskPartitioner) Restore(device, file string) error {
+	dump, err := p.s.FS().ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return p.script(device, dump)
+}
+
+// Shrink only sets the size of the partition, sfdisk keeps any other unspecified field of the entry
+func (p sfdiskPartitioner) Shrink(disk, num string, size deployment.MiB) error {
+	return p.script(disk, fmt.Appendf(nil, ",%dMiB\n", size), "-N", num)
+}
+
+func (p sfdiskPartitioner) Delete(disk, num string) error {
+	_, err := p.s.Runner().Run(sfdiskCmd, "--no-reread", "--no-tell-kernel", "--delete", disk, num)
+	return err
+}
+
+// script feeds the given sfdisk script to sfdisk for the given device. Partitions of the device are
+// usually in use, so the kernel is notified by the caller instead of sfdisk.
+func (p sfdiskPartitioner) script(device string, script []byte, flags ...string) error {
+	var out bytes.Buffer
+	args := append([]string{"--no-reread", "--no-tell-kernel"}, flags...)
+	err := p.s.Runner().RunContextWithPipe(
+		context.Background(), func(w io.Writer) error {
+			_, err := w.Write(script)
+			return err
+		}, &out, &out, "", nil, sfdiskCmd, append(args, device)...,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out.Bytes()))
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart_test
+
+import (
+	"os/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	blockmock "github.com/suse/elemental/v3/pkg/block/mock"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const sfdiskDump = `label: gpt
+device: /dev/sda
+unit: sectors
+
+/dev/sda1 : start=2048, size=2097152, type=C12A7328-F81F-11D2-BA4B-00A0C93EC93B, name="EFI"
+`
+
+var _ = Describe("Partitioner", Label("partitioner"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var missing map[string]bool
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/sys/class/block/sda3/partition": "3\n",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		missing = map[string]bool{}
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if missing[cmd] {
+				return nil, &exec.Error{Name: cmd, Err: exec.ErrNotFound}
+			}
+			if cmd == "sfdisk" && args[0] == "--dump" {
+				return []byte(sfdiskDump), nil
+			}
+			return []byte{}, nil
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("prefers sgdisk", func() {
+		Expect(repart.NewPartitioner(s).Name()).To(Equal("sgdisk"))
+	})
+
+	It("falls back to sfdisk if sgdisk is not installed", func() {
+		missing["sgdisk"] = true
+		Expect(repart.NewPartitioner(s).Name()).To(Equal("sfdisk"))
+	})
+
+	It("keeps sgdisk if sfdisk is not installed either", func() {
+		missing["sgdisk"] = true
+		missing["sfdisk"] = true
+		Expect(repart.NewPartitioner(s).Name()).To(Equal("sgdisk"))
+	})
+
+	It("backs up and restores the partition table with sfdisk", func() {
+		missing["sgdisk"] = true
+		bDev := blockmock.NewBlockDevice(&block.Partition{Path: "/dev/sda1", Disk: "/dev/sda", FileSystem: "vfat"})
+
+		ok, err := repart.BackupDevice(s, bDev, "/dev/sda", "/backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		data, err := fs.ReadFile("/backup/sda.sfdisk")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(sfdiskDump))
+
+		runner.ClearCmds()
+		Expect(repart.RestoreDevice(s, bDev, "/dev/sda", "/backup")).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"sfdisk", "--no-reread", "--no-tell-kernel", "/dev/sda"},
+			{"partx", "-u", "/dev/sda"},
+		})).To(Succeed())
+	})
+
+	It("restores an sgdisk backup with sgdisk even if sfdisk is preferred", func() {
+		missing["sgdisk"] = true
+		Expect(vfs.MkdirAll(fs, "/backup", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/backup/sda.gpt", []byte{}, vfs.FilePerm)).To(Succeed())
+		bDev := blockmock.NewBlockDevice()
+
+		Expect(repart.RestoreDevice(s, bDev, "/dev/sda", "/backup")).To(MatchError(ContainSubstring("executable file not found")))
+		Expect(runner.IncludesCmds([][]string{{"sgdisk", "--load-backup=/backup/sda.gpt", "/dev/sda"}})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"sfdisk", "--no-reread"}})).NotTo(Succeed())
+	})
+
+	It("shrinks a partition with sfdisk", func() {
+		missing["sgdisk"] = true
+		bPart := &block.Partition{Path: "/dev/sda3", Disk: "/dev/sda"}
+		Expect(repart.ShrinkDevicePartition(s, bPart, 8192)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"sfdisk", "--no-reread", "--no-tell-kernel", "-N", "3", "/dev/sda"},
+		})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"sgdisk", "--delete=3"}})).NotTo(Succeed())
+	})
+
+	It("deletes a partition with sfdisk", func() {
+		Expect(repart.NewSfdiskPartitioner(s).Delete("/dev/sda", "3")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"sfdisk", "--no-reread", "--no-tell-kernel", "--delete", "/dev/sda", "3"},
+		})).To(Succeed())
+	})
+})
@@ -0,0 +1,79 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// ShrinkDevicePartition shrinks the given partition to the given size. systemd-repart never shrinks
+// partitions, so the partition entry is resized with the partitioner returned by NewPartitioner keeping
+// its start sector, type, unique GUID, attributes and name. The filesystem of the partition must already
+// fit in the new size.
+func ShrinkDevicePartition(s *sys.System, bPart *block.Partition, size deployment.MiB) error {
+	if size == 0 {
+		return fmt.Errorf("shrinking partition '%s' requires a size", bPart.Path)
+	}
+
+	num, err := partitionNumber(s, bPart.Path)
+	if err != nil {
+		return err
+	}
+
+	p := NewPartitioner(s)
+	s.Logger().Info("Shrinking partition '%s' to %dMiB with %s", bPart.Path, size, p.Name())
+	err = p.Shrink(bPart.Disk, num, size)
+	if err != nil {
+		return fmt.Errorf("shrinking partition '%s': %w", bPart.Path, err)
+	}
+	notifyKernel(s, bPart.Disk)
+	return nil
+}
+
+// DeleteDevicePartition removes the given partition from the partition table of its disk with the
+// partitioner returned by NewPartitioner
+func DeleteDevicePartition(s *sys.System, bPart *block.Partition) error {
+	num, err := partitionNumber(s, bPart.Path)
+	if err != nil {
+		return err
+	}
+
+	p := NewPartitioner(s)
+	s.Logger().Info("Deleting partition '%s' with %s", bPart.Path, p.Name())
+	err = p.Delete(bPart.Disk, num)
+	if err != nil {
+		return fmt.Errorf("deleting partition '%s': %w", bPart.Path, err)
+	}
+	notifyKernel(s, bPart.Disk)
+	return nil
+}
+
+// partitionNumber returns the number of the given partition within its disk partition table
+func partitionNumber(s *sys.System, path string) (string, error) {
+	data, err := s.FS().ReadFile(filepath.Join("/sys/class/block", filepath.Base(path), "partition"))
+	if err != nil {
+		return "", fmt.Errorf("reading partition number of '%s': %w", path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
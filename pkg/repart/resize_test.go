@@ -0,0 +1,91 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+)
+
+const sgdiskInfo = `Partition GUID code: 0FC63DAF-8483-4772-8E79-3D69D8477DE4 (Linux filesystem)
+Partition unique GUID: 6E1F4E7B-7F4B-4B8B-9E3A-1B0C2D3E4F50
+First sector: 16779264 (at 8.0 GiB)
+Last sector: 50333695 (at 24.0 GiB)
+Partition size: 33554432 sectors (16.0 GiB)
+Attribute flags: 0000000000000000
+Partition name: 'DATA'
+`
+
+var _ = Describe("Partition resize", Label("resize"), func() {
+	var runner *sysmock.Runner
+	var cleanup func()
+	var s *sys.System
+	var bPart *block.Partition
+
+	BeforeEach(func() {
+		runner = sysmock.NewRunner()
+		fs, c, err := sysmock.TestFS(map[string]string{
+			"/sys/class/block/sda3/partition": "3\n",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		cleanup = c
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "sgdisk" && args[0] == "--info=3" {
+				return []byte(sgdiskInfo), nil
+			}
+			return []byte{}, nil
+		}
+		bPart = &block.Partition{Path: "/dev/sda3", Disk: "/dev/sda"}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("recreates the partition entry with the new size", func() {
+		Expect(repart.ShrinkDevicePartition(s, bPart, 8192)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{
+			"sgdisk", "--delete=3", "--new=3:16779264:+8192M",
+			"--typecode=3:0FC63DAF-8483-4772-8E79-3D69D8477DE4",
+			"--partition-guid=3:6E1F4E7B-7F4B-4B8B-9E3A-1B0C2D3E4F50",
+			"--attributes=3:=:0000000000000000", "--change-name=3:DATA", "/dev/sda",
+		}})).To(Succeed())
+	})
+
+	It("fails if the partition number is unknown", func() {
+		bPart.Path = "/dev/sda4"
+		Expect(repart.ShrinkDevicePartition(s, bPart, 8192)).To(MatchError(ContainSubstring("reading partition number")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+
+	It("fails if the partition entry can't be parsed", func() {
+		runner.SideEffect = nil
+		Expect(repart.ShrinkDevicePartition(s, bPart, 8192)).To(MatchError(ContainSubstring("no start found")))
+	})
+})
@@ -0,0 +1,231 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// PartitionDeviceReusing applies the layout of the given disk keeping the existing partitions which match
+// the label or UUID of the partitions flagged for reuse, including their data. Any other existing partition
+// is deleted, so systemd-repart creates and formats them from scratch next to the reused ones. If no
+// partition is reused it behaves as PartitionAndFormatDevice. It returns the reused partitions. The
+// reused partitions are checked against the layout before touching the disk.
+func PartitionDeviceReusing(s *sys.System, b block.Device, d *deployment.Disk, opts ...Option) ([]*deployment.Partition, error) {
+	if d == nil {
+		return nil, fmt.Errorf("no disk to partition")
+	}
+	if !d.HasReusedPartitions() {
+		return nil, PartitionAndFormatDevice(s, d, opts...)
+	}
+
+	existing, err := b.GetDevicePartitions(d.Device)
+	if err != nil {
+		return nil, fmt.Errorf("listing partitions of device '%s': %w", d.Device, err)
+	}
+
+	var reused []*deployment.Partition
+	matches := map[*deployment.Partition]*block.Partition{}
+	for _, part := range d.Partitions {
+		if part == nil || !part.Reuse {
+			continue
+		}
+		match := findReusablePartition(existing, part)
+		if match == nil {
+			s.Logger().Info("No existing partition found to reuse as '%s', creating it", part.Label)
+			continue
+		}
+		matches[part] = match
+		reused = append(reused, part)
+	}
+	if len(reused) == 0 {
+		return nil, PartitionAndFormatDevice(s, d, opts...)
+	}
+
+	err = checkReusedPartitions(s, d, matches)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := map[string]bool{}
+	for _, part := range reused {
+		s.Logger().Info("Reusing existing partition '%s'", matches[part].Path)
+		part.UUID = matches[part].UUID
+		kept[matches[part].Path] = true
+	}
+
+	p := NewPartitioner(s)
+	for _, part := range existing {
+		if part == nil || kept[part.Path] {
+			continue
+		}
+		num, err := partitionNumber(s, part.Path)
+		if err != nil {
+			return nil, err
+		}
+		s.Logger().Info("Deleting partition '%s' with %s", part.Path, p.Name())
+		err = p.Delete(d.Device, num)
+		if err != nil {
+			return nil, fmt.Errorf("deleting partition '%s': %w", part.Path, err)
+		}
+	}
+	notifyKernel(s, d.Device)
+
+	uuids := make([]string, len(reused))
+	for i, part := range reused {
+		uuids[i] = part.UUID
+	}
+	err = repartDisk(s, d, "allow", opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed updating the partition table: %w", err)
+	}
+	notifyKernel(s, d.Device)
+
+	// systemd-repart matches existing partitions to definitions by type and order, not by label or UUID
+	for i, part := range reused {
+		if !strings.EqualFold(part.UUID, uuids[i]) {
+			return nil, fmt.Errorf(
+				"partition '%s' was not reused, its type and position must match the partition definition", uuids[i],
+			)
+		}
+	}
+	return reused, nil
+}
+
+// checkReusedPartitions checks the existing partitions matched for reuse can be kept by systemd-repart, which
+// matches existing partitions to definitions by type and order. Each matched partition must be matched once,
+// have the GPT type of their definition, follow the order of the definitions and have no new partition of
+// the same type defined before it. Formatted partitions must also have the filesystem of their definition.
+func checkReusedPartitions(s *sys.System, d *deployment.Disk, matches map[*deployment.Partition]*block.Partition) error {
+	matched := map[string]string{}
+	newTypes := map[string]string{}
+	var lastNum int
+	for _, part := range d.Partitions {
+		if part == nil {
+			continue
+		}
+		pType := partitionType(s, part)
+		match := matches[part]
+		if match == nil {
+			newTypes[pType] = part.Label
+			continue
+		}
+
+		if label, ok := matched[match.Path]; ok {
+			return fmt.Errorf("partition '%s' matches both '%s' and '%s'", match.Path, label, part.Label)
+		}
+		matched[match.Path] = part.Label
+
+		guid := typeGUID(pType)
+		if match.Type != "" && guid != "" && !strings.EqualFold(match.Type, guid) {
+			return fmt.Errorf(
+				"partition '%s' can't be reused as '%s', its type %s doesn't match %s", match.Path, part.Label, match.Type, pType,
+			)
+		}
+
+		if label, ok := newTypes[pType]; ok {
+			return fmt.Errorf(
+				"partition '%s' can't be reused, the new partition '%s' of the same type is defined before it", match.Path, label,
+			)
+		}
+
+		num, err := partitionNumber(s, match.Path)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return fmt.Errorf("invalid partition number of '%s': %w", match.Path, err)
+		}
+		if n <= lastNum {
+			return fmt.Errorf("partition '%s' can't be reused, reused partitions must keep the order of their definitions", match.Path)
+		}
+		lastNum = n
+
+		format := partitionFormat(part)
+		if !part.Encrypted && match.FileSystem != "" && format != "" && match.FileSystem != format {
+			return fmt.Errorf(
+				"partition '%s' can't be reused as '%s', it is formatted as %s instead of %s",
+				match.Path, part.Label, match.FileSystem, format,
+			)
+		}
+	}
+	return nil
+}
+
+// partitionType returns the GPT type systemd-repart assigns to the given partition
+func partitionType(s *sys.System, part *deployment.Partition) string {
+	if part.TypeUUID != "" {
+		return part.TypeUUID
+	}
+	return roleToType(s, part.Role)
+}
+
+// dpsTypes maps the systemd-repart type identifiers Elemental uses to their GPT type GUIDs as defined by
+// the Discoverable Partitions Specification
+var dpsTypes = map[string]string{
+	espType:        "c12a7328-f81f-11d2-ba4b-00a0c93ec93b",
+	genericType:    "0fc63daf-8483-4772-8e79-3d69d8477de4",
+	swapType:       "0657fd6d-a4ab-43c4-84e5-0933c84b4f4f",
+	"root-x86-64":  "4f68bce3-e8cd-4db1-96e7-fbcaf984b709",
+	"root-x86_64":  "4f68bce3-e8cd-4db1-96e7-fbcaf984b709",
+	"root-amd64":   "4f68bce3-e8cd-4db1-96e7-fbcaf984b709",
+	"root-arm64":   "b921b045-1df0-41c3-af44-4c6f280d3fae",
+	"root-aarch64": "b921b045-1df0-41c3-af44-4c6f280d3fae",
+	"root-riscv64": "72ec70a6-cf74-40e6-bd49-4bda08e8f224",
+}
+
+// typeGUID returns the GPT type GUID of the given systemd-repart partition type, which is either a
+// type identifier or a GUID. It returns an empty string for unknown type identifiers.
+func typeGUID(pType string) string {
+	if guid, ok := dpsTypes[pType]; ok {
+		return guid
+	}
+	if _, err := uuid.Parse(pType); err == nil {
+		return pType
+	}
+	return ""
+}
+
+// findReusablePartition returns the existing partition matching the UUID of the given partition if set,
+// or its label otherwise
+func findReusablePartition(existing block.PartitionList, part *deployment.Partition) *block.Partition {
+	for _, e := range existing {
+		if e == nil {
+			continue
+		}
+		if part.UUID != "" {
+			if strings.EqualFold(e.UUID, part.UUID) {
+				return e
+			}
+			continue
+		}
+		if e.Name == part.Label {
+			return e
+		}
+	}
+	return nil
+}
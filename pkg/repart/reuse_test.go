@@ -0,0 +1,201 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repart_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	blockmock "github.com/suse/elemental/v3/pkg/block/mock"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	homeUUID    = "5c1ad8d2-33c0-4ab0-8e34-6a2b2ac6e1b5"
+	espGUID     = "c12a7328-f81f-11d2-ba4b-00a0c93ec93b"
+	genericGUID = "0fc63daf-8483-4772-8e79-3d69d8477de4"
+)
+
+var _ = Describe("Partition reuse", Label("reuse"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var bDev *blockmock.Device
+	var disk *deployment.Disk
+	var repartJSON string
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/sys/class/block/sda1/partition": "1\n",
+			"/sys/class/block/sda2/partition": "2\n",
+			"/sys/class/block/sda3/partition": "3\n",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		bDev = blockmock.NewBlockDevice(
+			&block.Partition{
+				Path: "/dev/sda1", Disk: "/dev/sda", Name: "EFI", UUID: "c60d1845-7b04-4fc4-8639-8c49eb7277d5", Type: espGUID,
+			},
+			&block.Partition{Path: "/dev/sda2", Disk: "/dev/sda", Name: "SYSTEM", UUID: "ddb334a8-48a2-c4de-ddb3-849eb2443e92"},
+			&block.Partition{Path: "/dev/sda3", Disk: "/dev/sda", Name: "HOME", UUID: homeUUID, Type: genericGUID},
+		)
+		disk = &deployment.Disk{
+			Device: "/dev/sda",
+			Partitions: deployment.Partitions{
+				{Role: deployment.EFI, Label: "EFI", Size: 1024},
+				{Role: deployment.System, Label: "SYSTEM", Size: 8192},
+				{Role: deployment.Generic, Label: "HOME", MountPoint: "/home", Reuse: true},
+			},
+		}
+		repartJSON = fmt.Sprintf(`[
+			{"uuid" : "0c3b5a3e-1d4e-4f7d-9d7b-2f4c3c1e2a11", "file" : "/tmp/elemental-repart.d/0-efi.conf"},
+			{"uuid" : "9f2e8f4a-6b1d-4c55-a1b0-7d3e5c6f8a22", "file" : "/tmp/elemental-repart.d/1-system.conf"},
+			{"uuid" : "%s", "file" : "/tmp/elemental-repart.d/2-generic.conf"}
+		]`, homeUUID)
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "systemd-repart" {
+				return []byte(repartJSON), nil
+			}
+			return []byte{}, nil
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("keeps the partitions matching by label and deletes the others", func() {
+		reused, err := repart.PartitionDeviceReusing(s, bDev, disk)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reused).To(Equal([]*deployment.Partition{disk.Partitions[2]}))
+		Expect(disk.Partitions[2].UUID).To(Equal(homeUUID))
+		Expect(runner.MatchMilestones([][]string{
+			{"sgdisk", "--delete=1", "/dev/sda"},
+			{"sgdisk", "--delete=2", "/dev/sda"},
+			{"systemd-repart", "--json=pretty", "--definitions=/tmp/elemental-repart.d", "--dry-run=no", "--empty=allow"},
+		})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"sgdisk", "--delete=3"}})).NotTo(Succeed())
+	})
+
+	It("matches partitions by UUID if set", func() {
+		disk.Partitions[2].Label = "DATA"
+		disk.Partitions[2].UUID = homeUUID
+		reused, err := repart.PartitionDeviceReusing(s, bDev, disk)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reused).To(HaveLen(1))
+	})
+
+	It("creates the partitions from scratch if there is nothing to reuse", func() {
+		disk.Partitions[2].Label = "DATA"
+		reused, err := repart.PartitionDeviceReusing(s, bDev, disk)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reused).To(BeEmpty())
+		Expect(runner.IncludesCmds([][]string{{"sgdisk", "--delete"}})).NotTo(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"systemd-repart", "--json=pretty", "--definitions=/tmp/elemental-repart.d", "--dry-run=no", "--empty=force"},
+		})).To(Succeed())
+	})
+
+	It("fails if systemd-repart does not match the reused partition", func() {
+		repartJSON = `[{"uuid" : "0c3b5a3e-1d4e-4f7d-9d7b-2f4c3c1e2a11", "file" : "/tmp/elemental-repart.d/2-generic.conf"}]`
+		_, err := repart.PartitionDeviceReusing(s, bDev, disk)
+		Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("partition '%s' was not reused", homeUUID))))
+	})
+
+	It("fails on a nil disk", func() {
+		_, err := repart.PartitionDeviceReusing(s, bDev, nil)
+		Expect(err).To(MatchError("no disk to partition"))
+	})
+
+	It("fails before changing the disk if a new partition of the same type precedes the reused one", func() {
+		disk.Partitions = append(disk.Partitions[:2], &deployment.Partition{
+			Role: deployment.Generic, Label: "DATA", MountPoint: "/data",
+		}, disk.Partitions[2])
+		_, err := repart.PartitionDeviceReusing(s, bDev, disk)
+		Expect(err).To(MatchError(ContainSubstring("the new partition 'DATA' of the same type is defined before it")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+		Expect(disk.Partitions[3].UUID).To(BeEmpty())
+	})
+
+	It("fails before changing the disk if the reused partitions are out of order", func() {
+		disk.Partitions[0].Reuse = true
+		disk.Partitions[0], disk.Partitions[2] = disk.Partitions[2], disk.Partitions[0]
+		_, err := repart.PartitionDeviceReusing(s, bDev, disk)
+		Expect(err).To(MatchError(ContainSubstring("reused partitions must keep the order of their definitions")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+
+	It("fails before changing the disk if the filesystem of the reused partition differs", func() {
+		bDev = blockmock.NewBlockDevice(
+			&block.Partition{Path: "/dev/sda3", Disk: "/dev/sda", Name: "HOME", UUID: homeUUID, FileSystem: "ext4"},
+		)
+		disk.Partitions[2].FileSystem = deployment.Btrfs
+		_, err := repart.PartitionDeviceReusing(s, bDev, disk)
+		Expect(err).To(MatchError(ContainSubstring("it is formatted as ext4 instead of btrfs")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+
+	It("fails before changing the disk if the type of the reused partition differs", func() {
+		bDev = blockmock.NewBlockDevice(
+			&block.Partition{Path: "/dev/sda3", Disk: "/dev/sda", Name: "HOME", UUID: homeUUID, Type: espGUID},
+		)
+		_, err := repart.PartitionDeviceReusing(s, bDev, disk)
+		Expect(err).To(MatchError(ContainSubstring(
+			fmt.Sprintf("partition '/dev/sda3' can't be reused as 'HOME', its type %s doesn't match linux-generic", espGUID),
+		)))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+
+	It("checks the type of the reused partition against its type UUID if set", func() {
+		disk.Partitions[2].TypeUUID = strings.ToUpper(genericGUID)
+		_, err := repart.PartitionDeviceReusing(s, bDev, disk)
+		Expect(err).NotTo(HaveOccurred())
+
+		disk.Partitions[2].TypeUUID = "933ac7e1-2eb4-4f13-b844-0e14e2aef915"
+		_, err = repart.PartitionDeviceReusing(s, bDev, disk)
+		Expect(err).To(MatchError(ContainSubstring("doesn't match 933ac7e1-2eb4-4f13-b844-0e14e2aef915")))
+	})
+
+	It("fails if a partition can't be deleted", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "sgdisk" && args[0] != "--version" {
+				return nil, fmt.Errorf("sgdisk failed")
+			}
+			return []byte{}, nil
+		}
+		_, err := repart.PartitionDeviceReusing(s, bDev, disk)
+		Expect(err).To(MatchError(ContainSubstring("deleting partition '/dev/sda1': sgdisk failed")))
+		Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).NotTo(Succeed())
+	})
+})
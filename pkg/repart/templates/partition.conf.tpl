@@ -3,6 +3,12 @@ Type={{ .Type }}
 {{- if .Format }}
 Format={{ .Format }}
 {{- end }}
+{{- if .Encrypt }}
+Encrypt=key-file
+{{- end }}
+{{- if .GrowFS }}
+GrowFileSystem=yes
+{{- end }}
 {{- if .Size }}
 SizeMinBytes={{ .Size }}M
 SizeMaxBytes={{ .Size }}M
@@ -22,3 +28,6 @@ ExcludeFiles={{ $excl }}
 {{- if .ReadOnly }}
 ReadOnly={{ .ReadOnly }}
 {{- end }}
+{{- if .Flags }}
+Flags={{ printf "0x%016x" .Flags }}
+{{- end }}
\ No newline at end of file
@@ -0,0 +1,219 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requirements
+
+import (
+	_ "embed"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
+)
+
+// Base is the feature including the commands always required by an operation
+const Base = "base"
+
+//go:embed requirements.yaml
+var requirementsYAML []byte
+
+// Missing is a command required by a feature of an operation which is not available on the host
+type Missing struct {
+	Feature string `yaml:"feature"`
+	Command string `yaml:"command"`
+	Reason  string `yaml:"reason"`
+}
+
+type Option func(*Prober)
+
+// Prober checks the availability on the host of the commands required by each operation
+type Prober struct {
+	lookPath     func(string) (string, error)
+	requirements map[string]map[string][]string
+}
+
+// WithLookPath sets the function used to find commands on the host, defaults to exec.LookPath
+func WithLookPath(lookPath func(string) (string, error)) Option {
+	return func(p *Prober) {
+		p.lookPath = lookPath
+	}
+}
+
+func NewProber(opts ...Option) (*Prober, error) {
+	p := &Prober{lookPath: exec.LookPath}
+	for _, o := range opts {
+		o(p)
+	}
+
+	err := yaml.Unmarshal(requirementsYAML, &p.requirements)
+	if err != nil {
+		return nil, fmt.Errorf("parsing embedded requirements: %w", err)
+	}
+	return p, nil
+}
+
+// Operations returns the sorted list of operations with known requirements
+func (p Prober) Operations() []string {
+	ops := make([]string, 0, len(p.requirements))
+	for op := range p.requirements {
+		ops = append(ops, op)
+	}
+	slices.Sort(ops)
+	return ops
+}
+
+// Features returns the sorted list of features of the given operation
+func (p Prober) Features(operation string) []string {
+	features := make([]string, 0, len(p.requirements[operation]))
+	for feature := range p.requirements[operation] {
+		features = append(features, feature)
+	}
+	slices.Sort(features)
+	return features
+}
+
+// Missing returns the commands required by the base and given features of the operation which are not
+// available on the host. Features unknown to the operation do not require any command.
+func (p Prober) Missing(operation string, features ...string) ([]Missing, error) {
+	reqs, ok := p.requirements[operation]
+	if !ok {
+		return nil, fmt.Errorf("unknown operation '%s'", operation)
+	}
+
+	missing := []Missing{}
+	for _, feature := range append([]string{Base}, features...) {
+		for _, command := range reqs[feature] {
+			if err := p.find(command); err != nil {
+				missing = append(missing, Missing{Feature: feature, Command: command, Reason: err.Error()})
+			}
+		}
+	}
+	return missing, nil
+}
+
+// find looks up the given command, which can list alternative commands separated by '|'. The error of
+// the first alternative is returned if none of them is found.
+func (p Prober) find(command string) error {
+	var firstErr error
+	for _, alt := range strings.Split(command, "|") {
+		_, err := p.lookPath(alt)
+		if err == nil {
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Check returns an error describing all the unavailable features of the operation on the host
+func (p Prober) Check(operation string, features ...string) error {
+	missing, err := p.Missing(operation, features...)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	details := make([]string, 0, len(missing))
+	for _, m := range missing {
+		details = append(details, fmt.Sprintf("feature '%s' requires '%s': %s", m.Feature, m.Command, m.Reason))
+	}
+	return fmt.Errorf("missing host requirements for %s:\n  %s", operation, strings.Join(details, "\n  "))
+}
+
+// DeploymentFeatures returns the features in use by the given deployment
+func DeploymentFeatures(d *deployment.Deployment) []string {
+	features := []string{}
+
+	if d.Snapshotter != nil && d.Snapshotter.Name != "" {
+		features = append(features, d.Snapshotter.Name)
+	}
+	if d.BootConfig != nil && d.BootConfig.Bootloader == bootloader.BootGrub {
+		features = append(features, "grub")
+	}
+	if d.Firmware != nil && len(d.Firmware.BootEntries) > 0 {
+		features = append(features, "efi")
+	}
+	if d.Firmware != nil && d.Firmware.TPM != nil {
+		features = append(features, "tpm")
+	}
+	if d.IsNetworkUnlockEnabled() {
+		features = append(features, "network-unlock")
+	}
+	if d.GetRecoveryPartition() != nil {
+		features = append(features, "recovery")
+	}
+	if d.GetSwapPartition() != nil {
+		features = append(features, "swap")
+	}
+	if d.SourceOS != nil && d.SourceOS.VerifySignature != nil {
+		tool := d.SourceOS.VerifySignature.Tool
+		if tool == "" {
+			tool = deployment.CosignTool
+		}
+		features = append(features, tool)
+	}
+	if isXzTarball(d.SourceOS) || isXzTarball(d.OverlayTree) {
+		features = append(features, "xz")
+	}
+	for _, disk := range d.Disks {
+		if disk.Size > 0 {
+			features = append(features, "raw-disk")
+			break
+		}
+	}
+	for _, disk := range d.Disks {
+		if disk.Size > 0 {
+			continue
+		}
+		var feature string
+		switch disk.Wipe {
+		case deployment.WipeZapAll:
+			feature = string(deployment.WipeZapAll)
+		case deployment.WipeDiscard, deployment.WipeSecureDiscard:
+			feature = string(deployment.WipeDiscard)
+		default:
+			continue
+		}
+		if !slices.Contains(features, feature) {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+// isXzTarball returns true if the given source is an xz compressed tarball, which is decompressed
+// with the xz tool
+func isXzTarball(src *deployment.ImageSource) bool {
+	if src == nil || !src.IsTar() {
+		return false
+	}
+	uri := src.URI()
+	if u, err := url.Parse(uri); err == nil && src.IsRemote() {
+		uri = u.Path
+	}
+	return strings.HasSuffix(uri, ".xz") || strings.HasSuffix(uri, ".txz")
+}
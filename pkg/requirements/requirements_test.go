@@ -0,0 +1,119 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requirements_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/requirements"
+)
+
+func TestRequirementsSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Requirements test suite")
+}
+
+var _ = Describe("Requirements", Label("requirements"), func() {
+	var prober *requirements.Prober
+	var available []string
+	BeforeEach(func() {
+		var err error
+		available = []string{"systemd-repart", "lsblk", "udevadm", "rsync", "setfiles", "snapper", "btrfs", "chattr"}
+		prober, err = requirements.NewProber(requirements.WithLookPath(func(cmd string) (string, error) {
+			if slices.Contains(available, cmd) {
+				return "/usr/bin/" + cmd, nil
+			}
+			return "", fmt.Errorf("executable file not found in $PATH")
+		}))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	It("lists the embedded operations and features", func() {
+		Expect(prober.Operations()).To(ContainElements("install", "upgrade", "reset", "build-installer"))
+		Expect(prober.Features("install")).To(ContainElements(requirements.Base, "snapper", "grub", "raw-disk"))
+		Expect(prober.Features("unknown")).To(BeEmpty())
+	})
+	It("succeeds if all required commands are available", func() {
+		Expect(prober.Check("install", "snapper", "unknown-feature")).To(Succeed())
+	})
+	It("reports the missing commands of each feature", func() {
+		missing, err := prober.Missing("install", "snapper", "grub", "raw-disk")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(missing).To(ConsistOf(
+			requirements.Missing{Feature: "grub", Command: "grub2-editenv", Reason: "executable file not found in $PATH"},
+			requirements.Missing{Feature: "raw-disk", Command: "truncate", Reason: "executable file not found in $PATH"},
+			requirements.Missing{Feature: "raw-disk", Command: "losetup", Reason: "executable file not found in $PATH"},
+		))
+
+		err = prober.Check("install", "grub")
+		Expect(err).To(MatchError(ContainSubstring("feature 'grub' requires 'grub2-editenv'")))
+	})
+	It("accepts any of the alternative commands", func() {
+		available = append(available, "sfdisk", "cryptsetup")
+		Expect(prober.Check("upgrade", "layout")).To(Succeed())
+
+		available = slices.DeleteFunc(available, func(cmd string) bool { return cmd == "sfdisk" })
+		err := prober.Check("upgrade", "layout")
+		Expect(err).To(MatchError(ContainSubstring("feature 'layout' requires 'sgdisk|sfdisk'")))
+	})
+	It("does not require rsync, trees are synchronized natively without it", func() {
+		available = slices.DeleteFunc(available, func(cmd string) bool { return cmd == "rsync" })
+		for _, op := range []string{"install", "reset", "clone", "migrate-data"} {
+			missing, err := prober.Missing(op)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missing).NotTo(ContainElement(HaveField("Command", "rsync")), op)
+		}
+	})
+	It("always checks the base commands of the operation", func() {
+		available = []string{}
+		Expect(prober.Check("export")).To(MatchError(ContainSubstring("feature 'base' requires 'tar'")))
+	})
+	It("fails for unknown operations", func() {
+		_, err := prober.Missing("unknown")
+		Expect(err).To(MatchError("unknown operation 'unknown'"))
+	})
+	It("collects the features in use by a deployment", func() {
+		d := deployment.DefaultDeployment()
+		Expect(requirements.DeploymentFeatures(d)).To(Equal([]string{"snapper"}))
+
+		d.BootConfig.Bootloader = "grub"
+		d.Disks[0].Size = 1024
+		d.SourceOS = &deployment.ImageSource{VerifySignature: &deployment.SignatureVerification{}}
+		Expect(requirements.DeploymentFeatures(d)).To(Equal([]string{"snapper", "grub", "cosign", "raw-disk"}))
+
+		d.Disks[0].Wipe = deployment.WipeSecureDiscard
+		Expect(requirements.DeploymentFeatures(d)).NotTo(ContainElement("discard"))
+
+		d.Disks[0].Size = 0
+		d.Disks = append(d.Disks, &deployment.Disk{Wipe: deployment.WipeDiscard})
+		Expect(requirements.DeploymentFeatures(d)).To(Equal([]string{"snapper", "grub", "cosign", "discard"}))
+	})
+	It("requires xz for xz compressed tarball sources", func() {
+		d := deployment.DefaultDeployment()
+		d.SourceOS = deployment.NewTarSrc("/images/os.tar.gz")
+		Expect(requirements.DeploymentFeatures(d)).NotTo(ContainElement("xz"))
+
+		d.OverlayTree = deployment.NewTarSrc("/images/overlay.tar.xz")
+		Expect(requirements.DeploymentFeatures(d)).To(ContainElement("xz"))
+	})
+})
@@ -0,0 +1,144 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/rsync"
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+type Option func(*Restorer)
+
+type Restorer struct {
+	ctx        context.Context
+	s          *sys.System
+	t          transaction.Interface
+	targetRoot string
+	pending    int
+}
+
+func WithTransaction(t transaction.Interface) Option {
+	return func(r *Restorer) {
+		r.t = t
+	}
+}
+
+// WithTargetRoot sets the root the restored paths are written to. Defaults to
+// the running system root.
+func WithTargetRoot(root string) Option {
+	return func(r *Restorer) {
+		r.targetRoot = root
+	}
+}
+
+// WithPendingSnapshot restores the paths into the given snapshot, set as default for the next boot.
+// The snapshot is read-only, hence it is made writable for the time of the restore.
+func WithPendingSnapshot(id int) Option {
+	return func(r *Restorer) {
+		r.pending = id
+		r.targetRoot = fmt.Sprintf("/.snapshots/%d/snapshot", id)
+	}
+}
+
+func New(ctx context.Context, s *sys.System, opts ...Option) *Restorer {
+	r := &Restorer{
+		s:          s,
+		ctx:        ctx,
+		targetRoot: "/",
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	if r.t == nil {
+		r.t = transaction.NewSnapper(ctx, s)
+	}
+	return r
+}
+
+// Restore copies the given absolute path from the snapshot with the given ID into
+// the target root. Directories are synced on top of the existing ones, files
+// present in the target but missing in the snapshot are kept.
+func (r Restorer) Restore(d *deployment.Deployment, snapshotID int, path string) (err error) {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("path to restore must be absolute: '%s'", path)
+	}
+	path = filepath.Clean(path)
+
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	_, err = r.t.Init(*d)
+	if err != nil {
+		return fmt.Errorf("initializing transaction: %w", err)
+	}
+
+	snapRoot, err := r.t.MountSnapshot(snapshotID, cleanup)
+	if err != nil {
+		return fmt.Errorf("mounting snapshot: %w", err)
+	}
+
+	if r.pending > 0 {
+		snap := snapper.New(r.s)
+		err = snap.SetPermissions("/", r.pending, true)
+		if err != nil {
+			return fmt.Errorf("making pending snapshot '%d' writable: %w", r.pending, err)
+		}
+		cleanup.Push(func() error { return snap.SetPermissions("/", r.pending, false) })
+	}
+
+	source := filepath.Join(snapRoot, path)
+	target := filepath.Join(r.targetRoot, path)
+
+	ok, _ := vfs.Exists(r.s.FS(), source)
+	if !ok {
+		return fmt.Errorf("path '%s' not found in snapshot '%d'", path, snapshotID)
+	}
+
+	if ok, _ = vfs.IsDir(r.s.FS(), source); ok {
+		r.s.Logger().Info("Restoring directory '%s' from snapshot %d", path, snapshotID)
+		err = vfs.MkdirAll(r.s.FS(), target, vfs.DirPerm)
+		if err != nil {
+			return fmt.Errorf("creating target directory '%s': %w", target, err)
+		}
+		err = rsync.NewRsync(r.s, rsync.WithContext(r.ctx)).SyncData(source, target)
+		if err != nil {
+			return fmt.Errorf("syncing directory '%s': %w", path, err)
+		}
+		return nil
+	}
+
+	r.s.Logger().Info("Restoring file '%s' from snapshot %d", path, snapshotID)
+	err = vfs.MkdirAll(r.s.FS(), filepath.Dir(target), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating target directory '%s': %w", filepath.Dir(target), err)
+	}
+	err = vfs.CopyFileContext(r.ctx, r.s.FS(), source, target)
+	if err != nil {
+		return fmt.Errorf("copying file '%s': %w", path, err)
+	}
+	return nil
+}
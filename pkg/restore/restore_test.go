@@ -0,0 +1,131 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/restore"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	transmock "github.com/suse/elemental/v3/pkg/transaction/mock"
+)
+
+func TestRestoreSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Restore test suite")
+}
+
+var _ = Describe("Restore", Label("restore"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var d *deployment.Deployment
+	var r *restore.Restorer
+	var t *transmock.Transactioner
+
+	BeforeEach(func() {
+		var err error
+		t = &transmock.Transactioner{SnapshotPath: "/snapshot"}
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/snapshot/etc/hosts":         "127.0.0.1 localhost",
+			"/snapshot/etc/ssh/sshd_conf": "PermitRootLogin no",
+			"/etc/hosts":                  "overwritten",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithMounter(sysmock.NewMounter()), sys.WithRunner(runner),
+			sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		d = deployment.DefaultDeployment()
+		r = restore.New(context.Background(), s, restore.WithTransaction(t))
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("restores a file from the snapshot", func() {
+		Expect(r.Restore(d, 3, "/etc/hosts")).To(Succeed())
+		data, err := fs.ReadFile("/etc/hosts")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("127.0.0.1 localhost"))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("restores a file into the given target root", func() {
+		r = restore.New(context.Background(), s, restore.WithTransaction(t), restore.WithTargetRoot("/pending"))
+		Expect(r.Restore(d, 3, "/etc/hosts")).To(Succeed())
+		data, err := fs.ReadFile("/pending/etc/hosts")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("127.0.0.1 localhost"))
+	})
+	It("restores a file into the pending snapshot, made writable for the restore", func() {
+		r = restore.New(context.Background(), s, restore.WithTransaction(t), restore.WithPendingSnapshot(4))
+		Expect(r.Restore(d, 3, "/etc/hosts")).To(Succeed())
+		data, err := fs.ReadFile("/.snapshots/4/snapshot/etc/hosts")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("127.0.0.1 localhost"))
+		Expect(runner.CmdsMatch([][]string{
+			{"snapper", "--no-dbus", "modify", "--read-write", "4"},
+			{"snapper", "--no-dbus", "modify", "--read-only", "4"},
+		})).To(Succeed())
+	})
+	It("sets the pending snapshot read-only again if the restore fails", func() {
+		r = restore.New(context.Background(), s, restore.WithTransaction(t), restore.WithPendingSnapshot(4))
+		Expect(r.Restore(d, 3, "/etc/missing")).NotTo(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"snapper", "--no-dbus", "modify", "--read-write", "4"},
+			{"snapper", "--no-dbus", "modify", "--read-only", "4"},
+		})).To(Succeed())
+	})
+	It("restores a directory from the snapshot", func() {
+		Expect(r.Restore(d, 3, "/etc/ssh/")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{"rsync"}})).To(Succeed())
+		cmd := runner.GetCmds()[0]
+		Expect(cmd[len(cmd)-2]).To(HaveSuffix("/snapshot/etc/ssh/"))
+		Expect(cmd[len(cmd)-1]).To(HaveSuffix("/etc/ssh/"))
+		Expect(vfs.Exists(fs, "/etc/ssh")).To(BeTrue())
+	})
+	It("fails for relative paths", func() {
+		Expect(r.Restore(d, 3, "etc/hosts")).To(MatchError(ContainSubstring("must be absolute")))
+	})
+	It("fails if the path does not exist in the snapshot", func() {
+		err := r.Restore(d, 3, "/etc/missing")
+		Expect(err).To(MatchError("path '/etc/missing' not found in snapshot '3'"))
+	})
+	It("fails if the snapshot can't be mounted", func() {
+		t.MountSnapshotErr = fmt.Errorf("snapshot '3' not found")
+		err := r.Restore(d, 3, "/etc/hosts")
+		Expect(err).To(MatchError("mounting snapshot: snapshot '3' not found"))
+	})
+	It("fails on transaction initialization", func() {
+		t.InitErr = fmt.Errorf("init failed")
+		err := r.Restore(d, 3, "/etc/hosts")
+		Expect(err).To(MatchError("initializing transaction: init failed"))
+	})
+})
@@ -0,0 +1,632 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rsync
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/suse/elemental/v3/pkg/progress"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// nativeOpts are the rsync flags understood by the native synchronization
+type nativeOpts struct {
+	excludes      []string
+	protects      []string
+	xattrExcludes []string
+	filesFrom     string
+	delete        bool
+	links         bool
+	archive       bool
+	perms         bool
+	checksum      bool
+	xattrs        bool
+	acls          bool
+	hardLinks     bool
+	oneFileSystem bool
+}
+
+// parseNativeFlags parses the given rsync flags. Flags only affecting the rsync output or the
+// transfer of partial files are accepted and ignored, any other unknown flag is an error as its
+// semantics would be silently lost.
+func parseNativeFlags(flags []string) (nativeOpts, error) {
+	var opts nativeOpts
+
+	for i := 0; i < len(flags); i++ {
+		flag := flags[i]
+		switch {
+		case flag == "--archive" || flag == "-a":
+			opts.archive, opts.links, opts.perms = true, true, true
+		case flag == "--links" || flag == "-l":
+			opts.links = true
+		case flag == "--no-links":
+			opts.links = false
+		case flag == "--perms" || flag == "-p":
+			opts.perms = true
+		case flag == "--delete":
+			opts.delete = true
+		case flag == "--checksum" || flag == "-c":
+			opts.checksum = true
+		case flag == "--xattrs" || flag == "-X":
+			opts.xattrs = true
+		case flag == "--acls" || flag == "-A":
+			opts.acls = true
+		case flag == "--hard-links" || flag == "-H":
+			opts.hardLinks = true
+		case flag == "--one-file-system" || flag == "-x":
+			opts.oneFileSystem = true
+		case flag == "--recursive" || flag == "-r" || flag == "--human-readable" || flag == "-h" ||
+			flag == "--partial" || strings.HasPrefix(flag, "--info="):
+		case flag == "--exclude" || flag == "--files-from":
+			if i+1 == len(flags) {
+				return opts, fmt.Errorf("missing value of '%s' flag", flag)
+			}
+			i++
+			if flag == "--exclude" {
+				opts.excludes = append(opts.excludes, flags[i])
+			} else {
+				opts.filesFrom = flags[i]
+			}
+		case strings.HasPrefix(flag, "--exclude="):
+			opts.excludes = append(opts.excludes, strings.TrimPrefix(flag, "--exclude="))
+		case strings.HasPrefix(flag, "--files-from="):
+			opts.filesFrom = strings.TrimPrefix(flag, "--files-from=")
+		case strings.HasPrefix(flag, "--filter=protect "):
+			opts.protects = append(opts.protects, strings.TrimPrefix(flag, "--filter=protect "))
+		case strings.HasPrefix(flag, "--filter=P "):
+			opts.protects = append(opts.protects, strings.TrimPrefix(flag, "--filter=P "))
+		case strings.HasPrefix(flag, "--filter=-x "):
+			opts.xattrExcludes = append(opts.xattrExcludes, strings.TrimPrefix(flag, "--filter=-x "))
+		default:
+			return opts, fmt.Errorf("rsync flag '%s' is not supported by the native synchronization", flag)
+		}
+	}
+
+	if opts.delete && opts.filesFrom != "" {
+		return opts, fmt.Errorf("deleting files is not supported together with a files-from list")
+	}
+	return opts, nil
+}
+
+// includeXattr reports whether the extended attribute is preserved with the given options. ACLs
+// are stored as system.posix_acl_* attributes and are only preserved with the acls flag.
+func (o nativeOpts) includeXattr(name string) bool {
+	if strings.HasPrefix(name, "system.posix_acl_") {
+		return o.acls
+	}
+	if !o.xattrs || !strings.HasPrefix(name, "security.") && !strings.HasPrefix(name, "user.") {
+		return false
+	}
+	return !slices.ContainsFunc(o.xattrExcludes, func(p string) bool {
+		ok, _ := filepath.Match(p, name)
+		return ok
+	})
+}
+
+// matchesPattern reports whether the path, relative to the transfer root, matches the rsync pattern.
+// Patterns starting with '/' are anchored to the transfer root, other patterns match the trailing
+// components of the path. A trailing '/' only matches directories. Wildcards follow filepath.Match.
+func matchesPattern(pattern, rel string, isDir bool) bool {
+	if strings.HasSuffix(pattern, "/") {
+		if !isDir {
+			return false
+		}
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	path := "/" + rel
+	if strings.HasPrefix(pattern, "/") {
+		ok, _ := filepath.Match(pattern, path)
+		return ok
+	}
+	for i := range len(path) {
+		if path[i] != '/' {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, path[i+1:]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []string, rel string, isDir bool) bool {
+	return slices.ContainsFunc(patterns, func(p string) bool { return matchesPattern(p, rel, isDir) })
+}
+
+// nativeSync synchronizes the source tree into target without the rsync binary. It supports the
+// subset of rsync used by elemental: excludes, protect filters, deletion, files-from lists, symlinks,
+// hard links, special files, ownership, permissions, timestamps, ACLs, extended attributes and
+// staying on the source filesystem.
+func (r Rsync) nativeSync(source, target string, flags []string) error {
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fsys := r.s.FS()
+
+	opts, err := parseNativeFlags(flags)
+	if err != nil {
+		return err
+	}
+
+	if ok, _ := vfs.IsDir(fsys, source, true); !ok {
+		return fmt.Errorf("source directory '%s' not found", source)
+	}
+	err = vfs.MkdirAll(fsys, target, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating target directory '%s': %w", target, err)
+	}
+
+	var entries []string
+	if opts.filesFrom != "" {
+		entries, err = r.listFilesFrom(source, opts)
+	} else {
+		entries, err = r.listTree(ctx, source, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	progress.Start(r.s.Progress(), progress.StepSync, target)
+	var percent int
+	var dirs []string
+	linked := map[inode]string{}
+	for i, rel := range entries {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		isDir, err := r.syncEntry(ctx, filepath.Join(source, rel), filepath.Join(target, rel), opts, linked)
+		if err != nil {
+			return fmt.Errorf("synchronizing '%s': %w", rel, err)
+		}
+		if isDir {
+			dirs = append(dirs, rel)
+		}
+		if p := (i + 1) * 100 / len(entries); p != percent {
+			percent = p
+			r.s.Logger().Debug("synchronizing: %d%% (%d/%d)", p, i+1, len(entries))
+			r.s.Progress().Report(progress.Event{Step: progress.StepSync, Target: target, Percent: p})
+		}
+	}
+
+	if opts.delete {
+		err = r.deleteExtraneous(ctx, target, entries, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Directory times are set once their content is no longer modified
+	if opts.archive {
+		for _, rel := range slices.Backward(dirs) {
+			err = copyTimes(fsys, filepath.Join(source, rel), filepath.Join(target, rel))
+			if err != nil {
+				return fmt.Errorf("setting times of '%s': %w", rel, err)
+			}
+		}
+	}
+
+	progress.Done(r.s.Progress(), progress.StepSync, target)
+	return nil
+}
+
+// listTree returns the relative paths of the source tree which are not excluded, parents first
+func (r Rsync) listTree(ctx context.Context, source string, opts nativeOpts) ([]string, error) {
+	var entries []string
+	rootDev, err := deviceOf(r.s.FS(), source)
+	if err != nil {
+		return nil, err
+	}
+	err = vfs.WalkDirContext(ctx, r.s.FS(), source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if matchesAny(opts.excludes, rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		entries = append(entries, rel)
+		// As rsync, mount points are synchronized but not their content
+		if opts.oneFileSystem && d.IsDir() {
+			if dev, err := deviceOf(r.s.FS(), path); err != nil {
+				return err
+			} else if dev != rootDev {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing source directory '%s': %w", source, err)
+	}
+	return entries, nil
+}
+
+// listFilesFrom returns the relative paths listed in the files-from file including their parent
+// directories, as rsync does with the implied --relative flag. Listed directories are not recursed.
+func (r Rsync) listFilesFrom(source string, opts nativeOpts) ([]string, error) {
+	data, err := r.s.FS().ReadFile(opts.filesFrom)
+	if err != nil {
+		return nil, fmt.Errorf("reading files-from list: %w", err)
+	}
+
+	var entries []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		rel := strings.TrimPrefix(filepath.Clean("/"+line), "/")
+		if rel == "" {
+			continue
+		}
+
+		var paths []string
+		for p := rel; p != "."; p = filepath.Dir(p) {
+			paths = append(paths, p)
+		}
+		for _, p := range slices.Backward(paths) {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			info, err := r.s.FS().Lstat(filepath.Join(source, p))
+			if err != nil {
+				return nil, fmt.Errorf("listed file '%s' not found: %w", p, err)
+			}
+			if !matchesAny(opts.excludes, p, info.IsDir()) {
+				entries = append(entries, p)
+			}
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// syncEntry copies the source entry to target unless it is already up to date. It returns
+// true if the entry is a directory. With the hard-links flag, the linked map tracks the target
+// of the first copied path of each source inode.
+func (r Rsync) syncEntry(ctx context.Context, source, target string, opts nativeOpts, linked map[inode]string) (bool, error) {
+	fsys := r.s.FS()
+	info, err := fsys.Lstat(source)
+	if err != nil {
+		return false, err
+	}
+	current, _ := fsys.Lstat(target)
+
+	switch mode := info.Mode(); {
+	case mode.IsDir():
+		if current != nil && !current.IsDir() {
+			if err = fsys.RemoveAll(target); err != nil {
+				return true, err
+			}
+			current = nil
+		}
+		if err = vfs.MkdirAll(fsys, target, vfs.DirPerm); err != nil {
+			return true, err
+		}
+		if err = copyOwner(fsys, info, target, opts); err != nil {
+			return true, err
+		}
+		// Without the perms flag pre-existing directories keep their permissions
+		if opts.perms || current == nil {
+			if err = fsys.Chmod(target, mode); err != nil {
+				return true, err
+			}
+		}
+		return true, vfs.CopyXattrsFunc(fsys, source, target, opts.includeXattr)
+	case mode&fs.ModeSymlink != 0:
+		if !opts.links {
+			r.s.Logger().Debug("skipping non-regular file '%s'", source)
+			return false, nil
+		}
+		link, err := fsys.Readlink(source)
+		if err != nil {
+			return false, err
+		}
+		if current != nil && current.Mode()&fs.ModeSymlink != 0 {
+			if l, _ := fsys.Readlink(target); l == link {
+				return false, nil
+			}
+		}
+		if current != nil {
+			if err = fsys.RemoveAll(target); err != nil {
+				return false, err
+			}
+		}
+		if err = fsys.Symlink(link, target); err != nil {
+			return false, err
+		}
+		if err = copyOwner(fsys, info, target, opts); err != nil {
+			return false, err
+		}
+	case mode.IsRegular():
+		key, hardLinked := inodeOf(info)
+		hardLinked = hardLinked && opts.hardLinks
+		if first, ok := linked[key]; hardLinked && ok {
+			return false, linkFile(fsys, first, target, current)
+		}
+		if hardLinked {
+			linked[key] = target
+		}
+		if current != nil && upToDate(fsys, info, current, source, target, opts) {
+			return false, nil
+		}
+		// Without the perms flag pre-existing files keep their permissions
+		perm := mode
+		if !opts.perms && current != nil && current.Mode().IsRegular() {
+			perm = current.Mode()
+		}
+		if current != nil {
+			if err = fsys.RemoveAll(target); err != nil {
+				return false, err
+			}
+		}
+		// Ownership is set before copying the content as chown clears setuid bits and capabilities
+		f, err := fsys.Create(target)
+		if err != nil {
+			return false, err
+		}
+		_ = f.Close()
+		if err = copyOwner(fsys, info, target, opts); err != nil {
+			return false, err
+		}
+		if err = copyContent(ctx, fsys, source, target); err != nil {
+			return false, err
+		}
+		if err = fsys.Chmod(target, perm); err != nil {
+			return false, err
+		}
+		if err = vfs.CopyXattrsFunc(fsys, source, target, opts.includeXattr); err != nil {
+			return false, err
+		}
+	default:
+		if !opts.archive {
+			r.s.Logger().Debug("skipping special file '%s'", source)
+			return false, nil
+		}
+		if current != nil {
+			if err = fsys.RemoveAll(target); err != nil {
+				return false, err
+			}
+		}
+		if err = mknod(fsys, info, target); err != nil {
+			return false, err
+		}
+		if err = copyOwner(fsys, info, target, opts); err != nil {
+			return false, err
+		}
+	}
+
+	if opts.archive {
+		return false, copyTimes(fsys, source, target)
+	}
+	return false, nil
+}
+
+// deleteExtraneous removes the target entries missing in the synchronized entries. Excluded and
+// protected paths are kept including all their content.
+func (r Rsync) deleteExtraneous(ctx context.Context, target string, entries []string, opts nativeOpts) error {
+	synced := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		synced[e] = true
+	}
+
+	return vfs.WalkDirContext(ctx, r.s.FS(), target, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(target, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		keep := matchesAny(opts.excludes, rel, d.IsDir()) || matchesAny(opts.protects, rel, d.IsDir())
+		if !keep && !synced[rel] {
+			r.s.Logger().Debug("deleting '%s'", rel)
+			if err = r.s.FS().RemoveAll(path); err != nil {
+				return fmt.Errorf("deleting '%s': %w", rel, err)
+			}
+		}
+		if d.IsDir() && (keep || !synced[rel]) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// upToDate reports whether the regular target file matches the source. Files of the same size
+// are compared by content with the checksum flag and by modification time otherwise.
+func upToDate(fsys vfs.FS, src, dst fs.FileInfo, source, target string, opts nativeOpts) bool {
+	if !dst.Mode().IsRegular() || src.Size() != dst.Size() {
+		return false
+	}
+	if !opts.checksum {
+		return opts.archive && src.ModTime().Equal(dst.ModTime())
+	}
+	srcSum, err := fileChecksum(fsys, source)
+	if err != nil {
+		return false
+	}
+	dstSum, err := fileChecksum(fsys, target)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(srcSum, dstSum)
+}
+
+func fileChecksum(fsys vfs.FS, path string) ([]byte, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// inode identifies a file across the synchronized tree
+type inode struct {
+	dev uint64
+	ino uint64
+}
+
+// inodeOf returns the inode of the given file and whether it has multiple hard links
+func inodeOf(info fs.FileInfo) (inode, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return inode{}, false
+	}
+	return inode{dev: st.Dev, ino: st.Ino}, st.Nlink > 1
+}
+
+// deviceOf returns the device of the filesystem holding the given path
+func deviceOf(fsys vfs.FS, path string) (uint64, error) {
+	info, err := fsys.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, nil
+	}
+	return st.Dev, nil
+}
+
+// linkFile hard links target to the already synchronized first path of the same source inode
+func linkFile(fsys vfs.FS, first, target string, current fs.FileInfo) error {
+	if current != nil {
+		if info, err := fsys.Lstat(first); err == nil && os.SameFile(info, current) {
+			return nil
+		}
+		if err := fsys.RemoveAll(target); err != nil {
+			return err
+		}
+	}
+	return fsys.Link(first, target)
+}
+
+// copyContent writes the content of the source file to the existing target file. It stops once the
+// given context is done.
+func copyContent(ctx context.Context, fsys vfs.FS, source, target string) error {
+	src, err := fsys.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := fsys.OpenFile(target, os.O_WRONLY|os.O_TRUNC, vfs.FilePerm)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 1024*1024)
+	for {
+		if err = ctx.Err(); err != nil {
+			_ = dst.Close()
+			return err
+		}
+		n, rErr := src.Read(buf)
+		if n > 0 {
+			if _, err = dst.Write(buf[:n]); err != nil {
+				_ = dst.Close()
+				return err
+			}
+		}
+		if rErr == io.EOF {
+			break
+		} else if rErr != nil {
+			_ = dst.Close()
+			return rErr
+		}
+	}
+	return dst.Close()
+}
+
+// copyOwner sets the source ownership to target in archive mode. As rsync, ownership is
+// silently kept if the current user is not allowed to change it.
+func copyOwner(fsys vfs.FS, info fs.FileInfo, target string, opts nativeOpts) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !opts.archive || !ok {
+		return nil
+	}
+	raw, err := fsys.RawPath(target)
+	if err != nil {
+		return err
+	}
+	err = unix.Lchown(raw, int(st.Uid), int(st.Gid))
+	if errors.Is(err, unix.EPERM) {
+		return nil
+	}
+	return err
+}
+
+// copyTimes sets the access and modification times of source to target without following symlinks
+func copyTimes(fsys vfs.FS, source, target string) error {
+	info, err := fsys.Lstat(source)
+	if err != nil {
+		return err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	raw, err := fsys.RawPath(target)
+	if err != nil {
+		return err
+	}
+	times := []unix.Timespec{unix.NsecToTimespec(syscall.TimespecToNsec(st.Atim)), unix.NsecToTimespec(syscall.TimespecToNsec(st.Mtim))}
+	return unix.UtimesNanoAt(unix.AT_FDCWD, raw, times, unix.AT_SYMLINK_NOFOLLOW)
+}
+
+// mknod creates a device, fifo or socket node at target matching the source node
+func mknod(fsys vfs.FS, info fs.FileInfo, target string) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("unsupported file type of '%s'", info.Name())
+	}
+	raw, err := fsys.RawPath(target)
+	if err != nil {
+		return err
+	}
+	return unix.Mknod(raw, st.Mode, int(st.Rdev))
+}
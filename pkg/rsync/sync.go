@@ -19,20 +19,34 @@ package rsync
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os/exec"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/progress"
 	"github.com/suse/elemental/v3/pkg/sys"
 )
 
+// Backend is the implementation used to synchronize directory trees
+type Backend int
+
+const (
+	// AutoBackend uses rsync if it is installed and the native synchronization otherwise
+	AutoBackend Backend = iota
+	RsyncBackend
+	NativeBackend
+)
+
 type Rsync struct {
-	ctx   context.Context
-	flags []string
-	s     *sys.System
+	ctx     context.Context
+	flags   []string
+	s       *sys.System
+	backend Backend
 }
 
 type Opts func(r *Rsync)
@@ -51,6 +65,13 @@ func WithContext(ctx context.Context) Opts {
 	}
 }
 
+// WithBackend sets the implementation used to synchronize the trees, defaults to AutoBackend
+func WithBackend(b Backend) Opts {
+	return func(r *Rsync) {
+		r.backend = b
+	}
+}
+
 func NewRsync(s *sys.System, opts ...Opts) *Rsync {
 	rsync := &Rsync{
 		flags: DefaultFlags(),
@@ -92,6 +113,19 @@ func (r Rsync) MirrorData(source string, target string, excludes []string, delet
 }
 
 func (r Rsync) rsyncWrapper(source string, target string, flags []string) error {
+	if r.backend == NativeBackend {
+		return r.nativeSync(source, target, flags)
+	}
+
+	err := r.runRsync(source, target, flags)
+	if r.backend == AutoBackend && errors.Is(err, exec.ErrNotFound) {
+		r.s.Logger().Warn("rsync is not installed, falling back to the native synchronization")
+		return r.nativeSync(source, target, flags)
+	}
+	return err
+}
+
+func (r Rsync) runRsync(source string, target string, flags []string) error {
 	var err error
 
 	fs := r.s.FS()
@@ -115,19 +149,23 @@ func (r Rsync) rsyncWrapper(source string, target string, flags []string) error
 	args := flags
 	args = append(args, source, target)
 
-	if r.ctx != nil {
-		err = r.s.Runner().RunContextParseOutput(r.ctx, parseProgress(log), func(msg string) {
-			log.Debug("rsync stderr: %s", msg)
-		}, "rsync", args...)
-	} else {
-		_, err = r.s.Runner().Run("rsync", args...)
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
 	}
+	progress.Start(r.s.Progress(), progress.StepSync, target)
+	err = r.s.Runner().RunContextParseOutput(ctx, parseProgress(log, r.s.Progress(), target), func(msg string) {
+		log.Debug("rsync stderr: %s", msg)
+	}, "rsync", args...)
 
-	if err != nil {
+	if errors.Is(err, exec.ErrNotFound) {
+		return err
+	} else if err != nil {
 		log.Error("rsync finished with errors: %s", err.Error())
 		return err
 	}
 
+	progress.Done(r.s.Progress(), progress.StepSync, target)
 	return nil
 }
 
@@ -152,16 +190,19 @@ func OverlayTreeSyncFlags() []string {
 	}
 }
 
-func parseProgress(log log.Logger) func(string) {
-	var progress int
+// parseProgress returns an rsync output handler reporting the overall synchronization percentage
+// of the given target each time it changes
+func parseProgress(log log.Logger, reporter progress.Reporter, target string) func(string) {
+	var percent int
 	re := regexp.MustCompile(`.* (\d+(.\d+)?)% .*`)
 	return func(line string) {
 		match := re.FindStringSubmatch(line)
 		if match != nil {
 			i, _ := strconv.Atoi(match[1])
-			if i != progress {
+			if i != percent {
 				log.Debug("synchronizing: %s", line)
-				progress = i
+				percent = i
+				reporter.Report(progress.Event{Step: progress.StepSync, Target: target, Percent: i})
 			}
 		}
 	}
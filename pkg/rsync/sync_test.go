@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"context"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"slices"
 	"sync"
@@ -261,3 +262,141 @@ var _ = Describe("Sync tests", Label("rsync"), func() {
 		Expect(memLog.String()).To(ContainSubstring("synchronizing:"))
 	})
 })
+
+var _ = Describe("Native sync tests", Label("rsync", "native"), func() {
+	var sourceDir, destDir string
+	var err error
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var r *rsync.Rsync
+	BeforeEach(func() {
+		tfs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+		sourceDir, err = vfs.TempDir(tfs, "", "elementalsource")
+		Expect(err).ShouldNot(HaveOccurred())
+		destDir, err = vfs.TempDir(tfs, "", "elementaltarget")
+		Expect(err).ShouldNot(HaveOccurred())
+		r = rsync.NewRsync(s, rsync.WithBackend(rsync.NativeBackend))
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("preserves symlinks, permissions and modification times", func() {
+		Expect(vfs.MkdirAll(tfs, filepath.Join(sourceDir, "etc"), 0700)).To(Succeed())
+		Expect(tfs.WriteFile(filepath.Join(sourceDir, "etc", "file"), []byte("content"), 0640)).To(Succeed())
+		Expect(tfs.Symlink("etc/file", filepath.Join(sourceDir, "link"))).To(Succeed())
+		mtime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		raw, err := tfs.RawPath(filepath.Join(sourceDir, "etc", "file"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chtimes(raw, mtime, mtime)).To(Succeed())
+
+		Expect(r.SyncData(sourceDir, destDir)).To(Succeed())
+
+		link, err := tfs.Readlink(filepath.Join(destDir, "link"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(link).To(Equal("etc/file"))
+		info, err := tfs.Stat(filepath.Join(destDir, "etc", "file"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(fs.FileMode(0640)))
+		Expect(info.ModTime().Equal(mtime)).To(BeTrue())
+		info, err = tfs.Stat(filepath.Join(destDir, "etc"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(fs.FileMode(0700)))
+	})
+	It("skips symlinks with the no-links flag", func() {
+		Expect(tfs.Symlink("/some/target", filepath.Join(sourceDir, "link"))).To(Succeed())
+		r = rsync.NewRsync(s, rsync.WithBackend(rsync.NativeBackend), rsync.WithFlags("--archive", "--no-links"))
+		Expect(r.SyncData(sourceDir, destDir)).To(Succeed())
+		Expect(vfs.Exists(tfs, filepath.Join(destDir, "link"))).To(BeFalse())
+	})
+	It("updates files whose content changed with the checksum flag", func() {
+		Expect(tfs.WriteFile(filepath.Join(sourceDir, "file"), []byte("new"), vfs.FilePerm)).To(Succeed())
+		Expect(tfs.WriteFile(filepath.Join(destDir, "file"), []byte("old"), vfs.FilePerm)).To(Succeed())
+
+		Expect(r.SyncData(sourceDir, destDir)).To(Succeed())
+		data, err := tfs.ReadFile(filepath.Join(destDir, "file"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("new"))
+	})
+	It("only synchronizes the files listed in the files-from list", func() {
+		Expect(vfs.MkdirAll(tfs, filepath.Join(sourceDir, "etc", "ssh"), vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile(filepath.Join(sourceDir, "etc", "ssh", "sshd_config"), []byte{}, vfs.FilePerm)).To(Succeed())
+		Expect(tfs.WriteFile(filepath.Join(sourceDir, "etc", "hostname"), []byte{}, vfs.FilePerm)).To(Succeed())
+		Expect(tfs.WriteFile("/files", []byte("etc/ssh/sshd_config\n"), vfs.FilePerm)).To(Succeed())
+
+		r = rsync.NewRsync(s, rsync.WithBackend(rsync.NativeBackend), rsync.WithFlags(append(rsync.DefaultFlags(), "--files-from", "/files")...))
+		Expect(r.SyncData(sourceDir, destDir)).To(Succeed())
+		Expect(vfs.Exists(tfs, filepath.Join(destDir, "etc", "ssh", "sshd_config"))).To(BeTrue())
+		Expect(vfs.Exists(tfs, filepath.Join(destDir, "etc", "hostname"))).To(BeFalse())
+	})
+	It("keeps the content of protected directories when mirroring", func() {
+		Expect(vfs.MkdirAll(tfs, filepath.Join(destDir, "var", "lib"), vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile(filepath.Join(destDir, "var", "lib", "data"), []byte{}, vfs.FilePerm)).To(Succeed())
+		Expect(tfs.WriteFile(filepath.Join(destDir, "stale"), []byte{}, vfs.FilePerm)).To(Succeed())
+
+		Expect(r.MirrorData(sourceDir, destDir, nil, []string{"/var"})).To(Succeed())
+		Expect(vfs.Exists(tfs, filepath.Join(destDir, "var", "lib", "data"))).To(BeTrue())
+		Expect(vfs.Exists(tfs, filepath.Join(destDir, "stale"))).To(BeFalse())
+	})
+	It("stops once the context is cancelled", func() {
+		Expect(tfs.WriteFile(filepath.Join(sourceDir, "file"), []byte{}, vfs.FilePerm)).To(Succeed())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r = rsync.NewRsync(s, rsync.WithBackend(rsync.NativeBackend), rsync.WithContext(ctx))
+		Expect(r.SyncData(sourceDir, destDir)).To(MatchError(context.Canceled))
+	})
+	It("fails if the source does not exist", func() {
+		Expect(r.SyncData("/welp", destDir)).To(MatchError(ContainSubstring("source directory '/welp' not found")))
+	})
+	It("preserves hard links with the hard-links flag", func() {
+		Expect(tfs.WriteFile(filepath.Join(sourceDir, "file"), []byte("content"), vfs.FilePerm)).To(Succeed())
+		Expect(tfs.Link(filepath.Join(sourceDir, "file"), filepath.Join(sourceDir, "link"))).To(Succeed())
+
+		r = rsync.NewRsync(s, rsync.WithBackend(rsync.NativeBackend), rsync.WithFlags(rsync.OverlayTreeSyncFlags()...))
+		Expect(r.SyncData(sourceDir, destDir)).To(Succeed())
+		file, err := tfs.Stat(filepath.Join(destDir, "file"))
+		Expect(err).NotTo(HaveOccurred())
+		link, err := tfs.Stat(filepath.Join(destDir, "link"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.SameFile(file, link)).To(BeTrue())
+	})
+	It("keeps the permissions of existing directories without the perms flag", func() {
+		Expect(vfs.MkdirAll(tfs, filepath.Join(sourceDir, "etc"), 0700)).To(Succeed())
+		Expect(vfs.MkdirAll(tfs, filepath.Join(destDir, "etc"), 0755)).To(Succeed())
+
+		r = rsync.NewRsync(s, rsync.WithBackend(rsync.NativeBackend), rsync.WithFlags(rsync.OverlayTreeSyncFlags()...))
+		Expect(r.SyncData(sourceDir, destDir)).To(Succeed())
+		info, err := tfs.Stat(filepath.Join(destDir, "etc"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(fs.FileMode(0755)))
+	})
+	It("keeps the permissions of existing files without the perms flag", func() {
+		Expect(tfs.WriteFile(filepath.Join(sourceDir, "file"), []byte("new content"), vfs.FilePerm)).To(Succeed())
+		Expect(tfs.Chmod(filepath.Join(sourceDir, "file"), 0755)).To(Succeed())
+		Expect(tfs.WriteFile(filepath.Join(destDir, "file"), []byte("old"), vfs.FilePerm)).To(Succeed())
+		Expect(tfs.Chmod(filepath.Join(destDir, "file"), 0600)).To(Succeed())
+
+		r = rsync.NewRsync(s, rsync.WithBackend(rsync.NativeBackend), rsync.WithFlags(rsync.OverlayTreeSyncFlags()...))
+		Expect(r.SyncData(sourceDir, destDir)).To(Succeed())
+		data, err := tfs.ReadFile(filepath.Join(destDir, "file"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("new content"))
+		info, err := tfs.Stat(filepath.Join(destDir, "file"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(fs.FileMode(0600)))
+
+		Expect(tfs.WriteFile(filepath.Join(sourceDir, "file"), []byte("newer content"), vfs.FilePerm)).To(Succeed())
+		r = rsync.NewRsync(s, rsync.WithBackend(rsync.NativeBackend), rsync.WithFlags(append(rsync.OverlayTreeSyncFlags(), "--perms")...))
+		Expect(r.SyncData(sourceDir, destDir)).To(Succeed())
+		info, err = tfs.Stat(filepath.Join(destDir, "file"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(fs.FileMode(0755)))
+	})
+	It("fails on flags not supported by the native synchronization", func() {
+		r = rsync.NewRsync(s, rsync.WithBackend(rsync.NativeBackend), rsync.WithFlags("--archive", "--sparse"))
+		Expect(r.SyncData(sourceDir, destDir)).To(MatchError(ContainSubstring("rsync flag '--sparse' is not supported")))
+	})
+})
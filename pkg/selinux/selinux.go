@@ -18,7 +18,6 @@ limitations under the License.
 package selinux
 
 import (
-	"container/ring"
 	"context"
 	"fmt"
 	"path/filepath"
@@ -26,6 +25,7 @@ import (
 
 	"github.com/suse/elemental/v3/pkg/chroot"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/runner"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
@@ -54,8 +54,8 @@ func SystemRelabel(ctx context.Context, s *sys.System, rootDir string, snapshott
 		baseArgs := []string{"-i"}
 
 		// We only keep last 10 lines of the stdout and stderr for debugging purposes
-		stdOut := ring.New(debugLines)
-		stdErr := ring.New(debugLines)
+		stdOut := runner.NewLineTail(debugLines)
+		stdErr := runner.NewLineTail(debugLines)
 
 		if rootDir == "/" || rootDir == "" {
 			rootDir = "/"
@@ -81,15 +81,15 @@ func SystemRelabel(ctx context.Context, s *sys.System, rootDir string, snapshott
 		args = append(args, contextFile, rootDir)
 
 		s.Logger().Info("Applying SE Linux labels to the read-only root tree, forced relabelling")
-		err = s.Runner().RunContextParseOutput(ctx, stdHander(stdOut), stdHander(stdErr), "setfiles", slices.Concat(baseArgs, args)...)
+		err = s.Runner().RunContextParseOutput(ctx, stdOut.Handler, stdErr.Handler, "setfiles", slices.Concat(baseArgs, args)...)
 		logOutput(s, stdOut, stdErr)
 
 		if len(snapshotted) > 0 {
 			s.Logger().Info("Applying SE Linux labels to snapshotted RW volumes")
 			for _, path := range snapshotted {
-				stdOut = ring.New(debugLines)
-				stdErr = ring.New(debugLines)
-				err = s.Runner().RunContextParseOutput(ctx, stdHander(stdOut), stdHander(stdErr), "setfiles", append(baseArgs, contextFile, path)...)
+				stdOut = runner.NewLineTail(debugLines)
+				stdErr = runner.NewLineTail(debugLines)
+				err = s.Runner().RunContextParseOutput(ctx, stdOut.Handler, stdErr.Handler, "setfiles", append(baseArgs, contextFile, path)...)
 				logOutput(s, stdOut, stdErr)
 			}
 		}
@@ -112,26 +112,15 @@ func ChrootedSystemRelabel(ctx context.Context, s *sys.System, rootDir string, s
 	return nil
 }
 
-func stdHander(r *ring.Ring) func(string) {
-	return func(line string) {
-		r.Value = line
-		r = r.Next()
-	}
-}
-
-func logOutput(s *sys.System, stdOut, stdErr *ring.Ring) {
+func logOutput(s *sys.System, stdOut, stdErr *runner.LineTail) {
 	output := "\n------- stdOut -------\n"
-	stdOut.Do(func(v any) {
-		if v != nil {
-			output += v.(string) + "\n"
-		}
-	})
+	if out := stdOut.String(); out != "" {
+		output += out + "\n"
+	}
 	output += "------- stdErr -------\n"
-	stdErr.Do(func(v any) {
-		if v != nil {
-			output += v.(string) + "\n"
-		}
-	})
+	if out := stdErr.String(); out != "" {
+		output += out + "\n"
+	}
 	output += "----------------------\n"
 	s.Logger().Debug("SE Linux setfile call stdout: %s", output)
 }
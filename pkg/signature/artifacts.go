@@ -0,0 +1,191 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// GPGTool signs artifacts with armored detached GPG signatures
+	GPGTool = "gpg"
+
+	// ChecksumsFile lists the SHA256 checksums of the artifacts of a directory, in sha256sum format
+	ChecksumsFile = "SHA256SUMS"
+)
+
+// Signer defines how artifacts are signed
+type Signer struct {
+	// Tool is either gpg or cosign, defaults to gpg
+	Tool string
+	// Key is the GPG key ID, the default secret key is used if empty, or the path or KMS URI of the cosign private key
+	Key string
+}
+
+// Validate checks the signer settings are consistent
+func (sg *Signer) Validate() error {
+	switch sg.Tool {
+	case "", GPGTool:
+	case deployment.CosignTool:
+		if sg.Key == "" {
+			return fmt.Errorf("signing with %s requires a key", deployment.CosignTool)
+		}
+	default:
+		return fmt.Errorf("unsupported signing tool '%s', supported tools: %s, %s", sg.Tool, GPGTool, deployment.CosignTool)
+	}
+
+	return nil
+}
+
+// Sign writes a detached signature of the given file next to it, '<file>.asc' for gpg or
+// '<file>.sig' for cosign, and returns the path of the signature.
+func Sign(ctx context.Context, s *sys.System, file string, sg *Signer) (string, error) {
+	if err := sg.Validate(); err != nil {
+		return "", err
+	}
+
+	var sigFile string
+	var args []string
+	tool := sg.Tool
+	switch tool {
+	case deployment.CosignTool:
+		sigFile = file + ".sig"
+		args = []string{"sign-blob", "--yes", "--key", sg.Key, "--output-signature", sigFile, file}
+	default:
+		tool = GPGTool
+		sigFile = file + ".asc"
+		args = []string{"--batch", "--yes", "--armor", "--detach-sign", "--output", sigFile}
+		if sg.Key != "" {
+			args = append(args, "--local-user", sg.Key)
+		}
+		args = append(args, file)
+	}
+
+	s.Logger().Info("Signing '%s' with %s", file, tool)
+	out, err := s.Runner().RunContext(ctx, tool, args...)
+	if err != nil {
+		s.Logger().Debug("%s output: %s", tool, string(out))
+		return "", fmt.Errorf("signing '%s': %w", file, err)
+	}
+
+	return sigFile, nil
+}
+
+// WriteChecksums adds the SHA256 checksums of the given files to the SHA256SUMS file of their
+// directory, replacing previous entries of the same files. It returns the checksums files written.
+func WriteChecksums(s *sys.System, files ...string) ([]string, error) {
+	byDir := map[string][]string{}
+	var dirs []string
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], f)
+	}
+
+	var sumsFiles []string
+	for _, dir := range dirs {
+		sumsFile := filepath.Join(dir, ChecksumsFile)
+		if err := updateChecksums(s.FS(), sumsFile, byDir[dir]); err != nil {
+			return nil, err
+		}
+		sumsFiles = append(sumsFiles, sumsFile)
+	}
+
+	return sumsFiles, nil
+}
+
+func updateChecksums(fs vfs.FS, sumsFile string, files []string) error {
+	var lines []string
+	if data, err := fs.ReadFile(sumsFile); err == nil {
+		lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	for _, f := range files {
+		sum, err := fileChecksum(fs, f)
+		if err != nil {
+			return fmt.Errorf("computing checksum of '%s': %w", f, err)
+		}
+
+		name := filepath.Base(f)
+		lines = slices.DeleteFunc(lines, func(l string) bool {
+			_, entry, _ := strings.Cut(l, "  ")
+			return l == "" || entry == name
+		})
+		lines = append(lines, fmt.Sprintf("%s  %s", sum, name))
+	}
+
+	if err := fs.WriteFile(sumsFile, []byte(strings.Join(lines, "\n")+"\n"), vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing checksums file '%s': %w", sumsFile, err)
+	}
+
+	return nil
+}
+
+func fileChecksum(fs vfs.FS, file string) (string, error) {
+	f, err := fs.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// PublishArtifacts writes the SHA256SUMS files of the given artifacts and, if a signer is
+// given, signs the artifacts and the checksums files so downloads can be verified.
+func PublishArtifacts(ctx context.Context, s *sys.System, sg *Signer, artifacts ...string) error {
+	if sg != nil {
+		if err := sg.Validate(); err != nil {
+			return err
+		}
+	}
+
+	sumsFiles, err := WriteChecksums(s, artifacts...)
+	if err != nil {
+		return err
+	}
+
+	if sg == nil {
+		return nil
+	}
+
+	for _, f := range append(slices.Clone(artifacts), sumsFiles...) {
+		if _, err = Sign(ctx, s, f, sg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
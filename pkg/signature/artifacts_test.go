@@ -0,0 +1,102 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/signature"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// sha256 checksums of 'raw' and 'iso'
+const (
+	rawSum = "d7439bee24773bcbfa2d0a97947ee36227b10d1022b1a55847e928965bb6bfde"
+	isoSum = "e0e4548df88a35d5854d052281c5deedad16f286f82cb2c23f2f9dea494834ac"
+)
+
+var _ = Describe("Artifacts", Label("signature"), func() {
+	var runner *sysmock.Runner
+	var s *sys.System
+	var fs vfs.FS
+	var cleanup func()
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/out/image.raw":  "raw",
+			"/out/image.iso":  "iso",
+			"/out/SHA256SUMS": "0000  image.raw\n1111  other.raw\n",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		runner = sysmock.NewRunner()
+		s, err = sys.NewSystem(sys.WithRunner(runner), sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("updates the checksums file of the artifacts directory", func() {
+		sums, err := signature.WriteChecksums(s, "/out/image.raw", "/out/image.iso")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sums).To(Equal([]string{"/out/SHA256SUMS"}))
+
+		data, err := fs.ReadFile("/out/SHA256SUMS")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("1111  other.raw\n" + rawSum + "  image.raw\n" + isoSum + "  image.iso\n"))
+	})
+	It("signs the artifacts and the checksums with gpg", func() {
+		Expect(signature.PublishArtifacts(GinkgoT().Context(), s, &signature.Signer{Key: "builder@example.com"}, "/out/image.raw")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"gpg", "--batch", "--yes", "--armor", "--detach-sign", "--output", "/out/image.raw.asc", "--local-user", "builder@example.com", "/out/image.raw"},
+			{"gpg", "--batch", "--yes", "--armor", "--detach-sign", "--output", "/out/SHA256SUMS.asc", "--local-user", "builder@example.com", "/out/SHA256SUMS"},
+		})).To(Succeed())
+	})
+	It("signs the artifacts with cosign", func() {
+		sg := &signature.Signer{Tool: "cosign", Key: "/etc/cosign.key"}
+		sigFile, err := signature.Sign(GinkgoT().Context(), s, "/out/image.iso", sg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sigFile).To(Equal("/out/image.iso.sig"))
+		Expect(runner.CmdsMatch([][]string{
+			{"cosign", "sign-blob", "--yes", "--key", "/etc/cosign.key", "--output-signature", "/out/image.iso.sig", "/out/image.iso"},
+		})).To(Succeed())
+	})
+	It("only writes checksums without a signer", func() {
+		Expect(signature.PublishArtifacts(GinkgoT().Context(), s, nil, "/out/image.iso")).To(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+		Expect(vfs.Exists(fs, "/out/SHA256SUMS")).To(BeTrue())
+	})
+	It("fails with invalid signer settings or signing errors", func() {
+		err := signature.PublishArtifacts(GinkgoT().Context(), s, &signature.Signer{Tool: "cosign"}, "/out/image.iso")
+		Expect(err).To(MatchError("signing with cosign requires a key"))
+
+		err = signature.PublishArtifacts(GinkgoT().Context(), s, &signature.Signer{Tool: "notation"}, "/out/image.iso")
+		Expect(err).To(MatchError(ContainSubstring("unsupported signing tool 'notation'")))
+
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			return []byte{}, fmt.Errorf("no secret key")
+		}
+		err = signature.PublishArtifacts(GinkgoT().Context(), s, &signature.Signer{}, "/out/image.iso")
+		Expect(err).To(MatchError(ContainSubstring("no secret key")))
+	})
+})
@@ -0,0 +1,110 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/registry"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// Verify checks the signature of the image the given OCI image reference points to with the tool and trust
+// settings of the given verification config. The image digest is resolved, and its signature verified,
+// through the mirrors and with the credentials of the given registry configuration. It fails if the image
+// has no valid signature, otherwise it returns the reference pinned to the verified digest, which must be
+// used to pull the image so it can't be replaced in between.
+func Verify(
+	ctx context.Context, s *sys.System, imageRef string, v *deployment.SignatureVerification, reg *registry.Config,
+) (string, error) {
+	if err := v.Validate(); err != nil {
+		return "", err
+	}
+
+	digest, err := reg.Digest(ctx, imageRef)
+	if err != nil {
+		return "", err
+	}
+
+	refs, err := reg.References(imageRef)
+	if err != nil {
+		return "", fmt.Errorf("parsing image reference '%s': %w", imageRef, err)
+	}
+
+	authDir, err := vfs.TempDir(s.FS(), "", "elemental-verify-")
+	if err != nil {
+		return "", fmt.Errorf("creating registry auth directory: %w", err)
+	}
+	defer func() { _ = s.FS().RemoveAll(authDir) }()
+
+	err = reg.WriteAuthFile(ctx, s.FS(), filepath.Join(authDir, "config.json"), refs...)
+	if err != nil {
+		return "", err
+	}
+	env := append(os.Environ(), "DOCKER_CONFIG="+authDir)
+
+	tool := v.Tool
+	if tool != deployment.NotationTool {
+		tool = deployment.CosignTool
+	}
+
+	// Mirrors are tried first, the signature of a digest is valid wherever the image is stored
+	var errs []error
+	for _, ref := range refs {
+		pinned := ref.Context().Digest(digest).String()
+		args := verifyArgs(v, pinned, reg.IsInsecure(ref.Context().RegistryStr()))
+
+		s.Logger().Info("Verifying signature of image '%s' with %s", pinned, tool)
+		out, err := s.Runner().RunContextEnv(ctx, tool, env, args...)
+		if err == nil {
+			s.Logger().Debug("Verified signature of image '%s'", pinned)
+			return refs[len(refs)-1].Context().Digest(digest).String(), nil
+		}
+		s.Logger().Debug("%s output: %s", tool, string(out))
+		errs = append(errs, fmt.Errorf("%s: %w", pinned, err))
+	}
+
+	return "", fmt.Errorf("verifying signature of image '%s': %w", imageRef, errors.Join(errs...))
+}
+
+func verifyArgs(v *deployment.SignatureVerification, imageRef string, insecure bool) []string {
+	if v.Tool == deployment.NotationTool {
+		args := []string{"verify"}
+		if insecure {
+			args = append(args, "--insecure-registry")
+		}
+		return append(args, imageRef)
+	}
+
+	args := []string{"verify"}
+	if insecure {
+		args = append(args, "--allow-insecure-registry")
+	}
+	if v.Key != "" {
+		args = append(args, "--key", v.Key)
+	} else {
+		args = append(args, "--certificate-identity", v.Identity, "--certificate-oidc-issuer", v.Issuer)
+	}
+	return append(args, imageRef)
+}
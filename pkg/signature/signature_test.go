@@ -0,0 +1,120 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/registry"
+	"github.com/suse/elemental/v3/pkg/signature"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	image  = "registry.org/my/os@" + digest
+)
+
+func TestSignatureSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Signature test suite")
+}
+
+var _ = Describe("Signature", Label("signature"), func() {
+	var runner *sysmock.Runner
+	var s *sys.System
+	var cleanup func()
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		var fs vfs.FS
+		fs, cleanup, err = sysmock.TestFS(map[string]string{"/tmp/empty": ""})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithRunner(runner), sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("verifies cosign signatures with a public key", func() {
+		v := &deployment.SignatureVerification{Key: "/etc/cosign.pub"}
+		pinned, err := signature.Verify(GinkgoT().Context(), s, image, v, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pinned).To(Equal(image))
+		Expect(runner.CmdsMatch([][]string{{"cosign", "verify", "--key", "/etc/cosign.pub", image}})).To(Succeed())
+	})
+	It("verifies keyless cosign signatures", func() {
+		v := &deployment.SignatureVerification{
+			Tool: deployment.CosignTool, Identity: "builder@example.com", Issuer: "https://accounts.example.com",
+		}
+		_, err := signature.Verify(GinkgoT().Context(), s, image, v, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runner.CmdsMatch([][]string{{
+			"cosign", "verify", "--certificate-identity", "builder@example.com",
+			"--certificate-oidc-issuer", "https://accounts.example.com", image,
+		}})).To(Succeed())
+	})
+	It("verifies notation signatures", func() {
+		v := &deployment.SignatureVerification{Tool: deployment.NotationTool}
+		_, err := signature.Verify(GinkgoT().Context(), s, image, v, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runner.CmdsMatch([][]string{{"notation", "verify", image}})).To(Succeed())
+	})
+	It("verifies the signature through the registry mirrors and returns the origin reference", func() {
+		reg := &registry.Config{
+			Mirrors:  map[string][]string{"registry.org": {"mirror.example.com:5000"}},
+			Insecure: []string{"mirror.example.com:5000"},
+		}
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if args[len(args)-1] != image {
+				return []byte{}, fmt.Errorf("signature not found")
+			}
+			return []byte{}, nil
+		}
+		v := &deployment.SignatureVerification{Key: "/etc/cosign.pub"}
+		pinned, err := signature.Verify(GinkgoT().Context(), s, image, v, reg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pinned).To(Equal(image))
+		Expect(runner.CmdsMatch([][]string{
+			{"cosign", "verify", "--allow-insecure-registry", "--key", "/etc/cosign.pub", "mirror.example.com:5000/my/os@" + digest},
+			{"cosign", "verify", "--key", "/etc/cosign.pub", image},
+		})).To(Succeed())
+	})
+	It("fails if the signature is not valid", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			return []byte{}, fmt.Errorf("no matching signatures")
+		}
+		v := &deployment.SignatureVerification{Key: "/etc/cosign.pub"}
+		_, err := signature.Verify(GinkgoT().Context(), s, image, v, nil)
+		Expect(err).To(MatchError(ContainSubstring("no matching signatures")))
+	})
+	It("fails with invalid verification settings", func() {
+		v := &deployment.SignatureVerification{Tool: "gpg"}
+		_, err := signature.Verify(GinkgoT().Context(), s, image, v, nil)
+		Expect(err).To(HaveOccurred())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+})
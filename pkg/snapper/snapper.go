@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -45,16 +46,35 @@ type Snapper struct {
 }
 
 type Snapshot struct {
-	Number   int      `json:"number"`
-	Default  bool     `json:"default"`
-	Active   bool     `json:"active"`
-	UserData Metadata `json:"userdata,omitempty"`
+	Number      int      `json:"number"`
+	Default     bool     `json:"default"`
+	Active      bool     `json:"active"`
+	Date        string   `json:"date,omitempty"`
+	Description string   `json:"description,omitempty"`
+	UserData    Metadata `json:"userdata,omitempty"`
+}
+
+// Change is a file change between two snapshots as reported by snapper status
+type Change struct {
+	// Status is the snapper status flags of the change, e.g. '+' created, '-' deleted, 'c' modified content
+	Status string
+	Path   string
 }
 
 type Metadata map[string]string
 
 type Snapshots []*Snapshot
 
+// Get returns the snapshot with the given number, nil if not found
+func (s Snapshots) Get(id int) *Snapshot {
+	for _, snap := range s {
+		if snap.Number == id {
+			return snap
+		}
+	}
+	return nil
+}
+
 func (s Snapshots) GetDefault() int {
 	for _, snap := range s {
 		if snap.Default {
@@ -125,7 +145,7 @@ func (sn Snapper) ListSnapshots(root string, config string) (Snapshots, error) {
 	if config == "" {
 		config = root
 	}
-	args = append(args, "-c", config, "--jsonout", "list", "--columns", "number,default,active,userdata")
+	args = append(args, "-c", config, "--jsonout", "list", "--columns", "number,default,active,userdata,date,description")
 	cmdOut, err := sn.s.Runner().Run("snapper", args...)
 	if err != nil {
 		return nil, fmt.Errorf("collecting snapshots: %s: %w", string(cmdOut), err)
@@ -239,29 +259,132 @@ func (sn Snapper) SetDefault(root string, id int, metadata Metadata) error {
 	return err
 }
 
+func (sn Snapper) SetUserdata(root string, id int, metadata Metadata) error {
+	args := noDbusArgs()
+
+	if root != "" && root != "/" {
+		args = append(args, "--root", root)
+	}
+	args = append(args, "modify", "--userdata", metadata.String(), strconv.Itoa(id))
+	sn.s.Logger().Info("Setting snapshot userdata")
+	_, err := sn.s.Runner().Run("snapper", args...)
+	return err
+}
+
+// RetentionPolicy defines the snapshots kept by CleanupByPolicy and CleanupByUsage. The active and default
+// snapshots are always kept.
+type RetentionPolicy struct {
+	// KeepLast is the number of most recent snapshots kept, not counting the ones kept by KeepUserdata
+	KeepLast int
+	// KeepUserdata lists the userdata keys keeping the snapshots they are set to 'yes' on
+	KeepUserdata []string
+	// UsageThreshold is the usage of the filesystem, as a percentage, above which the oldest snapshots
+	// are deleted even if KeepLast would keep them. Zero disables it.
+	UsageThreshold int
+}
+
+// tagged returns true if the given snapshot is kept because of its userdata
+func (p RetentionPolicy) tagged(snap *Snapshot) bool {
+	return slices.ContainsFunc(p.KeepUserdata, func(key string) bool {
+		return snap.UserData != nil && snap.UserData[key] == "yes"
+	})
+}
+
 func (sn Snapper) Cleanup(root string, maxSnaps int) error {
+	return sn.CleanupByPolicy(root, RetentionPolicy{KeepLast: maxSnaps})
+}
+
+// CleanupByPolicy deletes the oldest snapshots beyond the KeepLast count of the given policy and, if the
+// usage of the filesystem is above the policy threshold, the oldest remaining ones until enough space is freed.
+// Snapshots flagged with any of the policy userdata keys are never deleted, nor counted.
+func (sn Snapper) CleanupByPolicy(root string, p RetentionPolicy) error {
 	// TODO instead of relying on manual cleanup we could provide a snapper plugin
 	// to handle cleanup and rely on 'snapper cleanup' command
 	snaps, err := sn.ListSnapshots(root, rootConfig)
 	if err != nil {
 		return fmt.Errorf("listing snapshots: %w", err)
 	}
-	deletes := len(snaps) - maxSnaps
-	i := 0
-	for deletes > 0 {
-		if !snaps[i].Active && !snaps[i].Default {
-			path := filepath.Join(root, SnapshotsPath, strconv.Itoa(snaps[i].Number), "snapshot")
-			err = sn.DeleteByPath(path)
-			if err != nil {
-				return fmt.Errorf("cleaning up snapshot '%s': %w", path, err)
-			}
-			deletes--
+
+	counted := slices.DeleteFunc(slices.Clone(snaps), p.tagged)
+	deletes := len(counted) - p.KeepLast
+
+	for _, snap := range counted {
+		if snap.Active || snap.Default || deletes <= 0 {
+			continue
+		}
+		err = sn.DeleteSnapshot(root, snap.Number)
+		if err != nil {
+			return err
+		}
+		deletes--
+	}
+
+	if p.UsageThreshold == 0 {
+		return nil
+	}
+	return sn.CleanupByUsage(root, p)
+}
+
+// CleanupByUsage deletes the oldest snapshots, other than the active and default ones, until the usage
+// of the btrfs filesystem drops below the threshold of the given policy, as a percentage of the filesystem
+// size. Snapshots flagged with any of the policy userdata keys are never deleted.
+func (sn Snapper) CleanupByUsage(root string, p RetentionPolicy) error {
+	snaps, err := sn.ListSnapshots(root, rootConfig)
+	if err != nil {
+		return fmt.Errorf("listing snapshots: %w", err)
+	}
+
+	for _, snap := range snaps {
+		usage, err := fsUsage(sn.s, root)
+		if err != nil {
+			return err
+		}
+		if usage < uint64(p.UsageThreshold) {
+			return nil
+		}
+		if snap.Active || snap.Default || p.tagged(snap) {
+			continue
 		}
-		i++
+
+		sn.s.Logger().Info("Filesystem usage at %d%%, deleting snapshot %d", usage, snap.Number)
+		err = sn.DeleteSnapshot(root, snap.Number)
+		if err != nil {
+			return err
+		}
+	}
+
+	usage, err := fsUsage(sn.s, root)
+	if err != nil {
+		return err
+	}
+	if usage >= uint64(p.UsageThreshold) {
+		sn.s.Logger().Warn("Filesystem usage is above %d%% with no snapshots left to delete", p.UsageThreshold)
+	}
+	return nil
+}
+
+// DeleteSnapshot removes the given snapshot of the root configuration
+func (sn Snapper) DeleteSnapshot(root string, id int) error {
+	path := filepath.Join(root, SnapshotsPath, strconv.Itoa(id), "snapshot")
+	err := sn.DeleteByPath(path)
+	if err != nil {
+		return fmt.Errorf("cleaning up snapshot '%s': %w", path, err)
 	}
 	return nil
 }
 
+// fsUsage returns the usage of the btrfs filesystem at the given path as a percentage of its size
+func fsUsage(s *sys.System, path string) (uint64, error) {
+	used, size, err := btrfs.Usage(s, path)
+	if err != nil {
+		return 0, err
+	}
+	if size == 0 {
+		return 0, fmt.Errorf("invalid zero size reported for filesystem at '%s'", path)
+	}
+	return used * 100 / size, nil
+}
+
 // DeleteByPath removes the given snapshot path including any nested RO subvolume
 func (sn Snapper) DeleteByPath(path string) error {
 	// TODO instead of relying on manual btrfs calls we could provide a snapper plugin
@@ -340,6 +463,33 @@ func (sn Snapper) Status(root, config, output string, num1, num2 int) error {
 	return nil
 }
 
+// Diff returns the files changed between the given snapshots
+func (sn Snapper) Diff(root, config string, num1, num2 int) ([]Change, error) {
+	args := noDbusArgs()
+
+	if root != "" && root != "/" {
+		args = append(args, "--root", root)
+	}
+	if config == "" {
+		config = rootConfig
+	}
+	args = append(args, "-c", config, "status", fmt.Sprintf("%d..%d", num1, num2))
+	out, err := sn.s.Runner().RunEnv("snapper", []string{env.CLocale}, args...)
+	if err != nil {
+		return nil, fmt.Errorf("comparing snapshots %d and %d: %s: %w", num1, num2, strings.TrimSpace(string(out)), err)
+	}
+
+	changes := []Change{}
+	for _, line := range strings.Split(string(out), "\n") {
+		status, path, ok := strings.Cut(line, " ")
+		if !ok || path == "" {
+			continue
+		}
+		changes = append(changes, Change{Status: status, Path: path})
+	}
+	return changes, nil
+}
+
 func unmarshalSnapperList(snapperOut []byte, config string) (Snapshots, error) {
 	var objmap map[string]*json.RawMessage
 	err := json.Unmarshal(snapperOut, &objmap)
@@ -229,6 +229,20 @@ var _ = Describe("Snapper", Label("snapper"), func() {
 			},
 		})).To(Succeed())
 	})
+	It("lists the changes between two snapshots", func() {
+		runner.SideEffect = func(_ string, _ ...string) ([]byte, error) {
+			return []byte("c..... /etc/hostname\n-..... /etc/motd\n"), nil
+		}
+		changes, err := snap.Diff("/some/root", "", 3, 4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal([]snapper.Change{
+			{Status: "c.....", Path: "/etc/hostname"},
+			{Status: "-.....", Path: "/etc/motd"},
+		}))
+		Expect(runner.CmdsMatch([][]string{{
+			"snapper", "--no-dbus", "--root", "/some/root", "-c", "root", "status", "3..4",
+		}})).To(Succeed())
+	})
 	Describe("ListSnapshots", func() {
 		It("gets the list of snapshots", func() {
 			runner.SideEffect = func(_ string, _ ...string) ([]byte, error) {
@@ -323,6 +337,130 @@ var _ = Describe("Snapper", Label("snapper"), func() {
 			})).To(Succeed())
 		})
 	})
+	Describe("CleanupByUsage", func() {
+		var usage []int
+		var policy snapper.RetentionPolicy
+		BeforeEach(func() {
+			usage = []int{90, 85, 70}
+			policy = snapper.RetentionPolicy{UsageThreshold: 80}
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if cmd == "btrfs" && args[0] == "filesystem" {
+					used := usage[0]
+					usage = usage[1:]
+					return fmt.Appendf(nil, "Overall:\n    Device size: 100\n    Used: %d\n", used), nil
+				}
+				return []byte(snapperList), nil
+			}
+		})
+		It("does nothing if the usage is below the threshold", func() {
+			usage = []int{50}
+			Expect(snap.CleanupByUsage("/some/root", policy)).To(Succeed())
+			Expect(runner.CmdsMatch([][]string{
+				{"snapper", "--no-dbus", "--root", "/some/root", "-c", "root", "--jsonout", "list"},
+				{"btrfs", "filesystem", "usage", "--raw", "/some/root"},
+			})).To(Succeed())
+		})
+		It("clears old snapshots until the usage drops below the threshold", func() {
+			Expect(snap.CleanupByUsage("/some/root", policy)).To(Succeed())
+			Expect(runner.CmdsMatch([][]string{
+				{"snapper", "--no-dbus", "--root", "/some/root", "-c", "root", "--jsonout", "list"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "property", "set", "-ts", "/some/root/.snapshots/336/snapshot", "ro", "false"},
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/some/root/.snapshots/336/snapshot"},
+				{"btrfs", "filesystem", "usage"},
+			})).To(Succeed())
+		})
+		It("fails to get the filesystem usage", func() {
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if cmd == "btrfs" {
+					return []byte{}, fmt.Errorf("usage failed")
+				}
+				return []byte(snapperList), nil
+			}
+			Expect(snap.CleanupByUsage("/some/root", policy)).To(MatchError(ContainSubstring("usage failed")))
+		})
+		It("does not delete tagged snapshots", func() {
+			usage = []int{90, 90, 90, 90, 90}
+			policy.KeepUserdata = []string{"important"}
+			Expect(snap.CleanupByUsage("/some/root", policy)).To(Succeed())
+			Expect(runner.CmdsMatch([][]string{
+				{"snapper", "--no-dbus", "--root", "/some/root", "-c", "root", "--jsonout", "list"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "property", "set", "-ts", "/some/root/.snapshots/336/snapshot", "ro", "false"},
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/some/root/.snapshots/336/snapshot"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "filesystem", "usage"},
+			})).To(Succeed())
+		})
+		It("fails if the filesystem reports a zero size", func() {
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if cmd == "btrfs" {
+					return []byte("Overall:\n    Device size: 0\n    Used: 0\n"), nil
+				}
+				return []byte(snapperList), nil
+			}
+			Expect(snap.CleanupByUsage("/some/root", policy)).To(MatchError(ContainSubstring("no device size found")))
+		})
+	})
+	Describe("CleanupByPolicy", func() {
+		var usage []int
+		BeforeEach(func() {
+			usage = []int{90, 85, 70}
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if cmd == "btrfs" && args[0] == "filesystem" {
+					used := usage[0]
+					usage = usage[1:]
+					return fmt.Appendf(nil, "Overall:\n    Device size: 100\n    Used: %d\n", used), nil
+				}
+				return []byte(snapperList), nil
+			}
+		})
+		It("keeps the snapshots flagged with the given userdata keys", func() {
+			policy := snapper.RetentionPolicy{KeepLast: 1, KeepUserdata: []string{"important"}}
+			Expect(snap.CleanupByPolicy("/some/root", policy)).To(Succeed())
+			Expect(runner.CmdsMatch([][]string{
+				{"snapper", "--no-dbus", "--root", "/some/root", "-c", "root", "--jsonout", "list"},
+				{"btrfs", "property", "set", "-ts", "/some/root/.snapshots/336/snapshot", "ro", "false"},
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/some/root/.snapshots/336/snapshot"},
+			})).To(Succeed())
+		})
+		It("clears old snapshots until the usage drops below the threshold", func() {
+			usage = []int{90, 90, 90, 70}
+			policy := snapper.RetentionPolicy{KeepLast: 8, UsageThreshold: 80}
+			Expect(snap.CleanupByPolicy("/some/root", policy)).To(Succeed())
+			Expect(runner.CmdsMatch([][]string{
+				{"snapper", "--no-dbus", "--root", "/some/root", "-c", "root", "--jsonout", "list"},
+				{"snapper", "--no-dbus", "--root", "/some/root", "-c", "root", "--jsonout", "list"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "property", "set", "-ts", "/some/root/.snapshots/336/snapshot", "ro", "false"},
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/some/root/.snapshots/336/snapshot"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "property", "set", "-ts", "/some/root/.snapshots/337/snapshot", "ro", "false"},
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/some/root/.snapshots/337/snapshot"},
+				{"btrfs", "filesystem", "usage"},
+			})).To(Succeed())
+		})
+		It("does not delete tagged snapshots to lower the usage", func() {
+			usage = []int{90, 90, 90, 90, 90}
+			policy := snapper.RetentionPolicy{KeepLast: 8, KeepUserdata: []string{"important"}, UsageThreshold: 80}
+			Expect(snap.CleanupByPolicy("/some/root", policy)).To(Succeed())
+			Expect(runner.CmdsMatch([][]string{
+				{"snapper", "--no-dbus", "--root", "/some/root", "-c", "root", "--jsonout", "list"},
+				{"snapper", "--no-dbus", "--root", "/some/root", "-c", "root", "--jsonout", "list"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "property", "set", "-ts", "/some/root/.snapshots/336/snapshot", "ro", "false"},
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/some/root/.snapshots/336/snapshot"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "filesystem", "usage"},
+				{"btrfs", "filesystem", "usage"},
+			})).To(Succeed())
+		})
+	})
 	Describe("ConfigureRoot", func() {
 		It("creates a root configuration", func() {
 			rootDir := "/some/root"
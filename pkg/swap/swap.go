@@ -0,0 +1,131 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package swap
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/pkg/btrfs"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// ZramConfig is the zram-generator drop-in, relative to the root tree, setting up the zram swap device
+const ZramConfig = "/etc/systemd/zram-generator.conf.d/40-elemental.conf"
+
+// FstabLines returns the fstab lines activating the swap space of the given partitions and configuration.
+// The swap partition is activated even if no swap configuration is set.
+func FstabLines(parts deployment.Partitions, cfg *deployment.SwapConfig) []fstab.Line {
+	lines := []fstab.Line{}
+	for _, part := range parts {
+		if part == nil || part.Role != deployment.Swap {
+			continue
+		}
+		lines = append(lines, fstab.Line{
+			Device:     fmt.Sprintf("PARTUUID=%s", part.UUID),
+			MountPoint: "none",
+			FileSystem: "swap",
+			Options:    []string{"defaults"},
+		})
+	}
+	if cfg != nil && cfg.Type == deployment.SwapFileType {
+		lines = append(lines, fstab.Line{
+			Device:     deployment.SwapFile,
+			MountPoint: "none",
+			FileSystem: "swap",
+			Options:    []string{"defaults"},
+		})
+	}
+	return lines
+}
+
+// Configure sets up the swap space of the given deployment in the given root tree. It creates the swap
+// file, if missing, and writes the zram-generator configuration, which is removed if zram is not in use.
+// The rw volume holding the swap file is expected to be mounted in the root tree.
+func Configure(s *sys.System, root string, d *deployment.Deployment) error {
+	zramFile := filepath.Join(root, ZramConfig)
+	if d.Swap == nil || d.Swap.Type != deployment.SwapZram {
+		err := s.FS().RemoveAll(zramFile)
+		if err != nil {
+			return fmt.Errorf("removing zram configuration: %w", err)
+		}
+	}
+	if d.Swap == nil {
+		return nil
+	}
+
+	switch d.Swap.Type {
+	case deployment.SwapZram:
+		err := vfs.MkdirAll(s.FS(), filepath.Dir(zramFile), vfs.DirPerm)
+		if err != nil {
+			return fmt.Errorf("creating zram-generator config directory: %w", err)
+		}
+		cfg := "[zram0]\n"
+		if d.Swap.Size > 0 {
+			cfg += fmt.Sprintf("zram-size = %d\n", d.Swap.Size)
+		}
+		err = vfs.WriteFileAtomic(s.FS(), zramFile, []byte(cfg), vfs.FilePerm)
+		if err != nil {
+			return fmt.Errorf("writing zram configuration '%s': %w", zramFile, err)
+		}
+	case deployment.SwapFileType:
+		swapFile := filepath.Join(root, deployment.SwapFile)
+		if ok, _ := vfs.Exists(s.FS(), swapFile); ok {
+			s.Logger().Debug("Swap file '%s' already exists", deployment.SwapFile)
+			return nil
+		}
+		err := btrfs.CreateSwapFile(s, swapFile, uint64(d.Swap.Size))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KernelCmdline returns the kernel parameters resuming from the swap space of the given deployment after
+// hibernation. It is empty if hibernation is not enabled. The swap file offset is read from the given
+// root tree.
+func KernelCmdline(s *sys.System, root string, d *deployment.Deployment) (string, error) {
+	if d.Swap == nil || !d.Swap.Hibernation {
+		return "", nil
+	}
+
+	switch d.Swap.Type {
+	case deployment.SwapPartition:
+		part := d.GetSwapPartition()
+		if part == nil {
+			return "", fmt.Errorf("no swap partition defined")
+		}
+		return fmt.Sprintf("resume=PARTUUID=%s", part.UUID), nil
+	case deployment.SwapFileType:
+		sysPart := d.GetSystemPartition()
+		if sysPart == nil {
+			return "", fmt.Errorf("no system partition defined")
+		}
+		offset, err := btrfs.SwapFileOffset(s, filepath.Join(root, deployment.SwapFile))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("resume=PARTUUID=%s resume_offset=%d", sysPart.UUID, offset), nil
+	default:
+		return "", fmt.Errorf("hibernation is not supported on '%s' swap", d.Swap.Type)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package swap_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/swap"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestSwapSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Swap test suite")
+}
+
+var _ = Describe("Swap", Label("swap"), func() {
+	var runner *sysmock.Runner
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var d *deployment.Deployment
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/root" + swap.ZramConfig: "[zram0]\n",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		d = deployment.DefaultDeployment()
+		d.GetSystemPartition().UUID = "sys-uuid"
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("activates the swap partition and the swap file in fstab", func() {
+		Expect(swap.FstabLines(d.GetSystemDisk().Partitions, nil)).To(BeEmpty())
+
+		d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{Role: deployment.Swap, UUID: "swap-uuid"})
+		Expect(swap.FstabLines(d.GetSystemDisk().Partitions, nil)).To(Equal([]fstab.Line{{
+			Device: "PARTUUID=swap-uuid", MountPoint: "none", FileSystem: "swap", Options: []string{"defaults"},
+		}}))
+
+		d.Disks[0].Partitions = d.Disks[0].Partitions[:2]
+		cfg := &deployment.SwapConfig{Type: deployment.SwapFileType, Size: 1024}
+		Expect(swap.FstabLines(d.GetSystemDisk().Partitions, cfg)).To(Equal([]fstab.Line{{
+			Device: deployment.SwapFile, MountPoint: "none", FileSystem: "swap", Options: []string{"defaults"},
+		}}))
+	})
+	It("configures a zram swap device", func() {
+		d.Swap = &deployment.SwapConfig{Type: deployment.SwapZram, Size: 4096}
+		Expect(swap.Configure(s, "/root", d)).To(Succeed())
+		data, err := tfs.ReadFile("/root" + swap.ZramConfig)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("[zram0]\nzram-size = 4096\n"))
+
+		d.Swap = nil
+		Expect(swap.Configure(s, "/root", d)).To(Succeed())
+		Expect(vfs.Exists(tfs, "/root"+swap.ZramConfig)).To(BeFalse())
+	})
+	It("creates the swap file only if missing", func() {
+		d.Swap = &deployment.SwapConfig{Type: deployment.SwapFileType, Size: 2048}
+		Expect(swap.Configure(s, "/root", d)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"btrfs", "filesystem", "mkswapfile", "--size", "2048m", "/root/swap/swapfile"},
+		})).To(Succeed())
+		Expect(vfs.Exists(tfs, "/root"+swap.ZramConfig)).To(BeFalse())
+
+		runner.ClearCmds()
+		Expect(vfs.MkdirAll(tfs, "/root/swap", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile("/root/swap/swapfile", []byte{}, vfs.FilePerm)).To(Succeed())
+		Expect(swap.Configure(s, "/root", d)).To(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("sets the kernel parameters to resume from hibernation", func() {
+		d.Swap = &deployment.SwapConfig{Type: deployment.SwapFileType, Size: 2048}
+		Expect(swap.KernelCmdline(s, "/root", d)).To(BeEmpty())
+
+		d.Swap.Hibernation = true
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "btrfs" && args[0] == "inspect-internal" {
+				return []byte("198122980\n"), nil
+			}
+			return []byte{}, nil
+		}
+		Expect(swap.KernelCmdline(s, "/root", d)).To(Equal("resume=PARTUUID=sys-uuid resume_offset=198122980"))
+
+		d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{Role: deployment.Swap, UUID: "swap-uuid"})
+		d.Swap = &deployment.SwapConfig{Type: deployment.SwapPartition, Hibernation: true}
+		Expect(swap.KernelCmdline(s, "/root", d)).To(Equal("resume=PARTUUID=swap-uuid"))
+	})
+})
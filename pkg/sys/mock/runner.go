@@ -70,6 +70,10 @@ func (r *Runner) RunContext(_ context.Context, command string, args ...string) (
 	return r.Run(command, args...)
 }
 
+func (r *Runner) RunContextEnv(_ context.Context, command string, envs []string, args ...string) ([]byte, error) {
+	return r.RunEnv(command, envs, args...)
+}
+
 func (r *Runner) RunContextWithPipe(
 	_ context.Context, stdinPipeFn func(io.Writer) error, stdout, _ io.Writer,
 	_ string, envs []string, command string, args ...string,
@@ -109,6 +113,9 @@ func (r *Runner) RunContextWithPipe(
 
 func (r *Runner) RunContextParseOutput(_ context.Context, stdoutH, _ func(string), command string, args ...string) error {
 	out, err := r.Run(command, args...)
+	if stdoutH == nil {
+		return err
+	}
 	scanner := bufio.NewScanner(strings.NewReader(string(out)))
 	for scanner.Scan() {
 		stdoutH(scanner.Text())
@@ -0,0 +1,110 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/log"
+)
+
+// AuditRecord is the entry written to the audit log for each executed command. Only the
+// names of the environment variables are recorded, as their values might hold credentials,
+// and secrets in arguments and errors are redacted.
+type AuditRecord struct {
+	Time     time.Time `json:"time"`
+	Command  string    `json:"command"`
+	Args     []string  `json:"args"`
+	Env      []string  `json:"env,omitempty"`
+	WorkDir  string    `json:"workDir,omitempty"`
+	Duration float64   `json:"durationSeconds"`
+	ExitCode int       `json:"exitCode"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type auditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithAuditLog writes an AuditRecord as a JSON line to the given writer for every command run.
+// Commands failing to start are recorded with a -1 exit code.
+func WithAuditLog(w io.Writer) RunOption {
+	return func(r *run) {
+		r.audit = &auditLog{w: w}
+	}
+}
+
+// record writes the audit record of the given command started at the given time
+func (r run) record(start time.Time, cmd *exec.Cmd, err error) {
+	if r.audit == nil {
+		return
+	}
+
+	redact := log.Redact
+	if r.logger != nil {
+		redact = r.logger.Redact
+	}
+
+	args := log.RedactArgs(cmd.Args[1:])
+	for i := range args {
+		args[i] = redact(args[i])
+	}
+
+	rec := AuditRecord{
+		Time:     start.UTC(),
+		Command:  cmd.Path,
+		Args:     args,
+		WorkDir:  cmd.Dir,
+		Duration: time.Since(start).Seconds(),
+	}
+
+	for _, e := range cmd.Env {
+		name, _, _ := strings.Cut(e, "=")
+		rec.Env = append(rec.Env, name)
+	}
+
+	if err != nil {
+		rec.Error = redact(err.Error())
+		rec.ExitCode = -1
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			rec.ExitCode = exitErr.ExitCode()
+		}
+	}
+
+	data, mErr := json.Marshal(rec)
+	if mErr != nil {
+		r.debug("could not serialize audit record of '%s': %s", cmd.Path, mErr.Error())
+		return
+	}
+
+	r.audit.mu.Lock()
+	defer r.audit.mu.Unlock()
+
+	if _, wErr := r.audit.w.Write(append(data, '\n')); wErr != nil {
+		r.debug("could not write audit record of '%s': %s", cmd.Path, wErr.Error())
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultOutputLimit is the maximum number of bytes of command error output held in memory
+const DefaultOutputLimit = 16 * 1024 * 1024
+
+// tailBuffer is an io.Writer keeping only the last bytes written up to the given limit.
+// A limit lower or equal to zero does not limit the buffer size.
+type tailBuffer struct {
+	limit     int
+	buf       []byte
+	truncated bool
+}
+
+func newTailBuffer(limit int) *tailBuffer {
+	return &tailBuffer{limit: limit}
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	// Compact only once twice the limit is reached to amortize the copies
+	if t.limit > 0 && len(t.buf) > 2*t.limit {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-t.limit:]...)
+		t.truncated = true
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) Bytes() []byte {
+	if t.limit > 0 && len(t.buf) > t.limit {
+		t.truncated = true
+		return t.buf[len(t.buf)-t.limit:]
+	}
+	return t.buf
+}
+
+// LineTail keeps the last lines of a command output. Its Handler method is meant to be
+// used as a line handler of RunContextParseOutput calls for verbose commands, so only the
+// tail of the output is kept for error reporting. It is safe to use the same LineTail as
+// the stdout and stderr handlers, lines are kept in the order they are handled.
+type LineTail struct {
+	mu    sync.Mutex
+	size  int
+	lines []string
+}
+
+// NewLineTail returns a LineTail keeping up to the given number of lines
+func NewLineTail(size int) *LineTail {
+	return &LineTail{size: size}
+}
+
+// Handler stores the given line, dropping the oldest one if the maximum is reached
+func (l *LineTail) Handler(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.lines) >= l.size {
+		l.lines = l.lines[1:]
+	}
+	l.lines = append(l.lines, line)
+}
+
+// Handlers returns a line handler passing each line to all the given handlers
+func Handlers(handlers ...func(string)) func(string) {
+	return func(line string) {
+		for _, h := range handlers {
+			h(line)
+		}
+	}
+}
+
+func (l *LineTail) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
@@ -27,12 +27,15 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/suse/elemental/v3/pkg/log"
 )
 
 type run struct {
-	logger log.Logger
+	logger      log.Logger
+	outputLimit int
+	audit       *auditLog
 }
 
 type RunOption func(r *run)
@@ -43,8 +46,17 @@ func WithLogger(l log.Logger) RunOption {
 	}
 }
 
+// WithOutputLimit sets the maximum number of bytes of command error output kept in memory by Run and
+// RunEnv calls for error reporting. Only the tail of the error output is kept above the limit. Zero or
+// lower does not limit its size. The output returned to callers is never truncated, as it is parsed.
+func WithOutputLimit(limit int) RunOption {
+	return func(r *run) {
+		r.outputLimit = limit
+	}
+}
+
 func NewRunner(opts ...RunOption) *run { //nolint:revive
-	r := &run{}
+	r := &run{outputLimit: DefaultOutputLimit}
 	for _, o := range opts {
 		o(r)
 	}
@@ -56,20 +68,34 @@ func (r run) Run(command string, args ...string) ([]byte, error) {
 }
 
 func (r run) RunEnv(command string, env []string, args ...string) ([]byte, error) {
+	return r.RunContextEnv(context.Background(), command, env, args...)
+}
+
+func (r run) RunContextEnv(ctx context.Context, command string, env []string, args ...string) ([]byte, error) {
+	// Only the names of the environment variables are logged, as their values might hold credentials
 	displayEnv := ""
-	if len(env) > 0 {
-		displayEnv = strings.Join(env, " ") + " "
+	for _, e := range env {
+		name, _, _ := strings.Cut(e, "=")
+		displayEnv += name + "=" + log.Redacted + " "
 	}
-	r.debug("Running cmd: '%s %s %s'", displayEnv, command, strings.Join(args, " "))
-	cmd := exec.Command(command, args...)
+	r.debug("Running cmd: '%s%s %s'", displayEnv, command, strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, command, args...)
 	cmd.Env = env
-	out, err := cmd.Output()
+	stdout, stderr := &bytes.Buffer{}, newTailBuffer(r.outputLimit)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	start := time.Now()
+	err := cmd.Run()
+	r.record(start, cmd, err)
+	out := stdout.Bytes()
 	if err != nil {
 		r.debug("%q command reported an error: %s", command, err.Error())
 		r.debug("%q command output: %s", command, out)
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
-			r.debug("%q stderr: %s", command, string(exitErr.Stderr))
+		if errOut := stderr.Bytes(); len(errOut) > 0 {
+			if stderr.truncated {
+				r.debug("%q stderr exceeded %d bytes, only the last bytes are kept", command, r.outputLimit)
+			}
+			r.debug("%q stderr: %s", command, string(errOut))
 		}
 	}
 	return out, err
@@ -78,7 +104,13 @@ func (r run) RunEnv(command string, env []string, args ...string) ([]byte, error
 func (r run) RunContext(ctx context.Context, command string, args ...string) ([]byte, error) {
 	r.debug("Running cmd: '%s %s'", command, strings.Join(args, " "))
 	cmd := exec.CommandContext(ctx, command, args...)
-	out, err := cmd.CombinedOutput()
+	combined := &bytes.Buffer{}
+	cmd.Stdout = combined
+	cmd.Stderr = combined
+	start := time.Now()
+	err := cmd.Run()
+	r.record(start, cmd, err)
+	out := combined.Bytes()
 	if err != nil {
 		r.debug("'%s' command reported an error: %s", command, err.Error())
 		r.debug("'%s' command output: %s", command, out)
@@ -119,8 +151,10 @@ func (r run) RunContextParseOutput(ctx context.Context, stdoutH, stderrH func(st
 			return err
 		}
 	}
+	start := time.Now()
 	err = cmd.Start()
 	if err != nil {
+		r.record(start, cmd, err)
 		r.debug("'%s' command reported an error: %s", command, err.Error())
 		_ = closePipes()
 		return err
@@ -138,6 +172,7 @@ func (r run) RunContextParseOutput(ctx context.Context, stdoutH, stderrH func(st
 
 	wg.Wait()
 	err = cmd.Wait()
+	r.record(start, cmd, err)
 	if err != nil {
 		r.debug("'%s' command exited with error: %s", command, err.Error())
 		return err
@@ -173,7 +208,9 @@ func (r run) RunContextWithPipe(
 		return fmt.Errorf("could not pipe stdin for command %q: %w", command, err)
 	}
 
+	start := time.Now()
 	if err = cmd.Start(); err != nil {
+		r.record(start, cmd, err)
 		_ = stdinPipe.Close()
 		return fmt.Errorf("%q command reported an error on start: %w", command, err)
 	}
@@ -181,15 +218,18 @@ func (r run) RunContextWithPipe(
 	if err = stdinPipeFn(stdinPipe); err != nil {
 		_ = stdinPipe.Close()
 		cErr := cmd.Wait()
+		r.record(start, cmd, cErr)
 		return fmt.Errorf("command returned error: %w, pipe closed with: %w", cErr, err)
 	}
 
 	if err = stdinPipe.Close(); err != nil {
-		_ = cmd.Wait()
+		r.record(start, cmd, cmd.Wait())
 		return fmt.Errorf("closing stdin pipe: %w", err)
 	}
 
-	if err = cmd.Wait(); err != nil {
+	err = cmd.Wait()
+	r.record(start, cmd, err)
+	if err != nil {
 		return fmt.Errorf("%q command returned an error: %w", command, err)
 	}
 
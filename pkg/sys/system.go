@@ -24,6 +24,7 @@ import (
 	"runtime"
 
 	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/progress"
 	"github.com/suse/elemental/v3/pkg/sys/mounter"
 	"github.com/suse/elemental/v3/pkg/sys/platform"
 	"github.com/suse/elemental/v3/pkg/sys/runner"
@@ -35,6 +36,7 @@ type Runner interface {
 	Run(cmd string, args ...string) ([]byte, error)
 	RunEnv(cmd string, env []string, args ...string) ([]byte, error)
 	RunContext(ctx context.Context, cmd string, args ...string) ([]byte, error)
+	RunContextEnv(ctx context.Context, cmd string, env []string, args ...string) ([]byte, error)
 	RunContextParseOutput(ctx context.Context, stdoutH, stderrH func(line string), cmd string, args ...string) error
 	RunContextWithPipe(
 		ctx context.Context, stdinPipeFn func(io.Writer) error, stdout,
@@ -54,6 +56,7 @@ type System struct {
 	runner   Runner
 	syscall  Syscall
 	platform *platform.Platform
+	progress progress.Reporter
 }
 
 type SystemOpts func(a *System) error
@@ -104,13 +107,25 @@ func WithPlatform(pf string) SystemOpts {
 	}
 }
 
+// WithProgress sets the reporter of the progress of long running operations
+func WithProgress(r progress.Reporter) SystemOpts {
+	return func(s *System) error {
+		if r == nil {
+			r = progress.Discard
+		}
+		s.progress = r
+		return nil
+	}
+}
+
 func NewSystem(opts ...SystemOpts) (*System, error) {
 	logger := log.New()
 	sysObj := &System{
-		fs:      vfs.New(),
-		logger:  logger,
-		syscall: syscall.Syscall(),
-		mounter: mounter.NewMounter(),
+		fs:       vfs.New(),
+		logger:   logger,
+		syscall:  syscall.Syscall(),
+		mounter:  mounter.NewMounter(),
+		progress: progress.Discard,
 	}
 
 	for _, o := range opts {
@@ -159,6 +174,17 @@ func (s System) Logger() log.Logger {
 	return s.logger
 }
 
+// WithComponent returns a copy of this system whose logger tags its records with the given component
+func (s System) WithComponent(name string) *System {
+	s.logger = s.logger.WithComponent(name)
+	return &s
+}
+
+// Progress returns the reporter of the progress of long running operations
+func (s System) Progress() progress.Reporter {
+	return s.progress
+}
+
 // CommandExists
 func CommandExists(command string) bool {
 	_, err := exec.LookPath(command)
@@ -0,0 +1,134 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyChunkSize is the maximum amount of bytes copied in-kernel between context checks
+const copyChunkSize = 64 * 1024 * 1024
+
+// errNoSparseCopy is returned when the source filesystem can't report its data segments
+var errNoSparseCopy = errors.New("sparse copy not supported")
+
+// copyFileData copies the whole content of source into the empty target file. It prefers, in order,
+// a reflink sharing the source extents, a copy of the data segments preserving the holes of the
+// source and a plain copy of all bytes.
+func copyFileData(ctx context.Context, target, source *os.File) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// FICLONE is only supported within the same btrfs or xfs (reflink=1) filesystem
+	if unix.IoctlFileClone(int(target.Fd()), int(source.Fd())) == nil {
+		return nil
+	}
+
+	err := copySparse(ctx, target, source)
+	if !errors.Is(err, errNoSparseCopy) {
+		return err
+	}
+
+	if _, err = source.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(target, &ctxReader{ctx: ctx, r: source})
+	return err
+}
+
+// copySparse copies the data segments of source to the same offsets of target and
+// truncates target to the source size, so holes are preserved.
+func copySparse(ctx context.Context, target, source *os.File) error {
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return errNoSparseCopy
+	}
+	size := info.Size()
+	srcFd := int(source.Fd())
+
+	inKernel := true
+	var offset int64
+	for offset < size {
+		start, err := unix.Seek(srcFd, offset, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			// Only a trailing hole is left
+			break
+		} else if err != nil {
+			return errNoSparseCopy
+		}
+		end, err := unix.Seek(srcFd, start, unix.SEEK_HOLE)
+		if err != nil {
+			return errNoSparseCopy
+		}
+
+		for start < end {
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+			length := min(end-start, copyChunkSize)
+
+			var n int64
+			if inKernel {
+				n, err = copyRange(target, source, start, length)
+				if isCopyRangeUnsupported(err) {
+					inKernel = false
+					continue
+				}
+			} else {
+				n, err = io.Copy(
+					io.NewOffsetWriter(target, start),
+					&ctxReader{ctx: ctx, r: io.NewSectionReader(source, start, length)},
+				)
+			}
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				return io.ErrUnexpectedEOF
+			}
+			start += n
+		}
+		offset = end
+	}
+
+	return target.Truncate(size)
+}
+
+// copyRange copies length bytes at the given offset of source to the same offset of target
+// with copy_file_range, so the kernel can avoid moving the data through user space.
+func copyRange(target, source *os.File, offset, length int64) (int64, error) {
+	srcOff, dstOff := offset, offset
+	n, err := unix.CopyFileRange(int(source.Fd()), &srcOff, int(target.Fd()), &dstOff, int(length), 0)
+	return int64(n), err
+}
+
+// isCopyRangeUnsupported returns true if copy_file_range can't be used between the given files,
+// for instance because they are on different filesystems or the kernel is too old.
+func isCopyRangeUnsupported(err error) bool {
+	return errors.Is(err, unix.EXDEV) || errors.Is(err, unix.ENOSYS) ||
+		errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EINVAL)
+}
@@ -18,6 +18,7 @@ limitations under the License.
 package vfs
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -71,8 +72,13 @@ func New() FS {
 
 // DirSize returns the accumulated size of all files in a directory. The result is in bytes.
 func DirSize(fs FS, path string, excludes ...string) (int64, error) {
+	return DirSizeContext(context.Background(), fs, path, excludes...)
+}
+
+// DirSizeContext is the same as DirSize but stops walking the directory once the given context is done.
+func DirSizeContext(ctx context.Context, fs FS, path string, excludes ...string) (int64, error) {
 	var size int64
-	err := WalkDirFs(fs, path, func(loopPath string, d os.DirEntry, err error) error {
+	err := WalkDirContext(ctx, fs, path, func(loopPath string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -461,11 +467,17 @@ func (d *statDirEntry) Info() (fs.FileInfo, error) { return d.info, nil }
 
 // WalkDirFs is the same as filepath.WalkDir but accepts an FS so it can be run on any FS type.
 func WalkDirFs(fs FS, root string, fn fs.WalkDirFunc) error {
+	return WalkDirContext(context.Background(), fs, root, fn)
+}
+
+// WalkDirContext is the same as WalkDirFs but stops walking once the given context is done,
+// returning the context error.
+func WalkDirContext(ctx context.Context, fs FS, root string, fn fs.WalkDirFunc) error {
 	info, err := fs.Stat(root)
 	if err != nil {
 		err = fn(root, nil, err)
 	} else {
-		err = walkDir(fs, root, &statDirEntry{info}, fn)
+		err = walkDir(ctx, fs, root, &statDirEntry{info}, fn)
 	}
 	if errors.Is(err, filepath.SkipDir) {
 		return nil
@@ -473,7 +485,11 @@ func WalkDirFs(fs FS, root string, fn fs.WalkDirFunc) error {
 	return err
 }
 
-func walkDir(fs FS, path string, d fs.DirEntry, walkDirFn fs.WalkDirFunc) error {
+func walkDir(ctx context.Context, fs FS, path string, d fs.DirEntry, walkDirFn fs.WalkDirFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if err := walkDirFn(path, d, nil); err != nil || !d.IsDir() {
 		if errors.Is(err, filepath.SkipDir) && d.IsDir() {
 			// Successfully skipped directory.
@@ -493,7 +509,7 @@ func walkDir(fs FS, path string, d fs.DirEntry, walkDirFn fs.WalkDirFunc) error
 
 	for _, d1 := range dirs {
 		path1 := filepath.Join(path, d1.Name())
-		if err := walkDir(fs, path1, d1, walkDirFn); err != nil {
+		if err := walkDir(ctx, fs, path1, d1, walkDirFn); err != nil {
 			if errors.Is(err, filepath.SkipDir) {
 				break
 			}
@@ -513,15 +529,22 @@ func readDir(vfs FS, dirname string) ([]fs.DirEntry, error) {
 }
 
 // CopyDir walks over the provided source directory and copies each file under the destination directory.
-// Optionally, a callback function may be executed for each destination path.
+// Extended attributes of files and directories are preserved as in CopyFile. Optionally, a callback function may be executed for each destination path.
 //
 // Does nothing if the source directory is an empty string or contains no files.
 func CopyDir(vfs FS, srcRoot, destRoot string, recursive bool, onCopy func(destPath string) error) error {
+	return CopyDirContext(context.Background(), vfs, srcRoot, destRoot, recursive, onCopy)
+}
+
+// CopyDirContext is the same as CopyDir but aborts the copy once the given context is done.
+func CopyDirContext(
+	ctx context.Context, vfs FS, srcRoot, destRoot string, recursive bool, onCopy func(destPath string) error,
+) error {
 	if srcRoot == "" {
 		return nil
 	}
 
-	return WalkDirFs(vfs, srcRoot, func(path string, entry fs.DirEntry, err error) error {
+	return WalkDirContext(ctx, vfs, srcRoot, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		} else if path == srcRoot {
@@ -542,10 +565,10 @@ func CopyDir(vfs FS, srcRoot, destRoot string, recursive bool, onCopy func(destP
 			if err = MkdirAll(vfs, destination, DirPerm); err != nil {
 				return fmt.Errorf("creating directory %q: %w", destination, err)
 			}
-			return nil
+			return CopyXattrs(vfs, path, destination)
 		}
 
-		if err = CopyFile(vfs, path, destination); err != nil {
+		if err = CopyFileContext(ctx, vfs, path, destination); err != nil {
 			return fmt.Errorf("copying file %q to %q: %w", path, destination, err)
 		}
 
@@ -561,16 +584,29 @@ func CopyDir(vfs FS, srcRoot, destRoot string, recursive bool, onCopy func(destP
 
 // CopyFile copies source file to a target file using the FS interface. If the target
 // is a directory, the source is copied into that directory using a source name file.
-// File mode is preserved.
+// File mode, security and user extended attributes are preserved. The copy reflinks the source
+// extents if the filesystem supports it, otherwise holes of sparse files are preserved.
 func CopyFile(fs FS, source string, target string) error {
-	return ConcatFiles(fs, []string{source}, target)
+	return ConcatFilesContext(context.Background(), fs, []string{source}, target)
+}
+
+// CopyFileContext is the same as CopyFile but aborts the copy once the given context is done.
+// The partially written target is removed.
+func CopyFileContext(ctx context.Context, fs FS, source string, target string) error {
+	return ConcatFilesContext(ctx, fs, []string{source}, target)
 }
 
 // ConcatFiles copies source files to a target file using the FS interface.
 // Source files are concatenated into the target file in the given order.
 // If the target is a directory, the source is copied into that directory using
 // the name of the first source file. The result keeps the file mode of the first source.
-func ConcatFiles(fs FS, sources []string, target string) (err error) {
+func ConcatFiles(fs FS, sources []string, target string) error {
+	return ConcatFilesContext(context.Background(), fs, sources, target)
+}
+
+// ConcatFilesContext is the same as ConcatFiles but aborts the copy once the given context is done.
+// The partially written target is removed.
+func ConcatFilesContext(ctx context.Context, fs FS, sources []string, target string) (err error) {
 	if len(sources) == 0 {
 		return fmt.Errorf("empty sources list")
 	}
@@ -594,14 +630,32 @@ func ConcatFiles(fs FS, sources []string, target string) (err error) {
 		}
 	}()
 
+	if len(sources) == 1 {
+		var sourceFile *os.File
+		sourceFile, err = fs.OpenFile(sources[0], os.O_RDONLY, FilePerm)
+		if err != nil {
+			return err
+		}
+		err = copyFileData(ctx, targetFile, sourceFile)
+		_ = sourceFile.Close()
+		if err != nil {
+			return err
+		}
+		if err = fs.Chmod(target, fInf.Mode()); err != nil {
+			return err
+		}
+		return CopyXattrs(fs, sources[0], target)
+	}
+
 	var sourceFile *os.File
 	for _, source := range sources {
 		sourceFile, err = fs.OpenFile(source, os.O_RDONLY, FilePerm)
 		if err != nil {
 			return err
 		}
-		_, err = io.Copy(targetFile, sourceFile)
+		_, err = io.Copy(targetFile, &ctxReader{ctx: ctx, r: sourceFile})
 		if err != nil {
+			_ = sourceFile.Close()
 			return err
 		}
 		err = sourceFile.Close()
@@ -613,6 +667,19 @@ func ConcatFiles(fs FS, sources []string, target string) (err error) {
 	return fs.Chmod(target, fInf.Mode())
 }
 
+// ctxReader is an io.Reader failing with the context error once the context is done
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
 // LoadEnvFile parses a file and constructs a map with the respective key-value pairs.
 func LoadEnvFile(fs FS, file string) (map[string]string, error) {
 	var envMap map[string]string
@@ -634,31 +701,65 @@ func LoadEnvFile(fs FS, file string) (map[string]string, error) {
 
 // WriteEnvFile writes an environment file with the given key-value pairs.
 func WriteEnvFile(fs FS, envs map[string]string, filename string) error {
-	var bkFile string
-
-	rawPath, err := fs.RawPath(filename)
+	content, err := godotenv.Marshal(envs)
 	if err != nil {
 		return err
 	}
+	return WriteFileAtomic(fs, filename, []byte(content+"\n"), FilePerm)
+}
+
+// WriteFileAtomic writes data to the named file through a temporary file in the same directory which
+// is synced to disk and then renamed over the named file. A power loss during the write leaves either
+// the previous or the new content in place, never a truncated file.
+func WriteFileAtomic(fileSystem FS, filename string, data []byte, perm fs.FileMode) (err error) {
+	dir := filepath.Dir(filename)
+	tmpFile := filepath.Join(dir, "."+filepath.Base(filename)+"."+nextRandom())
 
-	if ok, _ := Exists(fs, filename, true); ok {
-		bkFile = filename + ".bk"
-		err = fs.Rename(filename, bkFile)
+	f, err := fileSystem.OpenFile(tmpFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
 		if err != nil {
-			return err
+			_ = fileSystem.Remove(tmpFile)
 		}
+	}()
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return err
 	}
 
-	err = godotenv.Write(envs, rawPath)
+	// OpenFile is subject to umask
+	err = fileSystem.Chmod(tmpFile, perm)
+	if err != nil {
+		return err
+	}
+
+	err = fileSystem.Rename(tmpFile, filename)
 	if err != nil {
-		if bkFile != "" {
-			// try to restore renamed file
-			_ = fs.Rename(bkFile, filename)
-		}
 		return err
 	}
-	if bkFile != "" {
-		_ = fs.Remove(bkFile)
+
+	return syncDir(fileSystem, dir)
+}
+
+// syncDir flushes the given directory entries to disk
+func syncDir(fileSystem FS, dir string) error {
+	d, err := fileSystem.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = d.Close() }()
+
+	if syncer, ok := d.(interface{ Sync() error }); ok {
+		return syncer.Sync()
 	}
 	return nil
 }
@@ -18,11 +18,13 @@ limitations under the License.
 package vfs_test
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -30,6 +32,7 @@ import (
 
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"golang.org/x/sys/unix"
 )
 
 func TestVfsSuite(t *testing.T) {
@@ -272,6 +275,27 @@ var _ = Describe("FS", Label("fs"), func() {
 			Expect(len(foundPaths)).To(Equal(len(currentPaths)))
 			Expect(foundPaths).To(Equal(currentPaths))
 		})
+		It("Stops walking once the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var foundPaths []string
+			f := func(path string, _ fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				foundPaths = append(foundPaths, path)
+				if path == "/folder/file" {
+					cancel()
+				}
+				return nil
+			}
+			Expect(vfs.WalkDirContext(ctx, tfs, "/", f)).To(MatchError(context.Canceled))
+			Expect(foundPaths).To(Equal([]string{"/", "/folder", "/folder/file"}))
+
+			_, err := vfs.DirSizeContext(ctx, tfs, "/folder")
+			Expect(err).To(MatchError(context.Canceled))
+		})
 	})
 	Describe("CopyFile", func() {
 		It("Copies source file to target file", func() {
@@ -300,6 +324,53 @@ var _ = Describe("FS", Label("fs"), func() {
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(e).To(BeTrue())
 		})
+		It("Preserves the holes of sparse files", func() {
+			Expect(vfs.MkdirAll(tfs, "/some", vfs.DirPerm)).To(Succeed())
+			f, err := tfs.Create("/some/file")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f.WriteAt([]byte("data"), 4*1024*1024)).To(Equal(4))
+			Expect(f.Truncate(16 * 1024 * 1024)).To(Succeed())
+			Expect(f.Close()).To(Succeed())
+
+			Expect(vfs.CopyFile(tfs, "/some/file", "/some/otherfile")).To(Succeed())
+			src, err := tfs.ReadFile("/some/file")
+			Expect(err).NotTo(HaveOccurred())
+			dst, err := tfs.ReadFile("/some/otherfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dst).To(Equal(src))
+
+			fInfo, err := tfs.Stat("/some/otherfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fInfo.Size()).To(Equal(int64(16 * 1024 * 1024)))
+			Expect(fInfo.Sys().(*syscall.Stat_t).Blocks * 512).To(BeNumerically("<", 1024*1024))
+		})
+		It("Preserves user extended attributes", func() {
+			Expect(vfs.MkdirAll(tfs, "/some", vfs.DirPerm)).To(Succeed())
+			Expect(tfs.WriteFile("/some/file", []byte("content"), vfs.FilePerm)).To(Succeed())
+			src, err := tfs.RawPath("/some/file")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unix.Lsetxattr(src, "user.elemental", []byte("value"), 0)).To(Succeed())
+			Expect(unix.Lsetxattr(src, "trusted.elemental", []byte("value"), 0)).To(Or(Succeed(), MatchError(unix.EPERM)))
+
+			Expect(vfs.CopyFile(tfs, "/some/file", "/some/otherfile")).To(Succeed())
+			dst, err := tfs.RawPath("/some/otherfile")
+			Expect(err).NotTo(HaveOccurred())
+			buf := make([]byte, 16)
+			n, err := unix.Lgetxattr(dst, "user.elemental", buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(buf[:n])).To(Equal("value"))
+			_, err = unix.Lgetxattr(dst, "trusted.elemental", buf)
+			Expect(err).To(MatchError(unix.ENODATA))
+		})
+		It("Aborts the copy once the context is cancelled", func() {
+			Expect(vfs.MkdirAll(tfs, "/some", vfs.DirPerm)).To(Succeed())
+			Expect(tfs.WriteFile("/some/file", []byte("content"), vfs.FilePerm)).To(Succeed())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			Expect(vfs.CopyFileContext(ctx, tfs, "/some/file", "/some/otherfile")).To(MatchError(context.Canceled))
+			Expect(vfs.Exists(tfs, "/some/otherfile")).To(BeFalse())
+		})
 		It("Fails to open non existing file", func() {
 			err := vfs.MkdirAll(tfs, "/some", vfs.DirPerm)
 			Expect(err).ShouldNot(HaveOccurred())
@@ -368,6 +439,22 @@ var _ = Describe("FS", Label("fs"), func() {
 			Expect(string(content)).To(Equal("content3"))
 		})
 
+		It("Preserves the extended attributes of directories", func() {
+			Expect(tfs.Mkdir(filepath.Join(srcDir, "nested"), vfs.DirPerm)).To(Succeed())
+			nested, err := tfs.RawPath(filepath.Join(srcDir, "nested"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unix.Lsetxattr(nested, "user.elemental", []byte("dir"), 0)).To(Succeed())
+
+			Expect(vfs.CopyDir(tfs, srcDir, destDir, true, nil)).To(Succeed())
+
+			nested, err = tfs.RawPath(filepath.Join(destDir, "nested"))
+			Expect(err).NotTo(HaveOccurred())
+			buf := make([]byte, 16)
+			n, err := unix.Lgetxattr(nested, "user.elemental", buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(buf[:n])).To(Equal("dir"))
+		})
+
 		It("Executes the onCopy callback for every file", func() {
 			var visited []string
 
@@ -650,6 +737,30 @@ var _ = Describe("FS", Label("fs"), func() {
 			Expect(err).To(HaveOccurred())
 		})
 	})
+	Describe("WriteFileAtomic", func() {
+		BeforeEach(func() {
+			Expect(vfs.MkdirAll(tfs, "/test", vfs.DirPerm)).To(Succeed())
+			Expect(tfs.WriteFile("/test/file", []byte("old content"), vfs.FilePerm)).To(Succeed())
+		})
+		It("replaces the file content without leaving temporary files", func() {
+			Expect(vfs.WriteFileAtomic(tfs, "/test/file", []byte("new content"), 0444)).To(Succeed())
+
+			data, err := tfs.ReadFile("/test/file")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("new content"))
+
+			info, err := tfs.Stat("/test/file")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(fs.FileMode(0444)))
+
+			entries, err := tfs.ReadDir("/test")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+		It("returns error writing to dir that does not exist", func() {
+			Expect(vfs.WriteFileAtomic(tfs, "/test/some/dir/file", []byte("content"), vfs.FilePerm)).NotTo(Succeed())
+		})
+	})
 	Describe("LoadEnvFile", func() {
 		BeforeEach(func() {
 			Expect(vfs.MkdirAll(tfs, "/test", vfs.DirPerm)).To(Succeed())
@@ -0,0 +1,125 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// xattrPrefixes are the namespaces of the extended attributes preserved on copies. The security
+// namespace includes SELinux labels (security.selinux) and file capabilities (security.capability).
+var xattrPrefixes = []string{"security.", "user."}
+
+// CopyXattrs copies the security and user extended attributes of source to target without
+// following symlinks. Attributes not supported by the target filesystem or not allowed to the
+// current user are skipped.
+func CopyXattrs(fs FS, source, target string) error {
+	return CopyXattrsFunc(fs, source, target, isPreservedXattr)
+}
+
+// CopyXattrsFunc is the same as CopyXattrs but only copies the extended attributes whose
+// name is accepted by the given include function.
+func CopyXattrsFunc(fs FS, source, target string, include func(name string) bool) error {
+	src, err := fs.RawPath(source)
+	if err != nil {
+		return err
+	}
+	dst, err := fs.RawPath(target)
+	if err != nil {
+		return err
+	}
+
+	names, err := listXattrs(src)
+	if isXattrUnsupported(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("listing extended attributes of '%s': %w", source, err)
+	}
+
+	for _, name := range names {
+		if !include(name) {
+			continue
+		}
+		value, err := getXattr(src, name)
+		if isXattrUnsupported(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("reading extended attribute '%s' of '%s': %w", name, source, err)
+		}
+		err = unix.Lsetxattr(dst, name, value, 0)
+		if isXattrUnsupported(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("setting extended attribute '%s' to '%s': %w", name, target, err)
+		}
+	}
+	return nil
+}
+
+// isPreservedXattr reports whether the extended attribute is in one of the preserved namespaces
+func isPreservedXattr(name string) bool {
+	return slices.ContainsFunc(xattrPrefixes, func(prefix string) bool { return strings.HasPrefix(name, prefix) })
+}
+
+// listXattrs returns the names of the extended attributes of the given path
+func listXattrs(path string) ([]string, error) {
+	size, err := unix.Llistxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Llistxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range bytes.SplitSeq(buf[:size], []byte{0}) {
+		if len(name) != 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names, nil
+}
+
+// getXattr returns the value of the given extended attribute of path
+func getXattr(path, name string) ([]byte, error) {
+	size, err := unix.Lgetxattr(path, name, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Lgetxattr(path, name, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
+
+// isXattrUnsupported returns true if the error reports the filesystem has no extended attributes
+// support (e.g. vfat) or the current user is not allowed to read or set them (e.g. security.* as non
+// root or attributes denied by the LSM policy)
+func isXattrUnsupported(err error) bool {
+	return errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EPERM) || errors.Is(err, unix.EACCES)
+}
@@ -0,0 +1,229 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package takeover
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/internal/cpio"
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/kexec"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// isoPath is the location of the installer ISO within the initramfs of the takeover boot
+	isoPath = "/takeover/installer.iso"
+	// memOverhead is the memory, on top of twice the ISO size, required to run the live installer from RAM
+	memOverhead = 1024 * 1024 * 1024
+
+	memInfo = "/proc/meminfo"
+)
+
+type Option func(*Takeover)
+
+type Takeover struct {
+	ctx     context.Context
+	s       *sys.System
+	workDir string
+	confirm func(d *deployment.Deployment) error
+}
+
+// WithWorkDir sets the directory used to stage the takeover kernel and initrd,
+// defaults to the system temporary directory
+func WithWorkDir(dir string) Option {
+	return func(t *Takeover) {
+		t.workDir = dir
+	}
+}
+
+// WithConfirm sets a function called with the deployment of the installer ISO before anything is
+// staged, staging is aborted if it returns an error
+func WithConfirm(confirm func(d *deployment.Deployment) error) Option {
+	return func(t *Takeover) {
+		t.confirm = confirm
+	}
+}
+
+func New(ctx context.Context, s *sys.System, opts ...Option) *Takeover {
+	t := &Takeover{
+		ctx: ctx,
+		s:   s,
+	}
+	for _, o := range opts {
+		o(t)
+	}
+	return t
+}
+
+// Stage prepares the current host to be taken over by the given installer ISO. It loads the kernel
+// and initrd of the ISO, with the whole ISO appended to the initrd, to be booted with kexec. The live
+// installer then runs entirely from RAM and installs the ISO deployment to its configured disks,
+// which can be the disks of the currently running host. The given kernel command line is appended
+// to the one defined in the ISO. Returns the deployment to be installed by the ISO.
+func (t Takeover) Stage(iso, cmdline string) (d *deployment.Deployment, err error) {
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	current, err := deployment.Parse(t.s, "/")
+	if err != nil {
+		return nil, fmt.Errorf("parsing current deployment: %w", err)
+	} else if current != nil {
+		return nil, fmt.Errorf("current host is already an elemental deployment, use upgrade or reset instead")
+	}
+
+	info, err := t.s.FS().Stat(iso)
+	if err != nil {
+		return nil, fmt.Errorf("checking installer ISO '%s': %w", iso, err)
+	}
+	err = t.checkMemory(uint64(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+
+	tempDir, err := vfs.TempDir(t.s.FS(), t.workDir, "elemental-takeover")
+	if err != nil {
+		return nil, fmt.Errorf("creating working directory: %w", err)
+	}
+	cleanup.Push(func() error { return t.s.FS().RemoveAll(tempDir) })
+
+	d, err = installer.LoadISOInstallDesc(t.s, tempDir, iso)
+	if err != nil {
+		return nil, fmt.Errorf("loading installer ISO description: %w", err)
+	}
+	for _, disk := range d.Disks {
+		if disk.Device == "" {
+			return nil, fmt.Errorf("installer ISO does not define the target device, it can't be used unattended")
+		}
+	}
+	if t.confirm != nil {
+		err = t.confirm(d)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	kernel, initrd, err := t.extractKernelInitrd(iso, tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	takeoverInitrd, err := t.appendISO(tempDir, initrd, iso)
+	if err != nil {
+		return nil, err
+	}
+
+	kernelCmdline := fmt.Sprintf("root=live:%s rd.live.ram=1 rd.live.overlay.overlayfs=1", isoPath)
+	kernelCmdline = strings.Join(strings.Fields(fmt.Sprintf("%s %s %s", kernelCmdline, d.Installer.KernelCmdline, cmdline)), " ")
+
+	err = kexec.Load(t.s, kernel, takeoverInitrd, kernelCmdline)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// checkMemory verifies the host has enough memory to copy the ISO into the initramfs and then
+// into the live root filesystem
+func (t Takeover) checkMemory(isoSize uint64) error {
+	data, err := t.s.FS().ReadFile(memInfo)
+	if err != nil {
+		return fmt.Errorf("reading memory information: %w", err)
+	}
+
+	var total uint64
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			total, err = strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("parsing total memory: %w", err)
+			}
+			// meminfo reports kB
+			total *= 1024
+			break
+		}
+	}
+
+	required := 2*isoSize + memOverhead
+	if total < required {
+		return fmt.Errorf("not enough memory to run the installer from RAM, %dMiB required but only %dMiB available", required>>20, total>>20)
+	}
+	return nil
+}
+
+// extractKernelInitrd extracts the live kernel and initrd from the given ISO into the given directory
+func (t Takeover) extractKernelInitrd(iso, dir string) (string, string, error) {
+	bootDir := filepath.Join(dir, "boot")
+	err := installer.ExtractISO(t.s, iso, "/boot", bootDir)
+	if err != nil {
+		return "", "", fmt.Errorf("extracting boot files: %w", err)
+	}
+
+	initrd, err := vfs.FindFile(t.s.FS(), bootDir, filepath.Join("/*/*", bootloader.Initrd))
+	if err != nil {
+		return "", "", fmt.Errorf("finding initrd: %w", err)
+	}
+
+	kernel, err := vfs.FindFile(t.s.FS(), filepath.Dir(initrd), "/uImage*", "/Image*", "/zImage*", "/vmlinuz*", "/image*")
+	if err != nil {
+		return "", "", fmt.Errorf("finding kernel: %w", err)
+	}
+	return kernel, initrd, nil
+}
+
+// appendISO creates a new initrd including the given ISO at isoPath
+func (t Takeover) appendISO(dir, initrd, iso string) (string, error) {
+	cpioRoot := filepath.Join(dir, "cpio")
+	target := filepath.Join(cpioRoot, isoPath)
+	err := vfs.MkdirAll(t.s.FS(), filepath.Dir(target), vfs.DirPerm)
+	if err != nil {
+		return "", fmt.Errorf("creating ISO directory in initrd: %w", err)
+	}
+
+	t.s.Logger().Info("Appending installer ISO to the initrd")
+	err = vfs.CopyFileContext(t.ctx, t.s.FS(), iso, target)
+	if err != nil {
+		return "", fmt.Errorf("copying installer ISO: %w", err)
+	}
+
+	isoCPIO := filepath.Join(dir, "iso.cpio")
+	err = cpio.CreateCPIO(t.ctx, t.s, cpioRoot, isoCPIO)
+	if err != nil {
+		return "", fmt.Errorf("creating ISO cpio archive: %w", err)
+	}
+
+	// The kernel unpacks all concatenated cpio archives of the initrd
+	takeoverInitrd := filepath.Join(dir, "takeover-initrd")
+	err = vfs.ConcatFilesContext(t.ctx, t.s.FS(), []string{initrd, isoCPIO}, takeoverInitrd)
+	if err != nil {
+		return "", fmt.Errorf("creating takeover initrd: %w", err)
+	}
+	return takeoverInitrd, nil
+}
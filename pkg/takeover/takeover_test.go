@@ -0,0 +1,159 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package takeover_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/takeover"
+)
+
+func TestTakeoverSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Takeover test suite")
+}
+
+const installDesc = `disks:
+- target: /dev/sda
+  partitions:
+  - label: EFI
+    role: efi
+  - label: SYSTEM
+    role: system
+installer:
+  kernelCmdline: console=ttyS0
+`
+
+const memInfo = `MemTotal:        8039080 kB
+MemFree:         2208548 kB
+`
+
+var _ = Describe("Takeover", Label("takeover"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var t *takeover.Takeover
+	var desc string
+	BeforeEach(func() {
+		var err error
+		desc = installDesc
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/proc/meminfo":        memInfo,
+			"/iso/installer.iso":   []byte("iso"),
+			"/tmp/placeholder.txt": "",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		t = takeover.New(context.Background(), s, takeover.WithWorkDir("/tmp"))
+
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd != "xorriso" {
+				return []byte{}, nil
+			}
+			dest := args[len(args)-1]
+			switch {
+			case slices.Contains(args, "Install/install.yaml"):
+				return []byte{}, fs.WriteFile(dest, []byte(desc), vfs.FilePerm)
+			case slices.Contains(args, "/boot"):
+				kDir := filepath.Join(dest, "sl-micro", "6.14.0")
+				Expect(vfs.MkdirAll(fs, kDir, vfs.DirPerm)).To(Succeed())
+				Expect(fs.WriteFile(filepath.Join(kDir, "vmlinuz"), []byte("kernel"), vfs.FilePerm)).To(Succeed())
+				Expect(fs.WriteFile(filepath.Join(kDir, ".vmlinuz.hmac"), []byte("hmac"), vfs.FilePerm)).To(Succeed())
+				return []byte{}, fs.WriteFile(filepath.Join(kDir, "initrd"), []byte("initrd"), vfs.FilePerm)
+			}
+			return []byte{}, nil
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("stages the installer ISO kernel and initrd with kexec", func() {
+		d, err := t.Stage("/iso/installer.iso", "rd.debug")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.Disks[0].Device).To(Equal("/dev/sda"))
+
+		cmds := runner.GetCmds()
+		Expect(cmds).NotTo(BeEmpty())
+		kexecCmd := cmds[len(cmds)-1]
+		Expect(kexecCmd[0:2]).To(Equal([]string{"kexec", "--load"}))
+		Expect(kexecCmd[2]).To(HaveSuffix("/sl-micro/6.14.0/vmlinuz"))
+		Expect(kexecCmd[3]).To(MatchRegexp("^--initrd=.*/takeover-initrd$"))
+		Expect(kexecCmd[4]).To(Equal(
+			"--command-line=root=live:/takeover/installer.iso rd.live.ram=1 rd.live.overlay.overlayfs=1 console=ttyS0 rd.debug",
+		))
+
+		// Staging files are removed once loaded
+		entries, err := fs.ReadDir("/tmp")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+	})
+	It("fails if the host is already an elemental deployment", func() {
+		Expect(vfs.MkdirAll(fs, "/etc/elemental", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/etc/elemental/deployment.yaml", []byte(installDesc), vfs.FilePerm)).To(Succeed())
+		_, err := t.Stage("/iso/installer.iso", "")
+		Expect(err).To(MatchError(ContainSubstring("already an elemental deployment")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("fails if there is not enough memory", func() {
+		Expect(fs.WriteFile("/proc/meminfo", []byte("MemTotal:  1024 kB\n"), vfs.FilePerm)).To(Succeed())
+		_, err := t.Stage("/iso/installer.iso", "")
+		Expect(err).To(MatchError(ContainSubstring("not enough memory")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("fails if the ISO does not define the target device", func() {
+		desc = "disks:\n- partitions: []\n"
+		_, err := t.Stage("/iso/installer.iso", "")
+		Expect(err).To(MatchError(ContainSubstring("does not define the target device")))
+		Expect(runner.IncludesCmds([][]string{{"kexec"}})).NotTo(Succeed())
+	})
+	It("does not stage anything if the target devices are not confirmed", func() {
+		t = takeover.New(context.Background(), s, takeover.WithWorkDir("/tmp"), takeover.WithConfirm(
+			func(d *deployment.Deployment) error {
+				Expect(d.Disks[0].Device).To(Equal("/dev/sda"))
+				return fmt.Errorf("not confirmed")
+			},
+		))
+		_, err := t.Stage("/iso/installer.iso", "")
+		Expect(err).To(MatchError("not confirmed"))
+		Expect(runner.IncludesCmds([][]string{{"kexec"}})).NotTo(Succeed())
+	})
+	It("fails if the ISO can't be extracted", func() {
+		runner.SideEffect = nil
+		runner.ReturnError = fmt.Errorf("xorriso failed")
+		_, err := t.Stage("/iso/installer.iso", "")
+		Expect(err).To(MatchError(ContainSubstring("xorriso failed")))
+	})
+})
@@ -18,19 +18,24 @@ limitations under the License.
 package mock
 
 import (
+	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/transaction"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
 type Transactioner struct {
-	InitErr           error
-	StartErr          error
-	CommitErr         error
-	RollbackErr       error
-	Trans             *transaction.Transaction
-	UpgradeHelper     UpgradeHelper
-	SrcDigest         string
+	InitErr          error
+	StartErr         error
+	CommitErr        error
+	RollbackErr      error
+	MountSnapshotErr error
+	SnapshotPath     string
+	Trans            *transaction.Transaction
+	UpgradeHelper    UpgradeHelper
+	SrcDigest        string
+	// InitrdHelper makes Init return an upgrade helper implementing transaction.InitrdHelper
+	InitrdHelper      bool
 	rollbackCalled    bool
 	activeSnapshotIDs []int
 }
@@ -40,6 +45,7 @@ type UpgradeHelper struct {
 	MergeError    error
 	FstabError    error
 	LockError     error
+	InitrdError   error
 	srcDigest     string
 	kernelCmdline string
 }
@@ -65,12 +71,24 @@ func (u UpgradeHelper) GenerateKernelCmdline(_ *transaction.Transaction) string
 	return u.kernelCmdline
 }
 
+// InitrdUpgradeHelper is an UpgradeHelper requiring its own dracut configuration
+type InitrdUpgradeHelper struct {
+	UpgradeHelper
+}
+
+func (u InitrdUpgradeHelper) ConfigureInitrd(_ *transaction.Transaction) error {
+	return u.InitrdError
+}
+
 func NewTransaction() transaction.Interface {
 	return &Transactioner{}
 }
 
 func (t Transactioner) Init(_ deployment.Deployment) (transaction.UpgradeHelper, error) {
 	t.UpgradeHelper.srcDigest = t.SrcDigest
+	if t.InitrdHelper {
+		return InitrdUpgradeHelper{UpgradeHelper: t.UpgradeHelper}, t.InitErr
+	}
 	return t.UpgradeHelper, t.InitErr
 }
 
@@ -94,3 +112,7 @@ func (t Transactioner) RollbackCalled() bool {
 func (t Transactioner) GetActiveSnapshotIDs() ([]int, error) {
 	return t.activeSnapshotIDs, nil
 }
+
+func (t Transactioner) MountSnapshot(_ int, _ *cleanstack.CleanStack) (string, error) {
+	return t.SnapshotPath, t.MountSnapshotErr
+}
@@ -0,0 +1,466 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transaction
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/swap"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
+)
+
+const (
+	// OverlayImagesDir is the directory of the system partition holding the squashfs OS images
+	OverlayImagesDir = "images"
+	// OverlayDir is the directory of the system partition holding the persistent overlayfs upper and work directories
+	OverlayDir = "overlay"
+
+	// OverlayImageArg is the kernel command line argument setting the squashfs image of the system partition to boot
+	OverlayImageArg = "rd.elemental.image"
+	// OverlayArg is the kernel command line argument setting the directory of the system partition holding
+	// the overlayfs upper and work directories
+	OverlayArg = "rd.elemental.overlay"
+
+	// OverlayDracutModuleDir is the directory of the dracut module mounting the image and the overlay as the root
+	OverlayDracutModuleDir = "/usr/lib/dracut/modules.d/90elemental-overlay"
+	// OverlayDracutConfig is the dracut configuration adding the overlay dracut module to the initrd
+	OverlayDracutConfig = "/etc/dracut.conf.d/50-elemental-overlay.conf"
+
+	maxImages = 3
+)
+
+var (
+	//go:embed templates/module-setup.sh
+	overlayModuleSetup []byte
+
+	//go:embed templates/elemental-overlay-mount.sh
+	overlayMountHook []byte
+)
+
+// Overlay is a transaction backend deploying each OS image as a read-only squashfs image stored in the
+// system partition. The initrd includes a dracut module mounting the squashfs image given by the OverlayImageArg
+// kernel argument as the lower layer of an overlayfs whose upper layer persists in the system partition. It avoids
+// the copy-on-write wear of btrfs on low-end flash devices. Persistent data lives in the upper layer, hence
+// it is not merged on upgrades.
+type Overlay struct {
+	ctx        context.Context
+	s          *sys.System
+	d          *deployment.Deployment
+	cleanStack *cleanstack.CleanStack
+	lsBlk      block.Device
+	hwParts    block.PartitionList
+	sysMnt     string
+	imageIDs   []int
+}
+
+func NewOverlay(ctx context.Context, s *sys.System, d *deployment.Deployment, lsBlk block.Device) Interface {
+	return &Overlay{ctx: ctx, s: s, d: d, cleanStack: cleanstack.NewCleanStack(), lsBlk: lsBlk}
+}
+
+var _ Interface = (*Overlay)(nil)
+var _ UpgradeHelper = (*Overlay)(nil)
+var _ InitrdHelper = (*Overlay)(nil)
+
+// Init mounts the system partition and lists the images it already includes
+func (o *Overlay) Init(d deployment.Deployment) (UpgradeHelper, error) {
+	o.d = &d
+	if o.sysMnt != "" {
+		return o, nil
+	}
+
+	sysPart := d.GetSystemPartition()
+	if sysPart == nil {
+		return nil, fmt.Errorf("no system partition defined in deployment")
+	}
+
+	var err error
+	o.hwParts, err = o.lsBlk.GetAllPartitions()
+	if err != nil {
+		return nil, fmt.Errorf("probing host partitions: %w", err)
+	}
+
+	part := o.hwParts.GetByUUIDNameOrLabel(sysPart.UUID, sysPart.Role.String(), sysPart.Label)
+	if part == nil {
+		return nil, fmt.Errorf("system partition not found: %+v", sysPart)
+	}
+
+	o.sysMnt, err = o.mountSystemPartition(part.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range []string{OverlayImagesDir, filepath.Join(OverlayDir, "upper"), filepath.Join(OverlayDir, "work")} {
+		err = vfs.MkdirAll(o.s.FS(), filepath.Join(o.sysMnt, dir), vfs.DirPerm)
+		if err != nil {
+			return nil, fmt.Errorf("creating overlay directory '%s': %w", dir, err)
+		}
+	}
+
+	o.imageIDs, err = o.listImages()
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Start creates a staging tree for the new image and mounts the non system partitions into it
+func (o *Overlay) Start() (*Transaction, error) {
+	if o.sysMnt == "" {
+		return nil, fmt.Errorf("uninitialized overlay transaction")
+	}
+
+	id := 1
+	if len(o.imageIDs) > 0 {
+		id = slices.Max(o.imageIDs) + 1
+	}
+
+	o.s.Logger().Info("Starting an overlay transaction for image %d", id)
+	trans := &Transaction{ID: id, Path: o.stagingPath(id), status: started}
+	err := vfs.MkdirAll(o.s.FS(), trans.Path, vfs.DirPerm)
+	if err != nil {
+		return nil, fmt.Errorf("creating staging directory: %w", err)
+	}
+	o.cleanStack.Push(func() error { return o.s.FS().RemoveAll(trans.Path) })
+
+	for _, part := range o.d.GetAllPartitions() {
+		if part.Role == deployment.System || part.MountPoint == "" {
+			continue
+		}
+		err = o.mountPartition(trans.Path, part)
+		if err != nil {
+			return trans, o.Rollback(trans, err)
+		}
+	}
+	return trans, nil
+}
+
+// Commit squashes the staging tree of the given transaction into the image of the transaction and removes
+// old images
+func (o *Overlay) Commit(trans *Transaction, cleanup func() error) (err error) {
+	if trans.status != started {
+		return fmt.Errorf("transaction '%d' is not started", trans.ID)
+	}
+	o.s.Logger().Info("Committing transaction")
+
+	image := o.imagePath(trans.ID)
+	tmpImage := image + ".tmp"
+	err = filesystem.CreateSquashFS(
+		o.ctx, o.s, trans.Path, tmpImage,
+		append(filesystem.SquashfsCompressionOptions(o.imagesCompression()), "-noappend", "-one-file-system"),
+	)
+	if err != nil {
+		_ = o.s.FS().RemoveAll(tmpImage)
+		return fmt.Errorf("creating image squashfs: %w", err)
+	}
+	err = o.s.FS().Rename(tmpImage, image)
+	if err != nil {
+		return fmt.Errorf("storing image '%s': %w", image, err)
+	}
+
+	// The image is in place, hence from now on the transaction can't be rolledback anymore.
+	trans.status = committed
+	o.imageIDs = append(o.imageIDs, trans.ID)
+
+	err = o.pruneImages(trans.ID)
+	if err != nil {
+		o.s.Logger().Warn("Could not remove old images: %v", err)
+	}
+
+	if cleanup != nil {
+		o.cleanStack.Push(cleanup)
+	}
+	err = o.cleanStack.Cleanup(nil)
+	o.sysMnt = ""
+	if err != nil {
+		o.s.Logger().Error("transaction cleanup procedure failed after committing")
+	}
+	o.s.Logger().Info("Transaction closed")
+	return err
+}
+
+// Rollback unmounts the staging tree of the given transaction and removes it
+func (o *Overlay) Rollback(trans *Transaction, e error) error {
+	if trans.status == committed {
+		o.s.Logger().Warn("cannot rollback a committed transaction")
+		return e
+	}
+	o.s.Logger().Error("Closing transaction due to a failure: %v", e)
+	err := o.cleanStack.Cleanup(e)
+	o.sysMnt = ""
+	trans.status = failed
+	return err
+}
+
+// GetActiveSnapshotIDs returns the IDs of the images in the system partition
+func (o *Overlay) GetActiveSnapshotIDs() ([]int, error) {
+	return slices.Clone(o.imageIDs), nil
+}
+
+// MountSnapshot mounts the image with the given ID read-only to a temporary directory and returns the mount point
+func (o *Overlay) MountSnapshot(id int, cleanup *cleanstack.CleanStack) (string, error) {
+	if !slices.Contains(o.imageIDs, id) {
+		return "", fmt.Errorf("image '%d' not found", id)
+	}
+
+	mountPoint, err := vfs.TempDir(o.s.FS(), "", fmt.Sprintf("elemental_image%d", id))
+	if err != nil {
+		return "", fmt.Errorf("creating temporary mount point: %w", err)
+	}
+	cleanup.Push(func() error { return o.s.FS().RemoveAll(mountPoint) })
+
+	err = o.s.Mounter().Mount(o.imagePath(id), mountPoint, "squashfs", []string{"ro"})
+	if err != nil {
+		return "", fmt.Errorf("mounting image '%d': %w", id, err)
+	}
+	cleanup.Push(func() error { return o.s.Mounter().Unmount(mountPoint) })
+	return mountPoint, nil
+}
+
+// SyncImageContent unpacks the given image source into the staging tree of the given transaction
+func (o *Overlay) SyncImageContent(imgSrc *deployment.ImageSource, trans *Transaction, opts ...unpack.Opt) error {
+	if trans.status != started {
+		return fmt.Errorf("given transaction '%d' is not started", trans.ID)
+	}
+
+	// The very first image fully populates other partitions (e.g. the ESP), next ones
+	// leave them untouched.
+	excludes := []string{}
+	if len(o.imageIDs) > 0 {
+		for _, part := range o.d.GetAllPartitions() {
+			if part.Role != deployment.System && part.MountPoint != "" {
+				excludes = append(excludes, part.MountPoint)
+			}
+		}
+	}
+
+	o.s.Logger().Info("Unpacking image source: %s", imgSrc.String())
+	unpacker, err := unpack.NewUnpacker(o.s, imgSrc, opts...)
+	if err != nil {
+		return fmt.Errorf("initializing unpacker: %w", err)
+	}
+	digest, err := unpacker.SynchedUnpack(o.ctx, trans.Path, excludes, excludes)
+	if err != nil {
+		return fmt.Errorf("unpacking image to '%s': %w", trans.Path, err)
+	}
+	imgSrc.SetDigest(digest)
+	return nil
+}
+
+// Merge does nothing, persistent data is kept in the overlayfs upper layer
+func (o *Overlay) Merge(*Transaction) error {
+	return nil
+}
+
+// UpdateFstab writes the fstab of the staging tree including all non system partitions, the root
+// filesystem is set up by the initrd
+func (o *Overlay) UpdateFstab(trans *Transaction) error {
+	lines := []fstab.Line{}
+	parts := o.d.GetAllPartitions()
+	for _, part := range parts {
+		if part.Role == deployment.System || part.MountPoint == "" {
+			continue
+		}
+		lines = append(lines, fstab.Line{
+			Device:     fmt.Sprintf("PARTUUID=%s", part.UUID),
+			MountPoint: part.MountPoint,
+			Options:    part.MountOpts,
+			FileSystem: part.FileSystem.String(),
+		})
+	}
+	lines = append(lines, swap.FstabLines(parts, o.d.Swap)...)
+	return fstab.Write(o.s, filepath.Join(trans.Path, fstab.File), lines)
+}
+
+// Lock does nothing, the image is read-only once squashed on commit
+func (o *Overlay) Lock(*Transaction) error {
+	return nil
+}
+
+// ConfigureInitrd installs into the staging tree of the given transaction the dracut module mounting the
+// image and the overlay as the root of the system and adds it to the dracut configuration
+func (o *Overlay) ConfigureInitrd(trans *Transaction) error {
+	moduleDir := filepath.Join(trans.Path, OverlayDracutModuleDir)
+	err := vfs.MkdirAll(o.s.FS(), moduleDir, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating dracut module directory: %w", err)
+	}
+	for name, data := range map[string][]byte{
+		"module-setup.sh":            overlayModuleSetup,
+		"elemental-overlay-mount.sh": overlayMountHook,
+	} {
+		err = o.s.FS().WriteFile(filepath.Join(moduleDir, name), data, 0755)
+		if err != nil {
+			return fmt.Errorf("writing dracut module file '%s': %w", name, err)
+		}
+	}
+
+	confFile := filepath.Join(trans.Path, OverlayDracutConfig)
+	err = vfs.MkdirAll(o.s.FS(), filepath.Dir(confFile), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating dracut configuration directory: %w", err)
+	}
+	err = o.s.FS().WriteFile(confFile, []byte("add_dracutmodules+=\" elemental-overlay \"\n"), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing dracut configuration: %w", err)
+	}
+	return nil
+}
+
+func (o *Overlay) GenerateKernelCmdline(trans *Transaction) string {
+	return fmt.Sprintf(
+		"%s=/%s/%d.squashfs %s=/%s", OverlayImageArg, OverlayImagesDir, trans.ID, OverlayArg, OverlayDir,
+	)
+}
+
+func (o *Overlay) imagePath(id int) string {
+	return filepath.Join(o.sysMnt, OverlayImagesDir, fmt.Sprintf("%d.squashfs", id))
+}
+
+func (o *Overlay) stagingPath(id int) string {
+	return filepath.Join(o.sysMnt, OverlayImagesDir, fmt.Sprintf("%d.staging", id))
+}
+
+// listImages returns the sorted IDs of the images stored in the system partition
+func (o *Overlay) listImages() ([]int, error) {
+	entries, err := o.s.FS().ReadDir(filepath.Join(o.sysMnt, OverlayImagesDir))
+	if err != nil {
+		return nil, fmt.Errorf("listing images: %w", err)
+	}
+
+	ids := []int{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".squashfs")
+		if !ok {
+			continue
+		}
+		if id, err := strconv.Atoi(name); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// pruneImages removes the oldest images above the maximum count, the given new image and the booted image are kept
+func (o *Overlay) pruneImages(newID int) error {
+	booted := o.bootedImage()
+
+	var errs []error
+	kept := []int{}
+	deletes := len(o.imageIDs) - maxImages
+	for _, id := range o.imageIDs {
+		if deletes <= 0 || id == newID || id == booted {
+			kept = append(kept, id)
+			continue
+		}
+		o.s.Logger().Info("Removing image %d", id)
+		if err := o.s.FS().Remove(o.imagePath(id)); err != nil {
+			errs = append(errs, err)
+			kept = append(kept, id)
+			continue
+		}
+		deletes--
+	}
+	o.imageIDs = kept
+	return errors.Join(errs...)
+}
+
+// bootedImage returns the ID of the image the running system was booted from, or zero if unknown
+func (o *Overlay) bootedImage() int {
+	cmdline, err := o.s.FS().ReadFile("/proc/cmdline")
+	if err != nil {
+		return 0
+	}
+	for _, arg := range strings.Fields(string(cmdline)) {
+		if image, ok := strings.CutPrefix(arg, OverlayImageArg+"="); ok {
+			id, _ := strconv.Atoi(strings.TrimSuffix(filepath.Base(image), ".squashfs"))
+			return id
+		}
+	}
+	return 0
+}
+
+// mountSystemPartition returns the path of an existing read-write mount of the given system partition device,
+// typically set by the initrd, or mounts it to a temporary directory otherwise. The system partition mount
+// the initrd stacks the overlay root on is hidden, hence it is skipped.
+func (o *Overlay) mountSystemPartition(device string) (string, error) {
+	mountPoints, err := o.s.Mounter().GetMountPoints(device)
+	if err != nil {
+		return "", fmt.Errorf("getting mount points: %w", err)
+	}
+	for _, mnt := range mountPoints {
+		if mnt.Path != "/" && !slices.Contains(mnt.Opts, "ro") {
+			return mnt.Path, nil
+		}
+	}
+
+	sysMnt, err := vfs.TempDir(o.s.FS(), "", "elemental_overlay")
+	if err != nil {
+		return "", fmt.Errorf("creating temporary mount point: %w", err)
+	}
+	o.cleanStack.Push(func() error { return o.s.FS().RemoveAll(sysMnt) })
+
+	err = o.s.Mounter().Mount(device, sysMnt, "", []string{"rw"})
+	if err != nil {
+		return "", fmt.Errorf("mounting system partition '%s': %w", device, err)
+	}
+	o.cleanStack.Push(func() error { return o.s.Mounter().Unmount(sysMnt) })
+	return sysMnt, nil
+}
+
+func (o *Overlay) mountPartition(root string, part *deployment.Partition) error {
+	dev := o.hwParts.GetByUUIDNameOrLabel(part.UUID, part.Role.String(), part.Label)
+	if dev == nil {
+		return fmt.Errorf("partition not found: %+v", part)
+	}
+
+	target := filepath.Join(root, part.MountPoint)
+	err := vfs.MkdirAll(o.s.FS(), target, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating mountpoint '%s': %w", target, err)
+	}
+
+	err = o.s.Mounter().Mount(dev.Path, target, part.FileSystem.String(), part.MountOpts)
+	if err != nil {
+		return fmt.Errorf("mounting partition '%s': %w", part.Label, err)
+	}
+	o.cleanStack.Push(func() error { return o.s.Mounter().Unmount(target) })
+	return nil
+}
+
+// imagesCompression returns the compression of the squashfs images set in the deployment, if any
+func (o *Overlay) imagesCompression() *deployment.Compression {
+	if o.d == nil || o.d.Compression == nil {
+		return nil
+	}
+	return o.d.Compression.Images
+}
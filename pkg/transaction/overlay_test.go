@@ -0,0 +1,238 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transaction_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	blockmock "github.com/suse/elemental/v3/pkg/block/mock"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+var _ = Describe("OverlayTransaction", Label("transaction", "overlay"), func() {
+	var overlay transaction.Interface
+	var uh transaction.UpgradeHelper
+	var oRunner *sysmock.Runner
+	var oMounter *sysmock.Mounter
+	var oFS vfs.FS
+	var oCleanup func()
+	var squashErr error
+	var oSys *sys.System
+	var od *deployment.Deployment
+	var blk *blockmock.Device
+
+	BeforeEach(func() {
+		var err error
+		squashErr = nil
+		oMounter = sysmock.NewMounter()
+		oRunner = sysmock.NewRunner()
+		oFS, oCleanup, err = sysmock.TestFS(map[string]any{
+			"/run/system/images/1.squashfs": "",
+			"/run/system/images/2.squashfs": "",
+			"/run/system/images/3.squashfs": "",
+			"/proc/cmdline":                 "root=LABEL=SYSTEM rd.elemental.image=/images/1.squashfs",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		oSys, err = sys.NewSystem(
+			sys.WithFS(oFS), sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithRunner(oRunner), sys.WithMounter(oMounter),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(oMounter.Mount("/dev/loop0p2", "/run/system", "", []string{"rw"})).To(Succeed())
+
+		oRunner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "mksquashfs" {
+				if squashErr != nil {
+					return []byte{}, squashErr
+				}
+				return []byte{}, oFS.WriteFile(args[1], []byte{}, vfs.FilePerm)
+			}
+			return []byte{}, nil
+		}
+
+		od = deployment.DefaultDeployment()
+		sysPart := od.GetSystemPartition()
+		sysPart.FileSystem = deployment.Ext4
+		sysPart.RWVolumes = nil
+		od.SourceOS = deployment.NewDirSrc("/image/root")
+		od.Disks[0].Device = "/dev/loop0"
+		od.GetEfiPartition().UUID = "efi-uuid"
+		sysPart.UUID = "system-uuid"
+		Expect(od.Sanitize(oSys, deployment.CheckDiskDevice)).To(Succeed())
+
+		blk = blockmock.NewBlockDevice([]*block.Partition{
+			{
+				Name:       "loop0p1",
+				Path:       "/dev/loop0p1",
+				UUID:       "efi-uuid",
+				Label:      deployment.EfiLabel,
+				FileSystem: deployment.VFat.String(),
+			},
+			{
+				Name:       "loop0p2",
+				Path:       "/dev/loop0p2",
+				UUID:       "system-uuid",
+				Label:      deployment.SystemLabel,
+				FileSystem: deployment.Ext4.String(),
+			},
+			{
+				Name:       "vdb1",
+				Path:       "/dev/vdb1",
+				UUID:       "data-uuid",
+				Label:      "DATA",
+				FileSystem: deployment.XFS.String(),
+			},
+		}...)
+
+		overlay = transaction.NewOverlay(context.TODO(), oSys, od, blk)
+		uh, err = overlay.Init(*od)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		oCleanup()
+	})
+	It("commits a new image and removes old ones", func() {
+		trans, err := overlay.Start()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trans.ID).To(Equal(4))
+		Expect(trans.Path).To(Equal("/run/system/images/4.staging"))
+		Expect(oMounter.IsMountPoint("/run/system/images/4.staging/boot")).To(BeTrue())
+
+		Expect(uh.GenerateKernelCmdline(trans)).To(Equal("rd.elemental.image=/images/4.squashfs rd.elemental.overlay=/overlay"))
+
+		Expect(overlay.Commit(trans, nil)).To(Succeed())
+		Expect(oRunner.CmdsMatch([][]string{{
+			"mksquashfs", "/run/system/images/4.staging", "/run/system/images/4.squashfs.tmp",
+			"-b", "1024k", "-noappend", "-one-file-system",
+		}})).To(Succeed())
+
+		for image, exists := range map[string]bool{
+			"/run/system/images/1.squashfs": true,
+			"/run/system/images/2.squashfs": false,
+			"/run/system/images/3.squashfs": true,
+			"/run/system/images/4.squashfs": true,
+			"/run/system/images/4.staging":  false,
+		} {
+			ok, _ := vfs.Exists(oFS, image)
+			Expect(ok).To(Equal(exists), image)
+		}
+		Expect(oMounter.IsMountPoint("/run/system/images/4.staging/boot")).To(BeFalse())
+		Expect(oMounter.IsMountPoint("/run/system")).To(BeTrue())
+		Expect(overlay.GetActiveSnapshotIDs()).To(Equal([]int{1, 3, 4}))
+	})
+	It("writes an fstab without the system partition", func() {
+		trans, err := overlay.Start()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vfs.MkdirAll(oFS, "/run/system/images/4.staging/etc", vfs.DirPerm)).To(Succeed())
+		Expect(uh.UpdateFstab(trans)).To(Succeed())
+		data, err := oFS.ReadFile("/run/system/images/4.staging/etc/fstab")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("/boot"))
+		Expect(string(data)).NotTo(MatchRegexp(`\s/\s`))
+	})
+	It("mounts and writes the fstab of partitions from other disks", func() {
+		od.Disks = append(od.Disks, &deployment.Disk{Device: "/dev/vdb", Partitions: []*deployment.Partition{{
+			Role: deployment.Generic, Label: "DATA", UUID: "data-uuid", FileSystem: deployment.XFS, MountPoint: "/data",
+		}}})
+		overlay = transaction.NewOverlay(context.TODO(), oSys, od, blk)
+		uh, err := overlay.Init(*od)
+		Expect(err).NotTo(HaveOccurred())
+
+		trans, err := overlay.Start()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(oMounter.IsMountPoint("/run/system/images/4.staging/data")).To(BeTrue())
+		Expect(vfs.MkdirAll(oFS, "/run/system/images/4.staging/etc", vfs.DirPerm)).To(Succeed())
+		Expect(uh.UpdateFstab(trans)).To(Succeed())
+		data, err := oFS.ReadFile("/run/system/images/4.staging/etc/fstab")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("PARTUUID=data-uuid /data xfs"))
+	})
+	It("rolls back a failed transaction", func() {
+		trans, err := overlay.Start()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(overlay.Rollback(trans, fmt.Errorf("failed"))).To(MatchError("failed"))
+		ok, _ := vfs.Exists(oFS, "/run/system/images/4.staging")
+		Expect(ok).To(BeFalse())
+		Expect(oMounter.IsMountPoint("/run/system/images/4.staging/boot")).To(BeFalse())
+	})
+	It("fails to commit if the image can't be squashed", func() {
+		squashErr = fmt.Errorf("no space left")
+		trans, err := overlay.Start()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(overlay.Commit(trans, nil)).To(MatchError(ContainSubstring("no space left")))
+		ok, _ := vfs.Exists(oFS, "/run/system/images/4.squashfs")
+		Expect(ok).To(BeFalse())
+		Expect(overlay.GetActiveSnapshotIDs()).To(Equal([]int{1, 2, 3}))
+	})
+	It("installs the dracut module mounting the image and the overlay", func() {
+		trans, err := overlay.Start()
+		Expect(err).NotTo(HaveOccurred())
+		ih, ok := uh.(transaction.InitrdHelper)
+		Expect(ok).To(BeTrue())
+		Expect(ih.ConfigureInitrd(trans)).To(Succeed())
+
+		moduleDir := "/run/system/images/4.staging" + transaction.OverlayDracutModuleDir
+		for _, file := range []string{"module-setup.sh", "elemental-overlay-mount.sh"} {
+			info, err := oFS.Stat(moduleDir + "/" + file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm() & 0111).NotTo(BeZero())
+		}
+		data, err := oFS.ReadFile(moduleDir + "/elemental-overlay-mount.sh")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("getarg rd.elemental.image="))
+		Expect(string(data)).To(ContainSubstring("mount -t overlay overlay"))
+
+		data, err = oFS.ReadFile("/run/system/images/4.staging" + transaction.OverlayDracutConfig)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("add_dracutmodules+=\" elemental-overlay \"\n"))
+	})
+	It("skips the hidden system partition mount below the overlay root", func() {
+		Expect(oMounter.Unmount("/run/system")).To(Succeed())
+		Expect(oMounter.Mount("/dev/loop0p2", "/", "", []string{"rw"})).To(Succeed())
+		Expect(oMounter.Mount("/dev/loop0p2", "/run/system", "", []string{"rw"})).To(Succeed())
+
+		overlay = transaction.NewOverlay(context.TODO(), oSys, od, blk)
+		_, err := overlay.Init(*od)
+		Expect(err).NotTo(HaveOccurred())
+		trans, err := overlay.Start()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trans.Path).To(Equal("/run/system/images/4.staging"))
+	})
+	It("mounts an image read-only", func() {
+		cleanStack := cleanstack.NewCleanStack()
+		path, err := overlay.MountSnapshot(3, cleanStack)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(oMounter.IsMountPoint(path)).To(BeTrue())
+		Expect(cleanStack.Cleanup(nil)).To(Succeed())
+		Expect(oMounter.IsMountPoint(path)).To(BeFalse())
+
+		_, err = overlay.MountSnapshot(7, cleanStack)
+		Expect(err).To(MatchError("image '7' not found"))
+	})
+})
@@ -26,6 +26,7 @@ import (
 	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/swap"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
@@ -70,11 +71,6 @@ func (n Overwrite) Start() (*Transaction, error) {
 		return nil, fmt.Errorf("failed listing partitions")
 	}
 
-	sysDisk := n.d.GetSystemDisk()
-	if sysDisk == nil {
-		return nil, fmt.Errorf("no system disk found in deployment")
-	}
-
 	sysPart := n.d.GetSystemPartition()
 	if sysPart == nil {
 		return nil, fmt.Errorf("no system partition found in deployment")
@@ -85,7 +81,7 @@ func (n Overwrite) Start() (*Transaction, error) {
 		return nil, fmt.Errorf("failed mounting partition '%s': %w", sysPart.Label, err)
 	}
 
-	for _, p := range sysDisk.Partitions {
+	for _, p := range n.d.GetAllPartitions() {
 		if p.Role == deployment.System {
 			continue
 		}
@@ -134,6 +130,10 @@ func (n Overwrite) GetActiveSnapshotIDs() ([]int, error) {
 	return []int{0}, nil
 }
 
+func (n Overwrite) MountSnapshot(int, *cleanstack.CleanStack) (string, error) {
+	return "", fmt.Errorf("cannot mount snapshots using 'overwrite' snapshotter")
+}
+
 func (n Overwrite) SyncImageContent(imgSrc *deployment.ImageSource, trans *Transaction, opts ...unpack.Opt) (err error) {
 	if trans.status != started {
 		return fmt.Errorf("given transaction '%d' is not started", trans.ID)
@@ -160,20 +160,20 @@ func (n Overwrite) Merge(*Transaction) error {
 
 func (n Overwrite) UpdateFstab(trans *Transaction) error {
 	lines := []fstab.Line{}
-	sysDisk := n.d.GetSystemDisk()
-	if sysDisk == nil {
-		return fmt.Errorf("no system disk found in deployment")
-	}
-
-	for _, part := range sysDisk.Partitions {
+	parts := n.d.GetAllPartitions()
+	for _, part := range parts {
+		if part.Role == deployment.Swap {
+			continue
+		}
 		lines = append(lines, fstab.Line{
-			Device:     fmt.Sprintf("PARTUUID=%s", part.UUID),
+			Device:     part.FstabDevice(),
 			MountPoint: part.MountPoint,
 			Options:    part.MountOpts,
 			FileSystem: part.FileSystem.String(),
 		})
 
 	}
+	lines = append(lines, swap.FstabLines(parts, n.d.Swap)...)
 	fstabFile := filepath.Join(trans.Path, fstab.File)
 	return fstab.Write(n.s, fstabFile, lines)
 }
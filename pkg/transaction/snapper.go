@@ -23,10 +23,12 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 
 	"github.com/suse/elemental/v3/pkg/block"
 	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/btrfs"
 	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
@@ -38,16 +40,41 @@ import (
 const (
 	snapshotPathTmpl = ".snapshots/%d/snapshot"
 	updateProgress   = "update-in-progress"
+	bootTrial        = "boot-trial"
 	maxSnapshots     = 8
 )
 
 type snapperContext struct {
-	ctx          context.Context
-	s            *sys.System
-	partitions   deployment.Partitions
-	cleanStack   *cleanstack.CleanStack
-	snap         *snapper.Snapper
-	maxSnapshots int
+	ctx        context.Context
+	s          *sys.System
+	partitions deployment.Partitions
+	swap       *deployment.SwapConfig
+	cleanStack *cleanstack.CleanStack
+	snap       *snapper.Snapper
+	retention  snapper.RetentionPolicy
+	delta      bool
+}
+
+// retentionPolicy returns the snapper retention policy for the given snapshotter configuration, keeping
+// the last maxSnapshots snapshots by default
+func retentionPolicy(c *deployment.SnapshotterConfig) snapper.RetentionPolicy {
+	p := snapper.RetentionPolicy{KeepLast: maxSnapshots}
+	if c == nil {
+		return p
+	}
+	p.UsageThreshold = c.CleanupThreshold
+	r := c.Retention
+	if r == nil {
+		return p
+	}
+	if r.KeepLast > 0 {
+		p.KeepLast = r.KeepLast
+	}
+	if r.KeepImportant {
+		p.KeepUserdata = append(p.KeepUserdata, "important")
+	}
+	p.KeepUserdata = append(p.KeepUserdata, r.KeepTagged...)
+	return p
 }
 
 // checkCancelled returns the given error if not nil, otherwise it returns the context error if any.
@@ -63,16 +90,18 @@ type snapperT struct {
 	defaultID    int
 	activeID     int
 	rootDir      string
+	sysDevice    string
 	hwPartitions block.PartitionList
 }
 
 func NewSnapper(ctx context.Context, s *sys.System) Interface {
+	s = s.WithComponent("transaction")
 	sc := snapperContext{
-		ctx:          ctx,
-		s:            s,
-		cleanStack:   cleanstack.NewCleanStack(),
-		snap:         snapper.New(s),
-		maxSnapshots: maxSnapshots,
+		ctx:        ctx,
+		s:          s,
+		cleanStack: cleanstack.NewCleanStack(),
+		snap:       snapper.New(s),
+		retention:  retentionPolicy(nil),
 	}
 	return &snapperT{
 		snapperContext: sc,
@@ -86,6 +115,11 @@ func (sn *snapperT) Init(d deployment.Deployment) (uh UpgradeHelper, err error)
 	for _, disk := range d.Disks {
 		sn.partitions = append(sn.partitions, disk.Partitions...)
 	}
+	sn.swap = d.Swap
+	if d.Snapshotter != nil {
+		sn.delta = d.Snapshotter.Delta
+	}
+	sn.retention = retentionPolicy(d.Snapshotter)
 
 	if ok, err := sn.isInitiated(d); ok {
 		return sn.snapperContext, nil
@@ -109,6 +143,14 @@ func (sn snapperT) Start() (trans *Transaction, err error) {
 		return nil, fmt.Errorf("uninitialized snapshotter")
 	}
 
+	if sn.retention.UsageThreshold > 0 && sn.defaultID > 0 {
+		sn.s.Logger().Info("Cleaning up snapshots above %d%% of filesystem usage", sn.retention.UsageThreshold)
+		err = sn.snap.CleanupByUsage(sn.rootDir, sn.retention)
+		if err != nil {
+			return nil, fmt.Errorf("cleaning up snapshots: %w", err)
+		}
+	}
+
 	sn.s.Logger().Info("Creating new snapshot")
 	trans, err = sn.createNewSnapshot(sn.defaultID)
 	if err != nil {
@@ -141,7 +183,11 @@ func (sn snapperT) Commit(trans *Transaction, cleanup func() error) (err error)
 	}
 
 	sn.s.Logger().Info("Setting new default snapshot")
-	err = sn.snap.SetDefault(trans.Path, trans.ID, map[string]string{updateProgress: ""})
+	metadata := map[string]string{updateProgress: ""}
+	if trans.Trial {
+		metadata[bootTrial] = "yes"
+	}
+	err = sn.snap.SetDefault(trans.Path, trans.ID, metadata)
 	if err != nil {
 		return fmt.Errorf("setting new default snapshot: %w", err)
 	}
@@ -151,7 +197,7 @@ func (sn snapperT) Commit(trans *Transaction, cleanup func() error) (err error)
 	if cleanup != nil {
 		sn.cleanStack.Push(cleanup)
 	}
-	sn.cleanStack.Push(func() error { return sn.snap.Cleanup(sn.rootDir, sn.maxSnapshots) })
+	sn.cleanStack.Push(func() error { return sn.snap.CleanupByPolicy(sn.rootDir, sn.retention) })
 
 	err = sn.cleanStack.Cleanup(err)
 	if err != nil {
@@ -207,6 +253,7 @@ func (sn *snapperT) probe(d deployment.Deployment) (err error) {
 	if part == nil {
 		return fmt.Errorf("system partition not found: %+v", sysPart)
 	}
+	sn.sysDevice = part.Path
 
 	mountPoints, err := sn.s.Mounter().GetMountPoints(part.Path)
 	if err != nil {
@@ -231,6 +278,11 @@ func (sn *snapperT) probe(d deployment.Deployment) (err error) {
 		}
 		sn.defaultID = snaps.GetDefault()
 		sn.activeID = snaps.GetActive()
+
+		err = sn.restoreFailedTrial(snaps)
+		if err != nil {
+			return fmt.Errorf("restoring snapshot after failed trial boot: %w", err)
+		}
 	} else {
 		// Assume a freshly formatted partition
 		// setting root over top level volume
@@ -240,6 +292,57 @@ func (sn *snapperT) probe(d deployment.Deployment) (err error) {
 	return nil
 }
 
+// restoreFailedTrial sets the booted snapshot as the default one again if the default snapshot was
+// booted on trial and the bootloader fell back to the booted one, so the failed snapshot is not
+// used as the base of new transactions. The outcome of the trial is read from the grub environment,
+// a default snapshot committed but not booted yet is still pending its trial. The trial flag of
+// the booted snapshot is cleared once its trial boot completed.
+func (sn *snapperT) restoreFailedTrial(snaps snapper.Snapshots) error {
+	if sn.activeID == 0 {
+		return nil
+	}
+	trials := snaps.GetWithUserdata(bootTrial, "yes")
+	if !slices.Contains(trials, sn.defaultID) && !slices.Contains(trials, sn.activeID) {
+		return nil
+	}
+
+	state, err := bootloader.ReadTrialState(sn.s, deployment.EfiMnt)
+	if err != nil {
+		return fmt.Errorf("reading trial boot state: %w", err)
+	}
+
+	if slices.Contains(trials, sn.activeID) && state.Entry == "" && state.Completed {
+		sn.s.Logger().Info("Trial boot of snapshot %d completed", sn.activeID)
+		err = sn.snap.SetUserdata(sn.rootDir, sn.activeID, map[string]string{bootTrial: ""})
+		if err != nil {
+			return fmt.Errorf("clearing trial flag of snapshot %d: %w", sn.activeID, err)
+		}
+	}
+
+	if sn.activeID == sn.defaultID || !slices.Contains(trials, sn.defaultID) {
+		return nil
+	}
+	if state.Entry == strconv.Itoa(sn.defaultID) {
+		sn.s.Logger().Info("Snapshot %d is pending its trial boot", sn.defaultID)
+		return nil
+	}
+	if !state.Failed || state.Fallback != strconv.Itoa(sn.activeID) {
+		return nil
+	}
+
+	sn.s.Logger().Warn("Trial boot of snapshot %d failed, restoring snapshot %d", sn.defaultID, sn.activeID)
+	err = sn.snap.SetUserdata(sn.rootDir, sn.defaultID, map[string]string{bootTrial: "failed"})
+	if err != nil {
+		return fmt.Errorf("flagging snapshot %d as failed: %w", sn.defaultID, err)
+	}
+	err = sn.snap.SetDefault(sn.rootDir, sn.activeID, nil)
+	if err != nil {
+		return fmt.Errorf("setting default snapshot %d: %w", sn.activeID, err)
+	}
+	sn.defaultID = sn.activeID
+	return nil
+}
+
 func (sn snapperT) GetActiveSnapshotIDs() ([]int, error) {
 	snaps, err := sn.snap.ListSnapshots(sn.rootDir, "root")
 	if err != nil {
@@ -255,6 +358,37 @@ func (sn snapperT) GetActiveSnapshotIDs() ([]int, error) {
 	return snapIDs, nil
 }
 
+// MountSnapshot mounts the given snapshot read-only to a temporary directory and returns the mount point.
+// Unmounting the snapshot and removing the temporary directory are pushed to the given clean stack.
+func (sn snapperT) MountSnapshot(id int, cleanup *cleanstack.CleanStack) (string, error) {
+	if sn.sysDevice == "" {
+		return "", fmt.Errorf("uninitialized snapshotter")
+	}
+
+	snaps, err := sn.snap.ListSnapshots(sn.rootDir, "root")
+	if err != nil {
+		return "", fmt.Errorf("listing snapshots: %w", err)
+	}
+	if !slices.ContainsFunc(snaps, func(snap *snapper.Snapshot) bool { return snap.Number == id }) {
+		return "", fmt.Errorf("snapshot '%d' not found", id)
+	}
+
+	mountPoint, err := vfs.TempDir(sn.s.FS(), "", fmt.Sprintf("elemental_snapshot_%d", id))
+	if err != nil {
+		return "", fmt.Errorf("creating a temporary directory: %w", err)
+	}
+	cleanup.PushSuccessOnly(func() error { return sn.s.FS().RemoveAll(mountPoint) })
+
+	subvol := filepath.Join(btrfs.TopSubVol, fmt.Sprintf(snapshotPathTmpl, id))
+	err = sn.s.Mounter().Mount(sn.sysDevice, mountPoint, "", []string{"ro", fmt.Sprintf("subvol=%s", subvol)})
+	if err != nil {
+		return "", fmt.Errorf("mounting snapshot '%d': %w", id, err)
+	}
+	cleanup.Push(func() error { return sn.s.Mounter().Unmount(mountPoint) })
+
+	return mountPoint, nil
+}
+
 // mountPartition mounts the given partition to the given mount point. In addition it also
 // sets the umount cleanup task.
 func (sn snapperT) mountPartition(part *deployment.Partition, mountPoint string) error {
@@ -24,6 +24,9 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/transaction"
 )
 
@@ -177,6 +180,57 @@ var _ = Describe("SnapperTransaction", Label("transaction"), func() {
 					{"snapper", "--no-dbus", "--root", "/.snapshots/5/snapshot", "modify", "--default"},
 				})).To(Succeed())
 			})
+			It("flags the snapshot of a trial boot transaction", func() {
+				sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+					if slices.Contains(args, "create") {
+						return []byte("2\n"), nil
+					}
+					if slices.Contains(args, "list") {
+						return []byte(installSnapList), nil
+					}
+					return runner.ReturnValue, runner.ReturnError
+				}
+				trans.Trial = true
+				Expect(sn.Commit(trans, nil)).To(Succeed())
+				Expect(runner.GetCmds()).To(ContainElement(And(
+					ContainElements("modify", "--default", "5"),
+					ContainElement(ContainSubstring("boot-trial=yes")),
+				)))
+			})
+		})
+		It("mounts a snapshot read-only and cleans it up", func() {
+			cleanStack := cleanstack.NewCleanStack()
+			path, err := sn.MountSnapshot(2, cleanStack)
+			Expect(err).NotTo(HaveOccurred())
+			mnts, err := mount.GetMountPoints("/dev/sda2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mnts).To(ContainElement(And(
+				HaveField("Path", path),
+				HaveField("Opts", ConsistOf("ro", "subvol=@/.snapshots/2/snapshot")),
+			)))
+			Expect(cleanStack.Cleanup(nil)).To(Succeed())
+			Expect(mount.IsMountPoint(path)).To(BeFalse())
+			ok, _ := vfs.Exists(tfs, path)
+			Expect(ok).To(BeFalse())
+		})
+		It("fails to mount a non existing snapshot", func() {
+			cleanStack := cleanstack.NewCleanStack()
+			_, err := sn.MountSnapshot(7, cleanStack)
+			Expect(err).To(MatchError("snapshot '7' not found"))
+		})
+		It("fails to start a transaction if it can't clean up snapshots over the usage threshold", func() {
+			d.Snapshotter.CleanupThreshold = 80
+			runner.ClearCmds()
+			_ = initSnapperUpgrade(root)
+			sideEffects["btrfs"] = func(args ...string) ([]byte, error) {
+				return []byte{}, fmt.Errorf("usage failed")
+			}
+			_, err := sn.Start()
+			Expect(err).To(MatchError(ContainSubstring("cleaning up snapshots")))
+			Expect(runner.MatchMilestones([][]string{
+				{"snapper", "--no-dbus", "-c", "root", "--jsonout", "list"},
+				{"btrfs", "filesystem", "usage", "--raw", "/"},
+			})).To(Succeed())
 		})
 		It("it fails to start a transaction if it does not find previous snapshotted volumes", func() {
 			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
@@ -195,6 +249,98 @@ var _ = Describe("SnapperTransaction", Label("transaction"), func() {
 			})).To(Succeed())
 		})
 	})
+	It("keeps the tagged snapshots when cleaning up after committing a transaction", func() {
+		d.Snapshotter.Retention = &deployment.RetentionPolicy{KeepLast: 3, KeepTagged: []string{"pinned"}}
+		_ = initSnapperUpgrade("/")
+		trans := startUpgradeTransaction()
+		sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+			if slices.Contains(args, "create") {
+				return []byte("2\n"), nil
+			}
+			if slices.Contains(args, "list") {
+				return []byte(pinnedSnapList), nil
+			}
+			return runner.ReturnValue, runner.ReturnError
+		}
+		Expect(sn.Commit(trans, nil)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"btrfs", "subvolume", "delete", "-c", "-R", "/.snapshots/2/snapshot"},
+		})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"btrfs", "subvolume", "delete", "-c", "-R", "/.snapshots/1/snapshot"},
+		})).NotTo(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"btrfs", "subvolume", "delete", "-c", "-R", "/.snapshots/3/snapshot"},
+		})).NotTo(Succeed())
+	})
+	Describe("trial boots", func() {
+		var grubEnv string
+		BeforeEach(func() {
+			Expect(mount.Mount("/dev/sda2", "/", "", []string{"ro", "subvol=@/.snapshots/4/snapshot"})).To(Succeed())
+			Expect(vfs.MkdirAll(tfs, "/boot", vfs.DirPerm)).To(Succeed())
+			Expect(tfs.WriteFile("/boot/grubenv", []byte{}, vfs.FilePerm)).To(Succeed())
+			sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
+				return []byte(lsblkJson), nil
+			}
+			sideEffects["grub2-editenv"] = func(args ...string) ([]byte, error) {
+				return []byte(grubEnv), nil
+			}
+		})
+		It("restores the booted snapshot if the trial boot of the default one failed", func() {
+			grubEnv = "fallback_entry=4\nboot_success=0\n"
+			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+				if slices.Contains(args, "list") && slices.Contains(args, "root") {
+					return []byte(failedTrialSnapList), nil
+				}
+				return runner.ReturnValue, runner.ReturnError
+			}
+			sn = transaction.NewSnapper(ctx, s)
+			_, err = sn.Init(*d)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runner.CmdsMatch([][]string{
+				{"lsblk", "-p", "-b", "-n", "-J", "--output"},
+				{"snapper", "--no-dbus", "-c", "root", "--jsonout", "list"},
+				{"grub2-editenv", "/boot/grubenv", "list"},
+				{"snapper", "--no-dbus", "modify", "--userdata", "boot-trial=failed", "5"},
+				{"snapper", "--no-dbus", "modify", "--default", "4"},
+			})).To(Succeed())
+		})
+		It("does not restore the booted snapshot if the default one is pending its trial boot", func() {
+			grubEnv = "trial_entry=5\nfallback_entry=4\nboot_counter=3\nboot_success=0\n"
+			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+				if slices.Contains(args, "list") && slices.Contains(args, "root") {
+					return []byte(failedTrialSnapList), nil
+				}
+				return runner.ReturnValue, runner.ReturnError
+			}
+			sn = transaction.NewSnapper(ctx, s)
+			_, err = sn.Init(*d)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runner.CmdsMatch([][]string{
+				{"lsblk", "-p", "-b", "-n", "-J", "--output"},
+				{"snapper", "--no-dbus", "-c", "root", "--jsonout", "list"},
+				{"grub2-editenv", "/boot/grubenv", "list"},
+			})).To(Succeed())
+		})
+		It("clears the trial flag of the booted snapshot once its trial boot completed", func() {
+			grubEnv = "boot_success=1\n"
+			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+				if slices.Contains(args, "list") && slices.Contains(args, "root") {
+					return []byte(completedTrialSnapList), nil
+				}
+				return runner.ReturnValue, runner.ReturnError
+			}
+			sn = transaction.NewSnapper(ctx, s)
+			_, err = sn.Init(*d)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runner.CmdsMatch([][]string{
+				{"lsblk", "-p", "-b", "-n", "-J", "--output"},
+				{"snapper", "--no-dbus", "-c", "root", "--jsonout", "list"},
+				{"grub2-editenv", "/boot/grubenv", "list"},
+				{"snapper", "--no-dbus", "modify", "--userdata", "boot-trial=", "4"},
+			})).To(Succeed())
+		})
+	})
 	It("fails to init snapper transactioner if it can't list snapshots", func() {
 		Expect(mount.Mount("/dev/sda2", "/", "", []string{"ro", "subvol=@/.snapshots/4/snapshot"})).To(Succeed())
 		sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/suse/elemental/v3/pkg/fstab"
 	"github.com/suse/elemental/v3/pkg/rsync"
 	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/swap"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
@@ -46,6 +48,11 @@ func (sc snapperContext) SyncImageContent(imgSrc *deployment.ImageSource, trans
 	}
 	var unpacker unpack.Interface
 
+	if sc.delta {
+		// The new snapshot is a copy of the default one, hence only the new layers need to be applied
+		opts = append(slices.Clip(opts), unpack.WithDelta(true))
+	}
+
 	sc.s.Logger().Info("Unpacking image source: %s", imgSrc.String())
 	unpacker, err = unpack.NewUnpacker(sc.s, imgSrc, opts...)
 	if err != nil {
@@ -159,7 +166,7 @@ func (sc snapperContext) syncSnapshotDeleteExcludes() []string {
 
 // configureSnapper sets the snapper configuration for root and any snapshotted volume.
 func (sc snapperContext) configureSnapper(trans *Transaction) error {
-	err := sc.snap.ConfigureRoot(trans.Path, sc.maxSnapshots)
+	err := sc.snap.ConfigureRoot(trans.Path, sc.retention.KeepLast)
 	if err != nil {
 		return fmt.Errorf("setting root configuration: %w", err)
 	}
@@ -417,7 +424,7 @@ func (sc snapperContext) createFstab(trans *Transaction) error {
 			if len(opts) == 0 {
 				opts = []string{"defaults"}
 			}
-			line.Device = fmt.Sprintf("PARTUUID=%s", part.UUID)
+			line.Device = part.FstabDevice()
 			line.MountPoint = part.MountPoint
 			line.Options = opts
 			line.FileSystem = part.FileSystem.String()
@@ -450,6 +457,7 @@ func (sc snapperContext) createFstab(trans *Transaction) error {
 			fstabLines = append(fstabLines, line)
 		}
 	}
+	fstabLines = append(fstabLines, swap.FstabLines(sc.partitions, sc.swap)...)
 
 	return fstab.Write(sc.s, filepath.Join(trans.Path, fstab.File), fstabLines)
 }
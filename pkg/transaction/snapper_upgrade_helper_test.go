@@ -129,7 +129,7 @@ var _ = Describe("SnapperUpgradeHelper", Label("transaction"), func() {
 		It("it fails to create fstab file if the path does not exist", func() {
 			err := upgradeH.UpdateFstab(trans)
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("creating fstab: creating file: open"))
+			Expect(err.Error()).To(ContainSubstring("creating fstab: writing file: open"))
 			Expect(err.Error()).To(ContainSubstring("no such file or directory"))
 		})
 		It("locks the current transaction", func() {
@@ -0,0 +1,108 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// SnapshotManager manages the snapshots of a snapper based deployment
+type SnapshotManager struct {
+	s    *sys.System
+	snap *snapper.Snapper
+	root string
+}
+
+// NewSnapshotManager returns a SnapshotManager for the snapper based deployment at the given root
+func NewSnapshotManager(s *sys.System, root string) *SnapshotManager {
+	return &SnapshotManager{s: s, snap: snapper.New(s), root: root}
+}
+
+// List returns the snapshots of the root configuration
+func (sm SnapshotManager) List() (snapper.Snapshots, error) {
+	snaps, err := sm.snap.ListSnapshots(sm.root, "root")
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+	return snaps, nil
+}
+
+// Delete removes the given snapshot. The active and default snapshots can't be deleted.
+func (sm SnapshotManager) Delete(id int) error {
+	snap, err := sm.get(id)
+	if err != nil {
+		return err
+	}
+	if snap.Active || snap.Default {
+		return fmt.Errorf("cannot delete snapshot %d, it is the active or default snapshot", id)
+	}
+
+	sm.s.Logger().Info("Deleting snapshot %d", id)
+	return sm.snap.DeleteSnapshot(sm.root, id)
+}
+
+// Tag sets the given tags to the given snapshot, or removes them if remove is true. Tags are
+// userdata keys set to 'yes', honored by the snapshots retention policy.
+func (sm SnapshotManager) Tag(id int, remove bool, tags ...string) error {
+	if len(tags) == 0 {
+		return fmt.Errorf("no tags given")
+	}
+	if _, err := sm.get(id); err != nil {
+		return err
+	}
+
+	value := "yes"
+	if remove {
+		value = ""
+	}
+	metadata := snapper.Metadata{}
+	for _, tag := range tags {
+		metadata[tag] = value
+	}
+	err := sm.snap.SetUserdata(sm.root, id, metadata)
+	if err != nil {
+		return fmt.Errorf("tagging snapshot %d: %w", id, err)
+	}
+	return nil
+}
+
+// Diff returns the files changed from snapshot num1 to snapshot num2
+func (sm SnapshotManager) Diff(num1, num2 int) ([]snapper.Change, error) {
+	for _, id := range []int{num1, num2} {
+		if _, err := sm.get(id); err != nil {
+			return nil, err
+		}
+	}
+	return sm.snap.Diff(sm.root, "root", num1, num2)
+}
+
+// get returns the given snapshot or an error if it does not exist
+func (sm SnapshotManager) get(id int) (*snapper.Snapshot, error) {
+	snaps, err := sm.List()
+	if err != nil {
+		return nil, err
+	}
+	snap := snaps.Get(id)
+	if snap == nil {
+		return nil, fmt.Errorf("snapshot %d not found", id)
+	}
+	return snap, nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transaction_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+var _ = Describe("SnapshotManager", Label("transaction", "snapshots"), func() {
+	var manager *transaction.SnapshotManager
+	BeforeEach(func() {
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/.snapshots/2/snapshot/etc/os-release": "",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			fullCmd := strings.Join(append([]string{cmd}, args...), " ")
+			switch {
+			case strings.Contains(fullCmd, "list --columns"):
+				return []byte(pinnedSnapList), nil
+			case strings.Contains(fullCmd, "status 2..4"):
+				return []byte("c..... /etc/os-release\n+..... /etc/motd\n"), nil
+			}
+			return []byte{}, nil
+		}
+		manager = transaction.NewSnapshotManager(s, "/")
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("lists snapshots", func() {
+		snaps, err := manager.List()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snaps).To(HaveLen(5))
+		Expect(snaps.GetActive()).To(Equal(4))
+	})
+	It("deletes a snapshot", func() {
+		Expect(manager.Delete(2)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"snapper", "--no-dbus", "-c", "root", "--jsonout", "list"},
+			{"btrfs", "property", "set", "-ts", "/.snapshots/2/snapshot", "ro", "false"},
+			{"btrfs", "subvolume", "delete", "-c", "-R", "/.snapshots/2/snapshot"},
+		})).To(Succeed())
+		Expect(tfs.Stat("/.snapshots/2")).Error().To(HaveOccurred())
+	})
+	It("does not delete the active or default snapshots", func() {
+		Expect(manager.Delete(4)).NotTo(Succeed())
+		Expect(manager.Delete(5)).NotTo(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"btrfs"}})).NotTo(Succeed())
+	})
+	It("fails to delete a non existing snapshot", func() {
+		err := manager.Delete(7)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("snapshot 7 not found"))
+	})
+	It("tags and untags a snapshot", func() {
+		Expect(manager.Tag(3, false, "important")).To(Succeed())
+		Expect(manager.Tag(1, true, "pinned")).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"snapper", "--no-dbus", "modify", "--userdata", "important=yes", "3"},
+			{"snapper", "--no-dbus", "modify", "--userdata", "pinned=", "1"},
+		})).To(Succeed())
+	})
+	It("fails to tag without tags", func() {
+		Expect(manager.Tag(3, false)).NotTo(Succeed())
+	})
+	It("lists the changes between two snapshots", func() {
+		changes, err := manager.Diff(2, 4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal([]snapper.Change{
+			{Status: "c.....", Path: "/etc/os-release"},
+			{Status: "+.....", Path: "/etc/motd"},
+		}))
+	})
+	It("fails to diff if snapper status fails", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if strings.Contains(strings.Join(args, " "), "status") {
+				return []byte{}, fmt.Errorf("snapper failed")
+			}
+			return []byte(pinnedSnapList), nil
+		}
+		_, err := manager.Diff(2, 4)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("comparing snapshots 2 and 4"))
+	})
+})
@@ -0,0 +1,84 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transaction
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// SyncPolicy defines at which transaction milestones the filesystem holding the transaction
+// is explicitly flushed to disk instead of relying on the kernel to eventually flush it.
+type SyncPolicy uint
+
+const (
+	// SyncAfterContent flushes the transaction after syncing the OS image content
+	SyncAfterContent SyncPolicy = 1 << iota
+	// SyncAfterFstab flushes the transaction after writing the fstab file
+	SyncAfterFstab
+	// SyncBeforeLock flushes the transaction before it is flipped to read-only
+	SyncBeforeLock
+	// SyncBeforeBootloader flushes the transaction before the bootloader is switched to it
+	SyncBeforeBootloader
+
+	SyncNone          SyncPolicy = 0
+	DefaultSyncPolicy            = SyncAfterContent | SyncAfterFstab | SyncBeforeLock | SyncBeforeBootloader
+)
+
+func (p SyncPolicy) String() string {
+	switch p {
+	case SyncAfterContent:
+		return "content sync"
+	case SyncAfterFstab:
+		return "fstab update"
+	case SyncBeforeLock:
+		return "lock"
+	case SyncBeforeBootloader:
+		return "bootloader install"
+	default:
+		return fmt.Sprintf("sync policy %d", uint(p))
+	}
+}
+
+// Sync flushes the filesystem holding the given path if the policy includes the given milestone
+func (p SyncPolicy) Sync(s *sys.System, milestone SyncPolicy, path string) error {
+	if p&milestone == 0 {
+		return nil
+	}
+
+	s.Logger().Debug("Flushing filesystem of '%s' at %s milestone", path, milestone)
+	f, err := s.FS().Open(path)
+	if err != nil {
+		return fmt.Errorf("opening '%s': %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	osFile, ok := f.(*os.File)
+	if !ok {
+		return nil
+	}
+	err = unix.Syncfs(int(osFile.Fd()))
+	if err != nil {
+		return fmt.Errorf("flushing filesystem of '%s': %w", path, err)
+	}
+	return nil
+}
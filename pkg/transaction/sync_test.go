@@ -0,0 +1,53 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transaction_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+var _ = Describe("SyncPolicy", Label("transaction", "sync"), func() {
+	var syncSys *sys.System
+	BeforeEach(func() {
+		fs, fsCleanup, err := sysmock.TestFS(map[string]any{"/snapshot/etc/fstab": ""})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		syncSys, err = sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	It("flushes the filesystem at the milestones included in the policy", func() {
+		policy := transaction.SyncAfterContent | transaction.SyncBeforeLock
+		Expect(policy.Sync(syncSys, transaction.SyncAfterContent, "/snapshot")).To(Succeed())
+		Expect(policy.Sync(syncSys, transaction.SyncBeforeLock, "/snapshot/etc/fstab")).To(Succeed())
+	})
+	It("skips the milestones not included in the policy", func() {
+		Expect(transaction.SyncNone.Sync(syncSys, transaction.SyncAfterFstab, "/nonexisting")).To(Succeed())
+		policy := transaction.DefaultSyncPolicy &^ transaction.SyncBeforeBootloader
+		Expect(policy.Sync(syncSys, transaction.SyncBeforeBootloader, "/nonexisting")).To(Succeed())
+	})
+	It("fails to flush a non existing path", func() {
+		err := transaction.DefaultSyncPolicy.Sync(syncSys, transaction.SyncAfterFstab, "/nonexisting")
+		Expect(err).To(MatchError(ContainSubstring("opening '/nonexisting'")))
+	})
+})
@@ -0,0 +1,29 @@
+#!/bin/sh
+# Mounts the squashfs OS image set by rd.elemental.image as the read-only lower
+# layer of an overlayfs whose upper layer persists in the rd.elemental.overlay
+# directory. Both paths are relative to the system partition mounted by the
+# root= argument. Generated by elemental.
+
+type getarg > /dev/null 2>&1 || . /lib/dracut-lib.sh
+
+image=$(getarg rd.elemental.image=)
+[ -n "${image}" ] || return 0
+overlay=$(getarg rd.elemental.overlay=)
+overlay=${overlay:-/overlay}
+
+# The system partition stays reachable at SYSTEM_DIR after switching root,
+# upgrades store the new images there
+SYSTEM_DIR=/run/elemental/system
+LOWER_DIR=/run/elemental/image
+
+mount -o remount,rw "${NEWROOT}" || die "elemental-overlay: failed to remount the system partition read-write"
+mkdir -p "${SYSTEM_DIR}" "${LOWER_DIR}"
+mount --bind "${NEWROOT}" "${SYSTEM_DIR}" || die "elemental-overlay: failed to bind mount the system partition"
+
+[ -f "${SYSTEM_DIR}${image}" ] || die "elemental-overlay: image '${image}' not found in the system partition"
+mount -t squashfs -o ro,loop "${SYSTEM_DIR}${image}" "${LOWER_DIR}" || die "elemental-overlay: failed to mount image '${image}'"
+
+mkdir -p "${SYSTEM_DIR}${overlay}/upper" "${SYSTEM_DIR}${overlay}/work"
+mount -t overlay overlay \
+    -o "lowerdir=${LOWER_DIR},upperdir=${SYSTEM_DIR}${overlay}/upper,workdir=${SYSTEM_DIR}${overlay}/work" \
+    "${NEWROOT}" || die "elemental-overlay: failed to mount the overlay root"
@@ -0,0 +1,21 @@
+#!/bin/bash
+# Dracut module mounting the squashfs OS images of the overlay snapshotter as
+# the root of the system. Generated by elemental.
+
+check() {
+    # Only included on request, the overlay snapshotter adds it to the dracut configuration
+    return 255
+}
+
+depends() {
+    echo rootfs-block
+}
+
+installkernel() {
+    hostonly='' instmods overlay squashfs loop
+}
+
+install() {
+    inst_multiple mount mkdir
+    inst_hook pre-pivot 10 "$moddir/elemental-overlay-mount.sh"
+}
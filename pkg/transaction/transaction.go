@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/unpack"
@@ -43,6 +44,8 @@ func New(ctx context.Context, s *sys.System, d *deployment.Deployment, name stri
 		return NewSnapper(ctx, s), nil
 	case "overwrite":
 		return NewOverwrite(ctx, s, d, lsblk.NewLsDevice(s)), nil
+	case "overlay":
+		return NewOverlay(ctx, s, d, lsblk.NewLsDevice(s)), nil
 	}
 
 	return nil, fmt.Errorf("unknown snapshotter '%s'", name)
@@ -58,6 +61,9 @@ type Transaction struct {
 	ID     int
 	Path   string
 	Merges map[string]*Merge
+	// Trial flags the snapshot of the transaction as booted on trial, if its boot does not
+	// complete the bootloader falls back to the previous snapshot
+	Trial bool
 
 	status transactionState
 }
@@ -69,6 +75,7 @@ type Interface interface {
 	Rollback(*Transaction, error) error
 
 	GetActiveSnapshotIDs() ([]int, error)
+	MountSnapshot(id int, cleanup *cleanstack.CleanStack) (string, error)
 }
 
 type UpgradeHelper interface {
@@ -78,3 +85,11 @@ type UpgradeHelper interface {
 	Lock(*Transaction) error
 	GenerateKernelCmdline(*Transaction) string
 }
+
+// InitrdHelper is implemented by the upgrade helpers whose snapshots are only bootable by an initrd
+// including their own dracut configuration
+type InitrdHelper interface {
+	// ConfigureInitrd installs the dracut configuration required to boot the given transaction, the
+	// initrd is regenerated afterwards
+	ConfigureInitrd(*Transaction) error
+}
@@ -161,6 +161,71 @@ const upgradeSnapList = `{
   }
 `
 
+const pinnedSnapList = `{
+	"root": [
+	  {
+		"number": 1,
+		"default": false,
+		"active": false,
+		"userdata": {"pinned": "yes"}
+	  },{
+		"number": 2,
+		"default": false,
+		"active": false,
+		"userdata": null
+	  },{
+		"number": 3,
+		"default": false,
+		"active": false,
+		"userdata": null
+	  },{
+		"number": 4,
+		"default": false,
+		"active": true,
+		"userdata": null
+	  },{
+		"number": 5,
+		"default": true,
+		"active": false,
+		"userdata": null
+	  }
+	]
+  }
+`
+
+const failedTrialSnapList = `{
+	"root": [
+	  {
+		"number": 4,
+		"default": false,
+		"active": true,
+		"userdata": null
+	  },{
+		"number": 5,
+		"default": true,
+		"active": false,
+		"userdata": {
+		    "boot-trial": "yes"
+		}
+	  }
+	]
+  }
+`
+
+const completedTrialSnapList = `{
+	"root": [
+	  {
+		"number": 4,
+		"default": true,
+		"active": true,
+		"userdata": {
+		    "boot-trial": "yes"
+		}
+	  }
+	]
+  }
+`
+
 const installSnapList = `{
 	"root": [
 	  {
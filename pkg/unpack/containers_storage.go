@@ -0,0 +1,110 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unpack
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+const podmanCmd = "podman"
+
+// ContainersStorage unpacks images already present in the local podman containers storage. The
+// image is mounted read-only by podman and synched from its mountpoint, nothing is pulled.
+type ContainersStorage struct {
+	s          *sys.System
+	imageRef   string
+	rsyncFlags []string
+}
+
+type ContainersStorageOpt func(*ContainersStorage)
+
+func WithRsyncFlagsContainersStorage(flags ...string) ContainersStorageOpt {
+	return func(c *ContainersStorage) {
+		c.rsyncFlags = flags
+	}
+}
+
+func NewContainersStorageUnpacker(s *sys.System, imageRef string, opts ...ContainersStorageOpt) *ContainersStorage {
+	c := &ContainersStorage{s: s, imageRef: imageRef}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+func (c ContainersStorage) Unpack(ctx context.Context, destination string, excludes ...string) (string, error) {
+	return c.onMountedImage(ctx, func(rootfs string) error {
+		unpackD := NewDirectoryUnpacker(c.s, rootfs, WithRsyncFlagsDir(c.rsyncFlags...))
+		_, err := unpackD.Unpack(ctx, destination, excludes...)
+		return err
+	})
+}
+
+func (c ContainersStorage) SynchedUnpack(ctx context.Context, destination string, excludes []string, deleteExcludes []string) (string, error) {
+	return c.onMountedImage(ctx, func(rootfs string) error {
+		unpackD := NewDirectoryUnpacker(c.s, rootfs, WithRsyncFlagsDir(c.rsyncFlags...))
+		_, err := unpackD.SynchedUnpack(ctx, destination, excludes, deleteExcludes)
+		return err
+	})
+}
+
+// Size returns the size of the unpacked image as reported by the containers storage
+func (c ContainersStorage) Size(ctx context.Context) (int64, error) {
+	out, err := c.s.Runner().RunContext(ctx, podmanCmd, "image", "inspect", "--format", "{{.Size}}", c.imageRef)
+	if err != nil {
+		return 0, fmt.Errorf("image '%s' not found in containers storage: %w", c.imageRef, err)
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing size of image '%s': %w", c.imageRef, err)
+	}
+	return size, nil
+}
+
+// onMountedImage mounts the image from the containers storage and runs the given callback with the
+// image root-tree mountpoint. Returns the image config digest (imageID) on success.
+func (c ContainersStorage) onMountedImage(ctx context.Context, callback func(rootfs string) error) (digest string, err error) {
+	out, err := c.s.Runner().RunContext(ctx, podmanCmd, "image", "inspect", "--format", "{{.Id}}", c.imageRef)
+	if err != nil {
+		return "", fmt.Errorf("image '%s' not found in containers storage: %w", c.imageRef, err)
+	}
+	digest = "sha256:" + strings.TrimSpace(string(out))
+
+	out, err = c.s.Runner().RunContext(ctx, podmanCmd, "image", "mount", c.imageRef)
+	if err != nil {
+		return "", fmt.Errorf("mounting image '%s' from containers storage: %w", c.imageRef, err)
+	}
+	mountpoint := strings.TrimSpace(string(out))
+	defer func() {
+		_, uErr := c.s.Runner().Run(podmanCmd, "image", "unmount", c.imageRef)
+		if err == nil && uErr != nil {
+			err = fmt.Errorf("unmounting image '%s': %w", c.imageRef, uErr)
+		}
+	}()
+
+	err = callback(mountpoint)
+	if err != nil {
+		return "", err
+	}
+	return digest, nil
+}
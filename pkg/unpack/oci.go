@@ -19,32 +19,43 @@ package unpack
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
-	"github.com/schollz/progressbar/v3"
-
+	"github.com/suse/elemental/v3/pkg/archive"
 	"github.com/suse/elemental/v3/pkg/containerd"
+	"github.com/suse/elemental/v3/pkg/progress"
+	"github.com/suse/elemental/v3/pkg/registry"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 
 	backoff "github.com/cenkalti/backoff/v4"
-	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	containerregistry "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/daemon"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"go.yaml.in/yaml/v3"
 )
 
 const (
-	CtrdSockEnv = "CONTAINERD_SOCK"
-
-	workDirSuffix = ".workdir"
-	ctrdNamespace = "k8s.io"
+	CtrdSockEnv     = "CONTAINERD_SOCK"
+	DefaultCtrdSock = "/run/containerd/containerd.sock"
+
+	workDirSuffix   = ".workdir"
+	layersDirSuffix = ".layers"
+	// deltaLayersFile records, within the unpacked tree, the layers of the image it was unpacked from
+	deltaLayersFile = "usr/lib/elemental/image-layers.yaml"
+	ctrdNamespace   = "k8s.io"
 )
 
 type OCI struct {
@@ -56,6 +67,9 @@ type OCI struct {
 	rsyncFlags  []string
 	ctrdSock    string
 	ctrd        containerd.Interface
+	delta       bool
+	concurrency int
+	registry    *registry.Config
 }
 
 type OCIOpt func(*OCI)
@@ -90,6 +104,38 @@ func WithContainerd(ctrd containerd.Interface) OCIOpt {
 	}
 }
 
+// WithContainerdSockOCI sets the containerd socket used to mount local images, it implies a local unpacker
+func WithContainerdSockOCI(sock string) OCIOpt {
+	return func(o *OCI) {
+		o.local = true
+		o.ctrdSock = sock
+	}
+}
+
+// WithDeltaOCI makes synched unpacks apply only the new layers of the image on top of the destination tree
+// when the destination was unpacked from an image whose layers are a prefix of the image layers. Local changes
+// of the destination tree are only overwritten if the new layers include the changed paths.
+func WithDeltaOCI(delta bool) OCIOpt {
+	return func(o *OCI) {
+		o.delta = delta
+	}
+}
+
+// WithConcurrencyOCI sets the number of layers downloaded in parallel, layers are always applied in order.
+// Values lower than two keep the default streamed extraction of the image.
+func WithConcurrencyOCI(n int) OCIOpt {
+	return func(o *OCI) {
+		o.concurrency = n
+	}
+}
+
+// WithRegistryConfigOCI sets the credentials, mirrors and insecure registries used to fetch remote images
+func WithRegistryConfigOCI(c *registry.Config) OCIOpt {
+	return func(o *OCI) {
+		o.registry = c
+	}
+}
+
 func NewOCIUnpacker(s *sys.System, imageRef string, opts ...OCIOpt) *OCI {
 	unpacker := &OCI{
 		s:           s,
@@ -102,7 +148,7 @@ func NewOCIUnpacker(s *sys.System, imageRef string, opts ...OCIOpt) *OCI {
 		o(unpacker)
 	}
 
-	if unpacker.local {
+	if unpacker.local && unpacker.ctrdSock == "" {
 		sock := os.Getenv(CtrdSockEnv)
 		if ok, _ := vfs.Exists(unpacker.s.FS(), sock); ok {
 			unpacker.ctrdSock = sock
@@ -116,6 +162,9 @@ func (o OCI) SynchedUnpack(ctx context.Context, destination string, excludes []s
 	if o.ctrdSock != "" {
 		return o.synchedUnpackContainerd(ctx, destination, excludes, deleteExcludes)
 	}
+	if o.delta {
+		return o.deltaSynchedUnpack(ctx, destination, excludes, deleteExcludes)
+	}
 	return o.synchedUnpack(ctx, destination, excludes, deleteExcludes)
 }
 
@@ -155,28 +204,246 @@ func (o OCI) synchedUnpack(ctx context.Context, destination string, excludes []s
 	return digest, nil
 }
 
-func (o OCI) unpack(ctx context.Context, destination string, excludes ...string) (string, error) {
-	platform, err := containerregistry.ParsePlatform(o.platformRef)
+// deltaSynchedUnpack applies the image layers missing in the destination tree on top of it, provided
+// the destination tree was unpacked from an image whose layers are a prefix of the image layers. Whiteouts
+// of the protected paths are ignored, so they are never deleted. Otherwise it falls back to a full synched
+// unpack. The layers of the image are recorded in the destination tree for the next delta unpack.
+// Unlike a full synched unpack, the paths of the layers already applied are not reconciled with the image,
+// so local changes of the destination tree outside the new layers are kept.
+func (o OCI) deltaSynchedUnpack(ctx context.Context, destination string, excludes []string, deleteExcludes []string) (digest string, err error) {
+	img, err := o.image(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	opts := []name.Option{}
-	if !o.verify {
-		opts = append(opts, name.Insecure)
+	configName, err := img.ConfigName()
+	if err != nil {
+		return "", err
 	}
 
-	ref, err := name.ParseReference(o.imageRef, opts...)
+	layers, err := img.Layers()
 	if err != nil {
 		return "", err
 	}
+	meta := deltaLayersMeta{Digest: configName.String(), Layers: make([]string, len(layers))}
+	for i, layer := range layers {
+		layerDigest, err := layer.Digest()
+		if err != nil {
+			return "", err
+		}
+		meta.Layers[i] = layerDigest.String()
+	}
 
-	var img containerregistry.Image
+	metaFile := filepath.Join(destination, deltaLayersFile)
+	previous := readDeltaLayersMeta(o.s, metaFile)
+	err = o.s.FS().Remove(metaFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("removing image layers record: %w", err)
+	}
 
-	err = backoff.Retry(func() error {
-		img, err = fetchImage(ctx, ref, *platform, o.local)
+	if previous.isPrefixOf(meta) {
+		o.s.Logger().Info("Applying %d of %d image layers on top of the previous image tree", len(layers)-len(previous.Layers), len(layers))
+		err = o.applyLayersFiltered(ctx, destination, layers[len(previous.Layers):], func(root string) archive.Filter {
+			return deltaFilter(root, excludes, deleteExcludes)
+		})
+	} else {
+		o.s.Logger().Info("Previous image tree is not a base of the image, unpacking the whole image")
+		_, err = o.synchedUnpack(ctx, destination, excludes, deleteExcludes)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	err = writeDeltaLayersMeta(o.s, metaFile, meta)
+	if err != nil {
+		return "", err
+	}
+	return meta.Digest, nil
+}
+
+// applyLayers extracts the given layers in order on top of the destination tree. If concurrency is
+// greater than one, layers are downloaded in parallel ahead of being applied.
+func (o OCI) applyLayers(ctx context.Context, destination string, layers []containerregistry.Layer, excludes ...string) error {
+	return o.applyLayersFiltered(ctx, destination, layers, func(root string) archive.Filter {
+		return excludesFilter(root, excludes...)
+	})
+}
+
+// applyLayersFiltered extracts the given layers in order on top of the destination tree, the given function
+// returns the filter of the layer entries for the raw path of the destination.
+func (o OCI) applyLayersFiltered(
+	ctx context.Context, destination string, layers []containerregistry.Layer, filter func(root string) archive.Filter,
+) (err error) {
+	if len(layers) == 0 {
+		return nil
+	}
+
+	next := func(i int) (containerregistry.Layer, error) {
+		return layers[i], nil
+	}
+	if o.concurrency > 1 && len(layers) > 1 {
+		layersDir := filepath.Clean(destination) + layersDirSuffix
+		err = vfs.MkdirAll(o.s.FS(), layersDir, vfs.DirPerm)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := vfs.ForceRemoveAll(o.s.FS(), layersDir)
+			if err == nil && e != nil {
+				err = e
+			}
+		}()
+
+		fetchCtx, cancel := context.WithCancel(ctx)
+		fetched, wait := o.fetchLayers(fetchCtx, layersDir, layers)
+		defer wait()
+		defer cancel()
+
+		next = func(i int) (containerregistry.Layer, error) {
+			f := <-fetched[i]
+			return f.layer, f.err
+		}
+	}
+
+	root, err := o.s.FS().RawPath(destination)
+	if err != nil {
 		return err
-	}, backoff.WithMaxRetries(backoff.NewConstantBackOff(3*time.Second), 3))
+	}
+
+	reporter := o.s.Progress()
+	progress.Start(reporter, progress.StepUnpack, o.imageRef)
+	for i := range layers {
+		layer, err := next(i)
+		if err != nil {
+			return err
+		}
+		reader, err := layer.Uncompressed()
+		if err != nil {
+			return err
+		}
+		_, err = containerd.Apply(ctx, root, reader, filter(root))
+		reader.Close()
+		if err != nil {
+			return err
+		}
+		reporter.Report(progress.Event{
+			Step: progress.StepUnpack, Target: o.imageRef, Percent: (i + 1) * 100 / len(layers),
+		})
+	}
+	progress.Done(reporter, progress.StepUnpack, o.imageRef)
+	return nil
+}
+
+type fetchedLayer struct {
+	layer containerregistry.Layer
+	err   error
+}
+
+// fetchLayers downloads the given layers into the given directory running up to the configured concurrency
+// of parallel downloads. Downloads are started in layer order. It returns a channel for each layer delivering
+// the downloaded layer and a function to wait for all downloads to finish.
+func (o OCI) fetchLayers(ctx context.Context, dir string, layers []containerregistry.Layer) ([]chan fetchedLayer, func()) {
+	var wg sync.WaitGroup
+
+	fetched := make([]chan fetchedLayer, len(layers))
+	for i := range layers {
+		fetched[i] = make(chan fetchedLayer, 1)
+	}
+
+	sem := make(chan struct{}, o.concurrency)
+	wg.Go(func() {
+		for i, layer := range layers {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				fetched[i] <- fetchedLayer{err: ctx.Err()}
+				continue
+			}
+			wg.Go(func() {
+				defer func() { <-sem }()
+				l, err := o.fetchLayer(ctx, filepath.Join(dir, strconv.Itoa(i)), layer)
+				fetched[i] <- fetchedLayer{layer: l, err: err}
+			})
+		}
+	})
+	return fetched, wg.Wait
+}
+
+// fetchLayer downloads the compressed layer blob into the given file and returns a layer reading from it
+func (o OCI) fetchLayer(ctx context.Context, file string, layer containerregistry.Layer) (containerregistry.Layer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, fmt.Errorf("fetching layer: %w", err)
+	}
+	defer rc.Close()
+
+	f, err := o.s.FS().Create(file)
+	if err != nil {
+		return nil, fmt.Errorf("creating layer file: %w", err)
+	}
+	_, err = io.Copy(f, rc)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("downloading layer: %w", err)
+	}
+
+	return tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return o.s.FS().Open(file)
+	})
+}
+
+// deltaLayersMeta describes the image a tree was unpacked from
+type deltaLayersMeta struct {
+	Digest string   `yaml:"digest"`
+	Layers []string `yaml:"layers"`
+}
+
+// isPrefixOf returns true if the layers of the given metadata start with all the layers of this metadata
+func (m *deltaLayersMeta) isPrefixOf(meta deltaLayersMeta) bool {
+	if m == nil || len(m.Layers) == 0 || len(m.Layers) > len(meta.Layers) {
+		return false
+	}
+	return slices.Equal(m.Layers, meta.Layers[:len(m.Layers)])
+}
+
+// readDeltaLayersMeta reads the image layers record, returns nil if it is missing or can't be parsed
+func readDeltaLayersMeta(s *sys.System, file string) *deltaLayersMeta {
+	data, err := s.FS().ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	meta := &deltaLayersMeta{}
+	if err = yaml.Unmarshal(data, meta); err != nil {
+		s.Logger().Warn("Ignoring invalid image layers record '%s': %v", file, err)
+		return nil
+	}
+	return meta
+}
+
+func writeDeltaLayersMeta(s *sys.System, file string, meta deltaLayersMeta) error {
+	data, err := yaml.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("marshalling image layers record: %w", err)
+	}
+	err = vfs.MkdirAll(s.FS(), filepath.Dir(file), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating image layers record directory: %w", err)
+	}
+	err = vfs.WriteFileAtomic(s.FS(), file, data, vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing image layers record: %w", err)
+	}
+	return nil
+}
+
+func (o OCI) unpack(ctx context.Context, destination string, excludes ...string) (string, error) {
+	img, err := o.image(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -186,6 +453,14 @@ func (o OCI) unpack(ctx context.Context, destination string, excludes ...string)
 		return "", err
 	}
 
+	if o.concurrency > 1 {
+		layers, err := img.Layers()
+		if err != nil {
+			return "", err
+		}
+		return digest.String(), o.applyLayers(ctx, destination, layers, excludes...)
+	}
+
 	reader := mutate.Extract(img)
 	defer reader.Close()
 
@@ -194,17 +469,110 @@ func (o OCI) unpack(ctx context.Context, destination string, excludes ...string)
 		return "", err
 	}
 
-	bar := progressbar.DefaultBytes(-1, "Extracting")
-	defer bar.Close()
+	// The flattened image size is unknown ahead of extraction, only start and end are reported
+	progress.Start(o.s.Progress(), progress.StepUnpack, o.imageRef)
+	_, err = containerd.Apply(ctx, destination, reader, excludesFilter(destination, excludes...))
+	if err != nil {
+		return "", err
+	}
+	progress.Done(o.s.Progress(), progress.StepUnpack, o.imageRef)
+
+	return digest.String(), nil
+}
 
-	r := progressbar.NewReader(reader, bar)
+// Digest returns the config digest (imageID) of the image, as returned when unpacking it, without
+// fetching its layers
+func (o OCI) Digest(ctx context.Context) (string, error) {
+	if o.ctrdSock != "" {
+		_, img, err := o.containerdImage(ctx)
+		if err != nil {
+			return "", err
+		}
+		return img.Digest, nil
+	}
 
-	_, err = containerd.Apply(ctx, destination, &r, excludesFilter(destination, excludes...))
+	img, err := o.image(ctx)
+	if err != nil {
+		return "", fmt.Errorf("resolving image '%s': %w", o.imageRef, err)
+	}
 
-	return digest.String(), err
+	digest, err := img.ConfigName()
+	if err != nil {
+		return "", fmt.Errorf("reading config digest of image '%s': %w", o.imageRef, err)
+	}
+	return digest.String(), nil
 }
 
-func fetchImage(ctx context.Context, ref name.Reference, platform containerregistry.Platform, local bool) (containerregistry.Image, error) {
+// Size returns the sum of the layer sizes of the image in bytes. For remote images these are
+// the compressed layer sizes as reported by the manifest, nothing is downloaded.
+func (o OCI) Size(ctx context.Context) (int64, error) {
+	img, err := o.image(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("resolving image '%s': %w", o.imageRef, err)
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return 0, fmt.Errorf("listing layers of image '%s': %w", o.imageRef, err)
+	}
+
+	var size int64
+	for _, layer := range layers {
+		lSize, err := layer.Size()
+		if err != nil {
+			return 0, fmt.Errorf("reading layer size of image '%s': %w", o.imageRef, err)
+		}
+		size += lSize
+	}
+
+	return size, nil
+}
+
+// image resolves the image reference of this unpacker, retrying a few times on failure
+func (o OCI) image(ctx context.Context) (containerregistry.Image, error) {
+	platform, err := containerregistry.ParsePlatform(o.platformRef)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []name.Option{}
+	if !o.verify {
+		opts = append(opts, name.Insecure)
+	}
+
+	var refs []name.Reference
+	if o.local {
+		ref, err := name.ParseReference(o.imageRef, opts...)
+		if err != nil {
+			return nil, err
+		}
+		refs = []name.Reference{ref}
+	} else {
+		refs, err = o.registry.References(o.imageRef, opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var img containerregistry.Image
+
+	err = backoff.Retry(func() error {
+		for _, ref := range refs {
+			img, err = fetchImage(ctx, ref, *platform, o.local, o.registry)
+			if err == nil {
+				return nil
+			}
+			o.s.Logger().Debug("Failed fetching image '%s': %v", ref.Name(), err)
+		}
+		return err
+	}, backoff.WithMaxRetries(backoff.NewConstantBackOff(3*time.Second), 3))
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+func fetchImage(ctx context.Context, ref name.Reference, platform containerregistry.Platform, local bool, reg *registry.Config) (containerregistry.Image, error) {
 	if local {
 		return daemon.Image(ref,
 			daemon.WithContext(ctx),
@@ -212,9 +580,9 @@ func fetchImage(ctx context.Context, ref name.Reference, platform containerregis
 	}
 
 	return remote.Image(ref,
-		remote.WithTransport(http.DefaultTransport),
+		remote.WithTransport(reg.Transport(ref.Context().RegistryStr())),
 		remote.WithPlatform(platform),
-		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		remote.WithAuthFromKeychain(reg.Keychain()),
 		remote.WithContext(ctx),
 	)
 }
@@ -245,10 +613,25 @@ func (o OCI) onMountedContainerdImage(ctx context.Context, callback func(rootfs
 		return "", fmt.Errorf("only unpacked images can be mounted")
 	}
 
+	ctx, img, err := o.containerdImage(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	err = o.ctrd.RunOnMountedROSnapshot(ctx, img, callback)
+	if err != nil {
+		return "", err
+	}
+	return img.Digest, nil
+}
+
+// containerdImage finds the image reference in containerd, it returns the context within the containerd
+// namespace of the unpacker to further operate on the image
+func (o *OCI) containerdImage(ctx context.Context) (context.Context, containerd.ImgMeta, error) {
 	if o.ctrd == nil {
 		ctrd, err := containerd.NewWrapper(o.s, o.ctrdSock)
 		if err != nil {
-			return "", err
+			return ctx, containerd.ImgMeta{}, err
 		}
 		o.ctrd = ctrd
 	}
@@ -256,12 +639,7 @@ func (o OCI) onMountedContainerdImage(ctx context.Context, callback func(rootfs
 
 	img, err := o.ctrd.FindUnpackedImage(ctx, o.imageRef)
 	if err != nil {
-		return "", err
+		return ctx, containerd.ImgMeta{}, err
 	}
-
-	err = o.ctrd.RunOnMountedROSnapshot(ctx, img, callback)
-	if err != nil {
-		return "", err
-	}
-	return img.Digest, nil
+	return ctx, img, nil
 }
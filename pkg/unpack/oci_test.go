@@ -20,13 +20,25 @@ package unpack_test
 import (
 	"context"
 	"fmt"
+	"io"
+	stdlog "log"
+	"net/http/httptest"
 	"os"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
 	ctrdmock "github.com/suse/elemental/v3/pkg/containerd/mock"
 	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/registry"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
 	"github.com/suse/elemental/v3/pkg/sys/runner"
@@ -120,6 +132,192 @@ var _ = Describe("OCIUnpacker", Label("oci", "rootlesskit"), func() {
 	})
 })
 
+var _ = Describe("OCIUnpacker", Label("oci", "registry"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var srv *httptest.Server
+	var imageRef string
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+
+		srv = httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(stdlog.New(io.Discard, "", 0))))
+		imageRef = strings.TrimPrefix(srv.URL, "http://") + "/test/layered:latest"
+
+		img := empty.Image
+		for _, files := range []map[string][]byte{
+			{"etc/os-release": []byte("VERSION_ID=1")},
+			{"usr/bin/tool": []byte("tool")},
+			{"etc/os-release": []byte("VERSION_ID=2")},
+		} {
+			layer, err := crane.Layer(files)
+			Expect(err).NotTo(HaveOccurred())
+			img, err = mutate.AppendLayers(img, layer)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		ref, err := name.ParseReference(imageRef, name.Insecure)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remote.Write(ref, img)).To(Succeed())
+	})
+	AfterEach(func() {
+		srv.Close()
+		cleanup()
+	})
+	It("Unpacks a multi-layer image downloading layers in parallel", func() {
+		unpacker := unpack.NewOCIUnpacker(
+			s, imageRef, unpack.WithLocalOCI(false),
+			unpack.WithVerifyOCI(false), unpack.WithConcurrencyOCI(2),
+		)
+		Expect(vfs.MkdirAll(tfs, "/target/root", vfs.DirPerm)).To(Succeed())
+		digest, err := unpacker.Unpack(context.Background(), "/target/root")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(ContainSubstring("sha256:"))
+
+		// Layers are applied in order
+		data, err := tfs.ReadFile("/target/root/etc/os-release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("VERSION_ID=2"))
+		exists, _ := vfs.Exists(tfs, "/target/root/usr/bin/tool")
+		Expect(exists).To(BeTrue())
+		exists, _ = vfs.Exists(tfs, "/target/root.layers")
+		Expect(exists).To(BeFalse())
+	})
+	It("Returns the digest of the image it unpacks", func() {
+		unpacker := unpack.NewOCIUnpacker(s, imageRef, unpack.WithLocalOCI(false), unpack.WithVerifyOCI(false))
+		digest, err := unpacker.Digest(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(vfs.MkdirAll(tfs, "/target/root", vfs.DirPerm)).To(Succeed())
+		unpacked, err := unpacker.Unpack(context.Background(), "/target/root")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal(unpacked))
+	})
+	It("Unpacks an image from a registry mirror", func() {
+		mirror := strings.TrimPrefix(srv.URL, "http://")
+		unpacker := unpack.NewOCIUnpacker(
+			s, "registry.invalid./test/layered:latest", unpack.WithLocalOCI(false),
+			unpack.WithRegistryConfigOCI(&registry.Config{
+				Mirrors: map[string][]string{"registry.invalid.": {mirror}},
+			}),
+		)
+		Expect(vfs.MkdirAll(tfs, "/target/root", vfs.DirPerm)).To(Succeed())
+		digest, err := unpacker.Unpack(context.Background(), "/target/root")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(ContainSubstring("sha256:"))
+		exists, _ := vfs.Exists(tfs, "/target/root/usr/bin/tool")
+		Expect(exists).To(BeTrue())
+	})
+	It("Applies only the new layers on top of a tree unpacked from a base image", func() {
+		pushImage := func(ref string, layers ...map[string][]byte) {
+			img := empty.Image
+			for _, files := range layers {
+				layer, err := crane.Layer(files)
+				Expect(err).NotTo(HaveOccurred())
+				img, err = mutate.AppendLayers(img, layer)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			r, err := name.ParseReference(ref, name.Insecure)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote.Write(r, img)).To(Succeed())
+		}
+		registryHost := strings.TrimPrefix(srv.URL, "http://")
+		base := []map[string][]byte{{"etc/os-release": []byte("VERSION_ID=1")}, {"usr/bin/tool": []byte("tool")}}
+		pushImage(registryHost+"/test/base:latest", base...)
+		pushImage(registryHost+"/test/delta:latest", append(base,
+			map[string][]byte{"etc/os-release": []byte("VERSION_ID=2")},
+			map[string][]byte{"usr/bin/.wh.tool": {}, ".wh.protected": {}},
+		)...)
+
+		Expect(vfs.MkdirAll(tfs, "/target/root", vfs.DirPerm)).To(Succeed())
+		unpacker := unpack.NewOCIUnpacker(s, registryHost+"/test/base:latest", unpack.WithLocalOCI(false), unpack.WithDeltaOCI(true))
+		_, err := unpacker.SynchedUnpack(context.Background(), "/target/root", []string{}, []string{"/protected"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vfs.Exists(tfs, "/target/root/usr/bin/tool")).To(BeTrue())
+		Expect(vfs.Exists(tfs, "/target/root/usr/lib/elemental/image-layers.yaml")).To(BeTrue())
+
+		Expect(vfs.MkdirAll(tfs, "/target/root/protected", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile("/target/root/local", []byte("local"), vfs.FilePerm)).To(Succeed())
+
+		unpacker = unpack.NewOCIUnpacker(s, registryHost+"/test/delta:latest", unpack.WithLocalOCI(false), unpack.WithDeltaOCI(true))
+		digest, err := unpacker.SynchedUnpack(context.Background(), "/target/root", []string{}, []string{"/protected"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(ContainSubstring("sha256:"))
+
+		data, err := tfs.ReadFile("/target/root/etc/os-release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("VERSION_ID=2"))
+		Expect(vfs.Exists(tfs, "/target/root/usr/bin/tool")).To(BeFalse())
+		// Protected paths are kept and the tree is not synchronized from scratch
+		Expect(vfs.Exists(tfs, "/target/root/protected")).To(BeTrue())
+		Expect(vfs.Exists(tfs, "/target/root/local")).To(BeTrue())
+
+		data, err = tfs.ReadFile("/target/root/usr/lib/elemental/image-layers.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.Count(string(data), "sha256:")).To(Equal(5))
+	})
+	It("Keeps the local changes of the destination tree on delta unpacks, unlike full synched unpacks", func() {
+		base := []map[string][]byte{{"etc/os-release": []byte("VERSION_ID=1")}, {"usr/lib/lib.so": []byte("lib")}}
+		for ref, layers := range map[string][]map[string][]byte{
+			"test/base:latest":  base,
+			"test/delta:latest": append(base, map[string][]byte{"usr/bin/tool": []byte("tool")}),
+		} {
+			img := empty.Image
+			for _, files := range layers {
+				layer, err := crane.Layer(files)
+				Expect(err).NotTo(HaveOccurred())
+				img, err = mutate.AppendLayers(img, layer)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			r, err := name.ParseReference(strings.TrimPrefix(srv.URL, "http://")+"/"+ref, name.Insecure)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote.Write(r, img)).To(Succeed())
+		}
+		registryHost := strings.TrimPrefix(srv.URL, "http://")
+
+		Expect(vfs.MkdirAll(tfs, "/target/root", vfs.DirPerm)).To(Succeed())
+		unpacker := unpack.NewOCIUnpacker(s, registryHost+"/test/base:latest", unpack.WithLocalOCI(false), unpack.WithDeltaOCI(true))
+		_, err := unpacker.SynchedUnpack(context.Background(), "/target/root", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(tfs.Remove("/target/root/usr/lib/lib.so")).To(Succeed())
+		Expect(tfs.WriteFile("/target/root/etc/os-release", []byte("VERSION_ID=local"), vfs.FilePerm)).To(Succeed())
+
+		unpacker = unpack.NewOCIUnpacker(s, registryHost+"/test/delta:latest", unpack.WithLocalOCI(false), unpack.WithDeltaOCI(true))
+		_, err = unpacker.SynchedUnpack(context.Background(), "/target/root", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vfs.Exists(tfs, "/target/root/usr/bin/tool")).To(BeTrue())
+		Expect(vfs.Exists(tfs, "/target/root/usr/lib/lib.so")).To(BeFalse())
+		data, err := tfs.ReadFile("/target/root/etc/os-release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("VERSION_ID=local"))
+
+		unpacker = unpack.NewOCIUnpacker(s, registryHost+"/test/delta:latest", unpack.WithLocalOCI(false))
+		_, err = unpacker.SynchedUnpack(context.Background(), "/target/root", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vfs.Exists(tfs, "/target/root/usr/lib/lib.so")).To(BeTrue())
+		data, err = tfs.ReadFile("/target/root/etc/os-release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("VERSION_ID=1"))
+	})
+	It("Fails to unpack layers in parallel if the context is cancelled", func() {
+		unpacker := unpack.NewOCIUnpacker(
+			s, imageRef, unpack.WithLocalOCI(false),
+			unpack.WithVerifyOCI(false), unpack.WithConcurrencyOCI(2),
+		)
+		Expect(vfs.MkdirAll(tfs, "/target/root", vfs.DirPerm)).To(Succeed())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := unpacker.Unpack(ctx, "/target/root")
+		Expect(err).To(HaveOccurred())
+		exists, _ := vfs.Exists(tfs, "/target/root.layers")
+		Expect(exists).To(BeFalse())
+	})
+})
+
 var _ = Describe("OCIUnpacker", Label("oci", "containerd"), func() {
 	var tfs vfs.FS
 	var s *sys.System
@@ -156,6 +354,14 @@ var _ = Describe("OCIUnpacker", Label("oci", "containerd"), func() {
 		Expect(exists).To(BeTrue())
 		Expect(digest).To(Equal("imageID"))
 	})
+	It("Returns the digest of a local image from containerd", func() {
+		ctrd.Img.Digest = "imageID"
+		unpacker := unpack.NewOCIUnpacker(s, alpineImageRef, unpack.WithContainerd(ctrd), unpack.WithLocalOCI(true))
+
+		digest, err := unpacker.Digest(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal("imageID"))
+	})
 	It("Fails to find an image in containerd", func() {
 		ctrd.EFind = fmt.Errorf("image not found")
 		unpacker := unpack.NewOCIUnpacker(s, alpineImageRef, unpack.WithContainerd(ctrd), unpack.WithLocalOCI(true))
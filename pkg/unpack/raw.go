@@ -30,6 +30,7 @@ type umountFunc func() error
 type Raw struct {
 	s          *sys.System
 	path       string
+	fsType     string
 	rsyncFlags []string
 }
 
@@ -41,8 +42,15 @@ func WithRsyncFlagsRaw(flags ...string) RawOpt {
 	}
 }
 
+// WithFSTypeRaw sets the filesystem type used to mount the image, defaults to auto detection
+func WithFSTypeRaw(fsType string) RawOpt {
+	return func(r *Raw) {
+		r.fsType = fsType
+	}
+}
+
 func NewRawUnpacker(s *sys.System, path string, opts ...RawOpt) *Raw {
-	r := &Raw{s: s, path: path}
+	r := &Raw{s: s, path: path, fsType: "auto"}
 	for _, o := range opts {
 		o(r)
 	}
@@ -92,7 +100,7 @@ func (r Raw) mountImage() (string, umountFunc, error) {
 	if err != nil {
 		return "", nil, fmt.Errorf("creating a temporary directory to unpack image: %w", err)
 	}
-	err = r.s.Mounter().Mount(r.path, dir, "auto", []string{"ro"})
+	err = r.s.Mounter().Mount(r.path, dir, r.fsType, []string{"ro"})
 	if err != nil {
 		return "", nil, fmt.Errorf("mounting raw image %q: %w", r.path, err)
 	}
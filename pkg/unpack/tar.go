@@ -20,14 +20,19 @@ package unpack
 import (
 	"archive/tar"
 	"context"
+	"fmt"
+	"net/url"
 	"path/filepath"
 	"strings"
 
 	"github.com/suse/elemental/v3/pkg/archive"
+	"github.com/suse/elemental/v3/pkg/http"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
+const downloadDirSuffix = ".download"
+
 type Tar struct {
 	s          *sys.System
 	tarball    string
@@ -50,11 +55,54 @@ func NewTarUnpacker(s *sys.System, tarball string, opts ...TarOpt) *Tar {
 	return t
 }
 
-func (t Tar) Unpack(ctx context.Context, destination string, excludes ...string) (string, error) {
-	err := archive.ExtractTarball(ctx, t.s, t.tarball, destination, excludesFilter(destination, excludes...))
+// Unpack extracts the tarball to the given destination. Remote tarballs referenced by an HTTP(S) URL are
+// downloaded to a destination sibling directory first, a 'sha256=<checksum>' URL fragment verifies them.
+func (t Tar) Unpack(ctx context.Context, destination string, excludes ...string) (digest string, err error) {
+	tarball := t.tarball
+	if isRemoteTarball(tarball) {
+		downloadDir := filepath.Clean(destination) + downloadDirSuffix
+		err = vfs.MkdirAll(t.s.FS(), downloadDir, vfs.DirPerm)
+		if err != nil {
+			return "", err
+		}
+		defer func() {
+			e := vfs.ForceRemoveAll(t.s.FS(), downloadDir)
+			if err == nil && e != nil {
+				err = e
+			}
+		}()
+
+		tarball, err = t.download(ctx, downloadDir)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = archive.ExtractTarball(ctx, t.s, tarball, destination, excludesFilter(destination, excludes...))
 	return "", err
 }
 
+// download fetches the remote tarball to the given directory and returns the downloaded file path
+func (t Tar) download(ctx context.Context, dir string) (string, error) {
+	u, err := url.Parse(t.tarball)
+	if err != nil {
+		return "", fmt.Errorf("parsing tarball URL: %w", err)
+	}
+
+	path := filepath.Join(dir, filepath.Base(u.Path))
+	u.Fragment = ""
+	t.s.Logger().Info("Downloading tarball '%s'", u.String())
+	err = http.Fetch(ctx, t.s.FS(), t.tarball, path)
+	if err != nil {
+		return "", fmt.Errorf("downloading tarball: %w", err)
+	}
+	return path, nil
+}
+
+func isRemoteTarball(tarball string) bool {
+	return strings.HasPrefix(tarball, "http://") || strings.HasPrefix(tarball, "https://")
+}
+
 // SynchedUnpack for tarball files will extract tar contents to a destination sibling directory first and
 // after that it will sync it to the destination directory. Ideally the destination path should
 // not be mountpoint to a different filesystem of the sibling directories in order to benefit of
@@ -85,7 +133,7 @@ func (t Tar) SynchedUnpack(ctx context.Context, destination string, excludes []s
 
 // excludesFilter returns a filter to exclude given path in a tarball extraction. Given paths
 // are assumed to be always tied to tarball root
-func excludesFilter(root string, excludes ...string) func(h *tar.Header) (bool, error) {
+func excludesFilter(root string, excludes ...string) archive.Filter {
 	rootedExcl := make([]string, len(excludes))
 	for i, exclude := range excludes {
 		rootedExcl[i] = filepath.Clean(filepath.Join(root, exclude))
@@ -103,3 +151,28 @@ func excludesFilter(root string, excludes ...string) func(h *tar.Header) (bool,
 		return true, nil
 	}
 }
+
+// deltaFilter returns a filter to apply layers on top of an existing tree. On top of excluding the given
+// paths it ignores the whiteouts deleting any of the protected paths or their parent directories.
+func deltaFilter(root string, excludes []string, protected []string) archive.Filter {
+	const whiteoutPrefix = ".wh."
+	const whiteoutOpaque = ".wh..opq"
+
+	filter := excludesFilter(root, excludes...)
+	return func(h *tar.Header) (bool, error) {
+		dir, base := filepath.Split(filepath.Join("/", h.Name))
+		if target, ok := strings.CutPrefix(base, whiteoutPrefix); ok {
+			removed := filepath.Join(dir, target)
+			if target == whiteoutOpaque {
+				removed = filepath.Clean(dir)
+			}
+			for _, p := range protected {
+				p = filepath.Clean(filepath.Join("/", p))
+				if removed == "/" || p == removed || strings.HasPrefix(p, removed+"/") {
+					return false, nil
+				}
+			}
+		}
+		return filter(h)
+	}
+}
@@ -19,6 +19,10 @@ package unpack_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
 	"os"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -35,10 +39,10 @@ var _ = Describe("TarUnpacker", Label("tar"), func() {
 	var tfs vfs.FS
 	var unpacker *unpack.Tar
 	var s *sys.System
+	var gzData []byte
 
 	BeforeEach(func() {
 		var err error
-		var gzData []byte
 
 		gzData, err = os.ReadFile("../../tests/testdata/test.tar.gz")
 		Expect(err).NotTo(HaveOccurred())
@@ -113,4 +117,32 @@ var _ = Describe("TarUnpacker", Label("tar"), func() {
 		ok, _ = vfs.Exists(tfs, "/root/etc/elemental")
 		Expect(ok).To(BeFalse())
 	})
+
+	It("downloads and unpacks a remote tarball verifying its checksum", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write(gzData)
+		}))
+		defer srv.Close()
+
+		sum := sha256.Sum256(gzData)
+		unpacker = unpack.NewTarUnpacker(s, srv.URL+"/os.tar.gz#sha256="+hex.EncodeToString(sum[:]))
+		_, err := unpacker.Unpack(context.Background(), "/root")
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err := tfs.ReadFile("/root/etc/os-release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).NotTo(Equal("test"))
+
+		ok, _ := vfs.Exists(tfs, "/root.download")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("fails to unpack a remote tarball with an unsupported checksum", func() {
+		unpacker = unpack.NewTarUnpacker(s, "https://example.com/os.tar.gz#md5=abc")
+		_, err := unpacker.Unpack(context.Background(), "/root")
+		Expect(err).To(MatchError(ContainSubstring("only sha256 is supported")))
+
+		ok, _ := vfs.Exists(tfs, "/root.download")
+		Expect(ok).To(BeFalse())
+	})
 })
@@ -20,8 +20,10 @@ package unpack
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/registry"
 	"github.com/suse/elemental/v3/pkg/sys"
 )
 
@@ -42,6 +44,7 @@ type options struct {
 	dirOpts []DirectoryOpt
 	tarOpts []TarOpt
 	rawOpts []RawOpt
+	csOpts  []ContainersStorageOpt
 }
 
 type Opt func(deployment.ImageSrcType, *options)
@@ -83,16 +86,53 @@ func WithRsyncFlags(flags ...string) Opt {
 			o.dirOpts = append(o.dirOpts, WithRsyncFlagsDir(flags...))
 		case deployment.OCI:
 			o.ociOpts = append(o.ociOpts, WithRsyncFlagsOCI(flags...))
-		case deployment.Raw:
+		case deployment.Raw, deployment.Squash:
 			o.rawOpts = append(o.rawOpts, WithRsyncFlagsRaw(flags...))
 		case deployment.Tar:
 			o.tarOpts = append(o.tarOpts, WithRsyncFlagsTar(flags...))
+		case deployment.ContainersStorage:
+			o.csOpts = append(o.csOpts, WithRsyncFlagsContainersStorage(flags...))
+		default:
+		}
+	}
+}
+
+// WithConcurrency sets the number of OCI image layers downloaded in parallel
+func WithConcurrency(n int) Opt {
+	return func(srcType deployment.ImageSrcType, o *options) {
+		switch srcType {
+		case deployment.OCI:
+			o.ociOpts = append(o.ociOpts, WithConcurrencyOCI(n))
+		default:
+		}
+	}
+}
+
+// WithDelta makes synched unpacks of OCI images only apply the layers missing in the destination tree,
+// provided it was unpacked from an image sharing the same base layers.
+func WithDelta(delta bool) Opt {
+	return func(srcType deployment.ImageSrcType, o *options) {
+		switch srcType {
+		case deployment.OCI:
+			o.ociOpts = append(o.ociOpts, WithDeltaOCI(delta))
+		default:
+		}
+	}
+}
+
+// WithRegistryConfig sets the credentials, mirrors and insecure registries used to fetch OCI images
+func WithRegistryConfig(c *registry.Config) Opt {
+	return func(srcType deployment.ImageSrcType, o *options) {
+		switch srcType {
+		case deployment.OCI:
+			o.ociOpts = append(o.ociOpts, WithRegistryConfigOCI(c))
 		default:
 		}
 	}
 }
 
 func NewUnpacker(s *sys.System, src *deployment.ImageSource, opts ...Opt) (Interface, error) {
+	s = s.WithComponent("unpack")
 	o := &options{}
 	switch {
 	case src.IsEmpty():
@@ -117,6 +157,29 @@ func NewUnpacker(s *sys.System, src *deployment.ImageSource, opts ...Opt) (Inter
 			opt(deployment.Tar, o)
 		}
 		return NewTarUnpacker(s, src.URI(), o.tarOpts...), nil
+	case src.IsSquash():
+		// squashfs images are loop mounted as any other raw filesystem image
+		for _, opt := range opts {
+			opt(deployment.Squash, o)
+		}
+		o.rawOpts = append(o.rawOpts, WithFSTypeRaw("squashfs"))
+		return NewRawUnpacker(s, src.URI(), o.rawOpts...), nil
+	case src.IsContainersStorage():
+		for _, opt := range opts {
+			opt(deployment.ContainersStorage, o)
+		}
+		return NewContainersStorageUnpacker(s, src.URI(), o.csOpts...), nil
+	case src.IsContainerd():
+		// containerd images are unpacked by the OCI unpacker from the mounted image snapshot
+		for _, opt := range opts {
+			opt(deployment.OCI, o)
+		}
+		sock := os.Getenv(CtrdSockEnv)
+		if sock == "" {
+			sock = DefaultCtrdSock
+		}
+		o.ociOpts = append(o.ociOpts, WithContainerdSockOCI(sock))
+		return NewOCIUnpacker(s, src.URI(), o.ociOpts...), nil
 	default:
 		return nil, fmt.Errorf("unsupported type of image source")
 	}
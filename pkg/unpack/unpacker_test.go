@@ -89,6 +89,40 @@ var _ = Describe("DirectoryUnpacker", Label("directory"), func() {
 		_, ok := unpacker.(*unpack.Tar)
 		Expect(ok).To(BeTrue())
 	})
+	It("creates a raw unpacker for squashfs images", func() {
+		unpacker, err = unpack.NewUnpacker(s, deployment.NewSquashSrc("/some/image.squashfs"))
+		Expect(err).NotTo(HaveOccurred())
+		_, ok := unpacker.(*unpack.Raw)
+		Expect(ok).To(BeTrue())
+	})
+	It("creates a containerd unpacker", func() {
+		unpacker, err = unpack.NewUnpacker(s, deployment.NewContainerdSrc("domain.org/some/image:tag"))
+		Expect(err).NotTo(HaveOccurred())
+		_, ok := unpacker.(*unpack.OCI)
+		Expect(ok).To(BeTrue())
+	})
+	It("creates a containers-storage unpacker and syncs the mounted image", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "podman" && slices.Contains(args, "inspect") {
+				return []byte("abcdef\n"), nil
+			}
+			if cmd == "podman" && slices.Contains(args, "mount") {
+				return []byte("/some/root\n"), nil
+			}
+			return []byte{}, nil
+		}
+		unpacker, err = unpack.NewUnpacker(s, deployment.NewContainersStorageSrc("domain.org/some/image:tag"))
+		Expect(err).NotTo(HaveOccurred())
+		_, ok := unpacker.(*unpack.ContainersStorage)
+		Expect(ok).To(BeTrue())
+		digest, err := unpacker.Unpack(context.Background(), "/target/dir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal("sha256:abcdef"))
+		Expect(runner.IncludesCmds([][]string{
+			{"podman", "image", "mount", "domain.org/some/image:tag"},
+			{"podman", "image", "unmount", "domain.org/some/image:tag"},
+		})).To(Succeed())
+	})
 	It("fails with an empty source", func() {
 		unpacker, err = unpack.NewUnpacker(s, deployment.NewEmptySrc())
 		Expect(err).To(HaveOccurred())
@@ -0,0 +1,229 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/chroot"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// Stage is a point of the upgrade transaction at which hooks are executed
+type Stage string
+
+const (
+	// StageBeforeSync runs once the transaction is started, before the OS image is synced
+	StageBeforeSync Stage = "before-sync"
+	// StageAfterMerge runs once the RW volumes are merged and the fstab is updated
+	StageAfterMerge Stage = "after-merge"
+	// StageBeforeCommit runs once the snapshot content is complete, before the bootloader is installed
+	StageBeforeCommit Stage = "before-commit"
+	// StageAfterCommit runs once the transaction is committed
+	StageAfterCommit Stage = "after-commit"
+)
+
+// Stages lists all the hook stages in execution order
+var Stages = []Stage{StageBeforeSync, StageAfterMerge, StageBeforeCommit, StageAfterCommit}
+
+const (
+	// DelayExitCode is the exit code of a script hook asking to be executed again later
+	DelayExitCode = 75
+	// ScriptHookDelay is the time waited before executing again a script hook which asked for a delay
+	ScriptHookDelay = 10 * time.Second
+	// MaxHookDelays is the number of times a hook can delay the transaction before it is aborted
+	MaxHookDelays = 30
+)
+
+// Hook is executed at the transaction stages it is registered for. Returning an error aborts the
+// transaction, unless it is a DelayError, in which case the hook is executed again after the delay.
+type Hook interface {
+	Name() string
+	Run(ctx context.Context, stage Stage, root string) error
+}
+
+// DelayError is returned by hooks which require the transaction to wait before proceeding
+type DelayError struct {
+	Delay  time.Duration
+	Reason string
+}
+
+func (e *DelayError) Error() string {
+	return fmt.Sprintf("delayed for %s: %s", e.Delay, e.Reason)
+}
+
+type hookFunc struct {
+	name string
+	fn   func(ctx context.Context, stage Stage, root string) error
+}
+
+// NewHookFunc returns a Hook running the given function, allowing Go code to extend the transaction
+func NewHookFunc(name string, fn func(ctx context.Context, stage Stage, root string) error) Hook {
+	return hookFunc{name: name, fn: fn}
+}
+
+func (h hookFunc) Name() string {
+	return h.name
+}
+
+func (h hookFunc) Run(ctx context.Context, stage Stage, root string) error {
+	return h.fn(ctx, stage, root)
+}
+
+// ScriptHook executes a script on the host. The stage and the transaction root are passed over the
+// ELEMENTAL_HOOK_STAGE and ELEMENTAL_TRANSACTION_ROOT environment variables and exiting with
+// DelayExitCode delays the transaction by ScriptHookDelay.
+type ScriptHook struct {
+	s    *sys.System
+	path string
+}
+
+func NewScriptHook(s *sys.System, path string) *ScriptHook {
+	return &ScriptHook{s: s, path: path}
+}
+
+func (h *ScriptHook) Name() string {
+	return filepath.Base(h.path)
+}
+
+func (h *ScriptHook) Run(_ context.Context, stage Stage, root string) error {
+	env := []string{
+		fmt.Sprintf("ELEMENTAL_HOOK_STAGE=%s", stage),
+		fmt.Sprintf("ELEMENTAL_TRANSACTION_ROOT=%s", root),
+	}
+	out, err := h.s.Runner().RunEnv(h.path, env)
+	h.s.Logger().Debug("Hook '%s' output:\n%s", h.path, out)
+
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == DelayExitCode {
+		return &DelayError{Delay: ScriptHookDelay, Reason: strings.TrimSpace(string(out))}
+	}
+	return err
+}
+
+// LoadScriptHooks returns the executable files found in the '<stage>.d' subdirectories of the given
+// directory as script hooks, sorted by name within each stage
+func LoadScriptHooks(s *sys.System, dir string) (map[Stage][]Hook, error) {
+	hooks := map[Stage][]Hook{}
+	for _, stage := range Stages {
+		scripts, err := listScripts(s, filepath.Join(dir, string(stage)+".d"))
+		if err != nil {
+			return nil, err
+		}
+		for _, script := range scripts {
+			hooks[stage] = append(hooks[stage], NewScriptHook(s, script))
+		}
+	}
+	return hooks, nil
+}
+
+// listScripts returns the paths of the executable files of the given directory sorted by name, a missing
+// directory has no scripts
+func listScripts(s *sys.System, dir string) ([]string, error) {
+	if ok, _ := vfs.Exists(s.FS(), dir); !ok {
+		return nil, nil
+	}
+
+	entries, err := s.FS().ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading hooks directory '%s': %w", dir, err)
+	}
+	scripts := []string{}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("inspecting hook '%s': %w", entry.Name(), err)
+		}
+		if !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			s.Logger().Debug("Ignoring non executable hook '%s'", entry.Name())
+			continue
+		}
+		scripts = append(scripts, filepath.Join(dir, entry.Name()))
+	}
+	return scripts, nil
+}
+
+// configScriptHook runs the deployment configuration script chrooted into the transaction root
+type configScriptHook struct {
+	s      *sys.System
+	script string
+}
+
+func (h configScriptHook) Name() string {
+	return "configuration script"
+}
+
+func (h configScriptHook) Run(ctx context.Context, _ Stage, root string) error {
+	callback := func() error {
+		var stdOut, stdErr *string
+		stdOut = new(string)
+		stdErr = new(string)
+		defer func() {
+			logOutput(h.s, *stdOut, *stdErr)
+		}()
+		return h.s.Runner().RunContextParseOutput(ctx, stdHandler(stdOut), stdHandler(stdErr), configFile)
+	}
+	binds := map[string]string{h.script: configFile}
+	return chroot.ChrootedCallback(h.s, root, binds, callback)
+}
+
+// runHooks executes the given hooks in order, waiting for the requested delays
+func (u Upgrader) runHooks(stage Stage, root string, hooks []Hook) error {
+	for _, h := range hooks {
+		for delays := 0; ; delays++ {
+			u.s.Logger().Info("Running %s hook '%s'", stage, h.Name())
+			err := h.Run(u.ctx, stage, root)
+
+			var delay *DelayError
+			if !errors.As(err, &delay) {
+				if err != nil {
+					return fmt.Errorf("executing %s hook '%s': %w", stage, h.Name(), err)
+				}
+				break
+			}
+
+			if delays >= MaxHookDelays {
+				return fmt.Errorf("executing %s hook '%s': delayed more than %d times", stage, h.Name(), MaxHookDelays)
+			}
+			u.s.Logger().Info("Hook '%s' %s", h.Name(), delay.Error())
+			select {
+			case <-u.ctx.Done():
+				return fmt.Errorf("executing %s hook '%s': %w", stage, h.Name(), u.ctx.Err())
+			case <-time.After(delay.Delay):
+			}
+		}
+	}
+	return nil
+}
+
+// stageHooks returns the hooks registered for the given stage, the deployment configuration script
+// runs first on StageBeforeCommit
+func (u Upgrader) stageHooks(cfgScript string, stage Stage) []Hook {
+	hooks := slices.Clone(u.hooks[stage])
+	if stage == StageBeforeCommit && cfgScript != "" {
+		hooks = slices.Insert(hooks, 0, Hook(configScriptHook{s: u.s, script: cfgScript}))
+	}
+	return hooks
+}
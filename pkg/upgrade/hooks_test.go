@@ -0,0 +1,120 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func (e exitError) ExitCode() int {
+	return e.code
+}
+
+var _ = Describe("Hooks", Label("hooks"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("loads the executable scripts of each stage directory", func() {
+		Expect(vfs.MkdirAll(fs, "/hooks/before-sync.d", vfs.DirPerm)).To(Succeed())
+		Expect(vfs.MkdirAll(fs, "/hooks/after-commit.d/subdir", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/hooks/before-sync.d/20-disk", []byte{}, 0o755)).To(Succeed())
+		Expect(fs.WriteFile("/hooks/before-sync.d/10-health", []byte{}, 0o755)).To(Succeed())
+		Expect(fs.WriteFile("/hooks/before-sync.d/README", []byte{}, 0o644)).To(Succeed())
+		Expect(fs.WriteFile("/hooks/after-commit.d/notify", []byte{}, 0o700)).To(Succeed())
+
+		hooks, err := upgrade.LoadScriptHooks(s, "/hooks")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hooks).To(HaveLen(2))
+		Expect(hooks[upgrade.StageBeforeSync]).To(HaveLen(2))
+		Expect(hooks[upgrade.StageBeforeSync][0].Name()).To(Equal("10-health"))
+		Expect(hooks[upgrade.StageBeforeSync][1].Name()).To(Equal("20-disk"))
+		Expect(hooks[upgrade.StageAfterCommit]).To(HaveLen(1))
+		Expect(hooks[upgrade.StageAfterCommit][0].Name()).To(Equal("notify"))
+	})
+	It("loads no hooks from a missing directory", func() {
+		hooks, err := upgrade.LoadScriptHooks(s, "/missing")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hooks).To(BeEmpty())
+	})
+	It("runs scripts with the stage and transaction root", func() {
+		hook := upgrade.NewScriptHook(s, "/hooks/before-sync.d/10-health")
+		Expect(hook.Run(context.Background(), upgrade.StageBeforeSync, "/snapshot/path")).To(Succeed())
+		Expect(runner.EnvsMatch([][]string{{
+			"/hooks/before-sync.d/10-health",
+			"ELEMENTAL_HOOK_STAGE=before-sync", "ELEMENTAL_TRANSACTION_ROOT=/snapshot/path",
+		}})).To(Succeed())
+	})
+	It("delays the transaction when a script exits with the delay exit code", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			return []byte("waiting for the workload to drain\n"), exitError{code: upgrade.DelayExitCode}
+		}
+		hook := upgrade.NewScriptHook(s, "/hooks/before-sync.d/10-health")
+		err := hook.Run(context.Background(), upgrade.StageBeforeSync, "/snapshot/path")
+
+		var delay *upgrade.DelayError
+		Expect(errors.As(err, &delay)).To(BeTrue())
+		Expect(delay.Delay).To(Equal(upgrade.ScriptHookDelay))
+		Expect(delay.Reason).To(Equal("waiting for the workload to drain"))
+	})
+	It("fails when a script exits with any other error", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			return []byte{}, exitError{code: 1}
+		}
+		hook := upgrade.NewScriptHook(s, "/hooks/before-sync.d/10-health")
+		err := hook.Run(context.Background(), upgrade.StageBeforeSync, "/snapshot/path")
+		Expect(err).To(MatchError("exit status 1"))
+
+		var delay *upgrade.DelayError
+		Expect(errors.As(err, &delay)).To(BeFalse())
+	})
+})
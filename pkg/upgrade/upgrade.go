@@ -24,21 +24,38 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/chroot"
 	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/clevis"
+	"github.com/suse/elemental/v3/pkg/crypttab"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/fips"
 	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/firstboot"
+	"github.com/suse/elemental/v3/pkg/integrity"
+	"github.com/suse/elemental/v3/pkg/kexec"
+	"github.com/suse/elemental/v3/pkg/registry"
+	"github.com/suse/elemental/v3/pkg/repart"
 	"github.com/suse/elemental/v3/pkg/rsync"
 	"github.com/suse/elemental/v3/pkg/selinux"
+	"github.com/suse/elemental/v3/pkg/signature"
+	"github.com/suse/elemental/v3/pkg/swap"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/transaction"
 	"github.com/suse/elemental/v3/pkg/unpack"
+	"github.com/suse/elemental/v3/pkg/watchdog"
 )
 
-const configFile = "/etc/elemental/config.sh"
+const (
+	configFile = "/etc/elemental/config.sh"
+
+	// initrdCompressionConfig is the dracut configuration setting the compression of regenerated initrds
+	initrdCompressionConfig = "/etc/dracut.conf.d/40-elemental-compression.conf"
+)
 
 type Interface interface {
 	Upgrade(*deployment.Deployment) error
@@ -51,8 +68,20 @@ type Upgrader struct {
 	s          *sys.System
 	t          transaction.Interface
 	bm         *firmware.EfiBootManager
+	tpm        *firmware.TPMManager
+	seal       bool
 	b          bootloader.Bootloader
 	unpackOpts []unpack.Opt
+	kexec      bool
+	wdDevice   string
+	wdTimeout  time.Duration
+	syncPolicy transaction.SyncPolicy
+	hooks      map[Stage][]Hook
+	validators []Validator
+	syncImage  bool
+	registry   *registry.Config
+	local      bool
+	skipVerify bool
 }
 
 func WithTransaction(t transaction.Interface) Option {
@@ -67,6 +96,21 @@ func WithBootManager(bm *firmware.EfiBootManager) Option {
 	}
 }
 
+func WithTPMManager(tpm *firmware.TPMManager) Option {
+	return func(u *Upgrader) {
+		u.tpm = tpm
+	}
+}
+
+// WithVolumeSealing seals the keys of the LUKS volumes of the deployment TPM configuration once the
+// expected measurements of the new snapshot are recorded and binds the volumes of the network unlock
+// configuration to their tang servers, used on installation
+func WithVolumeSealing(seal bool) Option {
+	return func(u *Upgrader) {
+		u.seal = seal
+	}
+}
+
 func WithBootloader(b bootloader.Bootloader) Option {
 	return func(u *Upgrader) {
 		u.b = b
@@ -85,10 +129,90 @@ func WithUnpackOpts(opts ...unpack.Opt) Option {
 	}
 }
 
+// WithRegistryConfig sets the credentials, mirrors and insecure registries used to verify the OS image signature
+func WithRegistryConfig(c *registry.Config) Option {
+	return func(u *Upgrader) {
+		u.registry = c
+	}
+}
+
+// WithLocalImage sets whether the OS image is taken from the local container storage. The signature of
+// local images can't be verified, as they are not checked against their registry, so upgrading from a
+// local image of a deployment verifying signatures fails unless WithSkipSignatureVerification is set.
+func WithLocalImage(local bool) Option {
+	return func(u *Upgrader) {
+		u.local = local
+	}
+}
+
+// WithSkipSignatureVerification deploys the OS image without verifying its signature, even if the
+// deployment requires it
+func WithSkipSignatureVerification(skip bool) Option {
+	return func(u *Upgrader) {
+		u.skipVerify = skip
+	}
+}
+
+// WithKexec loads the kernel and initrd of the new snapshot boot entry for kexec, so the
+// next reboot can skip firmware initialization
+func WithKexec(kexec bool) Option {
+	return func(u *Upgrader) {
+		u.kexec = kexec
+	}
+}
+
+// WithWatchdog arms the given watchdog device while switching the bootloader to the new snapshot
+// and configures the new snapshot to boot once with the watchdog armed before falling back to
+// the previous boot entry. The watchdog only covers hangs of the systemd manager during that boot.
+func WithWatchdog(device string, timeout time.Duration) Option {
+	return func(u *Upgrader) {
+		u.wdDevice = device
+		u.wdTimeout = timeout
+	}
+}
+
+// WithSyncPolicy sets the transaction milestones at which the transaction filesystem is flushed to disk,
+// defaults to transaction.DefaultSyncPolicy
+func WithSyncPolicy(p transaction.SyncPolicy) Option {
+	return func(u *Upgrader) {
+		u.syncPolicy = p
+	}
+}
+
+// WithImageSync sets whether the OS image is synced into the new snapshot, defaults to true. Without it
+// the new snapshot keeps the OS content of its parent snapshot and only the overlay tree and the
+// configuration script are applied on top of it.
+func WithImageSync(sync bool) Option {
+	return func(u *Upgrader) {
+		u.syncImage = sync
+	}
+}
+
+// WithHooks registers the given hooks to be executed at the given transaction stage, in order
+func WithHooks(stage Stage, hooks ...Hook) Option {
+	return func(u *Upgrader) {
+		u.hooks[stage] = append(u.hooks[stage], hooks...)
+	}
+}
+
+// WithValidators registers the given validators to check the new snapshot before the transaction is
+// committed, they run after the default validators
+func WithValidators(validators ...Validator) Option {
+	return func(u *Upgrader) {
+		u.validators = append(u.validators, validators...)
+	}
+}
+
 func New(ctx context.Context, s *sys.System, opts ...Option) *Upgrader {
+	s = s.WithComponent("upgrade")
 	up := &Upgrader{
-		s:   s,
-		ctx: ctx,
+		s:          s,
+		ctx:        ctx,
+		tpm:        firmware.NewTPMManager(s),
+		syncPolicy: transaction.DefaultSyncPolicy,
+		hooks:      map[Stage][]Hook{},
+		validators: DefaultValidators(s),
+		syncImage:  true,
 	}
 	for _, o := range opts {
 		o(up)
@@ -125,9 +249,42 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 	}
 	cleanup.PushErrorOnly(func() error { return u.t.Rollback(trans, err) })
 
-	err = uh.SyncImageContent(d.SourceOS, trans, u.unpackOpts...)
+	// The image is pulled by the verified digest, so it can't be replaced after its verification
+	imgSrc := d.SourceOS
+	if u.syncImage && d.SourceOS.VerifySignature != nil {
+		switch {
+		case u.skipVerify:
+			u.s.Logger().Warn("Skipping signature verification of OS image '%s' as requested", d.SourceOS.URI())
+		case u.local || d.SourceOS.IsLocalStore():
+			return fmt.Errorf(
+				"verifying OS image signature: the signature of local image '%s' can't be verified, skip the verification explicitly to deploy it",
+				d.SourceOS.URI(),
+			)
+		default:
+			pinned, err := signature.Verify(u.ctx, u.s, d.SourceOS.URI(), d.SourceOS.VerifySignature, u.registry)
+			if err != nil {
+				return fmt.Errorf("verifying OS image signature: %w", err)
+			}
+			imgSrc = deployment.NewOCISrc(pinned)
+		}
+	}
+
+	err = u.runHooks(StageBeforeSync, trans.Path, u.stageHooks(d.CfgScript, StageBeforeSync))
 	if err != nil {
-		return fmt.Errorf("syncing OS image content: %w", err)
+		return err
+	}
+
+	if u.syncImage {
+		err = uh.SyncImageContent(imgSrc, trans, u.unpackOpts...)
+		if err != nil {
+			return fmt.Errorf("syncing OS image content: %w", err)
+		}
+		d.SourceOS.SetDigest(imgSrc.GetDigest())
+	}
+
+	err = u.syncPolicy.Sync(u.s, transaction.SyncAfterContent, trans.Path)
+	if err != nil {
+		return err
 	}
 
 	err = uh.Merge(trans)
@@ -140,6 +297,26 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		return fmt.Errorf("updating fstab: %w", err)
 	}
 
+	err = u.syncPolicy.Sync(u.s, transaction.SyncAfterFstab, trans.Path)
+	if err != nil {
+		return err
+	}
+
+	err = u.runHooks(StageAfterMerge, trans.Path, u.stageHooks(d.CfgScript, StageAfterMerge))
+	if err != nil {
+		return err
+	}
+
+	err = swap.Configure(u.s, trans.Path, d)
+	if err != nil {
+		return fmt.Errorf("configuring swap: %w", err)
+	}
+
+	err = configureInitrdCompression(u.s, trans.Path, d)
+	if err != nil {
+		return fmt.Errorf("configuring initrd compression: %w", err)
+	}
+
 	if d.IsFipsEnabled() {
 		err = fips.ChrootedEnable(u.ctx, u.s, trans.Path)
 		if err != nil {
@@ -147,6 +324,32 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		}
 	}
 
+	err = crypttab.Configure(u.s, trans.Path, d)
+	if err != nil {
+		return fmt.Errorf("configuring encrypted volumes: %w", err)
+	}
+
+	regenInitrd := d.InitrdCompression() != nil
+	if ih, ok := uh.(transaction.InitrdHelper); ok {
+		err = ih.ConfigureInitrd(trans)
+		if err != nil {
+			return fmt.Errorf("configuring initrd: %w", err)
+		}
+		regenInitrd = true
+	}
+
+	if d.IsNetworkUnlockEnabled() {
+		err = clevis.ConfigureInitrd(u.ctx, u.s, trans.Path, d.Security.NetworkUnlock)
+		if err != nil {
+			return fmt.Errorf("configuring initrd for network unlock: %w", err)
+		}
+	} else if regenInitrd {
+		err = regenerateInitrd(u.ctx, u.s, trans.Path)
+		if err != nil {
+			return fmt.Errorf("regenerating initrd: %w", err)
+		}
+	}
+
 	shared, snapshotted := parsePersistentPaths(d)
 	err = selinux.ChrootedSystemRelabel(u.ctx, u.s, trans.Path, snapshotted, shared)
 	if err != nil {
@@ -158,11 +361,6 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		return fmt.Errorf("writing deployment file: %w", err)
 	}
 
-	err = uh.Lock(trans)
-	if err != nil {
-		return fmt.Errorf("locking transaction '%d': %w", trans.ID, err)
-	}
-
 	if d.OverlayTree != nil && !d.OverlayTree.IsEmpty() {
 		unpacker, err := unpack.NewUnpacker(
 			u.s, d.OverlayTree, unpack.WithRsyncFlags(rsync.OverlayTreeSyncFlags()...),
@@ -176,18 +374,36 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		}
 	}
 
-	if d.CfgScript != "" {
-		err = u.configHook(d.CfgScript, trans.Path)
-		if err != nil {
-			return fmt.Errorf("executing configuration hook: %w", err)
-		}
+	err = firstboot.Configure(u.ctx, u.s, trans.Path, d)
+	if err != nil {
+		return fmt.Errorf("configuring first boot: %w", err)
+	}
+
+	err = u.runHooks(StageBeforeCommit, trans.Path, u.stageHooks(d.CfgScript, StageBeforeCommit))
+	if err != nil {
+		return err
 	}
 
 	cmdline := ""
 	initrdExts := []string{}
+	bootTries := 0
 	if d.BootConfig != nil {
 		cmdline = d.BootConfig.KernelCmdline
 		initrdExts = d.BootConfig.InitrdExtensions
+		bootTries = d.BootConfig.BootTries
+	}
+	if u.wdDevice != "" && bootTries == 0 {
+		// A watchdog reset falls back to the previous snapshot on the next boot
+		bootTries = 1
+	}
+	trans.Trial = bootTries > 0
+
+	resumeCmdline, err := swap.KernelCmdline(u.s, trans.Path, d)
+	if err != nil {
+		return fmt.Errorf("setting resume kernel parameters: %w", err)
+	}
+	if resumeCmdline != "" {
+		cmdline = strings.TrimSpace(fmt.Sprintf("%s %s", resumeCmdline, cmdline))
 	}
 
 	kernelCmdline := strings.TrimSpace(fmt.Sprintf("%s %s %s", d.BaseKernelCmdline(), uh.GenerateKernelCmdline(trans), cmdline))
@@ -196,6 +412,38 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		recKernelCmdline = strings.TrimSpace(fmt.Sprintf("%s %s", d.RecoveryKernelCmdline(), d.Installer.KernelCmdline))
 	}
 
+	if slices.ContainsFunc(d.Disks, func(disk *deployment.Disk) bool { return disk.ExpandPartitions }) {
+		err = repart.EnableExpandOnBoot(u.s, trans.Path)
+		if err != nil {
+			return fmt.Errorf("enabling partitions expansion on boot: %w", err)
+		}
+	}
+	if disk := d.GetSystemDisk(); disk != nil && disk.RepartDefinitions {
+		err = repart.WriteDefinitions(u.s, trans.Path, disk)
+		if err != nil {
+			return fmt.Errorf("writing systemd-repart definitions: %w", err)
+		}
+	}
+
+	var wd *watchdog.Watchdog
+	if u.wdDevice != "" {
+		err = watchdog.EnableOnBoot(u.s, trans.Path, u.wdTimeout, bootloader.BootCompleteUnit)
+		if err != nil {
+			return fmt.Errorf("enabling watchdog on boot: %w", err)
+		}
+
+		wd, err = watchdog.Arm(u.s, u.wdDevice, u.wdTimeout)
+		if err != nil {
+			return fmt.Errorf("arming watchdog: %w", err)
+		}
+		cleanup.Push(wd.Disarm)
+	}
+
+	err = u.syncPolicy.Sync(u.s, transaction.SyncBeforeBootloader, trans.Path)
+	if err != nil {
+		return err
+	}
+
 	espDir := filepath.Join(trans.Path, esp.MountPoint)
 	err = u.b.Install(bootloader.InstallCtx{
 		RootDir:          trans.Path,
@@ -205,16 +453,74 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		KernelCmdline:    kernelCmdline,
 		RecKernelCmdline: recKernelCmdline,
 		InitrdExtensions: initrdExts,
+		BootTries:        bootTries,
 	})
 	if err != nil {
 		return fmt.Errorf("installing bootloader: %w", err)
 	}
 
+	err = wd.Ping()
+	if err != nil {
+		return err
+	}
+
 	if d.Firmware != nil {
 		err = u.bm.CreateBootEntries(d.Firmware.BootEntries)
 		if err != nil {
 			return fmt.Errorf("creating EFI boot entries: %w", err)
 		}
+
+		if d.Firmware.TPM != nil {
+			err = u.measureBoot(d, trans.Path, espDir, trans.ID)
+			if err != nil {
+				return fmt.Errorf("updating TPM2 measured boot: %w", err)
+			}
+		}
+	}
+
+	if u.seal && d.IsNetworkUnlockEnabled() {
+		err = clevis.Bind(u.ctx, u.s, d.Security.NetworkUnlock, d.Security.LUKSKeyFile, luksDevices(d))
+		if err != nil {
+			return fmt.Errorf("binding LUKS volumes to tang servers: %w", err)
+		}
+	}
+
+	err = wd.Ping()
+	if err != nil {
+		return err
+	}
+
+	if u.kexec {
+		err = u.loadKexec(espDir, strconv.Itoa(trans.ID))
+		if err != nil {
+			return fmt.Errorf("loading kexec kernel: %w", err)
+		}
+		cleanup.PushErrorOnly(func() error { return kexec.Unload(u.s) })
+	}
+
+	err = u.runValidators(ValidationTarget{
+		Root: trans.Path, ESPDir: espDir, EntryID: strconv.Itoa(trans.ID), Bootloader: u.b,
+	})
+	if err != nil {
+		return fmt.Errorf("validating transaction: %w", err)
+	}
+
+	// The checksums manifest is generated once the snapshot contents are final
+	if d.Snapshotter != nil && d.Snapshotter.Checksums {
+		err = integrity.Generate(u.ctx, u.s, trans.Path, integrity.ExcludedPaths(d)...)
+		if err != nil {
+			return fmt.Errorf("generating checksums manifest: %w", err)
+		}
+	}
+
+	err = u.syncPolicy.Sync(u.s, transaction.SyncBeforeLock, trans.Path)
+	if err != nil {
+		return err
+	}
+
+	err = uh.Lock(trans)
+	if err != nil {
+		return fmt.Errorf("locking transaction '%d': %w", trans.ID, err)
 	}
 
 	commitCleanup := func() error {
@@ -231,22 +537,83 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		return fmt.Errorf("committing transaction: %w", err)
 	}
 
+	// The transaction is already committed, a failing after-commit hook can't undo it
+	err = u.runHooks(StageAfterCommit, trans.Path, u.stageHooks(d.CfgScript, StageAfterCommit))
+	if err != nil {
+		u.s.Logger().Warn("Upgrade committed, but %s hooks failed: %v", StageAfterCommit, err)
+	}
+	return nil
+}
+
+// measureBoot predicts the TPM2 measurements of the boot entries of the active snapshots and of the
+// new one before the transaction is committed, so sealed keys keep unlocking with the new kernel.
+// On installation the boot of the target disk is predicted and the encrypted volumes are sealed.
+func (u Upgrader) measureBoot(d *deployment.Deployment, root, espDir string, id int) error {
+	cfg := d.Firmware.TPM
+	ids, err := u.t.GetActiveSnapshotIDs()
+	if err != nil {
+		return fmt.Errorf("get active snapshots: %w", err)
+	}
+	if !slices.Contains(ids, id) {
+		ids = append(ids, id)
+	}
+
+	entries := make([]firmware.MeasuredEntry, 0, len(ids))
+	for _, snapshot := range ids {
+		entryID := strconv.Itoa(snapshot)
+		entry, err := u.b.GetBootEntry(espDir, entryID)
+		if err != nil {
+			if snapshot == id {
+				return err
+			}
+			u.s.Logger().Warn("Skipping measurements of snapshot %d: %v", snapshot, err)
+			continue
+		}
+		entries = append(entries, firmware.MeasuredEntry{
+			ID: entryID, Kernel: entry.Kernel, Initrd: entry.Initrd, KernelCmdline: entry.KernelCmdline,
+		})
+	}
+
+	var disk string
+	if u.seal {
+		if efiDisk := d.GetEfiDisk(); efiDisk != nil {
+			disk = efiDisk.Device
+		}
+	}
+	err = u.tpm.PredictMeasurements(root, disk, entries)
+	if err != nil {
+		return err
+	}
+
+	if cfg.PCRLock {
+		err = u.tpm.MakePolicy(root, cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	if u.seal && len(d.GetEncryptedPartitions()) > 0 {
+		return u.tpm.SealVolumes(root, cfg, d.Security.LUKSKeyFile, luksDevices(d))
+	}
 	return nil
 }
 
-func (u Upgrader) configHook(config string, root string) error {
-	u.s.Logger().Info("Running transaction hook")
-	callback := func() error {
-		var stdOut, stdErr *string
-		stdOut = new(string)
-		stdErr = new(string)
-		defer func() {
-			logOutput(u.s, *stdOut, *stdErr)
-		}()
-		return u.s.Runner().RunContextParseOutput(u.ctx, stdHandler(stdOut), stdHandler(stdErr), configFile)
-	}
-	binds := map[string]string{config: configFile}
-	return chroot.ChrootedCallback(u.s, root, binds, callback)
+// luksDevices returns the persistent paths of the LUKS volumes of the encrypted partitions
+func luksDevices(d *deployment.Deployment) []string {
+	var devices []string
+	for _, part := range d.GetEncryptedPartitions() {
+		devices = append(devices, part.LUKSDevice())
+	}
+	return devices
+}
+
+// loadKexec stages the kernel and initrd of the given boot entry to be booted on the next kexec reboot
+func (u Upgrader) loadKexec(espDir, entryID string) error {
+	entry, err := u.b.GetBootEntry(espDir, entryID)
+	if err != nil {
+		return err
+	}
+	return kexec.Load(u.s, entry.Kernel, entry.Initrd, entry.KernelCmdline)
 }
 
 func stdHandler(out *string) func(string) {
@@ -287,3 +654,61 @@ func parsePersistentPaths(d *deployment.Deployment) (shared, snapshotted []strin
 
 	return shared, snapshotted
 }
+
+// configureInitrdCompression writes the dracut configuration compressing the initrd with the compression
+// set in the deployment, so it applies whenever the initrd of the given root is regenerated. The
+// configuration is removed if no compression is set.
+func configureInitrdCompression(s *sys.System, root string, d *deployment.Deployment) error {
+	confFile := filepath.Join(root, initrdCompressionConfig)
+	c := d.InitrdCompression()
+	if c == nil {
+		err := s.FS().RemoveAll(confFile)
+		if err != nil {
+			return fmt.Errorf("removing dracut configuration: %w", err)
+		}
+		return nil
+	}
+
+	var compress string
+	switch c.Algorithm {
+	case deployment.Zstd:
+		compress = "zstd -q -T0"
+		if c.Level > 0 {
+			compress = fmt.Sprintf("zstd -%d -q -T0", c.Level)
+		}
+	case deployment.Xz:
+		// The kernel only supports the crc32 integrity check of xz
+		compress = "xz --check=crc32 --lzma2=dict=1MiB"
+	default:
+		return fmt.Errorf("unsupported compression algorithm '%s'", c.Algorithm)
+	}
+
+	err := vfs.MkdirAll(s.FS(), filepath.Dir(confFile), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating dracut configuration directory: %w", err)
+	}
+	err = s.FS().WriteFile(confFile, []byte(fmt.Sprintf("compress=\"%s\"\n", compress)), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing dracut configuration: %w", err)
+	}
+	return nil
+}
+
+// regenerateInitrd regenerates the initrd of the kernel of the given root, so the initrd shipped by the
+// OS image gets the compression configured by configureInitrdCompression and the dracut modules required
+// by the snapshotter
+func regenerateInitrd(ctx context.Context, s *sys.System, root string) error {
+	kernel, version, err := vfs.FindKernel(s.FS(), root)
+	if err != nil {
+		return fmt.Errorf("finding kernel: %w", err)
+	}
+
+	initrd := filepath.Join(strings.TrimPrefix(filepath.Dir(kernel), root), bootloader.Initrd)
+	callback := func() error {
+		s.Logger().Info("Regenerating initrd of kernel %s", version)
+		stdOut, err := s.Runner().RunContext(ctx, "dracut", "--force", "--kver", version, initrd)
+		s.Logger().Debug("dracut: %s", string(stdOut))
+		return err
+	}
+	return chroot.ChrootedCallback(s, root, nil, callback)
+}
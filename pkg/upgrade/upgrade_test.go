@@ -20,14 +20,19 @@ package upgrade_test
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/clevis"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/integrity"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
@@ -35,6 +40,7 @@ import (
 	"github.com/suse/elemental/v3/pkg/transaction"
 	transmock "github.com/suse/elemental/v3/pkg/transaction/mock"
 	"github.com/suse/elemental/v3/pkg/upgrade"
+	"github.com/suse/elemental/v3/pkg/watchdog"
 )
 
 func TestUpgradeSuite(t *testing.T) {
@@ -42,6 +48,24 @@ func TestUpgradeSuite(t *testing.T) {
 	RunSpecs(t, "Upgrade test suite")
 }
 
+type entryBootloader struct {
+	bootloader.None
+	entryErr error
+}
+
+func (b *entryBootloader) GetBootEntry(espDir, entryID string) (bootloader.BootEntry, error) {
+	if b.entryErr != nil {
+		return bootloader.BootEntry{}, b.entryErr
+	}
+	return bootloader.BootEntry{
+		Kernel:        espDir + "/os/" + entryID + "/vmlinuz",
+		Initrd:        espDir + "/os/" + entryID + "/initrd",
+		KernelCmdline: "root=LABEL=SYSTEM",
+	}, nil
+}
+
+const signedImage = "registry.org/my/os@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
 var _ = Describe("Upgrade", Label("upgrade"), func() {
 	var runner *sysmock.Runner
 	var mounter *sysmock.Mounter
@@ -61,12 +85,16 @@ var _ = Describe("Upgrade", Label("upgrade"), func() {
 		runner = sysmock.NewRunner()
 		mounter = sysmock.NewMounter()
 		fs, cleanup, err = sysmock.TestFS(map[string]any{
-			"/dev/pts/empty":         []byte{},
-			"/proc/empty":            []byte{},
-			"/sys/empty":             []byte{},
-			"/snapshot/path/empty":   []byte{},
-			"/opt/overlaytree/empty": []byte{},
-			"/opt/config.sh":         []byte{},
+			"/dev/pts/empty":                   []byte{},
+			"/proc/empty":                      []byte{},
+			"/sys/empty":                       []byte{},
+			"/snapshot/path/empty":             []byte{},
+			"/snapshot/path/etc/fstab":         []byte{},
+			"/snapshot/path/boot/os/2/vmlinuz": []byte{},
+			"/snapshot/path/boot/os/2/initrd":  []byte{},
+			"/opt/overlaytree/empty":           []byte{},
+			"/opt/config.sh":                   []byte{},
+			"/dev/watchdog":                    []byte{},
 		})
 		Expect(err).ToNot(HaveOccurred())
 		s, err = sys.NewSystem(
@@ -122,6 +150,61 @@ var _ = Describe("Upgrade", Label("upgrade"), func() {
 		Expect(err).To(MatchError("syncing OS image content: failed sync"))
 		Expect(t.RollbackCalled()).To(BeTrue())
 	})
+	It("verifies the OS image signature before syncing it", func() {
+		d.SourceOS = deployment.NewOCISrc(signedImage)
+		d.SourceOS.VerifySignature = &deployment.SignatureVerification{Key: "/etc/elemental/cosign.pub"}
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"cosign", "verify", "--key", "/etc/elemental/cosign.pub", signedImage},
+			{"/etc/elemental/config.sh"},
+		})).To(Succeed())
+		Expect(d.SourceOS.URI()).To(Equal(signedImage))
+		Expect(d.SourceOS.GetDigest()).To(Equal("imagedigest"))
+	})
+	It("fails to verify the signature of local OS images", func() {
+		d.SourceOS = deployment.NewOCISrc("registry.org/my/os:latest")
+		d.SourceOS.VerifySignature = &deployment.SignatureVerification{Key: "/etc/elemental/cosign.pub"}
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithLocalImage(true),
+		)
+		Expect(u.Upgrade(d)).To(MatchError(ContainSubstring("the signature of local image 'registry.org/my/os:latest' can't be verified")))
+		Expect(runner.IncludesCmds([][]string{{"cosign"}})).NotTo(Succeed())
+		Expect(t.RollbackCalled()).To(BeTrue())
+	})
+	It("fails to verify the signature of images of the containers storage", func() {
+		d.SourceOS = deployment.NewContainersStorageSrc(signedImage)
+		d.SourceOS.VerifySignature = &deployment.SignatureVerification{Key: "/etc/elemental/cosign.pub"}
+		Expect(u.Upgrade(d)).To(MatchError(ContainSubstring("can't be verified")))
+		Expect(runner.IncludesCmds([][]string{{"cosign"}})).NotTo(Succeed())
+	})
+	It("skips the signature verification of local OS images if requested", func() {
+		d.SourceOS = deployment.NewOCISrc("registry.org/my/os:latest")
+		d.SourceOS.VerifySignature = &deployment.SignatureVerification{Key: "/etc/elemental/cosign.pub"}
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithLocalImage(true),
+			upgrade.WithSkipSignatureVerification(true),
+		)
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"cosign"}})).NotTo(Succeed())
+		Expect(d.SourceOS.GetDigest()).To(Equal("imagedigest"))
+		Expect(d.SourceOS.VerifySignature).NotTo(BeNil())
+	})
+	It("fails on OS image signature verification", func() {
+		d.SourceOS = deployment.NewOCISrc(signedImage)
+		d.SourceOS.VerifySignature = &deployment.SignatureVerification{Tool: deployment.NotationTool}
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "notation" {
+				return []byte{}, fmt.Errorf("no valid signature")
+			}
+			return []byte{}, nil
+		}
+		err := u.Upgrade(d)
+		Expect(err).To(MatchError(ContainSubstring("verifying OS image signature")))
+		Expect(err).To(MatchError(ContainSubstring("no valid signature")))
+		Expect(t.RollbackCalled()).To(BeTrue())
+	})
 	It("fails on image merge", func() {
 		t.UpgradeHelper.MergeError = fmt.Errorf("failed merge")
 		err := u.Upgrade(d)
@@ -136,6 +219,22 @@ var _ = Describe("Upgrade", Label("upgrade"), func() {
 		Expect(err).To(MatchError("updating fstab: failed fstab update"))
 		Expect(t.RollbackCalled()).To(BeTrue())
 	})
+	It("fails to flush the transaction to disk", func() {
+		trans.Path = "/nonexisting/path"
+		err := u.Upgrade(d)
+		Expect(err).To(MatchError(ContainSubstring("opening '/nonexisting/path'")))
+		Expect(t.RollbackCalled()).To(BeTrue())
+	})
+	It("does not flush the transaction to disk if the sync policy is disabled", func() {
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+			upgrade.WithSyncPolicy(transaction.SyncNone),
+		)
+		trans.Path = "/nonexisting/path"
+		Expect(vfs.MkdirAll(fs, "/nonexisting/path/etc", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/nonexisting/path/etc/fstab", []byte{}, vfs.FilePerm)).To(Succeed())
+		Expect(u.Upgrade(d)).To(Succeed())
+	})
 	It("fails on locking snapshot", func() {
 		t.UpgradeHelper.LockError = fmt.Errorf("failed lock")
 		err := u.Upgrade(d)
@@ -168,7 +267,99 @@ var _ = Describe("Upgrade", Label("upgrade"), func() {
 		}
 		err := u.Upgrade(d)
 		Expect(err).To(HaveOccurred())
-		Expect(err).To(MatchError("executing configuration hook: failed hook"))
+		Expect(err).To(MatchError("executing before-commit hook 'configuration script': failed hook"))
+	})
+	It("runs the registered hooks at each transaction stage", func() {
+		stages := []string{}
+		hook := upgrade.NewHookFunc("record", func(_ context.Context, stage upgrade.Stage, root string) error {
+			stages = append(stages, fmt.Sprintf("%s:%s", stage, root))
+			return nil
+		})
+		opts := []upgrade.Option{upgrade.WithTransaction(t), upgrade.WithBootManager(firmware.NewEfiBootManager(s))}
+		for _, stage := range upgrade.Stages {
+			opts = append(opts, upgrade.WithHooks(stage, hook))
+		}
+		u = upgrade.New(context.Background(), s, opts...)
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(stages).To(Equal([]string{
+			"before-sync:/snapshot/path", "after-merge:/snapshot/path",
+			"before-commit:/snapshot/path", "after-commit:/snapshot/path",
+		}))
+	})
+	It("aborts the transaction if a hook fails", func() {
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+			upgrade.WithHooks(upgrade.StageAfterMerge, upgrade.NewHookFunc("health", func(context.Context, upgrade.Stage, string) error {
+				return fmt.Errorf("unhealthy")
+			})),
+		)
+		Expect(u.Upgrade(d)).To(MatchError("executing after-merge hook 'health': unhealthy"))
+		Expect(t.RollbackCalled()).To(BeTrue())
+		Expect(runner.IncludesCmds([][]string{{"/etc/elemental/config.sh"}})).NotTo(Succeed())
+	})
+	It("does not fail the committed upgrade if an after-commit hook fails", func() {
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+			upgrade.WithHooks(upgrade.StageAfterCommit, upgrade.NewHookFunc("notify", func(context.Context, upgrade.Stage, string) error {
+				return fmt.Errorf("unreachable")
+			})),
+		)
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(t.RollbackCalled()).To(BeFalse())
+	})
+	It("delays the transaction while a hook requests it", func() {
+		calls := 0
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+			upgrade.WithHooks(upgrade.StageBeforeSync, upgrade.NewHookFunc("wait", func(context.Context, upgrade.Stage, string) error {
+				calls++
+				if calls < 3 {
+					return &upgrade.DelayError{Delay: time.Millisecond, Reason: "busy"}
+				}
+				return nil
+			})),
+		)
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(calls).To(Equal(3))
+	})
+	It("aborts the transaction if a hook keeps delaying it", func() {
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+			upgrade.WithHooks(upgrade.StageBeforeSync, upgrade.NewHookFunc("wait", func(context.Context, upgrade.Stage, string) error {
+				return &upgrade.DelayError{Delay: time.Microsecond, Reason: "busy"}
+			})),
+		)
+		Expect(u.Upgrade(d)).To(MatchError(ContainSubstring("executing before-sync hook 'wait': delayed more than")))
+		Expect(t.RollbackCalled()).To(BeTrue())
+	})
+	It("applies the overlay tree and configuration script without syncing the OS image", func() {
+		t.UpgradeHelper.SyncError = fmt.Errorf("failed sync")
+		d.SourceOS = deployment.NewOCISrc("registry.org/my/os:latest")
+		d.SourceOS.VerifySignature = &deployment.SignatureVerification{Key: "/etc/elemental/cosign.pub"}
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithImageSync(false),
+		)
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"cosign"}})).NotTo(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"/etc/elemental/config.sh"}})).To(Succeed())
+	})
+	It("generates the checksums manifest once the configuration script ran", func() {
+		d.Snapshotter = &deployment.SnapshotterConfig{Checksums: true}
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "/etc/elemental/config.sh" {
+				return nil, fs.WriteFile("/snapshot/path/configured", []byte("yes"), vfs.FilePerm)
+			}
+			return []byte{}, nil
+		}
+		Expect(u.Upgrade(d)).To(Succeed())
+		manifest, err := fs.ReadFile(filepath.Join("/snapshot/path", integrity.ManifestFile))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(manifest)).To(ContainSubstring("  /configured"))
 	})
 	It("fails on transaction commit", func() {
 		t.CommitErr = fmt.Errorf("commit failed")
@@ -204,4 +395,180 @@ var _ = Describe("Upgrade", Label("upgrade"), func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(efiBootMgrCalled).To(BeTrue())
 	})
+	It("re-predicts the TPM2 measurements of the new kernel before committing", func() {
+		b := &entryBootloader{None: *bootloader.NewNone(s)}
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootloader(b),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+		)
+		d.Firmware = &deployment.FirmwareConfig{TPM: &firmware.TPMConfig{PCRLock: true}}
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{firmware.PCRLockCmd, "lock-pe", "--pcrlock=/snapshot/path/var/lib/pcrlock.d/650-elemental-kernel.pcrlock.d/2.pcrlock"},
+			{firmware.PCRLockCmd, "make-policy"},
+		})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"systemd-cryptenroll"}})).NotTo(Succeed())
+	})
+	It("seals the LUKS volumes on installation", func() {
+		b := &entryBootloader{None: *bootloader.NewNone(s)}
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootloader(b),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithVolumeSealing(true),
+		)
+		d.Firmware = &deployment.FirmwareConfig{TPM: &firmware.TPMConfig{}}
+		d.Disks[0].Device = "/dev/vda"
+		d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+			Role: deployment.Generic, MountPoint: "/data", FileSystem: deployment.Ext4, UUID: "data-uuid", Encrypted: true,
+		})
+		d.Security.LUKSKeyFile = "/etc/luks.key"
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{firmware.PCRLockCmd, "make-policy"}})).NotTo(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{firmware.PCRLockCmd, "lock-gpt", "--pcrlock=/snapshot/path/var/lib/pcrlock.d/600-gpt.pcrlock", "/dev/vda"},
+			{
+				"systemd-cryptenroll", "--unlock-key-file=/etc/luks.key", "--wipe-slot=tpm2", "--tpm2-device=auto",
+				"--tpm2-pcrs=7", "/dev/disk/by-partuuid/data-uuid",
+			},
+		})).To(Succeed())
+
+		data, err := fs.ReadFile("/snapshot/path/etc/crypttab")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("luks-data-uuid PARTUUID=data-uuid none luks,tpm2-device=auto\n"))
+	})
+	It("regenerates the initrd and binds the LUKS volumes for network unlock on installation", func() {
+		Expect(vfs.MkdirAll(fs, "/snapshot/path/usr/lib/modules/6.4.0-1-default", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/snapshot/path/usr/lib/modules/6.4.0-1-default/vmlinuz", []byte{}, vfs.FilePerm)).To(Succeed())
+		for _, path := range []string{"/dev", "/dev/pts", "/proc", "/sys"} {
+			Expect(vfs.MkdirAll(fs, path, vfs.DirPerm)).To(Succeed())
+		}
+
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootloader(bootloader.NewNone(s)),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithVolumeSealing(true),
+		)
+		d.Security.NetworkUnlock = &clevis.Config{
+			Servers: []clevis.TangServer{{URL: "http://tang.example.com", Thumbprint: "abc"}},
+		}
+		d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+			Role: deployment.Generic, MountPoint: "/data", FileSystem: deployment.Ext4, UUID: "data-uuid", Encrypted: true,
+		})
+		d.Security.LUKSKeyFile = "/etc/luks.key"
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"dracut", "--force", "--kver", "6.4.0-1-default", "/usr/lib/modules/6.4.0-1-default/initrd"},
+			{"clevis", "luks", "bind", "-k", "/etc/luks.key", "-d", "/dev/disk/by-partuuid/data-uuid", "tang"},
+		})).To(Succeed())
+	})
+	It("configures the compression of the regenerated initrd", func() {
+		Expect(vfs.MkdirAll(fs, "/snapshot/path/usr/lib/modules/6.4.0-1-default", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/snapshot/path/usr/lib/modules/6.4.0-1-default/vmlinuz", []byte{}, vfs.FilePerm)).To(Succeed())
+		d.Compression = &deployment.CompressionConfig{
+			Initrd: &deployment.Compression{Algorithm: deployment.Zstd, Level: 19},
+		}
+		Expect(u.Upgrade(d)).To(Succeed())
+		data, err := fs.ReadFile("/snapshot/path/etc/dracut.conf.d/40-elemental-compression.conf")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("compress=\"zstd -19 -q -T0\"\n"))
+		Expect(runner.IncludesCmds([][]string{
+			{"dracut", "--force", "--kver", "6.4.0-1-default", "/usr/lib/modules/6.4.0-1-default/initrd"},
+		})).To(Succeed())
+
+		d.Compression = nil
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(vfs.Exists(fs, "/snapshot/path/etc/dracut.conf.d/40-elemental-compression.conf")).To(BeFalse())
+	})
+	It("regenerates the initrd with the dracut configuration of the snapshotter", func() {
+		Expect(vfs.MkdirAll(fs, "/snapshot/path/usr/lib/modules/6.4.0-1-default", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/snapshot/path/usr/lib/modules/6.4.0-1-default/vmlinuz", []byte{}, vfs.FilePerm)).To(Succeed())
+		t.InitrdHelper = true
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"dracut", "--force", "--kver", "6.4.0-1-default", "/usr/lib/modules/6.4.0-1-default/initrd"},
+		})).To(Succeed())
+
+		runner.ClearCmds()
+		t.UpgradeHelper.InitrdError = fmt.Errorf("no space left")
+		Expect(u.Upgrade(d)).To(MatchError(ContainSubstring("configuring initrd: no space left")))
+		Expect(runner.IncludesCmds([][]string{{"dracut"}})).NotTo(Succeed())
+	})
+	It("ships the system disk layout as systemd-repart definitions", func() {
+		d.GetSystemDisk().RepartDefinitions = true
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(vfs.Exists(fs, "/snapshot/path/etc/repart.d/10-elemental-efi.conf")).To(BeTrue())
+		Expect(vfs.Exists(fs, "/snapshot/path/etc/repart.d/20-elemental-system.conf")).To(BeTrue())
+	})
+	It("fails to predict the TPM2 measurements if the new boot entry is unknown", func() {
+		b := &entryBootloader{None: *bootloader.NewNone(s), entryErr: fmt.Errorf("boot entry '2' not found")}
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootloader(b),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+		)
+		d.Firmware = &deployment.FirmwareConfig{TPM: &firmware.TPMConfig{PCRLock: true}}
+		Expect(u.Upgrade(d)).To(MatchError("updating TPM2 measured boot: boot entry '2' not found"))
+		Expect(t.RollbackCalled()).To(BeTrue())
+	})
+	It("flags the new snapshot as booted on trial", func() {
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(trans.Trial).To(BeFalse())
+
+		d.BootConfig.BootTries = 3
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(trans.Trial).To(BeTrue())
+	})
+	It("loads the new snapshot kernel for kexec", func() {
+		b := &entryBootloader{None: *bootloader.NewNone(s)}
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootloader(b),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithKexec(true),
+		)
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{
+			"kexec", "--load", "/snapshot/path/boot/os/2/vmlinuz",
+			"--initrd=/snapshot/path/boot/os/2/initrd", "--command-line=root=LABEL=SYSTEM",
+		}})).To(Succeed())
+	})
+	It("unloads the kexec kernel on transaction commit failure", func() {
+		b := &entryBootloader{None: *bootloader.NewNone(s)}
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootloader(b),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithKexec(true),
+		)
+		t.CommitErr = fmt.Errorf("commit failed")
+		Expect(u.Upgrade(d)).To(MatchError("committing transaction: commit failed"))
+		Expect(runner.MatchMilestones([][]string{{"kexec", "--load"}, {"kexec", "--unload"}})).To(Succeed())
+	})
+	It("fails to load kexec kernel if the boot entry is unknown", func() {
+		b := &entryBootloader{None: *bootloader.NewNone(s), entryErr: fmt.Errorf("boot entry '2' not found")}
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootloader(b),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithKexec(true),
+		)
+		Expect(u.Upgrade(d)).To(MatchError("loading kexec kernel: boot entry '2' not found"))
+		Expect(t.RollbackCalled()).To(BeTrue())
+	})
+	It("arms the watchdog while switching to the new snapshot", func() {
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+			upgrade.WithWatchdog("/dev/watchdog", time.Minute),
+		)
+		Expect(u.Upgrade(d)).To(Succeed())
+		data, err := fs.ReadFile("/dev/watchdog")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal([]byte{0, 0, 'V'}))
+		Expect(vfs.Exists(fs, "/snapshot/path"+watchdog.ConfigFile)).To(BeTrue())
+		Expect(vfs.Exists(fs, "/snapshot/path/etc/systemd/system/"+bootloader.BootCompleteUnit+".d/90-elemental-watchdog.conf")).To(BeTrue())
+	})
+	It("disarms the watchdog on transaction commit failure", func() {
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+			upgrade.WithWatchdog("/dev/watchdog", time.Minute),
+		)
+		t.CommitErr = fmt.Errorf("commit failed")
+		Expect(u.Upgrade(d)).To(MatchError("committing transaction: commit failed"))
+		data, err := fs.ReadFile("/dev/watchdog")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(HaveSuffix("V"))
+	})
 })
@@ -0,0 +1,241 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// ValidatorsDir is the subdirectory of the hooks directory including the validation scripts
+const ValidatorsDir = "validate.d"
+
+// Validator checks the new snapshot once it is locked and its boot entry is installed, before the
+// transaction is committed. Returning an error aborts and rolls back the transaction.
+type Validator interface {
+	Name() string
+	Validate(ctx context.Context, target ValidationTarget) error
+}
+
+// ValidationTarget is the transaction content to validate
+type ValidationTarget struct {
+	// Root is the root tree of the new snapshot
+	Root string
+	// ESPDir is the directory the EFI partition is mounted at within the root tree
+	ESPDir string
+	// EntryID is the identifier of the boot entry of the new snapshot
+	EntryID string
+	// Bootloader is the bootloader the boot entry is installed with
+	Bootloader bootloader.Bootloader
+}
+
+// DefaultValidators returns the validators checking every transaction: the kernel and initrd of the new boot
+// entry exist in the ESP and the fstab of the new snapshot parses
+func DefaultValidators(s *sys.System) []Validator {
+	return []Validator{bootFilesValidator{s: s}, fstabValidator{s: s}}
+}
+
+type validatorFunc struct {
+	name string
+	fn   func(ctx context.Context, target ValidationTarget) error
+}
+
+// NewValidatorFunc returns a validator executing the given function
+func NewValidatorFunc(name string, fn func(ctx context.Context, target ValidationTarget) error) Validator {
+	return validatorFunc{name: name, fn: fn}
+}
+
+func (v validatorFunc) Name() string {
+	return v.name
+}
+
+func (v validatorFunc) Validate(ctx context.Context, target ValidationTarget) error {
+	return v.fn(ctx, target)
+}
+
+type bootFilesValidator struct {
+	s *sys.System
+}
+
+func (v bootFilesValidator) Name() string {
+	return "boot files"
+}
+
+func (v bootFilesValidator) Validate(_ context.Context, target ValidationTarget) error {
+	entry, err := target.Bootloader.GetBootEntry(target.ESPDir, target.EntryID)
+	if errors.Is(err, errors.ErrUnsupported) {
+		v.s.Logger().Debug("Skipping boot files validation, boot entries are not supported by the bootloader")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, file := range []string{entry.Kernel, entry.Initrd} {
+		if ok, _ := vfs.Exists(v.s.FS(), file); !ok {
+			return fmt.Errorf("'%s' of boot entry '%s' not found", file, target.EntryID)
+		}
+	}
+	return nil
+}
+
+type fstabValidator struct {
+	s *sys.System
+}
+
+func (v fstabValidator) Name() string {
+	return "fstab"
+}
+
+func (v fstabValidator) Validate(_ context.Context, target ValidationTarget) error {
+	_, err := fstab.Read(v.s, filepath.Join(target.Root, fstab.File))
+	return err
+}
+
+// OSReleaseValidator checks the OS version of the new snapshot is not older than the version of the
+// given current root, preventing accidental downgrades. Versions which are not made of dot separated
+// numbers are not compared.
+type OSReleaseValidator struct {
+	s           *sys.System
+	currentRoot string
+}
+
+func NewOSReleaseValidator(s *sys.System, currentRoot string) *OSReleaseValidator {
+	return &OSReleaseValidator{s: s, currentRoot: currentRoot}
+}
+
+func (v *OSReleaseValidator) Name() string {
+	return "os-release version"
+}
+
+func (v *OSReleaseValidator) Validate(_ context.Context, target ValidationTarget) error {
+	current, err := v.versionID(v.currentRoot)
+	if err != nil {
+		return err
+	}
+	next, err := v.versionID(target.Root)
+	if err != nil {
+		return err
+	}
+
+	cmp, ok := compareVersions(next, current)
+	if !ok {
+		v.s.Logger().Warn("Skipping os-release version validation, can't compare '%s' to '%s'", next, current)
+		return nil
+	}
+	if cmp < 0 {
+		return fmt.Errorf("new OS version '%s' is older than the current version '%s'", next, current)
+	}
+	return nil
+}
+
+func (v *OSReleaseValidator) versionID(root string) (string, error) {
+	osVars, err := vfs.LoadEnvFile(v.s.FS(), filepath.Join(root, bootloader.OsReleasePath))
+	if err != nil {
+		return "", fmt.Errorf("loading %s vars: %w", bootloader.OsReleasePath, err)
+	}
+	return osVars["VERSION_ID"], nil
+}
+
+// compareVersions compares two dot separated numeric versions, it returns false if any of them is not numeric
+func compareVersions(a, b string) (int, bool) {
+	if a == "" || b == "" {
+		return 0, false
+	}
+	aFields, bFields := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(aFields), len(bFields)) {
+		var aNum, bNum int
+		var err error
+		if i < len(aFields) {
+			if aNum, err = strconv.Atoi(aFields[i]); err != nil {
+				return 0, false
+			}
+		}
+		if i < len(bFields) {
+			if bNum, err = strconv.Atoi(bFields[i]); err != nil {
+				return 0, false
+			}
+		}
+		if aNum != bNum {
+			if aNum < bNum {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+// ScriptValidator executes a script on the host. The root of the new snapshot is passed over the
+// ELEMENTAL_TRANSACTION_ROOT environment variable, a non zero exit code fails the validation.
+type ScriptValidator struct {
+	s    *sys.System
+	path string
+}
+
+func NewScriptValidator(s *sys.System, path string) *ScriptValidator {
+	return &ScriptValidator{s: s, path: path}
+}
+
+func (v *ScriptValidator) Name() string {
+	return filepath.Base(v.path)
+}
+
+func (v *ScriptValidator) Validate(_ context.Context, target ValidationTarget) error {
+	env := []string{fmt.Sprintf("ELEMENTAL_TRANSACTION_ROOT=%s", target.Root)}
+	out, err := v.s.Runner().RunEnv(v.path, env)
+	v.s.Logger().Debug("Validator '%s' output:\n%s", v.path, out)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// LoadScriptValidators returns the executable files found in the ValidatorsDir subdirectory of the given
+// directory as script validators, sorted by name
+func LoadScriptValidators(s *sys.System, dir string) ([]Validator, error) {
+	scripts, err := listScripts(s, filepath.Join(dir, ValidatorsDir))
+	if err != nil {
+		return nil, err
+	}
+	validators := make([]Validator, 0, len(scripts))
+	for _, script := range scripts {
+		validators = append(validators, NewScriptValidator(s, script))
+	}
+	return validators, nil
+}
+
+// runValidators executes the given validators in order, the first failure aborts the transaction
+func (u Upgrader) runValidators(target ValidationTarget) error {
+	for _, v := range u.validators {
+		u.s.Logger().Info("Running validator '%s'", v.Name())
+		err := v.Validate(u.ctx, target)
+		if err != nil {
+			return fmt.Errorf("validator '%s' failed: %w", v.Name(), err)
+		}
+	}
+	return nil
+}
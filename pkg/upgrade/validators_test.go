@@ -0,0 +1,109 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+var _ = Describe("Validators", Label("validators"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var target upgrade.ValidationTarget
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/etc/os-release":               "ID=sl-micro\nVERSION_ID=6.1\n",
+			"/snapshot/etc/os-release":      "ID=sl-micro\nVERSION_ID=6.2\n",
+			"/snapshot/etc/fstab":           "# comment\nPARTUUID=abc /var btrfs defaults 0 0\n",
+			"/snapshot/boot/os/2/vmlinuz":   "",
+			"/snapshot/boot/os/2/initrd":    "",
+			"/validators/validate.d/10-app": "",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.Chmod("/validators/validate.d/10-app", 0o755)).To(Succeed())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		target = upgrade.ValidationTarget{
+			Root: "/snapshot", ESPDir: "/snapshot/boot", EntryID: "2",
+			Bootloader: &entryBootloader{None: *bootloader.NewNone(s)},
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("checks the boot files and the fstab of the new snapshot", func() {
+		for _, v := range upgrade.DefaultValidators(s) {
+			Expect(v.Validate(context.Background(), target)).To(Succeed())
+		}
+
+		Expect(fs.Remove("/snapshot/boot/os/2/initrd")).To(Succeed())
+		Expect(fs.WriteFile("/snapshot/etc/fstab", []byte("PARTUUID=abc /var\n"), vfs.FilePerm)).To(Succeed())
+		for _, v := range upgrade.DefaultValidators(s) {
+			Expect(v.Validate(context.Background(), target)).NotTo(Succeed())
+		}
+	})
+	It("skips the boot files check if the bootloader has no boot entries", func() {
+		target.Bootloader = bootloader.NewNone(s)
+		Expect(fs.Remove("/snapshot/boot/os/2/initrd")).To(Succeed())
+		Expect(upgrade.DefaultValidators(s)[0].Validate(context.Background(), target)).To(Succeed())
+	})
+	It("rejects older OS versions", func() {
+		v := upgrade.NewOSReleaseValidator(s, "/")
+		Expect(v.Validate(context.Background(), target)).To(Succeed())
+
+		Expect(fs.WriteFile("/snapshot/etc/os-release", []byte("VERSION_ID=6.0.9\n"), vfs.FilePerm)).To(Succeed())
+		Expect(v.Validate(context.Background(), target)).To(MatchError(ContainSubstring("'6.0.9' is older than the current version '6.1'")))
+
+		Expect(fs.WriteFile("/snapshot/etc/os-release", []byte("VERSION_ID=tumbleweed\n"), vfs.FilePerm)).To(Succeed())
+		Expect(v.Validate(context.Background(), target)).To(Succeed())
+	})
+	It("runs the validation scripts with the transaction root", func() {
+		validators, err := upgrade.LoadScriptValidators(s, "/validators")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(validators).To(HaveLen(1))
+		Expect(validators[0].Name()).To(Equal("10-app"))
+
+		Expect(validators[0].Validate(context.Background(), target)).To(Succeed())
+		Expect(runner.EnvsMatch([][]string{{
+			"/validators/validate.d/10-app", "ELEMENTAL_TRANSACTION_ROOT=/snapshot",
+		}})).To(Succeed())
+
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			return []byte("application config missing\n"), exitError{code: 1}
+		}
+		Expect(validators[0].Validate(context.Background(), target)).To(MatchError("exit status 1: application config missing"))
+	})
+})
@@ -0,0 +1,138 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchdog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	DefaultDevice  = "/dev/watchdog"
+	DefaultTimeout = 10 * time.Minute
+
+	// ConfigFile is the systemd manager drop-in, relative to the root tree, arming the watchdog on boot
+	ConfigFile = "/etc/systemd/system.conf.d/90-elemental-watchdog.conf"
+
+	unitDir     = "/etc/systemd/system"
+	unitDropIn  = "90-elemental-watchdog.conf"
+	busctlUnset = "/usr/bin/busctl set-property org.freedesktop.systemd1 /org/freedesktop/systemd1 " +
+		"org.freedesktop.systemd1.Manager RuntimeWatchdogUSec t 0"
+
+	magicClose = "V"
+)
+
+// Watchdog is an armed hardware watchdog device, the system is reset if it is not pinged within its timeout
+type Watchdog struct {
+	s      *sys.System
+	device string
+	f      *os.File
+}
+
+// Arm opens and arms the given watchdog device, setting the given timeout if the device supports it.
+// It returns a nil Watchdog without error if the device is already in use, as it is already armed and
+// pinged by its owner, typically systemd, which does not guard the caller against hangs.
+func Arm(s *sys.System, device string, timeout time.Duration) (*Watchdog, error) {
+	f, err := s.FS().OpenFile(device, os.O_WRONLY, 0)
+	if errors.Is(err, syscall.EBUSY) {
+		s.Logger().Warn("Watchdog '%s' is already armed by another process, hangs are not guarded against", device)
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("opening watchdog '%s': %w", device, err)
+	}
+	s.Logger().Info("Armed watchdog '%s'", device)
+
+	err = unix.IoctlSetPointerInt(int(f.Fd()), unix.WDIOC_SETTIMEOUT, int(timeout.Seconds()))
+	if err != nil {
+		s.Logger().Warn("Could not set watchdog timeout to %s, using device default: %v", timeout, err)
+	}
+
+	return &Watchdog{s: s, device: device, f: f}, nil
+}
+
+// Ping resets the watchdog timer
+func (w *Watchdog) Ping() error {
+	if w == nil {
+		return nil
+	}
+
+	_, err := w.f.Write([]byte{0})
+	if err != nil {
+		return fmt.Errorf("pinging watchdog '%s': %w", w.device, err)
+	}
+	return nil
+}
+
+// Disarm stops the watchdog and closes the device
+func (w *Watchdog) Disarm() error {
+	if w == nil {
+		return nil
+	}
+
+	_, err := w.f.Write([]byte(magicClose))
+	if cErr := w.f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return fmt.Errorf("disarming watchdog '%s': %w", w.device, err)
+	}
+	w.s.Logger().Info("Disarmed watchdog '%s'", w.device)
+	return nil
+}
+
+// EnableOnBoot configures systemd in the given root tree to arm and ping the hardware watchdog with
+// the given timeout from early boot, so a hang of the systemd manager while booting resets the system.
+// The watchdog is pinged by systemd itself, hence failing units or an emergency shell don't reset the
+// system. The configuration is scoped to the boot until the given unit, which completes the boot, runs:
+// the unit removes it and stops the watchdog, so later boots and upgrades don't keep it armed.
+func EnableOnBoot(s *sys.System, root string, timeout time.Duration, completeUnit string) error {
+	cfgFile := filepath.Join(root, ConfigFile)
+	err := vfs.MkdirAll(s.FS(), filepath.Dir(cfgFile), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating systemd config directory: %w", err)
+	}
+
+	secs := int(timeout.Seconds())
+	cfg := fmt.Sprintf("[Manager]\nRuntimeWatchdogSec=%d\nRebootWatchdogSec=%d\n", secs, secs)
+	err = vfs.WriteFileAtomic(s.FS(), cfgFile, []byte(cfg), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing watchdog config '%s': %w", cfgFile, err)
+	}
+
+	dropIn := filepath.Join(unitDir, completeUnit+".d", unitDropIn)
+	err = vfs.MkdirAll(s.FS(), filepath.Join(root, filepath.Dir(dropIn)), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating systemd unit drop-in directory: %w", err)
+	}
+
+	unit := fmt.Sprintf("[Service]\nExecStart=/usr/bin/rm -f %s %s\nExecStart=-%s\n", ConfigFile, dropIn, busctlUnset)
+	err = vfs.WriteFileAtomic(s.FS(), filepath.Join(root, dropIn), []byte(unit), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing watchdog drop-in of unit '%s': %w", completeUnit, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchdog_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/watchdog"
+)
+
+func TestWatchdogSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Watchdog test suite")
+}
+
+var _ = Describe("Watchdog", Label("watchdog"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/dev/watchdog": "",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("arms, pings and disarms the watchdog device", func() {
+		wd, err := watchdog.Arm(s, "/dev/watchdog", time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wd.Ping()).To(Succeed())
+		Expect(wd.Disarm()).To(Succeed())
+
+		data, err := tfs.ReadFile("/dev/watchdog")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal([]byte{0, 'V'}))
+	})
+	It("fails to arm a missing watchdog device", func() {
+		_, err := watchdog.Arm(s, "/dev/watchdog1", time.Minute)
+		Expect(err).To(MatchError(ContainSubstring("opening watchdog '/dev/watchdog1'")))
+	})
+	It("does nothing on a nil watchdog", func() {
+		var wd *watchdog.Watchdog
+		Expect(wd.Ping()).To(Succeed())
+		Expect(wd.Disarm()).To(Succeed())
+	})
+	It("configures systemd to arm the watchdog on boot until the boot is completed", func() {
+		Expect(watchdog.EnableOnBoot(s, "/root", 90*time.Second, "boot-complete.service")).To(Succeed())
+		data, err := tfs.ReadFile("/root" + watchdog.ConfigFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("[Manager]\nRuntimeWatchdogSec=90\nRebootWatchdogSec=90\n"))
+
+		data, err = tfs.ReadFile("/root/etc/systemd/system/boot-complete.service.d/90-elemental-watchdog.conf")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(HavePrefix(
+			"[Service]\nExecStart=/usr/bin/rm -f " + watchdog.ConfigFile +
+				" /etc/systemd/system/boot-complete.service.d/90-elemental-watchdog.conf\n",
+		))
+		Expect(string(data)).To(ContainSubstring("RuntimeWatchdogUSec t 0\n"))
+	})
+})